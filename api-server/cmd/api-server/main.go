@@ -1,14 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/cache"
 	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/exporter"
 	"github.com/task-monitor/api-server/internal/handler"
+	"github.com/task-monitor/api-server/internal/logger"
+	"github.com/task-monitor/api-server/internal/metrics"
 	"github.com/task-monitor/api-server/internal/middleware"
 	"github.com/task-monitor/api-server/internal/repository"
 	"github.com/task-monitor/api-server/internal/service"
@@ -17,8 +27,17 @@ import (
 func main() {
 	// 支持命令行参数和环境变量指定配置文件路径
 	configPath := flag.String("config", "", "配置文件路径")
+	checkConfig := flag.Bool("check-config", false, "加载并校验配置后退出（0 表示配置有效）")
+	encryptSecret := flag.Bool("encrypt-secret", false, "从标准输入读取明文，输出 enc: 密文后退出（密钥来自 "+config.SecretKeyEnv+"/"+config.SecretKeyFileEnv+"）")
 	flag.Parse()
 
+	if *encryptSecret {
+		if err := runEncryptSecret(); err != nil {
+			log.Fatalf("Failed to encrypt secret: %v", err)
+		}
+		return
+	}
+
 	// 优先级：命令行参数 > 环境变量 > 默认路径
 	if *configPath == "" {
 		*configPath = os.Getenv("API_SERVER_CONFIG")
@@ -32,16 +51,39 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config from %s: %v", *configPath, err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Config %s is invalid: %v", *configPath, err)
+	}
+	if *checkConfig {
+		fmt.Printf("Config %s is valid\n", *configPath)
+		return
+	}
+
+	// 初始化日志
+	_, logCloser := logger.Setup(cfg.Log)
+	defer logCloser.Close()
+	if overrides := cfg.EnvOverrides(); len(overrides) > 0 {
+		slog.Info("config overridden by environment", "vars", overrides)
+	}
 
 	// 初始化数据库
-	db, err := config.InitDB(&cfg.Database)
+	db, err := config.InitDB(&cfg.Database, logger.NewGormLogger())
 	if err != nil {
-		log.Fatalf("Failed to init database: %v", err)
+		fatal("failed to init database", err)
 	}
 
+	// 注册 SQL 耗时与连接池指标
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		fatal("failed to register gorm metrics", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDBStats(sqlDB, cfg.Database.Database)
+	}
+	metrics.RegisterBatchStats(handler.BatchAnalyzeStats)
+
 	// 自动建表并创建默认用户
 	if err := config.AutoMigrateAndSeed(db); err != nil {
-		log.Fatalf("Failed to migrate and seed: %v", err)
+		fatal("failed to migrate and seed", err)
 	}
 
 	// 初始化Repository
@@ -49,32 +91,141 @@ func main() {
 	jobRepo := repository.NewJobRepository(db)
 	paramRepo := repository.NewParameterRepository(db)
 	codeRepo := repository.NewCodeRepository(db)
-	metricsRepo := repository.NewMetricsRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	savedViewRepo := repository.NewSavedViewRepository(db)
+
+	// 查询缓存：redis.enabled 时使用 Redis，否则（或连接失败时）使用进程内缓存
+	queryCache := cache.New(cfg.Redis)
+	defer queryCache.Close()
+	metricsRepo := repository.NewCachedMetricsRepository(repository.NewMetricsRepository(db), queryCache,
+		time.Duration(cfg.Cache.MetricsTTLSeconds)*time.Second)
 
 	// 初始化Service
 	nodeService := service.NewNodeService(nodeRepo)
-	jobService := service.NewJobService(jobRepo, paramRepo, codeRepo, metricsRepo)
+	// 作业分组持久化在 job_groups 表，后台增量同步；分组变化后失效作业查询缓存
+	baseJobService := service.NewJobService(jobRepo, paramRepo, codeRepo, metricsRepo)
+	baseJobService.SetJobGroupRepository(repository.NewJobGroupRepository(db))
+	jobAnalysisRepo := repository.NewJobAnalysisRepository(db)
+	baseJobService.SetJobAnalysisRepository(jobAnalysisRepo)
+	projectRepo := repository.NewProjectRepository(db)
+	baseJobService.SetProjectRepository(projectRepo)
+	jobService := service.NewCachedJobService(baseJobService, queryCache,
+		time.Duration(cfg.Cache.JobsTTLSeconds)*time.Second)
+	baseJobService.SetGroupsChangedHook(jobService.InvalidateJobs)
+	baseJobService.StartJobGroupSync(context.Background(), time.Duration(cfg.JobGroups.SyncIntervalSeconds)*time.Second)
+	// 作业归属项目由后台按归属规则增量计算；归属变化后失效作业查询缓存
+	projectService := service.NewProjectService(projectRepo, jobRepo, paramRepo, userRepo, cfg.Projects)
+	projectService.SetChangedHook(jobService.InvalidateJobs)
+	projectService.StartProjectSync(context.Background(), time.Duration(cfg.Projects.SyncIntervalSeconds)*time.Second)
 	authService := service.NewAuthService(userRepo, cfg.JWT.Secret, cfg.JWT.ExpireMinutes)
+	npuService := service.NewNPUService(metricsRepo)
+	statsService := service.NewStatsService(nodeRepo, metricsRepo, jobService,
+		time.Duration(cfg.Metrics.NPUStaleMinutes)*time.Minute)
+	nodeCardService := service.NewNodeCardService(nodeRepo, metricsRepo, jobService,
+		time.Duration(cfg.Metrics.NPUStaleMinutes)*time.Minute)
+	nodeCardService.SetStatusHistoryRepository(repository.NewJobStatusHistoryRepository(db))
+	insightsService := service.NewInsightsService(jobService, metricsRepo, cfg.Insights)
+	accountingService := service.NewAccountingService(jobService, paramRepo, cfg.Accounting)
+	accountingService.SetProjectLookup(projectService)
 
 	// 初始化LLM Service（始终创建，可通过页面启用/禁用）
-	jobAnalysisRepo := repository.NewJobAnalysisRepository(db)
 	llmService := service.NewLLMService(jobService, jobAnalysisRepo, cfg.LLM)
+	// 为历史分析结果补齐可筛选字段
+	go func() {
+		if n, err := llmService.BackfillAnalysisFields(context.Background()); err != nil {
+			slog.Error("failed to backfill analysis fields", "processed", n, "error", err)
+		} else if n > 0 {
+			slog.Info("analysis fields backfilled", "processed", n)
+		}
+	}()
 	if cfg.LLM.Enabled {
-		log.Println("LLM service enabled")
+		slog.Info("LLM service enabled", "default_model_id", cfg.LLM.DefaultModelID)
 	}
+	searchService := service.NewSearchService(jobRepo, nodeRepo, jobAnalysisRepo)
+	savedViewService := service.NewSavedViewService(savedViewRepo)
+	// 定时报表：计划来自配置文件与 report_schedules 表
+	reportService := service.NewReportService(jobService, jobAnalysisRepo, repository.NewReportRepository(db), cfg.Reports)
+	reportService.Start(context.Background())
 
 	// 初始化Handler
 	nodeHandler := handler.NewNodeHandler(nodeService)
+	nodeHandler.SetNodeCardService(nodeCardService)
+	nodeHandler.SetProjectService(projectService)
 	jobHandler := handler.NewJobHandler(jobService, llmService, cfg.LLM.BatchConcurrency)
+	jobHandler.SetSavedViewService(savedViewService)
+	jobHandler.SetExportConfig(cfg.Export)
+	jobHandler.SetProjectService(projectService)
 	configHandler := handler.NewConfigHandler(llmService, cfg, *configPath)
 	authHandler := handler.NewAuthHandler(authService)
+	cacheHandler := handler.NewCacheHandler(queryCache)
+	distributedJobHandler := handler.NewDistributedJobHandler(jobService)
+	distributedJobHandler.SetProjectService(projectService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	searchHandler.SetProjectService(projectService)
+	savedViewHandler := handler.NewSavedViewHandler(savedViewService)
+	reportHandler := handler.NewReportHandler(reportService)
+	reportHandler.SetProjectService(projectService)
+	statsHandler := handler.NewStatsHandler(statsService)
+	statsHandler.SetProjectService(projectService)
+	insightsHandler := handler.NewInsightsHandler(insightsService)
+	insightsHandler.SetProjectService(projectService)
+	accountingHandler := handler.NewAccountingHandler(accountingService)
+	accountingHandler.SetProjectService(projectService)
+	projectHandler := handler.NewProjectHandler(projectService)
+	clusterCollector := exporter.NewClusterCollector(npuService, jobService, cfg.Metrics)
+
+	// 配置热加载：SIGHUP 或配置文件变更时重新加载，可热更新的字段即时生效，其余字段提示需要重启
+	reloader := config.NewReloader(*configPath, cfg)
+	reloader.Register([]string{"llm"}, func(c *config.Config) {
+		llmService.UpdateConfig(c.LLM)
+		jobHandler.SetBatchConcurrency(c.LLM.BatchConcurrency)
+	})
+	reloader.Register([]string{"database.max_open_conns", "database.max_idle_conns"}, func(c *config.Config) {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.SetMaxOpenConns(c.Database.MaxOpenConns)
+			sqlDB.SetMaxIdleConns(c.Database.MaxIdleConns)
+		}
+	})
+	reloader.Register([]string{"log.level", "log.slow_query_ms"}, func(c *config.Config) {
+		logger.ApplyConfig(c.Log)
+	})
+	reloader.Register([]string{"jwt.expire_minutes"}, func(c *config.Config) {
+		authService.SetExpireMinutes(c.JWT.ExpireMinutes)
+	})
+	reloader.Register([]string{"metrics"}, func(c *config.Config) {
+		clusterCollector.ApplyConfig(c.Metrics)
+		statsService.SetStaleAfter(time.Duration(c.Metrics.NPUStaleMinutes) * time.Minute)
+		nodeCardService.SetStaleAfter(time.Duration(c.Metrics.NPUStaleMinutes) * time.Minute)
+	})
+	reloader.Register([]string{"insights"}, func(c *config.Config) {
+		insightsService.SetConfig(c.Insights)
+	})
+	reloader.Register([]string{"accounting"}, func(c *config.Config) {
+		accountingService.SetConfig(c.Accounting)
+	})
+	reloader.Register([]string{"projects.restrict_visibility", "projects.admins"}, func(c *config.Config) {
+		projectService.SetConfig(c.Projects)
+	})
+	reloader.Register([]string{"reports.dir", "reports.schedules", "reports.webhook_hosts"}, func(c *config.Config) {
+		reportService.UpdateConfig(c.Reports)
+	})
+	reloader.Register(nil, configHandler.SetConfig)
+	configHandler.SetReloader(reloader)
+
+	reloader.WatchSignal(context.Background())
+	if cfg.Server.ReloadInterval > 0 {
+		reloader.WatchFile(context.Background(), time.Duration(cfg.Server.ReloadInterval)*time.Second)
+	}
 
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
 
 	// 创建路由
-	r := gin.Default()
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(), middleware.Metrics(), gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered", "panic", recovered, "route", c.FullPath())
+		c.AbortWithStatus(500)
+	}))
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
@@ -83,6 +234,16 @@ func main() {
 		})
 	})
 
+	// 携带令牌时识别当前用户，用于解析个人保存视图（viewId）与项目可见范围
+	optionalAuth := middleware.OptionalJWTAuth(authService)
+
+	// Prometheus 指标
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	// 集群 NPU 状态与作业统计，数据来自数据库，单独抓取；包含全部项目，可见范围受限时需携带管理员令牌
+	r.GET("/metrics/cluster", optionalAuth,
+		handler.FullScopeOnly(projectService, "cluster metrics cover all projects and are only available to administrators"),
+		gin.WrapH(clusterCollector.Handler()))
+
 	// API路由组
 	api := r.Group("/api/v1")
 	{
@@ -92,20 +253,44 @@ func main() {
 		// 节点（只读）
 		api.GET("/nodes", nodeHandler.GetNodes)
 		api.GET("/nodes/stats", nodeHandler.GetNodeStats)
+		api.GET("/nodes/free-cards", optionalAuth, nodeHandler.FindFreeCards)
 		api.GET("/nodes/:nodeId", nodeHandler.GetNodeByID)
+		api.GET("/nodes/:nodeId/cards", optionalAuth, nodeHandler.GetNodeCards)
+		api.GET("/nodes/:nodeId/overview", optionalAuth, nodeHandler.GetNodeOverview)
 
 		// 作业（只读）
-		api.GET("/jobs", jobHandler.GetJobs)
-		api.GET("/jobs/grouped", jobHandler.GetGroupedJobs)
-		api.GET("/jobs/grouped/card-counts", jobHandler.GetDistinctCardCounts)
-		api.GET("/jobs/stats", jobHandler.GetJobStats)
+		api.GET("/jobs", optionalAuth, jobHandler.GetJobs)
+		api.GET("/jobs/grouped", optionalAuth, jobHandler.GetGroupedJobs)
+		api.GET("/jobs/grouped/card-counts", optionalAuth, jobHandler.GetDistinctCardCounts)
+		api.GET("/jobs/stats", optionalAuth, jobHandler.GetJobStats)
+		api.GET("/jobs/distributed", optionalAuth, distributedJobHandler.GetDistributedJobs)
+		api.GET("/jobs/distributed/:distributedId", optionalAuth, distributedJobHandler.GetDistributedJobDetail)
 		api.GET("/jobs/batch-analyze/:batchId", jobHandler.GetBatchAnalyzeProgress)
-		api.GET("/jobs/analyses/batch", jobHandler.GetBatchAnalyses)
-		api.GET("/jobs/analyses/export", jobHandler.ExportAnalysesCSV)
-		api.GET("/jobs/:jobId", jobHandler.GetJobByID)
-		api.GET("/jobs/:jobId/parameters", jobHandler.GetJobParameters)
-		api.GET("/jobs/:jobId/code", jobHandler.GetJobCode)
-		api.GET("/jobs/:jobId/analysis", jobHandler.GetJobAnalysis)
+		api.GET("/jobs/analyses/batch", optionalAuth, jobHandler.GetBatchAnalyses)
+		api.GET("/jobs/analyses/export", optionalAuth, jobHandler.ExportAnalysesCSV)
+		api.GET("/jobs/analyses/export/xlsx", optionalAuth, jobHandler.ExportAnalysesXLSX)
+		api.POST("/export/jobs", optionalAuth, jobHandler.ExportJobs)
+		api.GET("/exports/:exportId", optionalAuth, jobHandler.GetExportTask)
+		api.GET("/exports/:exportId/download", optionalAuth, jobHandler.DownloadExport)
+		api.GET("/jobs/:jobId", optionalAuth, jobHandler.GetJobByID)
+		api.GET("/jobs/:jobId/parameters", optionalAuth, jobHandler.GetJobParameters)
+		api.GET("/jobs/:jobId/code", optionalAuth, jobHandler.GetJobCode)
+		api.GET("/jobs/:jobId/analysis", optionalAuth, jobHandler.GetJobAnalysis)
+
+		// 集群利用率统计
+		api.GET("/stats/cluster", optionalAuth, statsHandler.GetClusterStats)
+		api.GET("/stats/nodes", optionalAuth, statsHandler.GetNodeStats)
+		api.GET("/stats/trends", optionalAuth, statsHandler.GetTrends)
+
+		// 资源使用洞察
+		api.GET("/insights/idle-jobs", optionalAuth, insightsHandler.GetIdleJobs)
+
+		// 全局搜索
+		api.GET("/search", optionalAuth, searchHandler.Search)
+
+		// 保存视图（只读，匿名仅可见共享视图）
+		api.GET("/views", optionalAuth, savedViewHandler.ListViews)
+		api.GET("/views/:id", optionalAuth, savedViewHandler.GetView)
 
 		// 配置（只读）
 		api.GET("/config/llm", configHandler.GetLLMConfig)
@@ -126,15 +311,90 @@ func main() {
 		authed.POST("/jobs/batch-analyze", jobHandler.BatchAnalyze)
 		authed.POST("/jobs/batch-analyze/:batchId/cancel", jobHandler.CancelBatchAnalyze)
 		authed.POST("/jobs/:jobId/analyze", jobHandler.AnalyzeJob)
+		authed.POST("/exports/:exportId/cancel", jobHandler.CancelExportTask)
+
+		// 保存视图（写操作，仅所有者可修改/删除）
+		authed.POST("/views", savedViewHandler.CreateView)
+		authed.PUT("/views/:id", savedViewHandler.UpdateView)
+		authed.DELETE("/views/:id", savedViewHandler.DeleteView)
+
+		// 定时报表
+		authed.GET("/reports/schedules", reportHandler.ListSchedules)
+		authed.POST("/reports/schedules", reportHandler.CreateSchedule)
+		authed.PUT("/reports/schedules/:id", reportHandler.UpdateSchedule)
+		authed.DELETE("/reports/schedules/:id", reportHandler.DeleteSchedule)
+		authed.GET("/reports/runs", reportHandler.ListRuns)
+		authed.POST("/reports/runs", reportHandler.RunSchedule)
+		authed.GET("/reports/runs/:id", reportHandler.GetRun)
+		authed.GET("/reports/preview", reportHandler.PreviewReport)
+
+		// 卡时核算
+		authed.GET("/accounting/card-hours", accountingHandler.GetCardHours)
+		authed.GET("/accounting/card-hours/jobs", accountingHandler.GetCardHourRecords)
+
+		// 项目与作业归属
+		authed.GET("/projects", projectHandler.ListProjects)
+		authed.POST("/projects", projectHandler.CreateProject)
+		authed.GET("/projects/:id", projectHandler.GetProject)
+		authed.PUT("/projects/:id", projectHandler.UpdateProject)
+		authed.DELETE("/projects/:id", projectHandler.DeleteProject)
+		authed.GET("/projects/:id/members", projectHandler.ListMembers)
+		authed.POST("/projects/:id/members", projectHandler.AddMember)
+		authed.DELETE("/projects/:id/members/:userId", projectHandler.RemoveMember)
+		authed.GET("/projects/:id/rules", projectHandler.ListRules)
+		authed.POST("/projects/:id/rules", projectHandler.CreateRule)
+		authed.PUT("/projects/:id/rules/:ruleId", projectHandler.UpdateRule)
+		authed.DELETE("/projects/:id/rules/:ruleId", projectHandler.DeleteRule)
+		authed.GET("/jobs/:jobId/project", projectHandler.GetJobProject)
+		authed.PUT("/jobs/:jobId/project", projectHandler.SetJobProject)
+		authed.DELETE("/jobs/:jobId/project", projectHandler.ClearJobProject)
 
 		// 配置修改
 		authed.PUT("/config/llm", configHandler.UpdateLLMConfig)
+		authed.POST("/config/llm/models/:id/test", configHandler.TestLLMModel)
+		authed.POST("/config/reload", configHandler.ReloadConfig)
+
+		// 查询缓存（数据导入、回填后调用）
+		authed.POST("/cache/invalidate", cacheHandler.InvalidateCache)
 	}
 
 	// 启动服务器
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
-	log.Printf("API Server starting on %s", addr)
+	slog.Info("API server starting", "addr", addr, "mode", cfg.Server.Mode)
 	if err := r.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		fatal("failed to start server", err)
+	}
+}
+
+// fatal 记录错误日志后退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// runEncryptSecret 读取标准输入中的明文并输出可写入配置文件的密文
+func runEncryptSecret() error {
+	key, err := config.LoadSecretKey()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return config.ErrSecretKeyMissing
+	}
+
+	plain, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+	plain = strings.TrimRight(plain, "\r\n")
+	if plain == "" {
+		return fmt.Errorf("empty input")
+	}
+
+	encrypted, err := config.EncryptSecret(plain, key)
+	if err != nil {
+		return err
 	}
+	fmt.Println(encrypted)
+	return nil
 }
@@ -0,0 +1,116 @@
+// Package cache 提供查询结果缓存：配置了 Redis 时多实例共享，否则使用进程内缓存
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/metrics"
+)
+
+// KeyPrefix 所有缓存键的公共前缀，避免与同一 Redis 中的其他数据冲突
+const KeyPrefix = "task_monitor:cache:"
+
+// 缓存命名空间，失效时按命名空间整体删除
+const (
+	NamespaceJobs    = "jobs"
+	NamespaceMetrics = "metrics"
+)
+
+// Cache 查询缓存。值以 JSON 存储，读取时反序列化到 dest，调用方拿到的总是独立副本。
+type Cache interface {
+	// Get 读取缓存，未命中时返回 false
+	Get(ctx context.Context, key string, dest interface{}) (bool, error)
+	// Set 写入缓存，ttl 到期后自动失效
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// InvalidateNamespace 删除命名空间下的全部缓存
+	InvalidateNamespace(ctx context.Context, namespace string) error
+	// Backend 返回缓存后端名称（redis / memory）
+	Backend() string
+	Close() error
+}
+
+// New 根据 Redis 配置创建缓存；未启用 Redis 或连接失败时回退到进程内缓存
+func New(cfg config.RedisConfig) Cache {
+	if !cfg.Enabled {
+		return NewMemory()
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		slog.Warn("redis unavailable, falling back to in-memory cache",
+			"addr", client.Options().Addr, "error", err)
+		client.Close()
+		return NewMemory()
+	}
+	slog.Info("query cache using redis", "addr", client.Options().Addr, "db", cfg.DB)
+	return NewRedis(client)
+}
+
+// Key 拼接命名空间与键名
+func Key(namespace string, parts ...interface{}) string {
+	key := KeyPrefix + namespace
+	for _, p := range parts {
+		key += ":" + fmt.Sprint(p)
+	}
+	return key
+}
+
+// Remember 先读缓存，未命中时调用 load 并写回。
+// 缓存读写失败只记录日志，不影响查询结果；ttl <= 0 时直接调用 load。
+func Remember[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	if c == nil || ttl <= 0 {
+		return load()
+	}
+
+	var cached T
+	hit, err := c.Get(ctx, key, &cached)
+	if err != nil {
+		slog.DebugContext(ctx, "cache get failed", "key", key, "error", err)
+	}
+	if hit {
+		metrics.CacheRequests.WithLabelValues(namespaceOf(key), "hit").Inc()
+		return cached, nil
+	}
+	metrics.CacheRequests.WithLabelValues(namespaceOf(key), "miss").Inc()
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if err := c.Set(ctx, key, value, ttl); err != nil {
+		slog.DebugContext(ctx, "cache set failed", "key", key, "error", err)
+	}
+	return value, nil
+}
+
+// namespaceOf 从缓存键中取出命名空间，用作指标标签
+func namespaceOf(key string) string {
+	ns, _, _ := strings.Cut(strings.TrimPrefix(key, KeyPrefix), ":")
+	return ns
+}
+
+// ErrNotSerializable 值无法编码为 JSON
+var ErrNotSerializable = errors.New("cache: value is not serializable")
+
+func encode(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSerializable, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/task-monitor/api-server/internal/config"
+)
+
+type sample struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	c := NewRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	t.Cleanup(func() { c.Close() })
+	return c, mr
+}
+
+func backends(t *testing.T) map[string]Cache {
+	r, _ := newTestRedis(t)
+	return map[string]Cache{"memory": NewMemory(), "redis": r}
+}
+
+func TestCache_SetGetInvalidate(t *testing.T) {
+	ctx := context.Background()
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			jobKey := Key(NamespaceJobs, "stats")
+			metricKey := Key(NamespaceMetrics, "latest", "node-001", "0,1")
+			require.NoError(t, c.Set(ctx, jobKey, sample{Name: "a", Count: 1}, time.Minute))
+			require.NoError(t, c.Set(ctx, metricKey, sample{Name: "b", Count: 2}, time.Minute))
+
+			var got sample
+			hit, err := c.Get(ctx, jobKey, &got)
+			require.NoError(t, err)
+			assert.True(t, hit)
+			assert.Equal(t, sample{Name: "a", Count: 1}, got)
+
+			require.NoError(t, c.InvalidateNamespace(ctx, NamespaceJobs))
+			hit, err = c.Get(ctx, jobKey, &got)
+			require.NoError(t, err)
+			assert.False(t, hit)
+
+			// 其他命名空间不受影响
+			hit, err = c.Get(ctx, metricKey, &got)
+			require.NoError(t, err)
+			assert.True(t, hit)
+		})
+	}
+}
+
+func TestMemory_Expires(t *testing.T) {
+	m := NewMemory()
+	now := time.Unix(1770373800, 0)
+	m.now = func() time.Time { return now }
+
+	require.NoError(t, m.Set(context.Background(), "k", 1, 10*time.Second))
+	var v int
+	hit, _ := m.Get(context.Background(), "k", &v)
+	assert.True(t, hit)
+
+	now = now.Add(10 * time.Second)
+	hit, _ = m.Get(context.Background(), "k", &v)
+	assert.False(t, hit)
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	defer m.Close()
+	m.maxEntries = 2
+
+	require.NoError(t, m.Set(ctx, "a", 1, time.Minute))
+	require.NoError(t, m.Set(ctx, "b", 2, time.Minute))
+	// 读取 a 后 b 成为最久未使用的条目
+	var v int
+	hit, _ := m.Get(ctx, "a", &v)
+	require.True(t, hit)
+	require.NoError(t, m.Set(ctx, "c", 3, time.Minute))
+
+	assert.Len(t, m.entries, 2)
+	hit, _ = m.Get(ctx, "b", &v)
+	assert.False(t, hit)
+	hit, _ = m.Get(ctx, "a", &v)
+	assert.True(t, hit)
+	assert.Equal(t, 1, v)
+	hit, _ = m.Get(ctx, "c", &v)
+	assert.True(t, hit)
+
+	// 覆盖已有键不触发淘汰
+	require.NoError(t, m.Set(ctx, "a", 4, time.Minute))
+	assert.Len(t, m.entries, 2)
+	hit, _ = m.Get(ctx, "a", &v)
+	assert.True(t, hit)
+	assert.Equal(t, 4, v)
+}
+
+func TestMemory_SweepRemovesExpired(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	defer m.Close()
+	now := time.Unix(1770373800, 0)
+	m.now = func() time.Time { return now }
+
+	require.NoError(t, m.Set(ctx, "short", 1, 10*time.Second))
+	require.NoError(t, m.Set(ctx, "long", 2, time.Minute))
+
+	now = now.Add(10 * time.Second)
+	m.sweep()
+	assert.Len(t, m.entries, 1)
+	assert.Equal(t, 1, m.order.Len())
+	var v int
+	hit, _ := m.Get(ctx, "long", &v)
+	assert.True(t, hit)
+}
+
+func TestRedis_Expires(t *testing.T) {
+	r, mr := newTestRedis(t)
+	require.NoError(t, r.Set(context.Background(), "k", 1, 10*time.Second))
+
+	mr.FastForward(11 * time.Second)
+	var v int
+	hit, err := r.Get(context.Background(), "k", &v)
+	require.NoError(t, err)
+	assert.False(t, hit)
+}
+
+func TestRemember(t *testing.T) {
+	c := NewMemory()
+	ctx := context.Background()
+	key := Key(NamespaceJobs, "remember")
+	calls := 0
+	load := func() ([]int, error) {
+		calls++
+		return []int{1, 8}, nil
+	}
+
+	v, err := Remember(ctx, c, key, time.Minute, load)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 8}, v)
+	v, err = Remember(ctx, c, key, time.Minute, load)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 8}, v)
+	assert.Equal(t, 1, calls)
+
+	// ttl 为 0 时不使用缓存
+	_, err = Remember(ctx, c, key, 0, load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRemember_ErrorNotCached(t *testing.T) {
+	c := NewMemory()
+	ctx := context.Background()
+	key := Key(NamespaceJobs, "error")
+
+	_, err := Remember(ctx, c, key, time.Minute, func() (int, error) { return 0, errors.New("db down") })
+	assert.Error(t, err)
+
+	v, err := Remember(ctx, c, key, time.Minute, func() (int, error) { return 42, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 42, v)
+}
+
+func TestNew_FallsBackToMemory(t *testing.T) {
+	assert.Equal(t, "memory", New(config.RedisConfig{Enabled: false}).Backend())
+	// 不可达的 Redis 地址回退到进程内缓存
+	assert.Equal(t, "memory", New(config.RedisConfig{Enabled: true, Host: "127.0.0.1", Port: 1}).Backend())
+
+	mr := miniredis.RunT(t)
+	c := New(config.RedisConfig{Enabled: true, Host: mr.Host(), Port: mustPort(t, mr)})
+	defer c.Close()
+	assert.Equal(t, "redis", c.Backend())
+}
+
+func mustPort(t *testing.T, mr *miniredis.Miniredis) int {
+	port, err := strconv.Atoi(mr.Port())
+	require.NoError(t, err)
+	return port
+}
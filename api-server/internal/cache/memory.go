@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// memoryMaxEntries 进程内缓存的最大条目数，超出时淘汰最久未使用的条目
+	memoryMaxEntries = 10000
+	// memorySweepInterval 定期清理过期条目的间隔，避免长时间不读的键占用内存
+	memorySweepInterval = time.Minute
+)
+
+type memoryEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+// Memory 进程内缓存，未配置 Redis 或 Redis 不可用时使用；多实例部署时各实例独立失效。
+// 条目数超过上限时按 LRU 淘汰，后台定期清理过期条目。
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // 按最近访问排序，队首最新
+	maxEntries int
+	now        func() time.Time
+	stop       chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewMemory 创建进程内缓存并启动过期条目清理，Close 时停止
+func NewMemory() *Memory {
+	m := &Memory{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: memoryMaxEntries,
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+	go m.sweepLoop(memorySweepInterval)
+	return m
+}
+
+// Get 实现 Cache
+func (m *Memory) Get(_ context.Context, key string, dest interface{}) (bool, error) {
+	m.mu.Lock()
+	var data []byte
+	elem, ok := m.entries[key]
+	if ok {
+		entry := elem.Value.(*memoryEntry)
+		if m.now().Before(entry.expires) {
+			m.order.MoveToFront(elem)
+			data = entry.data
+		} else {
+			m.remove(elem)
+			ok = false
+		}
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set 实现 Cache
+func (m *Memory) Set(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expires := m.now().Add(ttl)
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.data, entry.expires = data, expires
+		m.order.MoveToFront(elem)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, data: data, expires: expires})
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+// InvalidateNamespace 实现 Cache
+func (m *Memory) InvalidateNamespace(_ context.Context, namespace string) error {
+	prefix := Key(namespace) + ":"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, elem := range m.entries {
+		if strings.HasPrefix(k, prefix) {
+			m.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Backend 实现 Cache
+func (m *Memory) Backend() string {
+	return "memory"
+}
+
+// Close 实现 Cache，停止过期条目清理
+func (m *Memory) Close() error {
+	m.closeOnce.Do(func() { close(m.stop) })
+	return nil
+}
+
+func (m *Memory) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.sweep()
+		}
+	}
+}
+
+// sweep 删除所有已过期的条目
+func (m *Memory) sweep() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for _, elem := range m.entries {
+		if !now.Before(elem.Value.(*memoryEntry).expires) {
+			m.remove(elem)
+		}
+	}
+}
+
+// remove 删除条目，调用方需持有锁
+func (m *Memory) remove(elem *list.Element) {
+	m.order.Remove(elem)
+	delete(m.entries, elem.Value.(*memoryEntry).key)
+}
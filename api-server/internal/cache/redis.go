@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanBatch 失效命名空间时每次 SCAN 的键数量
+const scanBatch = 500
+
+// Redis 基于 Redis 的缓存，多个 API Server 实例共享同一份缓存与失效
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis 使用已连接的 Redis 客户端创建缓存
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Get 实现 Cache
+func (r *Redis) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set 实现 Cache
+func (r *Redis) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, data, ttl).Err()
+}
+
+// InvalidateNamespace 实现 Cache；使用 SCAN 分批删除，不阻塞 Redis
+func (r *Redis) InvalidateNamespace(ctx context.Context, namespace string) error {
+	iter := r.client.Scan(ctx, 0, Key(namespace)+":*", scanBatch).Iterator()
+	keys := make([]string, 0, scanBatch)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == scanBatch {
+			if err := r.client.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return r.client.Unlink(ctx, keys...).Err()
+	}
+	return nil
+}
+
+// Backend 实现 Cache
+func (r *Redis) Backend() string {
+	return "redis"
+}
+
+// Close 实现 Cache
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
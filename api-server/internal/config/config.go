@@ -3,29 +3,50 @@ package config
 import (
 	"fmt"
 	"os"
+	"reflect"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config 配置结构
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
-	Log      LogConfig      `yaml:"log"`
-	LLM      LLMConfig      `yaml:"llm"`
-	JWT      JWTConfig      `yaml:"jwt"`
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Redis      RedisConfig      `yaml:"redis"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Log        LogConfig        `yaml:"log"`
+	LLM        LLMConfig        `yaml:"llm"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	JobGroups  JobGroupsConfig  `yaml:"job_groups"`
+	Export     ExportConfig     `yaml:"export"`
+	Reports    ReportsConfig    `yaml:"reports"`
+	Insights   InsightsConfig   `yaml:"insights"`
+	Accounting AccountingConfig `yaml:"accounting"`
+	Projects   ProjectsConfig   `yaml:"projects"`
+
+	// secretRefs 敏感字段的原始写法（enc:/${ENV}），SaveConfig 据此避免写回明文
+	secretRefs map[string]secretRef
+	// envOverrides 被 TASK_MONITOR_* 环境变量覆盖的字段，SaveConfig 时还原为文件原值
+	envOverrides map[string]envOverride
+	// fileKeys 配置文件中出现的键路径（段名、段内字段与模型字段），为空表示配置不是从文件加载的
+	fileKeys map[string]bool
+	// implicitValues 文件中未出现的字段在加载后的取值（默认值或环境变量覆盖值），SaveConfig 时未修改的不写回
+	implicitValues map[string]interface{}
 }
 
 // LLMModelConfig 单个LLM模型配置
 type LLMModelConfig struct {
-	ID       string `yaml:"id" json:"id"`
-	Name     string `yaml:"name" json:"name"`
-	Endpoint string `yaml:"endpoint" json:"endpoint"`
-	APIKey   string `yaml:"api_key" json:"api_key"`
-	Model    string `yaml:"model" json:"model"`
-	Timeout  int    `yaml:"timeout" json:"timeout"`
-	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	ID              string `yaml:"id" json:"id"`
+	Name            string `yaml:"name" json:"name"`
+	Provider        string `yaml:"provider,omitempty" json:"provider"` // openai（默认）, anthropic, ollama
+	Endpoint        string `yaml:"endpoint" json:"endpoint"`
+	APIKey          string `yaml:"api_key" json:"api_key"`
+	Model           string `yaml:"model" json:"model"`
+	Timeout         int    `yaml:"timeout" json:"timeout"`
+	MaxTokens       int    `yaml:"max_tokens,omitempty" json:"max_tokens"`
+	DisableJSONMode bool   `yaml:"disable_json_mode,omitempty" json:"disable_json_mode"` // 服务端不支持结构化输出时关闭
+	Enabled         bool   `yaml:"enabled" json:"enabled"`
 }
 
 // JWTConfig JWT认证配置
@@ -50,6 +71,8 @@ type LLMConfig struct {
 type ServerConfig struct {
 	Port int    `yaml:"port"`
 	Mode string `yaml:"mode"` // debug, release
+	// ReloadInterval 配置文件变更检查间隔（秒），0 表示不监听文件，仅响应 SIGHUP
+	ReloadInterval int `yaml:"reload_interval"`
 }
 
 // DatabaseConfig 数据库配置
@@ -72,13 +95,100 @@ type RedisConfig struct {
 	DB       int    `yaml:"db"`
 }
 
+// CacheConfig 查询缓存配置；redis.enabled 时缓存存放在 Redis，否则（或 Redis 不可用时）使用进程内缓存
+type CacheConfig struct {
+	JobsTTLSeconds    int `yaml:"jobs_ttl_seconds"`    // 分组作业列表、作业统计、卡数选项的缓存时长
+	MetricsTTLSeconds int `yaml:"metrics_ttl_seconds"` // 最新 NPU 指标的缓存时长
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
-	Level string `yaml:"level"` // debug, info, warn, error
-	File  string `yaml:"file"`
+	Level       string `yaml:"level"`         // debug, info, warn, error
+	Format      string `yaml:"format"`        // json（默认）, text
+	File        string `yaml:"file"`          // 为空时输出到标准输出
+	MaxSizeMB   int    `yaml:"max_size_mb"`   // 单个日志文件大小上限，超过后轮转
+	MaxBackups  int    `yaml:"max_backups"`   // 保留的历史日志文件数
+	MaxAgeDays  int    `yaml:"max_age_days"`  // 历史日志文件保留天数
+	Compress    bool   `yaml:"compress"`      // 是否 gzip 压缩历史日志
+	SlowQueryMs int    `yaml:"slow_query_ms"` // SQL 慢查询阈值（毫秒）
+}
+
+// MetricsConfig 集群指标导出配置（/metrics/cluster）
+type MetricsConfig struct {
+	CacheSeconds    int `yaml:"cache_seconds"`     // 两次抓取之间复用快照的时长，避免每次抓取都查询 MySQL
+	NPUStaleMinutes int `yaml:"npu_stale_minutes"` // 超过该时长未上报的 NPU 不再导出
+}
+
+// JobGroupsConfig 持久化作业分组配置
+type JobGroupsConfig struct {
+	SyncIntervalSeconds int `yaml:"sync_interval_seconds"` // 增量同步间隔，新上报的作业最迟在该间隔后出现在分组列表中
+}
+
+// ExportConfig 分析结果导出配置
+type ExportConfig struct {
+	ChunkSize      int    `yaml:"chunk_size"`      // 流式导出每批查询的分组数
+	Dir            string `yaml:"dir"`             // 异步导出文件的存放目录
+	RetentionHours int    `yaml:"retention_hours"` // 异步导出文件保留时长，过期后删除
+	MaxRunning     int    `yaml:"max_running"`     // 同时运行的异步导出任务上限，超出时拒绝新任务
+	MaxTotalMB     int    `yaml:"max_total_mb"`    // 导出目录中文件（含运行中任务）的总大小上限
+	TimeoutMinutes int    `yaml:"timeout_minutes"` // 单个异步导出任务的最长运行时间
+}
+
+// ReportsConfig 定时报表配置；除 schedules 外也可以通过接口在数据库中维护报表计划
+type ReportsConfig struct {
+	Dir                  string                 `yaml:"dir"`                    // 未指定 webhook 与 dir 的计划写入该目录
+	CheckIntervalSeconds int                    `yaml:"check_interval_seconds"` // 检查计划是否到期的间隔
+	Schedules            []ReportScheduleConfig `yaml:"schedules"`
+	// WebhookHosts 通过接口维护的计划允许投递的 webhook 主机，带端口时须完全一致；为空时接口创建的计划不能设置 webhook
+	WebhookHosts []string `yaml:"webhook_hosts"`
+}
+
+// ReportScheduleConfig 配置文件中的报表计划，名称在配置与数据库计划之间唯一
+type ReportScheduleConfig struct {
+	Name       string   `yaml:"name" json:"name"`
+	Cron       string   `yaml:"cron" json:"cron"`                         // 标准 5 段 cron 表达式，按服务器本地时区
+	PeriodDays int      `yaml:"period_days,omitempty" json:"period_days"` // 统计窗口天数，默认 7
+	Formats    []string `yaml:"formats,omitempty" json:"formats"`         // markdown / html / csv，默认 markdown
+	Webhook    string   `yaml:"webhook,omitempty" json:"webhook"`         // 报表以 JSON POST 到该地址
+	Dir        string   `yaml:"dir,omitempty" json:"dir"`                 // 报表文件写入目录
+	TopN       int      `yaml:"top_n,omitempty" json:"top_n"`             // 各列表最多条数，默认 10
+	Disabled   bool     `yaml:"disabled,omitempty" json:"disabled"`
+}
+
+// InsightsConfig 空闲占卡检测配置（/insights/idle-jobs），百分比均为 0-100
+type InsightsConfig struct {
+	LookbackMinutes        int `yaml:"lookback_minutes"`         // 默认回看窗口
+	MinRunningMinutes      int `yaml:"min_running_minutes"`      // 运行不足该时长的作业不参与检测，避免把加载阶段误判为空闲
+	IdleAICorePercent      int `yaml:"idle_aicore_percent"`      // 窗口内平均 AICore 利用率不超过该值视为空闲
+	IdleHBMPercent         int `yaml:"idle_hbm_percent"`         // 空闲且平均 HBM 占用不低于该值视为空占卡
+	LowAICorePercent       int `yaml:"low_aicore_percent"`       // 平均 AICore 利用率低于该值视为低利用率
+	ImbalanceSpreadPercent int `yaml:"imbalance_spread_percent"` // 多卡作业各卡平均利用率极差不低于该值视为负载不均
+}
+
+// AccountingConfig 卡时核算配置
+type AccountingConfig struct {
+	OwnerRules []OwnerRuleConfig `yaml:"owner_rules"` // 按顺序匹配，先匹配到的规则优先
+}
+
+// OwnerRuleConfig 作业归属映射规则：field 的值匹配 pattern 时，owner/project 取模板展开结果，
+// 模板可用 $1、${name} 引用捕获组；两者至少填写一个
+type OwnerRuleConfig struct {
+	Field   string `yaml:"field" json:"field"` // user / cwd / command_line / job_name / node / env:<变量名>
+	Pattern string `yaml:"pattern" json:"pattern"`
+	Owner   string `yaml:"owner,omitempty" json:"owner"`
+	Project string `yaml:"project,omitempty" json:"project"`
+}
+
+// ProjectsConfig 项目归属与可见范围配置；项目、成员与归属规则通过接口在数据库中维护
+type ProjectsConfig struct {
+	SyncIntervalSeconds int `yaml:"sync_interval_seconds"` // 按归属规则计算新增与变更作业归属的间隔
+	// RestrictVisibility 开启后非管理员只能看到所属项目与未归属的作业，匿名请求只能看到未归属的作业
+	RestrictVisibility bool     `yaml:"restrict_visibility"`
+	Admins             []string `yaml:"admins"` // 管理员用户名：可见全部作业，可维护任意项目
 }
 
 // LoadConfig 加载配置文件
+// 依次应用 TASK_MONITOR_* 环境变量覆盖、默认值、密文与环境变量引用解析；校验由调用方通过 Validate 执行。
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -89,22 +199,189 @@ func LoadConfig(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if config.fileKeys, err = yamlKeys(data); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if err := applyEnvOverrides(&config); err != nil {
+		return nil, fmt.Errorf("failed to apply env overrides: %w", err)
+	}
+	applyDefaults(&config)
+
+	if err := resolveSecrets(&config); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	captureEnvOverrideValues(&config)
+	captureImplicitValues(&config)
 
 	return &config, nil
 }
 
 // SaveConfig 将配置写回YAML文件
+// 只写回文件中原有的字段与运行时修改过的字段，默认值不会落盘；环境变量覆盖的字段若未在运行时修改则写回文件原值；
+// 敏感字段不会以解密后的明文写回：未修改的保留原始写法，新值须加密保存，未配置密钥时拒绝保存（返回 ErrSecretKeyRequired）。
 func SaveConfig(path string, cfg *Config) error {
-	data, err := yaml.Marshal(cfg)
+	omit := unchangedImplicitFields(cfg)
+	out := *cfg
+	out.LLM.Models = append([]LLMModelConfig(nil), cfg.LLM.Models...)
+	out.Reports.Schedules = append([]ReportScheduleConfig(nil), cfg.Reports.Schedules...)
+	out.Accounting.OwnerRules = append([]OwnerRuleConfig(nil), cfg.Accounting.OwnerRules...)
+	out.Projects.Admins = append([]string(nil), cfg.Projects.Admins...)
+	restoreEnvOverrides(&out, cfg.envOverrides)
+
+	created, err := protectSecrets(&out, cfg.secretRefs)
 	if err != nil {
+		return fmt.Errorf("failed to protect secrets: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(&out); err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if cfg.fileKeys != nil {
+		pruneImplicitKeys(&doc, omit, cfg.fileKeys)
+	}
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+
+	if len(created) > 0 {
+		if cfg.secretRefs == nil {
+			cfg.secretRefs = make(map[string]secretRef)
+		}
+		for field, ref := range created {
+			cfg.secretRefs[field] = ref
+		}
+	}
 	return nil
 }
 
+// yamlKeys 返回配置文件中出现的键路径：段名、段内字段（如 log.slow_query_ms）与模型字段
+// （如 llm.models[qwen].timeout），路径格式与 walkConfigFields 一致
+func yamlKeys(data []byte) (map[string]bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	if len(doc.Content) == 0 {
+		return keys, nil
+	}
+	forEachMappingKey(doc.Content[0], func(section string, value *yaml.Node) {
+		keys[section] = true
+		forEachMappingKey(value, func(field string, value *yaml.Node) {
+			keys[section+"."+field] = true
+			if value.Kind != yaml.SequenceNode {
+				return
+			}
+			for _, item := range value.Content {
+				id := mappingValue(item, "id")
+				if id == "" {
+					continue
+				}
+				forEachMappingKey(item, func(key string, _ *yaml.Node) {
+					keys[fmt.Sprintf("%s.%s[%s].%s", section, field, id, key)] = true
+				})
+			}
+		})
+	})
+	return keys, nil
+}
+
+// captureImplicitValues 记录文件中未出现的字段在加载完成后的取值
+func captureImplicitValues(cfg *Config) {
+	cfg.implicitValues = make(map[string]interface{})
+	_ = walkConfigFields(cfg, func(path, _ string, field reflect.Value) error {
+		if !cfg.fileKeys[path] {
+			cfg.implicitValues[path] = field.Interface()
+		}
+		return nil
+	})
+}
+
+// unchangedImplicitFields 文件中未出现、且运行时未修改的字段，保存时不写回
+func unchangedImplicitFields(cfg *Config) map[string]bool {
+	omit := make(map[string]bool)
+	_ = walkConfigFields(cfg, func(path, _ string, field reflect.Value) error {
+		if v, ok := cfg.implicitValues[path]; ok && reflect.DeepEqual(field.Interface(), v) {
+			omit[path] = true
+		}
+		return nil
+	})
+	return omit
+}
+
+// pruneImplicitKeys 从待写盘的文档中删除 omit 中的字段，以及文件中原本没有、当前为空的列表字段与段
+func pruneImplicitKeys(doc *yaml.Node, omit, fileKeys map[string]bool) {
+	root := doc
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return
+		}
+		root = root.Content[0]
+	}
+	filterMapping(root, func(section string, value *yaml.Node) bool {
+		filterMapping(value, func(field string, value *yaml.Node) bool {
+			path := section + "." + field
+			if omit[path] {
+				return false
+			}
+			if value.Kind == yaml.SequenceNode {
+				if len(value.Content) == 0 && !fileKeys[path] {
+					return false
+				}
+				for _, item := range value.Content {
+					id := mappingValue(item, "id")
+					filterMapping(item, func(key string, _ *yaml.Node) bool {
+						return id == "" || !omit[fmt.Sprintf("%s[%s].%s", path, id, key)]
+					})
+				}
+			}
+			return true
+		})
+		return len(value.Content) > 0 || fileKeys[section]
+	})
+}
+
+// forEachMappingKey 按顺序遍历映射节点的键值对，非映射节点忽略
+func forEachMappingKey(node *yaml.Node, fn func(key string, value *yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fn(node.Content[i].Value, node.Content[i+1])
+	}
+}
+
+// filterMapping 只保留 keep 返回 true 的键值对
+func filterMapping(node *yaml.Node, keep func(key string, value *yaml.Node) bool) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	kept := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if keep(node.Content[i].Value, node.Content[i+1]) {
+			kept = append(kept, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = kept
+}
+
+// mappingValue 返回映射节点中 key 对应的标量值
+func mappingValue(node *yaml.Node, key string) string {
+	value := ""
+	forEachMappingKey(node, func(k string, v *yaml.Node) {
+		if k == key && v.Kind == yaml.ScalarNode {
+			value = v.Value
+		}
+	})
+	return value
+}
+
 // GetDSN 获取数据库连接字符串
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
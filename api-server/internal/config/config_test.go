@@ -0,0 +1,280 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validConfigYAML = `
+server:
+  port: 8080
+database:
+  host: db
+  user: monitor
+  password: secret
+  database: task_monitor
+jwt:
+  secret: jwt-secret
+llm:
+  default_model_id: qwen
+  models:
+    - id: qwen
+      endpoint: http://localhost:8000/v1
+      model: qwen2.5
+      enabled: true
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+
+	assert.Equal(t, "release", cfg.Server.Mode)
+	assert.Equal(t, 3306, cfg.Database.Port)
+	assert.Equal(t, 100, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 10, cfg.Database.MaxIdleConns)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.Equal(t, 60, cfg.LLM.Timeout)
+	assert.Equal(t, 5, cfg.LLM.BatchConcurrency)
+	assert.Equal(t, 1440, cfg.JWT.ExpireMinutes)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoadConfig_EnvOverrides(t *testing.T) {
+	t.Setenv("TASK_MONITOR_SERVER_PORT", "9090")
+	t.Setenv("TASK_MONITOR_SERVER_MODE", "debug")
+	t.Setenv("TASK_MONITOR_DATABASE_MAX_OPEN_CONNS", "20")
+	t.Setenv("TASK_MONITOR_REDIS_ENABLED", "true")
+	t.Setenv("TASK_MONITOR_LLM_MODELS_QWEN_API_KEY", "sk-env")
+
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+
+	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, "debug", cfg.Server.Mode)
+	assert.Equal(t, 20, cfg.Database.MaxOpenConns)
+	assert.True(t, cfg.Redis.Enabled)
+	assert.Equal(t, "sk-env", cfg.LLM.Models[0].APIKey)
+	assert.Equal(t, []string{
+		"TASK_MONITOR_DATABASE_MAX_OPEN_CONNS",
+		"TASK_MONITOR_LLM_MODELS_QWEN_API_KEY",
+		"TASK_MONITOR_REDIS_ENABLED",
+		"TASK_MONITOR_SERVER_MODE",
+		"TASK_MONITOR_SERVER_PORT",
+	}, cfg.EnvOverrides())
+}
+
+func TestLoadConfig_ExplicitZeroKeepsValue(t *testing.T) {
+	t.Setenv("TASK_MONITOR_LOG_SLOW_QUERY_MS", "0")
+
+	content := validConfigYAML + `
+insights:
+  idle_aicore_percent: 0
+cache:
+  jobs_ttl_seconds: 0
+`
+	cfg, err := LoadConfig(writeConfig(t, content))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, cfg.Log.SlowQueryMs)
+	assert.Equal(t, 0, cfg.Insights.IdleAICorePercent)
+	assert.Equal(t, 0, cfg.Cache.JobsTTLSeconds)
+	// 同一段中未出现的字段仍取默认值
+	assert.Equal(t, 10, cfg.Insights.IdleHBMPercent)
+	assert.Equal(t, 10, cfg.Cache.MetricsTTLSeconds)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_RejectsZeroIntervals(t *testing.T) {
+	content := validConfigYAML + `
+job_groups:
+  sync_interval_seconds: 0
+projects:
+  sync_interval_seconds: 0
+`
+	cfg, err := LoadConfig(writeConfig(t, content))
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 2)
+	assert.Contains(t, err.Error(), "job_groups.sync_interval_seconds must be positive")
+	assert.Contains(t, err.Error(), "projects.sync_interval_seconds must be positive")
+}
+
+func TestLoadConfig_InvalidEnvOverride(t *testing.T) {
+	t.Setenv("TASK_MONITOR_SERVER_PORT", "abc")
+
+	_, err := LoadConfig(writeConfig(t, validConfigYAML))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TASK_MONITOR_SERVER_PORT")
+}
+
+func TestSaveConfig_DoesNotPersistEnvOverrides(t *testing.T) {
+	t.Setenv("TASK_MONITOR_SERVER_PORT", "9090")
+	t.Setenv("TASK_MONITOR_LLM_MODELS_QWEN_API_KEY", "sk-env")
+	t.Setenv(SecretKeyEnv, "")
+	t.Setenv(SecretKeyFileEnv, "")
+
+	path := writeConfig(t, validConfigYAML)
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	cfg.LLM.Timeout = 90
+
+	require.NoError(t, SaveConfig(path, cfg))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "port: 8080")
+	assert.Contains(t, string(data), "timeout: 90")
+	assert.NotContains(t, string(data), "sk-env")
+}
+
+func TestSaveConfig_OnlyPersistsFileAndModifiedFields(t *testing.T) {
+	t.Setenv("TASK_MONITOR_LOG_LEVEL", "debug")
+	t.Setenv(SecretKeyEnv, "")
+	t.Setenv(SecretKeyFileEnv, "")
+
+	path := writeConfig(t, validConfigYAML)
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	cfg.LLM.Timeout = 90
+	cfg.LLM.Models[0].Timeout = 30
+
+	require.NoError(t, SaveConfig(path, cfg))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "port: 8080")
+	assert.Contains(t, content, "timeout: 90")
+	assert.Contains(t, content, "timeout: 30")
+	// 默认值、环境变量覆盖值与空列表不落盘
+	for _, key := range []string{"max_open_conns", "slow_query_ms", "level:", "insights:", "reports:", "owner_rules", "max_tokens"} {
+		assert.NotContains(t, content, key)
+	}
+
+	reloaded, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 100, reloaded.Database.MaxOpenConns)
+	assert.Equal(t, 90, reloaded.LLM.Timeout)
+	assert.Equal(t, 30, reloaded.LLM.Models[0].Timeout)
+}
+
+func TestValidate_Errors(t *testing.T) {
+	t.Setenv("TASK_MONITOR_SERVER_PORT", "0")
+	t.Setenv("TASK_MONITOR_SERVER_MODE", "prod")
+	t.Setenv("TASK_MONITOR_JWT_SECRET", "")
+	t.Setenv("TASK_MONITOR_DATABASE_MAX_OPEN_CONNS", "5")
+	t.Setenv("TASK_MONITOR_DATABASE_MAX_IDLE_CONNS", "8")
+	t.Setenv("TASK_MONITOR_LOG_LEVEL", "verbose")
+
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 5)
+	assert.Contains(t, err.Error(), "server.port")
+	assert.Contains(t, err.Error(), "server.mode")
+	assert.Contains(t, err.Error(), "jwt.secret")
+	assert.Contains(t, err.Error(), "max_idle_conns")
+	assert.Contains(t, err.Error(), "log.level")
+}
+
+func TestValidate_DuplicateModelIDs(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+	cfg.LLM.Models = append(cfg.LLM.Models, LLMModelConfig{ID: "qwen"})
+	cfg.LLM.DefaultModelID = "missing"
+
+	err = cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicated")
+	assert.Contains(t, err.Error(), "default_model_id")
+}
+
+func TestValidate_ReportSchedules(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+	cfg.Reports.Schedules = []ReportScheduleConfig{
+		{Name: "weekly", Cron: "0 9 * * 1", Formats: []string{"markdown", "csv"}, Webhook: "https://hooks.example.com/x"},
+		{Name: "weekly", Cron: "0 9 * * 1"},
+		{Name: "bad-cron", Cron: "every monday"},
+		{Name: "bad-format", Cron: "@daily", Formats: []string{"pdf"}},
+		{Name: "bad-webhook", Cron: "@daily", Webhook: "ftp://example.com"},
+	}
+
+	err = cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 4)
+	assert.Contains(t, err.Error(), `"weekly" is duplicated`)
+	assert.Contains(t, err.Error(), "reports.schedules[bad-cron]: invalid cron")
+	assert.Contains(t, err.Error(), `unsupported format "pdf"`)
+	assert.Contains(t, err.Error(), "webhook must be an http(s) URL")
+}
+
+func TestValidate_Insights(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+	assert.Equal(t, 60, cfg.Insights.LookbackMinutes)
+	assert.Equal(t, 5, cfg.Insights.IdleAICorePercent)
+	require.NoError(t, cfg.Validate())
+
+	cfg.Insights.IdleHBMPercent = 120
+	cfg.Insights.IdleAICorePercent = 50
+	err = cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 2)
+	assert.Contains(t, err.Error(), "insights.idle_hbm_percent must be between 0 and 100")
+	assert.Contains(t, err.Error(), "must not exceed low_aicore_percent")
+}
+
+func TestValidate_OwnerRules(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+	cfg.Accounting.OwnerRules = []OwnerRuleConfig{
+		{Field: "cwd", Pattern: `^/data/(\w+)/`, Owner: "$1"},
+		{Field: "env:TEAM", Pattern: `.+`, Project: "$0"},
+		{Field: "hostname", Pattern: `.*`, Owner: "x"},
+		{Field: "cwd", Pattern: `([`, Owner: "x"},
+		{Field: "env:", Pattern: `.*`, Owner: "x"},
+		{Field: "user", Pattern: `.*`},
+	}
+
+	err = cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 4)
+	assert.Contains(t, err.Error(), `accounting.owner_rules[2]: unsupported field "hostname"`)
+	assert.Contains(t, err.Error(), "accounting.owner_rules[3]: invalid pattern")
+	assert.Contains(t, err.Error(), "accounting.owner_rules[5]: owner or project is required")
+}
+
+func TestValidate_Projects(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, validConfigYAML))
+	require.NoError(t, err)
+	assert.Equal(t, 60, cfg.Projects.SyncIntervalSeconds)
+	cfg.Projects.SyncIntervalSeconds = -1
+	cfg.Projects.Admins = []string{"admin", " "}
+
+	err = cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 2)
+	assert.Contains(t, err.Error(), "projects.admins[1] must not be empty")
+}
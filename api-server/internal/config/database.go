@@ -2,7 +2,7 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -13,12 +13,13 @@ import (
 	"github.com/task-monitor/api-server/internal/model"
 )
 
-// InitDB 初始化数据库连接
-func InitDB(cfg *DatabaseConfig) (*gorm.DB, error) {
+// InitDB 初始化数据库连接，gormLogger 为空时使用 GORM 默认日志（仅慢查询与错误）
+func InitDB(cfg *DatabaseConfig, gormLogger logger.Interface) (*gorm.DB, error) {
 	dsn := cfg.GetDSN()
 
-	// 配置GORM日志
-	gormLogger := logger.Default.LogMode(logger.Info)
+	if gormLogger == nil {
+		gormLogger = logger.Default
+	}
 
 	// 连接数据库
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
@@ -44,15 +45,58 @@ func InitDB(cfg *DatabaseConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("Database connected successfully")
+	slog.Info("database connected", "host", cfg.Host, "database", cfg.Database)
 	return db, nil
 }
 
+// ensureJobIndexes jobs 表由采集端创建，这里只补充分组增量同步按变更时间查询所需的索引
+func ensureJobIndexes(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.Job{}) {
+		return nil
+	}
+	for _, field := range []string{"CreatedAt", "UpdatedAt"} {
+		if migrator.HasIndex(&model.Job{}, field) {
+			continue
+		}
+		if err := migrator.CreateIndex(&model.Job{}, field); err != nil {
+			return fmt.Errorf("failed to create jobs index on %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// backfillJobGroupEndTime 为 end_time 列加入前持久化的分组补齐根作业的结束时间，否则这些分组会被当作
+// 未结束（end_time IS NULL）而落入所有按结束时间下限筛选的结果。只更新仍为空且根作业已结束的分组，可重复执行
+func backfillJobGroupEndTime(db *gorm.DB) error {
+	result := db.Exec(`UPDATE job_groups g INNER JOIN jobs j ON j.job_id = g.root_job_id
+		SET g.end_time = j.end_time
+		WHERE g.end_time IS NULL AND j.end_time IS NOT NULL`)
+	if result.Error != nil {
+		return fmt.Errorf("failed to backfill job group end time: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		slog.Info("job group end time backfilled", "groups", result.RowsAffected)
+	}
+	return nil
+}
+
 // AutoMigrateAndSeed 自动建表并创建默认用户
 func AutoMigrateAndSeed(db *gorm.DB) error {
-	if err := db.AutoMigrate(&model.User{}, &model.JobAnalysis{}); err != nil {
+	if err := db.AutoMigrate(&model.User{}, &model.JobAnalysis{},
+		&model.JobGroupRecord{}, &model.JobGroupMember{}, &model.JobGroupSyncState{}, &model.SavedView{},
+		&model.ReportSchedule{}, &model.ReportRun{},
+		&model.Project{}, &model.ProjectMember{}, &model.ProjectRule{}, &model.JobProject{}); err != nil {
 		return fmt.Errorf("failed to migrate tables: %w", err)
 	}
+	if err := ensureJobIndexes(db); err != nil {
+		return err
+	}
+	if db.Migrator().HasTable(&model.Job{}) {
+		if err := backfillJobGroupEndTime(db); err != nil {
+			return err
+		}
+	}
 
 	var count int64
 	db.Model(&model.User{}).Count(&count)
@@ -68,7 +112,7 @@ func AutoMigrateAndSeed(db *gorm.DB) error {
 		if err := db.Create(&defaultAdmin).Error; err != nil {
 			return fmt.Errorf("failed to create default admin: %w", err)
 		}
-		log.Println("Default admin user created (admin/admin123)")
+		slog.Warn("default admin user created, change the password after first login", "username", "admin")
 	}
 	return nil
 }
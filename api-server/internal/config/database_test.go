@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestBackfillJobGroupEndTime(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 迁移前持久化的已结束分组 end_time 为空，按根作业补齐；再次执行时没有需要更新的分组
+	mock.ExpectExec("UPDATE job_groups g INNER JOIN jobs j ON j.job_id = g.root_job_id\\s+SET g.end_time = j.end_time\\s+" +
+		"WHERE g.end_time IS NULL AND j.end_time IS NOT NULL").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE job_groups g").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, backfillJobGroupEndTime(db))
+	assert.NoError(t, backfillJobGroupEndTime(db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
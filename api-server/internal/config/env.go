@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 环境变量覆盖前缀
+// 命名规则：TASK_MONITOR_<段>_<字段>，段与字段取 yaml 键名的大写形式，例如
+// TASK_MONITOR_SERVER_PORT、TASK_MONITOR_DATABASE_MAX_OPEN_CONNS、TASK_MONITOR_LLM_API_KEY；
+// 模型按ID定位：TASK_MONITOR_LLM_MODELS_<ID>_<字段>，ID 中非字母数字字符替换为下划线。
+const EnvPrefix = "TASK_MONITOR_"
+
+var envNameSanitizer = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// envOverride 记录被环境变量覆盖字段在文件中的原值，SaveConfig 时据此避免把覆盖值写回文件
+type envOverride struct {
+	fileValue interface{}
+	envValue  interface{}
+}
+
+// configFieldFunc 字段遍历回调：path 为 yaml 路径（如 llm.models[qwen].api_key），envName 为对应环境变量名
+type configFieldFunc func(path, envName string, field reflect.Value) error
+
+// walkConfigFields 遍历所有可覆盖的标量字段
+func walkConfigFields(cfg *Config, fn configFieldFunc) error {
+	root := reflect.ValueOf(cfg).Elem()
+	rootType := root.Type()
+	for i := 0; i < rootType.NumField(); i++ {
+		section := rootType.Field(i)
+		tag := yamlKey(section)
+		if tag == "" || section.Type.Kind() != reflect.Struct {
+			continue
+		}
+		if err := walkStructFields(tag+".", EnvPrefix+envName(tag)+"_", root.Field(i), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkStructFields(pathPrefix, envPrefix string, v reflect.Value, fn configFieldFunc) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := yamlKey(f)
+		if tag == "" {
+			continue
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String, reflect.Int, reflect.Bool:
+			if err := fn(pathPrefix+tag, envPrefix+envName(tag), field); err != nil {
+				return err
+			}
+		case reflect.Slice:
+			if field.Type().Elem() != reflect.TypeOf(LLMModelConfig{}) {
+				continue
+			}
+			for j := 0; j < field.Len(); j++ {
+				model := field.Index(j)
+				id := model.FieldByName("ID").String()
+				if id == "" {
+					continue
+				}
+				modelPath := fmt.Sprintf("%s%s[%s].", pathPrefix, tag, id)
+				if err := walkStructFields(modelPath, envPrefix+envName(tag)+"_"+envName(id)+"_", model, fn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func yamlKey(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func envName(key string) string {
+	return strings.Trim(envNameSanitizer.ReplaceAllString(strings.ToUpper(key), "_"), "_")
+}
+
+// applyEnvOverrides 用 TASK_MONITOR_* 环境变量覆盖配置文件中的值
+func applyEnvOverrides(cfg *Config) error {
+	cfg.envOverrides = make(map[string]envOverride)
+	return walkConfigFields(cfg, func(_, name string, field reflect.Value) error {
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		fileValue := field.Interface()
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(raw)
+		case reflect.Int:
+			n, err := strconv.Atoi(strings.TrimSpace(raw))
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %q is not an integer", name, raw)
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(strings.TrimSpace(raw))
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %q is not a boolean", name, raw)
+			}
+			field.SetBool(b)
+		}
+		cfg.envOverrides[name] = envOverride{fileValue: fileValue}
+		return nil
+	})
+}
+
+// captureEnvOverrideValues 在默认值与密文解析完成后记录覆盖字段的最终值
+func captureEnvOverrideValues(cfg *Config) {
+	if len(cfg.envOverrides) == 0 {
+		return
+	}
+	_ = walkConfigFields(cfg, func(_, name string, field reflect.Value) error {
+		if o, ok := cfg.envOverrides[name]; ok {
+			o.envValue = field.Interface()
+			cfg.envOverrides[name] = o
+		}
+		return nil
+	})
+}
+
+// restoreEnvOverrides 对仍保持覆盖值的字段还原为文件中的原值；运行时被修改过的字段按新值写入
+func restoreEnvOverrides(out *Config, overrides map[string]envOverride) {
+	if len(overrides) == 0 {
+		return
+	}
+	_ = walkConfigFields(out, func(_, name string, field reflect.Value) error {
+		if o, ok := overrides[name]; ok && reflect.DeepEqual(field.Interface(), o.envValue) {
+			field.Set(reflect.ValueOf(o.fileValue))
+		}
+		return nil
+	})
+}
+
+// EnvOverrides 返回本次加载生效的环境变量名（已排序）
+func (c *Config) EnvOverrides() []string {
+	names := make([]string, 0, len(c.envOverrides))
+	for name := range c.envOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configDefaults 字段默认值，键为 walkConfigFields 的路径
+// server.port 不设默认值，缺省即视为配置错误。
+var configDefaults = map[string]interface{}{
+	"server.mode":                       "release",
+	"database.host":                     "localhost",
+	"database.port":                     3306,
+	"database.max_open_conns":           100,
+	"redis.host":                        "localhost",
+	"redis.port":                        6379,
+	"log.level":                         "info",
+	"log.format":                        "json",
+	"log.max_size_mb":                   100,
+	"log.max_backups":                   7,
+	"log.max_age_days":                  30,
+	"log.slow_query_ms":                 200,
+	"llm.timeout":                       60,
+	"llm.batch_concurrency":             5,
+	"jwt.expire_minutes":                1440,
+	"cache.jobs_ttl_seconds":            15,
+	"cache.metrics_ttl_seconds":         10,
+	"metrics.cache_seconds":             30,
+	"metrics.npu_stale_minutes":         10,
+	"job_groups.sync_interval_seconds":  30,
+	"export.chunk_size":                 500,
+	"export.dir":                        "./data/exports",
+	"export.retention_hours":            24,
+	"export.max_running":                4,
+	"export.max_total_mb":               2048,
+	"export.timeout_minutes":            60,
+	"reports.dir":                       "./data/reports",
+	"reports.check_interval_seconds":    30,
+	"insights.lookback_minutes":         60,
+	"insights.min_running_minutes":      30,
+	"insights.idle_aicore_percent":      5,
+	"insights.idle_hbm_percent":         10,
+	"insights.low_aicore_percent":       30,
+	"insights.imbalance_spread_percent": 40,
+	"projects.sync_interval_seconds":    60,
+}
+
+// applyDefaults 为未配置的字段填充默认值
+// 数值字段只要在配置文件或环境变量中出现过就保留原值（包括显式的 0）；字符串字段为空时总是填充默认值。
+func applyDefaults(cfg *Config) {
+	_ = walkConfigFields(cfg, func(path, name string, field reflect.Value) error {
+		def, ok := configDefaults[path]
+		if !ok || !field.IsZero() {
+			return nil
+		}
+		if field.Kind() != reflect.String && cfg.isConfigured(path, name) {
+			return nil
+		}
+		field.Set(reflect.ValueOf(def))
+		return nil
+	})
+	if !cfg.isConfigured("database.max_idle_conns", EnvPrefix+"DATABASE_MAX_IDLE_CONNS") {
+		cfg.Database.MaxIdleConns = 10
+		if cfg.Database.MaxOpenConns > 0 && cfg.Database.MaxOpenConns < 10 {
+			cfg.Database.MaxIdleConns = cfg.Database.MaxOpenConns
+		}
+	}
+}
+
+// isConfigured 字段是否在配置文件或环境变量中出现过
+func (c *Config) isConfigured(path, envName string) bool {
+	if c.fileKeys[path] {
+		return true
+	}
+	_, ok := c.envOverrides[envName]
+	return ok
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ReloadResult 一次热加载的结果
+type ReloadResult struct {
+	Changed         []string `json:"changed"`         // 与当前运行配置不同的字段
+	Applied         []string `json:"applied"`         // 已在运行时生效的字段
+	RestartRequired []string `json:"restartRequired"` // 需要重启才能生效的字段
+}
+
+// reloadApplier 热加载应用回调；fields 为其负责的字段路径前缀，为空表示每次配置变化都调用
+type reloadApplier struct {
+	fields []string
+	apply  func(cfg *Config)
+}
+
+// Reloader 配置热加载：重新读取配置文件并校验，通过后把可热更新字段一次性应用到各服务。
+// 校验失败时不应用任何字段；未注册的字段变化仅报告为需要重启。
+type Reloader struct {
+	path     string
+	mu       sync.Mutex
+	current  *Config // 最近一次应用的配置快照
+	appliers []reloadApplier
+
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader 创建热加载器，cfg 为启动时加载的配置
+func NewReloader(path string, cfg *Config) *Reloader {
+	r := &Reloader{path: path, current: cloneConfig(cfg)}
+	if info, err := os.Stat(path); err == nil {
+		r.modTime, r.size = info.ModTime(), info.Size()
+	}
+	return r
+}
+
+// Register 注册热加载回调，fields 为可热更新的字段路径前缀（如 "llm"、"database.max_open_conns"）
+func (r *Reloader) Register(fields []string, apply func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, reloadApplier{fields: fields, apply: apply})
+}
+
+// Reload 重新加载配置文件并应用
+func (r *Reloader) Reload() (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := LoadConfig(r.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{Changed: diffConfig(r.current, cfg)}
+	if len(result.Changed) == 0 {
+		return result, nil
+	}
+
+	triggered := make([]bool, len(r.appliers))
+	for _, path := range result.Changed {
+		reloadable := false
+		for i, a := range r.appliers {
+			if matchFieldPrefix(path, a.fields) {
+				triggered[i] = true
+				reloadable = true
+			}
+		}
+		if reloadable {
+			result.Applied = append(result.Applied, path)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, path)
+		}
+	}
+
+	for i, a := range r.appliers {
+		if triggered[i] || len(a.fields) == 0 {
+			a.apply(cfg)
+		}
+	}
+	r.current = cloneConfig(cfg)
+	return result, nil
+}
+
+// WatchSignal 收到 SIGHUP 时重新加载配置
+func (r *Reloader) WatchSignal(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				r.reloadAndLog("SIGHUP")
+			}
+		}
+	}()
+}
+
+// WatchFile 按固定间隔检查配置文件的修改时间与大小，变化时重新加载
+func (r *Reloader) WatchFile(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(r.path)
+				if err != nil {
+					continue
+				}
+				if info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+					continue
+				}
+				r.modTime, r.size = info.ModTime(), info.Size()
+				r.reloadAndLog("file change")
+			}
+		}
+	}()
+}
+
+func (r *Reloader) reloadAndLog(trigger string) {
+	result, err := r.Reload()
+	if err != nil {
+		slog.Error("config reload rejected, keeping current config", "trigger", trigger, "error", err)
+		return
+	}
+	if len(result.Changed) == 0 {
+		slog.Info("config reload: no changes", "trigger", trigger)
+		return
+	}
+	if len(result.Applied) > 0 {
+		slog.Info("config reload applied", "trigger", trigger, "fields", result.Applied)
+	}
+	if len(result.RestartRequired) > 0 {
+		slog.Warn("config reload requires restart", "trigger", trigger, "fields", result.RestartRequired)
+	}
+}
+
+// diffConfig 返回两份配置中值不同的字段路径（已排序），敏感字段同样按解析后的值比较
+func diffConfig(oldCfg, newCfg *Config) []string {
+	oldValues := configValues(oldCfg)
+	newValues := configValues(newCfg)
+
+	var changed []string
+	for path, v := range newValues {
+		if ov, ok := oldValues[path]; !ok || !reflect.DeepEqual(ov, v) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range oldValues {
+		if _, ok := newValues[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// configValues 收集可比较的字段值：标量字段与按 ID 展开的模型字段逐个比较，
+// 其余切片与 map 字段（报表计划、归属规则、管理员列表等）整体比较
+func configValues(cfg *Config) map[string]interface{} {
+	values := make(map[string]interface{})
+	_ = walkConfigFields(cfg, func(path, _ string, field reflect.Value) error {
+		values[path] = field.Interface()
+		return nil
+	})
+
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		sectionTag := yamlKey(root.Type().Field(i))
+		section := root.Field(i)
+		if sectionTag == "" || section.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < section.NumField(); j++ {
+			tag := yamlKey(section.Type().Field(j))
+			field := section.Field(j)
+			if tag == "" || (field.Kind() != reflect.Slice && field.Kind() != reflect.Map) ||
+				field.Type().Elem() == reflect.TypeOf(LLMModelConfig{}) {
+				continue
+			}
+			// 空列表与未配置视为相同
+			var v interface{}
+			if field.Len() > 0 {
+				v = field.Interface()
+			}
+			values[sectionTag+"."+tag] = v
+		}
+	}
+	return values
+}
+
+func matchFieldPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneConfig 复制配置快照，切片字段深拷贝，避免后续修改影响比较基准
+func cloneConfig(cfg *Config) *Config {
+	out := *cfg
+	out.LLM.Models = append([]LLMModelConfig(nil), cfg.LLM.Models...)
+	out.Reports.Schedules = nil
+	for _, s := range cfg.Reports.Schedules {
+		s.Formats = append([]string(nil), s.Formats...)
+		out.Reports.Schedules = append(out.Reports.Schedules, s)
+	}
+	out.Reports.WebhookHosts = append([]string(nil), cfg.Reports.WebhookHosts...)
+	out.Accounting.OwnerRules = append([]OwnerRuleConfig(nil), cfg.Accounting.OwnerRules...)
+	out.Projects.Admins = append([]string(nil), cfg.Projects.Admins...)
+	return &out
+}
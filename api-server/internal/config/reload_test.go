@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_AppliesReloadableFields(t *testing.T) {
+	path := writeConfig(t, validConfigYAML)
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	r := NewReloader(path, cfg)
+	var llmApplied, alwaysApplied *Config
+	poolApplied := false
+	r.Register([]string{"llm"}, func(c *Config) { llmApplied = c })
+	r.Register([]string{"database.max_open_conns"}, func(c *Config) { poolApplied = true })
+	r.Register(nil, func(c *Config) { alwaysApplied = c })
+
+	updated := strings.Replace(validConfigYAML, "model: qwen2.5", "model: qwen3", 1)
+	updated = strings.Replace(updated, "port: 8080", "port: 9090", 1)
+	require.NoError(t, os.WriteFile(path, []byte(updated), 0600))
+
+	result, err := r.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"llm.models[qwen].model", "server.port"}, result.Changed)
+	assert.Equal(t, []string{"llm.models[qwen].model"}, result.Applied)
+	assert.Equal(t, []string{"server.port"}, result.RestartRequired)
+
+	require.NotNil(t, llmApplied)
+	assert.Equal(t, "qwen3", llmApplied.LLM.Models[0].Model)
+	assert.False(t, poolApplied)
+	assert.Same(t, llmApplied, alwaysApplied)
+
+	// 再次加载无变化时不调用回调
+	llmApplied = nil
+	result, err = r.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, result.Changed)
+	assert.Nil(t, llmApplied)
+}
+
+func TestReloader_InvalidConfigNotApplied(t *testing.T) {
+	path := writeConfig(t, validConfigYAML)
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	r := NewReloader(path, cfg)
+	applied := false
+	r.Register([]string{"llm"}, func(c *Config) { applied = true })
+
+	updated := strings.Replace(validConfigYAML, "model: qwen2.5", "model: qwen3", 1)
+	updated = strings.Replace(updated, "secret: jwt-secret", "secret: \"\"", 1)
+	require.NoError(t, os.WriteFile(path, []byte(updated), 0600))
+
+	_, err = r.Reload()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "jwt.secret")
+	assert.False(t, applied)
+}
+
+func TestReloader_DetectsSliceSections(t *testing.T) {
+	base := validConfigYAML + `
+reports:
+  webhook_hosts: [hooks.example.com]
+  schedules:
+    - name: weekly
+      cron: "0 8 * * 1"
+      formats: [markdown]
+accounting:
+  owner_rules:
+    - field: user
+      pattern: "^(.+)$"
+      owner: $1
+projects:
+  admins: [alice, bob]
+`
+	cases := []struct {
+		name    string
+		old     string
+		new     string
+		section string
+		changed string
+	}{
+		{"schedules", "cron: \"0 8 * * 1\"", "cron: \"0 9 * * 1\"", "reports", "reports.schedules"},
+		{"schedule formats", "formats: [markdown]", "formats: [markdown, csv]", "reports", "reports.schedules"},
+		{"webhook hosts", "webhook_hosts: [hooks.example.com]", "webhook_hosts: []", "reports", "reports.webhook_hosts"},
+		{"owner rules", "field: user", "field: cwd", "accounting", "accounting.owner_rules"},
+		{"admins", "admins: [alice, bob]", "admins: [alice]", "projects", "projects.admins"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfig(t, base)
+			cfg, err := LoadConfig(path)
+			require.NoError(t, err)
+
+			r := NewReloader(path, cfg)
+			var applied *Config
+			r.Register([]string{tc.section}, func(c *Config) { applied = c })
+
+			// 未修改时不报告变化
+			result, err := r.Reload()
+			require.NoError(t, err)
+			assert.Empty(t, result.Changed)
+
+			require.NoError(t, os.WriteFile(path, []byte(strings.Replace(base, tc.old, tc.new, 1)), 0600))
+			result, err = r.Reload()
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.changed}, result.Changed)
+			assert.Equal(t, []string{tc.changed}, result.Applied)
+			assert.NotNil(t, applied)
+		})
+	}
+}
+
+func TestReloader_UnregisteredSliceRequiresRestart(t *testing.T) {
+	path := writeConfig(t, validConfigYAML)
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	r := NewReloader(path, cfg)
+
+	require.NoError(t, os.WriteFile(path, []byte(validConfigYAML+"projects:\n  admins: [alice]\n"), 0600))
+	result, err := r.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"projects.admins"}, result.RestartRequired)
+}
+
+func TestCloneConfig_CopiesSlices(t *testing.T) {
+	cfg := &Config{}
+	cfg.Projects.Admins = []string{"alice"}
+	cfg.Reports.Schedules = []ReportScheduleConfig{{Name: "weekly", Formats: []string{"markdown"}}}
+	cfg.Accounting.OwnerRules = []OwnerRuleConfig{{Field: "user"}}
+
+	clone := cloneConfig(cfg)
+	cfg.Projects.Admins[0] = "mallory"
+	cfg.Reports.Schedules[0].Formats[0] = "csv"
+	cfg.Accounting.OwnerRules[0].Field = "cwd"
+
+	assert.Equal(t, []string{"alice"}, clone.Projects.Admins)
+	assert.Equal(t, []string{"markdown"}, clone.Reports.Schedules[0].Formats)
+	assert.Equal(t, "user", clone.Accounting.OwnerRules[0].Field)
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 敏感配置支持两种写法：
+//   - enc:<base64>  AES-256-GCM 密文（nonce + ciphertext），密钥来自环境变量或密钥文件
+//   - ${ENV_NAME}   运行时从环境变量读取
+//
+// 其余值按明文处理（兼容旧配置），但运行时新写入的敏感值只能加密保存。
+const (
+	encryptedSecretPrefix = "enc:"
+
+	// SecretKeyEnv 加密密钥（32字节，base64 或 hex 编码）
+	SecretKeyEnv = "TASK_MONITOR_SECRET_KEY"
+	// SecretKeyFileEnv 加密密钥文件路径，文件内容格式同 SecretKeyEnv
+	SecretKeyFileEnv = "TASK_MONITOR_SECRET_KEY_FILE"
+)
+
+// ErrSecretKeyMissing 存在加密值但未配置密钥
+var ErrSecretKeyMissing = errors.New("secret key not configured: set " + SecretKeyEnv + " or " + SecretKeyFileEnv)
+
+// ErrSecretKeyRequired 保存新的敏感值但未配置密钥，拒绝以明文写盘
+var ErrSecretKeyRequired = errors.New("refusing to write a secret in plaintext: set " + SecretKeyEnv + " or " + SecretKeyFileEnv +
+	" to a 32-byte key (base64 or hex encoded, e.g. from `openssl rand -base64 32`) and restart, " +
+	"or reference an environment variable with ${ENV_NAME} in the config file")
+
+var envRefPattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// secretRef 记录敏感字段在磁盘上的原始写法与解析后的值，保存时据此还原；明文写法的 raw 与 resolved 相同
+type secretRef struct {
+	raw      string
+	resolved string
+}
+
+// LoadSecretKey 读取加密密钥；两者都未配置时返回 nil, nil
+func LoadSecretKey() ([]byte, error) {
+	encoded := strings.TrimSpace(os.Getenv(SecretKeyEnv))
+	source := SecretKeyEnv
+	if encoded == "" {
+		path := strings.TrimSpace(os.Getenv(SecretKeyFileEnv))
+		if path == "" {
+			return nil, nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret key file: %w", err)
+		}
+		encoded = strings.TrimSpace(string(data))
+		source = path
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("invalid secret key from %s: must be 32 bytes, base64 or hex encoded", source)
+}
+
+// EncryptSecret 使用 AES-256-GCM 加密，返回 enc: 前缀的密文
+func EncryptSecret(plain string, key []byte) (string, error) {
+	gcm, err := newSecretGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 enc: 前缀的密文
+func DecryptSecret(value string, key []byte) (string, error) {
+	if !strings.HasPrefix(value, encryptedSecretPrefix) {
+		return "", fmt.Errorf("value is not encrypted")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedSecretPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	gcm, err := newSecretGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value: too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value (wrong key?): %w", err)
+	}
+	return string(plain), nil
+}
+
+// IsSecretReference 判断值是否为密文或环境变量引用（可以原样写回磁盘）
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, encryptedSecretPrefix) || envRefPattern.MatchString(value)
+}
+
+func newSecretGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// secretFields 返回所有敏感字段的指针，key 为字段路径（模型按ID定位，避免增删模型后错位）
+func secretFields(cfg *Config) map[string]*string {
+	fields := map[string]*string{
+		"database.password": &cfg.Database.Password,
+		"redis.password":    &cfg.Redis.Password,
+		"jwt.secret":        &cfg.JWT.Secret,
+		"llm.api_key":       &cfg.LLM.APIKey,
+	}
+	for i := range cfg.LLM.Models {
+		fields[fmt.Sprintf("llm.models[%s].api_key", cfg.LLM.Models[i].ID)] = &cfg.LLM.Models[i].APIKey
+	}
+	// webhook 地址中常带有访问令牌
+	for i := range cfg.Reports.Schedules {
+		fields[fmt.Sprintf("reports.schedules[%s].webhook", cfg.Reports.Schedules[i].Name)] = &cfg.Reports.Schedules[i].Webhook
+	}
+	return fields
+}
+
+// resolveSecrets 解析密文与环境变量引用，并记录原始写法（包括文件中已有的明文）
+func resolveSecrets(cfg *Config) error {
+	var key []byte
+	keyLoaded := false
+	cfg.secretRefs = make(map[string]secretRef)
+
+	for path, field := range secretFields(cfg) {
+		raw := *field
+		var resolved string
+		switch {
+		case strings.HasPrefix(raw, encryptedSecretPrefix):
+			if !keyLoaded {
+				var err error
+				if key, err = LoadSecretKey(); err != nil {
+					return err
+				}
+				keyLoaded = true
+			}
+			if key == nil {
+				return fmt.Errorf("%s: %w", path, ErrSecretKeyMissing)
+			}
+			plain, err := DecryptSecret(raw, key)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			resolved = plain
+		case envRefPattern.MatchString(raw):
+			name := envRefPattern.FindStringSubmatch(raw)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return fmt.Errorf("%s: environment variable %s is not set", path, name)
+			}
+			resolved = value
+		case raw == "":
+			continue
+		default:
+			// 明文不需要解析，只记录原值
+			cfg.secretRefs[path] = secretRef{raw: raw, resolved: raw}
+			continue
+		}
+		*field = resolved
+		cfg.secretRefs[path] = secretRef{raw: raw, resolved: resolved}
+	}
+	return nil
+}
+
+// protectSecrets 将待写盘配置中的敏感字段替换为安全写法：
+// 未修改的字段还原为原始密文/环境变量引用；新值与文件中已有的明文在配置了密钥时加密。
+// 未配置密钥时文件中已有的明文原样保留（兼容旧配置），新值返回 ErrSecretKeyRequired，不写盘。
+// 返回本次新生成的密文记录，写盘成功后合并到原配置。
+func protectSecrets(out *Config, refs map[string]secretRef) (map[string]secretRef, error) {
+	var key []byte
+	keyLoaded := false
+	created := make(map[string]secretRef)
+
+	for path, field := range secretFields(out) {
+		value := *field
+		if value == "" || IsSecretReference(value) {
+			continue
+		}
+		ref, known := refs[path]
+		if known && ref.resolved == value && IsSecretReference(ref.raw) {
+			*field = ref.raw
+			continue
+		}
+		if !keyLoaded {
+			var err error
+			if key, err = LoadSecretKey(); err != nil {
+				return nil, err
+			}
+			keyLoaded = true
+		}
+		if key == nil {
+			if known && ref.raw == value {
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", path, ErrSecretKeyRequired)
+		}
+		encrypted, err := EncryptSecret(value, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		*field = encrypted
+		created[path] = secretRef{raw: encrypted, resolved: value}
+	}
+	return created, nil
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSecretKey(t *testing.T) []byte {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	t.Setenv(SecretKeyEnv, base64.StdEncoding.EncodeToString(key))
+	t.Setenv(SecretKeyFileEnv, "")
+	return key
+}
+
+func TestEncryptDecryptSecret(t *testing.T) {
+	key := testSecretKey(t)
+
+	encrypted, err := EncryptSecret("sk-secret", key)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:"))
+	assert.NotContains(t, encrypted, "sk-secret")
+
+	plain, err := DecryptSecret(encrypted, key)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", plain)
+
+	_, err = DecryptSecret(encrypted, []byte("fedcba9876543210fedcba9876543210"))
+	assert.Error(t, err)
+}
+
+func TestLoadSecretKey_File(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "")
+	keyFile := filepath.Join(t.TempDir(), "secret.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)+"\n"), 0600))
+	t.Setenv(SecretKeyFileEnv, keyFile)
+
+	key, err := LoadSecretKey()
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+}
+
+func TestLoadSecretKey_Invalid(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "too-short")
+	_, err := LoadSecretKey()
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_ResolvesSecrets(t *testing.T) {
+	key := testSecretKey(t)
+	encrypted, err := EncryptSecret("db-pass", key)
+	require.NoError(t, err)
+	t.Setenv("TEST_JWT_SECRET", "jwt-from-env")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  password: "`+encrypted+`"
+jwt:
+  secret: "${TEST_JWT_SECRET}"
+llm:
+  models:
+    - id: m1
+      api_key: plain-key
+`), 0600))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "db-pass", cfg.Database.Password)
+	assert.Equal(t, "jwt-from-env", cfg.JWT.Secret)
+	assert.Equal(t, "plain-key", cfg.LLM.Models[0].APIKey)
+}
+
+func TestLoadConfig_EncryptedWithoutKey(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "")
+	t.Setenv(SecretKeyFileEnv, "")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("jwt:\n  secret: \"enc:AAAA\"\n"), 0600))
+
+	_, err := LoadConfig(path)
+	assert.ErrorIs(t, err, ErrSecretKeyMissing)
+}
+
+func TestLoadConfig_MissingEnvReference(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("jwt:\n  secret: \"${TEST_UNSET_SECRET_VAR}\"\n"), 0600))
+
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TEST_UNSET_SECRET_VAR")
+}
+
+func TestSaveConfig_NeverWritesDecryptedSecrets(t *testing.T) {
+	key := testSecretKey(t)
+	encrypted, err := EncryptSecret("db-pass", key)
+	require.NoError(t, err)
+	t.Setenv("TEST_JWT_SECRET", "jwt-from-env")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  password: "`+encrypted+`"
+jwt:
+  secret: "${TEST_JWT_SECRET}"
+llm:
+  models:
+    - id: m1
+      api_key: plain-key
+`), 0600))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	cfg.LLM.Models = append(cfg.LLM.Models, LLMModelConfig{ID: "m2", APIKey: "new-key"})
+
+	require.NoError(t, SaveConfig(path, cfg))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, encrypted)
+	assert.Contains(t, content, "${TEST_JWT_SECRET}")
+	assert.NotContains(t, content, "db-pass")
+	assert.NotContains(t, content, "jwt-from-env")
+	assert.NotContains(t, content, "plain-key")
+	assert.NotContains(t, content, "new-key")
+
+	// 内存中的配置保持明文，重新加载后解析一致
+	assert.Equal(t, "new-key", cfg.LLM.Models[1].APIKey)
+	reloaded, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "db-pass", reloaded.Database.Password)
+	assert.Equal(t, "plain-key", reloaded.LLM.Models[0].APIKey)
+	assert.Equal(t, "new-key", reloaded.LLM.Models[1].APIKey)
+}
+
+func TestSaveConfig_WithoutKeyRefusesNewPlaintext(t *testing.T) {
+	t.Setenv(SecretKeyEnv, "")
+	t.Setenv(SecretKeyFileEnv, "")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("llm:\n  api_key: legacy-key\n"), 0600))
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	// 文件中已有的明文原样保留
+	cfg.LLM.Timeout = 90
+	require.NoError(t, SaveConfig(path, cfg))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "legacy-key")
+
+	// 新的明文拒绝写盘，文件保持不变
+	cfg.LLM.APIKey = "new-key"
+	err = SaveConfig(path, cfg)
+	assert.ErrorIs(t, err, ErrSecretKeyRequired)
+	assert.Contains(t, err.Error(), SecretKeyEnv)
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, after)
+
+	// 环境变量引用可以保存
+	cfg.LLM.APIKey = "${TEST_LLM_KEY}"
+	require.NoError(t, SaveConfig(path, cfg))
+}
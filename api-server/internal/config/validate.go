@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	validServerModes = []string{"debug", "release", "test"}
+	validLogLevels   = []string{"debug", "info", "warn", "error"}
+	validLogFormats  = []string{"json", "text"}
+)
+
+// ValidationError 配置校验错误，汇总所有问题一次性返回
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate 校验配置，启动时调用以尽早暴露错误
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !validPort(c.Server.Port) {
+		addf("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if !contains(validServerModes, c.Server.Mode) {
+		addf("server.mode must be one of %s, got %q", strings.Join(validServerModes, "/"), c.Server.Mode)
+	}
+
+	if c.Server.ReloadInterval < 0 {
+		addf("server.reload_interval must not be negative, got %d", c.Server.ReloadInterval)
+	}
+
+	if c.Database.Host == "" {
+		addf("database.host is required")
+	}
+	if !validPort(c.Database.Port) {
+		addf("database.port must be between 1 and 65535, got %d", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		addf("database.user is required")
+	}
+	if c.Database.Database == "" {
+		addf("database.database is required")
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		addf("database.max_open_conns must be positive, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 {
+		addf("database.max_idle_conns must not be negative, got %d", c.Database.MaxIdleConns)
+	} else if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		addf("database.max_idle_conns (%d) must not exceed max_open_conns (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+
+	if c.Redis.Enabled {
+		if c.Redis.Host == "" {
+			addf("redis.host is required when redis is enabled")
+		}
+		if !validPort(c.Redis.Port) {
+			addf("redis.port must be between 1 and 65535, got %d", c.Redis.Port)
+		}
+		if c.Redis.DB < 0 {
+			addf("redis.db must not be negative, got %d", c.Redis.DB)
+		}
+	}
+
+	if !contains(validLogLevels, c.Log.Level) {
+		addf("log.level must be one of %s, got %q", strings.Join(validLogLevels, "/"), c.Log.Level)
+	}
+	if !contains(validLogFormats, c.Log.Format) {
+		addf("log.format must be one of %s, got %q", strings.Join(validLogFormats, "/"), c.Log.Format)
+	}
+	if c.Log.MaxSizeMB < 0 || c.Log.MaxBackups < 0 || c.Log.MaxAgeDays < 0 {
+		addf("log.max_size_mb, log.max_backups and log.max_age_days must not be negative")
+	}
+	if c.Log.SlowQueryMs < 0 {
+		addf("log.slow_query_ms must not be negative, got %d", c.Log.SlowQueryMs)
+	}
+
+	if strings.TrimSpace(c.JWT.Secret) == "" {
+		addf("jwt.secret is required (set it in the config file or %sJWT_SECRET)", EnvPrefix)
+	}
+	if c.JWT.ExpireMinutes <= 0 {
+		addf("jwt.expire_minutes must be positive, got %d", c.JWT.ExpireMinutes)
+	}
+
+	if c.LLM.Timeout <= 0 {
+		addf("llm.timeout must be positive, got %d", c.LLM.Timeout)
+	}
+	if c.LLM.BatchConcurrency <= 0 {
+		addf("llm.batch_concurrency must be positive, got %d", c.LLM.BatchConcurrency)
+	}
+	seen := make(map[string]bool, len(c.LLM.Models))
+	for i, m := range c.LLM.Models {
+		id := strings.TrimSpace(m.ID)
+		if id == "" {
+			addf("llm.models[%d].id is required", i)
+			continue
+		}
+		if seen[id] {
+			addf("llm.models[%d].id %q is duplicated", i, id)
+		}
+		seen[id] = true
+		if m.Timeout < 0 {
+			addf("llm.models[%s].timeout must not be negative, got %d", id, m.Timeout)
+		}
+		if m.MaxTokens < 0 {
+			addf("llm.models[%s].max_tokens must not be negative, got %d", id, m.MaxTokens)
+		}
+	}
+	if c.LLM.DefaultModelID != "" && len(c.LLM.Models) > 0 && !seen[c.LLM.DefaultModelID] {
+		addf("llm.default_model_id %q does not match any model", c.LLM.DefaultModelID)
+	}
+
+	if c.Cache.JobsTTLSeconds < 0 || c.Cache.MetricsTTLSeconds < 0 {
+		addf("cache ttl must not be negative, got jobs=%d metrics=%d", c.Cache.JobsTTLSeconds, c.Cache.MetricsTTLSeconds)
+	}
+	if c.Metrics.CacheSeconds < 0 {
+		addf("metrics.cache_seconds must not be negative, got %d", c.Metrics.CacheSeconds)
+	}
+	if c.Metrics.NPUStaleMinutes <= 0 {
+		addf("metrics.npu_stale_minutes must be positive, got %d", c.Metrics.NPUStaleMinutes)
+	}
+	if c.JobGroups.SyncIntervalSeconds <= 0 {
+		addf("job_groups.sync_interval_seconds must be positive, got %d", c.JobGroups.SyncIntervalSeconds)
+	}
+	if c.Export.ChunkSize < 0 || c.Export.RetentionHours < 0 {
+		addf("export chunk_size and retention_hours must not be negative, got chunk_size=%d retention_hours=%d",
+			c.Export.ChunkSize, c.Export.RetentionHours)
+	}
+
+	if c.Reports.CheckIntervalSeconds < 0 {
+		addf("reports.check_interval_seconds must not be negative, got %d", c.Reports.CheckIntervalSeconds)
+	}
+	names := make(map[string]bool, len(c.Reports.Schedules))
+	for i, s := range c.Reports.Schedules {
+		name := strings.TrimSpace(s.Name)
+		if name == "" {
+			addf("reports.schedules[%d].name is required", i)
+			continue
+		}
+		if names[name] {
+			addf("reports.schedules[%d].name %q is duplicated", i, name)
+		}
+		names[name] = true
+		if err := ValidateReportSchedule(s); err != nil {
+			addf("reports.schedules[%s]: %v", name, err)
+		}
+	}
+
+	in := c.Insights
+	if in.LookbackMinutes <= 0 {
+		addf("insights.lookback_minutes must be positive, got %d", in.LookbackMinutes)
+	}
+	if in.MinRunningMinutes < 0 {
+		addf("insights.min_running_minutes must not be negative, got %d", in.MinRunningMinutes)
+	}
+	for _, p := range []struct {
+		name  string
+		value int
+	}{
+		{"idle_aicore_percent", in.IdleAICorePercent},
+		{"idle_hbm_percent", in.IdleHBMPercent},
+		{"low_aicore_percent", in.LowAICorePercent},
+		{"imbalance_spread_percent", in.ImbalanceSpreadPercent},
+	} {
+		if p.value < 0 || p.value > 100 {
+			addf("insights.%s must be between 0 and 100, got %d", p.name, p.value)
+		}
+	}
+	if in.IdleAICorePercent > in.LowAICorePercent {
+		addf("insights.idle_aicore_percent (%d) must not exceed low_aicore_percent (%d)", in.IdleAICorePercent, in.LowAICorePercent)
+	}
+
+	for i, r := range c.Accounting.OwnerRules {
+		if err := ValidateOwnerRule(r); err != nil {
+			addf("accounting.owner_rules[%d]: %v", i, err)
+		}
+	}
+
+	if c.Projects.SyncIntervalSeconds <= 0 {
+		addf("projects.sync_interval_seconds must be positive, got %d", c.Projects.SyncIntervalSeconds)
+	}
+	for i, name := range c.Projects.Admins {
+		if strings.TrimSpace(name) == "" {
+			addf("projects.admins[%d] must not be empty", i)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportFormats 报表支持的输出格式
+var ReportFormats = []string{"markdown", "html", "csv"}
+
+// ValidateReportSchedule 校验报表计划的 cron 表达式、统计窗口与输出格式；配置文件与接口创建的计划共用
+func ValidateReportSchedule(s ReportScheduleConfig) error {
+	if _, err := cron.ParseStandard(s.Cron); err != nil {
+		return fmt.Errorf("invalid cron %q: %w", s.Cron, err)
+	}
+	if s.PeriodDays < 0 || s.PeriodDays > 366 {
+		return fmt.Errorf("period_days must be between 0 and 366, got %d", s.PeriodDays)
+	}
+	if s.TopN < 0 {
+		return fmt.Errorf("top_n must not be negative, got %d", s.TopN)
+	}
+	for _, f := range s.Formats {
+		if !contains(ReportFormats, f) {
+			return fmt.Errorf("unsupported format %q", f)
+		}
+	}
+	if s.Webhook != "" {
+		u, err := url.Parse(s.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook must be an http(s) URL")
+		}
+	}
+	return nil
+}
+
+// OwnerRuleFields 归属映射规则支持的匹配字段，另外支持 env:<变量名>
+var OwnerRuleFields = []string{"user", "cwd", "command_line", "job_name", "node"}
+
+// ValidateOwnerRule 校验归属映射规则的匹配字段与正则表达式
+func ValidateOwnerRule(r OwnerRuleConfig) error {
+	if !contains(OwnerRuleFields, r.Field) && (!strings.HasPrefix(r.Field, "env:") || r.Field == "env:") {
+		return fmt.Errorf("unsupported field %q", r.Field)
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+	if r.Owner == "" && r.Project == "" {
+		return fmt.Errorf("owner or project is required")
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+// Package exporter 将集群 NPU 状态与作业统计导出为 Prometheus 指标（/metrics/cluster）
+package exporter
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/metrics"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// NPUMetricsSource 提供全集群最新 NPU 指标
+type NPUMetricsSource interface {
+	GetLatestNPUMetrics(maxAge time.Duration) ([]model.NPUMetric, error)
+}
+
+// JobCountsSource 提供按状态/类型/框架聚合的作业组数量
+type JobCountsSource interface {
+	GetJobGroupCounts(scope service.ProjectFilter) ([]service.JobGroupCount, error)
+}
+
+var (
+	chipLabels = []string{"node_id", "npu_id", "chip", "name"}
+
+	aicoreDesc      = newDesc("npu", "aicore_usage_percent", "AICore utilization of the NPU chip.", chipLabels...)
+	hbmUsageDesc    = newDesc("npu", "hbm_usage_bytes", "HBM used by the NPU chip.", chipLabels...)
+	hbmTotalDesc    = newDesc("npu", "hbm_total_bytes", "HBM capacity of the NPU chip.", chipLabels...)
+	memUsageDesc    = newDesc("npu", "memory_usage_bytes", "On-chip memory used by the NPU chip.", chipLabels...)
+	memTotalDesc    = newDesc("npu", "memory_total_bytes", "On-chip memory capacity of the NPU chip.", chipLabels...)
+	powerDesc       = newDesc("npu", "power_watts", "Power draw of the NPU chip.", chipLabels...)
+	tempDesc        = newDesc("npu", "temperature_celsius", "Temperature of the NPU chip.", chipLabels...)
+	healthyDesc     = newDesc("npu", "healthy", "1 if the NPU chip reports health OK, 0 otherwise.", chipLabels...)
+	healthDesc      = newDesc("npu", "health_info", "Health state reported by the NPU chip (always 1).", append(chipLabels, "health")...)
+	reportedAtDesc  = newDesc("npu", "last_report_timestamp_seconds", "Unix time of the latest sample for the NPU chip.", chipLabels...)
+	jobGroupsDesc   = newDesc("jobs", "groups", "Number of job groups by status, job type and framework.", "status", "job_type", "framework")
+	refreshOKDesc   = newDesc("cluster_exporter", "refresh_success", "1 if the latest snapshot refresh succeeded.")
+	refreshedAtDesc = newDesc("cluster_exporter", "last_refresh_timestamp_seconds", "Unix time of the latest successful snapshot refresh.")
+	staleDesc       = newDesc("cluster_exporter", "snapshot_stale", "1 if the exported snapshot is left over from before a failed refresh.")
+)
+
+func newDesc(subsystem, name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, subsystem, name), help, labels, nil)
+}
+
+// snapshot 一次从数据库读取的集群状态
+type snapshot struct {
+	npuMetrics []model.NPUMetric
+	jobCounts  []service.JobGroupCount
+	at         time.Time
+}
+
+// ClusterCollector 集群状态采集器，实现 prometheus.Collector。
+// 采集结果在 cache_seconds 内复用，多个 Prometheus 实例或高频抓取不会放大数据库压力。
+type ClusterCollector struct {
+	npuSource NPUMetricsSource
+	jobSource JobCountsSource
+	now       func() time.Time
+
+	mu         sync.Mutex
+	cacheTTL   time.Duration
+	staleAfter time.Duration
+	cached     *snapshot
+	lastErr    error
+	failedAt   time.Time // 最近一次刷新失败的时间，cache_seconds 内不再重试
+}
+
+// NewClusterCollector 创建集群状态采集器
+func NewClusterCollector(npuSource NPUMetricsSource, jobSource JobCountsSource, cfg config.MetricsConfig) *ClusterCollector {
+	c := &ClusterCollector{
+		npuSource: npuSource,
+		jobSource: jobSource,
+		now:       time.Now,
+	}
+	c.ApplyConfig(cfg)
+	return c
+}
+
+// ApplyConfig 更新缓存时长与 NPU 过期时间，支持热加载
+func (c *ClusterCollector) ApplyConfig(cfg config.MetricsConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cacheTTL = time.Duration(cfg.CacheSeconds) * time.Second
+	c.staleAfter = time.Duration(cfg.NPUStaleMinutes) * time.Minute
+}
+
+// Handler 返回独立注册表的指标输出，与 API Server 自身的 /metrics 分开抓取
+func (c *ClusterCollector) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Describe 实现 prometheus.Collector
+func (c *ClusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		aicoreDesc, hbmUsageDesc, hbmTotalDesc, memUsageDesc, memTotalDesc, powerDesc, tempDesc,
+		healthyDesc, healthDesc, reportedAtDesc, jobGroupsDesc, refreshOKDesc, refreshedAtDesc, staleDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect 实现 prometheus.Collector；刷新失败时继续输出上一次成功的快照，并标记为过期
+func (c *ClusterCollector) Collect(ch chan<- prometheus.Metric) {
+	snap, err := c.load()
+
+	ok, stale := 1.0, 0.0
+	if err != nil {
+		ok, stale = 0, 1
+	}
+	ch <- prometheus.MustNewConstMetric(refreshOKDesc, prometheus.GaugeValue, ok)
+	if snap == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(refreshedAtDesc, prometheus.GaugeValue, unixSeconds(snap.at))
+	ch <- prometheus.MustNewConstMetric(staleDesc, prometheus.GaugeValue, stale)
+
+	for _, m := range snap.npuMetrics {
+		collectNPUMetric(ch, m)
+	}
+	for _, jc := range snap.jobCounts {
+		ch <- prometheus.MustNewConstMetric(jobGroupsDesc, prometheus.GaugeValue, float64(jc.Count),
+			jc.Status, jc.JobType, jc.Framework)
+	}
+}
+
+// load 返回缓存快照，过期时同步刷新；并发抓取共享同一次刷新。
+// 刷新失败后在 cache_seconds 内不再访问数据库，直接返回上一次成功的快照与失败原因。
+func (c *ClusterCollector) load() (*snapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.lastErr != nil {
+		if now.Sub(c.failedAt) < c.cacheTTL {
+			return c.cached, c.lastErr
+		}
+	} else if c.cached != nil && now.Sub(c.cached.at) < c.cacheTTL {
+		return c.cached, nil
+	}
+
+	npuMetrics, err := c.npuSource.GetLatestNPUMetrics(c.staleAfter)
+	if err == nil {
+		var jobCounts []service.JobGroupCount
+		jobCounts, err = c.jobSource.GetJobGroupCounts(service.ProjectFilter{})
+		if err == nil {
+			c.cached = &snapshot{npuMetrics: dedupNPUMetrics(npuMetrics), jobCounts: jobCounts, at: now}
+		}
+	}
+	if err != nil {
+		slog.Warn("cluster metrics refresh failed", "error", err)
+		c.failedAt = now
+	}
+	c.lastErr = err
+	return c.cached, err
+}
+
+// npuSeriesKey 芯片指标的标签组合，与 collectNPUMetric 输出的标签一致
+type npuSeriesKey struct {
+	nodeID, npuID, busID, name string
+}
+
+// dedupNPUMetrics 按标签组合去重：同一芯片在最新时间戳上有多条记录时保留 ID 最大的一条，
+// 否则同名同标签的序列重复输出会导致整次抓取失败
+func dedupNPUMetrics(metrics []model.NPUMetric) []model.NPUMetric {
+	index := make(map[npuSeriesKey]int, len(metrics))
+	result := make([]model.NPUMetric, 0, len(metrics))
+	for _, m := range metrics {
+		if m.NodeID == nil || m.NPUID == nil {
+			continue
+		}
+		key := npuSeriesKey{*m.NodeID, strconv.Itoa(*m.NPUID), deref(m.BusID), deref(m.Name)}
+		if i, ok := index[key]; ok {
+			prev := result[i]
+			if m.Timestamp.After(prev.Timestamp) || (m.Timestamp.Equal(prev.Timestamp) && m.ID > prev.ID) {
+				result[i] = m
+			}
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, m)
+	}
+	return result
+}
+
+func collectNPUMetric(ch chan<- prometheus.Metric, m model.NPUMetric) {
+	if m.NodeID == nil || m.NPUID == nil {
+		return
+	}
+	labels := []string{*m.NodeID, strconv.Itoa(*m.NPUID), deref(m.BusID), deref(m.Name)}
+
+	gauge := func(desc *prometheus.Desc, v *float64, scale float64) {
+		if v != nil {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, *v*scale, labels...)
+		}
+	}
+	const mb = 1024 * 1024
+	gauge(aicoreDesc, m.AICoreUsagePercent, 1)
+	gauge(hbmUsageDesc, m.HBMUsageMB, mb)
+	gauge(hbmTotalDesc, m.HBMTotalMB, mb)
+	gauge(memUsageDesc, m.MemoryUsageMB, mb)
+	gauge(memTotalDesc, m.MemoryTotalMB, mb)
+	gauge(powerDesc, m.PowerW, 1)
+	gauge(tempDesc, m.TempC, 1)
+
+	if m.Health != nil {
+		healthy := 0.0
+		if *m.Health == "OK" {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(healthyDesc, prometheus.GaugeValue, healthy, labels...)
+		ch <- prometheus.MustNewConstMetric(healthDesc, prometheus.GaugeValue, 1, append(labels, *m.Health)...)
+	}
+	ch <- prometheus.MustNewConstMetric(reportedAtDesc, prometheus.GaugeValue, unixSeconds(m.Timestamp), labels...)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
@@ -0,0 +1,153 @@
+package exporter
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+type fakeNPUSource struct {
+	metrics []model.NPUMetric
+	err     error
+	calls   int
+	maxAge  time.Duration
+}
+
+func (f *fakeNPUSource) GetLatestNPUMetrics(maxAge time.Duration) ([]model.NPUMetric, error) {
+	f.calls++
+	f.maxAge = maxAge
+	return f.metrics, f.err
+}
+
+type fakeJobSource struct {
+	counts []service.JobGroupCount
+	calls  int
+}
+
+func (f *fakeJobSource) GetJobGroupCounts(scope service.ProjectFilter) ([]service.JobGroupCount, error) {
+	f.calls++
+	return f.counts, nil
+}
+
+func strPtr(s string) *string     { return &s }
+func intPtr(i int) *int           { return &i }
+func floatPtr(f float64) *float64 { return &f }
+
+func scrape(t *testing.T, c *ClusterCollector) string {
+	w := httptest.NewRecorder()
+	c.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics/cluster", nil))
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func newTestCollector() (*ClusterCollector, *fakeNPUSource, *fakeJobSource, *time.Time) {
+	npu := &fakeNPUSource{metrics: []model.NPUMetric{{
+		NodeID:             strPtr("node-001"),
+		NPUID:              intPtr(3),
+		BusID:              strPtr("0000:C1:00.0"),
+		Name:               strPtr("910B3"),
+		Health:             strPtr("Warning"),
+		AICoreUsagePercent: floatPtr(87.5),
+		HBMUsageMB:         floatPtr(1024),
+		HBMTotalMB:         floatPtr(65536),
+		PowerW:             floatPtr(310),
+		TempC:              floatPtr(55),
+		Timestamp:          time.Unix(1770373780, 0),
+	}}}
+	jobs := &fakeJobSource{counts: []service.JobGroupCount{
+		{Status: "running", JobType: "training", Framework: "pytorch", Count: 4},
+	}}
+	now := time.Unix(1770373800, 0)
+	c := NewClusterCollector(npu, jobs, config.MetricsConfig{CacheSeconds: 30, NPUStaleMinutes: 10})
+	c.now = func() time.Time { return now }
+	return c, npu, jobs, &now
+}
+
+func TestClusterCollector_Exports(t *testing.T) {
+	c, npu, _, _ := newTestCollector()
+
+	body := scrape(t, c)
+
+	chip := `chip="0000:C1:00.0",name="910B3",node_id="node-001",npu_id="3"`
+	assert.Contains(t, body, `task_monitor_npu_aicore_usage_percent{`+chip+`} 87.5`)
+	assert.Contains(t, body, `task_monitor_npu_hbm_usage_bytes{`+chip+`} 1.073741824e+09`)
+	assert.Contains(t, body, `task_monitor_npu_power_watts{`+chip+`} 310`)
+	assert.Contains(t, body, `task_monitor_npu_temperature_celsius{`+chip+`} 55`)
+	assert.Contains(t, body, `task_monitor_npu_healthy{`+chip+`} 0`)
+	assert.Contains(t, body, `task_monitor_npu_health_info{chip="0000:C1:00.0",health="Warning",name="910B3",node_id="node-001",npu_id="3"} 1`)
+	assert.Contains(t, body, `task_monitor_jobs_groups{framework="pytorch",job_type="training",status="running"} 4`)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_refresh_success 1`)
+	// 未上报的字段不导出
+	assert.NotContains(t, body, `task_monitor_npu_memory_usage_bytes{`)
+	assert.Equal(t, 10*time.Minute, npu.maxAge)
+}
+
+func TestClusterCollector_CachesBetweenScrapes(t *testing.T) {
+	c, npu, jobs, now := newTestCollector()
+
+	scrape(t, c)
+	*now = now.Add(10 * time.Second)
+	scrape(t, c)
+	assert.Equal(t, 1, npu.calls)
+	assert.Equal(t, 1, jobs.calls)
+
+	*now = now.Add(30 * time.Second)
+	scrape(t, c)
+	assert.Equal(t, 2, npu.calls)
+	assert.Equal(t, 2, jobs.calls)
+}
+
+func TestClusterCollector_KeepsLastSnapshotOnError(t *testing.T) {
+	c, npu, _, now := newTestCollector()
+	body := scrape(t, c)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_snapshot_stale 0`)
+
+	npu.err = errors.New("db down")
+	*now = now.Add(time.Minute)
+	body = scrape(t, c)
+
+	assert.Equal(t, 2, npu.calls)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_refresh_success 0`)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_snapshot_stale 1`)
+	assert.Contains(t, body, `task_monitor_jobs_groups{framework="pytorch",job_type="training",status="running"} 4`)
+
+	// 失败后 cache_seconds 内继续输出旧快照，不再访问数据库
+	npu.err = nil
+	*now = now.Add(10 * time.Second)
+	body = scrape(t, c)
+	assert.Equal(t, 2, npu.calls)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_refresh_success 0`)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_snapshot_stale 1`)
+
+	// 距失败超过 cache_seconds 后重试
+	*now = now.Add(20 * time.Second)
+	body = scrape(t, c)
+	assert.Equal(t, 3, npu.calls)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_refresh_success 1`)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_snapshot_stale 0`)
+}
+
+func TestClusterCollector_DedupsSameChip(t *testing.T) {
+	c, npu, _, _ := newTestCollector()
+	dup := npu.metrics[0]
+	dup.ID = 9
+	dup.AICoreUsagePercent = floatPtr(42)
+	npu.metrics = append(npu.metrics, dup)
+
+	body := scrape(t, c)
+
+	chip := `chip="0000:C1:00.0",name="910B3",node_id="node-001",npu_id="3"`
+	assert.Contains(t, body, `task_monitor_npu_aicore_usage_percent{`+chip+`} 42`)
+	assert.NotContains(t, body, `task_monitor_npu_aicore_usage_percent{`+chip+`} 87.5`)
+	assert.Contains(t, body, `task_monitor_cluster_exporter_refresh_success 1`)
+}
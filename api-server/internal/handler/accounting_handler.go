@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// AccountingHandler 卡时核算处理器
+type AccountingHandler struct {
+	accountingService service.AccountingServiceInterface
+	projectService    service.ProjectServiceInterface
+}
+
+// NewAccountingHandler 创建卡时核算处理器
+func NewAccountingHandler(accountingService service.AccountingServiceInterface) *AccountingHandler {
+	return &AccountingHandler{accountingService: accountingService}
+}
+
+// SetProjectService 启用项目可见范围：只统计当前用户可见的作业
+func (h *AccountingHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
+// GetCardHours 按归属人、项目、框架或作业类型汇总卡时；groupBy 逗号分隔，可组合多个维度，缺省按归属人；
+// format=csv 时下载 CSV
+func (h *AccountingHandler) GetCardHours(c *gin.Context) {
+	from, to, ok := parseAccountingPeriod(c)
+	if !ok {
+		return
+	}
+	var groupBy []string
+	for _, dim := range strings.Split(c.Query("groupBy"), ",") {
+		if dim = strings.TrimSpace(dim); dim != "" {
+			groupBy = append(groupBy, dim)
+		}
+	}
+	format, ok := parseAccountingFormat(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	report, err := h.accountingService.GetCardHours(c.Request.Context(), from, to, dedupeStrings(groupBy), scope)
+	if err != nil {
+		respondAccountingError(c, err)
+		return
+	}
+	if format == "" {
+		utils.SuccessResponse(c, report)
+		return
+	}
+	content, err := service.RenderCardHoursCSV(report)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to render csv: "+err.Error())
+		return
+	}
+	writeAccountingCSV(c, "card_hours", from, to, content)
+}
+
+// GetCardHourRecords 列出统计区间内每个作业分组的卡时与归属；format=csv 时下载 CSV
+func (h *AccountingHandler) GetCardHourRecords(c *gin.Context) {
+	from, to, ok := parseAccountingPeriod(c)
+	if !ok {
+		return
+	}
+	format, ok := parseAccountingFormat(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	records, err := h.accountingService.ListCardHourRecords(c.Request.Context(), from, to, scope)
+	if err != nil {
+		respondAccountingError(c, err)
+		return
+	}
+	if format == "" {
+		utils.SuccessResponse(c, records)
+		return
+	}
+	content, err := service.RenderCardHourRecordsCSV(records)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to render csv: "+err.Error())
+		return
+	}
+	writeAccountingCSV(c, "card_hour_records", from, to, content)
+}
+
+// parseAccountingPeriod 解析统计区间，from 缺省为本月 1 日零点，to 缺省为当前时间
+func parseAccountingPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	query := c.Request.URL.Query()
+	fromMs, err := parseTimeParam(query, "from")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return time.Time{}, time.Time{}, false
+	}
+	toMs, err := parseTimeParam(query, "to")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return time.Time{}, time.Time{}, false
+	}
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if fromMs != nil {
+		from = time.UnixMilli(*fromMs)
+	}
+	to := now
+	if toMs != nil {
+		to = time.UnixMilli(*toMs)
+	}
+	return from, to, true
+}
+
+func parseAccountingFormat(c *gin.Context) (string, bool) {
+	format := c.Query("format")
+	if format != "" && format != "csv" {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("unsupported format %q", format))
+		return "", false
+	}
+	return format, true
+}
+
+func writeAccountingCSV(c *gin.Context, name string, from, to time.Time, content []byte) {
+	filename := fmt.Sprintf("%s_%s_%s.csv", name, from.Format("20060102"), to.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", content)
+}
+
+func respondAccountingError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidAccountingQuery) {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to compute card hours: "+err.Error())
+}
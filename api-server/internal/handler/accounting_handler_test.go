@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// MockAccountingService is a mock implementation of AccountingServiceInterface
+type MockAccountingService struct {
+	mock.Mock
+}
+
+func (m *MockAccountingService) GetCardHours(ctx context.Context, from, to time.Time, groupBy []string, scope service.ProjectFilter) (*service.CardHourReport, error) {
+	args := m.Called(ctx, from, to, groupBy, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CardHourReport), args.Error(1)
+}
+
+func (m *MockAccountingService) ListCardHourRecords(ctx context.Context, from, to time.Time, scope service.ProjectFilter) ([]service.CardHourRecord, error) {
+	args := m.Called(ctx, from, to, scope)
+	return args.Get(0).([]service.CardHourRecord), args.Error(1)
+}
+
+func TestAccountingHandler_GetCardHours_CSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAccountingService)
+	handler := NewAccountingHandler(mockService)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	report := &service.CardHourReport{From: from, To: to, GroupBy: []string{"owner", "project"},
+		Items: []service.CardHourUsage{{Owner: "alice", Project: "llm", Jobs: 2, CardHours: 96}}}
+	mockService.On("GetCardHours", mock.Anything, mock.MatchedBy(from.Equal), mock.MatchedBy(to.Equal), []string{"owner", "project"}, service.ProjectFilter{}).
+		Return(report, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/accounting/card-hours?from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z&groupBy=owner,project,owner&format=csv", nil)
+	handler.GetCardHours(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "card_hours_20260301_20260401.csv")
+	assert.Contains(t, w.Body.String(), "alice,llm,2,0,96.00")
+	mockService.AssertExpectations(t)
+}
+
+func TestAccountingHandler_BadRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAccountingService)
+	handler := NewAccountingHandler(mockService)
+	mockService.On("ListCardHourRecords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]service.CardHourRecord(nil), service.ErrInvalidAccountingQuery)
+
+	for _, query := range []string{"from=yesterday", "format=xlsx", "from=2026-04-01T00:00:00Z&to=2026-03-01T00:00:00Z"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/accounting/card-hours/jobs?"+query, nil)
+		handler.GetCardHourRecords(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockService.AssertNumberOfCalls(t, "ListCardHourRecords", 1)
+}
+
+func TestAccountingHandler_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockAccountingService)
+	handler := NewAccountingHandler(mockService)
+	handler.SetProjectService(newRestrictedProjectService())
+	// 汇总与明细都只统计可见范围内的作业
+	mockService.On("GetCardHours", mock.Anything, mock.Anything, mock.Anything, []string(nil), restrictedScope).
+		Return(&service.CardHourReport{Items: []service.CardHourUsage{{Owner: "alice", Jobs: 1, CardHours: 8}}}, nil)
+	mockService.On("ListCardHourRecords", mock.Anything, mock.Anything, mock.Anything, restrictedScope).
+		Return([]service.CardHourRecord{{JobID: "job-a"}}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/accounting/card-hours", nil)
+	handler.GetCardHours(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/accounting/card-hours/jobs", nil)
+	handler.GetCardHourRecords(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "job-a")
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/cache"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// CacheHandler 查询缓存管理
+type CacheHandler struct {
+	cache cache.Cache
+}
+
+// NewCacheHandler 创建缓存处理器
+func NewCacheHandler(c cache.Cache) *CacheHandler {
+	return &CacheHandler{cache: c}
+}
+
+// InvalidateCacheRequest 缓存失效请求；namespaces 为空时失效全部
+type InvalidateCacheRequest struct {
+	Namespaces []string `json:"namespaces"`
+}
+
+var cacheNamespaces = []string{cache.NamespaceJobs, cache.NamespaceMetrics}
+
+// InvalidateCache 失效查询缓存，供数据导入、回填任务写库后调用
+func (h *CacheHandler) InvalidateCache(c *gin.Context) {
+	var req InvalidateCacheRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+	}
+
+	namespaces := req.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = cacheNamespaces
+	}
+	for _, ns := range namespaces {
+		if !slices.Contains(cacheNamespaces, ns) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "unknown cache namespace: "+ns)
+			return
+		}
+	}
+
+	for _, ns := range namespaces {
+		if err := h.cache.InvalidateNamespace(c.Request.Context(), ns); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "invalidate cache failed: "+err.Error())
+			return
+		}
+	}
+	utils.SuccessResponse(c, gin.H{
+		"backend":     h.cache.Backend(),
+		"invalidated": namespaces,
+	})
+}
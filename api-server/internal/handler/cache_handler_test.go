@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-monitor/api-server/internal/cache"
+)
+
+func TestCacheHandler_InvalidateCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	c := cache.NewMemory()
+	jobKey := cache.Key(cache.NamespaceJobs, "stats")
+	metricKey := cache.Key(cache.NamespaceMetrics, "latest", "node-001", "0")
+	c.Set(ctx, jobKey, 1, time.Minute)
+	c.Set(ctx, metricKey, 1, time.Minute)
+	handler := NewCacheHandler(c)
+
+	w := httptest.NewRecorder()
+	gc, _ := gin.CreateTestContext(w)
+	gc.Request = httptest.NewRequest("POST", "/api/v1/cache/invalidate", strings.NewReader(`{"namespaces":["jobs"]}`))
+	gc.Request.Header.Set("Content-Type", "application/json")
+
+	handler.InvalidateCache(gc)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var v int
+	hit, _ := c.Get(ctx, jobKey, &v)
+	assert.False(t, hit)
+	hit, _ = c.Get(ctx, metricKey, &v)
+	assert.True(t, hit)
+
+	// 无请求体时失效全部命名空间
+	w = httptest.NewRecorder()
+	gc, _ = gin.CreateTestContext(w)
+	gc.Request = httptest.NewRequest("POST", "/api/v1/cache/invalidate", nil)
+
+	handler.InvalidateCache(gc)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	hit, _ = c.Get(ctx, metricKey, &v)
+	assert.False(t, hit)
+}
+
+func TestCacheHandler_InvalidateCache_UnknownNamespace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewCacheHandler(cache.NewMemory())
+
+	w := httptest.NewRecorder()
+	gc, _ := gin.CreateTestContext(w)
+	gc.Request = httptest.NewRequest("POST", "/api/v1/cache/invalidate", strings.NewReader(`{"namespaces":["users"]}`))
+	gc.Request.Header.Set("Content-Type", "application/json")
+
+	handler.InvalidateCache(gc)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
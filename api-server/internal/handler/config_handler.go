@@ -17,6 +17,7 @@ type ConfigHandler struct {
 	llmService service.LLMServiceInterface
 	config     *config.Config
 	configPath string
+	reloader   *config.Reloader
 	mu         sync.Mutex
 }
 
@@ -29,6 +30,33 @@ func NewConfigHandler(llmService service.LLMServiceInterface, cfg *config.Config
 	}
 }
 
+// SetReloader 设置配置热加载器，启用 ReloadConfig 接口
+func (h *ConfigHandler) SetReloader(r *config.Reloader) {
+	h.reloader = r
+}
+
+// SetConfig 热加载后替换配置，之后的保存基于新配置进行
+func (h *ConfigHandler) SetConfig(cfg *config.Config) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.config = cfg
+}
+
+// ReloadConfig 重新加载配置文件，返回已生效与需要重启的字段
+func (h *ConfigHandler) ReloadConfig(c *gin.Context) {
+	if h.reloader == nil {
+		utils.ErrorResponse(c, http.StatusNotImplemented, "config reload is not enabled")
+		return
+	}
+
+	result, err := h.reloader.Reload()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	utils.SuccessResponse(c, result)
+}
+
 // GetLLMConfig 获取LLM配置（API Key脱敏）
 func (h *ConfigHandler) GetLLMConfig(c *gin.Context) {
 	cfg := h.llmService.GetConfig()
@@ -95,17 +123,57 @@ func (h *ConfigHandler) UpdateLLMConfig(c *gin.Context) {
 		return
 	}
 
-	// 更新内存中的配置
-	h.config.LLM = llmCfg
+	// 先持久化到文件，成功后再更新内存中的配置，避免保存被拒绝时内存与文件不一致
+	candidate := *h.config
+	candidate.LLM = llmCfg
+	if err := config.SaveConfig(h.configPath, &candidate); err != nil {
+		if errors.Is(err, config.ErrSecretKeyRequired) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to save config file: "+err.Error())
+		return
+	}
+	*h.config = candidate
 	h.llmService.UpdateConfig(llmCfg)
 
-	// 持久化到文件
-	if err := config.SaveConfig(h.configPath, h.config); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "config updated in memory but failed to save to file: "+err.Error())
+	utils.SuccessResponse(c, h.llmService.GetConfig())
+}
+
+// TestLLMModel 测试指定模型的连通性（延迟、HTTP状态、模型名是否存在、是否返回可解析JSON）
+// 请求体可选：传入模型配置时按该配置测试（用于保存前验证），否则使用已保存的配置。
+func (h *ConfigHandler) TestLLMModel(c *gin.Context) {
+	tester, ok := h.llmService.(service.LLMModelTesterInterface)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusNotImplemented, "LLM service does not support model testing")
 		return
 	}
 
-	utils.SuccessResponse(c, h.llmService.GetConfig())
+	var override *config.LLMModelConfig
+	if c.Request.ContentLength > 0 {
+		var req config.LLMModelConfig
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if !service.IsSupportedLLMProvider(req.Provider) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "unsupported provider: "+req.Provider)
+			return
+		}
+		override = &req
+	}
+
+	result, err := tester.TestModel(c.Param("id"), override)
+	if err != nil {
+		if errors.Is(err, service.ErrLLMModelNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, result)
 }
 
 func mergeModelConfig(newModels, oldModels []config.LLMModelConfig) ([]config.LLMModelConfig, error) {
@@ -121,10 +189,14 @@ func mergeModelConfig(newModels, oldModels []config.LLMModelConfig) ([]config.LL
 		m.Name = strings.TrimSpace(m.Name)
 		m.Endpoint = strings.TrimSpace(m.Endpoint)
 		m.Model = strings.TrimSpace(m.Model)
+		m.Provider = service.NormalizeLLMProvider(m.Provider)
 
 		if m.ID == "" {
 			return nil, errors.New("model id is required")
 		}
+		if !service.IsSupportedLLMProvider(m.Provider) {
+			return nil, errors.New("unsupported provider for model " + m.ID + ": " + m.Provider)
+		}
 		if _, ok := seen[m.ID]; ok {
 			return nil, errors.New("duplicate model id: " + m.ID)
 		}
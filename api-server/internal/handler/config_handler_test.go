@@ -2,16 +2,21 @@ package handler
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/service"
 )
 
 func TestConfigHandler_GetLLMConfig(t *testing.T) {
@@ -52,6 +57,9 @@ func TestConfigHandler_GetLLMConfig(t *testing.T) {
 func TestConfigHandler_UpdateLLMConfig(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	t.Setenv(config.SecretKeyEnv, base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	t.Setenv(config.SecretKeyFileEnv, "")
+
 	// 创建临时配置文件
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.yaml")
@@ -107,14 +115,54 @@ func TestConfigHandler_UpdateLLMConfig(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, float64(200), response["code"])
 
-	// 验证文件已持久化
+	// 验证文件已持久化，API Key 加密保存
 	savedData, err := os.ReadFile(configPath)
 	assert.NoError(t, err)
 	assert.Contains(t, string(savedData), "new-model")
+	assert.NotContains(t, string(savedData), "new-key")
 
 	mockLLM.AssertExpectations(t)
 }
 
+func TestConfigHandler_UpdateLLMConfig_RefusesPlaintextSecretWithoutKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv(config.SecretKeyEnv, "")
+	t.Setenv(config.SecretKeyFileEnv, "")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	original := "server:\n  port: 8080\n"
+	os.WriteFile(configPath, []byte(original), 0644)
+
+	mockLLM := new(MockLLMService)
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: 8080},
+		LLM:    config.LLMConfig{Endpoint: "http://old:8000/v1", Timeout: 30},
+	}
+	h := NewConfigHandler(mockLLM, cfg, configPath)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"endpoint": "http://new:8000/v1",
+		"api_key":  "new-key",
+	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("PUT", "/api/v1/config/llm", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.UpdateLLMConfig(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), config.SecretKeyEnv)
+
+	// 文件与内存中的配置均保持不变，也不会通知 LLM 服务
+	savedData, err := os.ReadFile(configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, original, string(savedData))
+	assert.Equal(t, "http://old:8000/v1", cfg.LLM.Endpoint)
+	assert.Empty(t, cfg.LLM.APIKey)
+	mockLLM.AssertNotCalled(t, "UpdateConfig", mock.Anything)
+}
+
 func TestConfigHandler_UpdateLLMConfig_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -131,3 +179,131 @@ func TestConfigHandler_UpdateLLMConfig_InvalidBody(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+func TestConfigHandler_TestLLMModel_Saved(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLLM := new(MockLLMService)
+	h := NewConfigHandler(mockLLM, &config.Config{}, "/tmp/test.yaml")
+
+	exists := true
+	mockLLM.On("TestModel", "m1", (*config.LLMModelConfig)(nil)).Return(&service.LLMModelTestResult{
+		ModelID:       "m1",
+		Success:       true,
+		LatencyMs:     120,
+		HTTPStatus:    200,
+		ModelExists:   &exists,
+		JSONParseable: true,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "m1"}}
+	c.Request = httptest.NewRequest("POST", "/api/v1/config/llm/models/m1/test", nil)
+
+	h.TestLLMModel(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, true, data["success"])
+	assert.Equal(t, float64(200), data["httpStatus"])
+	assert.Equal(t, true, data["modelExists"])
+	mockLLM.AssertExpectations(t)
+}
+
+func TestConfigHandler_TestLLMModel_WithOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLLM := new(MockLLMService)
+	h := NewConfigHandler(mockLLM, &config.Config{}, "/tmp/test.yaml")
+
+	mockLLM.On("TestModel", "new-model", mock.MatchedBy(func(m *config.LLMModelConfig) bool {
+		return m != nil && m.Provider == "ollama" && m.Model == "qwen2.5:7b"
+	})).Return(&service.LLMModelTestResult{ModelID: "new-model"}, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"provider": "ollama",
+		"endpoint": "http://localhost:11434",
+		"model":    "qwen2.5:7b",
+	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "new-model"}}
+	c.Request = httptest.NewRequest("POST", "/api/v1/config/llm/models/new-model/test", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.TestLLMModel(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockLLM.AssertExpectations(t)
+}
+
+func TestConfigHandler_TestLLMModel_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLLM := new(MockLLMService)
+	h := NewConfigHandler(mockLLM, &config.Config{}, "/tmp/test.yaml")
+
+	mockLLM.On("TestModel", "missing", (*config.LLMModelConfig)(nil)).
+		Return(nil, fmt.Errorf("%w: missing", service.ErrLLMModelNotFound))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "missing"}}
+	c.Request = httptest.NewRequest("POST", "/api/v1/config/llm/models/missing/test", nil)
+
+	h.TestLLMModel(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestConfigHandler_ReloadConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	base := "server:\n  port: 8080\ndatabase:\n  user: u\n  database: d\njwt:\n  secret: s\nllm:\n  timeout: 60\n"
+	os.WriteFile(configPath, []byte(base), 0644)
+	cfg, err := config.LoadConfig(configPath)
+	assert.NoError(t, err)
+
+	mockLLM := new(MockLLMService)
+	h := NewConfigHandler(mockLLM, cfg, configPath)
+	reloader := config.NewReloader(configPath, cfg)
+	reloader.Register([]string{"llm"}, func(c *config.Config) { mockLLM.UpdateConfig(c.LLM) })
+	reloader.Register(nil, h.SetConfig)
+	h.SetReloader(reloader)
+
+	mockLLM.On("UpdateConfig", mock.MatchedBy(func(c config.LLMConfig) bool { return c.Timeout == 120 })).Return()
+	os.WriteFile(configPath, []byte(strings.Replace(strings.Replace(base, "timeout: 60", "timeout: 120", 1), "8080", "9090", 1)), 0644)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/config/reload", nil)
+
+	h.ReloadConfig(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"llm.timeout"}, data["applied"])
+	assert.Equal(t, []interface{}{"server.port"}, data["restartRequired"])
+	assert.Equal(t, 120, h.config.LLM.Timeout)
+	mockLLM.AssertExpectations(t)
+}
+
+func TestConfigHandler_ReloadConfig_NotEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewConfigHandler(new(MockLLMService), &config.Config{}, "/tmp/test.yaml")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/config/reload", nil)
+
+	h.ReloadConfig(c)
+
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
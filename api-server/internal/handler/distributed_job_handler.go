@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+	"gorm.io/gorm"
+)
+
+// DistributedJobHandler 跨节点分布式作业处理器
+type DistributedJobHandler struct {
+	service        service.DistributedJobServiceInterface
+	projectService service.ProjectServiceInterface
+}
+
+// NewDistributedJobHandler 创建分布式作业处理器
+func NewDistributedJobHandler(svc service.DistributedJobServiceInterface) *DistributedJobHandler {
+	return &DistributedJobHandler{service: svc}
+}
+
+// SetProjectService 启用项目可见范围：只返回全部成员都对当前用户可见的分布式作业
+func (h *DistributedJobHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
+// GetDistributedJobs 获取分布式作业列表
+func (h *DistributedJobHandler) GetDistributedJobs(c *gin.Context) {
+	statuses := c.QueryArray("status")
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	jobs, total, err := h.service.GetDistributedJobs(statuses, page, pageSize, scope)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, utils.PaginationResponse{
+		Items: jobs,
+		Pagination: utils.Pagination{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// GetDistributedJobDetail 获取分布式作业合并详情
+func (h *DistributedJobHandler) GetDistributedJobDetail(c *gin.Context) {
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	detail, err := h.service.GetDistributedJobDetail(c.Param("distributedId"), scope)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, 404, "Distributed job not found")
+		} else {
+			utils.ErrorResponse(c, 500, "Database error: "+err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, detail)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/service"
+	"gorm.io/gorm"
+)
+
+// MockDistributedJobService is a mock implementation of DistributedJobServiceInterface
+type MockDistributedJobService struct {
+	mock.Mock
+}
+
+func (m *MockDistributedJobService) GetDistributedJobs(statuses []string, page, pageSize int, scope service.ProjectFilter) ([]service.DistributedJob, int64, error) {
+	args := m.Called(statuses, page, pageSize, scope)
+	return args.Get(0).([]service.DistributedJob), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockDistributedJobService) GetDistributedJobDetail(distributedID string, scope service.ProjectFilter) (*service.DistributedJobDetail, error) {
+	args := m.Called(distributedID, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.DistributedJobDetail), args.Error(1)
+}
+
+func TestDistributedJobHandler_GetDistributedJobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockDistributedJobService)
+	handler := NewDistributedJobHandler(mockService)
+
+	jobs := []service.DistributedJob{{DistributedID: "dist-0123456789ab", Status: "running", NodeCount: 2, TotalCardCount: 16}}
+	mockService.On("GetDistributedJobs", []string{"running"}, 1, 20, service.ProjectFilter{}).Return(jobs, int64(1), nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/distributed?status=running", nil)
+
+	handler.GetDistributedJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data struct {
+			Items []service.DistributedJob `json:"items"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Data.Items, 1)
+	assert.Equal(t, 16, resp.Data.Items[0].TotalCardCount)
+	mockService.AssertExpectations(t)
+}
+
+func TestDistributedJobHandler_GetDistributedJobDetail_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockDistributedJobService)
+	handler := NewDistributedJobHandler(mockService)
+	mockService.On("GetDistributedJobDetail", "dist-missing", service.ProjectFilter{}).Return(nil, gorm.ErrRecordNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/distributed/dist-missing", nil)
+	c.Params = gin.Params{{Key: "distributedId", Value: "dist-missing"}}
+
+	handler.GetDistributedJobDetail(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDistributedJobHandler_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockDistributedJobService)
+	handler := NewDistributedJobHandler(mockService)
+	handler.SetProjectService(newRestrictedProjectService())
+	// 成员分组归属其他项目的分布式作业由服务按可见范围过滤
+	mockService.On("GetDistributedJobs", []string(nil), 1, 20, restrictedScope).Return([]service.DistributedJob{}, int64(0), nil)
+	mockService.On("GetDistributedJobDetail", "dist-other", restrictedScope).Return(nil, gorm.ErrRecordNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/distributed", nil)
+	handler.GetDistributedJobs(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":0`)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/distributed/dist-other", nil)
+	c.Params = gin.Params{{Key: "distributedId", Value: "dist-other"}}
+	handler.GetDistributedJobDetail(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,392 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// exportTask 异步导出任务，文件写入下载目录，完成后可通过下载接口获取
+type exportTask struct {
+	ID         string     `json:"exportId"`
+	Format     string     `json:"format"`
+	Status     string     `json:"status"` // running / done / failed / cancelled
+	Rows       int64      `json:"rows"`
+	Size       int64      `json:"size"`
+	Filename   string     `json:"filename"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// DownloadURL 完成后可下载的地址；ExpiresAt 为文件清理时间，任务结束后才确定
+	DownloadURL string     `json:"downloadUrl"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+
+	// owner 提交任务的用户，只有本人可以查询、下载与取消
+	owner  uint
+	path   string
+	cancel context.CancelFunc
+	mu     sync.Mutex
+}
+
+// snapshot 复制当前状态用于响应
+func (t *exportTask) snapshot() exportTask {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return exportTask{
+		ID:          t.ID,
+		Format:      t.Format,
+		Status:      t.Status,
+		Rows:        t.Rows,
+		Size:        t.Size,
+		Filename:    t.Filename,
+		Error:       t.Error,
+		CreatedAt:   t.CreatedAt,
+		FinishedAt:  t.FinishedAt,
+		DownloadURL: t.DownloadURL,
+		ExpiresAt:   t.ExpiresAt,
+	}
+}
+
+// exportDownloadPrefix 异步导出下载接口的路由前缀，与 main 中注册的 /api/v1/exports 一致
+const exportDownloadPrefix = "/api/v1/exports/"
+
+// exportCleanupInterval 后台清理过期任务的间隔
+const exportCleanupInterval = 10 * time.Minute
+
+var (
+	errExportBusy  = errors.New("too many running exports, try again later")
+	errExportQuota = errors.New("export storage limit exceeded")
+)
+
+// exportTaskManager 管理异步导出任务；任务状态保存在内存中，过期任务及其文件由后台定期清理。
+// 同时运行的任务数、目录中文件总大小与单个任务的运行时长均有上限
+type exportTaskManager struct {
+	dir        string
+	retention  time.Duration
+	timeout    time.Duration
+	maxRunning int
+	maxBytes   int64
+	tasks      sync.Map // map[string]*exportTask
+
+	mu      sync.Mutex
+	running int
+	used    int64 // 已完成文件与运行中任务已写出的字节数
+}
+
+// newExportTaskManager 创建任务管理器并启动后台清理；目录中上次运行遗留的文件已无对应任务，直接删除
+func newExportTaskManager(cfg config.ExportConfig) *exportTaskManager {
+	m := &exportTaskManager{
+		dir:        cfg.Dir,
+		retention:  time.Duration(cfg.RetentionHours) * time.Hour,
+		timeout:    time.Duration(cfg.TimeoutMinutes) * time.Minute,
+		maxRunning: cfg.MaxRunning,
+		maxBytes:   int64(cfg.MaxTotalMB) << 20,
+	}
+	if m.retention <= 0 {
+		m.retention = 24 * time.Hour
+	}
+	if m.timeout <= 0 {
+		m.timeout = time.Hour
+	}
+	if m.maxRunning <= 0 {
+		m.maxRunning = 4
+	}
+	if m.maxBytes <= 0 {
+		m.maxBytes = 2048 << 20
+	}
+	m.removeStale()
+	go m.janitor()
+	return m
+}
+
+// removeStale 删除导出目录中遗留的导出文件
+func (m *exportTaskManager) removeStale() {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !isExportFileName(entry.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(m.dir, entry.Name())); err != nil {
+			slog.Warn("failed to remove stale export", "file", entry.Name(), "error", err)
+		}
+	}
+}
+
+// isExportFileName 判断是否为 newExportID 生成的文件名（<32 位十六进制>.<扩展名>）
+func isExportFileName(name string) bool {
+	id, _, ok := strings.Cut(name, ".")
+	if !ok || len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func (m *exportTaskManager) janitor() {
+	ticker := time.NewTicker(exportCleanupInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.cleanup(now)
+	}
+}
+
+// reserve 为即将写出的 n 字节占用目录配额，超出上限时返回 false
+func (m *exportTaskManager) reserve(n int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used+n > m.maxBytes {
+		return false
+	}
+	m.used += n
+	return true
+}
+
+func (m *exportTaskManager) release(n int64) {
+	m.mu.Lock()
+	m.used -= n
+	m.mu.Unlock()
+}
+
+// acquire 占用一个运行名额；运行中任务已满或目录配额用尽时拒绝
+func (m *exportTaskManager) acquire() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running >= m.maxRunning {
+		return errExportBusy
+	}
+	if m.used >= m.maxBytes {
+		return errExportQuota
+	}
+	m.running++
+	return nil
+}
+
+func (m *exportTaskManager) finish() {
+	m.mu.Lock()
+	m.running--
+	m.mu.Unlock()
+}
+
+// quotaWriter 写出时计入目录配额，超出上限返回 errExportQuota
+type quotaWriter struct {
+	m       *exportTaskManager
+	w       io.Writer
+	written int64
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	if !q.m.reserve(int64(len(p))) {
+		return 0, errExportQuota
+	}
+	q.written += int64(len(p))
+	return q.w.Write(p)
+}
+
+// exportRunFunc 写出导出内容，progress 以新增行数回调
+type exportRunFunc func(ctx context.Context, out io.Writer, progress func(int)) error
+
+// start 为 owner 创建任务并在后台执行；先写入临时文件，成功后重命名，下载接口不会读到半成品。
+// 运行中任务已满时返回 errExportBusy，目录配额用尽时返回 errExportQuota
+func (m *exportTaskManager) start(owner uint, format, filename string, run exportRunFunc) (*exportTask, error) {
+	m.cleanup(time.Now())
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return nil, err
+	}
+	id, err := newExportID()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.acquire(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	task := &exportTask{
+		ID:          id,
+		Format:      format,
+		Status:      "running",
+		Filename:    filename,
+		CreatedAt:   time.Now(),
+		DownloadURL: exportDownloadPrefix + id + "/download",
+		owner:       owner,
+		path:        filepath.Join(m.dir, id+"."+format),
+		cancel:      cancel,
+	}
+	m.tasks.Store(id, task)
+
+	go func() {
+		defer m.finish()
+		defer cancel()
+		size, err := m.run(ctx, task, run)
+		now := time.Now()
+		task.mu.Lock()
+		defer task.mu.Unlock()
+		expires := now.Add(m.retention)
+		task.FinishedAt = &now
+		task.ExpiresAt = &expires
+		switch {
+		case err == nil:
+			task.Status = "done"
+			task.Size = size
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			task.Status = "failed"
+			task.Error = fmt.Sprintf("export exceeded timeout of %s", m.timeout)
+		case ctx.Err() != nil:
+			task.Status = "cancelled"
+		default:
+			task.Status = "failed"
+			task.Error = err.Error()
+			slog.Error("async export failed", "export_id", task.ID, "format", format, "error", err)
+		}
+	}()
+	return task, nil
+}
+
+// run 写出任务文件并返回文件大小；失败时删除临时文件并归还占用的配额
+func (m *exportTaskManager) run(ctx context.Context, task *exportTask, run exportRunFunc) (int64, error) {
+	tmp := task.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	out := &quotaWriter{m: m, w: f}
+	err = run(ctx, out, func(n int) {
+		task.mu.Lock()
+		task.Rows += int64(n)
+		task.mu.Unlock()
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, task.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		m.release(out.written)
+		return 0, err
+	}
+	return out.written, nil
+}
+
+func (m *exportTaskManager) get(id string) (*exportTask, bool) {
+	v, ok := m.tasks.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return v.(*exportTask), true
+}
+
+// cleanup 删除已结束且超过保留时长的任务及文件，并归还文件占用的配额
+func (m *exportTaskManager) cleanup(now time.Time) {
+	m.tasks.Range(func(key, v interface{}) bool {
+		task := v.(*exportTask)
+		task.mu.Lock()
+		expired := task.FinishedAt != nil && now.Sub(*task.FinishedAt) > m.retention
+		size := task.Size
+		task.mu.Unlock()
+		if expired {
+			if err := os.Remove(task.path); err != nil && !os.IsNotExist(err) {
+				slog.Warn("failed to remove expired export", "export_id", task.ID, "error", err)
+			}
+			m.release(size)
+			m.tasks.Delete(key)
+		}
+		return true
+	})
+}
+
+func newExportID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GetExportTask 查询异步导出任务状态
+func (h *JobHandler) GetExportTask(c *gin.Context) {
+	task, ok := h.lookupExportTask(c)
+	if !ok {
+		return
+	}
+	utils.SuccessResponse(c, task.snapshot())
+}
+
+// DownloadExport 下载已完成的异步导出文件
+func (h *JobHandler) DownloadExport(c *gin.Context) {
+	task, ok := h.lookupExportTask(c)
+	if !ok {
+		return
+	}
+	state := task.snapshot()
+	if state.Status != "done" {
+		utils.ErrorResponse(c, 409, fmt.Sprintf("export is %s", state.Status))
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(task.path, state.Filename)
+}
+
+// CancelExportTask 取消运行中的异步导出任务
+func (h *JobHandler) CancelExportTask(c *gin.Context) {
+	task, ok := h.lookupExportTask(c)
+	if !ok {
+		return
+	}
+	task.cancel()
+	utils.SuccessResponse(c, gin.H{"message": "cancelled"})
+}
+
+// startExportTask 以当前用户身份提交异步导出；匿名请求返回 401，任务数或配额超限返回 429/507
+func (h *JobHandler) startExportTask(c *gin.Context, format, filename string, run exportRunFunc) {
+	if h.exportTasks == nil {
+		utils.ErrorResponse(c, 501, "async export is not configured")
+		return
+	}
+	owner := currentUserID(c)
+	if owner == 0 {
+		utils.ErrorResponse(c, 401, "authentication required for async export")
+		return
+	}
+	task, err := h.exportTasks.start(owner, format, filename, run)
+	switch {
+	case errors.Is(err, errExportBusy):
+		utils.ErrorResponse(c, 429, err.Error())
+	case errors.Is(err, errExportQuota):
+		utils.ErrorResponse(c, 507, err.Error())
+	case err != nil:
+		utils.ErrorResponse(c, 500, "failed to start export: "+err.Error())
+	default:
+		utils.SuccessResponse(c, task.snapshot())
+	}
+}
+
+// lookupExportTask 查找当前用户提交的任务；其他用户的任务按不存在处理
+func (h *JobHandler) lookupExportTask(c *gin.Context) (*exportTask, bool) {
+	if h.exportTasks == nil {
+		utils.ErrorResponse(c, 501, "async export is not configured")
+		return nil, false
+	}
+	task, ok := h.exportTasks.get(c.Param("exportId"))
+	if !ok || task.owner != currentUserID(c) {
+		utils.ErrorResponse(c, 404, "export not found")
+		return nil, false
+	}
+	return task, true
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// InsightsHandler 资源使用洞察处理器
+type InsightsHandler struct {
+	insightsService service.InsightsServiceInterface
+	projectService  service.ProjectServiceInterface
+}
+
+// NewInsightsHandler 创建资源使用洞察处理器
+func NewInsightsHandler(insightsService service.InsightsServiceInterface) *InsightsHandler {
+	return &InsightsHandler{insightsService: insightsService}
+}
+
+// SetProjectService 启用项目可见范围：只检测当前用户可见的作业
+func (h *InsightsHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
+// GetIdleJobs 检测运行中作业的空闲占卡、低利用率与多卡负载不均，按浪费卡时倒序返回；
+// lookbackMinutes 缺省取配置的回看窗口，issue 按问题类型过滤
+func (h *InsightsHandler) GetIdleJobs(c *gin.Context) {
+	var lookback time.Duration
+	if raw := c.Query("lookbackMinutes"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid lookbackMinutes")
+			return
+		}
+		lookback = time.Duration(n) * time.Minute
+	}
+
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	report, err := h.insightsService.DetectIdleJobs(c.Request.Context(), lookback, c.Query("issue"), scope)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidIdleQuery) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to detect idle jobs: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, report)
+}
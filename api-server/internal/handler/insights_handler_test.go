@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// MockInsightsService is a mock implementation of InsightsServiceInterface
+type MockInsightsService struct {
+	mock.Mock
+}
+
+func (m *MockInsightsService) DetectIdleJobs(ctx context.Context, lookback time.Duration, issue string, scope service.ProjectFilter) (*service.IdleJobsReport, error) {
+	args := m.Called(ctx, lookback, issue, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.IdleJobsReport), args.Error(1)
+}
+
+func TestInsightsHandler_GetIdleJobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockInsightsService)
+	handler := NewInsightsHandler(mockService)
+	report := &service.IdleJobsReport{LookbackMinutes: 120, FlaggedJobs: 1, Jobs: []service.IdleJob{{JobID: "job-1"}}}
+	mockService.On("DetectIdleJobs", mock.Anything, 2*time.Hour, service.IdleIssueHolding, service.ProjectFilter{}).Return(report, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/insights/idle-jobs?lookbackMinutes=120&issue=idle_holding", nil)
+	handler.GetIdleJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"jobId":"job-1"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestInsightsHandler_GetIdleJobs_BadRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockInsightsService)
+	handler := NewInsightsHandler(mockService)
+	mockService.On("DetectIdleJobs", mock.Anything, time.Duration(0), "busy", service.ProjectFilter{}).
+		Return(nil, fmt.Errorf("%w: unsupported issue", service.ErrInvalidIdleQuery))
+
+	for _, query := range []string{"lookbackMinutes=abc", "lookbackMinutes=0", "issue=busy"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/insights/idle-jobs?"+query, nil)
+		handler.GetIdleJobs(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockService.AssertNumberOfCalls(t, "DetectIdleJobs", 1)
+}
+
+func TestInsightsHandler_GetIdleJobs_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockInsightsService)
+	handler := NewInsightsHandler(mockService)
+	handler.SetProjectService(newRestrictedProjectService())
+	// 只检测可见范围内的作业，其他项目的空闲作业不出现在结果中
+	report := &service.IdleJobsReport{Jobs: []service.IdleJob{{JobID: "job-a"}}, FlaggedJobs: 1}
+	mockService.On("DetectIdleJobs", mock.Anything, time.Duration(0), "", restrictedScope).Return(report, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/insights/idle-jobs", nil)
+	handler.GetIdleJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "job-a")
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,499 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/parquet-go/parquet-go"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// 作业数据导出格式
+const (
+	exportFormatJSONL   = "jsonl"
+	exportFormatParquet = "parquet"
+)
+
+// parquetRowGroupRows Parquet 每个行组的最大行数，限制写出时缓存在内存中的数据量
+const parquetRowGroupRows = 10000
+
+// jobsExportRequest 作业数据导出请求体；filters 与 GetGroupedJobs 查询参数同名同义
+type jobsExportRequest struct {
+	Format    string              `json:"format"`
+	Fields    []string            `json:"fields"`
+	JobIDs    []string            `json:"jobIds"`
+	Filters   map[string][]string `json:"filters"`
+	SortBy    string              `json:"sortBy"`
+	SortOrder string              `json:"sortOrder"`
+}
+
+// jobExportRecord 一个作业分组的导出数据，以分组主作业为准
+type jobExportRecord struct {
+	Group      service.JobGroup
+	Parameters []model.Parameter
+	Codes      []model.Code
+	Cards      []service.NPUCardInfo
+	Analysis   *service.JobAnalysisResponse
+}
+
+// jobExportParameter 导出的参数摘要，不含参数原文、配置文件内容与环境变量
+type jobExportParameter struct {
+	ParameterSource *string         `json:"parameterSource"`
+	ParameterData   json.RawMessage `json:"parameterData"`
+	ConfigFilePath  *string         `json:"configFilePath"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
+
+// jobExportCode 导出的代码元数据，不含脚本内容
+type jobExportCode struct {
+	ScriptPath        *string   `json:"scriptPath"`
+	ShScriptPath      *string   `json:"shScriptPath"`
+	ImportedLibraries *string   `json:"importedLibraries"`
+	ConfigFiles       *string   `json:"configFiles"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// jobExportFieldKind 导出字段类型，决定 Parquet 列类型
+type jobExportFieldKind int
+
+const (
+	jobFieldString jobExportFieldKind = iota
+	jobFieldInt
+	jobFieldMillis // 毫秒时间戳
+	jobFieldTime
+	jobFieldJSON // 嵌套结构，JSONL 中为对象/数组，Parquet 中为 JSON 字符串列
+)
+
+// 字段依赖的额外数据
+const (
+	jobNeedNone = iota
+	jobNeedParameters
+	jobNeedCode
+	jobNeedCards
+	jobNeedAnalysis
+)
+
+// jobExportField 可导出字段；value 返回 nil 表示空值
+type jobExportField struct {
+	Name  string
+	kind  jobExportFieldKind
+	needs int
+	value func(r *jobExportRecord) interface{}
+}
+
+// jobExportFields 全部可导出字段，按输出顺序排列；jobId 始终导出
+var jobExportFields = []jobExportField{
+	{Name: "jobId", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return r.Group.MainJob.JobID }},
+	{Name: "nodeId", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.NodeID) }},
+	{Name: "hostId", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.HostID) }},
+	{Name: "jobName", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.JobName) }},
+	{Name: "jobType", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.JobType) }},
+	{Name: "pid", kind: jobFieldInt, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.PID) }},
+	{Name: "ppid", kind: jobFieldInt, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.PPID) }},
+	{Name: "pgid", kind: jobFieldInt, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.PGID) }},
+	{Name: "processName", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.ProcessName) }},
+	{Name: "commandLine", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.CommandLine) }},
+	{Name: "framework", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.Framework) }},
+	{Name: "modelFormat", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.ModelFormat) }},
+	{Name: "status", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.Status) }},
+	{Name: "startTime", kind: jobFieldMillis, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.StartTime) }},
+	{Name: "endTime", kind: jobFieldMillis, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.EndTime) }},
+	{Name: "cwd", kind: jobFieldString, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.CWD) }},
+	{Name: "createdAt", kind: jobFieldTime, value: func(r *jobExportRecord) interface{} { return r.Group.MainJob.CreatedAt }},
+	{Name: "updatedAt", kind: jobFieldTime, value: func(r *jobExportRecord) interface{} { return derefOrNil(r.Group.MainJob.UpdatedAt) }},
+	{Name: "groupId", kind: jobFieldInt, value: func(r *jobExportRecord) interface{} {
+		if r.Group.GroupID == 0 {
+			return nil
+		}
+		return int64(r.Group.GroupID)
+	}},
+	{Name: "cardCount", kind: jobFieldInt, value: func(r *jobExportRecord) interface{} {
+		if r.Group.CardCount == nil {
+			return nil
+		}
+		return int64(*r.Group.CardCount)
+	}},
+	{Name: "memberJobIds", kind: jobFieldJSON, value: func(r *jobExportRecord) interface{} {
+		ids := make([]string, 0, len(r.Group.ChildJobs))
+		for _, child := range r.Group.ChildJobs {
+			ids = append(ids, child.JobID)
+		}
+		return ids
+	}},
+	{Name: "parameters", kind: jobFieldJSON, needs: jobNeedParameters, value: func(r *jobExportRecord) interface{} {
+		params := make([]jobExportParameter, 0, len(r.Parameters))
+		for _, p := range r.Parameters {
+			params = append(params, jobExportParameter{
+				ParameterSource: p.ParameterSource,
+				ParameterData:   rawJSONOrString(p.ParameterData),
+				ConfigFilePath:  p.ConfigFilePath,
+				Timestamp:       p.Timestamp,
+			})
+		}
+		return params
+	}},
+	{Name: "code", kind: jobFieldJSON, needs: jobNeedCode, value: func(r *jobExportRecord) interface{} {
+		codes := make([]jobExportCode, 0, len(r.Codes))
+		for _, c := range r.Codes {
+			codes = append(codes, jobExportCode{
+				ScriptPath:        c.ScriptPath,
+				ShScriptPath:      c.ShScriptPath,
+				ImportedLibraries: c.ImportedLibraries,
+				ConfigFiles:       c.ConfigFiles,
+				Timestamp:         c.Timestamp,
+			})
+		}
+		return codes
+	}},
+	{Name: "npuCards", kind: jobFieldJSON, needs: jobNeedCards, value: func(r *jobExportRecord) interface{} {
+		if r.Cards == nil {
+			return []service.NPUCardInfo{}
+		}
+		return r.Cards
+	}},
+	{Name: "analysis", kind: jobFieldJSON, needs: jobNeedAnalysis, value: func(r *jobExportRecord) interface{} {
+		if r.Analysis == nil {
+			return nil
+		}
+		return r.Analysis
+	}},
+}
+
+// selectJobExportFields 按名称选择导出字段（保持定义顺序），为空时导出全部字段
+func selectJobExportFields(names []string) ([]jobExportField, error) {
+	if len(names) == 0 {
+		return jobExportFields, nil
+	}
+	wanted := map[string]bool{"jobId": true}
+	for _, name := range names {
+		wanted[strings.TrimSpace(name)] = true
+	}
+	selected := make([]jobExportField, 0, len(wanted))
+	for _, f := range jobExportFields {
+		if wanted[f.Name] {
+			selected = append(selected, f)
+			delete(wanted, f.Name)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("unsupported export field %q", name)
+	}
+	return selected, nil
+}
+
+func derefOrNil[T any](p *T) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// rawJSONOrString 参数解析结果原样嵌入 JSON；不是合法 JSON 时作为字符串输出
+func rawJSONOrString(s *string) json.RawMessage {
+	if s == nil || *s == "" {
+		return json.RawMessage("null")
+	}
+	if json.Valid([]byte(*s)) {
+		return json.RawMessage(*s)
+	}
+	quoted, _ := json.Marshal(*s)
+	return quoted
+}
+
+// jobRecordWriter 逐批写出作业导出记录，Close 写完文件尾
+type jobRecordWriter interface {
+	WriteRecords(records []jobExportRecord) error
+	Close() error
+}
+
+// ExportJobs 提交作业数据导出任务（JSONL 或 Parquet），返回任务状态与下载地址。
+// 每个作业分组一条记录，包含主作业字段、分组成员、参数摘要、代码元数据、NPU 卡信息与 AI 分析结果；
+// 指定 jobIds 时只导出这些分组，否则按 filters 筛选全部分组
+func (h *JobHandler) ExportJobs(c *gin.Context) {
+	if h.exportTasks == nil {
+		utils.ErrorResponse(c, 501, "async export is not configured")
+		return
+	}
+	var req jobsExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Format == "" {
+		req.Format = exportFormatJSONL
+	}
+	if req.Format != exportFormatJSONL && req.Format != exportFormatParquet {
+		utils.ErrorResponse(c, 400, fmt.Sprintf("unsupported export format %q", req.Format))
+		return
+	}
+	fields, err := selectJobExportFields(req.Fields)
+	if err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+	spec, err := parseJobsExportSpec(req)
+	if err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+	if !h.applyProjectScope(c, &spec.filter.JobFilter) {
+		return
+	}
+	for _, f := range fields {
+		if f.needs == jobNeedAnalysis && h.llmService == nil {
+			utils.ErrorResponse(c, 501, "LLM service is not configured")
+			return
+		}
+	}
+
+	format := req.Format
+	filename := fmt.Sprintf("jobs_%s.%s", time.Now().Format("20060102_150405"), format)
+	h.startExportTask(c, format, filename, func(ctx context.Context, out io.Writer, progress func(int)) error {
+		w, err := newJobRecordWriter(format, out, fields)
+		if err != nil {
+			return err
+		}
+		return h.streamJobsExport(ctx, spec, fields, w, progress)
+	})
+}
+
+// parseJobsExportSpec 校验筛选参数并转换为分批遍历参数
+func parseJobsExportSpec(req jobsExportRequest) (*analysisExportSpec, error) {
+	for key := range req.Filters {
+		if _, ok := groupFilterParams[key]; !ok {
+			return nil, fmt.Errorf("unsupported filter %q", key)
+		}
+	}
+	query := url.Values(req.Filters)
+	if err := checkCardCountParams(query); err != nil {
+		return nil, err
+	}
+	filter, err := parseGroupFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	spec := &analysisExportSpec{
+		scope:       "filtered",
+		filter:      filter,
+		sortBy:      req.SortBy,
+		sortOrder:   req.SortOrder,
+		selectedIDs: dedupeStrings(nonEmpty(req.JobIDs)),
+	}
+	if len(spec.selectedIDs) > 0 {
+		spec.scope = "selected"
+	}
+	return spec, nil
+}
+
+// streamJobsExport 分批遍历分组，按所选字段批量查询参数、代码、NPU 卡与分析结果后写出
+func (h *JobHandler) streamJobsExport(ctx context.Context, spec *analysisExportSpec, fields []jobExportField, w jobRecordWriter, progress func(int)) error {
+	needs := make(map[int]bool)
+	for _, f := range fields {
+		needs[f.needs] = true
+	}
+	err := h.forEachExportChunk(ctx, spec, func(groups []service.JobGroup) error {
+		records, err := h.buildJobExportRecords(groups, needs)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteRecords(records); err != nil {
+			return err
+		}
+		progress(len(records))
+		return nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (h *JobHandler) buildJobExportRecords(groups []service.JobGroup, needs map[int]bool) ([]jobExportRecord, error) {
+	jobIDs := make([]string, 0, len(groups))
+	mainJobs := make([]model.Job, 0, len(groups))
+	for _, g := range groups {
+		jobIDs = append(jobIDs, g.MainJob.JobID)
+		mainJobs = append(mainJobs, g.MainJob)
+	}
+	var (
+		params   map[string][]model.Parameter
+		codes    map[string][]model.Code
+		cards    map[string][]service.NPUCardInfo
+		analyses map[string]*service.JobAnalysisResponse
+		err      error
+	)
+	if needs[jobNeedParameters] {
+		if params, err = h.jobService.GetJobsParameters(jobIDs); err != nil {
+			return nil, err
+		}
+	}
+	if needs[jobNeedCode] {
+		if codes, err = h.jobService.GetJobCodes(jobIDs); err != nil {
+			return nil, err
+		}
+	}
+	if needs[jobNeedCards] {
+		if cards, err = h.jobService.GetJobsNPUCards(mainJobs); err != nil {
+			return nil, err
+		}
+	}
+	if needs[jobNeedAnalysis] {
+		if analyses, err = h.llmService.GetBatchAnalyses(jobIDs); err != nil {
+			return nil, fmt.Errorf("failed to fetch analyses: %w", err)
+		}
+	}
+
+	records := make([]jobExportRecord, 0, len(groups))
+	for _, g := range groups {
+		id := g.MainJob.JobID
+		records = append(records, jobExportRecord{
+			Group:      g,
+			Parameters: params[id],
+			Codes:      codes[id],
+			Cards:      cards[id],
+			Analysis:   analyses[id],
+		})
+	}
+	return records, nil
+}
+
+func newJobRecordWriter(format string, out io.Writer, fields []jobExportField) (jobRecordWriter, error) {
+	if format == exportFormatParquet {
+		return newParquetJobWriter(out, fields), nil
+	}
+	return &jsonlJobWriter{out: bufio.NewWriter(out), fields: fields}, nil
+}
+
+// jsonlJobWriter 每行一个 JSON 对象，字段按定义顺序输出
+type jsonlJobWriter struct {
+	out    *bufio.Writer
+	fields []jobExportField
+}
+
+func (w *jsonlJobWriter) WriteRecords(records []jobExportRecord) error {
+	for i := range records {
+		w.out.WriteByte('{')
+		for j, f := range w.fields {
+			if j > 0 {
+				w.out.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.Name)
+			w.out.Write(key)
+			w.out.WriteByte(':')
+			value, err := json.Marshal(f.value(&records[i]))
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", f.Name, err)
+			}
+			w.out.Write(value)
+		}
+		if _, err := w.out.WriteString("}\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *jsonlJobWriter) Close() error {
+	return w.out.Flush()
+}
+
+// parquetJobWriter 按所选字段动态生成扁平 schema：标量字段为可空的对应类型列，
+// 时间字段为毫秒精度 TIMESTAMP，嵌套字段为 JSON 字符串列
+type parquetJobWriter struct {
+	writer  *parquet.Writer
+	fields  []jobExportField
+	columns []int // fields[i] 在 schema 中的列序号
+}
+
+func newParquetJobWriter(out io.Writer, fields []jobExportField) *parquetJobWriter {
+	group := parquet.Group{}
+	for _, f := range fields {
+		group[f.Name] = parquet.Optional(parquetNode(f.kind))
+	}
+	schema := parquet.NewSchema("job", group)
+	index := make(map[string]int, len(fields))
+	for i, path := range schema.Columns() {
+		index[path[0]] = i
+	}
+	columns := make([]int, len(fields))
+	for i, f := range fields {
+		columns[i] = index[f.Name]
+	}
+	return &parquetJobWriter{
+		writer: parquet.NewWriter(out, schema,
+			parquet.Compression(&parquet.Snappy),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupRows)),
+		fields:  fields,
+		columns: columns,
+	}
+}
+
+func parquetNode(kind jobExportFieldKind) parquet.Node {
+	switch kind {
+	case jobFieldInt:
+		return parquet.Int(64)
+	case jobFieldMillis, jobFieldTime:
+		return parquet.Timestamp(parquet.Millisecond)
+	case jobFieldJSON:
+		return parquet.JSON()
+	default:
+		return parquet.String()
+	}
+}
+
+func (w *parquetJobWriter) WriteRecords(records []jobExportRecord) error {
+	rows := make([]parquet.Row, 0, len(records))
+	for i := range records {
+		row := make(parquet.Row, len(w.fields))
+		for j, f := range w.fields {
+			value, err := parquetValue(f, f.value(&records[i]))
+			if err != nil {
+				return err
+			}
+			col := w.columns[j]
+			if value.IsNull() {
+				row[col] = value.Level(0, 0, col)
+			} else {
+				row[col] = value.Level(0, 1, col)
+			}
+		}
+		rows = append(rows, row)
+	}
+	_, err := w.writer.WriteRows(rows)
+	return err
+}
+
+// parquetValue 把字段值转换为对应列类型的 Parquet 值
+func parquetValue(f jobExportField, v interface{}) (parquet.Value, error) {
+	if v == nil {
+		return parquet.NullValue(), nil
+	}
+	switch f.kind {
+	case jobFieldTime:
+		return parquet.ValueOf(v.(time.Time).UnixMilli()), nil
+	case jobFieldJSON:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return parquet.Value{}, fmt.Errorf("failed to encode %s: %w", f.Name, err)
+		}
+		return parquet.ValueOf(data), nil
+	default:
+		return parquet.ValueOf(v), nil
+	}
+}
+
+func (w *parquetJobWriter) Close() error {
+	return w.writer.Close()
+}
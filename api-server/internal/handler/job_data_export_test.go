@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+func postExportJobs(handler *JobHandler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/export/jobs", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", uint(3))
+	handler.ExportJobs(c)
+	return w
+}
+
+func exportJobGroup() service.JobGroup {
+	nodeID, status := "node-1", "running"
+	start := int64(1770373780000)
+	cards := 2
+	return service.JobGroup{
+		MainJob:   model.Job{JobID: "job-1", NodeID: &nodeID, Status: &status, StartTime: &start},
+		ChildJobs: []model.Job{{JobID: "job-1a"}, {JobID: "job-1b"}},
+		CardCount: &cards,
+		GroupID:   7,
+	}
+}
+
+func TestSelectJobExportFields(t *testing.T) {
+	fields, err := selectJobExportFields([]string{"status", "analysis"})
+	assert.NoError(t, err)
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"jobId", "status", "analysis"}, names)
+
+	all, err := selectJobExportFields(nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, len(jobExportFields))
+
+	_, err = selectJobExportFields([]string{"scriptContent"})
+	assert.Error(t, err)
+}
+
+func TestJobHandler_StreamJobsExport_JSONL(t *testing.T) {
+	mockJobService := new(MockJobService)
+	mockLLMService := new(MockLLMService)
+	handler := NewJobHandler(mockJobService, mockLLMService)
+
+	filter := service.JobGroupFilter{}
+	mockJobService.On("GetGroupedJobsByCursor", filter, "", "", "", defaultExportChunkSize).
+		Return([]service.JobGroup{exportJobGroup()}, int64(1), "", nil)
+	source, data, path := "cmdline", `{"lr":0.001}`, "/workspace/train.py"
+	content := "import torch"
+	mockJobService.On("GetJobsParameters", []string{"job-1"}).Return(map[string][]model.Parameter{
+		"job-1": {{ParameterSource: &source, ParameterData: &data}},
+	}, nil)
+	mockJobService.On("GetJobCodes", []string{"job-1"}).Return(map[string][]model.Code{
+		"job-1": {{ScriptPath: &path, ScriptContent: &content}},
+	}, nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-1"}).Return(map[string]*service.JobAnalysisResponse{
+		"job-1": {Summary: "training"},
+	}, nil)
+
+	fields, err := selectJobExportFields([]string{"status", "startTime", "groupId", "cardCount", "memberJobIds", "parameters", "code", "analysis"})
+	assert.NoError(t, err)
+	spec, err := parseJobsExportSpec(jobsExportRequest{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	rows := 0
+	err = handler.streamJobsExport(context.Background(), spec, fields, &jsonlJobWriter{out: bufio.NewWriter(&buf), fields: fields}, func(n int) { rows += n })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rows)
+	assert.True(t, strings.HasPrefix(buf.String(), `{"jobId":"job-1","status":"running",`))
+	assert.NotContains(t, buf.String(), "import torch")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, float64(1770373780000), record["startTime"])
+	assert.Equal(t, float64(7), record["groupId"])
+	assert.Equal(t, float64(2), record["cardCount"])
+	assert.Equal(t, []interface{}{"job-1a", "job-1b"}, record["memberJobIds"])
+	params := record["parameters"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"lr": 0.001}, params[0].(map[string]interface{})["parameterData"])
+	assert.Equal(t, path, record["code"].([]interface{})[0].(map[string]interface{})["scriptPath"])
+	assert.Equal(t, "training", record["analysis"].(map[string]interface{})["summary"])
+	mockJobService.AssertNotCalled(t, "GetJobsNPUCards", mock.Anything)
+}
+
+func TestJobHandler_StreamJobsExport_Parquet(t *testing.T) {
+	mockJobService := new(MockJobService)
+	handler := NewJobHandler(mockJobService, nil)
+
+	mockJobService.On("GetGroupedJobsByCursor", service.JobGroupFilter{}, "", "", "", defaultExportChunkSize).
+		Return([]service.JobGroup{exportJobGroup(), {MainJob: model.Job{JobID: "job-2"}}}, int64(2), "", nil)
+	mockJobService.On("GetJobsNPUCards", mock.Anything).Return(map[string][]service.NPUCardInfo{
+		"job-1": {{NpuID: 0, MemoryUsageMB: 1024}},
+	}, nil)
+
+	fields, err := selectJobExportFields([]string{"status", "startTime", "cardCount", "npuCards"})
+	assert.NoError(t, err)
+	spec, err := parseJobsExportSpec(jobsExportRequest{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = handler.streamJobsExport(context.Background(), spec, fields, newParquetJobWriter(&buf, fields), func(int) {})
+	assert.NoError(t, err)
+
+	type row struct {
+		JobID     string  `parquet:"jobId,optional"`
+		Status    *string `parquet:"status,optional"`
+		StartTime *int64  `parquet:"startTime,optional"`
+		CardCount *int64  `parquet:"cardCount,optional"`
+		NPUCards  *string `parquet:"npuCards,optional"`
+	}
+	rows, err := parquet.Read[row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, "job-1", rows[0].JobID)
+		assert.Equal(t, "running", *rows[0].Status)
+		assert.Equal(t, int64(1770373780000), *rows[0].StartTime)
+		assert.Equal(t, int64(2), *rows[0].CardCount)
+		assert.JSONEq(t, `[{"npuId":0,"memoryUsageMb":1024,"metrics":null}]`, *rows[0].NPUCards)
+		assert.Nil(t, rows[1].Status)
+		assert.Nil(t, rows[1].CardCount)
+		assert.JSONEq(t, `[]`, *rows[1].NPUCards)
+	}
+}
+
+func TestJobHandler_ExportJobs_AsyncSelected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	handler := NewJobHandler(mockJobService, nil)
+	handler.SetExportConfig(config.ExportConfig{Dir: t.TempDir(), RetentionHours: 1})
+
+	filter := service.JobGroupFilter{JobFilter: service.JobFilter{Statuses: []string{"running"}}}
+	mockJobService.On("GetGroupedJobsByCursor", filter, "", "", "", defaultExportChunkSize).
+		Return(exportGroups("job-1", "job-2", "job-3"), int64(3), "", nil)
+
+	w := postExportJobs(handler, `{"format":"jsonl","fields":["status"],"jobIds":["job-3","job-1"],"filters":{"status":["running"]}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var started struct {
+		Data exportTask `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	assert.Equal(t, exportFormatJSONL, started.Data.Format)
+	assert.Equal(t, "/api/v1/exports/"+started.Data.ID+"/download", started.Data.DownloadURL)
+
+	var state exportTask
+	assert.Eventually(t, func() bool {
+		task, ok := handler.exportTasks.get(started.Data.ID)
+		if !ok {
+			return false
+		}
+		state = task.snapshot()
+		return state.Status != "running"
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "done", state.Status)
+	assert.Equal(t, int64(2), state.Rows)
+	assert.NotNil(t, state.ExpiresAt)
+
+	w = httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "exportId", Value: state.ID}}
+	c.Request = httptest.NewRequest("GET", state.DownloadURL, nil)
+	c.Set("userID", uint(3))
+	handler.DownloadExport(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"jobId\":\"job-1\",\"status\":null}\n{\"jobId\":\"job-3\",\"status\":null}\n", w.Body.String())
+}
+
+func TestJobHandler_ExportJobs_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewJobHandler(new(MockJobService), nil)
+	w := postExportJobs(handler, `{"format":"jsonl"}`)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+
+	handler.SetExportConfig(config.ExportConfig{Dir: t.TempDir()})
+	for _, body := range []string{
+		`{"format":"csv"}`,
+		`{"fields":["scriptContent"]}`,
+		`{"filters":{"page":["2"]}}`,
+		`{"filters":{"cardCount":["many"]}}`,
+		`{"filters":{"startTime":["yesterday"]}}`,
+	} {
+		w = postExportJobs(handler, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	// 未配置 LLM 服务时不能导出分析结果
+	w = postExportJobs(handler, `{"fields":["analysis"]}`)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// defaultExportChunkSize 未配置 export.chunk_size 时每批查询的分组数
+const defaultExportChunkSize = 500
+
+// 导出文件格式
+const (
+	exportFormatCSV  = "csv"
+	exportFormatXLSX = "xlsx"
+)
+
+// analysisExportWriter 逐批写出导出行；Finish 写完剩余内容，出错或取消时调用 Discard 释放资源
+type analysisExportWriter interface {
+	// needs 返回写出所需的额外数据：启动脚本、硬件统计
+	needs() (needScript, needHardware bool)
+	WriteChunk(rows []analysisExportRow) error
+	Finish() error
+	Discard()
+}
+
+// SetExportConfig 设置导出分批大小并启用异步导出（写入 cfg.Dir，保留 cfg.RetentionHours 小时，并限制运行数、总大小与时长）
+func (h *JobHandler) SetExportConfig(cfg config.ExportConfig) {
+	if cfg.ChunkSize > 0 {
+		h.exportChunkSize = cfg.ChunkSize
+	}
+	if cfg.Dir != "" {
+		h.exportTasks = newExportTaskManager(cfg)
+	}
+}
+
+// serveAnalysisExport 解析导出参数后同步写出响应，或 async=true 时提交后台导出任务
+func (h *JobHandler) serveAnalysisExport(c *gin.Context, format string) {
+	spec, ok := h.parseAnalysisExport(c)
+	if !ok {
+		return
+	}
+	filename := fmt.Sprintf("ai-analysis-overview_%s.%s", time.Now().Format("20060102_150405"), format)
+
+	if c.Query("async") == "true" {
+		h.startExportTask(c, format, filename, func(ctx context.Context, out io.Writer, progress func(int)) error {
+			w, err := newAnalysisExportWriter(format, out, spec.columns)
+			if err != nil {
+				return err
+			}
+			return h.streamAnalysisExport(ctx, spec, w, progress)
+		})
+		return
+	}
+
+	out := &attachmentWriter{c: c, contentType: exportContentType(format), filename: filename}
+	w, err := newAnalysisExportWriter(format, out, spec.columns)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "failed to create export: "+err.Error())
+		return
+	}
+	if err := h.streamAnalysisExport(c.Request.Context(), spec, w, nil); err != nil {
+		if !out.started {
+			respondExportError(c, err)
+			return
+		}
+		// 已开始写出响应体，只能中断连接，客户端会收到不完整的文件
+		slog.Warn("analysis export aborted", "format", format, "error", err)
+		c.Abort()
+	}
+}
+
+func respondExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		c.Abort()
+	case errors.Is(err, utils.ErrInvalidCursor):
+		utils.ErrorResponse(c, 400, err.Error())
+	default:
+		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
+	}
+}
+
+func newAnalysisExportWriter(format string, out io.Writer, columns []analysisCSVColumn) (analysisExportWriter, error) {
+	if format == exportFormatXLSX {
+		return newXLSXAnalysisWriter(out, columns)
+	}
+	return &csvAnalysisWriter{out: out, columns: columns}, nil
+}
+
+func exportContentType(format string) string {
+	if format == exportFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// streamAnalysisExport 分批查询分组并写出，每批批量查询分析结果、代码与 NPU 卡信息；
+// ctx 取消（如客户端断开）时在批次之间停止。progress 在每批写出后以该批行数回调，可为 nil
+func (h *JobHandler) streamAnalysisExport(ctx context.Context, spec *analysisExportSpec, w analysisExportWriter, progress func(int)) error {
+	needScript, needHardware := w.needs()
+	err := h.forEachExportChunk(ctx, spec, func(groups []service.JobGroup) error {
+		rows, err := h.buildAnalysisExportRows(groups, needScript, needHardware)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteChunk(rows); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(len(rows))
+		}
+		return nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		w.Discard()
+		return err
+	}
+	return w.Finish()
+}
+
+// forEachExportChunk 按 scope 分批遍历分组：page 只查询当前页；filtered/selected 按启动时间排序时使用游标，
+// 其他排序按页码分批。selected 只保留 jobIds 指定的分组，全部找到后提前结束
+func (h *JobHandler) forEachExportChunk(ctx context.Context, spec *analysisExportSpec, fn func([]service.JobGroup) error) error {
+	if spec.scope != "filtered" && spec.scope != "selected" {
+		groups, _, err := h.jobService.GetGroupedJobs(spec.filter, spec.sortBy, spec.sortOrder, spec.page, spec.pageSize)
+		if err != nil {
+			return err
+		}
+		return fn(groups)
+	}
+
+	var selected map[string]struct{}
+	if spec.scope == "selected" {
+		selected = make(map[string]struct{}, len(spec.selectedIDs))
+		for _, id := range spec.selectedIDs {
+			selected[id] = struct{}{}
+		}
+	}
+	// emit 处理一批分组，返回是否还需继续
+	emit := func(groups []service.JobGroup) (bool, error) {
+		if selected != nil {
+			matched := make([]service.JobGroup, 0, len(selected))
+			for _, g := range groups {
+				if _, ok := selected[g.MainJob.JobID]; ok {
+					matched = append(matched, g)
+					delete(selected, g.MainJob.JobID)
+				}
+			}
+			groups = matched
+		}
+		if len(groups) > 0 {
+			if err := fn(groups); err != nil {
+				return false, err
+			}
+		}
+		return selected == nil || len(selected) > 0, nil
+	}
+
+	chunk := h.exportChunkSize
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		groups, _, next, err := h.jobService.GetGroupedJobsByCursor(spec.filter, spec.sortBy, spec.sortOrder, cursor, chunk)
+		if errors.Is(err, service.ErrCursorSortUnsupported) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		more, err := emit(groups)
+		if err != nil || !more || next == "" {
+			return err
+		}
+		cursor = next
+	}
+
+	// 游标不支持的排序字段：按页码分批
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		groups, _, err := h.jobService.GetGroupedJobs(spec.filter, spec.sortBy, spec.sortOrder, page, chunk)
+		if err != nil {
+			return err
+		}
+		more, err := emit(groups)
+		if err != nil || !more || len(groups) < chunk {
+			return err
+		}
+	}
+}
+
+// buildAnalysisExportRows 组装一批导出行，分析结果、启动脚本与 NPU 卡信息均按批查询
+func (h *JobHandler) buildAnalysisExportRows(groups []service.JobGroup, needScript, needHardware bool) ([]analysisExportRow, error) {
+	jobIDs := make([]string, 0, len(groups))
+	mainJobs := make([]model.Job, 0, len(groups))
+	for _, g := range groups {
+		jobIDs = append(jobIDs, g.MainJob.JobID)
+		mainJobs = append(mainJobs, g.MainJob)
+	}
+	analyses, err := h.llmService.GetBatchAnalyses(jobIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch analyses: %w", err)
+	}
+	var codes map[string][]model.Code
+	if needScript {
+		if codes, err = h.jobService.GetJobCodes(jobIDs); err != nil {
+			return nil, err
+		}
+	}
+	var cards map[string][]service.NPUCardInfo
+	if needHardware {
+		if cards, err = h.jobService.GetJobsNPUCards(mainJobs); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([]analysisExportRow, 0, len(groups))
+	for _, g := range groups {
+		row := analysisExportRow{Group: g, Analysis: analyses[g.MainJob.JobID], StartupScript: "-"}
+		if needScript {
+			row.StartupScript = extractStartupScript(codes[g.MainJob.JobID])
+		}
+		if needHardware {
+			row.Cards = cards[g.MainJob.JobID]
+			row.Hardware = buildExportHardwareStats(row.Cards)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// attachmentWriter 首次写入时设置下载响应头；未写入前出错仍可返回 JSON 错误
+type attachmentWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", w.filename))
+		w.c.Header("Cache-Control", "no-store")
+	}
+	n, err := w.c.Writer.Write(p)
+	w.c.Writer.Flush()
+	return n, err
+}
+
+// csvAnalysisWriter CSV 导出：UTF-8 BOM + 表头，每批写出后刷新
+type csvAnalysisWriter struct {
+	out     io.Writer
+	columns []analysisCSVColumn
+	csv     *csv.Writer
+}
+
+func (w *csvAnalysisWriter) needs() (bool, bool) {
+	return columnNeeds(w.columns)
+}
+
+func (w *csvAnalysisWriter) start() error {
+	if w.csv != nil {
+		return nil
+	}
+	if _, err := w.out.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	w.csv = csv.NewWriter(w.out)
+	header := make([]string, len(w.columns))
+	for i, col := range w.columns {
+		header[i] = col.Name
+	}
+	return w.csv.Write(header)
+}
+
+func (w *csvAnalysisWriter) WriteChunk(rows []analysisExportRow) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(w.columns))
+		for i, col := range w.columns {
+			record[i] = utils.SanitizeCSVCell(col.value(row))
+		}
+		if err := w.csv.Write(record); err != nil {
+			return err
+		}
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvAnalysisWriter) Finish() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvAnalysisWriter) Discard() {}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+func exportGroups(ids ...string) []service.JobGroup {
+	groups := make([]service.JobGroup, 0, len(ids))
+	for _, id := range ids {
+		groups = append(groups, service.JobGroup{MainJob: model.Job{JobID: id}})
+	}
+	return groups
+}
+
+func readExportCSV(t *testing.T, body string) [][]string {
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\uFEFF"))).ReadAll()
+	assert.NoError(t, err)
+	return rows
+}
+
+func TestJobHandler_ExportAnalysesCSV_StreamsCursorChunks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockLLMService := new(MockLLMService)
+	handler := NewJobHandler(mockJobService, mockLLMService)
+	handler.SetExportConfig(config.ExportConfig{ChunkSize: 2})
+
+	filter := service.JobGroupFilter{}
+	mockJobService.On("GetGroupedJobsByCursor", filter, "", "", "", 2).Return(exportGroups("job-1", "job-2"), int64(3), "c1", nil)
+	mockJobService.On("GetGroupedJobsByCursor", filter, "", "", "c1", 2).Return(exportGroups("job-3"), int64(3), "", nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-1", "job-2"}).Return(map[string]*service.JobAnalysisResponse{}, nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-3"}).Return(map[string]*service.JobAnalysisResponse{}, nil)
+	scriptPath := "/workspace/run.sh"
+	mockJobService.On("GetJobCodes", []string{"job-1", "job-2"}).Return(map[string][]model.Code{"job-2": {{ScriptPath: &scriptPath}}}, nil)
+	mockJobService.On("GetJobCodes", []string{"job-3"}).Return(map[string][]model.Code{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export?columns=jobId&columns=startupScript", nil)
+
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, [][]string{
+		{"jobId", "startupScript"},
+		{"job-1", "'-"},
+		{"job-2", scriptPath},
+		{"job-3", "'-"},
+	}, readExportCSV(t, w.Body.String()))
+	mockJobService.AssertExpectations(t)
+	mockLLMService.AssertExpectations(t)
+}
+
+func TestJobHandler_ExportAnalysesCSV_SelectedStopsWhenAllFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockLLMService := new(MockLLMService)
+	handler := NewJobHandler(mockJobService, mockLLMService)
+	handler.SetExportConfig(config.ExportConfig{ChunkSize: 2})
+
+	// 第一批已包含全部选中作业，不再查询下一批
+	mockJobService.On("GetGroupedJobsByCursor", service.JobGroupFilter{}, "", "", "", 2).Return(exportGroups("job-1", "job-2"), int64(10), "c1", nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-2"}).Return(map[string]*service.JobAnalysisResponse{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export?scope=selected&jobIds=job-2&columns=jobId", nil)
+
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, [][]string{{"jobId"}, {"job-2"}}, readExportCSV(t, w.Body.String()))
+	mockJobService.AssertNumberOfCalls(t, "GetGroupedJobsByCursor", 1)
+}
+
+func TestJobHandler_ExportAnalysesCSV_PagesWhenCursorUnsupported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockLLMService := new(MockLLMService)
+	handler := NewJobHandler(mockJobService, mockLLMService)
+	handler.SetExportConfig(config.ExportConfig{ChunkSize: 2})
+
+	filter := service.JobGroupFilter{}
+	mockJobService.On("GetGroupedJobsByCursor", filter, "jobName", "", "", 2).
+		Return([]service.JobGroup(nil), int64(0), "", service.ErrCursorSortUnsupported)
+	mockJobService.On("GetGroupedJobs", filter, "jobName", "", 1, 2).Return(exportGroups("job-a", "job-b"), int64(3), nil)
+	mockJobService.On("GetGroupedJobs", filter, "jobName", "", 2, 2).Return(exportGroups("job-c"), int64(3), nil)
+	mockLLMService.On("GetBatchAnalyses", mock.Anything).Return(map[string]*service.JobAnalysisResponse{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export?sortBy=jobName&columns=jobId", nil)
+
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, [][]string{{"jobId"}, {"job-a"}, {"job-b"}, {"job-c"}}, readExportCSV(t, w.Body.String()))
+	mockJobService.AssertExpectations(t)
+}
+
+func TestJobHandler_ExportAnalysesCSV_ErrorBeforeOutput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	handler := NewJobHandler(mockJobService, new(MockLLMService))
+	mockJobService.On("GetGroupedJobsByCursor", service.JobGroupFilter{}, "", "", "", defaultExportChunkSize).
+		Return([]service.JobGroup(nil), int64(0), "", errors.New("db down"))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export", nil)
+
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestJobHandler_ExportAnalysesCSV_ClientGone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	handler := NewJobHandler(mockJobService, new(MockLLMService))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export", nil).WithContext(ctx)
+
+	handler.ExportAnalysesCSV(c)
+
+	mockJobService.AssertNotCalled(t, "GetGroupedJobsByCursor", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestJobHandler_ExportAnalysesCSV_Async(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockLLMService := new(MockLLMService)
+	handler := NewJobHandler(mockJobService, mockLLMService)
+	handler.SetExportConfig(config.ExportConfig{Dir: t.TempDir(), RetentionHours: 1})
+
+	mockJobService.On("GetGroupedJobsByCursor", service.JobGroupFilter{}, "", "", "", defaultExportChunkSize).
+		Return(exportGroups("job-1"), int64(1), "", nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-1"}).Return(map[string]*service.JobAnalysisResponse{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export?async=true&columns=jobId", nil)
+	c.Set("userID", uint(3))
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var started struct {
+		Data exportTask `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	exportID := started.Data.ID
+	assert.NotEmpty(t, exportID)
+
+	// 等待后台任务完成
+	var state exportTask
+	assert.Eventually(t, func() bool {
+		task, ok := handler.exportTasks.get(exportID)
+		if !ok {
+			return false
+		}
+		state = task.snapshot()
+		return state.Status != "running"
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "done", state.Status)
+	assert.Equal(t, int64(1), state.Rows)
+
+	// 其他用户与匿名请求看不到该任务
+	for _, userID := range []interface{}{uint(4), nil} {
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "exportId", Value: exportID}}
+		c.Request = httptest.NewRequest("GET", "/api/v1/exports/"+exportID+"/download", nil)
+		if userID != nil {
+			c.Set("userID", userID)
+		}
+		handler.DownloadExport(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "exportId", Value: exportID}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/exports/"+exportID+"/download", nil)
+	c.Set("userID", uint(3))
+	handler.DownloadExport(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".csv")
+	assert.Equal(t, [][]string{{"jobId"}, {"job-1"}}, readExportCSV(t, w.Body.String()))
+}
+
+func TestJobHandler_ExportAnalysesCSV_AsyncRequiresAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	handler := NewJobHandler(mockJobService, new(MockLLMService))
+	handler.SetExportConfig(config.ExportConfig{Dir: t.TempDir()})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export?async=true&columns=jobId", nil)
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockJobService.AssertNotCalled(t, "GetGroupedJobsByCursor", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestJobHandler_ExportTask_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewJobHandler(new(MockJobService), new(MockLLMService))
+	handler.SetExportConfig(config.ExportConfig{Dir: t.TempDir()})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "exportId", Value: "missing"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/exports/missing", nil)
+	handler.GetExportTask(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestExportTaskManager_CleanupExpired(t *testing.T) {
+	manager := newExportTaskManager(config.ExportConfig{Dir: t.TempDir(), RetentionHours: 1})
+	finished := time.Now().Add(-2 * time.Hour)
+	manager.tasks.Store("old", &exportTask{ID: "old", Size: 100, FinishedAt: &finished, path: manager.dir + "/old.csv"})
+	manager.tasks.Store("running", &exportTask{ID: "running", Status: "running"})
+	manager.used = 150
+
+	manager.cleanup(time.Now())
+
+	_, ok := manager.get("old")
+	assert.False(t, ok)
+	_, ok = manager.get("running")
+	assert.True(t, ok)
+	assert.Equal(t, int64(50), manager.used)
+}
+
+func TestExportTaskManager_RemovesStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "0123456789abcdef0123456789abcdef.csv")
+	other := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, os.WriteFile(stale, []byte("x"), 0o644))
+	assert.NoError(t, os.WriteFile(other, []byte("x"), 0o644))
+
+	newExportTaskManager(config.ExportConfig{Dir: dir})
+
+	_, err := os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(other)
+	assert.NoError(t, err)
+}
+
+func TestExportTaskManager_Limits(t *testing.T) {
+	manager := newExportTaskManager(config.ExportConfig{Dir: t.TempDir(), MaxRunning: 1, MaxTotalMB: 1})
+
+	release := make(chan struct{})
+	blocking, err := manager.start(3, "csv", "a.csv", func(ctx context.Context, out io.Writer, progress func(int)) error {
+		<-release
+		return nil
+	})
+	assert.NoError(t, err)
+	_, err = manager.start(3, "csv", "b.csv", func(ctx context.Context, out io.Writer, progress func(int)) error { return nil })
+	assert.ErrorIs(t, err, errExportBusy)
+	close(release)
+	assert.Eventually(t, func() bool { return blocking.snapshot().Status == "done" }, 2*time.Second, 10*time.Millisecond)
+
+	// 超出目录配额的任务失败并归还已占用的配额
+	big, err := manager.start(3, "csv", "c.csv", func(ctx context.Context, out io.Writer, progress func(int)) error {
+		_, err := out.Write(make([]byte, 2<<20))
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return big.snapshot().Status == "failed" }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, errExportQuota.Error(), big.snapshot().Error)
+	assert.Equal(t, int64(0), manager.used)
+}
+
+func TestExportTaskManager_Timeout(t *testing.T) {
+	manager := newExportTaskManager(config.ExportConfig{Dir: t.TempDir()})
+	manager.timeout = 10 * time.Millisecond
+
+	task, err := manager.start(3, "csv", "a.csv", func(ctx context.Context, out io.Writer, progress func(int)) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return task.snapshot().Status == "failed" }, 2*time.Second, 10*time.Millisecond)
+	assert.Contains(t, task.snapshot().Error, "timeout")
+}
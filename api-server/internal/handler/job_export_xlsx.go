@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"io"
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSX 工作表名称
+const (
+	xlsxSheetGroups     = "Job Groups"
+	xlsxSheetCards      = "NPU Cards"
+	xlsxSheetIssues     = "Issues"
+	xlsxSheetParameters = "Parameter Checks"
+)
+
+// ExportAnalysesXLSX 导出 AI 分析概览 Excel，scope、筛选、viewId、columns、async 参数与 CSV 导出一致。
+// 包含四个工作表：分组概览（columns 选择的列）、每卡硬件统计、问题列表（每个问题一行）、参数检查（每个检查项一行）；
+// 数字与时间按类型写入，表头冻结
+func (h *JobHandler) ExportAnalysesXLSX(c *gin.Context) {
+	h.serveAnalysisExport(c, exportFormatXLSX)
+}
+
+// xlsxAnalysisWriter XLSX 导出：各工作表使用流式写入，行数据超出内存阈值后由 excelize 暂存到临时文件，
+// Finish 时打包写出
+type xlsxAnalysisWriter struct {
+	out     io.Writer
+	columns []analysisCSVColumn
+	file    *excelize.File
+	groups  *xlsxSheetWriter
+	cards   *xlsxSheetWriter
+	issues  *xlsxSheetWriter
+	params  *xlsxSheetWriter
+}
+
+// xlsxSheetWriter 单个工作表的流式写入，记录下一行行号
+type xlsxSheetWriter struct {
+	sw        *excelize.StreamWriter
+	nextRow   int
+	dateStyle int
+}
+
+func newXLSXAnalysisWriter(out io.Writer, columns []analysisCSVColumn) (*xlsxAnalysisWriter, error) {
+	file := excelize.NewFile()
+	w := &xlsxAnalysisWriter{out: out, columns: columns, file: file}
+	if err := w.init(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// init 创建四个工作表并写入冻结的表头
+func (w *xlsxAnalysisWriter) init() error {
+	headerStyle, err := w.file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D9E1F2"}},
+	})
+	if err != nil {
+		return err
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	dateStyle, err := w.file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return err
+	}
+
+	groupHeaders := make([]string, len(w.columns))
+	groupWidths := make([]float64, len(w.columns))
+	for i, col := range w.columns {
+		groupHeaders[i] = col.Name
+		groupWidths[i] = xlsxColumnWidth(col.Name)
+	}
+	sheets := []struct {
+		name    string
+		headers []string
+		widths  []float64
+		target  **xlsxSheetWriter
+	}{
+		{xlsxSheetGroups, groupHeaders, groupWidths, &w.groups},
+		{xlsxSheetCards, []string{"jobId", "jobName", "nodeId", "npuId", "chips", "processMemoryMb",
+			"hbmUsageMb", "hbmTotalMb", "hbmUsagePercent", "aicoreUsagePercent"},
+			[]float64{20, 30, 16, 8, 8, 16, 14, 14, 16, 18}, &w.cards},
+		{xlsxSheetIssues, []string{"jobId", "jobName", "nodeId", "severity", "category", "description", "suggestion"},
+			[]float64{20, 30, 16, 10, 16, 60, 60}, &w.issues},
+		{xlsxSheetParameters, []string{"jobId", "jobName", "nodeId", "checkStatus", "parameter", "value", "assessment", "reason"},
+			[]float64{20, 30, 16, 12, 24, 24, 12, 60}, &w.params},
+	}
+	for i, sheet := range sheets {
+		if i == 0 {
+			// 新文件自带 Sheet1，重命名为第一个工作表
+			if err := w.file.SetSheetName("Sheet1", sheet.name); err != nil {
+				return err
+			}
+		} else if _, err := w.file.NewSheet(sheet.name); err != nil {
+			return err
+		}
+		sw, err := newXLSXSheetWriter(w.file, sheet.name, sheet.headers, sheet.widths, headerStyle, dateStyle)
+		if err != nil {
+			return err
+		}
+		*sheet.target = sw
+	}
+	w.file.SetActiveSheet(0)
+	return nil
+}
+
+func newXLSXSheetWriter(file *excelize.File, name string, headers []string, widths []float64, headerStyle, dateStyle int) (*xlsxSheetWriter, error) {
+	sw, err := file.NewStreamWriter(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return nil, err
+	}
+	for i, width := range widths {
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return nil, err
+		}
+	}
+	header := make([]interface{}, len(headers))
+	for i, h := range headers {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: h}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+	return &xlsxSheetWriter{sw: sw, nextRow: 2, dateStyle: dateStyle}, nil
+}
+
+func (s *xlsxSheetWriter) append(values []interface{}) error {
+	for i, v := range values {
+		if t, ok := v.(time.Time); ok {
+			values[i] = excelize.Cell{StyleID: s.dateStyle, Value: t}
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, s.nextRow)
+	if err != nil {
+		return err
+	}
+	s.nextRow++
+	return s.sw.SetRow(cell, values)
+}
+
+// needs 每卡统计工作表始终需要硬件数据
+func (w *xlsxAnalysisWriter) needs() (bool, bool) {
+	needScript, _ := columnNeeds(w.columns)
+	return needScript, true
+}
+
+func (w *xlsxAnalysisWriter) WriteChunk(rows []analysisExportRow) error {
+	for _, row := range rows {
+		job := row.Group.MainJob
+
+		values := make([]interface{}, len(w.columns))
+		for i, col := range w.columns {
+			if col.typed != nil {
+				values[i] = col.typed(row)
+			} else {
+				values[i] = col.value(row)
+			}
+		}
+		if err := w.groups.append(values); err != nil {
+			return err
+		}
+
+		for _, card := range row.Cards {
+			totals := sumHardwareTotals([]service.NPUCardInfo{card})
+			hbmPercent, hbmOK := totals.HBMUsagePercent()
+			aicore, aicoreOK := totals.AICoreUsagePercent()
+			if err := w.cards.append([]interface{}{
+				job.JobID, valueOrDash(job.JobName), valueOrDash(job.NodeID), card.NpuID, totals.Chips,
+				round2(totals.ProcessMemoryMB), round2(totals.HBMUsageMB), round2(totals.HBMTotalMB),
+				numberOrNil(hbmPercent, hbmOK), numberOrNil(aicore, aicoreOK),
+			}); err != nil {
+				return err
+			}
+		}
+
+		if row.Analysis == nil {
+			continue
+		}
+		for _, issue := range row.Analysis.Issues {
+			if err := w.issues.append([]interface{}{
+				job.JobID, valueOrDash(job.JobName), valueOrDash(job.NodeID),
+				issue.Severity, issue.Category, issue.Description, issue.Suggestion,
+			}); err != nil {
+				return err
+			}
+		}
+		if check := row.Analysis.ParameterCheck; check != nil {
+			for _, item := range check.Items {
+				if err := w.params.append([]interface{}{
+					job.JobID, valueOrDash(job.JobName), valueOrDash(job.NodeID),
+					check.Status, item.Parameter, item.Value, item.Assessment, item.Reason,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (w *xlsxAnalysisWriter) Finish() error {
+	defer w.file.Close()
+	for _, s := range []*xlsxSheetWriter{w.groups, w.cards, w.issues, w.params} {
+		if err := s.sw.Flush(); err != nil {
+			return err
+		}
+	}
+	return w.file.Write(w.out)
+}
+
+func (w *xlsxAnalysisWriter) Discard() {
+	w.file.Close()
+}
+
+// xlsxColumnWidth 分组工作表的列宽，长文本列加宽
+func xlsxColumnWidth(name string) float64 {
+	switch name {
+	case "commandLine", "summary", "startupScript":
+		return 60
+	case "jobName", "hardwareOccupancy":
+		return 30
+	case "startTime":
+		return 20
+	default:
+		return 16
+	}
+}
+
+// timeOrNil 毫秒时间戳转为本地时间，缺失时返回 nil（空单元格）
+func timeOrNil(v *int64) interface{} {
+	if v == nil || *v <= 0 {
+		return nil
+	}
+	return time.UnixMilli(*v)
+}
+
+// hardwareNumber 有硬件数据时返回保留两位小数的数值，否则返回 nil
+func hardwareNumber(row analysisExportRow, v float64, ok bool) interface{} {
+	if len(row.Cards) == 0 {
+		return nil
+	}
+	return numberOrNil(v, ok)
+}
+
+func numberOrNil(v float64, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return round2(v)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestJobHandler_ExportAnalysesXLSX(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockLLMService := new(MockLLMService)
+	handler := NewJobHandler(mockJobService, mockLLMService)
+
+	jobName := "worker-1"
+	nodeID := "node-001"
+	startTime := int64(1736039823000)
+	cardCount := 2
+	hbmUsage := 2048.0
+	hbmTotal := 4096.0
+	aiCore := 88.0
+
+	mainJob := model.Job{JobID: "job-001", JobName: &jobName, NodeID: &nodeID, StartTime: &startTime}
+	mockJobService.On("GetGroupedJobsByCursor", service.JobGroupFilter{JobFilter: service.JobFilter{NodeIDs: []string{"node-001"}}}, "", "", "", defaultExportChunkSize).
+		Return([]service.JobGroup{{MainJob: mainJob, CardCount: &cardCount}}, int64(1), "", nil)
+	mockJobService.On("GetJobsNPUCards", []model.Job{mainJob}).Return(map[string][]service.NPUCardInfo{
+		"job-001": {
+			{NpuID: 0, MemoryUsageMB: 1024, Metrics: []model.NPUMetric{{HBMUsageMB: &hbmUsage, HBMTotalMB: &hbmTotal, AICoreUsagePercent: &aiCore}}},
+			{NpuID: 1, MemoryUsageMB: 512},
+		},
+	}, nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-001"}).Return(map[string]*service.JobAnalysisResponse{
+		"job-001": {
+			Issues: []service.JobAnalysisIssue{
+				{Severity: "warning", Category: "perf", Description: "low aicore"},
+				{Severity: "info", Category: "config", Description: "no profiling"},
+			},
+			ParameterCheck: &service.JobAnalysisParameterCheck{
+				Status: "warning",
+				Items:  []service.JobAnalysisParameterItem{{Parameter: "batch_size", Value: "1", Assessment: "low"}},
+			},
+		},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export/xlsx?nodeId=node-001&columns=jobId&columns=startTime&columns=cardCount&columns=hbmUsagePercent", nil)
+
+	handler.ExportAnalysesXLSX(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".xlsx")
+
+	file, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+	assert.Equal(t, []string{xlsxSheetGroups, xlsxSheetCards, xlsxSheetIssues, xlsxSheetParameters}, file.GetSheetList())
+
+	rows, err := file.GetRows(xlsxSheetGroups)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jobId", "startTime", "cardCount", "hbmUsagePercent"}, rows[0])
+	// 数字与时间按类型写入
+	for _, cell := range []string{"B2", "C2", "D2"} {
+		cellType, err := file.GetCellType(xlsxSheetGroups, cell)
+		assert.NoError(t, err)
+		assert.NotEqual(t, excelize.CellTypeSharedString, cellType, cell)
+	}
+	value, _ := file.GetCellValue(xlsxSheetGroups, "D2", excelize.Options{RawCellValue: true})
+	assert.Equal(t, "50", value)
+
+	panes, err := file.GetPanes(xlsxSheetGroups)
+	assert.NoError(t, err)
+	assert.True(t, panes.Freeze)
+	assert.Equal(t, 1, panes.YSplit)
+
+	cardRows, _ := file.GetRows(xlsxSheetCards)
+	assert.Len(t, cardRows, 3)
+	issueRows, _ := file.GetRows(xlsxSheetIssues)
+	assert.Len(t, issueRows, 3)
+	paramRows, _ := file.GetRows(xlsxSheetParameters)
+	if assert.Len(t, paramRows, 2) {
+		assert.Equal(t, "batch_size", paramRows[1][4])
+	}
+	// 未选中启动脚本列时不查询代码
+	mockJobService.AssertNotCalled(t, "GetJobCodes", mock.Anything)
+	mockJobService.AssertExpectations(t)
+}
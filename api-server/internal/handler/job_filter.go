@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// groupFilterParams parseGroupFilter 识别的全部筛选参数
+var groupFilterParams = map[string]struct{}{
+	"nodeId": {}, "status": {}, "type": {}, "framework": {}, "cardCount": {}, "minCardCount": {}, "maxCardCount": {},
+	"startTime": {}, "endTime": {}, "endFrom": {}, "endTo": {}, "search": {}, "projectId": {},
+	"category": {}, "subCategory": {}, "inferenceFramework": {}, "modelName": {}, "modelSize": {},
+	"precision": {}, "npuUtilization": {}, "hbmUtilization": {}, "issueSeverity": {},
+}
+
+// parseJobFilter 解析作业列表通用筛选参数：
+// nodeId、status、type、framework 可重复；startTime/endTime 为启动时间范围（RFC3339 或毫秒时间戳）；
+// endFrom/endTo 为结束时间范围，未结束的作业满足 endFrom、不满足 endTo；search 为关键词；
+// projectId 为归属项目，可重复，unassigned 表示未归属任何项目
+func parseJobFilter(query url.Values) (service.JobFilter, error) {
+	filter := service.JobFilter{
+		NodeIDs:    nonEmpty(query["nodeId"]),
+		Statuses:   query["status"],
+		JobTypes:   query["type"],
+		Frameworks: query["framework"],
+		Search:     query.Get("search"),
+	}
+	var err error
+	if filter.StartFrom, err = parseTimeParam(query, "startTime"); err != nil {
+		return filter, err
+	}
+	if filter.StartTo, err = parseTimeParam(query, "endTime"); err != nil {
+		return filter, err
+	}
+	if filter.StartFrom != nil && filter.StartTo != nil && *filter.StartFrom > *filter.StartTo {
+		return filter, fmt.Errorf("startTime must not be after endTime")
+	}
+	if filter.EndFrom, err = parseTimeParam(query, "endFrom"); err != nil {
+		return filter, err
+	}
+	if filter.EndTo, err = parseTimeParam(query, "endTo"); err != nil {
+		return filter, err
+	}
+	if filter.EndFrom != nil && filter.EndTo != nil && *filter.EndFrom > *filter.EndTo {
+		return filter, fmt.Errorf("endFrom must not be after endTo")
+	}
+	if filter.Projects.ProjectIDs, err = parseProjectIDs(query); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseProjectIDs 解析归属项目参数 projectId（可重复），unassigned 表示未归属任何项目
+func parseProjectIDs(query url.Values) ([]uint, error) {
+	var ids []uint
+	for _, s := range nonEmpty(query["projectId"]) {
+		if s == "unassigned" {
+			// 未归属用 0 表示
+			ids = append(ids, 0)
+			continue
+		}
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid projectId %q", s)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// parseGroupFilter 在作业筛选参数基础上解析分组卡数筛选 cardCount（可重复，unknown 表示卡数未知）、
+// 卡数范围 minCardCount/maxCardCount（含边界，卡数未知的分组不匹配）
+// 与主进程 AI 分析字段筛选（均可重复）：category、subCategory、inferenceFramework、modelName、modelSize、
+// precision、npuUtilization、hbmUtilization、issueSeverity。
+// 启用持久化分组时卡数取自 job_groups.card_count，由后台同步维护，新占用或释放的卡最多滞后一个
+// job_groups.sync_interval_seconds 才反映到卡数筛选中
+func parseGroupFilter(query url.Values) (service.JobGroupFilter, error) {
+	jobFilter, err := parseJobFilter(query)
+	if err != nil {
+		return service.JobGroupFilter{}, err
+	}
+	filter := service.JobGroupFilter{
+		JobFilter: jobFilter,
+		Analysis: service.AnalysisFilter{
+			Categories:          nonEmpty(query["category"]),
+			SubCategories:       nonEmpty(query["subCategory"]),
+			InferenceFrameworks: nonEmpty(query["inferenceFramework"]),
+			ModelNames:          nonEmpty(query["modelName"]),
+			ModelSizes:          nonEmpty(query["modelSize"]),
+			Precisions:          nonEmpty(query["precision"]),
+			NPUUtilizations:     nonEmpty(query["npuUtilization"]),
+			HBMUtilizations:     nonEmpty(query["hbmUtilization"]),
+			IssueSeverities:     nonEmpty(query["issueSeverity"]),
+		},
+	}
+	for _, s := range query["cardCount"] {
+		if s == "unknown" {
+			// unknown 用 0 表示，service 层会匹配 CardCount == nil
+			filter.CardCounts = append(filter.CardCounts, 0)
+		} else if v, err := strconv.Atoi(s); err == nil {
+			filter.CardCounts = append(filter.CardCounts, v)
+		}
+	}
+	if filter.MinCardCount, err = parseCardCountParam(query, "minCardCount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxCardCount, err = parseCardCountParam(query, "maxCardCount"); err != nil {
+		return filter, err
+	}
+	if filter.MinCardCount != nil && filter.MaxCardCount != nil && *filter.MinCardCount > *filter.MaxCardCount {
+		return filter, fmt.Errorf("minCardCount must not be greater than maxCardCount")
+	}
+	return filter, nil
+}
+
+// parseCardCountParam 解析卡数范围参数，须为非负整数；参数缺失或为空时返回 nil
+func parseCardCountParam(query url.Values, name string) (*int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("invalid %s %q: expect a non-negative integer", name, raw)
+	}
+	return &v, nil
+}
+
+// parseTimeParam 解析时间参数为毫秒时间戳，支持 RFC3339 与毫秒时间戳；参数缺失或为空时返回 nil
+func parseTimeParam(query url.Values, name string) (*int64, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return &ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: expect RFC3339 or epoch milliseconds", name, raw)
+	}
+	ms := t.UnixMilli()
+	return &ms, nil
+}
+
+// checkCardCountParams 校验 cardCount 取值为整数或 unknown；列表接口忽略非法值，保存或提交前需要显式报错
+func checkCardCountParams(query url.Values) error {
+	for _, s := range query["cardCount"] {
+		if _, err := strconv.Atoi(s); err != nil && s != "unknown" {
+			return fmt.Errorf("invalid cardCount %q", s)
+		}
+	}
+	return nil
+}
+
+// nonEmpty 去掉空字符串，兼容前端传 nodeId= 表示不限
+func nonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
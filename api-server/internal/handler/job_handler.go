@@ -1,9 +1,9 @@
 package handler
 
 import (
-	"encoding/csv"
 	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -40,11 +40,28 @@ var (
 	batchIDSeq  int64
 )
 
+// BatchAnalyzeStats 统计运行中的批量分析：批次数与尚未完成的作业数
+func BatchAnalyzeStats() (running int, pendingJobs int64) {
+	batchStates.Range(func(_, v interface{}) bool {
+		state := v.(*batchAnalyzeState)
+		if state.Status == "running" {
+			running++
+			pendingJobs += int64(state.Total) - atomic.LoadInt64(&state.Current)
+		}
+		return true
+	})
+	return running, pendingJobs
+}
+
 // JobHandler 作业处理器
 type JobHandler struct {
 	jobService       service.JobServiceInterface
 	llmService       service.LLMServiceInterface
-	batchConcurrency int
+	viewService      service.SavedViewServiceInterface
+	projectService   service.ProjectServiceInterface
+	exportChunkSize  int
+	exportTasks      *exportTaskManager
+	batchConcurrency int64 // 原子读写，配置热加载时更新
 }
 
 // NewJobHandler 创建作业处理器
@@ -57,18 +74,69 @@ func NewJobHandler(jobService service.JobServiceInterface, llmService service.LL
 	return &JobHandler{
 		jobService:       jobService,
 		llmService:       llmService,
-		batchConcurrency: concurrency,
+		exportChunkSize:  defaultExportChunkSize,
+		batchConcurrency: int64(concurrency),
+	}
+}
+
+// SetBatchConcurrency 更新批量分析并发数，仅对之后提交的批次生效
+func (h *JobHandler) SetBatchConcurrency(n int) {
+	if n <= 0 {
+		return
+	}
+	atomic.StoreInt64(&h.batchConcurrency, int64(n))
+}
+
+// SetSavedViewService 启用保存视图：分组列表与导出接口可通过 viewId 引用视图
+func (h *JobHandler) SetSavedViewService(viewService service.SavedViewServiceInterface) {
+	h.viewService = viewService
+}
+
+// SetProjectService 启用项目可见范围：作业列表、统计、导出与作业详情只返回当前用户可见的作业
+func (h *JobHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
+// applyProjectScope 将当前用户的项目可见范围合并到筛选条件；查询失败时写入错误响应并返回 false
+func (h *JobHandler) applyProjectScope(c *gin.Context, filter *service.JobFilter) bool {
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return false
+	}
+	filter.Projects.Restricted = scope.Restricted
+	filter.Projects.VisibleIDs = scope.VisibleIDs
+	return true
+}
+
+// checkJobVisible 作业不在当前用户的可见范围内时按不存在处理，写入错误响应并返回 false
+func (h *JobHandler) checkJobVisible(c *gin.Context, jobID string) bool {
+	if h.projectService == nil {
+		return true
+	}
+	visible, err := h.projectService.CanViewJob(jobID, currentUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
+		return false
+	}
+	if !visible {
+		utils.ErrorResponse(c, 404, "Job not found")
+		return false
 	}
+	return true
 }
 
 // GetJobs 获取作业列表
-// 支持多条件筛选：nodeId、status、type、framework可以单独使用或组合使用
+// 支持多条件筛选：nodeId、status、type、framework、startTime/endTime、search可以单独使用或组合使用
 // 支持排序：sortBy指定排序字段，sortOrder指定排序方向(asc/desc)
 func (h *JobHandler) GetJobs(c *gin.Context) {
-	nodeID := c.Query("nodeId")
-	statuses := c.QueryArray("status")
-	jobTypes := c.QueryArray("type")
-	frameworks := c.QueryArray("framework")
+	filter, err := parseJobFilter(c.Request.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+	if !h.applyProjectScope(c, &filter) {
+		return
+	}
 	sortBy := c.Query("sortBy")
 	sortOrder := c.Query("sortOrder")
 
@@ -84,7 +152,18 @@ func (h *JobHandler) GetJobs(c *gin.Context) {
 		pageSize = 100
 	}
 
-	jobs, total, err := h.jobService.GetJobs(nodeID, statuses, jobTypes, frameworks, sortBy, sortOrder, page, pageSize)
+	// 传入 cursor 参数（第一页为空值）时使用键集分页
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		jobs, total, next, err := h.jobService.GetJobsByCursor(filter, sortBy, sortOrder, cursor, pageSize)
+		if err != nil {
+			respondCursorError(c, err)
+			return
+		}
+		utils.SuccessResponse(c, cursorPaginationResponse(jobs, pageSize, total, next))
+		return
+	}
+
+	jobs, total, err := h.jobService.GetJobs(filter, sortBy, sortOrder, page, pageSize)
 	if err != nil {
 		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
 		return
@@ -109,6 +188,9 @@ func (h *JobHandler) GetJobs(c *gin.Context) {
 // GetJobByID 获取作业详情（含 NPU 卡信息和关联进程）
 func (h *JobHandler) GetJobByID(c *gin.Context) {
 	jobID := c.Param("jobId")
+	if !h.checkJobVisible(c, jobID) {
+		return
+	}
 	aggregate := c.DefaultQuery("aggregate", "true") != "false"
 
 	detail, err := h.jobService.GetJobDetail(jobID, aggregate)
@@ -127,6 +209,9 @@ func (h *JobHandler) GetJobByID(c *gin.Context) {
 // GetJobParameters 获取作业参数
 func (h *JobHandler) GetJobParameters(c *gin.Context) {
 	jobID := c.Param("jobId")
+	if !h.checkJobVisible(c, jobID) {
+		return
+	}
 
 	params, err := h.jobService.GetJobParameters(jobID)
 	if err != nil {
@@ -140,6 +225,9 @@ func (h *JobHandler) GetJobParameters(c *gin.Context) {
 // GetJobCode 获取作业代码
 func (h *JobHandler) GetJobCode(c *gin.Context) {
 	jobID := c.Param("jobId")
+	if !h.checkJobVisible(c, jobID) {
+		return
+	}
 
 	code, err := h.jobService.GetJobCode(jobID)
 	if err != nil {
@@ -151,29 +239,28 @@ func (h *JobHandler) GetJobCode(c *gin.Context) {
 }
 
 // GetGroupedJobs 获取分组作业列表（按 node_id+pgid 分组）
+// 传入 viewId 时以保存视图的参数为默认值，请求中显式传入的参数优先
 func (h *JobHandler) GetGroupedJobs(c *gin.Context) {
-	nodeID := c.Query("nodeId")
-	statuses := c.QueryArray("status")
-	jobTypes := c.QueryArray("type")
-	frameworks := c.QueryArray("framework")
-	cardCountStrs := c.QueryArray("cardCount")
-	var cardCounts []int
-	for _, s := range cardCountStrs {
-		if s == "unknown" {
-			// unknown 用 0 表示，service 层会匹配 CardCount == nil
-			cardCounts = append(cardCounts, 0)
-		} else if v, err := strconv.Atoi(s); err == nil {
-			cardCounts = append(cardCounts, v)
-		}
+	query, _, ok := h.resolveViewQuery(c)
+	if !ok {
+		return
 	}
-	sortBy := c.Query("sortBy")
-	sortOrder := c.Query("sortOrder")
+	filter, err := parseGroupFilter(query)
+	if err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+	if !h.applyProjectScope(c, &filter.JobFilter) {
+		return
+	}
+	sortBy := query.Get("sortBy")
+	sortOrder := query.Get("sortOrder")
 
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	page, err := strconv.Atoi(query.Get("page"))
 	if err != nil || page < 1 {
 		page = 1
 	}
-	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
 	if err != nil || pageSize < 1 {
 		pageSize = 20
 	}
@@ -181,7 +268,17 @@ func (h *JobHandler) GetGroupedJobs(c *gin.Context) {
 		pageSize = 100
 	}
 
-	groups, total, err := h.jobService.GetGroupedJobs(nodeID, statuses, jobTypes, frameworks, cardCounts, sortBy, sortOrder, page, pageSize)
+	if cursor, ok := query["cursor"]; ok {
+		groups, total, next, err := h.jobService.GetGroupedJobsByCursor(filter, sortBy, sortOrder, cursor[0], pageSize)
+		if err != nil {
+			respondCursorError(c, err)
+			return
+		}
+		utils.SuccessResponse(c, cursorPaginationResponse(groups, pageSize, total, next))
+		return
+	}
+
+	groups, total, err := h.jobService.GetGroupedJobs(filter, sortBy, sortOrder, page, pageSize)
 	if err != nil {
 		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
 		return
@@ -203,9 +300,35 @@ func (h *JobHandler) GetGroupedJobs(c *gin.Context) {
 	})
 }
 
-// GetDistinctCardCounts 获取所有去重的卡数值
+// cursorPaginationResponse 游标分页响应：page 为 0，nextCursor 为空表示已到最后一页
+func cursorPaginationResponse(items interface{}, pageSize int, total int64, next string) utils.PaginationResponse {
+	return utils.PaginationResponse{
+		Items: items,
+		Pagination: utils.Pagination{
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+		NextCursor: next,
+	}
+}
+
+// respondCursorError 游标无效或排序字段不支持游标时返回 400
+func respondCursorError(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrInvalidCursor) || errors.Is(err, service.ErrCursorSortUnsupported) {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+	utils.ErrorResponse(c, 500, "Database error: "+err.Error())
+}
+
+// GetDistinctCardCounts 获取可见范围内所有去重的卡数值，可用 projectId 进一步限定项目
 func (h *JobHandler) GetDistinctCardCounts(c *gin.Context) {
-	counts, err := h.jobService.GetDistinctCardCounts()
+	scope, ok := requestScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	counts, err := h.jobService.GetDistinctCardCounts(scope)
 	if err != nil {
 		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
 		return
@@ -213,9 +336,17 @@ func (h *JobHandler) GetDistinctCardCounts(c *gin.Context) {
 	utils.SuccessResponse(c, counts)
 }
 
-// GetJobStats 获取作业统计信息
+// GetJobStats 获取作业统计信息，支持与分组列表相同的筛选参数
 func (h *JobHandler) GetJobStats(c *gin.Context) {
-	stats, err := h.jobService.GetJobStats()
+	filter, err := parseGroupFilter(c.Request.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+	if !h.applyProjectScope(c, &filter.JobFilter) {
+		return
+	}
+	stats, err := h.jobService.GetJobStats(filter)
 	if err != nil {
 		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
 		return
@@ -232,6 +363,9 @@ func (h *JobHandler) AnalyzeJob(c *gin.Context) {
 	}
 
 	jobID := c.Param("jobId")
+	if !h.checkJobVisible(c, jobID) {
+		return
+	}
 	var req struct {
 		ModelID string `json:"modelId"`
 	}
@@ -274,6 +408,9 @@ func (h *JobHandler) GetJobAnalysis(c *gin.Context) {
 	}
 
 	jobID := c.Param("jobId")
+	if !h.checkJobVisible(c, jobID) {
+		return
+	}
 
 	result, err := h.llmService.GetAnalysis(jobID)
 	if err != nil {
@@ -298,6 +435,11 @@ func (h *JobHandler) BatchAnalyze(c *gin.Context) {
 		utils.ErrorResponse(c, 400, "jobIds is required")
 		return
 	}
+	for _, jobID := range req.JobIDs {
+		if !h.checkJobVisible(c, jobID) {
+			return
+		}
+	}
 
 	batchID := fmt.Sprintf("batch-%d-%d", time.Now().UnixMilli(), atomic.AddInt64(&batchIDSeq, 1))
 	state := &batchAnalyzeState{
@@ -307,8 +449,9 @@ func (h *JobHandler) BatchAnalyze(c *gin.Context) {
 	}
 	batchStates.Store(batchID, state)
 
+	concurrency := atomic.LoadInt64(&h.batchConcurrency)
 	go func() {
-		sem := make(chan struct{}, h.batchConcurrency)
+		sem := make(chan struct{}, concurrency)
 		var wg sync.WaitGroup
 		for _, jobID := range req.JobIDs {
 			// 检查是否已取消
@@ -364,9 +507,20 @@ func (h *JobHandler) BatchAnalyze(c *gin.Context) {
 	utils.SuccessResponse(c, gin.H{"batchId": batchID})
 }
 
-// GetBatchAnalyses 批量获取分析摘要
+// GetBatchAnalyses 批量获取分析摘要，不在项目可见范围内的作业不返回
 func (h *JobHandler) GetBatchAnalyses(c *gin.Context) {
 	jobIDs := c.QueryArray("jobIds")
+	if len(jobIDs) > 0 && h.projectService != nil {
+		scope, ok := projectScope(c, h.projectService)
+		if !ok {
+			return
+		}
+		var err error
+		if jobIDs, err = h.projectService.FilterVisibleJobIDs(jobIDs, scope); err != nil {
+			utils.ErrorResponse(c, 500, "Database error: "+err.Error())
+			return
+		}
+	}
 	if len(jobIDs) == 0 {
 		utils.SuccessResponse(c, gin.H{})
 		return
@@ -384,163 +538,265 @@ func (h *JobHandler) GetBatchAnalyses(c *gin.Context) {
 // - filtered: 导出当前筛选条件下的全部主作业
 // - page: 导出当前页主作业
 // - selected: 导出 jobIds 指定的主作业
+// columns 可重复，按给定顺序导出指定列；未传时使用 viewId 视图中保存的列，均为空则导出全部列。
+// 分批查询并逐批写出；async=true 时在后台写入下载目录并返回导出任务ID
 func (h *JobHandler) ExportAnalysesCSV(c *gin.Context) {
+	h.serveAnalysisExport(c, exportFormatCSV)
+}
+
+// analysisExportSpec 导出参数：scope、筛选条件、排序与列选择，CSV 与 XLSX 导出共用
+type analysisExportSpec struct {
+	scope       string
+	filter      service.JobGroupFilter
+	sortBy      string
+	sortOrder   string
+	page        int
+	pageSize    int
+	selectedIDs []string
+	columns     []analysisCSVColumn
+}
+
+// parseAnalysisExport 解析导出参数（scope、筛选、viewId、columns）；参数错误时写入错误响应并返回 ok=false
+func (h *JobHandler) parseAnalysisExport(c *gin.Context) (*analysisExportSpec, bool) {
 	if h.llmService == nil {
 		utils.ErrorResponse(c, 501, "LLM service is not configured")
-		return
+		return nil, false
 	}
 
-	scope := c.DefaultQuery("scope", "filtered")
-	nodeID := c.Query("nodeId")
-	statuses := c.QueryArray("status")
-	jobTypes := c.QueryArray("type")
-	frameworks := c.QueryArray("framework")
-	cardCountStrs := c.QueryArray("cardCount")
-	var cardCounts []int
-	for _, s := range cardCountStrs {
-		if s == "unknown" {
-			cardCounts = append(cardCounts, 0)
-		} else if v, err := strconv.Atoi(s); err == nil {
-			cardCounts = append(cardCounts, v)
-		}
+	query, viewColumns, ok := h.resolveViewQuery(c)
+	if !ok {
+		return nil, false
+	}
+	spec := &analysisExportSpec{
+		scope:       query.Get("scope"),
+		sortBy:      query.Get("sortBy"),
+		sortOrder:   query.Get("sortOrder"),
+		selectedIDs: dedupeStrings(query["jobIds"]),
+	}
+	if spec.scope == "" {
+		spec.scope = "filtered"
+	}
+	var err error
+	if spec.filter, err = parseGroupFilter(query); err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return nil, false
+	}
+	if !h.applyProjectScope(c, &spec.filter.JobFilter) {
+		return nil, false
+	}
+	columnNames := nonEmpty(query["columns"])
+	if len(columnNames) == 0 {
+		columnNames = viewColumns
+	}
+	if spec.columns, err = selectAnalysisCSVColumns(columnNames); err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return nil, false
 	}
-	sortBy := c.Query("sortBy")
-	sortOrder := c.Query("sortOrder")
 
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
+	spec.page, err = strconv.Atoi(query.Get("page"))
+	if err != nil || spec.page < 1 {
+		spec.page = 1
 	}
-	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-	if err != nil || pageSize < 1 {
-		pageSize = 20
+	spec.pageSize, err = strconv.Atoi(query.Get("pageSize"))
+	if err != nil || spec.pageSize < 1 {
+		spec.pageSize = 20
 	}
 
-	selectedIDs := dedupeStrings(c.QueryArray("jobIds"))
-	if scope == "selected" && len(selectedIDs) == 0 {
+	if spec.scope == "selected" && len(spec.selectedIDs) == 0 {
 		utils.ErrorResponse(c, 400, "jobIds is required when scope=selected")
-		return
+		return nil, false
 	}
+	return spec, true
+}
 
-	queryPage := page
-	queryPageSize := pageSize
-	if scope == "filtered" || scope == "selected" {
-		queryPage = 1
-		queryPageSize = 100000
+// columnNeeds 判断所选列是否需要查询启动脚本与硬件统计
+func columnNeeds(columns []analysisCSVColumn) (needScript, needHardware bool) {
+	for _, col := range columns {
+		needScript = needScript || col.needs == needStartupScript
+		needHardware = needHardware || col.needs == needHardwareStats
 	}
+	return needScript, needHardware
+}
 
-	groups, _, err := h.jobService.GetGroupedJobs(nodeID, statuses, jobTypes, frameworks, cardCounts, sortBy, sortOrder, queryPage, queryPageSize)
+// resolveViewQuery 合并 viewId 引用的保存视图参数与请求参数，请求中显式传入的参数优先；
+// 同时返回视图保存的导出列。视图不存在或不可见时写入错误响应并返回 ok=false
+func (h *JobHandler) resolveViewQuery(c *gin.Context) (query url.Values, columns []string, ok bool) {
+	query = c.Request.URL.Query()
+	rawID := query.Get("viewId")
+	if rawID == "" {
+		return query, nil, true
+	}
+	if h.viewService == nil {
+		utils.ErrorResponse(c, 501, "saved views are not configured")
+		return nil, nil, false
+	}
+	id, err := strconv.ParseUint(rawID, 10, 64)
 	if err != nil {
-		utils.ErrorResponse(c, 500, "Database error: "+err.Error())
-		return
+		utils.ErrorResponse(c, 400, "invalid viewId")
+		return nil, nil, false
 	}
-
-	if scope == "selected" {
-		idSet := make(map[string]struct{}, len(selectedIDs))
-		for _, id := range selectedIDs {
-			idSet[id] = struct{}{}
+	view, err := h.viewService.Get(uint(id), currentUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrViewNotFound) {
+			utils.ErrorResponse(c, 404, err.Error())
+		} else {
+			utils.ErrorResponse(c, 500, "Database error: "+err.Error())
 		}
-		filtered := make([]service.JobGroup, 0, len(selectedIDs))
-		for _, g := range groups {
-			if _, ok := idSet[g.MainJob.JobID]; ok {
-				filtered = append(filtered, g)
-			}
+		return nil, nil, false
+	}
+	for key, values := range view.Params {
+		if _, exists := query[key]; !exists {
+			query[key] = values
 		}
-		groups = filtered
 	}
+	return query, view.Columns, true
+}
 
-	jobIDs := make([]string, 0, len(groups))
-	for _, g := range groups {
-		jobIDs = append(jobIDs, g.MainJob.JobID)
-	}
-	analyses, err := h.llmService.GetBatchAnalyses(jobIDs)
-	if err != nil {
-		utils.ErrorResponse(c, 500, "failed to fetch analyses: "+err.Error())
-		return
+// currentUserID 返回认证中间件写入的用户 ID，匿名请求返回 0
+func currentUserID(c *gin.Context) uint {
+	if v, exists := c.Get("userID"); exists {
+		if id, ok := v.(uint); ok {
+			return id
+		}
 	}
+	return 0
+}
 
-	filename := fmt.Sprintf("ai-analysis-overview_%s.csv", time.Now().Format("20060102_150405"))
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-	c.Header("Cache-Control", "no-store")
-
-	_, _ = c.Writer.Write([]byte("\xEF\xBB\xBF"))
-	writer := csv.NewWriter(c.Writer)
-	defer writer.Flush()
+// analysisExportRow 导出单行所需的数据
+type analysisExportRow struct {
+	Group         service.JobGroup
+	Analysis      *service.JobAnalysisResponse
+	StartupScript string
+	Cards         []service.NPUCardInfo
+	Hardware      exportHardwareStats
+}
 
-	_ = writer.Write([]string{
-		"jobId", "jobName", "nodeId", "status", "jobType", "framework", "processName", "commandLine", "startupScript", "startTime", "cardCount",
-		"processMemoryMb", "hbmUsageMb", "hbmTotalMb", "hbmUsagePercent", "aicoreUsagePercent", "hardwareOccupancy",
-		"summary", "taskType", "modelName", "runtimeStatus", "npuUtilization", "hbmUtilization", "issuesCount",
-	})
+// 导出列依赖的额外查询
+const (
+	needNothing = iota
+	needStartupScript
+	needHardwareStats
+)
 
-	for _, group := range groups {
-		job := group.MainJob
-		analysis := analyses[job.JobID]
+// analysisCSVColumn 导出列定义；typed 为 XLSX 中的类型化取值（数字、时间，nil 表示空单元格），
+// 未设置时使用 value 的文本
+type analysisCSVColumn struct {
+	Name  string
+	needs int
+	value func(row analysisExportRow) string
+	typed func(row analysisExportRow) interface{}
+}
 
-		startupScript := "-"
-		if codes, codeErr := h.jobService.GetJobCode(job.JobID); codeErr == nil {
-			startupScript = extractStartupScript(codes)
+// analysisCSVColumns 全部导出列，顺序即默认导出顺序
+var analysisCSVColumns = []analysisCSVColumn{
+	{Name: "jobId", value: func(r analysisExportRow) string { return r.Group.MainJob.JobID }},
+	{Name: "jobName", value: func(r analysisExportRow) string { return valueOrDash(r.Group.MainJob.JobName) }},
+	{Name: "nodeId", value: func(r analysisExportRow) string { return valueOrDash(r.Group.MainJob.NodeID) }},
+	{Name: "status", value: func(r analysisExportRow) string { return valueOrDash(r.Group.MainJob.Status) }},
+	{Name: "jobType", value: func(r analysisExportRow) string { return valueOrDash(r.Group.MainJob.JobType) }},
+	{Name: "framework", value: func(r analysisExportRow) string { return valueOrDash(r.Group.MainJob.Framework) }},
+	{Name: "processName", value: func(r analysisExportRow) string { return valueOrDash(r.Group.MainJob.ProcessName) }},
+	{Name: "commandLine", value: func(r analysisExportRow) string { return valueOrDash(r.Group.MainJob.CommandLine) }},
+	{Name: "startupScript", needs: needStartupScript, value: func(r analysisExportRow) string { return r.StartupScript }},
+	{Name: "startTime", value: func(r analysisExportRow) string { return formatTimeMs(r.Group.MainJob.StartTime) },
+		typed: func(r analysisExportRow) interface{} { return timeOrNil(r.Group.MainJob.StartTime) }},
+	{Name: "cardCount", value: func(r analysisExportRow) string {
+		if r.Group.CardCount == nil {
+			return "unknown"
 		}
-
-		hwStats := exportHardwareStats{}
-		if detail, detailErr := h.jobService.GetJobDetail(job.JobID, true); detailErr == nil && detail != nil {
-			hwStats = buildExportHardwareStats(detail.NPUCards)
+		return strconv.Itoa(*r.Group.CardCount)
+	}, typed: func(r analysisExportRow) interface{} {
+		if r.Group.CardCount == nil {
+			return nil
 		}
-
-		cardCount := "unknown"
-		if group.CardCount != nil {
-			cardCount = strconv.Itoa(*group.CardCount)
+		return *r.Group.CardCount
+	}},
+	{Name: "processMemoryMb", needs: needHardwareStats, value: func(r analysisExportRow) string { return r.Hardware.ProcessMemoryMB },
+		typed: func(r analysisExportRow) interface{} {
+			return hardwareNumber(r, r.Hardware.totals.ProcessMemoryMB, true)
+		}},
+	{Name: "hbmUsageMb", needs: needHardwareStats, value: func(r analysisExportRow) string { return r.Hardware.HBMUsageMB },
+		typed: func(r analysisExportRow) interface{} { return hardwareNumber(r, r.Hardware.totals.HBMUsageMB, true) }},
+	{Name: "hbmTotalMb", needs: needHardwareStats, value: func(r analysisExportRow) string { return r.Hardware.HBMTotalMB },
+		typed: func(r analysisExportRow) interface{} { return hardwareNumber(r, r.Hardware.totals.HBMTotalMB, true) }},
+	{Name: "hbmUsagePercent", needs: needHardwareStats, value: func(r analysisExportRow) string { return r.Hardware.HBMUsagePercent },
+		typed: func(r analysisExportRow) interface{} {
+			v, ok := r.Hardware.totals.HBMUsagePercent()
+			return hardwareNumber(r, v, ok)
+		}},
+	{Name: "aicoreUsagePercent", needs: needHardwareStats, value: func(r analysisExportRow) string { return r.Hardware.AICoreUsagePercent },
+		typed: func(r analysisExportRow) interface{} {
+			v, ok := r.Hardware.totals.AICoreUsagePercent()
+			return hardwareNumber(r, v, ok)
+		}},
+	{Name: "hardwareOccupancy", needs: needHardwareStats, value: func(r analysisExportRow) string { return r.Hardware.HardwareOccupancy }},
+	{Name: "summary", value: func(r analysisExportRow) string {
+		if r.Analysis == nil {
+			return ""
 		}
-
-		summary := ""
-		taskType := ""
-		modelName := ""
-		runtimeStatus := ""
-		npuUtil := ""
-		hbmUtil := ""
-		issuesCount := "0"
-		if analysis != nil {
-			summary = analysis.Summary
-			taskType = analysis.TaskType.Category
-			if analysis.ModelInfo != nil && analysis.ModelInfo.ModelName != nil {
-				modelName = *analysis.ModelInfo.ModelName
-			}
-			if analysis.RuntimeAnalysis != nil {
-				runtimeStatus = analysis.RuntimeAnalysis.Status
-			}
-			npuUtil = analysis.ResourceAssessment.NpuUtilization
-			hbmUtil = analysis.ResourceAssessment.HbmUtilization
-			issuesCount = strconv.Itoa(len(analysis.Issues))
+		return r.Analysis.Summary
+	}},
+	{Name: "taskType", value: func(r analysisExportRow) string {
+		if r.Analysis == nil {
+			return ""
 		}
+		return r.Analysis.TaskType.Category
+	}},
+	{Name: "modelName", value: func(r analysisExportRow) string {
+		if r.Analysis == nil || r.Analysis.ModelInfo == nil || r.Analysis.ModelInfo.ModelName == nil {
+			return ""
+		}
+		return *r.Analysis.ModelInfo.ModelName
+	}},
+	{Name: "runtimeStatus", value: func(r analysisExportRow) string {
+		if r.Analysis == nil || r.Analysis.RuntimeAnalysis == nil {
+			return ""
+		}
+		return r.Analysis.RuntimeAnalysis.Status
+	}},
+	{Name: "npuUtilization", value: func(r analysisExportRow) string {
+		if r.Analysis == nil {
+			return ""
+		}
+		return r.Analysis.ResourceAssessment.NpuUtilization
+	}},
+	{Name: "hbmUtilization", value: func(r analysisExportRow) string {
+		if r.Analysis == nil {
+			return ""
+		}
+		return r.Analysis.ResourceAssessment.HbmUtilization
+	}},
+	{Name: "issuesCount", value: func(r analysisExportRow) string {
+		if r.Analysis == nil {
+			return "0"
+		}
+		return strconv.Itoa(len(r.Analysis.Issues))
+	}, typed: func(r analysisExportRow) interface{} {
+		if r.Analysis == nil {
+			return 0
+		}
+		return len(r.Analysis.Issues)
+	}},
+}
 
-		_ = writer.Write([]string{
-			sanitizeCSVCell(job.JobID),
-			sanitizeCSVCell(valueOrDash(job.JobName)),
-			sanitizeCSVCell(valueOrDash(job.NodeID)),
-			sanitizeCSVCell(valueOrDash(job.Status)),
-			sanitizeCSVCell(valueOrDash(job.JobType)),
-			sanitizeCSVCell(valueOrDash(job.Framework)),
-			sanitizeCSVCell(valueOrDash(job.ProcessName)),
-			sanitizeCSVCell(valueOrDash(job.CommandLine)),
-			sanitizeCSVCell(startupScript),
-			sanitizeCSVCell(formatTimeMs(job.StartTime)),
-			sanitizeCSVCell(cardCount),
-			sanitizeCSVCell(hwStats.ProcessMemoryMB),
-			sanitizeCSVCell(hwStats.HBMUsageMB),
-			sanitizeCSVCell(hwStats.HBMTotalMB),
-			sanitizeCSVCell(hwStats.HBMUsagePercent),
-			sanitizeCSVCell(hwStats.AICoreUsagePercent),
-			sanitizeCSVCell(hwStats.HardwareOccupancy),
-			sanitizeCSVCell(summary),
-			sanitizeCSVCell(taskType),
-			sanitizeCSVCell(modelName),
-			sanitizeCSVCell(runtimeStatus),
-			sanitizeCSVCell(npuUtil),
-			sanitizeCSVCell(hbmUtil),
-			sanitizeCSVCell(issuesCount),
-		})
+// selectAnalysisCSVColumns 按名称选择导出列（保持传入顺序、忽略重复），names 为空时返回全部列
+func selectAnalysisCSVColumns(names []string) ([]analysisCSVColumn, error) {
+	if len(names) == 0 {
+		return analysisCSVColumns, nil
+	}
+	byName := make(map[string]analysisCSVColumn, len(analysisCSVColumns))
+	for _, col := range analysisCSVColumns {
+		byName[col.Name] = col
+	}
+	columns := make([]analysisCSVColumn, 0, len(names))
+	for _, name := range dedupeStrings(names) {
+		col, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown export column %q", name)
+		}
+		columns = append(columns, col)
 	}
+	return columns, nil
 }
 
 type exportHardwareStats struct {
@@ -550,68 +806,95 @@ type exportHardwareStats struct {
 	HBMUsagePercent    string
 	AICoreUsagePercent string
 	HardwareOccupancy  string
+
+	totals hardwareTotals
 }
 
-func buildExportHardwareStats(cards []service.NPUCardInfo) exportHardwareStats {
-	if len(cards) == 0 {
-		return exportHardwareStats{
-			ProcessMemoryMB:    "-",
-			HBMUsageMB:         "-",
-			HBMTotalMB:         "-",
-			HBMUsagePercent:    "-",
-			AICoreUsagePercent: "-",
-			HardwareOccupancy:  "-",
-		}
+// hardwareTotals 硬件统计的数值形式，供 XLSX 按数字类型写入
+type hardwareTotals struct {
+	Cards           int
+	Chips           int
+	ProcessMemoryMB float64
+	HBMUsageMB      float64
+	HBMTotalMB      float64
+	aicoreSum       float64
+	aicoreCount     int
+}
+
+// HBMUsagePercent HBM 使用率，总量未知时 ok 为 false
+func (t hardwareTotals) HBMUsagePercent() (float64, bool) {
+	if t.HBMTotalMB <= 0 {
+		return 0, false
 	}
+	return t.HBMUsageMB * 100 / t.HBMTotalMB, true
+}
 
-	var (
-		processMemoryMB float64
-		hbmUsageMB      float64
-		hbmTotalMB      float64
-		aicoreSum       float64
-		aicoreCount     int
-		chipCount       int
-	)
+// AICoreUsagePercent 各芯片 AICore 使用率均值，无数据时 ok 为 false
+func (t hardwareTotals) AICoreUsagePercent() (float64, bool) {
+	if t.aicoreCount == 0 {
+		return 0, false
+	}
+	return t.aicoreSum / float64(t.aicoreCount), true
+}
 
+func sumHardwareTotals(cards []service.NPUCardInfo) hardwareTotals {
+	totals := hardwareTotals{Cards: len(cards)}
 	for _, card := range cards {
-		processMemoryMB += card.MemoryUsageMB
-		chipCount += len(card.Metrics)
+		totals.ProcessMemoryMB += card.MemoryUsageMB
+		totals.Chips += len(card.Metrics)
 		for _, metric := range card.Metrics {
 			if metric.HBMUsageMB != nil {
-				hbmUsageMB += *metric.HBMUsageMB
+				totals.HBMUsageMB += *metric.HBMUsageMB
 			}
 			if metric.HBMTotalMB != nil {
-				hbmTotalMB += *metric.HBMTotalMB
+				totals.HBMTotalMB += *metric.HBMTotalMB
 			}
 			if metric.AICoreUsagePercent != nil {
-				aicoreSum += *metric.AICoreUsagePercent
-				aicoreCount++
+				totals.aicoreSum += *metric.AICoreUsagePercent
+				totals.aicoreCount++
 			}
 		}
 	}
+	return totals
+}
+
+func buildExportHardwareStats(cards []service.NPUCardInfo) exportHardwareStats {
+	if len(cards) == 0 {
+		return exportHardwareStats{
+			ProcessMemoryMB:    "-",
+			HBMUsageMB:         "-",
+			HBMTotalMB:         "-",
+			HBMUsagePercent:    "-",
+			AICoreUsagePercent: "-",
+			HardwareOccupancy:  "-",
+		}
+	}
+
+	totals := sumHardwareTotals(cards)
 
 	hbmUsagePercent := "-"
-	if hbmTotalMB > 0 {
-		hbmUsagePercent = fmt.Sprintf("%.2f", hbmUsageMB*100/hbmTotalMB)
+	if v, ok := totals.HBMUsagePercent(); ok {
+		hbmUsagePercent = fmt.Sprintf("%.2f", v)
 	}
 
 	aicoreUsage := "-"
-	if aicoreCount > 0 {
-		aicoreUsage = fmt.Sprintf("%.2f", aicoreSum/float64(aicoreCount))
+	if v, ok := totals.AICoreUsagePercent(); ok {
+		aicoreUsage = fmt.Sprintf("%.2f", v)
 	}
 
-	occupancy := fmt.Sprintf("cards=%d,chips=%d", len(cards), chipCount)
+	occupancy := fmt.Sprintf("cards=%d,chips=%d", totals.Cards, totals.Chips)
 	if aicoreUsage != "-" {
 		occupancy = fmt.Sprintf("%s,aicore=%s%%", occupancy, aicoreUsage)
 	}
 
 	return exportHardwareStats{
-		ProcessMemoryMB:    fmt.Sprintf("%.2f", processMemoryMB),
-		HBMUsageMB:         fmt.Sprintf("%.2f", hbmUsageMB),
-		HBMTotalMB:         fmt.Sprintf("%.2f", hbmTotalMB),
+		ProcessMemoryMB:    fmt.Sprintf("%.2f", totals.ProcessMemoryMB),
+		HBMUsageMB:         fmt.Sprintf("%.2f", totals.HBMUsageMB),
+		HBMTotalMB:         fmt.Sprintf("%.2f", totals.HBMTotalMB),
 		HBMUsagePercent:    hbmUsagePercent,
 		AICoreUsagePercent: aicoreUsage,
 		HardwareOccupancy:  occupancy,
+		totals:             totals,
 	}
 }
 
@@ -669,16 +952,6 @@ func formatTimeMs(v *int64) string {
 	return time.Unix(sec, nsec).Format("2006-01-02 15:04:05")
 }
 
-func sanitizeCSVCell(v string) string {
-	if v == "" {
-		return ""
-	}
-	if strings.HasPrefix(v, "=") || strings.HasPrefix(v, "+") || strings.HasPrefix(v, "-") || strings.HasPrefix(v, "@") {
-		return "'" + v
-	}
-	return v
-}
-
 // GetBatchAnalyzeProgress 查询批量分析进度
 func (h *JobHandler) GetBatchAnalyzeProgress(c *gin.Context) {
 	batchID := c.Param("batchId")
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/task-monitor/api-server/internal/config"
 	"github.com/task-monitor/api-server/internal/model"
 	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -46,28 +48,62 @@ func (m *MockJobService) GetJobParameters(jobID string) ([]model.Parameter, erro
 	return args.Get(0).([]model.Parameter), args.Error(1)
 }
 
+func (m *MockJobService) GetJobsParameters(jobIDs []string) (map[string][]model.Parameter, error) {
+	args := m.Called(jobIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]model.Parameter), args.Error(1)
+}
+
 func (m *MockJobService) GetJobCode(jobID string) ([]model.Code, error) {
 	args := m.Called(jobID)
 	return args.Get(0).([]model.Code), args.Error(1)
 }
 
+func (m *MockJobService) GetJobCodes(jobIDs []string) (map[string][]model.Code, error) {
+	args := m.Called(jobIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]model.Code), args.Error(1)
+}
+
+func (m *MockJobService) GetJobsNPUCards(jobs []model.Job) (map[string][]service.NPUCardInfo, error) {
+	args := m.Called(jobs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]service.NPUCardInfo), args.Error(1)
+}
+
 func (m *MockJobService) GetAllJobs() ([]model.Job, error) {
 	args := m.Called()
 	return args.Get(0).([]model.Job), args.Error(1)
 }
 
-func (m *MockJobService) GetJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error) {
-	args := m.Called(nodeID, statuses, jobTypes, frameworks, sortBy, sortOrder, page, pageSize)
+func (m *MockJobService) GetJobs(filter service.JobFilter, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error) {
+	args := m.Called(filter, sortBy, sortOrder, page, pageSize)
 	return args.Get(0).([]model.Job), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockJobService) GetGroupedJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, cardCounts []int, sortBy, sortOrder string, page, pageSize int) ([]service.JobGroup, int64, error) {
-	args := m.Called(nodeID, statuses, jobTypes, frameworks, cardCounts, sortBy, sortOrder, page, pageSize)
+func (m *MockJobService) GetGroupedJobs(filter service.JobGroupFilter, sortBy, sortOrder string, page, pageSize int) ([]service.JobGroup, int64, error) {
+	args := m.Called(filter, sortBy, sortOrder, page, pageSize)
 	return args.Get(0).([]service.JobGroup), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockJobService) GetDistinctCardCounts() ([]int, error) {
-	args := m.Called()
+func (m *MockJobService) GetJobsByCursor(filter service.JobFilter, sortBy, sortOrder, cursor string, pageSize int) ([]model.Job, int64, string, error) {
+	args := m.Called(filter, sortBy, sortOrder, cursor, pageSize)
+	return args.Get(0).([]model.Job), args.Get(1).(int64), args.String(2), args.Error(3)
+}
+
+func (m *MockJobService) GetGroupedJobsByCursor(filter service.JobGroupFilter, sortBy, sortOrder, cursor string, pageSize int) ([]service.JobGroup, int64, string, error) {
+	args := m.Called(filter, sortBy, sortOrder, cursor, pageSize)
+	return args.Get(0).([]service.JobGroup), args.Get(1).(int64), args.String(2), args.Error(3)
+}
+
+func (m *MockJobService) GetDistinctCardCounts(scope service.ProjectFilter) ([]int, error) {
+	args := m.Called(scope)
 	return args.Get(0).([]int), args.Error(1)
 }
 
@@ -79,8 +115,8 @@ func (m *MockJobService) GetJobDetail(jobID string, aggregate bool) (*service.Jo
 	return args.Get(0).(*service.JobDetailResponse), args.Error(1)
 }
 
-func (m *MockJobService) GetJobStats() (map[string]int64, error) {
-	args := m.Called()
+func (m *MockJobService) GetJobStats(filter service.JobGroupFilter) (map[string]int64, error) {
+	args := m.Called(filter)
 	return args.Get(0).(map[string]int64), args.Error(1)
 }
 
@@ -102,7 +138,7 @@ func TestJobHandler_GetJobs_ByNodeID(t *testing.T) {
 	}
 
 	var statuses, jobTypes, frameworks []string
-	mockService.On("GetJobs", "node-001", statuses, jobTypes, frameworks, "", "", 1, 20).Return(expectedJobs, int64(1), nil)
+	mockService.On("GetJobs", service.JobFilter{NodeIDs: []string{"node-001"}, Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "", 1, 20).Return(expectedJobs, int64(1), nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -138,7 +174,7 @@ func TestJobHandler_GetJobs_ByStatus(t *testing.T) {
 
 	statuses := []string{"running"}
 	var jobTypes, frameworks []string
-	mockService.On("GetJobs", "", statuses, jobTypes, frameworks, "", "", 1, 20).Return(expectedJobs, int64(1), nil)
+	mockService.On("GetJobs", service.JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "", 1, 20).Return(expectedJobs, int64(1), nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -158,7 +194,7 @@ func TestJobHandler_GetJobs_NoParams(t *testing.T) {
 
 	expectedJobs := []model.Job{}
 	var statuses2, jobTypes2, frameworks2 []string
-	mockService.On("GetJobs", "", statuses2, jobTypes2, frameworks2, "", "", 1, 20).Return(expectedJobs, int64(0), nil)
+	mockService.On("GetJobs", service.JobFilter{Statuses: statuses2, JobTypes: jobTypes2, Frameworks: frameworks2}, "", "", 1, 20).Return(expectedJobs, int64(0), nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -170,6 +206,46 @@ func TestJobHandler_GetJobs_NoParams(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestJobHandler_GetJobs_TimeRangeAndSearch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	handler := NewJobHandler(mockService, nil)
+
+	// startTime 支持 RFC3339，endTime 支持毫秒时间戳；nodeId 可重复
+	from := int64(1738713600000) // 2025-02-05T00:00:00Z
+	to := int64(1738800000000)
+	filter := service.JobFilter{NodeIDs: []string{"node-001", "node-002"}, StartFrom: &from, StartTo: &to, Search: "llama sft"}
+	mockService.On("GetJobs", filter, "", "", 1, 20).Return([]model.Job{}, int64(0), nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs?nodeId=node-001&nodeId=node-002&startTime=2025-02-05T00:00:00Z&endTime=1738800000000&search=llama+sft", nil)
+
+	handler.GetJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestJobHandler_GetJobs_InvalidTimeRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	handler := NewJobHandler(mockService, nil)
+
+	for _, query := range []string{"startTime=yesterday", "startTime=2000&endTime=1000"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/jobs?"+query, nil)
+
+		handler.GetJobs(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockService.AssertNotCalled(t, "GetJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestJobHandler_GetJobByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -295,7 +371,7 @@ func TestJobHandler_GetGroupedJobs(t *testing.T) {
 
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
-	mockService.On("GetGroupedJobs", "node-001", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20).
+	mockService.On("GetGroupedJobs", service.JobGroupFilter{JobFilter: service.JobFilter{NodeIDs: []string{"node-001"}, Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20).
 		Return(expectedGroups, int64(1), nil)
 
 	w := httptest.NewRecorder()
@@ -326,7 +402,7 @@ func TestJobHandler_GetGroupedJobs_WithCardCountFilter(t *testing.T) {
 
 	var statuses, jobTypes, frameworks []string
 	cardCounts := []int{4}
-	mockService.On("GetGroupedJobs", "", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20).
+	mockService.On("GetGroupedJobs", service.JobGroupFilter{JobFilter: service.JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20).
 		Return(expectedGroups, int64(0), nil)
 
 	w := httptest.NewRecorder()
@@ -339,13 +415,65 @@ func TestJobHandler_GetGroupedJobs_WithCardCountFilter(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestParseGroupFilter_CardCountAndEndTimeRange(t *testing.T) {
+	filter, err := parseGroupFilter(url.Values{
+		"minCardCount": {"8"}, "maxCardCount": {"16"},
+		"endFrom": {"2026-02-06T00:00:00Z"}, "endTo": {"1770422400000"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, *filter.MinCardCount)
+	assert.Equal(t, 16, *filter.MaxCardCount)
+	assert.Equal(t, int64(1770336000000), *filter.EndFrom)
+	assert.Equal(t, int64(1770422400000), *filter.EndTo)
+	// endTime 仍是启动时间上限
+	assert.Nil(t, filter.StartTo)
+
+	for _, query := range []url.Values{
+		{"minCardCount": {"eight"}},
+		{"maxCardCount": {"-1"}},
+		{"minCardCount": {"16"}, "maxCardCount": {"8"}},
+		{"endFrom": {"yesterday"}},
+		{"endFrom": {"1770422400000"}, "endTo": {"1770336000000"}},
+	} {
+		_, err := parseGroupFilter(query)
+		assert.Error(t, err, query.Encode())
+	}
+}
+
+func TestJobHandler_GetGroupedJobs_WithAnalysisFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	handler := NewJobHandler(mockService, nil)
+
+	filter := service.JobGroupFilter{
+		JobFilter: service.JobFilter{Statuses: []string{"running"}},
+		Analysis: service.AnalysisFilter{
+			Categories:          []string{"inference"},
+			InferenceFrameworks: []string{"vLLM"},
+			NPUUtilizations:     []string{"idle", "low"},
+			IssueSeverities:     []string{"critical"},
+		},
+	}
+	mockService.On("GetGroupedJobs", filter, "", "", 1, 20).Return([]service.JobGroup{}, int64(0), nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped?status=running&category=inference&inferenceFramework=vLLM&npuUtilization=idle&npuUtilization=low&issueSeverity=critical&modelName=", nil)
+
+	handler.GetGroupedJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
 func TestJobHandler_GetDistinctCardCounts(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockService := new(MockJobService)
 	handler := NewJobHandler(mockService, nil)
 
-	mockService.On("GetDistinctCardCounts").Return([]int{1, 2, 4, 8, 16}, nil)
+	mockService.On("GetDistinctCardCounts", service.ProjectFilter{}).Return([]int{1, 2, 4, 8, 16}, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -369,28 +497,33 @@ type MockLLMService struct {
 	mock.Mock
 }
 
-func (m *MockLLMService) AnalyzeJob(jobID string) (*service.JobAnalysisResponse, error) {
+func (m *MockLLMService) AnalyzeJob(jobID string) (*service.AnalysisWithStatus, error) {
 	args := m.Called(jobID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*service.JobAnalysisResponse), args.Error(1)
+	return args.Get(0).(*service.AnalysisWithStatus), args.Error(1)
 }
 
-func (m *MockLLMService) AnalyzeJobWithModel(jobID, modelID string) (*service.JobAnalysisResponse, error) {
+func (m *MockLLMService) AnalyzeJobWithModel(jobID, modelID string) (*service.AnalysisWithStatus, error) {
 	args := m.Called(jobID, modelID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*service.JobAnalysisResponse), args.Error(1)
+	return args.Get(0).(*service.AnalysisWithStatus), args.Error(1)
 }
 
-func (m *MockLLMService) GetAnalysis(jobID string) (*service.JobAnalysisResponse, error) {
+func (m *MockLLMService) AnalyzeJobSync(jobID string) error {
+	args := m.Called(jobID)
+	return args.Error(0)
+}
+
+func (m *MockLLMService) GetAnalysis(jobID string) (*service.AnalysisWithStatus, error) {
 	args := m.Called(jobID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*service.JobAnalysisResponse), args.Error(1)
+	return args.Get(0).(*service.AnalysisWithStatus), args.Error(1)
 }
 
 func (m *MockLLMService) GetBatchAnalyses(jobIDs []string) (map[string]*service.JobAnalysisResponse, error) {
@@ -410,6 +543,14 @@ func (m *MockLLMService) UpdateConfig(cfg config.LLMConfig) {
 	m.Called(cfg)
 }
 
+func (m *MockLLMService) TestModel(modelID string, override *config.LLMModelConfig) (*service.LLMModelTestResult, error) {
+	args := m.Called(modelID, override)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.LLMModelTestResult), args.Error(1)
+}
+
 func TestJobHandler_AnalyzeJob_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -417,19 +558,7 @@ func TestJobHandler_AnalyzeJob_Success(t *testing.T) {
 	mockLLMService := new(MockLLMService)
 	handler := NewJobHandler(mockJobService, mockLLMService)
 
-	expectedResult := &service.JobAnalysisResponse{
-		Summary: "这是一个vLLM推理作业",
-		TaskType: service.JobAnalysisTaskType{
-			Category: "inference",
-		},
-		ResourceAssessment: service.JobAnalysisResourceAssessment{
-			NpuUtilization: "high",
-			HbmUtilization: "high",
-			Description:    "资源利用率良好",
-		},
-		Issues: []service.JobAnalysisIssue{},
-	}
-
+	expectedResult := &service.AnalysisWithStatus{Status: "analyzing"}
 	mockLLMService.On("AnalyzeJob", "job-001").Return(expectedResult, nil)
 
 	w := httptest.NewRecorder()
@@ -445,6 +574,8 @@ func TestJobHandler_AnalyzeJob_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
 	assert.Equal(t, float64(200), response["code"])
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, "analyzing", data["status"])
 	mockLLMService.AssertExpectations(t)
 }
 
@@ -491,7 +622,7 @@ func TestJobHandler_AnalyzeJob_WithCustomModel(t *testing.T) {
 	mockLLMService := new(MockLLMService)
 	handler := NewJobHandler(mockJobService, mockLLMService)
 
-	resp := &service.JobAnalysisResponse{Summary: "ok"}
+	resp := &service.AnalysisWithStatus{Status: "analyzing"}
 	mockLLMService.On("AnalyzeJobWithModel", "job-001", "qwen-max").Return(resp, nil)
 
 	w := httptest.NewRecorder()
@@ -563,11 +694,11 @@ func TestJobHandler_ExportAnalysesCSV_Success(t *testing.T) {
 	jobTypes := []string{"inference"}
 	frameworks := []string{"vllm"}
 	cardCounts := []int{2}
-	mockJobService.On("GetGroupedJobs", "node-001", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 100000).
-		Return(groups, int64(1), nil)
-	mockJobService.On("GetJobCode", "job-001").Return([]model.Code{{ScriptPath: &scriptPath}}, nil)
-	mockJobService.On("GetJobDetail", "job-001", true).Return(&service.JobDetailResponse{
-		NPUCards: []service.NPUCardInfo{
+	mockJobService.On("GetGroupedJobsByCursor", service.JobGroupFilter{JobFilter: service.JobFilter{NodeIDs: []string{"node-001"}, Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", "", defaultExportChunkSize).
+		Return(groups, int64(1), "", nil)
+	mockJobService.On("GetJobCodes", []string{"job-001"}).Return(map[string][]model.Code{"job-001": {{ScriptPath: &scriptPath}}}, nil)
+	mockJobService.On("GetJobsNPUCards", []model.Job{groups[0].MainJob}).Return(map[string][]service.NPUCardInfo{
+		"job-001": {
 			{
 				NpuID:         0,
 				MemoryUsageMB: 1024,
@@ -672,3 +803,97 @@ func TestJobHandler_ExportAnalysesCSV_LLMNotConfigured(t *testing.T) {
 	assert.Equal(t, float64(501), response["code"])
 	assert.Equal(t, "LLM service is not configured", response["message"])
 }
+
+func TestJobHandler_GetGroupedJobs_Cursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	handler := NewJobHandler(mockService, nil)
+
+	var statuses, jobTypes, frameworks []string
+	var cardCounts []int
+	groups := []service.JobGroup{{MainJob: model.Job{JobID: "job-002"}, ChildJobs: []model.Job{}}}
+	mockService.On("GetGroupedJobsByCursor", service.JobGroupFilter{JobFilter: service.JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", "", 1).
+		Return(groups, int64(2), "next-token", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped?cursor=&pageSize=1", nil)
+
+	handler.GetGroupedJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data utils.PaginationResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "next-token", response.Data.NextCursor)
+	assert.Equal(t, int64(2), response.Data.Pagination.Total)
+	mockService.AssertNotCalled(t, "GetGroupedJobs")
+}
+
+func TestJobHandler_GetJobs_InvalidCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	handler := NewJobHandler(mockService, nil)
+
+	var statuses, jobTypes, frameworks []string
+	mockService.On("GetJobsByCursor", service.JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "", "bad", 20).
+		Return([]model.Job(nil), int64(0), "", utils.ErrInvalidCursor)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs?cursor=bad", nil)
+
+	handler.GetJobs(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestJobHandler_GetBatchAnalyses_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLLMService := new(MockLLMService)
+	handler := NewJobHandler(new(MockJobService), mockLLMService)
+	projectService := newRestrictedProjectService()
+	handler.SetProjectService(projectService)
+	// job-b 归属其他项目，不查询也不返回
+	projectService.On("FilterVisibleJobIDs", []string{"job-a", "job-b"}, restrictedScope).Return([]string{"job-a"}, nil)
+	projectService.On("FilterVisibleJobIDs", []string{"job-b"}, restrictedScope).Return([]string{}, nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-a"}).
+		Return(map[string]*service.JobAnalysisResponse{"job-a": {}}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/batch?jobIds=job-a&jobIds=job-b", nil)
+	handler.GetBatchAnalyses(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response["data"].(map[string]interface{})
+	assert.Contains(t, data, "job-a")
+	assert.NotContains(t, data, "job-b")
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/batch?jobIds=job-b", nil)
+	handler.GetBatchAnalyses(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{}`, string(mustJSONField(t, w.Body.Bytes(), "data")))
+	mockLLMService.AssertNumberOfCalls(t, "GetBatchAnalyses", 1)
+}
+
+// mustJSONField 取响应 JSON 中的顶层字段
+func mustJSONField(t *testing.T, body []byte, field string) json.RawMessage {
+	t.Helper()
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	return resp[field]
+}
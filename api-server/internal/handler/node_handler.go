@@ -2,6 +2,8 @@ package handler
 
 import (
 	"errors"
+	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/task-monitor/api-server/internal/service"
@@ -11,7 +13,9 @@ import (
 
 // NodeHandler 节点处理器
 type NodeHandler struct {
-	nodeService service.NodeServiceInterface
+	nodeService     service.NodeServiceInterface
+	nodeCardService service.NodeCardServiceInterface
+	projectService  service.ProjectServiceInterface
 }
 
 // NewNodeHandler 创建节点处理器
@@ -21,6 +25,16 @@ func NewNodeHandler(nodeService service.NodeServiceInterface) *NodeHandler {
 	}
 }
 
+// SetNodeCardService 启用按卡查看节点 NPU 状态与空闲卡查询
+func (h *NodeHandler) SetNodeCardService(nodeCardService service.NodeCardServiceInterface) {
+	h.nodeCardService = nodeCardService
+}
+
+// SetProjectService 启用项目可见范围：节点概览只返回可见的分组与状态变更，不可见分组的进程不返回作业信息
+func (h *NodeHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
 // GetNodes 获取节点列表
 func (h *NodeHandler) GetNodes(c *gin.Context) {
 	status := c.Query("status")
@@ -70,3 +84,67 @@ func (h *NodeHandler) GetNodeStats(c *gin.Context) {
 
 	utils.SuccessResponse(c, stats)
 }
+
+// GetNodeCards 获取节点上每张 NPU 卡的健康状态、最新指标与占用进程
+func (h *NodeHandler) GetNodeCards(c *gin.Context) {
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	cards, err := h.nodeCardService.GetNodeCards(c.Param("nodeId"), scope)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Node not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, cards)
+}
+
+// GetNodeOverview 获取节点概览：节点信息、心跳间隔、运行中的作业分组、每卡状态、功耗与利用率汇总和最近状态变更
+func (h *NodeHandler) GetNodeOverview(c *gin.Context) {
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	overview, err := h.nodeCardService.GetNodeOverview(c.Param("nodeId"), scope)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Node not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, overview)
+}
+
+// FindFreeCards 查找有足够空闲 NPU 卡的节点，参数 npuModel（可选）、count（默认 1）与 projectId（可选，
+// 只在这些项目运行中作业所在的节点内查找）
+func (h *NodeHandler) FindFreeCards(c *gin.Context) {
+	scope, ok := requestScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	count := 1
+	if v := c.Query("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid count")
+			return
+		}
+		count = n
+	}
+	result, err := h.nodeCardService.FindFreeCards(c.Query("npuModel"), count, scope)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFreeCardQuery) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, result)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
 	"gorm.io/gorm"
 )
 
@@ -42,6 +43,35 @@ func (m *MockNodeService) GetNodeStats() (map[string]int64, error) {
 	return args.Get(0).(map[string]int64), args.Error(1)
 }
 
+// MockNodeCardService is a mock implementation of NodeCardServiceInterface
+type MockNodeCardService struct {
+	mock.Mock
+}
+
+func (m *MockNodeCardService) GetNodeCards(nodeID string, scope service.ProjectFilter) (*service.NodeCards, error) {
+	args := m.Called(nodeID, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.NodeCards), args.Error(1)
+}
+
+func (m *MockNodeCardService) GetNodeOverview(nodeID string, scope service.ProjectFilter) (*service.NodeOverview, error) {
+	args := m.Called(nodeID, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.NodeOverview), args.Error(1)
+}
+
+func (m *MockNodeCardService) FindFreeCards(npuModel string, count int, scope service.ProjectFilter) (*service.FreeCardsResult, error) {
+	args := m.Called(npuModel, count, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.FreeCardsResult), args.Error(1)
+}
+
 func TestNodeHandler_GetNodes(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -165,3 +195,143 @@ func TestNodeHandler_GetNodeByID_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	mockService.AssertExpectations(t)
 }
+
+func TestNodeHandler_GetNodeCards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockCards := new(MockNodeCardService)
+	handler := NewNodeHandler(new(MockNodeService))
+	handler.SetNodeCardService(mockCards)
+	mockCards.On("GetNodeCards", "node-001", service.ProjectFilter{}).Return(&service.NodeCards{NodeID: "node-001", TotalCards: 8, FreeCards: 3}, nil)
+	mockCards.On("GetNodeCards", "non-existent", service.ProjectFilter{}).Return(nil, gorm.ErrRecordNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "nodeId", Value: "node-001"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/nodes/node-001/cards", nil)
+	handler.GetNodeCards(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(3), response["data"].(map[string]interface{})["freeCards"])
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "nodeId", Value: "non-existent"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/nodes/non-existent/cards", nil)
+	handler.GetNodeCards(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockCards.AssertExpectations(t)
+}
+
+func TestNodeHandler_GetNodeCards_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockCards := new(MockNodeCardService)
+	handler := NewNodeHandler(new(MockNodeService))
+	handler.SetNodeCardService(mockCards)
+	handler.SetProjectService(newRestrictedProjectService())
+	mockCards.On("GetNodeCards", "node-001", restrictedScope).Return(&service.NodeCards{NodeID: "node-001"}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Params = gin.Params{{Key: "nodeId", Value: "node-001"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/nodes/node-001/cards", nil)
+	handler.GetNodeCards(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockCards.AssertExpectations(t)
+}
+
+func TestNodeHandler_GetNodeOverview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockCards := new(MockNodeCardService)
+	handler := NewNodeHandler(new(MockNodeService))
+	handler.SetNodeCardService(mockCards)
+	age := int64(30)
+	mockCards.On("GetNodeOverview", "node-001", service.ProjectFilter{}).Return(&service.NodeOverview{
+		Node:                model.Node{NodeID: "node-001"},
+		HeartbeatAgeSeconds: &age,
+		RunningGroupCount:   2,
+	}, nil)
+	mockCards.On("GetNodeOverview", "non-existent", service.ProjectFilter{}).Return(nil, gorm.ErrRecordNotFound)
+	mockCards.On("GetNodeOverview", "node-err", service.ProjectFilter{}).Return(nil, errors.New("connection refused"))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "nodeId", Value: "node-001"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/nodes/node-001/overview", nil)
+	handler.GetNodeOverview(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(30), data["heartbeatAgeSeconds"])
+	assert.Equal(t, float64(2), data["runningGroupCount"])
+	assert.Equal(t, "node-001", data["node"].(map[string]interface{})["nodeId"])
+
+	for nodeID, code := range map[string]int{"non-existent": http.StatusNotFound, "node-err": http.StatusInternalServerError} {
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "nodeId", Value: nodeID}}
+		c.Request = httptest.NewRequest("GET", "/api/v1/nodes/"+nodeID+"/overview", nil)
+		handler.GetNodeOverview(c)
+		assert.Equal(t, code, w.Code, nodeID)
+	}
+	mockCards.AssertExpectations(t)
+}
+
+func TestNodeHandler_FindFreeCards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockCards := new(MockNodeCardService)
+	handler := NewNodeHandler(new(MockNodeService))
+	handler.SetNodeCardService(mockCards)
+	mockCards.On("FindFreeCards", "910B", 8, service.ProjectFilter{}).Return(&service.FreeCardsResult{NPUModel: "910B", Count: 8}, nil)
+	mockCards.On("FindFreeCards", "", 100, service.ProjectFilter{}).Return(nil, service.ErrInvalidFreeCardQuery)
+	mockCards.On("FindFreeCards", "", 1, service.ProjectFilter{ProjectIDs: []uint{7}}).Return(&service.FreeCardsResult{Count: 1}, nil)
+
+	cases := []struct {
+		query string
+		code  int
+	}{
+		{"npuModel=910B&count=8", http.StatusOK},
+		{"count=100", http.StatusBadRequest},
+		{"count=eight", http.StatusBadRequest},
+		{"projectId=7", http.StatusOK},
+		{"projectId=abc", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/nodes/free-cards?"+tc.query, nil)
+		handler.FindFreeCards(c)
+		assert.Equal(t, tc.code, w.Code, tc.query)
+	}
+	mockCards.AssertExpectations(t)
+}
+
+func TestNodeHandler_GetNodeOverview_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockCards := new(MockNodeCardService)
+	handler := NewNodeHandler(new(MockNodeService))
+	handler.SetNodeCardService(mockCards)
+	handler.SetProjectService(newRestrictedProjectService())
+	mockCards.On("GetNodeOverview", "node-001", restrictedScope).Return(&service.NodeOverview{Node: model.Node{NodeID: "node-001"}}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Params = gin.Params{{Key: "nodeId", Value: "node-001"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/nodes/node-001/overview", nil)
+	handler.GetNodeOverview(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockCards.AssertExpectations(t)
+}
@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// ProjectHandler 项目、成员、归属规则与作业手动归属处理器
+type ProjectHandler struct {
+	projectService service.ProjectServiceInterface
+}
+
+// NewProjectHandler 创建项目处理器
+func NewProjectHandler(projectService service.ProjectServiceInterface) *ProjectHandler {
+	return &ProjectHandler{projectService: projectService}
+}
+
+// ListProjects 列出当前用户可见的项目
+func (h *ProjectHandler) ListProjects(c *gin.Context) {
+	projects, err := h.projectService.List(currentUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, projects)
+}
+
+// GetProject 获取单个项目
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	project, err := h.projectService.Get(id, currentUserID(c))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, project)
+}
+
+// CreateProject 创建项目，当前用户成为项目 owner
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var input service.ProjectInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	project, err := h.projectService.Create(currentUserID(c), input)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, project)
+}
+
+// UpdateProject 更新项目名称与描述
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	var input service.ProjectInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	project, err := h.projectService.Update(id, currentUserID(c), input)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, project)
+}
+
+// DeleteProject 删除项目，归属于该项目的作业变为未归属
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	if err := h.projectService.Delete(id, currentUserID(c)); err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, nil)
+}
+
+// ListMembers 列出项目成员
+func (h *ProjectHandler) ListMembers(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	members, err := h.projectService.ListMembers(id, currentUserID(c))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, members)
+}
+
+// AddMember 按用户名添加成员或修改成员角色
+func (h *ProjectHandler) AddMember(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	var input service.ProjectMemberInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	member, err := h.projectService.AddMember(id, currentUserID(c), input)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, member)
+}
+
+// RemoveMember 移除成员
+func (h *ProjectHandler) RemoveMember(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	userID, ok := parseUintParam(c, "userId", "invalid user id")
+	if !ok {
+		return
+	}
+	if err := h.projectService.RemoveMember(id, currentUserID(c), userID); err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, nil)
+}
+
+// ListRules 列出项目的归属规则
+func (h *ProjectHandler) ListRules(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	rules, err := h.projectService.ListRules(id, currentUserID(c))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, rules)
+}
+
+// CreateRule 添加归属规则
+func (h *ProjectHandler) CreateRule(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	var input service.ProjectRuleInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	rule, err := h.projectService.CreateRule(id, currentUserID(c), input)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, rule)
+}
+
+// UpdateRule 更新归属规则
+func (h *ProjectHandler) UpdateRule(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	ruleID, ok := parseUintParam(c, "ruleId", "invalid rule id")
+	if !ok {
+		return
+	}
+	var input service.ProjectRuleInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	rule, err := h.projectService.UpdateRule(id, ruleID, currentUserID(c), input)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, rule)
+}
+
+// DeleteRule 删除归属规则
+func (h *ProjectHandler) DeleteRule(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "invalid project id")
+	if !ok {
+		return
+	}
+	ruleID, ok := parseUintParam(c, "ruleId", "invalid rule id")
+	if !ok {
+		return
+	}
+	if err := h.projectService.DeleteRule(id, ruleID, currentUserID(c)); err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, nil)
+}
+
+// GetJobProject 查询作业的归属项目
+func (h *ProjectHandler) GetJobProject(c *gin.Context) {
+	info, err := h.projectService.GetJobProject(c.Param("jobId"), currentUserID(c))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, info)
+}
+
+// jobProjectRequest 手动指定作业归属的请求体
+type jobProjectRequest struct {
+	ProjectID uint `json:"projectId" binding:"required"`
+}
+
+// SetJobProject 手动指定作业的归属项目，覆盖归属规则
+func (h *ProjectHandler) SetJobProject(c *gin.Context) {
+	var req jobProjectRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	info, err := h.projectService.AssignJob(c.Param("jobId"), req.ProjectID, currentUserID(c))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, info)
+}
+
+// ClearJobProject 取消作业的手动归属，恢复按归属规则计算
+func (h *ProjectHandler) ClearJobProject(c *gin.Context) {
+	info, err := h.projectService.ClearJobAssignment(c.Param("jobId"), currentUserID(c))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, info)
+}
+
+func parseUintParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func bindJSON(c *gin.Context, v interface{}) bool {
+	if err := c.ShouldBindJSON(v); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func respondProjectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrProjectNotFound), errors.Is(err, service.ErrProjectRuleNotFound),
+		errors.Is(err, service.ErrProjectJobNotFound), errors.Is(err, service.ErrProjectUserNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrProjectForbidden), errors.Is(err, service.ErrProjectAdminRequired):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrProjectNameExists):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrProjectNameRequired), errors.Is(err, service.ErrInvalidProjectRule),
+		errors.Is(err, service.ErrInvalidProjectMember):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+	}
+}
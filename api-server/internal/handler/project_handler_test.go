@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// MockProjectService is a mock implementation of ProjectServiceInterface
+type MockProjectService struct {
+	mock.Mock
+}
+
+func (m *MockProjectService) VisibleScope(userID uint) (service.ProjectFilter, error) {
+	args := m.Called(userID)
+	return args.Get(0).(service.ProjectFilter), args.Error(1)
+}
+
+func (m *MockProjectService) CanViewJob(jobID string, userID uint) (bool, error) {
+	args := m.Called(jobID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProjectService) FilterVisibleJobIDs(jobIDs []string, scope service.ProjectFilter) ([]string, error) {
+	args := m.Called(jobIDs, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockProjectService) List(userID uint) ([]model.Project, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Project), args.Error(1)
+}
+
+func (m *MockProjectService) Get(id, userID uint) (*model.Project, error) {
+	args := m.Called(id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectService) Create(userID uint, input service.ProjectInput) (*model.Project, error) {
+	args := m.Called(userID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectService) Update(id, userID uint, input service.ProjectInput) (*model.Project, error) {
+	args := m.Called(id, userID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectService) Delete(id, userID uint) error {
+	return m.Called(id, userID).Error(0)
+}
+
+func (m *MockProjectService) ListMembers(id, userID uint) ([]service.ProjectMemberInfo, error) {
+	args := m.Called(id, userID)
+	return args.Get(0).([]service.ProjectMemberInfo), args.Error(1)
+}
+
+func (m *MockProjectService) AddMember(id, userID uint, input service.ProjectMemberInput) (*service.ProjectMemberInfo, error) {
+	args := m.Called(id, userID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ProjectMemberInfo), args.Error(1)
+}
+
+func (m *MockProjectService) RemoveMember(id, userID, memberID uint) error {
+	return m.Called(id, userID, memberID).Error(0)
+}
+
+func (m *MockProjectService) ListRules(id, userID uint) ([]model.ProjectRule, error) {
+	args := m.Called(id, userID)
+	return args.Get(0).([]model.ProjectRule), args.Error(1)
+}
+
+func (m *MockProjectService) CreateRule(id, userID uint, input service.ProjectRuleInput) (*model.ProjectRule, error) {
+	args := m.Called(id, userID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ProjectRule), args.Error(1)
+}
+
+func (m *MockProjectService) UpdateRule(id, ruleID, userID uint, input service.ProjectRuleInput) (*model.ProjectRule, error) {
+	args := m.Called(id, ruleID, userID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ProjectRule), args.Error(1)
+}
+
+func (m *MockProjectService) DeleteRule(id, ruleID, userID uint) error {
+	return m.Called(id, ruleID, userID).Error(0)
+}
+
+func (m *MockProjectService) GetJobProject(jobID string, userID uint) (*service.JobProjectInfo, error) {
+	args := m.Called(jobID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.JobProjectInfo), args.Error(1)
+}
+
+func (m *MockProjectService) AssignJob(jobID string, projectID, userID uint) (*service.JobProjectInfo, error) {
+	args := m.Called(jobID, projectID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.JobProjectInfo), args.Error(1)
+}
+
+func (m *MockProjectService) ClearJobAssignment(jobID string, userID uint) (*service.JobProjectInfo, error) {
+	args := m.Called(jobID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.JobProjectInfo), args.Error(1)
+}
+
+func TestProjectHandler_CreateRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockProjectService)
+	handler := NewProjectHandler(mockService)
+	input := service.ProjectRuleInput{Field: "env:PROJECT", Pattern: "^llm$", Priority: 10}
+	mockService.On("CreateRule", uint(2), uint(3), input).
+		Return(&model.ProjectRule{ID: 1, ProjectID: 2, Field: input.Field, Pattern: input.Pattern, Priority: 10}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	c.Request = httptest.NewRequest("POST", "/api/v1/projects/2/rules",
+		strings.NewReader(`{"field":"env:PROJECT","pattern":"^llm$","priority":10}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateRule(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestProjectHandler_ErrorMapping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{service.ErrProjectNotFound, http.StatusNotFound},
+		{service.ErrProjectUserNotFound, http.StatusNotFound},
+		{service.ErrProjectForbidden, http.StatusForbidden},
+		{service.ErrProjectAdminRequired, http.StatusForbidden},
+		{service.ErrProjectNameExists, http.StatusConflict},
+		{service.ErrInvalidProjectMember, http.StatusBadRequest},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		mockService := new(MockProjectService)
+		handler := NewProjectHandler(mockService)
+		mockService.On("AddMember", uint(2), uint(3), mock.Anything).Return(nil, tc.err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", uint(3))
+		c.Params = gin.Params{{Key: "id", Value: "2"}}
+		c.Request = httptest.NewRequest("POST", "/api/v1/projects/2/members", strings.NewReader(`{"username":"alice"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.AddMember(c)
+
+		assert.Equal(t, tc.code, w.Code, tc.err.Error())
+	}
+}
+
+func TestProjectHandler_InvalidInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockProjectService)
+	handler := NewProjectHandler(mockService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/projects/abc", nil)
+	handler.GetProject(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// projectId 缺失
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "jobId", Value: "job-001"}}
+	c.Request = httptest.NewRequest("PUT", "/api/v1/jobs/job-001/project", strings.NewReader(`{}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler.SetJobProject(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	mockService.AssertNotCalled(t, "AssignJob", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestJobHandler_GetGroupedJobs_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	mockProjects := new(MockProjectService)
+	handler := NewJobHandler(mockService, nil)
+	handler.SetProjectService(mockProjects)
+
+	mockProjects.On("VisibleScope", uint(3)).Return(service.ProjectFilter{Restricted: true, VisibleIDs: []uint{2}}, nil)
+	mockService.On("GetGroupedJobs", mock.MatchedBy(func(f service.JobGroupFilter) bool {
+		p := f.Projects
+		return p.Restricted && len(p.VisibleIDs) == 1 && p.VisibleIDs[0] == 2 &&
+			len(p.ProjectIDs) == 2 && p.ProjectIDs[0] == 2 && p.ProjectIDs[1] == 0
+	}), "", "", 1, 20).Return([]service.JobGroup{}, int64(0), nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped?projectId=2&projectId=unassigned", nil)
+
+	handler.GetGroupedJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestJobHandler_GetJobByID_HiddenByProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	mockProjects := new(MockProjectService)
+	handler := NewJobHandler(mockService, nil)
+	handler.SetProjectService(mockProjects)
+	mockProjects.On("CanViewJob", "job-001", uint(0)).Return(false, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "jobId", Value: "job-001"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/job-001", nil)
+
+	handler.GetJobByID(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertNotCalled(t, "GetJobDetail", mock.Anything, mock.Anything)
+}
+
+func TestJobHandler_AnalyzeJob_HiddenByProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLLM := new(MockLLMService)
+	mockProjects := new(MockProjectService)
+	handler := NewJobHandler(new(MockJobService), mockLLM)
+	handler.SetProjectService(mockProjects)
+	mockProjects.On("CanViewJob", "job-001", uint(3)).Return(true, nil)
+	mockProjects.On("CanViewJob", "job-002", uint(3)).Return(false, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Params = gin.Params{{Key: "jobId", Value: "job-002"}}
+	c.Request = httptest.NewRequest("POST", "/api/v1/jobs/job-002/analyze", nil)
+	handler.AnalyzeJob(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("POST", "/api/v1/jobs/batch-analyze", strings.NewReader(`{"jobIds":["job-001","job-002"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler.BatchAnalyze(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockLLM.AssertNotCalled(t, "AnalyzeJob", mock.Anything)
+}
+
+func TestJobHandler_GetDistinctCardCounts_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockJobService)
+	handler := NewJobHandler(mockService, nil)
+	handler.SetProjectService(newRestrictedProjectService())
+	mockService.On("GetDistinctCardCounts", restrictedScope).Return([]int{8}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped/card-counts", nil)
+	handler.GetDistinctCardCounts(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestFullScopeOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.GetHeader("X-User") == "3" {
+			c.Set("userID", uint(3))
+		}
+	})
+	projects := newRestrictedProjectService()
+	projects.On("VisibleScope", uint(0)).Return(service.ProjectFilter{}, nil)
+	r.GET("/metrics/cluster", FullScopeOnly(projects, "forbidden"), func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics/cluster", nil)
+	req.Header.Set("X-User", "3")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics/cluster", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestParseJobFilter_InvalidProjectID(t *testing.T) {
+	for _, v := range []string{"0", "abc"} {
+		_, err := parseJobFilter(url.Values{"projectId": {v}})
+		assert.Error(t, err, v)
+	}
+}
+
+// restrictedScope 用户 3 只属于项目 5，开启了可见范围限制
+var restrictedScope = service.ProjectFilter{Restricted: true, VisibleIDs: []uint{5}}
+
+func newRestrictedProjectService() *MockProjectService {
+	projectService := new(MockProjectService)
+	projectService.On("VisibleScope", uint(3)).Return(restrictedScope, nil)
+	return projectService
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// projectScope 查询当前用户的项目可见范围，各处理器按该范围过滤返回的作业；未启用项目服务时不限制。
+// 查询失败时写入错误响应并返回 false
+func projectScope(c *gin.Context, projectService service.ProjectServiceInterface) (service.ProjectFilter, bool) {
+	if projectService == nil {
+		return service.ProjectFilter{}, true
+	}
+	scope, err := projectService.VisibleScope(currentUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return service.ProjectFilter{}, false
+	}
+	return scope, true
+}
+
+// requestScope 在当前用户的可见范围上叠加请求参数 projectId（可重复，unassigned 表示未归属），
+// 用于统计类接口。参数无效或查询失败时写入错误响应并返回 false
+func requestScope(c *gin.Context, projectService service.ProjectServiceInterface) (service.ProjectFilter, bool) {
+	ids, err := parseProjectIDs(c.Request.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return service.ProjectFilter{}, false
+	}
+	scope, ok := projectScope(c, projectService)
+	if !ok {
+		return service.ProjectFilter{}, false
+	}
+	scope.ProjectIDs = ids
+	return scope, true
+}
+
+// requireFullScope 当前用户的可见范围受限时以 message 返回 403，用于只按全集群提供的数据
+func requireFullScope(c *gin.Context, projectService service.ProjectServiceInterface, message string) bool {
+	scope, ok := projectScope(c, projectService)
+	if !ok {
+		return false
+	}
+	if scope.Restricted {
+		utils.ErrorResponse(c, http.StatusForbidden, message)
+		return false
+	}
+	return true
+}
+
+// FullScopeOnly 返回中间件：可见范围受限的用户以 message 返回 403，其余请求继续处理
+func FullScopeOnly(projectService service.ProjectServiceInterface, message string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireFullScope(c, projectService, message) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+const (
+	defaultReportRunLimit = 50
+	maxReportRunLimit     = 200
+	// maxReportPreviewDays 预览报表的最大统计跨度
+	maxReportPreviewDays = 366
+)
+
+// ReportHandler 定时报表处理器
+type ReportHandler struct {
+	reportService  service.ReportServiceInterface
+	projectService service.ProjectServiceInterface
+}
+
+// NewReportHandler 创建定时报表处理器
+func NewReportHandler(reportService service.ReportServiceInterface) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// SetProjectService 启用项目可见范围：预览只统计当前用户可见的作业；计划与运行记录对应全集群报表，受限用户不可维护或查看
+func (h *ReportHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
+// requireFullScope 当前用户的可见范围受限时返回 403；定时报表按全集群生成
+func (h *ReportHandler) requireFullScope(c *gin.Context) bool {
+	return requireFullScope(c, h.projectService, "scheduled reports cover all projects and are only available to administrators")
+}
+
+// ListSchedules 列出配置文件与数据库中的报表计划，webhook 地址已脱敏
+func (h *ReportHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.reportService.ListSchedules()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, schedules)
+}
+
+// CreateSchedule 创建报表计划
+func (h *ReportHandler) CreateSchedule(c *gin.Context) {
+	if !h.requireFullScope(c) {
+		return
+	}
+	var input service.ReportScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	schedule, err := h.reportService.CreateSchedule(input, currentUserID(c))
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, schedule)
+}
+
+// UpdateSchedule 更新报表计划；配置文件中的计划不可修改
+func (h *ReportHandler) UpdateSchedule(c *gin.Context) {
+	if !h.requireFullScope(c) {
+		return
+	}
+	id, ok := parseReportID(c, "invalid schedule id")
+	if !ok {
+		return
+	}
+	var input service.ReportScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	schedule, err := h.reportService.UpdateSchedule(id, input)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, schedule)
+}
+
+// DeleteSchedule 删除报表计划，运行记录保留
+func (h *ReportHandler) DeleteSchedule(c *gin.Context) {
+	if !h.requireFullScope(c) {
+		return
+	}
+	id, ok := parseReportID(c, "invalid schedule id")
+	if !ok {
+		return
+	}
+	if err := h.reportService.DeleteSchedule(id); err != nil {
+		respondReportError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, nil)
+}
+
+// runScheduleRequest 手动运行计划的请求体
+type runScheduleRequest struct {
+	Schedule string `json:"schedule" binding:"required"`
+}
+
+// RunSchedule 立即运行一次计划（不论是否启用），报表在后台生成，可通过返回的运行记录 ID 查询结果
+func (h *ReportHandler) RunSchedule(c *gin.Context) {
+	if !h.requireFullScope(c) {
+		return
+	}
+	var req runScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	run, err := h.reportService.RunSchedule(req.Schedule)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, run)
+}
+
+// ListRuns 列出运行记录，按开始时间倒序；schedule 参数按计划名称过滤
+func (h *ReportHandler) ListRuns(c *gin.Context) {
+	if !h.requireFullScope(c) {
+		return
+	}
+	limit := defaultReportRunLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	if limit > maxReportRunLimit {
+		limit = maxReportRunLimit
+	}
+	runs, err := h.reportService.ListRuns(c.Query("schedule"), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, runs)
+}
+
+// GetRun 获取单条运行记录
+func (h *ReportHandler) GetRun(c *gin.Context) {
+	if !h.requireFullScope(c) {
+		return
+	}
+	id, ok := parseReportID(c, "invalid run id")
+	if !ok {
+		return
+	}
+	run, err := h.reportService.GetRun(id)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, run)
+}
+
+// PreviewReport 按指定区间即时生成报表。format 为空时返回 JSON 汇总，否则直接输出渲染后的文档；
+// from/to 缺省为截止今天零点的最近 7 天
+func (h *ReportHandler) PreviewReport(c *gin.Context) {
+	query := c.Request.URL.Query()
+	fromMs, err := parseTimeParam(query, "from")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	toMs, err := parseTimeParam(query, "to")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if toMs != nil {
+		to = time.UnixMilli(*toMs)
+	}
+	from := to.AddDate(0, 0, -7)
+	if fromMs != nil {
+		from = time.UnixMilli(*fromMs)
+	}
+	if !to.After(from) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "to must be after from")
+		return
+	}
+	if to.Sub(from) > maxReportPreviewDays*24*time.Hour {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("report period must not exceed %d days", maxReportPreviewDays))
+		return
+	}
+	topN := 0
+	if raw := c.Query("topN"); raw != "" {
+		if topN, err = strconv.Atoi(raw); err != nil || topN < 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid topN")
+			return
+		}
+	}
+	format := c.Query("format")
+	if format != "" && format != service.ReportFormatMarkdown && format != service.ReportFormatHTML && format != service.ReportFormatCSV {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("unsupported format %q", format))
+		return
+	}
+
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	report, err := h.reportService.GenerateReport(c.Request.Context(), from, to, topN, scope)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate report: "+err.Error())
+		return
+	}
+	if format == "" {
+		utils.SuccessResponse(c, report)
+		return
+	}
+	content, err := service.RenderReport(report, format)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to render report: "+err.Error())
+		return
+	}
+	c.Data(http.StatusOK, service.ReportContentType(format), content)
+}
+
+func parseReportID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondReportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrReportScheduleNotFound), errors.Is(err, service.ErrReportRunNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrReportScheduleReadOnly):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrReportScheduleExists), errors.Is(err, service.ErrReportRunning):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidReportSchedule):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+	}
+}
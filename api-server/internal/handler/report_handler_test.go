@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// MockReportService is a mock implementation of ReportServiceInterface
+type MockReportService struct {
+	mock.Mock
+}
+
+func (m *MockReportService) GenerateReport(ctx context.Context, from, to time.Time, topN int, scope service.ProjectFilter) (*service.UtilizationReport, error) {
+	args := m.Called(ctx, from, to, topN, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.UtilizationReport), args.Error(1)
+}
+
+func (m *MockReportService) ListSchedules() ([]model.ReportSchedule, error) {
+	args := m.Called()
+	return args.Get(0).([]model.ReportSchedule), args.Error(1)
+}
+
+func (m *MockReportService) CreateSchedule(input service.ReportScheduleInput, userID uint) (*model.ReportSchedule, error) {
+	args := m.Called(input, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReportSchedule), args.Error(1)
+}
+
+func (m *MockReportService) UpdateSchedule(id uint, input service.ReportScheduleInput) (*model.ReportSchedule, error) {
+	args := m.Called(id, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReportSchedule), args.Error(1)
+}
+
+func (m *MockReportService) DeleteSchedule(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockReportService) RunSchedule(name string) (*model.ReportRun, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReportRun), args.Error(1)
+}
+
+func (m *MockReportService) ListRuns(scheduleName string, limit int) ([]model.ReportRun, error) {
+	args := m.Called(scheduleName, limit)
+	return args.Get(0).([]model.ReportRun), args.Error(1)
+}
+
+func (m *MockReportService) GetRun(id uint) (*model.ReportRun, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReportRun), args.Error(1)
+}
+
+func TestReportHandler_CreateSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReportService)
+	handler := NewReportHandler(mockService)
+	webhook := "https://hooks.example.com/send"
+	input := service.ReportScheduleInput{Name: "weekly", Cron: "0 9 * * 1", Formats: []string{"markdown"}, Webhook: &webhook}
+	mockService.On("CreateSchedule", input, uint(3)).Return(&model.ReportSchedule{ID: 1, Name: "weekly"}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("POST", "/api/v1/reports/schedules", strings.NewReader(
+		`{"name":"weekly","cron":"0 9 * * 1","formats":["markdown"],"webhook":"https://hooks.example.com/send"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateSchedule(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestReportHandler_ErrorMapping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{service.ErrReportScheduleNotFound, http.StatusNotFound},
+		{service.ErrReportScheduleReadOnly, http.StatusForbidden},
+		{service.ErrReportScheduleExists, http.StatusConflict},
+		{fmt.Errorf("%w: bad cron", service.ErrInvalidReportSchedule), http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		mockService := new(MockReportService)
+		handler := NewReportHandler(mockService)
+		mockService.On("UpdateSchedule", uint(5), mock.Anything).Return(nil, tc.err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "5"}}
+		c.Request = httptest.NewRequest("PUT", "/api/v1/reports/schedules/5", strings.NewReader(`{"name":"weekly"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.UpdateSchedule(c)
+
+		assert.Equal(t, tc.code, w.Code, tc.err.Error())
+	}
+}
+
+func TestReportHandler_RunSchedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReportService)
+	handler := NewReportHandler(mockService)
+	mockService.On("RunSchedule", "weekly").Return(nil, service.ErrReportRunning)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/reports/runs", strings.NewReader(`{"schedule":"weekly"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.RunSchedule(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestReportHandler_ListRuns_Limit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReportService)
+	handler := NewReportHandler(mockService)
+	mockService.On("ListRuns", "weekly", maxReportRunLimit).Return([]model.ReportRun{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/reports/runs?schedule=weekly&limit=1000", nil)
+	handler.ListRuns(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/reports/runs?limit=abc", nil)
+	handler.ListRuns(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestReportHandler_PreviewReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReportService)
+	handler := NewReportHandler(mockService)
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	report := &service.UtilizationReport{From: from, To: to, IssueSeverities: map[string]int{}}
+	mockService.On("GenerateReport", mock.Anything, mock.MatchedBy(from.Equal), mock.MatchedBy(to.Equal), 5, service.ProjectFilter{}).Return(report, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/reports/preview?from=2026-03-02T00:00:00Z&to=2026-03-09T00:00:00Z&topN=5&format=html", nil)
+	handler.PreviewReport(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<h1>NPU 使用与 AI 分析周报</h1>")
+
+	for _, query := range []string{
+		"from=2026-03-09T00:00:00Z&to=2026-03-02T00:00:00Z",
+		"from=2024-01-01T00:00:00Z&to=2026-03-02T00:00:00Z",
+		"format=pdf",
+		"topN=-1",
+	} {
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/reports/preview?"+query, nil)
+		handler.PreviewReport(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockService.AssertNumberOfCalls(t, "GenerateReport", 1)
+}
+
+func TestReportHandler_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockReportService)
+	handler := NewReportHandler(mockService)
+	handler.SetProjectService(newRestrictedProjectService())
+	mockService.On("GenerateReport", mock.Anything, mock.Anything, mock.Anything, 0, restrictedScope).
+		Return(&service.UtilizationReport{}, nil)
+
+	// 预览只统计可见范围内的作业
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/reports/preview", nil)
+	handler.PreviewReport(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 运行记录对应全集群报表，受限用户不可查看
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/reports/runs", nil)
+	handler.ListRuns(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request = httptest.NewRequest("GET", "/api/v1/reports/runs/1", nil)
+	handler.GetRun(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 计划同样按全集群生成并投递，受限用户不可创建、修改、删除或手动运行
+	body := `{"name":"mine","cron":"0 9 * * 1","webhook":"http://10.0.0.1/hook"}`
+	for name, call := range map[string]func(*gin.Context){
+		"create": handler.CreateSchedule, "update": handler.UpdateSchedule,
+		"delete": handler.DeleteSchedule, "run": handler.RunSchedule,
+	} {
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Set("userID", uint(3))
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request = httptest.NewRequest("POST", "/api/v1/reports/schedules", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		call(c)
+		assert.Equal(t, http.StatusForbidden, w.Code, name)
+	}
+	mockService.AssertNotCalled(t, "ListRuns", mock.Anything, mock.Anything)
+	mockService.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything)
+	mockService.AssertExpectations(t)
+}
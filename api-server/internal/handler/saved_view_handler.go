@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// savedViewParams 视图可保存的 GetGroupedJobs 查询参数：筛选参数加排序与每页条数；
+// 分页位置（page/cursor）与导出范围不属于视图
+var savedViewParams = func() map[string]struct{} {
+	params := map[string]struct{}{"sortBy": {}, "sortOrder": {}, "pageSize": {}}
+	for key := range groupFilterParams {
+		params[key] = struct{}{}
+	}
+	return params
+}()
+
+// SavedViewHandler 保存视图处理器
+type SavedViewHandler struct {
+	viewService service.SavedViewServiceInterface
+}
+
+// NewSavedViewHandler 创建保存视图处理器
+func NewSavedViewHandler(viewService service.SavedViewServiceInterface) *SavedViewHandler {
+	return &SavedViewHandler{viewService: viewService}
+}
+
+// ListViews 列出当前用户可见的视图（自己的与共享的），匿名请求仅返回共享视图
+func (h *SavedViewHandler) ListViews(c *gin.Context) {
+	views, err := h.viewService.List(currentUserID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, views)
+}
+
+// GetView 获取单个视图
+func (h *SavedViewHandler) GetView(c *gin.Context) {
+	id, ok := parseViewID(c)
+	if !ok {
+		return
+	}
+	view, err := h.viewService.Get(id, currentUserID(c))
+	if err != nil {
+		respondViewError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, view)
+}
+
+// CreateView 创建视图
+func (h *SavedViewHandler) CreateView(c *gin.Context) {
+	input, ok := bindViewInput(c)
+	if !ok {
+		return
+	}
+	view, err := h.viewService.Create(currentUserID(c), input)
+	if err != nil {
+		respondViewError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, view)
+}
+
+// UpdateView 全量更新视图，仅所有者可操作
+func (h *SavedViewHandler) UpdateView(c *gin.Context) {
+	id, ok := parseViewID(c)
+	if !ok {
+		return
+	}
+	input, ok := bindViewInput(c)
+	if !ok {
+		return
+	}
+	view, err := h.viewService.Update(id, currentUserID(c), input)
+	if err != nil {
+		respondViewError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, view)
+}
+
+// DeleteView 删除视图，仅所有者可操作
+func (h *SavedViewHandler) DeleteView(c *gin.Context) {
+	id, ok := parseViewID(c)
+	if !ok {
+		return
+	}
+	if err := h.viewService.Delete(id, currentUserID(c)); err != nil {
+		respondViewError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, nil)
+}
+
+func parseViewID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid view id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// bindViewInput 解析请求体并校验视图参数与导出列
+func bindViewInput(c *gin.Context) (service.SavedViewInput, bool) {
+	var input service.SavedViewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return input, false
+	}
+	if err := validateViewInput(input); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return input, false
+	}
+	return input, true
+}
+
+// validateViewInput 只允许保存分组列表的筛选/排序参数，取值按列表接口的规则解析一遍，
+// 保证通过 viewId 引用时不会因视图内容报错
+func validateViewInput(input service.SavedViewInput) error {
+	for key := range input.Params {
+		if _, ok := savedViewParams[key]; !ok {
+			return fmt.Errorf("unsupported view parameter %q", key)
+		}
+	}
+	query := url.Values(input.Params)
+	if _, err := parseGroupFilter(query); err != nil {
+		return err
+	}
+	if err := checkCardCountParams(query); err != nil {
+		return err
+	}
+	if _, err := selectAnalysisCSVColumns(input.Columns); err != nil {
+		return err
+	}
+	return nil
+}
+
+func respondViewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrViewNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrViewForbidden):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrViewNameExists):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrViewNameRequired):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+	}
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// MockSavedViewService is a mock implementation of SavedViewServiceInterface
+type MockSavedViewService struct {
+	mock.Mock
+}
+
+func (m *MockSavedViewService) List(userID uint) ([]model.SavedView, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) Get(id uint, userID uint) (*model.SavedView, error) {
+	args := m.Called(id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) Create(ownerID uint, input service.SavedViewInput) (*model.SavedView, error) {
+	args := m.Called(ownerID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) Update(id uint, userID uint, input service.SavedViewInput) (*model.SavedView, error) {
+	args := m.Called(id, userID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) Delete(id uint, userID uint) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func TestSavedViewHandler_CreateView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSavedViewService)
+	handler := NewSavedViewHandler(mockService)
+
+	input := service.SavedViewInput{
+		Name:    "running vllm",
+		Shared:  true,
+		Params:  map[string][]string{"status": {"running"}, "framework": {"vllm"}, "cardCount": {"8", "unknown"}},
+		Columns: []string{"jobId", "modelName"},
+	}
+	mockService.On("Create", uint(3), input).Return(&model.SavedView{ID: 1, Name: input.Name, OwnerID: 3}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("POST", "/api/v1/views", strings.NewReader(
+		`{"name":"running vllm","shared":true,"params":{"status":["running"],"framework":["vllm"],"cardCount":["8","unknown"]},"columns":["jobId","modelName"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateView(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSavedViewHandler_CreateView_InvalidInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bodies := []string{
+		`{"name":"v","params":{"page":["2"]}}`,
+		`{"name":"v","params":{"startTime":["yesterday"]}}`,
+		`{"name":"v","params":{"cardCount":["eight"]}}`,
+		`{"name":"v","columns":["jobId","password"]}`,
+		`{`,
+	}
+	for _, body := range bodies {
+		mockService := new(MockSavedViewService)
+		handler := NewSavedViewHandler(mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", uint(3))
+		c.Request = httptest.NewRequest("POST", "/api/v1/views", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.CreateView(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		mockService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	}
+}
+
+func TestSavedViewHandler_ErrorMapping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{service.ErrViewNotFound, http.StatusNotFound},
+		{service.ErrViewForbidden, http.StatusForbidden},
+		{service.ErrViewNameExists, http.StatusConflict},
+		{service.ErrViewNameRequired, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		mockService := new(MockSavedViewService)
+		handler := NewSavedViewHandler(mockService)
+		mockService.On("Update", uint(5), uint(3), mock.Anything).Return(nil, tc.err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", uint(3))
+		c.Params = gin.Params{{Key: "id", Value: "5"}}
+		c.Request = httptest.NewRequest("PUT", "/api/v1/views/5", strings.NewReader(`{"name":"v"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.UpdateView(c)
+
+		assert.Equal(t, tc.code, w.Code, tc.err.Error())
+	}
+}
+
+func TestSavedViewHandler_ListViews_Anonymous(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSavedViewService)
+	handler := NewSavedViewHandler(mockService)
+	mockService.On("List", uint(0)).Return([]model.SavedView{{ID: 1, Name: "shared", Shared: true}}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/views", nil)
+
+	handler.ListViews(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestJobHandler_GetGroupedJobs_WithView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockViewService := new(MockSavedViewService)
+	handler := NewJobHandler(mockJobService, nil)
+	handler.SetSavedViewService(mockViewService)
+
+	mockViewService.On("Get", uint(7), uint(3)).Return(&model.SavedView{
+		ID:      7,
+		OwnerID: 3,
+		Params:  map[string][]string{"status": {"running"}, "framework": {"vllm"}, "pageSize": {"50"}},
+	}, nil)
+	// 请求中显式传入的 status 覆盖视图中的取值
+	expected := service.JobGroupFilter{JobFilter: service.JobFilter{Statuses: []string{"stopped"}, Frameworks: []string{"vllm"}}}
+	mockJobService.On("GetGroupedJobs", expected, "", "", 1, 50).Return([]service.JobGroup{}, int64(0), nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped?viewId=7&status=stopped", nil)
+
+	handler.GetGroupedJobs(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockJobService.AssertExpectations(t)
+	mockViewService.AssertExpectations(t)
+}
+
+func TestJobHandler_GetGroupedJobs_ViewNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockViewService := new(MockSavedViewService)
+	handler := NewJobHandler(mockJobService, nil)
+	handler.SetSavedViewService(mockViewService)
+	mockViewService.On("Get", uint(7), uint(0)).Return(nil, service.ErrViewNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped?viewId=7", nil)
+
+	handler.GetGroupedJobs(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockJobService.AssertNotCalled(t, "GetGroupedJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestJobHandler_ExportAnalysesCSV_ViewColumns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockJobService := new(MockJobService)
+	mockLLMService := new(MockLLMService)
+	mockViewService := new(MockSavedViewService)
+	handler := NewJobHandler(mockJobService, mockLLMService)
+	handler.SetSavedViewService(mockViewService)
+
+	mockViewService.On("Get", uint(7), uint(0)).Return(&model.SavedView{
+		ID:      7,
+		Shared:  true,
+		Params:  map[string][]string{"framework": {"vllm"}},
+		Columns: []string{"modelName", "jobId"},
+	}, nil)
+	modelName := "qwen2.5"
+	mockJobService.On("GetGroupedJobsByCursor", service.JobGroupFilter{JobFilter: service.JobFilter{Frameworks: []string{"vllm"}}}, "", "", "", defaultExportChunkSize).
+		Return([]service.JobGroup{{MainJob: model.Job{JobID: "job-001"}}}, int64(1), "", nil)
+	mockLLMService.On("GetBatchAnalyses", []string{"job-001"}).Return(map[string]*service.JobAnalysisResponse{
+		"job-001": {ModelInfo: &service.JobAnalysisModelInfo{ModelName: &modelName}},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export?viewId=7", nil)
+
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(w.Body.String(), "\uFEFF"))).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"modelName", "jobId"}, {"qwen2.5", "job-001"}}, rows)
+	// 未选中启动脚本与硬件统计列时不查询作业详情
+	mockJobService.AssertNotCalled(t, "GetJobCodes", mock.Anything)
+	mockJobService.AssertNotCalled(t, "GetJobsNPUCards", mock.Anything)
+	mockJobService.AssertExpectations(t)
+}
+
+func TestJobHandler_ExportAnalysesCSV_UnknownColumn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewJobHandler(new(MockJobService), new(MockLLMService))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/analyses/export?columns=jobId&columns=secret", nil)
+
+	handler.ExportAnalysesCSV(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// SearchHandler 全局搜索处理器
+type SearchHandler struct {
+	searchService  service.SearchServiceInterface
+	projectService service.ProjectServiceInterface
+}
+
+// NewSearchHandler 创建全局搜索处理器
+func NewSearchHandler(searchService service.SearchServiceInterface) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// SetProjectService 启用项目可见范围：作业与分析结果只返回当前用户可见的作业
+func (h *SearchHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
+// Search 全局搜索节点、作业与 AI 分析结果
+// q 为关键词（必填），type 为 node/job/analysis/all（默认 all），limit 为每类返回数量（默认 10，最大 50）
+func (h *SearchHandler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		utils.ErrorResponse(c, 400, "q is required")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	scope, ok := projectScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	result, err := h.searchService.Search(query, c.DefaultQuery("type", service.SearchTypeAll), limit, scope)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSearchType) {
+			utils.ErrorResponse(c, 400, err.Error())
+		} else {
+			utils.ErrorResponse(c, 500, "Database error: "+err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, result)
+}
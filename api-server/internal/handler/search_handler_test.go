@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// MockSearchService is a mock implementation of SearchServiceInterface
+type MockSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSearchService) Search(query, searchType string, limit int, scope service.ProjectFilter) (*service.SearchResult, error) {
+	args := m.Called(query, searchType, limit, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.SearchResult), args.Error(1)
+}
+
+func TestSearchHandler_Search(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSearchService)
+	handler := NewSearchHandler(mockService)
+
+	jobName := "train_model.py"
+	mockService.On("Search", "train", "job", 50, service.ProjectFilter{}).Return(&service.SearchResult{
+		Jobs:     []model.Job{{JobID: "abc123def456", JobName: &jobName}},
+		Nodes:    []model.Node{},
+		Analyses: []service.AnalysisSearchHit{},
+		Total:    1,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/search?q=+train+&type=job&limit=500", nil)
+
+	handler.Search(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["total"])
+	assert.Len(t, data["jobs"], 1)
+	mockService.AssertExpectations(t)
+}
+
+func TestSearchHandler_Search_BadRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSearchService)
+	handler := NewSearchHandler(mockService)
+	mockService.On("Search", "x", "user", 10, service.ProjectFilter{}).Return(nil, service.ErrInvalidSearchType)
+	mockService.On("Search", "y", "all", 10, service.ProjectFilter{}).Return(nil, errors.New("db down"))
+
+	cases := map[string]int{
+		"/api/v1/search":               http.StatusBadRequest,
+		"/api/v1/search?q=x&type=user": http.StatusBadRequest,
+		"/api/v1/search?q=y":           http.StatusInternalServerError,
+	}
+	for url, code := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", url, nil)
+
+		handler.Search(c)
+
+		assert.Equal(t, code, w.Code, url)
+	}
+}
+
+func TestSearchHandler_Search_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockSearchService)
+	handler := NewSearchHandler(mockService)
+	handler.SetProjectService(newRestrictedProjectService())
+	// 可见范围随搜索条件下推，其他项目的作业与分析结果不会命中
+	mockService.On("Search", "train", "all", 10, restrictedScope).Return(&service.SearchResult{
+		Jobs: []model.Job{}, Nodes: []model.Node{}, Analyses: []service.AnalysisSearchHit{},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(3))
+	c.Request = httptest.NewRequest("GET", "/api/v1/search?q=train", nil)
+	handler.Search(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
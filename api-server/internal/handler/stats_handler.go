@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-monitor/api-server/internal/service"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// defaultTrendWindow 趋势查询未指定开始时间时的默认时间范围
+const defaultTrendWindow = 24 * time.Hour
+
+// StatsHandler 集群统计处理器
+type StatsHandler struct {
+	statsService   service.StatsServiceInterface
+	projectService service.ProjectServiceInterface
+}
+
+// NewStatsHandler 创建集群统计处理器
+func NewStatsHandler(statsService service.StatsServiceInterface) *StatsHandler {
+	return &StatsHandler{statsService: statsService}
+}
+
+// SetProjectService 启用项目可见范围：统计只包含可见项目作业占用的卡、所在节点与作业数
+func (h *StatsHandler) SetProjectService(projectService service.ProjectServiceInterface) {
+	h.projectService = projectService
+}
+
+// GetClusterStats 获取集群整体统计：节点、NPU 卡与芯片占用、平均负载、功耗与运行中作业分布；
+// 指定 projectId 或可见范围受限时只统计范围内作业
+func (h *StatsHandler) GetClusterStats(c *gin.Context) {
+	scope, ok := requestScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	stats, err := h.statsService.GetClusterStats(scope)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, stats)
+}
+
+// GetNodeStats 获取各节点的 NPU 占用与负载统计，范围同 GetClusterStats
+func (h *StatsHandler) GetNodeStats(c *gin.Context) {
+	scope, ok := requestScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	nodes, err := h.statsService.GetNodeStats(scope)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, gin.H{"nodes": nodes})
+}
+
+// GetTrends 获取趋势数据；endTime 缺省为当前时间，startTime 缺省为 endTime 前 24 小时，范围同 GetClusterStats
+func (h *StatsHandler) GetTrends(c *gin.Context) {
+	scope, ok := requestScope(c, h.projectService)
+	if !ok {
+		return
+	}
+	query := c.Request.URL.Query()
+	startMs, err := parseTimeParam(query, "startTime")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	endMs, err := parseTimeParam(query, "endTime")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	end := time.Now()
+	if endMs != nil {
+		end = time.UnixMilli(*endMs)
+	}
+	start := end.Add(-defaultTrendWindow)
+	if startMs != nil {
+		start = time.UnixMilli(*startMs)
+	}
+
+	metric := c.DefaultQuery("metric", service.TrendNPUUsage)
+	trend, err := h.statsService.GetTrend(metric, start, end, c.Query("interval"), scope)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTrendQuery) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, trend)
+}
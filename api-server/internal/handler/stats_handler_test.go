@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/service"
+)
+
+// MockStatsService is a mock implementation of StatsServiceInterface
+type MockStatsService struct {
+	mock.Mock
+}
+
+func (m *MockStatsService) GetClusterStats(scope service.ProjectFilter) (*service.ClusterStats, error) {
+	args := m.Called(scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ClusterStats), args.Error(1)
+}
+
+func (m *MockStatsService) GetNodeStats(scope service.ProjectFilter) ([]service.NodeStats, error) {
+	args := m.Called(scope)
+	return args.Get(0).([]service.NodeStats), args.Error(1)
+}
+
+func (m *MockStatsService) GetTrend(metric string, from, to time.Time, interval string, scope service.ProjectFilter) (*service.Trend, error) {
+	args := m.Called(metric, from, to, interval, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.Trend), args.Error(1)
+}
+
+func TestStatsHandler_GetClusterStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockStatsService)
+	handler := NewStatsHandler(mockService)
+	stats := &service.ClusterStats{TotalNodes: 2, NPUUsageStats: service.NPUUsageStats{TotalCards: 16, UsedCards: 10, IdleCards: 6}}
+	mockService.On("GetClusterStats", service.ProjectFilter{}).Return(stats, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/stats/cluster", nil)
+	handler.GetClusterStats(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(16), resp.Data["totalCards"])
+	assert.Equal(t, float64(6), resp.Data["idleCards"])
+}
+
+func TestStatsHandler_GetTrends(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockStatsService)
+	handler := NewStatsHandler(mockService)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	mockService.On("GetTrend", "used_cards", mock.MatchedBy(start.Equal), mock.MatchedBy(end.Equal), "1h", service.ProjectFilter{}).
+		Return(&service.Trend{Metric: "used_cards", Interval: "1h"}, nil)
+	mockService.On("GetTrend", "gpu_usage", mock.Anything, mock.Anything, "", service.ProjectFilter{}).
+		Return(nil, fmt.Errorf("%w: unsupported metric", service.ErrInvalidTrendQuery))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/stats/trends?metric=used_cards&startTime=2026-03-01T00:00:00Z&endTime=2026-03-02T00:00:00Z&interval=1h", nil)
+	handler.GetTrends(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, query := range []string{"metric=gpu_usage", "startTime=yesterday"} {
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/stats/trends?"+query, nil)
+		handler.GetTrends(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestStatsHandler_ProjectScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockStatsService)
+	handler := NewStatsHandler(mockService)
+	handler.SetProjectService(newRestrictedProjectService())
+	scoped := service.ProjectFilter{ProjectIDs: []uint{5}, Restricted: true, VisibleIDs: []uint{5}}
+	mockService.On("GetClusterStats", scoped).Return(&service.ClusterStats{TotalNodes: 1}, nil)
+	mockService.On("GetNodeStats", restrictedScope).Return([]service.NodeStats{}, nil)
+	mockService.On("GetTrend", service.TrendNPUUsage, mock.Anything, mock.Anything, "", restrictedScope).
+		Return(&service.Trend{Metric: service.TrendNPUUsage}, nil)
+
+	requests := []struct {
+		path   string
+		handle gin.HandlerFunc
+		code   int
+	}{
+		{"/api/v1/stats/cluster?projectId=5", handler.GetClusterStats, http.StatusOK},
+		{"/api/v1/stats/nodes", handler.GetNodeStats, http.StatusOK},
+		{"/api/v1/stats/trends", handler.GetTrends, http.StatusOK},
+		{"/api/v1/stats/cluster?projectId=abc", handler.GetClusterStats, http.StatusBadRequest},
+	}
+	for _, r := range requests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", uint(3))
+		c.Request = httptest.NewRequest("GET", r.path, nil)
+		r.handle(c)
+		assert.Equal(t, r.code, w.Code, r.path)
+	}
+	mockService.AssertExpectations(t)
+}
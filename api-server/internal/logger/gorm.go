@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger 将 GORM 日志输出到 slog，级别跟随全局日志级别：
+// debug 记录全部 SQL；info/warn 仅记录慢查询与错误；error 仅记录错误。
+type GormLogger struct {
+	// forced 非零时覆盖全局级别（db.Debug() 等通过 LogMode 指定）
+	forced gormlogger.LogLevel
+}
+
+// NewGormLogger 创建 GORM 日志适配器
+func NewGormLogger() *GormLogger {
+	return &GormLogger{}
+}
+
+func (l *GormLogger) effectiveLevel() gormlogger.LogLevel {
+	if l.forced != 0 {
+		return l.forced
+	}
+	switch lv := level.Level(); {
+	case lv <= slog.LevelDebug:
+		return gormlogger.Info
+	case lv <= slog.LevelWarn:
+		return gormlogger.Warn
+	default:
+		return gormlogger.Error
+	}
+}
+
+// LogMode 实现 gormlogger.Interface
+func (l *GormLogger) LogMode(lv gormlogger.LogLevel) gormlogger.Interface {
+	return &GormLogger{forced: lv}
+}
+
+// Info 实现 gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.effectiveLevel() >= gormlogger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+	}
+}
+
+// Warn 实现 gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.effectiveLevel() >= gormlogger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+	}
+}
+
+// Error 实现 gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.effectiveLevel() >= gormlogger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+	}
+}
+
+// Trace 实现 gormlogger.Interface，记录 SQL 执行情况
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	lv := l.effectiveLevel()
+	if lv <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	threshold := time.Duration(slowQueryThreshold.Load())
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && lv >= gormlogger.Error:
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "sql error", "component", "gorm", "error", err,
+			"elapsed_ms", elapsed.Milliseconds(), "rows", rows, "sql", sql)
+	case threshold > 0 && elapsed > threshold && lv >= gormlogger.Warn:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "slow sql", "component", "gorm", "threshold_ms", threshold.Milliseconds(),
+			"elapsed_ms", elapsed.Milliseconds(), "rows", rows, "sql", sql)
+	case lv >= gormlogger.Info:
+		// 全局 debug 时按 debug 输出；db.Debug() 显式开启时按 info 输出，避免被级别过滤
+		logLevel := slog.LevelDebug
+		if l.forced != 0 {
+			logLevel = slog.LevelInfo
+		}
+		sql, rows := fc()
+		slog.Log(ctx, logLevel, "sql", "component", "gorm",
+			"elapsed_ms", elapsed.Milliseconds(), "rows", rows, "sql", sql)
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/task-monitor/api-server/internal/config"
+)
+
+type ctxKey struct{}
+
+var (
+	// level 全局日志级别，支持热更新
+	level = new(slog.LevelVar)
+	// slowQueryThreshold GORM 慢查询阈值（纳秒），0 表示不记录慢查询
+	slowQueryThreshold atomic.Int64
+)
+
+// Setup 根据日志配置初始化全局 slog 日志；返回的 Closer 用于退出时关闭日志文件
+func Setup(cfg config.LogConfig) (*slog.Logger, io.Closer) {
+	ApplyConfig(cfg)
+
+	var out io.Writer = os.Stdout
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		rotator := &lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+			LocalTime:  true,
+		}
+		out, closer = rotator, rotator
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "text") {
+		handler = slog.NewTextHandler(out, opts)
+	} else {
+		handler = slog.NewJSONHandler(out, opts)
+	}
+
+	l := slog.New(&contextHandler{Handler: handler})
+	slog.SetDefault(l)
+	return l, closer
+}
+
+// ApplyConfig 更新可热加载的日志配置（级别、慢查询阈值）
+func ApplyConfig(cfg config.LogConfig) {
+	level.Set(ParseLevel(cfg.Level))
+	slowQueryThreshold.Store(int64(time.Duration(cfg.SlowQueryMs) * time.Millisecond))
+}
+
+// ParseLevel 解析日志级别，未知值按 info 处理
+func ParseLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID 将请求ID写入 context，之后通过 slog.*Context 输出的日志自动带上 request_id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, requestID)
+}
+
+// RequestIDFromContext 读取 context 中的请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// contextHandler 从 context 中提取请求ID附加到日志记录
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
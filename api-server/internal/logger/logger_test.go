@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/config"
+)
+
+func setupFileLogger(t *testing.T, cfg config.LogConfig) string {
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	cfg.File = filepath.Join(t.TempDir(), "api-server.log")
+	_, closer := Setup(cfg)
+	t.Cleanup(func() { closer.Close() })
+	return cfg.File
+}
+
+func readLogLines(t *testing.T, path string) []map[string]interface{} {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestSetup_JSONFileWithLevelAndRequestID(t *testing.T) {
+	path := setupFileLogger(t, config.LogConfig{Level: "info", Format: "json", MaxSizeMB: 1})
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	slog.DebugContext(ctx, "hidden")
+	slog.InfoContext(ctx, "visible", "job_id", "job-1")
+
+	lines := readLogLines(t, path)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "visible", lines[0]["msg"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.Equal(t, "job-1", lines[0]["job_id"])
+}
+
+func TestApplyConfig_ChangesLevel(t *testing.T) {
+	path := setupFileLogger(t, config.LogConfig{Level: "error", Format: "json"})
+
+	slog.Warn("dropped")
+	ApplyConfig(config.LogConfig{Level: "debug"})
+	slog.Debug("kept")
+
+	lines := readLogLines(t, path)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "kept", lines[0]["msg"])
+}
+
+func TestGormLogger_Trace(t *testing.T) {
+	path := setupFileLogger(t, config.LogConfig{Level: "info", Format: "json", SlowQueryMs: 100})
+	gl := NewGormLogger()
+	fc := func() (string, int64) { return "SELECT 1", 1 }
+
+	gl.Trace(context.Background(), time.Now(), fc, nil)                    // 普通查询：info 级别不记录
+	gl.Trace(context.Background(), time.Now().Add(-time.Second), fc, nil)  // 慢查询
+	gl.Trace(context.Background(), time.Now(), fc, errors.New("deadlock")) // 错误
+
+	lines := readLogLines(t, path)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "slow sql", lines[0]["msg"])
+	assert.Equal(t, "SELECT 1", lines[0]["sql"])
+	assert.Equal(t, "sql error", lines[1]["msg"])
+	assert.Equal(t, "deadlock", lines[1]["error"])
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, ParseLevel("DEBUG"))
+	assert.Equal(t, slog.LevelWarn, ParseLevel("warn"))
+	assert.Equal(t, slog.LevelError, ParseLevel("error"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("unknown"))
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace 指标名前缀
+const Namespace = "task_monitor"
+
+// Registry API Server 自身指标的注册表（不使用全局默认注册表，便于测试隔离）
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration HTTP 请求耗时，按路由模板统计，避免路径参数导致标签爆炸
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration GORM 查询耗时
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Database query latency by operation and table.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "table", "outcome"})
+
+	// LLMCalls LLM 调用次数，outcome: success / request_error / http_error / parse_error
+	LLMCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "llm",
+		Name:      "calls_total",
+		Help:      "LLM calls by model and outcome.",
+	}, []string{"model_id", "provider", "outcome"})
+
+	// LLMCallDuration LLM 调用耗时
+	LLMCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "llm",
+		Name:      "call_duration_seconds",
+		Help:      "LLM call latency by model.",
+		Buckets:   []float64{.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"model_id", "provider"})
+
+	// CacheRequests 查询缓存访问次数，result: hit / miss
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Query cache lookups by namespace and result.",
+	}, []string{"namespace", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		DBQueryDuration,
+		LLMCalls,
+		LLMCallDuration,
+		CacheRequests,
+	)
+}
+
+// Handler 返回 Prometheus 文本格式的指标输出
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveLLMCall 记录一次 LLM 调用
+func ObserveLLMCall(modelID, provider, outcome string, elapsed time.Duration) {
+	LLMCalls.WithLabelValues(modelID, provider, outcome).Inc()
+	LLMCallDuration.WithLabelValues(modelID, provider).Observe(elapsed.Seconds())
+}
+
+// RegisterDBStats 注册连接池指标（来自 sql.DB.Stats()）
+func RegisterDBStats(db *sql.DB, dbName string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// RegisterBatchStats 注册批量分析指标，fn 返回运行中的批次数与尚未完成的作业数
+func RegisterBatchStats(fn func() (running int, pendingJobs int64)) {
+	Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "batch_analyze",
+			Name:      "running_batches",
+			Help:      "Number of batch analyses currently running.",
+		}, func() float64 {
+			running, _ := fn()
+			return float64(running)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "batch_analyze",
+			Name:      "pending_jobs",
+			Help:      "Number of jobs not yet analyzed in running batches.",
+		}, func() float64 {
+			_, pending := fn()
+			return float64(pending)
+		}),
+	)
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func scrape(t *testing.T) string {
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestGormPlugin_ObservesQueries(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	mock.ExpectQuery("SELECT \\* FROM `metrics_test_nodes`").
+		WillReturnRows(sqlmock.NewRows([]string{"node_id"}).AddRow("node-001"))
+
+	var rows []map[string]interface{}
+	require.NoError(t, db.Table("metrics_test_nodes").Find(&rows).Error)
+
+	assert.Contains(t, scrape(t),
+		`task_monitor_db_query_duration_seconds_count{operation="query",outcome="success",table="metrics_test_nodes"} 1`)
+}
+
+func TestObserveLLMCall(t *testing.T) {
+	ObserveLLMCall("metrics-test-model", "openai", "http_error", 2*time.Second)
+
+	body := scrape(t)
+	assert.Contains(t, body, `task_monitor_llm_calls_total{model_id="metrics-test-model",outcome="http_error",provider="openai"} 1`)
+	assert.Contains(t, body, `task_monitor_llm_call_duration_seconds_count{model_id="metrics-test-model",provider="openai"} 1`)
+}
+
+func TestRegisterBatchStats(t *testing.T) {
+	RegisterBatchStats(func() (int, int64) { return 2, 15 })
+
+	body := scrape(t)
+	assert.Contains(t, body, "task_monitor_batch_analyze_running_batches 2")
+	assert.Contains(t, body, "task_monitor_batch_analyze_pending_jobs 15")
+}
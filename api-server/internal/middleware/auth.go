@@ -38,3 +38,18 @@ func JWTAuth(authService service.AuthServiceInterface) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalJWTAuth 可选JWT认证：携带有效令牌时写入用户信息，未携带或令牌无效时按匿名用户继续处理
+// 用于公开接口中需要区分当前用户的场景（如解析个人保存视图）
+func OptionalJWTAuth(authService service.AuthServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if userID, username, err := authService.ParseToken(parts[1]); err == nil {
+				c.Set("userID", userID)
+				c.Set("username", username)
+			}
+		}
+		c.Next()
+	}
+}
@@ -129,3 +129,45 @@ func TestJWTAuth_InvalidToken(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 	mockSvc.AssertExpectations(t)
 }
+
+func TestOptionalJWTAuth_ValidToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockAuthService)
+
+	mockSvc.On("ParseToken", "valid-token").Return(uint(2), "alice", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped", nil)
+	c.Request.Header.Set("Authorization", "Bearer valid-token")
+
+	OptionalJWTAuth(mockSvc)(c)
+
+	assert.False(t, c.IsAborted())
+	userID, _ := c.Get("userID")
+	assert.Equal(t, uint(2), userID)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestOptionalJWTAuth_AnonymousOrInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockAuthService)
+
+	mockSvc.On("ParseToken", "bad-token").Return(uint(0), "", errors.New("invalid token"))
+
+	for _, header := range []string{"", "Token abc", "Bearer bad-token"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/jobs/grouped", nil)
+		if header != "" {
+			c.Request.Header.Set("Authorization", header)
+		}
+
+		OptionalJWTAuth(mockSvc)(c)
+
+		assert.False(t, c.IsAborted(), header)
+		_, exists := c.Get("userID")
+		assert.False(t, exists, header)
+	}
+	mockSvc.AssertExpectations(t)
+}
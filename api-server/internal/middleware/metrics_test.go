@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-monitor/api-server/internal/metrics"
+)
+
+func TestMetrics_UsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Metrics())
+	r.GET("/metrics-test/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics-test/a", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics-test/b", nil))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, w.Body.String(),
+		`task_monitor_http_request_duration_seconds_count{method="GET",route="/metrics-test/:id",status="200"} 2`)
+}
@@ -20,8 +20,8 @@ type Job struct {
 	StartTime   *int64     `gorm:"column:start_time" json:"startTime"`
 	EndTime     *int64     `gorm:"column:end_time" json:"endTime"`
 	CWD         *string    `gorm:"column:cwd" json:"cwd"`
-	CreatedAt   time.Time  `gorm:"column:created_at" json:"createdAt"`
-	UpdatedAt   *time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	CreatedAt   time.Time  `gorm:"column:created_at;index" json:"createdAt"`
+	UpdatedAt   *time.Time `gorm:"column:updated_at;index" json:"updatedAt"`
 }
 
 func (Job) TableName() string {
@@ -10,8 +10,24 @@ type JobAnalysis struct {
 	Result    string    `gorm:"column:result;type:longtext;not null"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at"`
+	JobAnalysisFields
 }
 
 func (JobAnalysis) TableName() string {
 	return "job_analysis"
 }
+
+// JobAnalysisFields 从分析结果 JSON 中提取的关键字段，建索引用于列表筛选。
+// 分析完成时写入；FieldsExtracted 为 false 的历史记录由启动时的回填任务补齐
+type JobAnalysisFields struct {
+	Category           string `gorm:"column:category;type:varchar(32);index;not null;default:''"`
+	SubCategory        string `gorm:"column:sub_category;type:varchar(64);index;not null;default:''"`
+	InferenceFramework string `gorm:"column:inference_framework;type:varchar(64);index;not null;default:''"`
+	ModelName          string `gorm:"column:model_name;type:varchar(255);index;not null;default:''"`
+	ModelSize          string `gorm:"column:model_size;type:varchar(32);index;not null;default:''"`
+	Precision          string `gorm:"column:model_precision;type:varchar(32);index;not null;default:''"` // precision 为 MySQL 保留字
+	NPUUtilization     string `gorm:"column:npu_utilization;type:varchar(32);index;not null;default:''"`
+	HBMUtilization     string `gorm:"column:hbm_utilization;type:varchar(32);index;not null;default:''"`
+	MaxIssueSeverity   string `gorm:"column:max_issue_severity;type:varchar(16);index;not null;default:''"` // critical / warning / info / none
+	FieldsExtracted    bool   `gorm:"column:fields_extracted;index;not null;default:false"`
+}
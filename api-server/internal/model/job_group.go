@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// JobGroupRecord 持久化的作业分组（进程树），由 API Server 后台按作业变更增量维护。
+// 根作业的名称、类型、框架、状态、启动与结束时间冗余存储，用于在 SQL 中筛选、排序和分页。
+type JobGroupRecord struct {
+	ID        uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	NodeID    string    `gorm:"column:node_id;size:128;index" json:"nodeId"`
+	RootJobID string    `gorm:"column:root_job_id;size:128;uniqueIndex" json:"rootJobId"`
+	JobName   *string   `gorm:"column:job_name;size:255" json:"jobName"`
+	JobType   *string   `gorm:"column:job_type;size:64;index" json:"jobType"`
+	Framework *string   `gorm:"column:framework;size:64;index" json:"framework"`
+	Status    *string   `gorm:"column:status;size:32;index" json:"status"`
+	StartTime *int64    `gorm:"column:start_time;index" json:"startTime"`
+	EndTime   *int64    `gorm:"column:end_time;index" json:"endTime"`
+	CardCount *int      `gorm:"column:card_count;index" json:"cardCount"` // nil 表示 unknown
+	JobCount  int       `gorm:"column:job_count" json:"jobCount"`
+	Hidden    bool      `gorm:"column:hidden;index" json:"hidden"` // 纯停止词进程组（shell、容器运行时等），不在列表与统计中展示
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (JobGroupRecord) TableName() string {
+	return "job_groups"
+}
+
+// JobGroupMember 作业到分组的映射
+type JobGroupMember struct {
+	JobID   string `gorm:"column:job_id;size:128;primaryKey" json:"jobId"`
+	GroupID uint   `gorm:"column:group_id;index" json:"groupId"`
+}
+
+func (JobGroupMember) TableName() string {
+	return "job_group_members"
+}
+
+// JobGroupSyncState 分组增量同步进度（单行，ID 固定为 1）
+type JobGroupSyncState struct {
+	ID        uint       `gorm:"column:id;primaryKey" json:"id"`
+	Watermark time.Time  `gorm:"column:watermark" json:"watermark"`  // 已处理到的作业变更时间（created_at/updated_at）
+	RebuiltAt *time.Time `gorm:"column:rebuilt_at" json:"rebuiltAt"` // 最近一次全量重建完成时间，为空表示尚未建立
+}
+
+func (JobGroupSyncState) TableName() string {
+	return "job_group_sync_state"
+}
@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// Project 项目/团队。作业按归属规则自动归属到项目，也可以手动指定
+type Project struct {
+	ID          uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name        string    `gorm:"column:name;size:100;not null;uniqueIndex" json:"name"`
+	Description string    `gorm:"column:description;size:500" json:"description"`
+	CreatedBy   uint      `gorm:"column:created_by" json:"createdBy"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (Project) TableName() string {
+	return "projects"
+}
+
+// 项目成员角色：owner 可维护项目信息、成员与归属规则，member 可查看项目作业并手动认领作业
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleMember = "member"
+)
+
+// ProjectMember 项目成员
+type ProjectMember struct {
+	ProjectID uint      `gorm:"column:project_id;primaryKey" json:"projectId"`
+	UserID    uint      `gorm:"column:user_id;primaryKey;index" json:"userId"`
+	Role      string    `gorm:"column:role;size:16;not null" json:"role"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (ProjectMember) TableName() string {
+	return "project_members"
+}
+
+// ProjectRule 作业归属规则：作业的 field 取值匹配正则 pattern 时归属到项目。
+// 全部项目的规则按 priority 从高到低、id 从小到大依次匹配，第一条命中的规则生效
+type ProjectRule struct {
+	ID        uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ProjectID uint      `gorm:"column:project_id;not null;index" json:"projectId"`
+	Field     string    `gorm:"column:field;size:128;not null" json:"field"` // cwd / command_line / node / env:<变量名>
+	Pattern   string    `gorm:"column:pattern;size:512;not null" json:"pattern"`
+	Priority  int       `gorm:"column:priority;not null;default:0" json:"priority"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (ProjectRule) TableName() string {
+	return "project_rules"
+}
+
+// 作业归属来源
+const (
+	JobProjectSourceRule   = "rule"
+	JobProjectSourceManual = "manual"
+)
+
+// JobProject 作业的归属项目，没有记录的作业视为未归属。
+// 规则归属由后台按作业变更与规则变更重新计算，手动归属不会被规则覆盖
+type JobProject struct {
+	JobID     string    `gorm:"column:job_id;size:128;primaryKey" json:"jobId"`
+	ProjectID uint      `gorm:"column:project_id;not null;index" json:"projectId"`
+	Source    string    `gorm:"column:source;size:16;not null" json:"source"` // rule / manual
+	RuleID    *uint     `gorm:"column:rule_id" json:"ruleId"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (JobProject) TableName() string {
+	return "job_projects"
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// ReportSchedule 通过接口维护的报表计划；配置文件中的计划不入库，运行时以 Source=config 合并展示
+type ReportSchedule struct {
+	ID         uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name       string    `gorm:"column:name;size:100;not null;uniqueIndex" json:"name"`
+	Cron       string    `gorm:"column:cron;size:100;not null" json:"cron"`
+	PeriodDays int       `gorm:"column:period_days;not null" json:"periodDays"`
+	Formats    []string  `gorm:"column:formats;type:text;serializer:json" json:"formats"`
+	Webhook    string    `gorm:"column:webhook;size:1024" json:"webhook"`
+	TopN       int       `gorm:"column:top_n;not null" json:"topN"`
+	Enabled    bool      `gorm:"column:enabled;not null" json:"enabled"`
+	CreatedBy  uint      `gorm:"column:created_by" json:"createdBy"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	Source     string    `gorm:"-" json:"source"`        // config / db
+	Dir        string    `gorm:"-" json:"dir,omitempty"` // 仅配置文件中的计划可指定写入目录
+}
+
+func (ReportSchedule) TableName() string {
+	return "report_schedules"
+}
+
+// ReportRun 报表运行记录
+type ReportRun struct {
+	ID               uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ScheduleName     string     `gorm:"column:schedule_name;size:100;not null;index" json:"scheduleName"`
+	Trigger          string     `gorm:"column:trigger_type;size:16;not null" json:"trigger"` // schedule / manual；trigger 为 MySQL 保留字
+	PeriodFrom       time.Time  `gorm:"column:period_from" json:"periodFrom"`
+	PeriodTo         time.Time  `gorm:"column:period_to" json:"periodTo"`
+	Status           string     `gorm:"column:status;size:16;not null;index" json:"status"` // running / succeeded / failed
+	Files            []string   `gorm:"column:files;type:text;serializer:json" json:"files"`
+	WebhookDelivered bool       `gorm:"column:webhook_delivered;not null" json:"webhookDelivered"`
+	Error            string     `gorm:"column:error;type:text" json:"error,omitempty"`
+	StartedAt        time.Time  `gorm:"column:started_at;index" json:"startedAt"`
+	FinishedAt       *time.Time `gorm:"column:finished_at" json:"finishedAt"`
+}
+
+func (ReportRun) TableName() string {
+	return "report_runs"
+}
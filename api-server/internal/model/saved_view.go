@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// SavedView 保存的分组作业筛选视图
+// Params 为 GetGroupedJobs 查询参数（参数名 -> 取值列表），Columns 为 CSV 导出列选择，空表示导出全部列
+type SavedView struct {
+	ID        uint                `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name      string              `gorm:"column:name;size:100;not null;uniqueIndex:idx_saved_views_owner_name,priority:2" json:"name"`
+	OwnerID   uint                `gorm:"column:owner_id;not null;uniqueIndex:idx_saved_views_owner_name,priority:1" json:"ownerId"`
+	Shared    bool                `gorm:"column:shared;not null;default:false;index" json:"shared"`
+	Params    map[string][]string `gorm:"column:params;type:text;serializer:json" json:"params"`
+	Columns   []string            `gorm:"column:columns;type:text;serializer:json" json:"columns"`
+	CreatedAt time.Time           `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt time.Time           `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (SavedView) TableName() string {
+	return "saved_views"
+}
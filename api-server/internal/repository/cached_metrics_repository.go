@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/cache"
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+// CachedMetricsRepository 为最新 NPU 指标查询加缓存，其余方法直接透传。
+// 作业详情按卡查询最新指标的频率远高于 agent 上报频率，短 TTL 即可大幅减少重复查询。
+type CachedMetricsRepository struct {
+	MetricsRepositoryInterface
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedMetricsRepository 创建带缓存的指标Repository
+func NewCachedMetricsRepository(inner MetricsRepositoryInterface, c cache.Cache, ttl time.Duration) *CachedMetricsRepository {
+	return &CachedMetricsRepository{
+		MetricsRepositoryInterface: inner,
+		cache:                      c,
+		ttl:                        ttl,
+	}
+}
+
+// FindLatestNPUMetrics 带缓存的最新 NPU 指标查询，缓存键由节点与排序后的卡号决定
+func (r *CachedMetricsRepository) FindLatestNPUMetrics(nodeID string, npuIDs []int) ([]model.NPUMetric, error) {
+	if len(npuIDs) == 0 {
+		return []model.NPUMetric{}, nil
+	}
+
+	ids := append([]int(nil), npuIDs...)
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	key := cache.Key(cache.NamespaceMetrics, "latest", nodeID, strings.Join(parts, ","))
+
+	return cache.Remember(context.Background(), r.cache, key, r.ttl, func() ([]model.NPUMetric, error) {
+		return r.MetricsRepositoryInterface.FindLatestNPUMetrics(nodeID, npuIDs)
+	})
+}
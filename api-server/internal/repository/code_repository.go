@@ -22,3 +22,13 @@ func (r *CodeRepository) FindByJobID(jobID string) ([]model.Code, error) {
 	err := r.db.Where("job_id = ?", jobID).Order("timestamp DESC").Find(&codes).Error
 	return codes, err
 }
+
+// FindByJobIDs 批量查找多个作业的代码，同一作业内按时间倒序
+func (r *CodeRepository) FindByJobIDs(jobIDs []string) ([]model.Code, error) {
+	if len(jobIDs) == 0 {
+		return []model.Code{}, nil
+	}
+	var codes []model.Code
+	err := r.db.Where("job_id IN ?", jobIDs).Order("job_id ASC").Order("timestamp DESC").Find(&codes).Error
+	return codes, err
+}
@@ -28,3 +28,27 @@ func TestCodeRepository_FindByJobID(t *testing.T) {
 	assert.Equal(t, "/path/to/script.py", *codes[0].ScriptPath)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestCodeRepository_FindByJobIDs(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewCodeRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "job_id", "script_path"}).
+		AddRow(1, "job-001", "/path/a.py").
+		AddRow(2, "job-002", "/path/b.py")
+
+	mock.ExpectQuery("SELECT \\* FROM `codes` WHERE job_id IN \\(\\?,\\?\\) ORDER BY job_id ASC,timestamp DESC").
+		WithArgs("job-001", "job-002").
+		WillReturnRows(rows)
+
+	codes, err := repo.FindByJobIDs([]string{"job-001", "job-002"})
+	assert.NoError(t, err)
+	assert.Len(t, codes, 2)
+
+	empty, err := repo.FindByJobIDs(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
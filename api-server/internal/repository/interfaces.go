@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/task-monitor/api-server/internal/model"
+import (
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+)
 
 // NodeRepositoryInterface defines the interface for node repository operations
 // API Server只需要查询功能，不需要写入功能
@@ -8,6 +12,7 @@ type NodeRepositoryInterface interface {
 	FindByID(nodeID string) (*model.Node, error)
 	FindAll() ([]model.Node, error)
 	FindByStatus(status string) ([]model.Node, error)
+	Search(query string, limit int) ([]model.Node, error)
 }
 
 // JobRepositoryInterface defines the interface for job repository operations
@@ -19,22 +24,69 @@ type JobRepositoryInterface interface {
 	FindByNodeIDAndPPID(nodeID string, ppid int64) ([]model.Job, error)
 	FindByStatus(status string) ([]model.Job, error)
 	FindAll() ([]model.Job, error)
-	Find(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string, limit, offset int) ([]model.Job, error)
-	Count(nodeID string, statuses []string, jobTypes []string, frameworks []string) (int64, error)
-	FindFiltered(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string) ([]model.Job, error)
+	Find(filter JobFilter, sortBy, sortOrder string, limit, offset int) ([]model.Job, error)
+	Count(filter JobFilter) (int64, error)
+	FindFiltered(filter JobFilter, sortBy, sortOrder string) ([]model.Job, error)
+	// FindByCursor 按 (start_time, job_id) 键集分页查询，返回游标之后的至多 limit 行
+	FindByCursor(filter JobFilter, desc bool, cursor *JobCursor, limit int) ([]model.Job, error)
 	UpdateFields(jobID string, fields map[string]interface{}) error
+	// FindByIDs 根据作业ID列表批量查询
+	FindByIDs(jobIDs []string) ([]model.Job, error)
+	// FindByNodeForGrouping 查询节点上的全部作业（重建分组用）；nodeID 为空时匹配 node_id 为空或 NULL 的作业
+	FindByNodeForGrouping(nodeID string) ([]model.Job, error)
+	// FindRelatives 查询节点上与给定进程直接相关的作业（父进程、子进程、同进程组）
+	FindRelatives(nodeID string, pids, ppids, pgids []int64) ([]model.Job, error)
+	// FindChangedSince 查询在 since 之后创建或更新的作业
+	FindChangedSince(since time.Time) ([]model.Job, error)
+	// MaxChangeTime 查询作业表最近一次创建或更新的时间
+	MaxChangeTime() (time.Time, error)
+	// DistinctNodeIDs 查询作业表中出现过的全部节点ID
+	DistinctNodeIDs() ([]string, error)
+}
+
+// JobGroupRepositoryInterface defines the interface for persisted job group operations
+// 分组表由 API Server 后台维护，需要写入
+type JobGroupRepositoryInterface interface {
+	// Find 按筛选条件分页查询可见分组，返回当前页与总数
+	Find(filter JobGroupFilter, sortBy, sortOrder string, limit, offset int) ([]model.JobGroupRecord, int64, error)
+	// FindByCursor 按 (start_time, root_job_id) 键集分页查询可见分组，返回游标之后的分组与总数
+	FindByCursor(filter JobGroupFilter, desc bool, cursor *JobCursor, limit int) ([]model.JobGroupRecord, int64, error)
+	// DistinctCardCounts 查询 projects 范围内全部可见分组的去重卡数
+	DistinctCardCounts(projects ProjectFilter) ([]int, error)
+	// CountByStatusTypeFramework 按根作业状态、类型、框架统计满足筛选条件的可见分组数量
+	CountByStatusTypeFramework(filter JobGroupFilter) ([]JobGroupCountRow, error)
+	// FindMembersByJobIDs 查询作业所属分组
+	FindMembersByJobIDs(jobIDs []string) ([]model.JobGroupMember, error)
+	// FindMembersByGroupIDs 查询分组的全部成员
+	FindMembersByGroupIDs(groupIDs []uint) ([]model.JobGroupMember, error)
+	// FindGroupIDsByNode 查询节点上的全部分组ID
+	FindGroupIDsByNode(nodeID string) ([]uint, error)
+	// FindGroupIDsByStatus 查询根作业处于指定状态的分组ID
+	FindGroupIDsByStatus(status string) ([]uint, error)
+	// ReplaceGroups 用重新计算的分组替换旧分组
+	ReplaceGroups(oldGroupIDs []uint, groups []JobGroupWithMembers) error
+	// DeleteGroupsExceptNodes 删除不属于给定节点的分组
+	DeleteGroupsExceptNodes(nodeIDs []string) error
+	// GetSyncState 读取同步进度，尚未同步过时返回 nil
+	GetSyncState() (*model.JobGroupSyncState, error)
+	// SaveSyncState 保存同步进度
+	SaveSyncState(state *model.JobGroupSyncState) error
 }
 
 // ParameterRepositoryInterface defines the interface for parameter repository operations
 // API Server只需要查询功能，不需要写入功能
 type ParameterRepositoryInterface interface {
 	FindByJobID(jobID string) ([]model.Parameter, error)
+	FindEnvVarsByJobIDs(jobIDs []string) ([]model.Parameter, error)
+	// FindSummariesByJobIDs 批量查询参数，不含参数原文、配置文件内容与环境变量
+	FindSummariesByJobIDs(jobIDs []string) ([]model.Parameter, error)
 }
 
 // CodeRepositoryInterface defines the interface for code repository operations
 // API Server只需要查询功能，不需要写入功能
 type CodeRepositoryInterface interface {
 	FindByJobID(jobID string) ([]model.Code, error)
+	FindByJobIDs(jobIDs []string) ([]model.Code, error)
 }
 
 // MetricsRepositoryInterface defines the interface for metrics repository operations
@@ -59,6 +111,20 @@ type MetricsRepositoryInterface interface {
 	FindNPUMetricsNearTime(nodeID string, npuIDs []int, beforeMs int64) ([]model.NPUMetric, error)
 	// FindNPUMetricsPeakInPeriod 查询指定卡号在时间段内 HBM 峰值对应的指标记录
 	FindNPUMetricsPeakInPeriod(nodeID string, npuIDs []int, startMs, endMs int64) ([]model.NPUMetric, error)
+	// FindLatestNPUMetricsSince 查询全集群每张芯片在 since 之后的最新 NPU 指标
+	FindLatestNPUMetricsSince(since time.Time) ([]model.NPUMetric, error)
+	// FindRunningNPUProcesses 查询全集群运行中的 NPU 进程，可按项目范围过滤
+	FindRunningNPUProcesses(projects ProjectFilter) ([]RunningNPUProcess, error)
+	// FindLatestNodeNPUMetricsSince 查询单个节点每张芯片在 since 之后的最新 NPU 指标
+	FindLatestNodeNPUMetricsSince(nodeID string, since time.Time) ([]model.NPUMetric, error)
+	// FindRunningNPUProcessesByNode 查询单个节点上运行中的 NPU 进程
+	FindRunningNPUProcessesByNode(nodeID string) ([]model.NPUProcess, error)
+	// AggregateNPUMetrics 按时间桶聚合 NPU 指标，可只统计项目范围内作业占用过的卡
+	AggregateNPUMetrics(from, to time.Time, bucketSeconds int64, projects ProjectFilter) ([]NPUMetricBucket, error)
+	// FindNPUProcessSpans 查询时间段内运行过、且在项目范围内的作业占用的 NPU 卡
+	FindNPUProcessSpans(fromMs, toMs int64, projects ProjectFilter) ([]NPUProcessSpan, error)
+	// FindNPUMetricWindowStats 统计指定卡号的各芯片在 since 之后的指标均值
+	FindNPUMetricWindowStats(nodeID string, npuIDs []int, since time.Time) ([]NPUChipWindowStats, error)
 }
 
 // JobAnalysisRepositoryInterface defines the interface for job analysis repository operations
@@ -67,6 +133,11 @@ type JobAnalysisRepositoryInterface interface {
 	FindByJobIDs(jobIDs []string) ([]model.JobAnalysis, error)
 	Upsert(analysis *model.JobAnalysis) error
 	UpdateStatus(jobID, status, result string) error
+	Search(query string, limit int, projects ProjectFilter) ([]model.JobAnalysis, error)
+	UpdateFields(jobID string, fields model.JobAnalysisFields) error
+	FindUnextracted(limit int) ([]model.JobAnalysis, error)
+	FindJobIDsByFields(jobIDs []string, filter AnalysisFilter) ([]string, error)
+	FindFieldsByJobIDs(jobIDs []string) ([]model.JobAnalysis, error)
 }
 
 // UserRepositoryInterface defines the interface for user repository operations
@@ -79,3 +150,57 @@ type UserRepositoryInterface interface {
 	Delete(id uint) error
 	Count() (int64, error)
 }
+
+// SavedViewRepositoryInterface defines the interface for saved view repository operations
+type SavedViewRepositoryInterface interface {
+	FindByID(id uint) (*model.SavedView, error)
+	FindByOwnerAndName(ownerID uint, name string) (*model.SavedView, error)
+	FindVisible(userID uint) ([]model.SavedView, error)
+	Create(view *model.SavedView) error
+	Update(view *model.SavedView) error
+	Delete(id uint) error
+}
+
+// ReportRepositoryInterface defines the interface for report schedule and run operations
+type ReportRepositoryInterface interface {
+	ListSchedules() ([]model.ReportSchedule, error)
+	FindScheduleByID(id uint) (*model.ReportSchedule, error)
+	FindScheduleByName(name string) (*model.ReportSchedule, error)
+	CreateSchedule(schedule *model.ReportSchedule) error
+	UpdateSchedule(schedule *model.ReportSchedule) error
+	DeleteSchedule(id uint) error
+	CreateRun(run *model.ReportRun) error
+	UpdateRun(run *model.ReportRun) error
+	FindRunByID(id uint) (*model.ReportRun, error)
+	ListRuns(scheduleName string, limit int) ([]model.ReportRun, error)
+	LatestRunStarts() (map[string]time.Time, error)
+}
+
+// ProjectRepositoryInterface defines the interface for project, membership and job assignment operations
+type ProjectRepositoryInterface interface {
+	FindAll() ([]model.Project, error)
+	FindByID(id uint) (*model.Project, error)
+	FindByName(name string) (*model.Project, error)
+	Create(project *model.Project) error
+	Update(project *model.Project) error
+	Delete(id uint) error
+	FindMembers(projectID uint) ([]model.ProjectMember, error)
+	FindMember(projectID, userID uint) (*model.ProjectMember, error)
+	SaveMember(member *model.ProjectMember) error
+	DeleteMember(projectID, userID uint) error
+	FindProjectIDsByUser(userID uint) ([]uint, error)
+	FindRules(projectID uint) ([]model.ProjectRule, error)
+	FindRuleByID(id uint) (*model.ProjectRule, error)
+	CreateRule(rule *model.ProjectRule) error
+	UpdateRule(rule *model.ProjectRule) error
+	DeleteRule(id uint) error
+	FindJobProjects(jobIDs []string) ([]model.JobProject, error)
+	SaveRuleAssignments(upserts []model.JobProject, clearJobIDs []string) error
+	SetManualAssignment(jobID string, projectID uint) error
+	DeleteAssignment(jobID string) error
+}
+
+// JobStatusHistoryRepositoryInterface defines the interface for job status history queries
+type JobStatusHistoryRepositoryInterface interface {
+	FindRecentByNode(nodeID string, limit int, projects ProjectFilter) ([]NodeStatusChange, error)
+}
@@ -47,3 +47,55 @@ func (r *JobAnalysisRepository) UpdateStatus(jobID, status, result string) error
 	}
 	return r.db.Model(&model.JobAnalysis{}).Where("job_id = ?", jobID).Updates(updates).Error
 }
+
+// Search 在已完成的分析结果 JSON 中按关键词子串匹配，按更新时间倒序；projects 限制作业的归属项目
+func (r *JobAnalysisRepository) Search(query string, limit int, projects ProjectFilter) ([]model.JobAnalysis, error) {
+	var analyses []model.JobAnalysis
+	cond, args := searchCondition(query, []string{"result"})
+	if cond == "" {
+		return analyses, nil
+	}
+	db := projects.apply(r.db.Where("status = ?", "completed").Where(cond, args...), "job_id")
+	err := db.Order("updated_at DESC").Limit(limit).Find(&analyses).Error
+	return analyses, err
+}
+
+// UpdateFields 写入从分析结果中提取的字段并标记为已提取，不改动 updated_at（回填历史记录时保留原分析时间）
+func (r *JobAnalysisRepository) UpdateFields(jobID string, fields model.JobAnalysisFields) error {
+	fields.FieldsExtracted = true
+	return r.db.Model(&model.JobAnalysis{}).Where("job_id = ?", jobID).
+		Select("category", "sub_category", "inference_framework", "model_name", "model_size", "model_precision",
+			"npu_utilization", "hbm_utilization", "max_issue_severity", "fields_extracted").
+		UpdateColumns(&model.JobAnalysis{JobAnalysisFields: fields}).Error
+}
+
+// FindUnextracted 查找尚未提取字段的已完成分析，用于回填
+func (r *JobAnalysisRepository) FindUnextracted(limit int) ([]model.JobAnalysis, error) {
+	var analyses []model.JobAnalysis
+	err := r.db.Where("status = ? AND fields_extracted = ?", "completed", false).
+		Order("id ASC").Limit(limit).Find(&analyses).Error
+	return analyses, err
+}
+
+// FindJobIDsByFields 在给定作业中筛选分析字段满足条件的作业ID
+func (r *JobAnalysisRepository) FindJobIDsByFields(jobIDs []string, filter AnalysisFilter) ([]string, error) {
+	if len(jobIDs) == 0 {
+		return []string{}, nil
+	}
+	var ids []string
+	err := filter.apply(r.db.Model(&model.JobAnalysis{}).Where("job_id IN ?", jobIDs)).Pluck("job_id", &ids).Error
+	return ids, err
+}
+
+// FindFieldsByJobIDs 批量查询已完成分析的提取字段，不加载分析结果原文
+func (r *JobAnalysisRepository) FindFieldsByJobIDs(jobIDs []string) ([]model.JobAnalysis, error) {
+	if len(jobIDs) == 0 {
+		return []model.JobAnalysis{}, nil
+	}
+	var analyses []model.JobAnalysis
+	err := r.db.Select("job_id", "status", "category", "sub_category", "inference_framework", "model_name", "model_size",
+		"model_precision", "npu_utilization", "hbm_utilization", "max_issue_severity", "fields_extracted").
+		Where("job_id IN ? AND status = ?", jobIDs, "completed").
+		Find(&analyses).Error
+	return analyses, err
+}
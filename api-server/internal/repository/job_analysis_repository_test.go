@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+func TestJobAnalysisRepository_UpdateFields(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobAnalysisRepository(db)
+
+	// 空字段也要写入（覆盖重新分析前的旧值）
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `job_analysis` SET `category`=\\?,`sub_category`=\\?,`inference_framework`=\\?,`model_name`=\\?,`model_size`=\\?,"+
+		"`model_precision`=\\?,`npu_utilization`=\\?,`hbm_utilization`=\\?,`max_issue_severity`=\\?,`fields_extracted`=\\? WHERE job_id = \\?").
+		WithArgs("inference", "", "vLLM", "", "", "", "idle", "", "critical", true, "job-001").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.UpdateFields("job-001", model.JobAnalysisFields{
+		Category:           "inference",
+		InferenceFramework: "vLLM",
+		NPUUtilization:     "idle",
+		MaxIssueSeverity:   "critical",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobAnalysisRepository_FindJobIDsByFields(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobAnalysisRepository(db)
+
+	mock.ExpectQuery("SELECT `job_id` FROM `job_analysis` WHERE job_id IN \\(\\?,\\?\\) AND status = \\? AND category IN \\(\\?\\) "+
+		"AND max_issue_severity IN \\(\\?,\\?\\) AND \\(model_name LIKE \\? OR model_name LIKE \\?\\)").
+		WithArgs("job-001", "job-002", "completed", "inference", "critical", "warning", "%qwen%", "%llama%").
+		WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow("job-002"))
+
+	ids, err := repo.FindJobIDsByFields([]string{"job-001", "job-002"}, AnalysisFilter{
+		Categories:      []string{"inference"},
+		IssueSeverities: []string{"critical", "warning"},
+		ModelNames:      []string{"qwen", "llama"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-002"}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobAnalysisRepository_Search_ProjectScope(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobAnalysisRepository(db)
+
+	// 排除归属于不可见项目的作业
+	mock.ExpectQuery("SELECT \\* FROM `job_analysis` WHERE status = \\? AND \\(result LIKE \\?\\) "+
+		"AND job_id NOT IN \\(SELECT `job_id` FROM `job_projects` WHERE project_id NOT IN \\(\\?\\)\\) ORDER BY updated_at DESC LIMIT 10").
+		WithArgs("completed", "%llama%", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"job_id", "status"}).AddRow("job-001", "completed"))
+
+	analyses, err := repo.Search("llama", 10, ProjectFilter{Restricted: true, VisibleIDs: []uint{5}})
+	assert.NoError(t, err)
+	assert.Len(t, analyses, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+)
+
+// jobSearchColumns 关键词搜索匹配的作业字段
+var jobSearchColumns = []string{"job_name", "command_line", "cwd", "process_name"}
+
+// JobFilter 作业筛选条件，各条件之间为 AND，空值表示不限
+type JobFilter struct {
+	NodeIDs    []string `json:"nodeIds,omitempty"`
+	Statuses   []string `json:"statuses,omitempty"`
+	JobTypes   []string `json:"jobTypes,omitempty"`
+	Frameworks []string `json:"frameworks,omitempty"`
+	StartFrom  *int64   `json:"startFrom,omitempty"` // 启动时间下限（毫秒时间戳，含）
+	StartTo    *int64   `json:"startTo,omitempty"`   // 启动时间上限（毫秒时间戳，含）
+	EndFrom    *int64   `json:"endFrom,omitempty"`   // 结束时间下限（毫秒时间戳，含），未结束（end_time 为空）的作业总是保留
+	EndTo      *int64   `json:"endTo,omitempty"`     // 结束时间上限（毫秒时间戳，含），未结束的作业不匹配
+	// Search 关键词，按空白拆分为多个词，每个词须在作业名、命令行、工作目录、进程名之一中以子串出现（不区分大小写取决于列排序规则）
+	Search string `json:"search,omitempty"`
+	// Projects 按作业归属项目筛选；分组列表按分组根作业的归属判断
+	Projects ProjectFilter `json:"projects"`
+}
+
+// apply 将筛选条件追加到查询上，列名不带表前缀
+func (f JobFilter) apply(query *gorm.DB) *gorm.DB {
+	if len(f.NodeIDs) == 1 {
+		query = query.Where("node_id = ?", f.NodeIDs[0])
+	} else if len(f.NodeIDs) > 1 {
+		query = query.Where("node_id IN ?", f.NodeIDs)
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+	if len(f.JobTypes) > 0 {
+		query = query.Where("job_type IN ?", f.JobTypes)
+	}
+	if len(f.Frameworks) > 0 {
+		query = query.Where("framework IN ?", f.Frameworks)
+	}
+	query = f.applyTimeRange(query)
+	if cond, args := searchCondition(f.Search, jobSearchColumns); cond != "" {
+		query = query.Where(cond, args...)
+	}
+	return f.Projects.apply(query, "job_id")
+}
+
+// applyTimeRange 追加启动时间范围与结束时间范围条件
+func (f JobFilter) applyTimeRange(query *gorm.DB) *gorm.DB {
+	if f.StartFrom != nil {
+		query = query.Where("start_time >= ?", *f.StartFrom)
+	}
+	if f.StartTo != nil {
+		query = query.Where("start_time <= ?", *f.StartTo)
+	}
+	if f.EndFrom != nil {
+		query = query.Where("end_time IS NULL OR end_time >= ?", *f.EndFrom)
+	}
+	if f.EndTo != nil {
+		query = query.Where("end_time <= ?", *f.EndTo)
+	}
+	return query
+}
+
+// ProjectFilter 按作业归属项目筛选，归属记录在 job_projects 表中，没有记录的作业视为未归属
+type ProjectFilter struct {
+	ProjectIDs []uint `json:"projectIds,omitempty"` // 只保留归属这些项目的作业，0 表示未归属
+	// Restricted 为 true 时按用户可见范围限制：只保留未归属或归属 VisibleIDs 中项目的作业
+	Restricted bool   `json:"restricted,omitempty"`
+	VisibleIDs []uint `json:"visibleIds,omitempty"`
+}
+
+// IsEmpty 未设置项目条件
+func (f ProjectFilter) IsEmpty() bool {
+	return len(f.ProjectIDs) == 0 && !f.Restricted
+}
+
+// Matches 判断归属项目为 projectID（0 表示未归属）的作业是否满足条件，供内存筛选使用
+func (f ProjectFilter) Matches(projectID uint) bool {
+	if len(f.ProjectIDs) > 0 && !slices.Contains(f.ProjectIDs, projectID) {
+		return false
+	}
+	return !f.Restricted || projectID == 0 || slices.Contains(f.VisibleIDs, projectID)
+}
+
+// apply 将项目条件追加到查询上，idCol 为作业ID列（jobs.job_id 或 job_groups.root_job_id）
+func (f ProjectFilter) apply(query *gorm.DB, idCol string) *gorm.DB {
+	assigned := func() *gorm.DB {
+		return query.Session(&gorm.Session{NewDB: true}).Model(&model.JobProject{}).Select("job_id")
+	}
+	if len(f.ProjectIDs) > 0 {
+		var known []uint
+		includeUnassigned := false
+		for _, id := range f.ProjectIDs {
+			if id == 0 {
+				includeUnassigned = true
+			} else {
+				known = append(known, id)
+			}
+		}
+		switch {
+		case includeUnassigned && len(known) > 0:
+			query = query.Where("("+idCol+" IN (?) OR "+idCol+" NOT IN (?))",
+				assigned().Where("project_id IN ?", known), assigned())
+		case includeUnassigned:
+			query = query.Where(idCol+" NOT IN (?)", assigned())
+		default:
+			query = query.Where(idCol+" IN (?)", assigned().Where("project_id IN ?", known))
+		}
+	}
+	if f.Restricted {
+		// 排除归属于不可见项目的作业
+		hidden := assigned()
+		if len(f.VisibleIDs) > 0 {
+			hidden = hidden.Where("project_id NOT IN ?", f.VisibleIDs)
+		}
+		query = query.Where(idCol+" NOT IN (?)", hidden)
+	}
+	return query
+}
+
+// searchCondition 生成关键词子串匹配条件：词与词之间 AND，同一个词在各列之间 OR。无关键词时返回空串
+func searchCondition(search string, columns []string) (string, []interface{}) {
+	terms := strings.Fields(search)
+	if len(terms) == 0 {
+		return "", nil
+	}
+	var conds []string
+	var args []interface{}
+	for _, term := range terms {
+		pattern := "%" + escapeLike(term) + "%"
+		var ors []string
+		for _, col := range columns {
+			ors = append(ors, col+" LIKE ?")
+			args = append(args, pattern)
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// escapeLike 转义 LIKE 通配符，关键词中的 % 和 _ 按字面匹配（MySQL 默认转义符为反斜杠）
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// AnalysisFilter 按 AI 分析提取字段筛选，仅匹配已完成的分析；各字段多值之间 OR，字段之间 AND。
+// ModelNames 为子串匹配，其余为精确匹配（大小写不敏感取决于列排序规则）
+type AnalysisFilter struct {
+	Categories          []string `json:"categories,omitempty"`
+	SubCategories       []string `json:"subCategories,omitempty"`
+	InferenceFrameworks []string `json:"inferenceFrameworks,omitempty"`
+	ModelNames          []string `json:"modelNames,omitempty"`
+	ModelSizes          []string `json:"modelSizes,omitempty"`
+	Precisions          []string `json:"precisions,omitempty"`
+	NPUUtilizations     []string `json:"npuUtilizations,omitempty"`
+	HBMUtilizations     []string `json:"hbmUtilizations,omitempty"`
+	IssueSeverities     []string `json:"issueSeverities,omitempty"` // 最高问题级别：critical / warning / info / none
+}
+
+// IsEmpty 未设置任何分析字段条件
+func (f AnalysisFilter) IsEmpty() bool {
+	return len(f.Categories) == 0 && len(f.SubCategories) == 0 && len(f.InferenceFrameworks) == 0 &&
+		len(f.ModelNames) == 0 && len(f.ModelSizes) == 0 && len(f.Precisions) == 0 &&
+		len(f.NPUUtilizations) == 0 && len(f.HBMUtilizations) == 0 && len(f.IssueSeverities) == 0
+}
+
+// apply 将分析字段条件追加到 job_analysis 查询上
+func (f AnalysisFilter) apply(query *gorm.DB) *gorm.DB {
+	query = query.Where("status = ?", "completed")
+	exact := []struct {
+		column string
+		values []string
+	}{
+		{"category", f.Categories},
+		{"sub_category", f.SubCategories},
+		{"inference_framework", f.InferenceFrameworks},
+		{"model_size", f.ModelSizes},
+		{"model_precision", f.Precisions},
+		{"npu_utilization", f.NPUUtilizations},
+		{"hbm_utilization", f.HBMUtilizations},
+		{"max_issue_severity", f.IssueSeverities},
+	}
+	for _, cond := range exact {
+		if len(cond.values) > 0 {
+			query = query.Where(cond.column+" IN ?", cond.values)
+		}
+	}
+	if len(f.ModelNames) > 0 {
+		var ors []string
+		var args []interface{}
+		for _, name := range f.ModelNames {
+			ors = append(ors, "model_name LIKE ?")
+			args = append(args, "%"+escapeLike(name)+"%")
+		}
+		query = query.Where(strings.Join(ors, " OR "), args...)
+	}
+	return query
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// writeBatchSize 分组与成员批量写入的批次大小
+	writeBatchSize = 500
+	// lookupBatchSize 按ID列表查询时每批的ID数，避免 IN 列表超过 MySQL 预处理语句 65535 个占位符的限制
+	lookupBatchSize = 1000
+)
+
+// findInBatches 把 ids 按 lookupBatchSize 分批交给 find 查询并合并结果
+func findInBatches[K any, T any](ids []K, find func(batch []K) ([]T, error)) ([]T, error) {
+	result := make([]T, 0, len(ids))
+	for start := 0; start < len(ids); start += lookupBatchSize {
+		rows, err := find(ids[start:min(start+lookupBatchSize, len(ids))])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rows...)
+	}
+	return result, nil
+}
+
+// JobGroupFilter 分组列表筛选条件。节点、状态、类型、框架、启动时间作用于分组根作业的字段，
+// 关键词搜索命中分组内任一成员作业即匹配
+type JobGroupFilter struct {
+	JobFilter
+	CardCounts   []int          `json:"cardCounts,omitempty"`   // 0 表示卡数未知
+	MinCardCount *int           `json:"minCardCount,omitempty"` // 卡数下限（含），卡数未知的分组不匹配
+	MaxCardCount *int           `json:"maxCardCount,omitempty"` // 卡数上限（含），卡数未知的分组不匹配
+	Analysis     AnalysisFilter `json:"analysis"`               // 作用于分组主进程的 AI 分析结果
+}
+
+// JobGroupWithMembers 待写入的分组及其全部成员作业ID
+type JobGroupWithMembers struct {
+	Group  model.JobGroupRecord
+	JobIDs []string
+}
+
+// JobGroupCountRow 按根作业状态、类型、框架聚合的分组数量
+type JobGroupCountRow struct {
+	Status    string `gorm:"column:status"`
+	JobType   string `gorm:"column:job_type"`
+	Framework string `gorm:"column:framework"`
+	Count     int64  `gorm:"column:count"`
+}
+
+// JobGroupRepository 持久化作业分组数据访问层（分组表由 API Server 维护，可读写）
+type JobGroupRepository struct {
+	db *gorm.DB
+}
+
+// NewJobGroupRepository 创建作业分组Repository
+func NewJobGroupRepository(db *gorm.DB) *JobGroupRepository {
+	return &JobGroupRepository{db: db}
+}
+
+// visibleGroups 列表与统计只包含非停止词分组
+func (r *JobGroupRepository) visibleGroups() *gorm.DB {
+	return r.db.Model(&model.JobGroupRecord{}).Where("hidden = ?", false)
+}
+
+// Find 按筛选条件分页查询分组，返回当前页与总数
+func (r *JobGroupRepository) Find(filter JobGroupFilter, sortBy, sortOrder string, limit, offset int) ([]model.JobGroupRecord, int64, error) {
+	query := r.filteredGroups(filter)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	orderClause := "start_time DESC, root_job_id DESC"
+	if col, ok := allowedSortColumns[sortBy]; ok {
+		dir := "ASC"
+		if sortOrder == "desc" {
+			dir = "DESC"
+		}
+		orderClause = col + " " + dir + ", root_job_id DESC"
+	}
+
+	var groups []model.JobGroupRecord
+	err := query.Order(orderClause).Limit(limit).Offset(offset).Find(&groups).Error
+	return groups, total, err
+}
+
+// FindByCursor 按 (start_time, root_job_id) 键集分页查询分组，返回游标之后的至多 limit 个分组与筛选后的总数
+func (r *JobGroupRepository) FindByCursor(filter JobGroupFilter, desc bool, cursor *JobCursor, limit int) ([]model.JobGroupRecord, int64, error) {
+	query := r.filteredGroups(filter)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var groups []model.JobGroupRecord
+	err := whereAfterCursor(query, "root_job_id", cursor, desc).
+		Order(keysetOrder("root_job_id", desc)).
+		Limit(limit).
+		Find(&groups).Error
+	return groups, total, err
+}
+
+// filteredGroups 按筛选条件构建可见分组查询
+func (r *JobGroupRepository) filteredGroups(filter JobGroupFilter) *gorm.DB {
+	rootFilter := filter.JobFilter
+	rootFilter.Search = ""
+	rootFilter.Projects = ProjectFilter{}
+	query := filter.Projects.apply(rootFilter.apply(r.visibleGroups()), "root_job_id")
+	if cond, args := searchCondition(filter.Search, jobSearchColumns); cond != "" {
+		matched := r.db.Model(&model.JobGroupMember{}).
+			Select("job_group_members.group_id").
+			Joins("JOIN jobs ON jobs.job_id = job_group_members.job_id").
+			Where(cond, args...)
+		query = query.Where("id IN (?)", matched)
+	}
+	if !filter.Analysis.IsEmpty() {
+		analyzed := filter.Analysis.apply(r.db.Model(&model.JobAnalysis{}).Select("job_id"))
+		query = query.Where("root_job_id IN (?)", analyzed)
+	}
+	if len(filter.CardCounts) > 0 {
+		var known []int
+		includeUnknown := false
+		for _, c := range filter.CardCounts {
+			if c == 0 {
+				includeUnknown = true
+			} else {
+				known = append(known, c)
+			}
+		}
+		switch {
+		case includeUnknown && len(known) > 0:
+			query = query.Where("card_count IS NULL OR card_count IN ?", known)
+		case includeUnknown:
+			query = query.Where("card_count IS NULL")
+		default:
+			query = query.Where("card_count IN ?", known)
+		}
+	}
+	if filter.MinCardCount != nil {
+		query = query.Where("card_count >= ?", *filter.MinCardCount)
+	}
+	if filter.MaxCardCount != nil {
+		query = query.Where("card_count <= ?", *filter.MaxCardCount)
+	}
+	return query
+}
+
+// DistinctCardCounts 查询 projects 范围内全部可见分组的去重卡数（不含未知）
+func (r *JobGroupRepository) DistinctCardCounts(projects ProjectFilter) ([]int, error) {
+	var counts []int
+	err := projects.apply(r.visibleGroups(), "root_job_id").
+		Where("card_count IS NOT NULL").
+		Distinct().
+		Order("card_count").
+		Pluck("card_count", &counts).Error
+	return counts, err
+}
+
+// CountByStatusTypeFramework 按根作业状态、类型、框架统计满足筛选条件的可见分组数量，空值记为 unknown
+func (r *JobGroupRepository) CountByStatusTypeFramework(filter JobGroupFilter) ([]JobGroupCountRow, error) {
+	var rows []JobGroupCountRow
+	err := r.filteredGroups(filter).
+		Select("COALESCE(NULLIF(status, ''), 'unknown') AS status, " +
+			"COALESCE(NULLIF(job_type, ''), 'unknown') AS job_type, " +
+			"COALESCE(NULLIF(framework, ''), 'unknown') AS framework, COUNT(*) AS count").
+		Group("1, 2, 3").
+		Order("1, 2, 3").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// FindMembersByJobIDs 查询作业所属分组，ID 较多时分批查询
+func (r *JobGroupRepository) FindMembersByJobIDs(jobIDs []string) ([]model.JobGroupMember, error) {
+	return findInBatches(jobIDs, func(batch []string) ([]model.JobGroupMember, error) {
+		var members []model.JobGroupMember
+		err := r.db.Where("job_id IN ?", batch).Find(&members).Error
+		return members, err
+	})
+}
+
+// FindMembersByGroupIDs 查询分组的全部成员，ID 较多时分批查询
+func (r *JobGroupRepository) FindMembersByGroupIDs(groupIDs []uint) ([]model.JobGroupMember, error) {
+	return findInBatches(groupIDs, func(batch []uint) ([]model.JobGroupMember, error) {
+		var members []model.JobGroupMember
+		err := r.db.Where("group_id IN ?", batch).Find(&members).Error
+		return members, err
+	})
+}
+
+// FindGroupIDsByNode 查询节点上的全部分组ID
+func (r *JobGroupRepository) FindGroupIDsByNode(nodeID string) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.JobGroupRecord{}).Where("node_id = ?", nodeID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// FindGroupIDsByStatus 查询根作业处于指定状态的分组ID
+func (r *JobGroupRepository) FindGroupIDsByStatus(status string) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.JobGroupRecord{}).Where("status = ?", status).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ReplaceGroups 用重新计算的分组替换 oldGroupIDs 对应的旧分组。
+// 分组按 root_job_id 覆盖写入，根作业不变的分组保留原ID；旧分组中未再出现的被删除。
+func (r *JobGroupRepository) ReplaceGroups(oldGroupIDs []uint, groups []JobGroupWithMembers) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(oldGroupIDs) > 0 {
+			if err := tx.Where("group_id IN ?", oldGroupIDs).Delete(&model.JobGroupMember{}).Error; err != nil {
+				return err
+			}
+		}
+
+		keep := make([]uint, 0, len(groups))
+		if len(groups) > 0 {
+			records := make([]model.JobGroupRecord, len(groups))
+			rootJobIDs := make([]string, len(groups))
+			for i, g := range groups {
+				records[i] = g.Group
+				records[i].ID = 0
+				rootJobIDs[i] = g.Group.RootJobID
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "root_job_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"node_id", "job_name", "job_type", "framework", "status", "start_time", "end_time",
+					"card_count", "job_count", "hidden", "updated_at",
+				}),
+			}).CreateInBatches(&records, writeBatchSize).Error
+			if err != nil {
+				return err
+			}
+
+			// 覆盖写入时数据库不返回已有行的ID，按 root_job_id 回查
+			var saved []model.JobGroupRecord
+			if err := tx.Select("id", "root_job_id").Where("root_job_id IN ?", rootJobIDs).Find(&saved).Error; err != nil {
+				return err
+			}
+			idByRoot := make(map[string]uint, len(saved))
+			for _, s := range saved {
+				idByRoot[s.RootJobID] = s.ID
+			}
+
+			members := make([]model.JobGroupMember, 0, len(groups))
+			for _, g := range groups {
+				id, ok := idByRoot[g.Group.RootJobID]
+				if !ok {
+					return errors.New("job group not found after upsert: " + g.Group.RootJobID)
+				}
+				keep = append(keep, id)
+				for _, jobID := range g.JobIDs {
+					members = append(members, model.JobGroupMember{JobID: jobID, GroupID: id})
+				}
+			}
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "job_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"group_id"}),
+			}).CreateInBatches(&members, writeBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(oldGroupIDs) > 0 {
+			query := tx.Where("id IN ?", oldGroupIDs)
+			if len(keep) > 0 {
+				query = query.Where("id NOT IN ?", keep)
+			}
+			if err := query.Delete(&model.JobGroupRecord{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteGroupsExceptNodes 删除不属于给定节点的分组及其成员（作业数据已清理的节点）
+func (r *JobGroupRepository) DeleteGroupsExceptNodes(nodeIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&model.JobGroupRecord{}).Select("id")
+		if len(nodeIDs) > 0 {
+			stale = stale.Where("node_id NOT IN ?", nodeIDs)
+		}
+		if err := tx.Where("group_id IN (?)", stale).Delete(&model.JobGroupMember{}).Error; err != nil {
+			return err
+		}
+		// 显式条件：节点列表为空时删除全部分组
+		query := tx.Where("1 = 1")
+		if len(nodeIDs) > 0 {
+			query = query.Where("node_id NOT IN ?", nodeIDs)
+		}
+		return query.Delete(&model.JobGroupRecord{}).Error
+	})
+}
+
+// GetSyncState 读取同步进度，尚未同步过时返回 nil
+func (r *JobGroupRepository) GetSyncState() (*model.JobGroupSyncState, error) {
+	var state model.JobGroupSyncState
+	err := r.db.Where("id = ?", 1).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveSyncState 保存同步进度
+func (r *JobGroupRepository) SaveSyncState(state *model.JobGroupSyncState) error {
+	state.ID = 1
+	return r.db.Save(state).Error
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobGroupRepository_Find_FiltersAndPaginatesInSQL(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobGroupRepository(db)
+	filter := JobGroupFilter{
+		JobFilter:  JobFilter{NodeIDs: []string{"node-001"}, Statuses: []string{"running"}},
+		CardCounts: []int{0, 8},
+	}
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `job_groups` WHERE hidden = \\? AND node_id = \\? AND status IN \\(\\?\\) AND \\(card_count IS NULL OR card_count IN \\(\\?\\)\\)").
+		WithArgs(false, "node-001", "running", 8).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery("SELECT \\* FROM `job_groups` WHERE .* ORDER BY start_time DESC, root_job_id DESC LIMIT 20 OFFSET 20").
+		WithArgs(false, "node-001", "running", 8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "node_id", "root_job_id", "card_count"}).
+			AddRow(3, "node-001", "job-003", 8))
+
+	groups, total, err := repo.Find(filter, "", "", 20, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21), total)
+	assert.Len(t, groups, 1)
+	assert.Equal(t, "job-003", groups[0].RootJobID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobGroupRepository_Find_CardCountAndEndTimeRange(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobGroupRepository(db)
+	minCards, maxCards := 8, 16
+	endFrom, endTo := int64(1770336000000), int64(1770422400000)
+	filter := JobGroupFilter{
+		JobFilter:    JobFilter{EndFrom: &endFrom, EndTo: &endTo},
+		MinCardCount: &minCards,
+		MaxCardCount: &maxCards,
+	}
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `job_groups` WHERE hidden = \\? AND \\(end_time IS NULL OR end_time >= \\?\\) AND end_time <= \\? "+
+		"AND card_count >= \\? AND card_count <= \\?").
+		WithArgs(false, endFrom, endTo, 8, 16).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `job_groups` WHERE .* ORDER BY start_time DESC, root_job_id DESC LIMIT 20").
+		WithArgs(false, endFrom, endTo, 8, 16).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "root_job_id", "card_count"}).AddRow(1, "job-001", 8))
+
+	groups, total, err := repo.Find(filter, "", "", 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, groups, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobGroupRepository_FindByCursor(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobGroupRepository(db)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `job_groups` WHERE hidden = \\?").
+		WithArgs(false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT \\* FROM `job_groups` WHERE hidden = \\? AND \\(start_time IS NULL AND root_job_id < \\?\\) ORDER BY start_time DESC, root_job_id DESC LIMIT 2").
+		WithArgs(false, "job-009").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "root_job_id"}).AddRow(1, "job-001"))
+
+	groups, total, err := repo.FindByCursor(JobGroupFilter{}, true, &JobCursor{ID: "job-009"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, groups, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobGroupRepository_FindByCursor_EndFrom(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobGroupRepository(db)
+	to, from := int64(2000), int64(1000)
+	filter := JobGroupFilter{JobFilter: JobFilter{StartTo: &to, EndFrom: &from}}
+
+	// 未结束或在下限之后结束的分组
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `job_groups` WHERE hidden = \\? AND start_time <= \\? AND \\(end_time IS NULL OR end_time >= \\?\\)").
+		WithArgs(false, to, from).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `job_groups` WHERE hidden = \\? AND start_time <= \\? AND \\(end_time IS NULL OR end_time >= \\?\\) ORDER BY start_time DESC, root_job_id DESC LIMIT 2").
+		WithArgs(false, to, from).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "root_job_id"}).AddRow(1, "job-001"))
+
+	groups, total, err := repo.FindByCursor(filter, true, nil, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, groups, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobGroupRepository_Find_SearchMatchesMemberJobs(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobGroupRepository(db)
+	from := int64(1000)
+	filter := JobGroupFilter{JobFilter: JobFilter{StartFrom: &from, Search: "llama"}}
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `job_groups` WHERE hidden = \\? AND start_time >= \\? AND id IN "+
+		"\\(SELECT job_group_members.group_id FROM `job_group_members` JOIN jobs ON jobs.job_id = job_group_members.job_id "+
+		"WHERE \\(job_name LIKE \\? OR command_line LIKE \\? OR cwd LIKE \\? OR process_name LIKE \\?\\)\\)").
+		WithArgs(false, from, "%llama%", "%llama%", "%llama%", "%llama%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `job_groups` WHERE .* ORDER BY start_time DESC, root_job_id DESC LIMIT 20").
+		WithArgs(false, from, "%llama%", "%llama%", "%llama%", "%llama%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "root_job_id"}).AddRow(4, "job-004"))
+
+	groups, total, err := repo.Find(filter, "", "", 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, groups, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobGroupRepository_Find_AnalysisFilter(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobGroupRepository(db)
+	filter := JobGroupFilter{Analysis: AnalysisFilter{NPUUtilizations: []string{"idle"}}}
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `job_groups` WHERE hidden = \\? AND root_job_id IN "+
+		"\\(SELECT `job_id` FROM `job_analysis` WHERE status = \\? AND npu_utilization IN \\(\\?\\)\\)").
+		WithArgs(false, "completed", "idle").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `job_groups` WHERE .* ORDER BY start_time DESC, root_job_id DESC LIMIT 20").
+		WithArgs(false, "completed", "idle").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "root_job_id"}).AddRow(5, "job-005"))
+
+	groups, total, err := repo.Find(filter, "", "", 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, groups, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"github.com/task-monitor/api-server/internal/model"
 	"gorm.io/gorm"
 )
@@ -72,22 +75,9 @@ var allowedSortColumns = map[string]string{
 }
 
 // Find 灵活查询作业，支持多条件筛选、排序和分页
-func (r *JobRepository) Find(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string, limit, offset int) ([]model.Job, error) {
+func (r *JobRepository) Find(filter JobFilter, sortBy, sortOrder string, limit, offset int) ([]model.Job, error) {
 	var jobs []model.Job
-	query := r.db
-
-	if nodeID != "" {
-		query = query.Where("node_id = ?", nodeID)
-	}
-	if len(statuses) > 0 {
-		query = query.Where("status IN ?", statuses)
-	}
-	if len(jobTypes) > 0 {
-		query = query.Where("job_type IN ?", jobTypes)
-	}
-	if len(frameworks) > 0 {
-		query = query.Where("framework IN ?", frameworks)
-	}
+	query := filter.apply(r.db)
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -110,49 +100,69 @@ func (r *JobRepository) Find(nodeID string, statuses []string, jobTypes []string
 	return jobs, err
 }
 
+// JobCursor 键集分页游标：上一页最后一行的 (start_time, 作业ID)；分组列表中 ID 为根作业ID
+type JobCursor struct {
+	StartTime *int64 `json:"s"`
+	ID        string `json:"i"`
+}
+
+// whereAfterCursor 追加 (start_time, idCol) 键集条件，返回排在游标之后的行。
+// MySQL 中 NULL 在升序时排最前、降序时排最后，条件与之保持一致。
+func whereAfterCursor(query *gorm.DB, idCol string, cursor *JobCursor, desc bool) *gorm.DB {
+	if cursor == nil {
+		return query
+	}
+	switch {
+	case desc && cursor.StartTime != nil:
+		return query.Where("start_time < ? OR (start_time = ? AND "+idCol+" < ?) OR start_time IS NULL",
+			*cursor.StartTime, *cursor.StartTime, cursor.ID)
+	case desc:
+		return query.Where("start_time IS NULL AND "+idCol+" < ?", cursor.ID)
+	case cursor.StartTime != nil:
+		return query.Where("start_time > ? OR (start_time = ? AND "+idCol+" > ?)",
+			*cursor.StartTime, *cursor.StartTime, cursor.ID)
+	default:
+		return query.Where("(start_time IS NULL AND "+idCol+" > ?) OR start_time IS NOT NULL", cursor.ID)
+	}
+}
+
+// keysetOrder 键集分页的排序子句，两列同向
+func keysetOrder(idCol string, desc bool) string {
+	if desc {
+		return "start_time DESC, " + idCol + " DESC"
+	}
+	return "start_time ASC, " + idCol + " ASC"
+}
+
+// FindByCursor 按 (start_time, job_id) 键集分页查询作业，返回游标之后的至多 limit 行
+func (r *JobRepository) FindByCursor(filter JobFilter, desc bool, cursor *JobCursor, limit int) ([]model.Job, error) {
+	var jobs []model.Job
+	query := filter.apply(r.db)
+
+	err := whereAfterCursor(query, "job_id", cursor, desc).
+		Order(keysetOrder("job_id", desc)).
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
 // UpdateFields 更新作业的指定字段
 func (r *JobRepository) UpdateFields(jobID string, fields map[string]interface{}) error {
 	return r.db.Model(&model.Job{}).Where("job_id = ?", jobID).Updates(fields).Error
 }
 // Count 统计符合条件的作业数量
-func (r *JobRepository) Count(nodeID string, statuses []string, jobTypes []string, frameworks []string) (int64, error) {
+func (r *JobRepository) Count(filter JobFilter) (int64, error) {
 	var total int64
-	query := r.db.Model(&model.Job{})
-
-	if nodeID != "" {
-		query = query.Where("node_id = ?", nodeID)
-	}
-	if len(statuses) > 0 {
-		query = query.Where("status IN ?", statuses)
-	}
-	if len(jobTypes) > 0 {
-		query = query.Where("job_type IN ?", jobTypes)
-	}
-	if len(frameworks) > 0 {
-		query = query.Where("framework IN ?", frameworks)
-	}
+	query := filter.apply(r.db.Model(&model.Job{}))
 
 	err := query.Count(&total).Error
 	return total, err
 }
 
 // FindFiltered 查出所有符合筛选条件的 jobs（不分页，不分组），用于 service 层在内存中构建进程树
-func (r *JobRepository) FindFiltered(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string) ([]model.Job, error) {
+func (r *JobRepository) FindFiltered(filter JobFilter, sortBy, sortOrder string) ([]model.Job, error) {
 	var jobs []model.Job
-	query := r.db
-
-	if nodeID != "" {
-		query = query.Where("node_id = ?", nodeID)
-	}
-	if len(statuses) > 0 {
-		query = query.Where("status IN ?", statuses)
-	}
-	if len(jobTypes) > 0 {
-		query = query.Where("job_type IN ?", jobTypes)
-	}
-	if len(frameworks) > 0 {
-		query = query.Where("framework IN ?", frameworks)
-	}
+	query := filter.apply(r.db)
 
 	orderClause := "start_time DESC, job_id DESC"
 	if col, ok := allowedSortColumns[sortBy]; ok {
@@ -165,4 +175,93 @@ func (r *JobRepository) FindFiltered(nodeID string, statuses []string, jobTypes
 
 	err := query.Order(orderClause).Find(&jobs).Error
 	return jobs, err
-}
\ No newline at end of file
+}
+// FindByIDs 根据作业ID列表批量查询，ID 较多时分批查询
+func (r *JobRepository) FindByIDs(jobIDs []string) ([]model.Job, error) {
+	return findInBatches(jobIDs, func(batch []string) ([]model.Job, error) {
+		var jobs []model.Job
+		err := r.db.Where("job_id IN ?", batch).Find(&jobs).Error
+		return jobs, err
+	})
+}
+
+// FindByNodeForGrouping 查询节点上的全部作业，用于重建分组；nodeID 为空时匹配 node_id 为空或 NULL 的作业
+func (r *JobRepository) FindByNodeForGrouping(nodeID string) ([]model.Job, error) {
+	var jobs []model.Job
+	err := whereGroupingNode(r.db, nodeID).Order("start_time DESC, job_id DESC").Find(&jobs).Error
+	return jobs, err
+}
+
+// whereGroupingNode 分组维护按节点划分作业，node_id 为空或 NULL 的作业视为同一个节点
+func whereGroupingNode(db *gorm.DB, nodeID string) *gorm.DB {
+	if nodeID == "" {
+		return db.Where("(node_id IS NULL OR node_id = '')")
+	}
+	return db.Where("node_id = ?", nodeID)
+}
+
+// FindRelatives 查询节点上与给定进程直接相关的作业：父进程（pid IN ppids）、子进程（ppid IN pids）、同进程组（pgid IN pgids）
+func (r *JobRepository) FindRelatives(nodeID string, pids, ppids, pgids []int64) ([]model.Job, error) {
+	var conds []string
+	var args []interface{}
+	if len(ppids) > 0 {
+		conds = append(conds, "pid IN ?")
+		args = append(args, ppids)
+	}
+	if len(pids) > 0 {
+		conds = append(conds, "ppid IN ?")
+		args = append(args, pids)
+	}
+	if len(pgids) > 0 {
+		conds = append(conds, "pgid IN ?")
+		args = append(args, pgids)
+	}
+	if len(conds) == 0 {
+		return []model.Job{}, nil
+	}
+
+	var jobs []model.Job
+	err := whereGroupingNode(r.db, nodeID).
+		Where("("+strings.Join(conds, " OR ")+")", args...).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// FindChangedSince 查询在 since 之后创建或更新的作业。两个条件拆成 UNION 的两条查询，
+// 分别使用 created_at 与 updated_at 上的索引，避免 OR 条件退化为全表扫描
+func (r *JobRepository) FindChangedSince(since time.Time) ([]model.Job, error) {
+	var jobs []model.Job
+	created := r.db.Model(&model.Job{}).Where("created_at >= ?", since)
+	updated := r.db.Model(&model.Job{}).Where("updated_at >= ?", since)
+	err := r.db.Raw("? UNION ?", created, updated).Scan(&jobs).Error
+	return jobs, err
+}
+
+// MaxChangeTime 查询作业表最近一次创建或更新的时间，表为空时返回零值
+func (r *JobRepository) MaxChangeTime() (time.Time, error) {
+	var row struct {
+		MaxCreated *time.Time `gorm:"column:max_created"`
+		MaxUpdated *time.Time `gorm:"column:max_updated"`
+	}
+	err := r.db.Model(&model.Job{}).
+		Select("MAX(created_at) AS max_created, MAX(updated_at) AS max_updated").
+		Scan(&row).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+	var max time.Time
+	if row.MaxCreated != nil {
+		max = *row.MaxCreated
+	}
+	if row.MaxUpdated != nil && row.MaxUpdated.After(max) {
+		max = *row.MaxUpdated
+	}
+	return max, nil
+}
+
+// DistinctNodeIDs 查询作业表中出现过的全部节点ID（node_id 为 NULL 的作业归为空字符串）
+func (r *JobRepository) DistinctNodeIDs() ([]string, error) {
+	var nodeIDs []string
+	err := r.db.Model(&model.Job{}).Distinct().Pluck("COALESCE(node_id, '')", &nodeIDs).Error
+	return nodeIDs, err
+}
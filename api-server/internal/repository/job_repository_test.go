@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -86,7 +88,7 @@ func TestJobRepository_Find_WithPagination(t *testing.T) {
 		WithArgs("node-001", "running").
 		WillReturnRows(rows)
 
-	jobs, err := repo.Find("node-001", []string{"running"}, nil, nil, "", "", 10, 20)
+	jobs, err := repo.Find(JobFilter{NodeIDs: []string{"node-001"}, Statuses: []string{"running"}}, "", "", 10, 20)
 	assert.NoError(t, err)
 	assert.Len(t, jobs, 2)
 	assert.Equal(t, "job-001", jobs[0].JobID)
@@ -105,7 +107,7 @@ func TestJobRepository_Count(t *testing.T) {
 		WithArgs("node-001", "running").
 		WillReturnRows(rows)
 
-	total, err := repo.Count("node-001", []string{"running"}, nil, nil)
+	total, err := repo.Count(JobFilter{NodeIDs: []string{"node-001"}, Statuses: []string{"running"}})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(5), total)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -125,10 +127,119 @@ func TestJobRepository_FindFiltered(t *testing.T) {
 		WithArgs("node-001", "running").
 		WillReturnRows(rows)
 
-	jobs, err := repo.FindFiltered("node-001", []string{"running"}, nil, nil, "", "")
+	jobs, err := repo.FindFiltered(JobFilter{NodeIDs: []string{"node-001"}, Statuses: []string{"running"}}, "", "")
 	assert.NoError(t, err)
 	assert.Len(t, jobs, 2)
 	assert.Equal(t, "job-001", jobs[0].JobID)
 	assert.Equal(t, "job-002", jobs[1].JobID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestJobRepository_FindByCursor(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobRepository(db)
+	startTime := int64(1770373780000)
+
+	rows := sqlmock.NewRows([]string{"job_id", "node_id", "start_time"}).
+		AddRow("job-001", "node-001", 1770373770000)
+
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE node_id = \\? AND \\(start_time < \\? OR \\(start_time = \\? AND job_id < \\?\\) OR start_time IS NULL\\) ORDER BY start_time DESC, job_id DESC LIMIT 21").
+		WithArgs("node-001", startTime, startTime, "job-005").
+		WillReturnRows(rows)
+
+	jobs, err := repo.FindByCursor(JobFilter{NodeIDs: []string{"node-001"}}, true, &JobCursor{StartTime: &startTime, ID: "job-005"}, 21)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepository_FindByCursor_AscendingFromNullStartTime(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobRepository(db)
+
+	// 升序时 NULL 排最前：游标停在 NULL 上时，后续包括剩余 NULL 行与全部非 NULL 行
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE \\(start_time IS NULL AND job_id > \\?\\) OR start_time IS NOT NULL ORDER BY start_time ASC, job_id ASC LIMIT 11").
+		WithArgs("job-002").
+		WillReturnRows(sqlmock.NewRows([]string{"job_id"}))
+
+	_, err := repo.FindByCursor(JobFilter{}, false, &JobCursor{ID: "job-002"}, 11)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepository_Count_TimeRangeAndSearch(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobRepository(db)
+	from, to := int64(1000), int64(2000)
+
+	// 多个节点用 IN；关键词按空白拆分，每个词在四个字段间 OR，词与词 AND；% 与 _ 按字面匹配
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `jobs` WHERE node_id IN \\(\\?,\\?\\) AND start_time >= \\? AND start_time <= \\? AND "+
+		"\\(\\(job_name LIKE \\? OR command_line LIKE \\? OR cwd LIKE \\? OR process_name LIKE \\?\\) AND "+
+		"\\(job_name LIKE \\? OR command_line LIKE \\? OR cwd LIKE \\? OR process_name LIKE \\?\\)\\)").
+		WithArgs("node-001", "node-002", from, to,
+			"%train%", "%train%", "%train%", "%train%",
+			`%100\%%`, `%100\%%`, `%100\%%`, `%100\%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	total, err := repo.Count(JobFilter{
+		NodeIDs:   []string{"node-001", "node-002"},
+		StartFrom: &from,
+		StartTo:   &to,
+		Search:    " train  100% ",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepository_FindByIDs_Batches(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobRepository(db)
+	ids := make([]string, lookupBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("job-%04d", i)
+	}
+
+	// 超过批次大小的ID列表拆成多条 IN 查询
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE job_id IN \\(").
+		WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow("job-0000"))
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE job_id IN \\(\\?\\)").
+		WithArgs(ids[lookupBatchSize]).
+		WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow(ids[lookupBatchSize]))
+
+	jobs, err := repo.FindByIDs(ids)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	jobs, err = repo.FindByIDs(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, jobs)
+	assert.Empty(t, jobs)
+}
+
+func TestJobRepository_FindChangedSince(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobRepository(db)
+	since := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	// 创建时间与更新时间各走一条可用索引的查询，UNION 去重
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE created_at >= \\? UNION SELECT \\* FROM `jobs` WHERE updated_at >= \\?").
+		WithArgs(since, since).
+		WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow("job-001").AddRow("job-002"))
+
+	jobs, err := repo.FindChangedSince(since)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
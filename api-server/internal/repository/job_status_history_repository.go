@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobStatusHistoryRepository 作业状态变更历史数据访问层
+type JobStatusHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewJobStatusHistoryRepository(db *gorm.DB) *JobStatusHistoryRepository {
+	return &JobStatusHistoryRepository{db: db}
+}
+
+// NodeStatusChange 节点上作业的一次状态变更，附带作业名称
+type NodeStatusChange struct {
+	ID        uint      `gorm:"column:id" json:"id"`
+	JobID     string    `gorm:"column:job_id" json:"jobId"`
+	JobName   *string   `gorm:"column:job_name" json:"jobName"`
+	OldStatus *string   `gorm:"column:old_status" json:"oldStatus"`
+	NewStatus *string   `gorm:"column:new_status" json:"newStatus"`
+	Reason    *string   `gorm:"column:reason" json:"reason"`
+	ChangedAt time.Time `gorm:"column:changed_at" json:"changedAt"`
+}
+
+// FindRecentByNode 查询节点上 projects 范围内作业最近的状态变更，按变更时间从新到旧
+func (r *JobStatusHistoryRepository) FindRecentByNode(nodeID string, limit int, projects ProjectFilter) ([]NodeStatusChange, error) {
+	var rows []NodeStatusChange
+	query := r.db.Table("job_status_histories h").
+		Select("h.id, h.job_id, j.job_name, h.old_status, h.new_status, h.reason, h.changed_at").
+		Joins("INNER JOIN jobs j ON j.job_id = h.job_id").
+		Where("j.node_id = ?", nodeID)
+	err := projects.apply(query, "h.job_id").
+		Order("h.changed_at DESC, h.id DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
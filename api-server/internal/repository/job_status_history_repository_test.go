@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobStatusHistoryRepository_FindRecentByNode(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobStatusHistoryRepository(db)
+	changedAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "job_id", "job_name", "old_status", "new_status", "reason", "changed_at"}).
+		AddRow(7, "job-1", "train", "running", "completed", "job_monitor", changedAt)
+
+	mock.ExpectQuery("SELECT h.id, h.job_id, j.job_name, h.old_status, h.new_status, h.reason, h.changed_at\\s+" +
+		"FROM job_status_histories h\\s+INNER JOIN jobs j ON j.job_id = h.job_id\\s+WHERE j.node_id = \\?\\s+" +
+		"ORDER BY h.changed_at DESC, h.id DESC LIMIT 20").
+		WithArgs("node-001").
+		WillReturnRows(rows)
+
+	changes, err := repo.FindRecentByNode("node-001", 20, ProjectFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, uint(7), changes[0].ID)
+		assert.Equal(t, "job-1", changes[0].JobID)
+		assert.Equal(t, "train", *changes[0].JobName)
+		assert.Equal(t, "completed", *changes[0].NewStatus)
+		assert.Equal(t, changedAt, changes[0].ChangedAt)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobStatusHistoryRepository_FindRecentByNode_ProjectScope(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobStatusHistoryRepository(db)
+
+	// 排除归属于不可见项目的作业
+	mock.ExpectQuery("WHERE j.node_id = \\? AND h.job_id NOT IN \\(SELECT `job_id` FROM `job_projects` WHERE project_id NOT IN \\(\\?\\)\\) "+
+		"ORDER BY h.changed_at DESC, h.id DESC LIMIT 20").
+		WithArgs("node-001", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id"}))
+
+	changes, err := repo.FindRecentByNode("node-001", 20, ProjectFilter{Restricted: true, VisibleIDs: []uint{5}})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -181,3 +181,160 @@ func (r *MetricsRepository) FindLatestNPUMetrics(nodeID string, npuIDs []int) ([
 	`, nodeID, npuIDs, nodeID).Scan(&metrics).Error
 	return metrics, err
 }
+
+// FindLatestNPUMetricsSince 查询全集群每张芯片（node_id + npu_id + bus_id）的最新 NPU 指标，
+// 仅考虑 since 之后上报的记录，已下线节点的陈旧数据不会返回
+func (r *MetricsRepository) FindLatestNPUMetricsSince(since time.Time) ([]model.NPUMetric, error) {
+	var metrics []model.NPUMetric
+	err := r.db.Raw(`
+		SELECT m.* FROM npu_metrics m
+		INNER JOIN (
+			SELECT node_id, npu_id, bus_id, MAX(timestamp) AS max_ts
+			FROM npu_metrics
+			WHERE timestamp >= ?
+			GROUP BY node_id, npu_id, bus_id
+		) latest ON m.node_id = latest.node_id AND m.npu_id = latest.npu_id
+			AND m.bus_id <=> latest.bus_id AND m.timestamp = latest.max_ts
+		ORDER BY m.node_id, m.npu_id, m.bus_id
+	`, since).Scan(&metrics).Error
+	return metrics, err
+}
+
+// FindLatestNodeNPUMetricsSince 查询单个节点每张芯片（npu_id + bus_id）在 since 之后上报的最新 NPU 指标
+func (r *MetricsRepository) FindLatestNodeNPUMetricsSince(nodeID string, since time.Time) ([]model.NPUMetric, error) {
+	var metrics []model.NPUMetric
+	err := r.db.Raw(`
+		SELECT m.* FROM npu_metrics m
+		INNER JOIN (
+			SELECT npu_id, bus_id, MAX(timestamp) AS max_ts
+			FROM npu_metrics
+			WHERE node_id = ? AND timestamp >= ?
+			GROUP BY npu_id, bus_id
+		) latest ON m.npu_id = latest.npu_id AND m.bus_id <=> latest.bus_id AND m.timestamp = latest.max_ts
+		WHERE m.node_id = ?
+		ORDER BY m.npu_id, m.bus_id
+	`, nodeID, since, nodeID).Scan(&metrics).Error
+	return metrics, err
+}
+
+// FindRunningNPUProcessesByNode 查询单个节点上运行中的 NPU 进程，包含进程名与显存占用
+func (r *MetricsRepository) FindRunningNPUProcessesByNode(nodeID string) ([]model.NPUProcess, error) {
+	var processes []model.NPUProcess
+	err := r.db.Where("node_id = ? AND status = ? AND npu_id IS NOT NULL", nodeID, "running").
+		Order("npu_id ASC").Order("pid ASC").
+		Find(&processes).Error
+	return processes, err
+}
+
+// RunningNPUProcess 运行中的 NPU 进程占用的卡与芯片，PGID 取自对应的运行中作业（无对应作业时为空）
+type RunningNPUProcess struct {
+	NodeID string `gorm:"column:node_id"`
+	NPUID  int    `gorm:"column:npu_id"`
+	ChipID *int   `gorm:"column:chip_id"`
+	PID    int64  `gorm:"column:pid"`
+	PGID   *int64 `gorm:"column:pgid"`
+}
+
+// FindRunningNPUProcesses 查询全集群运行中的 NPU 进程；projects 非空时只保留 projects 范围内作业的进程（不含无对应作业的进程）
+func (r *MetricsRepository) FindRunningNPUProcesses(projects ProjectFilter) ([]RunningNPUProcess, error) {
+	var rows []RunningNPUProcess
+	query := r.db.Table("npu_processes np").
+		Select("DISTINCT np.node_id, np.npu_id, np.chip_id, np.pid, j.pgid").
+		Joins("LEFT JOIN jobs j ON j.node_id = np.node_id AND j.pid = np.pid AND j.status = 'running'").
+		Where("np.status = 'running' AND np.node_id IS NOT NULL AND np.npu_id IS NOT NULL")
+	err := projects.apply(query, "j.job_id").Scan(&rows).Error
+	return rows, err
+}
+
+// NPUMetricBucket 一个时间桶内的 NPU 指标聚合，Bucket 为相对起始时间的桶序号
+type NPUMetricBucket struct {
+	Bucket        int64    `gorm:"column:bucket"`
+	AvgAICore     *float64 `gorm:"column:avg_aicore"`
+	AvgHBMPercent *float64 `gorm:"column:avg_hbm_percent"`
+	AvgPowerW     *float64 `gorm:"column:avg_power_w"`
+	Chips         int64    `gorm:"column:chips"`
+	Nodes         int64    `gorm:"column:nodes"`
+}
+
+// AggregateNPUMetrics 按 bucketSeconds 将 [from, to) 内的 NPU 指标分桶聚合，桶序号从 from 开始计算。
+// projects 非空时只统计 [from, to) 内被 projects 范围内作业占用过的卡
+func (r *MetricsRepository) AggregateNPUMetrics(from, to time.Time, bucketSeconds int64, projects ProjectFilter) ([]NPUMetricBucket, error) {
+	var rows []NPUMetricBucket
+	query := r.db.Table("npu_metrics").
+		Select(`FLOOR((UNIX_TIMESTAMP(timestamp) - ?) / ?) AS bucket,
+			AVG(aicore_usage_percent) AS avg_aicore,
+			AVG(hbm_usage_mb * 100 / NULLIF(hbm_total_mb, 0)) AS avg_hbm_percent,
+			AVG(power_w) AS avg_power_w,
+			COUNT(DISTINCT node_id, npu_id, bus_id) AS chips,
+			COUNT(DISTINCT node_id) AS nodes`, from.Unix(), bucketSeconds).
+		Where("timestamp >= ? AND timestamp < ?", from, to)
+	if !projects.IsEmpty() {
+		query = query.Where("(node_id, npu_id) IN (?)", r.projectSpans(from.UnixMilli(), to.UnixMilli(), projects).
+			Select("np.node_id, np.npu_id"))
+	}
+	err := query.Group("bucket").Order("bucket").Scan(&rows).Error
+	return rows, err
+}
+
+// projectSpans 在 [fromMs, toMs) 内运行过、且在 projects 范围内的作业与其占用的 NPU 进程
+func (r *MetricsRepository) projectSpans(fromMs, toMs int64, projects ProjectFilter) *gorm.DB {
+	query := r.db.Table("npu_processes np").
+		Joins("INNER JOIN jobs j ON j.node_id = np.node_id AND j.pid = np.pid").
+		Where("np.npu_id IS NOT NULL AND j.start_time < ?", toMs).
+		Where("j.end_time IS NULL OR j.end_time >= ?", fromMs)
+	return projects.apply(query, "j.job_id")
+}
+
+// NPUProcessSpan 占用 NPU 卡的作业进程及其运行区间
+type NPUProcessSpan struct {
+	NodeID    string     `gorm:"column:node_id"`
+	NPUID     int        `gorm:"column:npu_id"`
+	PID       int64      `gorm:"column:pid"`
+	PGID      *int64     `gorm:"column:pgid"`
+	StartTime *int64     `gorm:"column:start_time"`
+	EndTime   *int64     `gorm:"column:end_time"`
+	Status    *string    `gorm:"column:status"`
+	UpdatedAt *time.Time `gorm:"column:updated_at"`
+}
+
+// FindNPUProcessSpans 查询在 [fromMs, toMs) 内运行过、且在 projects 范围内的作业占用的 NPU 卡。
+// npu_processes 没有时间列，运行区间取自按 node_id + pid 关联的作业
+func (r *MetricsRepository) FindNPUProcessSpans(fromMs, toMs int64, projects ProjectFilter) ([]NPUProcessSpan, error) {
+	var rows []NPUProcessSpan
+	err := r.projectSpans(fromMs, toMs, projects).
+		Select("DISTINCT np.node_id, np.npu_id, np.pid, j.pgid, j.start_time, j.end_time, j.status, j.updated_at").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// NPUChipWindowStats 单个芯片在时间窗口内的指标统计
+type NPUChipWindowStats struct {
+	NPUID         int      `gorm:"column:npu_id"`
+	BusID         *string  `gorm:"column:bus_id"`
+	AvgAICore     *float64 `gorm:"column:avg_aicore"`
+	MaxAICore     *float64 `gorm:"column:max_aicore"`
+	AvgHBMUsageMB *float64 `gorm:"column:avg_hbm_usage_mb"`
+	HBMTotalMB    *float64 `gorm:"column:hbm_total_mb"`
+	Samples       int64    `gorm:"column:samples"`
+}
+
+// FindNPUMetricWindowStats 统计指定卡号的各芯片在 since 之后上报指标的平均/最大 AICore 利用率与平均 HBM 占用
+func (r *MetricsRepository) FindNPUMetricWindowStats(nodeID string, npuIDs []int, since time.Time) ([]NPUChipWindowStats, error) {
+	if len(npuIDs) == 0 {
+		return []NPUChipWindowStats{}, nil
+	}
+	var rows []NPUChipWindowStats
+	err := r.db.Raw(`
+		SELECT npu_id, bus_id,
+			AVG(aicore_usage_percent) AS avg_aicore,
+			MAX(aicore_usage_percent) AS max_aicore,
+			AVG(hbm_usage_mb) AS avg_hbm_usage_mb,
+			MAX(hbm_total_mb) AS hbm_total_mb,
+			COUNT(*) AS samples
+		FROM npu_metrics
+		WHERE node_id = ? AND npu_id IN ? AND timestamp >= ?
+		GROUP BY npu_id, bus_id
+		ORDER BY npu_id, bus_id
+	`, nodeID, npuIDs, since).Scan(&rows).Error
+	return rows, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -101,3 +102,180 @@ func TestMetricsRepository_FindNPUProcessesByPID_OnlyRunning(t *testing.T) {
 	assert.Len(t, processes, 2)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestMetricsRepository_FindLatestNPUMetricsSince(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	since := time.Unix(1770373200, 0)
+
+	rows := sqlmock.NewRows([]string{"id", "node_id", "npu_id", "bus_id", "aicore_usage_percent", "timestamp"}).
+		AddRow(1, "node-001", 0, "0000:C1:00.0", 80.0, time.Unix(1770373780, 0)).
+		AddRow(2, "node-002", 0, "0000:C1:00.0", 10.0, time.Unix(1770373790, 0))
+
+	mock.ExpectQuery("GROUP BY node_id, npu_id, bus_id[\\s\\S]*m.bus_id <=> latest.bus_id").
+		WithArgs(since).
+		WillReturnRows(rows)
+
+	metrics, err := repo.FindLatestNPUMetricsSince(since)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, "node-002", *metrics[1].NodeID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsRepository_FindRunningNPUProcesses(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	rows := sqlmock.NewRows([]string{"node_id", "npu_id", "chip_id", "pid", "pgid"}).
+		AddRow("node-001", 0, 1, int64(100), int64(90)).
+		AddRow("node-001", 1, nil, int64(200), nil)
+
+	mock.ExpectQuery("LEFT JOIN jobs j ON j.node_id = np.node_id AND j.pid = np.pid AND j.status = 'running'[\\s\\S]*WHERE np.status = 'running'").
+		WillReturnRows(rows)
+
+	processes, err := repo.FindRunningNPUProcesses(ProjectFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, processes, 2) {
+		assert.Equal(t, 1, *processes[0].ChipID)
+		assert.Equal(t, int64(90), *processes[0].PGID)
+		assert.Nil(t, processes[1].ChipID)
+		assert.Nil(t, processes[1].PGID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsRepository_FindRunningNPUProcesses_ProjectScope(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	mock.ExpectQuery("WHERE \\(np.status = 'running'[\\s\\S]*AND j.job_id IN \\(SELECT `job_id` FROM `job_projects` WHERE project_id IN \\(\\?\\)\\) "+
+		"AND j.job_id NOT IN \\(SELECT `job_id` FROM `job_projects` WHERE project_id NOT IN \\(\\?\\)\\)").
+		WithArgs(uint(5), uint(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"node_id", "npu_id", "chip_id", "pid", "pgid"}).AddRow("node-001", 0, nil, int64(100), int64(90)))
+
+	processes, err := repo.FindRunningNPUProcesses(ProjectFilter{ProjectIDs: []uint{5}, Restricted: true, VisibleIDs: []uint{5}})
+	assert.NoError(t, err)
+	assert.Len(t, processes, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsRepository_AggregateNPUMetrics(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	from := time.Unix(1770336000, 0)
+	to := from.Add(2 * time.Hour)
+	rows := sqlmock.NewRows([]string{"bucket", "avg_aicore", "avg_hbm_percent", "avg_power_w", "chips", "nodes"}).
+		AddRow(0, 55.5, 40.0, 200.0, 16, 2).
+		AddRow(1, nil, nil, nil, 0, 0)
+
+	mock.ExpectQuery("FLOOR\\(\\(UNIX_TIMESTAMP\\(timestamp\\) - \\?\\) / \\?\\) AS bucket[\\s\\S]*GROUP BY `bucket`").
+		WithArgs(from.Unix(), int64(3600), from, to).
+		WillReturnRows(rows)
+
+	buckets, err := repo.AggregateNPUMetrics(from, to, 3600, ProjectFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, buckets, 2) {
+		assert.Equal(t, 55.5, *buckets[0].AvgAICore)
+		assert.Equal(t, int64(16), buckets[0].Chips)
+		assert.Nil(t, buckets[1].AvgAICore)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsRepository_AggregateNPUMetrics_ProjectScope(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	from := time.Unix(1770336000, 0)
+	to := from.Add(2 * time.Hour)
+
+	mock.ExpectQuery("WHERE \\(timestamp >= \\? AND timestamp < \\?\\) AND \\(node_id, npu_id\\) IN \\(SELECT np.node_id, np.npu_id FROM npu_processes np "+
+		"INNER JOIN jobs j ON j.node_id = np.node_id AND j.pid = np.pid[\\s\\S]*j.job_id NOT IN \\(SELECT `job_id` FROM `job_projects`\\)\\) GROUP BY `bucket`").
+		WithArgs(from.Unix(), int64(3600), from, to, to.UnixMilli(), from.UnixMilli()).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "avg_aicore", "avg_hbm_percent", "avg_power_w", "chips", "nodes"}).AddRow(0, 10.0, 5.0, 100.0, 1, 1))
+
+	buckets, err := repo.AggregateNPUMetrics(from, to, 3600, ProjectFilter{Restricted: true})
+	assert.NoError(t, err)
+	assert.Len(t, buckets, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsRepository_FindNPUMetricWindowStats(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	since := time.Unix(1770336000, 0)
+	rows := sqlmock.NewRows([]string{"npu_id", "bus_id", "avg_aicore", "max_aicore", "avg_hbm_usage_mb", "hbm_total_mb", "samples"}).
+		AddRow(0, "0000:C1:00.0", 0.5, 2.0, 30000.0, 32768.0, 60).
+		AddRow(1, nil, nil, nil, nil, nil, 3)
+
+	mock.ExpectQuery("FROM npu_metrics[\\s\\S]*WHERE node_id = \\? AND npu_id IN \\(\\?,\\?\\) AND timestamp >= \\?[\\s\\S]*GROUP BY npu_id, bus_id").
+		WithArgs("node-001", 0, 1, since).
+		WillReturnRows(rows)
+
+	stats, err := repo.FindNPUMetricWindowStats("node-001", []int{0, 1}, since)
+	assert.NoError(t, err)
+	if assert.Len(t, stats, 2) {
+		assert.Equal(t, "0000:C1:00.0", *stats[0].BusID)
+		assert.Equal(t, 0.5, *stats[0].AvgAICore)
+		assert.Equal(t, int64(60), stats[0].Samples)
+		assert.Nil(t, stats[1].AvgAICore)
+	}
+
+	empty, err := repo.FindNPUMetricWindowStats("node-001", nil, since)
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsRepository_FindLatestNodeNPUMetricsSince(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	since := time.Unix(1770373200, 0)
+
+	rows := sqlmock.NewRows([]string{"id", "node_id", "npu_id", "bus_id", "health", "timestamp"}).
+		AddRow(1, "node-001", 0, "0000:C1:00.0", "OK", time.Unix(1770373780, 0)).
+		AddRow(2, "node-001", 1, "0000:C2:00.0", "Warning", time.Unix(1770373790, 0))
+
+	mock.ExpectQuery("WHERE node_id = \\? AND timestamp >= \\?[\\s\\S]*GROUP BY npu_id, bus_id[\\s\\S]*WHERE m.node_id = \\?").
+		WithArgs("node-001", since, "node-001").
+		WillReturnRows(rows)
+
+	metrics, err := repo.FindLatestNodeNPUMetricsSince("node-001", since)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, "Warning", *metrics[1].Health)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetricsRepository_FindRunningNPUProcessesByNode(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewMetricsRepository(db)
+	rows := sqlmock.NewRows([]string{"id", "node_id", "npu_id", "chip_id", "pid", "process_name", "memory_usage_mb", "status"}).
+		AddRow(1, "node-001", 0, 0, int64(100), "python", 2048.0, "running")
+
+	mock.ExpectQuery("SELECT \\* FROM `npu_processes` WHERE node_id = \\? AND status = \\? AND npu_id IS NOT NULL ORDER BY npu_id ASC,pid ASC").
+		WithArgs("node-001", "running").
+		WillReturnRows(rows)
+
+	processes, err := repo.FindRunningNPUProcessesByNode("node-001")
+	assert.NoError(t, err)
+	if assert.Len(t, processes, 1) {
+		assert.Equal(t, "python", *processes[0].ProcessName)
+		assert.Equal(t, 2048.0, *processes[0].MemoryUsageMB)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -39,3 +39,17 @@ func (r *NodeRepository) FindByStatus(status string) ([]model.Node, error) {
 	err := r.db.Where("status = ?", status).Find(&nodes).Error
 	return nodes, err
 }
+
+// nodeSearchColumns 关键词搜索匹配的节点字段
+var nodeSearchColumns = []string{"node_id", "hostname", "ip_address", "npu_model"}
+
+// Search 按关键词在节点ID、主机名、IP、NPU型号中子串匹配，每个词都须命中
+func (r *NodeRepository) Search(query string, limit int) ([]model.Node, error) {
+	var nodes []model.Node
+	cond, args := searchCondition(query, nodeSearchColumns)
+	if cond == "" {
+		return nodes, nil
+	}
+	err := r.db.Where(cond, args...).Order("node_id ASC").Limit(limit).Find(&nodes).Error
+	return nodes, err
+}
@@ -107,3 +107,25 @@ func TestNodeRepository_FindByStatus(t *testing.T) {
 	assert.Equal(t, "online", *nodes[0].Status)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestNodeRepository_Search(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewNodeRepository(db)
+
+	mock.ExpectQuery("SELECT \\* FROM `nodes` WHERE \\(node_id LIKE \\? OR hostname LIKE \\? OR ip_address LIKE \\? OR npu_model LIKE \\?\\) ORDER BY node_id ASC LIMIT 5").
+		WithArgs("%910B%", "%910B%", "%910B%", "%910B%").
+		WillReturnRows(sqlmock.NewRows([]string{"node_id", "npu_model"}).AddRow("node-001", "Ascend910B"))
+
+	nodes, err := repo.Search("910B", 5)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Equal(t, "node-001", nodes[0].NodeID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 空关键词不查询
+	nodes, err = repo.Search("  ", 5)
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+}
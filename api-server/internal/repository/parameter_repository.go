@@ -22,3 +22,30 @@ func (r *ParameterRepository) FindByJobID(jobID string) ([]model.Parameter, erro
 	err := r.db.Where("job_id = ?", jobID).Order("timestamp DESC").Find(&params).Error
 	return params, err
 }
+
+// FindEnvVarsByJobIDs 批量查询作业的环境变量（只取 job_id、env_vars、timestamp，避免加载参数原文与配置文件）
+func (r *ParameterRepository) FindEnvVarsByJobIDs(jobIDs []string) ([]model.Parameter, error) {
+	if len(jobIDs) == 0 {
+		return []model.Parameter{}, nil
+	}
+	var params []model.Parameter
+	err := r.db.Select("job_id", "env_vars", "timestamp").
+		Where("job_id IN ? AND env_vars IS NOT NULL", jobIDs).
+		Order("timestamp DESC").
+		Find(&params).Error
+	return params, err
+}
+
+// FindSummariesByJobIDs 批量查询作业参数（不加载参数原文、配置文件内容与环境变量），同一作业内按时间倒序
+func (r *ParameterRepository) FindSummariesByJobIDs(jobIDs []string) ([]model.Parameter, error) {
+	if len(jobIDs) == 0 {
+		return []model.Parameter{}, nil
+	}
+	var params []model.Parameter
+	err := r.db.Select("id", "job_id", "parameter_data", "parameter_source", "config_file_path", "timestamp").
+		Where("job_id IN ?", jobIDs).
+		Order("job_id ASC").
+		Order("timestamp DESC").
+		Find(&params).Error
+	return params, err
+}
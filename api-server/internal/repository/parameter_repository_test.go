@@ -29,3 +29,52 @@ func TestParameterRepository_FindByJobID(t *testing.T) {
 	assert.Equal(t, "batch_size=32", *params[1].ParameterRaw)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestParameterRepository_FindEnvVarsByJobIDs(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewParameterRepository(db)
+
+	rows := sqlmock.NewRows([]string{"job_id", "env_vars"}).
+		AddRow("job-001", `{"MASTER_ADDR":"10.0.0.1"}`)
+
+	mock.ExpectQuery("SELECT `job_id`,`env_vars`,`timestamp` FROM `parameters` WHERE job_id IN \\(\\?,\\?\\) AND env_vars IS NOT NULL ORDER BY timestamp DESC").
+		WithArgs("job-001", "job-002").
+		WillReturnRows(rows)
+
+	params, err := repo.FindEnvVarsByJobIDs([]string{"job-001", "job-002"})
+	assert.NoError(t, err)
+	assert.Len(t, params, 1)
+	assert.Equal(t, `{"MASTER_ADDR":"10.0.0.1"}`, *params[0].EnvVars)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	empty, err := repo.FindEnvVarsByJobIDs(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestParameterRepository_FindSummariesByJobIDs(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewParameterRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "job_id", "parameter_source", "config_file_path"}).
+		AddRow(1, "job-001", "cmdline", "/etc/train.yaml").
+		AddRow(2, "job-002", "config", nil)
+
+	mock.ExpectQuery("SELECT `id`,`job_id`,`parameter_data`,`parameter_source`,`config_file_path`,`timestamp` FROM `parameters` WHERE job_id IN \\(\\?,\\?\\) ORDER BY job_id ASC,timestamp DESC").
+		WithArgs("job-001", "job-002").
+		WillReturnRows(rows)
+
+	params, err := repo.FindSummariesByJobIDs([]string{"job-001", "job-002"})
+	assert.NoError(t, err)
+	assert.Len(t, params, 2)
+	assert.Nil(t, params[0].EnvVars)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	empty, err := repo.FindSummariesByJobIDs(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProjectRepository 项目、成员、归属规则与作业归属数据访问层
+type ProjectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+func (r *ProjectRepository) FindAll() ([]model.Project, error) {
+	var projects []model.Project
+	err := r.db.Order("name ASC").Find(&projects).Error
+	return projects, err
+}
+
+func (r *ProjectRepository) FindByID(id uint) (*model.Project, error) {
+	var project model.Project
+	if err := r.db.First(&project, id).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) FindByName(name string) (*model.Project, error) {
+	var project model.Project
+	if err := r.db.Where("name = ?", name).First(&project).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// Create 创建项目，创建者同时成为项目 owner
+func (r *ProjectRepository) Create(project *model.Project) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		if project.CreatedBy == 0 {
+			return nil
+		}
+		return tx.Create(&model.ProjectMember{ProjectID: project.ID, UserID: project.CreatedBy, Role: model.ProjectRoleOwner}).Error
+	})
+}
+
+func (r *ProjectRepository) Update(project *model.Project) error {
+	return r.db.Save(project).Error
+}
+
+// Delete 删除项目及其成员、归属规则与作业归属，归属于该项目的作业变为未归属
+func (r *ProjectRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []interface{}{&model.JobProject{}, &model.ProjectRule{}, &model.ProjectMember{}} {
+			if err := tx.Where("project_id = ?", id).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&model.Project{}, id).Error
+	})
+}
+
+func (r *ProjectRepository) FindMembers(projectID uint) ([]model.ProjectMember, error) {
+	var members []model.ProjectMember
+	err := r.db.Where("project_id = ?", projectID).Order("user_id ASC").Find(&members).Error
+	return members, err
+}
+
+// FindMember 查询用户在项目中的成员记录
+func (r *ProjectRepository) FindMember(projectID, userID uint) (*model.ProjectMember, error) {
+	var member model.ProjectMember
+	if err := r.db.Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// SaveMember 添加成员，已是成员时更新角色
+func (r *ProjectRepository) SaveMember(member *model.ProjectMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+func (r *ProjectRepository) DeleteMember(projectID, userID uint) error {
+	return r.db.Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&model.ProjectMember{}).Error
+}
+
+// FindProjectIDsByUser 查询用户所属的全部项目
+func (r *ProjectRepository) FindProjectIDsByUser(userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.ProjectMember{}).Where("user_id = ?", userID).Order("project_id ASC").Pluck("project_id", &ids).Error
+	return ids, err
+}
+
+// FindRules 查询归属规则；projectID 为 0 时返回全部项目的规则，按匹配顺序排列
+func (r *ProjectRepository) FindRules(projectID uint) ([]model.ProjectRule, error) {
+	var rules []model.ProjectRule
+	query := r.db.Order("priority DESC").Order("id ASC")
+	if projectID != 0 {
+		query = query.Where("project_id = ?", projectID)
+	}
+	err := query.Find(&rules).Error
+	return rules, err
+}
+
+func (r *ProjectRepository) FindRuleByID(id uint) (*model.ProjectRule, error) {
+	var rule model.ProjectRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *ProjectRepository) CreateRule(rule *model.ProjectRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *ProjectRepository) UpdateRule(rule *model.ProjectRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *ProjectRepository) DeleteRule(id uint) error {
+	return r.db.Delete(&model.ProjectRule{}, id).Error
+}
+
+// FindJobProjects 批量查询作业的归属记录，未归属的作业没有记录
+func (r *ProjectRepository) FindJobProjects(jobIDs []string) ([]model.JobProject, error) {
+	if len(jobIDs) == 0 {
+		return []model.JobProject{}, nil
+	}
+	var assignments []model.JobProject
+	err := r.db.Where("job_id IN ?", jobIDs).Find(&assignments).Error
+	return assignments, err
+}
+
+// SaveRuleAssignments 写入规则计算的归属：upserts 新增或更新，clearJobIDs 删除；均跳过手动归属的作业
+func (r *ProjectRepository) SaveRuleAssignments(upserts []model.JobProject, clearJobIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(clearJobIDs); start += writeBatchSize {
+			end := start + writeBatchSize
+			if end > len(clearJobIDs) {
+				end = len(clearJobIDs)
+			}
+			if err := tx.Where("job_id IN ? AND source = ?", clearJobIDs[start:end], model.JobProjectSourceRule).
+				Delete(&model.JobProject{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(upserts) == 0 {
+			return nil
+		}
+		// 冲突行仅在原归属也来自规则时更新，手动归属保持不变
+		keepManual := func(col string) clause.Assignment {
+			return clause.Assignment{
+				Column: clause.Column{Name: col},
+				Value:  gorm.Expr("IF(source = ?, "+col+", VALUES("+col+"))", model.JobProjectSourceManual),
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "job_id"}},
+			DoUpdates: clause.Set{keepManual("project_id"), keepManual("rule_id"), keepManual("updated_at"), keepManual("source")},
+		}).CreateInBatches(upserts, writeBatchSize).Error
+	})
+}
+
+// SetManualAssignment 手动指定作业的归属项目，覆盖规则归属
+func (r *ProjectRepository) SetManualAssignment(jobID string, projectID uint) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"project_id", "source", "rule_id", "updated_at"}),
+	}).Create(&model.JobProject{JobID: jobID, ProjectID: projectID, Source: model.JobProjectSourceManual}).Error
+}
+
+// DeleteAssignment 删除作业的归属记录，之后由规则重新计算
+func (r *ProjectRepository) DeleteAssignment(jobID string) error {
+	return r.db.Where("job_id = ?", jobID).Delete(&model.JobProject{}).Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+func TestJobRepository_Count_ProjectFilter(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobRepository(db)
+
+	// 项目 3 或未归属，且排除归属于不可见项目的作业
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `jobs` WHERE \\(\\(job_id IN \\(SELECT `job_id` FROM `job_projects` WHERE project_id IN \\(\\?\\)\\) "+
+		"OR job_id NOT IN \\(SELECT `job_id` FROM `job_projects`\\)\\)\\) "+
+		"AND job_id NOT IN \\(SELECT `job_id` FROM `job_projects` WHERE project_id NOT IN \\(\\?,\\?\\)\\)").
+		WithArgs(3, 3, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	total, err := repo.Count(JobFilter{Projects: ProjectFilter{ProjectIDs: []uint{3, 0}, Restricted: true, VisibleIDs: []uint{3, 5}}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobRepository_Count_ProjectFilter_NoVisibleProjects(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewJobRepository(db)
+
+	// 不属于任何项目时只能看到未归属的作业
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `jobs` WHERE job_id NOT IN \\(SELECT `job_id` FROM `job_projects`\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	total, err := repo.Count(JobFilter{Projects: ProjectFilter{Restricted: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProjectRepository_FindRules(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewProjectRepository(db)
+	mock.ExpectQuery("SELECT \\* FROM `project_rules` ORDER BY priority DESC,id ASC").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "field", "pattern", "priority"}).
+			AddRow(2, 1, "cwd", "^/data/", 10).
+			AddRow(1, 2, "node", "^infer-", 0))
+
+	rules, err := repo.FindRules(0)
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 2) {
+		assert.Equal(t, uint(2), rules[0].ID)
+		assert.Equal(t, 10, rules[0].Priority)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProjectRepository_SaveRuleAssignments(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewProjectRepository(db)
+	ruleID := uint(5)
+
+	// 清除与写入都只作用于规则归属，手动归属保持不变
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `job_projects` WHERE job_id IN \\(\\?\\) AND source = \\?").
+		WithArgs("job-c", model.JobProjectSourceRule).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `job_projects` .* ON DUPLICATE KEY UPDATE " +
+		"`project_id`=IF\\(source = \\?, project_id, VALUES\\(project_id\\)\\),.*" +
+		"`source`=IF\\(source = \\?, source, VALUES\\(source\\)\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.SaveRuleAssignments([]model.JobProject{
+		{JobID: "job-a", ProjectID: 1, Source: model.JobProjectSourceRule, RuleID: &ruleID},
+	}, []string{"job-c"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+)
+
+// ReportRepository 报表计划与运行记录数据访问层
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+func (r *ReportRepository) ListSchedules() ([]model.ReportSchedule, error) {
+	var schedules []model.ReportSchedule
+	err := r.db.Order("name ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *ReportRepository) FindScheduleByID(id uint) (*model.ReportSchedule, error) {
+	var schedule model.ReportSchedule
+	if err := r.db.First(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *ReportRepository) FindScheduleByName(name string) (*model.ReportSchedule, error) {
+	var schedule model.ReportSchedule
+	if err := r.db.Where("name = ?", name).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *ReportRepository) CreateSchedule(schedule *model.ReportSchedule) error {
+	return r.db.Create(schedule).Error
+}
+
+func (r *ReportRepository) UpdateSchedule(schedule *model.ReportSchedule) error {
+	return r.db.Save(schedule).Error
+}
+
+func (r *ReportRepository) DeleteSchedule(id uint) error {
+	return r.db.Delete(&model.ReportSchedule{}, id).Error
+}
+
+func (r *ReportRepository) CreateRun(run *model.ReportRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *ReportRepository) UpdateRun(run *model.ReportRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *ReportRepository) FindRunByID(id uint) (*model.ReportRun, error) {
+	var run model.ReportRun
+	if err := r.db.First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns 按开始时间倒序返回运行记录；scheduleName 为空表示全部计划
+func (r *ReportRepository) ListRuns(scheduleName string, limit int) ([]model.ReportRun, error) {
+	var runs []model.ReportRun
+	query := r.db.Model(&model.ReportRun{})
+	if scheduleName != "" {
+		query = query.Where("schedule_name = ?", scheduleName)
+	}
+	err := query.Order("started_at DESC").Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// LatestRunStarts 返回各计划最近一次按计划触发的开始时间，调度器启动时据此判断下次运行时间
+func (r *ReportRepository) LatestRunStarts() (map[string]time.Time, error) {
+	var rows []struct {
+		ScheduleName string
+		StartedAt    time.Time
+	}
+	err := r.db.Model(&model.ReportRun{}).
+		Select("schedule_name, MAX(started_at) AS started_at").
+		Where("trigger_type = ?", "schedule").
+		Group("schedule_name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		result[row.ScheduleName] = row.StartedAt
+	}
+	return result, nil
+}
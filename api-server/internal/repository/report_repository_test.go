@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportRepository_ListRuns(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewReportRepository(db)
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "schedule_name", "trigger_type", "status", "files", "started_at"}).
+		AddRow(2, "weekly", "manual", "succeeded", `["/data/reports/weekly.md"]`, now)
+
+	mock.ExpectQuery("SELECT \\* FROM `report_runs` WHERE schedule_name = \\? ORDER BY started_at DESC,id DESC LIMIT 20").
+		WithArgs("weekly").
+		WillReturnRows(rows)
+
+	runs, err := repo.ListRuns("weekly", 20)
+	assert.NoError(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, "manual", runs[0].Trigger)
+		assert.Equal(t, []string{"/data/reports/weekly.md"}, runs[0].Files)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReportRepository_LatestRunStarts(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewReportRepository(db)
+	started := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT schedule_name, MAX\\(started_at\\) AS started_at FROM `report_runs` WHERE trigger_type = \\? GROUP BY `schedule_name`").
+		WithArgs("schedule").
+		WillReturnRows(sqlmock.NewRows([]string{"schedule_name", "started_at"}).AddRow("weekly", started))
+
+	starts, err := repo.LatestRunStarts()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"weekly": started}, starts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
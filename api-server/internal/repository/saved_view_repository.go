@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+)
+
+type SavedViewRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedViewRepository(db *gorm.DB) *SavedViewRepository {
+	return &SavedViewRepository{db: db}
+}
+
+func (r *SavedViewRepository) FindByID(id uint) (*model.SavedView, error) {
+	var view model.SavedView
+	if err := r.db.First(&view, id).Error; err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// FindByOwnerAndName 按所有者与名称查找，用于创建/重命名时检查重名
+func (r *SavedViewRepository) FindByOwnerAndName(ownerID uint, name string) (*model.SavedView, error) {
+	var view model.SavedView
+	if err := r.db.Where("owner_id = ? AND name = ?", ownerID, name).First(&view).Error; err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// FindVisible 返回用户可见的视图：自己创建的与他人共享的；userID 为 0 时仅返回共享视图
+func (r *SavedViewRepository) FindVisible(userID uint) ([]model.SavedView, error) {
+	var views []model.SavedView
+	query := r.db.Model(&model.SavedView{})
+	if userID == 0 {
+		query = query.Where("shared = ?", true)
+	} else {
+		query = query.Where("owner_id = ? OR shared = ?", userID, true)
+	}
+	err := query.Order("name ASC").Order("id ASC").Find(&views).Error
+	return views, err
+}
+
+func (r *SavedViewRepository) Create(view *model.SavedView) error {
+	return r.db.Create(view).Error
+}
+
+func (r *SavedViewRepository) Update(view *model.SavedView) error {
+	return r.db.Save(view).Error
+}
+
+func (r *SavedViewRepository) Delete(id uint) error {
+	return r.db.Delete(&model.SavedView{}, id).Error
+}
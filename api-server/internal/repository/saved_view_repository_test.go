@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedViewRepository_FindVisible(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewSavedViewRepository(db)
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "owner_id", "shared", "params", "columns", "created_at", "updated_at"}).
+		AddRow(1, "mine", 3, false, `{"status":["running"]}`, `["jobId","modelName"]`, now, now).
+		AddRow(2, "team", 4, true, `{}`, nil, now, now)
+
+	mock.ExpectQuery("SELECT \\* FROM `saved_views` WHERE owner_id = \\? OR shared = \\? ORDER BY name ASC,id ASC").
+		WithArgs(3, true).
+		WillReturnRows(rows)
+
+	views, err := repo.FindVisible(3)
+	assert.NoError(t, err)
+	if assert.Len(t, views, 2) {
+		assert.Equal(t, []string{"running"}, views[0].Params["status"])
+		assert.Equal(t, []string{"jobId", "modelName"}, views[0].Columns)
+		assert.True(t, views[1].Shared)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSavedViewRepository_FindVisible_Anonymous(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewSavedViewRepository(db)
+	mock.ExpectQuery("SELECT \\* FROM `saved_views` WHERE shared = \\? ORDER BY name ASC,id ASC").
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	views, err := repo.FindVisible(0)
+	assert.NoError(t, err)
+	assert.Empty(t, views)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
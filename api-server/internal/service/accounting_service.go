@@ -0,0 +1,448 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// 卡时汇总维度
+const (
+	AccountingByOwner     = "owner"
+	AccountingByProject   = "project"
+	AccountingByFramework = "framework"
+	AccountingByJobType   = "job_type"
+)
+
+// AccountingDimensions 支持的汇总维度
+var AccountingDimensions = []string{AccountingByOwner, AccountingByProject, AccountingByFramework, AccountingByJobType}
+
+// MaxAccountingPeriod 卡时统计区间上限
+const MaxAccountingPeriod = 366 * 24 * time.Hour
+
+// accountingChunkSize 每批扫描的作业分组数
+const accountingChunkSize = 500
+
+// unknownAttribution 无法确定归属人或项目时的取值
+const unknownAttribution = "unknown"
+
+// 归属人来源
+const (
+	OwnerSourceRule = "rule" // 映射规则
+	OwnerSourceUser = "user" // 进程环境变量 USER/LOGNAME
+	OwnerSourceCWD  = "cwd"  // 工作目录 /home/<用户>
+)
+
+// ErrInvalidAccountingQuery 卡时统计参数不合法
+var ErrInvalidAccountingQuery = errors.New("invalid accounting query")
+
+// homeDirPattern 从工作目录推断用户
+var homeDirPattern = regexp.MustCompile(`^/home/([^/]+)`)
+
+// CardHourRecord 单个作业分组在统计区间内的卡时
+type CardHourRecord struct {
+	JobID       string  `json:"jobId"`
+	JobName     string  `json:"jobName"`
+	NodeID      string  `json:"nodeId"`
+	Framework   string  `json:"framework"`
+	JobType     string  `json:"jobType"`
+	Status      string  `json:"status"`
+	Owner       string  `json:"owner"`
+	OwnerSource string  `json:"ownerSource"` // rule / user / cwd，无法确定时为空
+	Project     string  `json:"project"`
+	CardCount   *int    `json:"cardCount"` // nil 表示卡数未知，不计卡时
+	StartTime   int64   `json:"startTime"`
+	EndTime     *int64  `json:"endTime"`
+	Hours       float64 `json:"hours"` // 区间内运行时长
+	CardHours   float64 `json:"cardHours"`
+}
+
+// CardHourUsage 一个汇总分组的卡时，只填写参与汇总的维度
+type CardHourUsage struct {
+	Owner           string  `json:"owner,omitempty"`
+	Project         string  `json:"project,omitempty"`
+	Framework       string  `json:"framework,omitempty"`
+	JobType         string  `json:"jobType,omitempty"`
+	Jobs            int     `json:"jobs"`
+	UnknownCardJobs int     `json:"unknownCardJobs"`
+	CardHours       float64 `json:"cardHours"`
+}
+
+// CardHourReport 卡时汇总结果，按卡时倒序
+type CardHourReport struct {
+	From            time.Time       `json:"from"`
+	To              time.Time       `json:"to"`
+	GroupBy         []string        `json:"groupBy"`
+	TotalJobs       int             `json:"totalJobs"`
+	UnknownCardJobs int             `json:"unknownCardJobs"`
+	TotalCardHours  float64         `json:"totalCardHours"`
+	Items           []CardHourUsage `json:"items"`
+}
+
+// ownerRule 编译后的归属映射规则
+type ownerRule struct {
+	field   string
+	re      *regexp.Regexp
+	owner   string
+	project string
+}
+
+// AccountingService 卡时核算：按作业分组的运行区间与卡数计算卡时，并归属到用户与项目
+type AccountingService struct {
+	jobService JobServiceInterface
+	paramRepo  repository.ParameterRepositoryInterface
+	now        func() time.Time
+
+	mu    sync.RWMutex
+	rules []ownerRule
+
+	projects ProjectLookup // 可选：按作业归属项目核算
+}
+
+// ProjectLookup 查询作业归属的项目名称
+type ProjectLookup interface {
+	ProjectNamesByJobIDs(jobIDs []string) (map[string]string, error)
+}
+
+// NewAccountingService 创建卡时核算服务
+func NewAccountingService(jobService JobServiceInterface, paramRepo repository.ParameterRepositoryInterface,
+	cfg config.AccountingConfig) *AccountingService {
+	s := &AccountingService{jobService: jobService, paramRepo: paramRepo, now: time.Now}
+	s.SetConfig(cfg)
+	return s
+}
+
+// SetConfig 更新归属映射规则，支持热加载；规则已在配置校验时检查，无法编译的规则被忽略
+func (s *AccountingService) SetConfig(cfg config.AccountingConfig) {
+	rules := make([]ownerRule, 0, len(cfg.OwnerRules))
+	for _, r := range cfg.OwnerRules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, ownerRule{field: r.Field, re: re, owner: r.Owner, project: r.Project})
+	}
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+}
+
+// SetProjectLookup 启用作业归属项目：已归属项目的作业按项目名称核算，未归属的作业仍使用映射规则给出的项目
+func (s *AccountingService) SetProjectLookup(lookup ProjectLookup) {
+	s.projects = lookup
+}
+
+func (s *AccountingService) ownerRules() []ownerRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rules
+}
+
+// GetCardHours 统计 [from, to) 内运行过的作业分组的卡时，按 groupBy 维度汇总；groupBy 为空时按归属人汇总
+func (s *AccountingService) GetCardHours(ctx context.Context, from, to time.Time, groupBy []string, scope ProjectFilter) (*CardHourReport, error) {
+	if len(groupBy) == 0 {
+		groupBy = []string{AccountingByOwner}
+	}
+	for _, dim := range groupBy {
+		if !slices.Contains(AccountingDimensions, dim) {
+			return nil, fmt.Errorf("%w: unsupported groupBy %q", ErrInvalidAccountingQuery, dim)
+		}
+	}
+	records, err := s.ListCardHourRecords(ctx, from, to, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CardHourReport{From: from, To: to, GroupBy: groupBy, Items: []CardHourUsage{}}
+	index := make(map[string]int)
+	for _, r := range records {
+		usage := CardHourUsage{}
+		parts := make([]string, 0, len(groupBy))
+		for _, dim := range groupBy {
+			switch dim {
+			case AccountingByOwner:
+				usage.Owner = r.Owner
+				parts = append(parts, r.Owner)
+			case AccountingByProject:
+				usage.Project = r.Project
+				parts = append(parts, r.Project)
+			case AccountingByFramework:
+				usage.Framework = r.Framework
+				parts = append(parts, r.Framework)
+			case AccountingByJobType:
+				usage.JobType = r.JobType
+				parts = append(parts, r.JobType)
+			}
+		}
+		key := strings.Join(parts, "\x00")
+		i, ok := index[key]
+		if !ok {
+			i = len(report.Items)
+			index[key] = i
+			report.Items = append(report.Items, usage)
+		}
+		item := &report.Items[i]
+		item.Jobs++
+		report.TotalJobs++
+		if r.CardCount == nil {
+			item.UnknownCardJobs++
+			report.UnknownCardJobs++
+		}
+		item.CardHours += r.CardHours
+		report.TotalCardHours += r.CardHours
+	}
+
+	for i := range report.Items {
+		report.Items[i].CardHours = round2(report.Items[i].CardHours)
+	}
+	report.TotalCardHours = round2(report.TotalCardHours)
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		return report.Items[i].CardHours > report.Items[j].CardHours
+	})
+	return report, nil
+}
+
+// ListCardHourRecords 返回 [from, to) 内运行过的每个作业分组的卡时明细，按启动时间倒序。
+// 卡数优先取分组记录，未知时按作业详情的 NPU 卡回退（已结束作业会查询已停止的 npu_processes 与其中的指标快照）；
+// scope 为用户的项目可见范围
+func (s *AccountingService) ListCardHourRecords(ctx context.Context, from, to time.Time, scope ProjectFilter) ([]CardHourRecord, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidAccountingQuery)
+	}
+	if to.Sub(from) > MaxAccountingPeriod {
+		return nil, fmt.Errorf("%w: period must not exceed %d days", ErrInvalidAccountingQuery, int(MaxAccountingPeriod.Hours()/24))
+	}
+
+	now := s.now()
+	rules := s.ownerRules()
+	// 区间开始前已结束的作业不会有卡时，按结束时间下限在查询中排除，避免扫描全部历史分组
+	fromMs, toMs := from.UnixMilli(), to.UnixMilli()-1
+	filter := JobGroupFilter{JobFilter: repository.JobFilter{StartTo: &toMs, EndFrom: &fromMs, Projects: scope}}
+	records := []CardHourRecord{}
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		groups, _, next, err := s.jobService.GetGroupedJobsByCursor(filter, "startTime", "desc", cursor, accountingChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("query job groups: %w", err)
+		}
+		chunk, err := s.buildRecords(groups, from, to, now, rules)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, chunk...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	return records, nil
+}
+
+func (s *AccountingService) buildRecords(groups []JobGroup, from, to, now time.Time, rules []ownerRule) ([]CardHourRecord, error) {
+	active := make([]JobGroup, 0, len(groups))
+	spans := make([]time.Duration, 0, len(groups))
+	var unknown []model.Job
+	for _, g := range groups {
+		span := jobActiveSpan(g.MainJob, from, to, now)
+		if span <= 0 {
+			continue
+		}
+		active = append(active, g)
+		spans = append(spans, span)
+		if g.CardCount == nil {
+			unknown = append(unknown, g.MainJob)
+		}
+	}
+	if len(active) == 0 {
+		return nil, nil
+	}
+
+	cards := map[string][]NPUCardInfo{}
+	if len(unknown) > 0 {
+		var err error
+		if cards, err = s.jobService.GetJobsNPUCards(unknown); err != nil {
+			return nil, fmt.Errorf("query npu cards: %w", err)
+		}
+	}
+	jobIDs := make([]string, 0, len(active))
+	for _, g := range active {
+		jobIDs = append(jobIDs, g.MainJob.JobID)
+	}
+	params, err := s.paramRepo.FindEnvVarsByJobIDs(jobIDs)
+	if err != nil {
+		return nil, fmt.Errorf("find env vars: %w", err)
+	}
+	envByJob := make(map[string]map[string]string)
+	collectLatestEnvVars(params, envByJob)
+	projectByJob := map[string]string{}
+	if s.projects != nil {
+		if projectByJob, err = s.projects.ProjectNamesByJobIDs(jobIDs); err != nil {
+			return nil, fmt.Errorf("find job projects: %w", err)
+		}
+	}
+
+	records := make([]CardHourRecord, 0, len(active))
+	for i, g := range active {
+		job := g.MainJob
+		owner, source, project := resolveOwnership(job, envByJob[job.JobID], rules)
+		if name, ok := projectByJob[job.JobID]; ok {
+			project = name
+		}
+		record := CardHourRecord{
+			JobID:       job.JobID,
+			JobName:     stringOrEmpty(job.JobName),
+			NodeID:      stringOrEmpty(job.NodeID),
+			Framework:   stringOrEmpty(job.Framework),
+			JobType:     stringOrEmpty(job.JobType),
+			Status:      stringOrEmpty(job.Status),
+			Owner:       owner,
+			OwnerSource: source,
+			Project:     project,
+			CardCount:   g.CardCount,
+			EndTime:     job.EndTime,
+			Hours:       round2(spans[i].Hours()),
+		}
+		if job.StartTime != nil {
+			record.StartTime = *job.StartTime
+		}
+		if record.CardCount == nil {
+			if n := len(cards[job.JobID]); n > 0 {
+				record.CardCount = &n
+			}
+		}
+		if record.CardCount != nil {
+			record.CardHours = round2(float64(*record.CardCount) * spans[i].Hours())
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// resolveOwnership 确定作业的归属人与项目：映射规则按顺序匹配，归属人与项目分别取第一条给出该值的规则；
+// 规则未给出归属人时依次取进程环境变量 USER/LOGNAME、工作目录 /home/<用户>（/root 视为 root）
+func resolveOwnership(job model.Job, env map[string]string, rules []ownerRule) (owner, source, project string) {
+	processUser := env["USER"]
+	if processUser == "" {
+		processUser = env["LOGNAME"]
+	}
+	for _, r := range rules {
+		if (owner != "" || r.owner == "") && (project != "" || r.project == "") {
+			continue
+		}
+		var value string
+		switch {
+		case r.field == "user":
+			value = processUser
+		case r.field == "cwd":
+			value = stringOrEmpty(job.CWD)
+		case r.field == "command_line":
+			value = stringOrEmpty(job.CommandLine)
+		case r.field == "job_name":
+			value = stringOrEmpty(job.JobName)
+		case r.field == "node":
+			value = stringOrEmpty(job.NodeID)
+		case strings.HasPrefix(r.field, "env:"):
+			value = env[strings.TrimPrefix(r.field, "env:")]
+		}
+		match := r.re.FindStringSubmatchIndex(value)
+		if value == "" || match == nil {
+			continue
+		}
+		if owner == "" && r.owner != "" {
+			if v := string(r.re.ExpandString(nil, r.owner, value, match)); v != "" {
+				owner, source = v, OwnerSourceRule
+			}
+		}
+		if project == "" && r.project != "" {
+			project = string(r.re.ExpandString(nil, r.project, value, match))
+		}
+	}
+
+	cwd := stringOrEmpty(job.CWD)
+	switch {
+	case owner != "":
+	case processUser != "":
+		owner, source = processUser, OwnerSourceUser
+	case homeDirPattern.MatchString(cwd):
+		owner, source = homeDirPattern.FindStringSubmatch(cwd)[1], OwnerSourceCWD
+	case cwd == "/root" || strings.HasPrefix(cwd, "/root/"):
+		owner, source = "root", OwnerSourceCWD
+	default:
+		owner = unknownAttribution
+	}
+	if project == "" {
+		project = unknownAttribution
+	}
+	return owner, source, project
+}
+
+// RenderCardHoursCSV 输出卡时汇总，列为参与汇总的维度加作业数与卡时，带 BOM 便于 Excel 识别 UTF-8；
+// 维度取值来自进程环境变量与工作目录，写入前做 CSV 注入转义
+func RenderCardHoursCSV(r *CardHourReport) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	header := append(append([]string(nil), r.GroupBy...), "jobs", "unknownCardJobs", "cardHours")
+	w.Write(header)
+	for _, item := range r.Items {
+		row := make([]string, 0, len(header))
+		for _, dim := range r.GroupBy {
+			switch dim {
+			case AccountingByOwner:
+				row = append(row, utils.SanitizeCSVCell(item.Owner))
+			case AccountingByProject:
+				row = append(row, utils.SanitizeCSVCell(item.Project))
+			case AccountingByFramework:
+				row = append(row, utils.SanitizeCSVCell(item.Framework))
+			case AccountingByJobType:
+				row = append(row, utils.SanitizeCSVCell(item.JobType))
+			}
+		}
+		row = append(row, strconv.Itoa(item.Jobs), strconv.Itoa(item.UnknownCardJobs),
+			strconv.FormatFloat(item.CardHours, 'f', 2, 64))
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// RenderCardHourRecordsCSV 输出作业分组卡时明细
+func RenderCardHourRecordsCSV(records []CardHourRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"jobId", "jobName", "nodeId", "framework", "jobType", "status", "owner", "ownerSource",
+		"project", "cardCount", "startTime", "endTime", "hours", "cardHours"})
+	for _, r := range records {
+		cardCount, end := "", ""
+		if r.CardCount != nil {
+			cardCount = strconv.Itoa(*r.CardCount)
+		}
+		if r.EndTime != nil {
+			end = formatMillis(*r.EndTime)
+		}
+		w.Write([]string{utils.SanitizeCSVCell(r.JobID), utils.SanitizeCSVCell(r.JobName), utils.SanitizeCSVCell(r.NodeID),
+			utils.SanitizeCSVCell(r.Framework), utils.SanitizeCSVCell(r.JobType), utils.SanitizeCSVCell(r.Status),
+			utils.SanitizeCSVCell(r.Owner), r.OwnerSource, utils.SanitizeCSVCell(r.Project), cardCount, formatMillis(r.StartTime), end,
+			strconv.FormatFloat(r.Hours, 'f', 2, 64), strconv.FormatFloat(r.CardHours, 'f', 2, 64)})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
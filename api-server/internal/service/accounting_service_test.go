@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+func accountingGroup(jobID, framework, cwd string, start, end time.Time, cards *int) JobGroup {
+	startMs, status := start.UnixMilli(), "running"
+	job := model.Job{JobID: jobID, Framework: &framework, CWD: &cwd, StartTime: &startMs, Status: &status}
+	if !end.IsZero() {
+		endMs := end.UnixMilli()
+		status = "completed"
+		job.EndTime = &endMs
+	}
+	return JobGroup{MainJob: job, CardCount: cards}
+}
+
+func TestResolveOwnership(t *testing.T) {
+	svc := NewAccountingService(nil, nil, config.AccountingConfig{OwnerRules: []config.OwnerRuleConfig{
+		{Field: "cwd", Pattern: `^/data/(?P<team>\w+)/(\w+)/`, Owner: "$2", Project: "${team}"},
+		{Field: "env:PROJECT", Pattern: `.+`, Project: "$0"},
+		{Field: "node", Pattern: `^infer-`, Project: "serving"},
+	}})
+	rules := svc.ownerRules()
+	str := func(s string) *string { return &s }
+
+	owner, source, project := resolveOwnership(model.Job{CWD: str("/data/nlp/alice/exp1")}, map[string]string{"USER": "svc"}, rules)
+	assert.Equal(t, []string{"alice", OwnerSourceRule, "nlp"}, []string{owner, source, project})
+
+	// 规则只给出项目时，归属人取进程用户
+	owner, source, project = resolveOwnership(model.Job{CWD: str("/tmp")}, map[string]string{"LOGNAME": "bob", "PROJECT": "llm"}, rules)
+	assert.Equal(t, []string{"bob", OwnerSourceUser, "llm"}, []string{owner, source, project})
+
+	owner, source, project = resolveOwnership(model.Job{CWD: str("/home/carol/work"), NodeID: str("infer-01")}, nil, rules)
+	assert.Equal(t, []string{"carol", OwnerSourceCWD, "serving"}, []string{owner, source, project})
+
+	owner, source, _ = resolveOwnership(model.Job{CWD: str("/root")}, nil, nil)
+	assert.Equal(t, []string{"root", OwnerSourceCWD}, []string{owner, source})
+
+	owner, source, project = resolveOwnership(model.Job{}, nil, nil)
+	assert.Equal(t, []string{unknownAttribution, "", unknownAttribution}, []string{owner, source, project})
+}
+
+func TestAccountingService_GetCardHours(t *testing.T) {
+	mockJobService := new(MockJobServiceForLLM)
+	mockParamRepo := new(MockParameterRepository)
+	svc := NewAccountingService(mockJobService, mockParamRepo, config.AccountingConfig{})
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	svc.now = func() time.Time { return to.Add(time.Hour) }
+
+	eight, four := 8, 4
+	groups := []JobGroup{
+		// 跨过统计起点，只计区间内的 10 小时
+		accountingGroup("job-a", "pytorch", "/home/alice/a", from.Add(-2*time.Hour), from.Add(10*time.Hour), &eight),
+		accountingGroup("job-b", "pytorch", "/home/bob/b", from.Add(24*time.Hour), from.Add(29*time.Hour), &four),
+		// 卡数未知，按作业详情回退
+		accountingGroup("job-c", "mindspore", "/home/alice/c", from.Add(48*time.Hour), from.Add(50*time.Hour), nil),
+		// 回退后仍未知
+		accountingGroup("job-d", "vllm", "/tmp", from.Add(72*time.Hour), from.Add(73*time.Hour), nil),
+		// 在统计区间之前已结束
+		accountingGroup("job-e", "pytorch", "/home/alice/e", from.Add(-48*time.Hour), from.Add(-24*time.Hour), &eight),
+	}
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		// 区间开始前结束的作业在查询中按结束时间下限排除
+		return *f.StartTo == to.UnixMilli()-1 && *f.EndFrom == from.UnixMilli()
+	}), "startTime", "desc", "", accountingChunkSize).Return(groups, int64(len(groups)), "", nil)
+	mockJobService.On("GetJobsNPUCards", mock.MatchedBy(func(jobs []model.Job) bool {
+		return len(jobs) == 2 && jobs[0].JobID == "job-c" && jobs[1].JobID == "job-d"
+	})).Return(map[string][]NPUCardInfo{"job-c": {{NpuID: 0}, {NpuID: 1}}, "job-d": {}}, nil)
+	mockParamRepo.On("FindEnvVarsByJobIDs", []string{"job-a", "job-b", "job-c", "job-d"}).
+		Return([]model.Parameter{envParam("job-d", `{"USER":"dave"}`)}, nil)
+
+	report, err := svc.GetCardHours(context.Background(), from, to, []string{AccountingByOwner, AccountingByFramework}, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.TotalJobs)
+	assert.Equal(t, 1, report.UnknownCardJobs)
+	assert.Equal(t, 104.0, report.TotalCardHours)
+	require.Len(t, report.Items, 4)
+	assert.Equal(t, CardHourUsage{Owner: "alice", Framework: "pytorch", Jobs: 1, CardHours: 80}, report.Items[0])
+	assert.Equal(t, CardHourUsage{Owner: "bob", Framework: "pytorch", Jobs: 1, CardHours: 20}, report.Items[1])
+	assert.Equal(t, CardHourUsage{Owner: "alice", Framework: "mindspore", Jobs: 1, CardHours: 4}, report.Items[2])
+	assert.Equal(t, CardHourUsage{Owner: "dave", Framework: "vllm", Jobs: 1, UnknownCardJobs: 1}, report.Items[3])
+
+	content, err := RenderCardHoursCSV(report)
+	require.NoError(t, err)
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(content), "\uFEFF"))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"owner", "framework", "jobs", "unknownCardJobs", "cardHours"}, rows[0])
+	assert.Equal(t, []string{"alice", "pytorch", "1", "0", "80.00"}, rows[1])
+}
+
+func TestAccountingService_GetCardHours_Invalid(t *testing.T) {
+	svc := NewAccountingService(new(MockJobServiceForLLM), new(MockParameterRepository), config.AccountingConfig{})
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := svc.GetCardHours(context.Background(), from, from.Add(time.Hour), []string{"node"}, ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidAccountingQuery)
+	_, err = svc.GetCardHours(context.Background(), from, from, nil, ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidAccountingQuery)
+	_, err = svc.GetCardHours(context.Background(), from, from.AddDate(2, 0, 0), nil, ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidAccountingQuery)
+}
+
+type stubProjectLookup map[string]string
+
+func (s stubProjectLookup) ProjectNamesByJobIDs(jobIDs []string) (map[string]string, error) {
+	return s, nil
+}
+
+func TestAccountingService_GetCardHours_ProjectLookup(t *testing.T) {
+	mockJobService := new(MockJobServiceForLLM)
+	mockParamRepo := new(MockParameterRepository)
+	svc := NewAccountingService(mockJobService, mockParamRepo, config.AccountingConfig{OwnerRules: []config.OwnerRuleConfig{
+		{Field: "cwd", Pattern: `^/data/(\w+)/`, Project: "$1"},
+	}})
+	svc.SetProjectLookup(stubProjectLookup{"job-a": "vision"})
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	svc.now = func() time.Time { return to }
+
+	one := 1
+	groups := []JobGroup{
+		accountingGroup("job-a", "pytorch", "/data/nlp/a", from, from.Add(time.Hour), &one),
+		accountingGroup("job-b", "pytorch", "/data/nlp/b", from, from.Add(2*time.Hour), &one),
+	}
+	mockJobService.On("GetGroupedJobsByCursor", mock.Anything, "startTime", "desc", "", accountingChunkSize).
+		Return(groups, int64(len(groups)), "", nil)
+	mockParamRepo.On("FindEnvVarsByJobIDs", []string{"job-a", "job-b"}).Return([]model.Parameter{}, nil)
+
+	// 已归属项目的作业按项目名称核算，未归属的作业仍使用映射规则
+	report, err := svc.GetCardHours(context.Background(), from, to, []string{AccountingByProject}, ProjectFilter{})
+	require.NoError(t, err)
+	require.Len(t, report.Items, 2)
+	assert.Equal(t, CardHourUsage{Project: "nlp", Jobs: 1, CardHours: 2}, report.Items[0])
+	assert.Equal(t, CardHourUsage{Project: "vision", Jobs: 1, CardHours: 1}, report.Items[1])
+}
+
+func TestAccountingService_ListCardHourRecords_ProjectScope(t *testing.T) {
+	mockJobService := new(MockJobServiceForLLM)
+	svc := NewAccountingService(mockJobService, new(MockParameterRepository), config.AccountingConfig{})
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	scope := ProjectFilter{Restricted: true, VisibleIDs: []uint{5}}
+	// 可见范围随分组查询下推，其他项目的作业不参与核算
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return f.Projects.Restricted && len(f.Projects.VisibleIDs) == 1 && f.Projects.VisibleIDs[0] == 5
+	}), "startTime", "desc", "", accountingChunkSize).Return([]JobGroup{}, int64(0), "", nil)
+
+	records, err := svc.ListCardHourRecords(context.Background(), from, from.AddDate(0, 0, 1), scope)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	mockJobService.AssertExpectations(t)
+}
+
+func TestRenderCardHourRecordsCSV_EscapesFormulas(t *testing.T) {
+	four := 4
+	content, err := RenderCardHourRecordsCSV([]CardHourRecord{{
+		JobID: "job-a", JobName: "=HYPERLINK(\"http://evil\")", NodeID: "node-1", Framework: "pytorch",
+		Status: "running", Owner: "@alice", OwnerSource: OwnerSourceUser, Project: "+ops",
+		CardCount: &four, StartTime: 1772323200000, Hours: 1, CardHours: 4,
+	}})
+	require.NoError(t, err)
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(content), "\uFEFF"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "'=HYPERLINK(\"http://evil\")", rows[1][1])
+	assert.Equal(t, "'@alice", rows[1][6])
+	assert.Equal(t, "'+ops", rows[1][8])
+
+	content, err = RenderCardHoursCSV(&CardHourReport{GroupBy: []string{AccountingByOwner}, Items: []CardHourUsage{{Owner: "-cmd", Jobs: 1}}})
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "'-cmd,1,0,0.00")
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+// analysisBackfillBatchSize 回填分析字段时每批处理的记录数
+const analysisBackfillBatchSize = 200
+
+// 分析字段的列宽（字符数），与 model.JobAnalysisFields 的 varchar 长度一致
+const (
+	analysisShortFieldLen = 32
+	analysisLongFieldLen  = 64
+	analysisNameFieldLen  = 255
+)
+
+// issueSeverityRank 问题级别排序，数值越大越严重
+var issueSeverityRank = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"critical": 3,
+}
+
+// extractAnalysisFields 从分析结果中提取用于筛选的字段；LLM 返回的 "null"/"unknown" 占位文本按空值处理，
+// 超过列宽的取值按字符截断
+func extractAnalysisFields(result *JobAnalysisResponse) model.JobAnalysisFields {
+	fields := model.JobAnalysisFields{
+		Category:       clipRunes(analysisValue(&result.TaskType.Category), analysisShortFieldLen),
+		SubCategory:    clipRunes(analysisValue(result.TaskType.SubCategory), analysisLongFieldLen),
+		NPUUtilization: clipRunes(strings.ToLower(analysisValue(&result.ResourceAssessment.NpuUtilization)), analysisShortFieldLen),
+		HBMUtilization: clipRunes(strings.ToLower(analysisValue(&result.ResourceAssessment.HbmUtilization)), analysisShortFieldLen),
+	}
+	fields.InferenceFramework = clipRunes(analysisValue(result.TaskType.InferenceFramework), analysisLongFieldLen)
+	if result.ModelInfo != nil {
+		fields.ModelName = clipRunes(analysisValue(result.ModelInfo.ModelName), analysisNameFieldLen)
+		fields.ModelSize = clipRunes(analysisValue(result.ModelInfo.ModelSize), analysisShortFieldLen)
+		fields.Precision = clipRunes(strings.ToLower(analysisValue(result.ModelInfo.Precision)), analysisShortFieldLen)
+	}
+
+	fields.MaxIssueSeverity = "none"
+	for _, issue := range result.Issues {
+		severity := strings.ToLower(strings.TrimSpace(issue.Severity))
+		if issueSeverityRank[severity] > issueSeverityRank[fields.MaxIssueSeverity] {
+			fields.MaxIssueSeverity = severity
+		}
+	}
+	return fields
+}
+
+// analysisValue 去掉首尾空白，占位文本视为空
+func analysisValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	s := strings.TrimSpace(*v)
+	switch strings.ToLower(s) {
+	case "null", "none", "n/a", "-":
+		return ""
+	}
+	return s
+}
+
+// clipRunes 按字符截断到 maxLen 个字符，不追加省略号
+func clipRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen])
+}
+
+// saveAnalysisFields 提取并写入分析字段，失败只记录日志，不影响分析结果
+func (s *LLMService) saveAnalysisFields(jobID string, result *JobAnalysisResponse) {
+	if err := s.analysisRepo.UpdateFields(jobID, extractAnalysisFields(result)); err != nil {
+		slog.Warn("failed to save analysis fields", "job_id", jobID, "error", err)
+	}
+}
+
+// BackfillAnalysisFields 为字段提取上线前已完成的分析补齐提取字段，返回处理的记录数。
+// 结果无法解析或字段写入失败的记录写入空字段并标记为已提取，避免重复处理
+func (s *LLMService) BackfillAnalysisFields(ctx context.Context) (int, error) {
+	processed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		analyses, err := s.analysisRepo.FindUnextracted(analysisBackfillBatchSize)
+		if err != nil {
+			return processed, fmt.Errorf("find unextracted analyses: %w", err)
+		}
+		if len(analyses) == 0 {
+			return processed, nil
+		}
+		for _, a := range analyses {
+			var fields model.JobAnalysisFields
+			var result JobAnalysisResponse
+			if err := json.Unmarshal([]byte(a.Result), &result); err == nil {
+				fields = extractAnalysisFields(&result)
+			}
+			if err := s.analysisRepo.UpdateFields(a.JobID, fields); err != nil {
+				slog.Warn("failed to backfill analysis fields, skipped", "job_id", a.JobID, "error", err)
+				// 空字段仍写不进去说明数据库不可用，中止本次回填，否则同一批记录会被反复查出
+				if err := s.analysisRepo.UpdateFields(a.JobID, model.JobAnalysisFields{}); err != nil {
+					return processed, fmt.Errorf("mark analysis fields extracted %s: %w", a.JobID, err)
+				}
+			}
+			processed++
+		}
+	}
+}
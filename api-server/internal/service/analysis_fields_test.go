@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+func TestExtractAnalysisFields(t *testing.T) {
+	sub, fw := "serving", " vLLM "
+	name, size, precision := "Qwen2.5-72B", "72B", "BF16"
+	nullText := "null"
+	result := &JobAnalysisResponse{
+		TaskType:  JobAnalysisTaskType{Category: "inference", SubCategory: &sub, InferenceFramework: &fw},
+		ModelInfo: &JobAnalysisModelInfo{ModelName: &name, ModelSize: &size, Precision: &precision, ParallelStrategy: &nullText},
+		ResourceAssessment: JobAnalysisResourceAssessment{
+			NpuUtilization: "Idle",
+			HbmUtilization: "high",
+		},
+		Issues: []JobAnalysisIssue{{Severity: "info"}, {Severity: "Critical"}, {Severity: "warning"}},
+	}
+
+	fields := extractAnalysisFields(result)
+	assert.Equal(t, model.JobAnalysisFields{
+		Category:           "inference",
+		SubCategory:        "serving",
+		InferenceFramework: "vLLM",
+		ModelName:          "Qwen2.5-72B",
+		ModelSize:          "72B",
+		Precision:          "bf16",
+		NPUUtilization:     "idle",
+		HBMUtilization:     "high",
+		MaxIssueSeverity:   "critical",
+	}, fields)
+
+	// 无问题时最高级别为 none；占位文本按空值处理
+	fields = extractAnalysisFields(&JobAnalysisResponse{TaskType: JobAnalysisTaskType{Category: "training", SubCategory: &nullText}})
+	assert.Equal(t, "none", fields.MaxIssueSeverity)
+	assert.Empty(t, fields.SubCategory)
+	assert.Empty(t, fields.ModelName)
+
+	// 超过列宽的取值按字符截断
+	longSize := strings.Repeat("大", 40)
+	longPrecision := strings.Repeat("X", 40)
+	fields = extractAnalysisFields(&JobAnalysisResponse{
+		TaskType:  JobAnalysisTaskType{Category: strings.Repeat("c", 40), SubCategory: &longSize},
+		ModelInfo: &JobAnalysisModelInfo{ModelSize: &longSize, Precision: &longPrecision},
+	})
+	assert.Equal(t, strings.Repeat("c", 32), fields.Category)
+	assert.Equal(t, longSize, fields.SubCategory)
+	assert.Equal(t, strings.Repeat("大", 32), fields.ModelSize)
+	assert.Equal(t, strings.Repeat("x", 32), fields.Precision)
+}
+
+func TestLLMService_BackfillAnalysisFields(t *testing.T) {
+	repo := new(MockJobAnalysisRepository)
+	svc := NewLLMService(new(MockJobServiceForLLM), repo, config.LLMConfig{})
+
+	repo.On("FindUnextracted", analysisBackfillBatchSize).Return([]model.JobAnalysis{
+		{JobID: "job-001", Status: "completed", Result: `{"taskType":{"category":"training"},"issues":[{"severity":"warning"}]}`},
+		{JobID: "job-002", Status: "completed", Result: "broken"},
+	}, nil).Once()
+	repo.On("FindUnextracted", analysisBackfillBatchSize).Return([]model.JobAnalysis{}, nil).Once()
+	repo.On("UpdateFields", "job-001", model.JobAnalysisFields{Category: "training", MaxIssueSeverity: "warning"}).Return(nil)
+	// 无法解析的结果写入空字段，避免下一轮重复处理
+	repo.On("UpdateFields", "job-002", model.JobAnalysisFields{}).Return(nil)
+
+	n, err := svc.BackfillAnalysisFields(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	repo.AssertExpectations(t)
+}
+
+func TestLLMService_BackfillAnalysisFields_SkipsFailedRow(t *testing.T) {
+	repo := new(MockJobAnalysisRepository)
+	svc := NewLLMService(new(MockJobServiceForLLM), repo, config.LLMConfig{})
+
+	repo.On("FindUnextracted", analysisBackfillBatchSize).Return([]model.JobAnalysis{
+		{JobID: "job-001", Status: "completed", Result: `{"taskType":{"category":"training"}}`},
+		{JobID: "job-002", Status: "completed", Result: `{"taskType":{"category":"inference"}}`},
+	}, nil).Once()
+	repo.On("FindUnextracted", analysisBackfillBatchSize).Return([]model.JobAnalysis{}, nil).Once()
+	// 写入失败的记录改写空字段并标记为已提取，继续处理后面的记录
+	repo.On("UpdateFields", "job-001", model.JobAnalysisFields{Category: "training", MaxIssueSeverity: "none"}).Return(errors.New("data too long")).Once()
+	repo.On("UpdateFields", "job-001", model.JobAnalysisFields{}).Return(nil).Once()
+	repo.On("UpdateFields", "job-002", model.JobAnalysisFields{Category: "inference", MaxIssueSeverity: "none"}).Return(nil).Once()
+
+	n, err := svc.BackfillAnalysisFields(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	repo.AssertExpectations(t)
+}
+
+func TestLLMService_BackfillAnalysisFields_MarkFails(t *testing.T) {
+	repo := new(MockJobAnalysisRepository)
+	svc := NewLLMService(new(MockJobServiceForLLM), repo, config.LLMConfig{})
+
+	repo.On("FindUnextracted", analysisBackfillBatchSize).Return([]model.JobAnalysis{
+		{JobID: "job-001", Status: "completed", Result: "broken"},
+	}, nil).Once()
+	repo.On("UpdateFields", "job-001", model.JobAnalysisFields{}).Return(assert.AnError)
+
+	n, err := svc.BackfillAnalysisFields(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 0, n)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,7 +18,7 @@ import (
 type AuthService struct {
 	userRepo      repository.UserRepositoryInterface
 	jwtSecret     string
-	expireMinutes int
+	expireMinutes int64 // 原子读写，配置热加载时更新
 }
 
 // NewAuthService 创建认证服务
@@ -28,10 +29,18 @@ func NewAuthService(userRepo repository.UserRepositoryInterface, jwtSecret strin
 	return &AuthService{
 		userRepo:      userRepo,
 		jwtSecret:     jwtSecret,
-		expireMinutes: expireMinutes,
+		expireMinutes: int64(expireMinutes),
 	}
 }
 
+// SetExpireMinutes 更新Token有效期，仅对之后签发的Token生效
+func (s *AuthService) SetExpireMinutes(expireMinutes int) {
+	if expireMinutes <= 0 {
+		return
+	}
+	atomic.StoreInt64(&s.expireMinutes, int64(expireMinutes))
+}
+
 // Login 用户登录，返回JWT token
 func (s *AuthService) Login(username, password string) (string, error) {
 	user, err := s.userRepo.FindByUsername(username)
@@ -44,7 +53,7 @@ func (s *AuthService) Login(username, password string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
-		"exp":      time.Now().Add(time.Duration(s.expireMinutes) * time.Minute).Unix(),
+		"exp":      time.Now().Add(time.Duration(atomic.LoadInt64(&s.expireMinutes)) * time.Minute).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtSecret))
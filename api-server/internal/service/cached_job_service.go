@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/cache"
+)
+
+// CachedJobService 为作业分组、统计等需要全量重建进程树的查询加缓存，其余方法直接透传给 JobService。
+// 作业字段回写（UpdateJobFields）后整体失效作业缓存；agent 直接写库产生的新数据依赖短 TTL 或失效接口刷新。
+type CachedJobService struct {
+	*JobService
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedJobService 创建带缓存的作业服务
+func NewCachedJobService(jobService *JobService, c cache.Cache, ttl time.Duration) *CachedJobService {
+	return &CachedJobService{
+		JobService: jobService,
+		cache:      c,
+		ttl:        ttl,
+	}
+}
+
+// groupedJobsPage 分组作业分页结果的缓存结构
+type groupedJobsPage struct {
+	Groups []JobGroup `json:"groups"`
+	Total  int64      `json:"total"`
+}
+
+// GetGroupedJobs 带缓存的分组作业查询，缓存键由全部查询参数决定
+func (s *CachedJobService) GetGroupedJobs(filter JobGroupFilter, sortBy, sortOrder string, page, pageSize int) ([]JobGroup, int64, error) {
+	params := fmt.Sprintf("%s|%s|%s|%d|%d", filterCacheKey(filter), sortBy, sortOrder, page, pageSize)
+	sum := sha1.Sum([]byte(params))
+	key := cache.Key(cache.NamespaceJobs, "grouped", hex.EncodeToString(sum[:]))
+
+	result, err := cache.Remember(context.Background(), s.cache, key, s.ttl, func() (groupedJobsPage, error) {
+		groups, total, err := s.JobService.GetGroupedJobs(filter, sortBy, sortOrder, page, pageSize)
+		return groupedJobsPage{Groups: groups, Total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.Groups, result.Total, nil
+}
+
+// filterCacheKey 筛选条件的稳定序列化，作为缓存键的一部分
+func filterCacheKey(filter JobGroupFilter) string {
+	data, _ := json.Marshal(filter)
+	return string(data)
+}
+
+// groupedJobsCursorPage 游标分页结果的缓存结构
+type groupedJobsCursorPage struct {
+	Groups     []JobGroup `json:"groups"`
+	Total      int64      `json:"total"`
+	NextCursor string     `json:"nextCursor"`
+}
+
+// GetGroupedJobsByCursor 带缓存的游标分页分组作业查询，缓存键包含游标
+func (s *CachedJobService) GetGroupedJobsByCursor(filter JobGroupFilter, sortBy, sortOrder, cursor string, pageSize int) ([]JobGroup, int64, string, error) {
+	params := fmt.Sprintf("%s|%s|%s|%s|%d", filterCacheKey(filter), sortBy, sortOrder, cursor, pageSize)
+	sum := sha1.Sum([]byte(params))
+	key := cache.Key(cache.NamespaceJobs, "grouped_cursor", hex.EncodeToString(sum[:]))
+
+	result, err := cache.Remember(context.Background(), s.cache, key, s.ttl, func() (groupedJobsCursorPage, error) {
+		groups, total, next, err := s.JobService.GetGroupedJobsByCursor(filter, sortBy, sortOrder, cursor, pageSize)
+		return groupedJobsCursorPage{Groups: groups, Total: total, NextCursor: next}, err
+	})
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return result.Groups, result.Total, result.NextCursor, nil
+}
+
+// GetJobStats 带缓存的作业统计，缓存键由筛选条件决定
+func (s *CachedJobService) GetJobStats(filter JobGroupFilter) (map[string]int64, error) {
+	sum := sha1.Sum([]byte(filterCacheKey(filter)))
+	key := cache.Key(cache.NamespaceJobs, "stats", hex.EncodeToString(sum[:]))
+	return cache.Remember(context.Background(), s.cache, key, s.ttl, func() (map[string]int64, error) {
+		return s.JobService.GetJobStats(filter)
+	})
+}
+
+// GetJobGroupCounts 带缓存的作业组分维度统计，缓存键包含可见范围
+func (s *CachedJobService) GetJobGroupCounts(scope ProjectFilter) ([]JobGroupCount, error) {
+	key := cache.Key(cache.NamespaceJobs, "group_counts", scopeCacheKey(scope))
+	return cache.Remember(context.Background(), s.cache, key, s.ttl, func() ([]JobGroupCount, error) {
+		return s.JobService.GetJobGroupCounts(scope)
+	})
+}
+
+// GetDistinctCardCounts 带缓存的卡数选项查询，缓存键包含可见范围
+func (s *CachedJobService) GetDistinctCardCounts(scope ProjectFilter) ([]int, error) {
+	key := cache.Key(cache.NamespaceJobs, "card_counts", scopeCacheKey(scope))
+	return cache.Remember(context.Background(), s.cache, key, s.ttl, func() ([]int, error) {
+		return s.JobService.GetDistinctCardCounts(scope)
+	})
+}
+
+// scopeCacheKey 可见范围的缓存键片段
+func scopeCacheKey(scope ProjectFilter) string {
+	sum := sha1.Sum([]byte(filterCacheKey(JobGroupFilter{JobFilter: JobFilter{Projects: scope}})))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetDistributedJobs 带缓存的分布式作业查询，缓存全量关联结果，筛选与分页在缓存之上进行
+func (s *CachedJobService) GetDistributedJobs(statuses []string, page, pageSize int, scope ProjectFilter) ([]DistributedJob, int64, error) {
+	jobs, err := s.cachedDistributedJobs()
+	if err == nil {
+		jobs, err = s.filterDistributedByProject(jobs, scope)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	items, total := pageDistributedJobs(jobs, statuses, page, pageSize)
+	return items, total, nil
+}
+
+// GetDistributedJobDetail 基于缓存的关联结果组装分布式作业详情，各节点详情实时查询
+func (s *CachedJobService) GetDistributedJobDetail(distributedID string, scope ProjectFilter) (*DistributedJobDetail, error) {
+	jobs, err := s.cachedDistributedJobs()
+	if err == nil {
+		jobs, err = s.filterDistributedByProject(jobs, scope)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.distributedJobDetail(jobs, distributedID)
+}
+
+func (s *CachedJobService) cachedDistributedJobs() ([]DistributedJob, error) {
+	return cache.Remember(context.Background(), s.cache, cache.Key(cache.NamespaceJobs, "distributed"), s.ttl, s.JobService.detectDistributedJobs)
+}
+
+// UpdateJobFields 更新作业字段后失效作业缓存（job_type/framework 回写会影响分组过滤与统计）
+func (s *CachedJobService) UpdateJobFields(jobID string, fields map[string]interface{}) error {
+	if err := s.JobService.UpdateJobFields(jobID, fields); err != nil {
+		return err
+	}
+	s.InvalidateJobs()
+	return nil
+}
+
+// InvalidateJobs 失效全部作业相关缓存
+func (s *CachedJobService) InvalidateJobs() {
+	if err := s.cache.InvalidateNamespace(context.Background(), cache.NamespaceJobs); err != nil {
+		slog.Warn("invalidate job cache failed", "error", err)
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/cache"
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+func TestCachedJobService_GetJobStats_CachedUntilUpdate(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	inner := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+	svc := NewCachedJobService(inner, cache.NewMemory(), time.Minute)
+
+	nodeID := "node-001"
+	pid, ppid := int64(100), int64(1)
+	startTime := int64(1770373780000)
+	running := "running"
+	mockJobRepo.On("FindAll").Return([]model.Job{
+		{JobID: "job-001", NodeID: &nodeID, PID: &pid, PPID: &ppid, StartTime: &startTime, Status: &running},
+	}, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{}, nil)
+	mockJobRepo.On("UpdateFields", "job-001", mock.Anything).Return(nil)
+
+	stats, err := svc.GetJobStats(JobGroupFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stats["running"])
+
+	_, err = svc.GetJobStats(JobGroupFilter{})
+	assert.NoError(t, err)
+	mockJobRepo.AssertNumberOfCalls(t, "FindAll", 1)
+
+	// 回写作业字段后缓存失效
+	assert.NoError(t, svc.UpdateJobFields("job-001", map[string]interface{}{"job_type": "training"}))
+	_, err = svc.GetJobStats(JobGroupFilter{})
+	assert.NoError(t, err)
+	mockJobRepo.AssertNumberOfCalls(t, "FindAll", 2)
+}
+
+func TestCachedJobService_GetGroupedJobs_KeyedByParams(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	inner := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+	svc := NewCachedJobService(inner, cache.NewMemory(), time.Minute)
+
+	nodeID := "node-001"
+	pid, ppid := int64(100), int64(1)
+	startTime := int64(1770373780000)
+	running := "running"
+	jobs := []model.Job{{JobID: "job-001", NodeID: &nodeID, PID: &pid, PPID: &ppid, StartTime: &startTime, Status: &running}}
+	mockJobRepo.On("FindFiltered", JobFilter{}, "", "").Return(jobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{NodeIDs: []string{"node-001"}}, "", "").Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{100: {0, 1}}, nil)
+
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{}, "", "", 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "job-001", groups[0].MainJob.JobID)
+	assert.Equal(t, 2, *groups[0].CardCount)
+
+	// 命中缓存，反序列化结果与原始结果一致
+	cached, total, err := svc.GetGroupedJobs(JobGroupFilter{}, "", "", 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, groups, cached)
+	mockJobRepo.AssertNumberOfCalls(t, "FindFiltered", 1)
+
+	// 参数不同则不共享缓存
+	_, _, err = svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{NodeIDs: []string{"node-001"}}}, "", "", 1, 20)
+	assert.NoError(t, err)
+	mockJobRepo.AssertNumberOfCalls(t, "FindFiltered", 2)
+}
@@ -0,0 +1,557 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"gorm.io/gorm"
+)
+
+// distributedStartWindowMs 同一 rendezvous 地址下，启动时间相差超过该值的分组视为不同的分布式作业（端口会被后续作业复用）
+const distributedStartWindowMs = int64(10 * 60 * 1000)
+
+// envLookupBatchSize 批量查询环境变量时每批的作业数
+const envLookupBatchSize = 1000
+
+// distributedLookback 分布式作业只在运行中或该时长内结束的分组中识别，避免每次请求加载全部历史分组与环境变量
+const distributedLookback = 7 * 24 * time.Hour
+
+// DistributedJob 跨节点分布式作业：各节点上属于同一次 torchrun/HCCL 通信组的作业分组
+type DistributedJob struct {
+	DistributedID  string                 `json:"distributedId"`
+	Rendezvous     string                 `json:"rendezvous"` // 关联依据，如 MASTER_ADDR:MASTER_PORT
+	Source         string                 `json:"source"`     // env / cmdline / rank_table
+	JobName        *string                `json:"jobName"`
+	JobType        *string                `json:"jobType"`
+	Framework      *string                `json:"framework"`
+	Status         string                 `json:"status"` // 任一节点 running 即为 running，否则取最差的终态
+	StartTime      *int64                 `json:"startTime"`
+	WorldSize      *int                   `json:"worldSize"`
+	NodeCount      int                    `json:"nodeCount"`
+	TotalCardCount int                    `json:"totalCardCount"` // 各节点已知卡数之和
+	Members        []DistributedJobMember `json:"members"`
+}
+
+// DistributedJobMember 分布式作业在单个节点上的成员（一个作业分组）
+type DistributedJobMember struct {
+	NodeID    string   `json:"nodeId"`
+	NodeRank  *int     `json:"nodeRank"` // NODE_RANK/GROUP_RANK 或 --node_rank
+	Ranks     []int    `json:"ranks"`    // 该节点 worker 进程的全局 RANK
+	CardCount *int     `json:"cardCount"`
+	Group     JobGroup `json:"group"`
+}
+
+// DistributedRankDetail 分布式作业单个节点成员的作业详情
+type DistributedRankDetail struct {
+	NodeID   string             `json:"nodeId"`
+	NodeRank *int               `json:"nodeRank"`
+	Detail   *JobDetailResponse `json:"detail"`
+}
+
+// DistributedJobDetail 分布式作业合并详情
+type DistributedJobDetail struct {
+	DistributedJob
+	RankDetails []DistributedRankDetail `json:"rankDetails"`
+}
+
+// distributedHint 从环境变量与命令行中提取的分布式启动信息
+type distributedHint struct {
+	key       string
+	source    string
+	worldSize *int
+	nodeRank  *int
+	ranks     []int
+	single    bool // 明确为单节点（--nnodes=1）
+}
+
+// distributedCandidate 带有分布式启动信息的作业分组
+type distributedCandidate struct {
+	group JobGroup
+	hint  distributedHint
+}
+
+// GetDistributedJobs 查询跨节点分布式作业，按启动时间倒序分页；scope 为用户的项目可见范围
+func (s *JobService) GetDistributedJobs(statuses []string, page, pageSize int, scope ProjectFilter) ([]DistributedJob, int64, error) {
+	jobs, err := s.detectDistributedJobs()
+	if err == nil {
+		jobs, err = s.filterDistributedByProject(jobs, scope)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	items, total := pageDistributedJobs(jobs, statuses, page, pageSize)
+	return items, total, nil
+}
+
+// GetDistributedJobDetail 查询分布式作业合并详情：各节点主作业的 NPU 卡与关联进程。不存在或不在可见范围内时返回 gorm.ErrRecordNotFound
+func (s *JobService) GetDistributedJobDetail(distributedID string, scope ProjectFilter) (*DistributedJobDetail, error) {
+	jobs, err := s.detectDistributedJobs()
+	if err == nil {
+		jobs, err = s.filterDistributedByProject(jobs, scope)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.distributedJobDetail(jobs, distributedID)
+}
+
+// filterDistributedByProject 保留全部成员分组都在项目可见范围内的分布式作业
+func (s *JobService) filterDistributedByProject(jobs []DistributedJob, scope ProjectFilter) ([]DistributedJob, error) {
+	if scope.IsEmpty() {
+		return jobs, nil
+	}
+	var groups []JobGroup
+	for _, job := range jobs {
+		for _, m := range job.Members {
+			groups = append(groups, m.Group)
+		}
+	}
+	visible, err := s.filterGroupsByProject(groups, scope)
+	if err != nil {
+		return nil, err
+	}
+	visibleIDs := make(map[string]struct{}, len(visible))
+	for _, g := range visible {
+		visibleIDs[g.MainJob.JobID] = struct{}{}
+	}
+	filtered := make([]DistributedJob, 0, len(jobs))
+	for _, job := range jobs {
+		ok := true
+		for _, m := range job.Members {
+			if _, found := visibleIDs[m.Group.MainJob.JobID]; !found {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered, nil
+}
+
+// pageDistributedJobs 按合并状态筛选并分页
+func pageDistributedJobs(jobs []DistributedJob, statuses []string, page, pageSize int) ([]DistributedJob, int64) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if len(statuses) > 0 {
+		filtered := make([]DistributedJob, 0, len(jobs))
+		for _, job := range jobs {
+			if slices.Contains(statuses, job.Status) {
+				filtered = append(filtered, job)
+			}
+		}
+		jobs = filtered
+	}
+
+	total := int64(len(jobs))
+	offset := (page - 1) * pageSize
+	if offset >= len(jobs) {
+		return []DistributedJob{}, total
+	}
+	end := offset + pageSize
+	if end > len(jobs) {
+		end = len(jobs)
+	}
+	return jobs[offset:end], total
+}
+
+// distributedJobDetail 组装各节点主作业的详情
+func (s *JobService) distributedJobDetail(jobs []DistributedJob, distributedID string) (*DistributedJobDetail, error) {
+	for _, job := range jobs {
+		if job.DistributedID != distributedID {
+			continue
+		}
+		detail := &DistributedJobDetail{DistributedJob: job, RankDetails: make([]DistributedRankDetail, 0, len(job.Members))}
+		for _, m := range job.Members {
+			jd, err := s.GetJobDetail(m.Group.MainJob.JobID, true)
+			if err != nil {
+				return nil, fmt.Errorf("job detail %s: %w", m.Group.MainJob.JobID, err)
+			}
+			detail.RankDetails = append(detail.RankDetails, DistributedRankDetail{NodeID: m.NodeID, NodeRank: m.NodeRank, Detail: jd})
+		}
+		return detail, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// detectDistributedJobs 关联各节点的作业分组：rendezvous 地址相同、启动时间接近且分布在两个以上节点的分组合并为一个分布式作业
+func (s *JobService) detectDistributedJobs() ([]DistributedJob, error) {
+	groups, err := s.loadRecentGroups(time.Now().Add(-distributedLookback))
+	if err != nil {
+		return nil, err
+	}
+	envByJob, err := s.loadEnvVars(groups)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string][]distributedCandidate)
+	var keys []string
+	for _, group := range groups {
+		if group.MainJob.NodeID == nil || *group.MainJob.NodeID == "" {
+			continue
+		}
+		hint := extractDistributedHint(group, envByJob)
+		if hint.key == "" || hint.single {
+			continue
+		}
+		if _, ok := byKey[hint.key]; !ok {
+			keys = append(keys, hint.key)
+		}
+		byKey[hint.key] = append(byKey[hint.key], distributedCandidate{group: group, hint: hint})
+	}
+
+	result := make([]DistributedJob, 0)
+	for _, key := range keys {
+		for _, cluster := range splitByStartTime(byKey[key]) {
+			if job, ok := buildDistributedJob(key, cluster); ok {
+				result = append(result, job)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return safeInt64(result[i].StartTime) > safeInt64(result[j].StartTime)
+	})
+	return result, nil
+}
+
+// loadRecentGroups 加载运行中或在 since 之后结束的分组
+func (s *JobService) loadRecentGroups(since time.Time) ([]JobGroup, error) {
+	endFrom := since.UnixMilli()
+	filter := JobGroupFilter{JobFilter: repository.JobFilter{EndFrom: &endFrom}}
+	if s.useGroupStore() {
+		// limit/offset 为 -1 时不分页
+		groups, _, err := s.getGroupedJobsFromStore(filter, "", "", -1, -1)
+		return groups, err
+	}
+	return s.buildFilteredGroups(filter, "", "")
+}
+
+// loadEnvVars 批量加载分组内作业最新一次上报的环境变量
+func (s *JobService) loadEnvVars(groups []JobGroup) (map[string]map[string]string, error) {
+	var jobIDs []string
+	for _, group := range groups {
+		jobIDs = append(jobIDs, group.MainJob.JobID)
+		for _, child := range group.ChildJobs {
+			jobIDs = append(jobIDs, child.JobID)
+		}
+	}
+
+	envByJob := make(map[string]map[string]string)
+	for start := 0; start < len(jobIDs); start += envLookupBatchSize {
+		end := start + envLookupBatchSize
+		if end > len(jobIDs) {
+			end = len(jobIDs)
+		}
+		params, err := s.paramRepo.FindEnvVarsByJobIDs(jobIDs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("find env vars: %w", err)
+		}
+		collectLatestEnvVars(params, envByJob)
+	}
+	return envByJob, nil
+}
+
+// collectLatestEnvVars 解析 FindEnvVarsByJobIDs 的结果（按 timestamp 倒序），每个作业取第一条可解析的记录
+func collectLatestEnvVars(params []model.Parameter, envByJob map[string]map[string]string) {
+	for _, p := range params {
+		if p.JobID == nil || p.EnvVars == nil {
+			continue
+		}
+		if _, ok := envByJob[*p.JobID]; ok {
+			continue
+		}
+		var env map[string]string
+		if err := json.Unmarshal([]byte(*p.EnvVars), &env); err != nil || len(env) == 0 {
+			continue
+		}
+		envByJob[*p.JobID] = env
+	}
+}
+
+// extractDistributedHint 从分组内各进程的环境变量和主进程命令行提取 rendezvous 信息。
+// 优先级：MASTER_ADDR/MASTER_PORT 环境变量 > torchrun 命令行参数 > MindSpore 调度节点 > HCCL rank table
+func extractDistributedHint(group JobGroup, envByJob map[string]map[string]string) distributedHint {
+	var hint distributedHint
+	jobs := append([]model.Job{group.MainJob}, group.ChildJobs...)
+
+	var schedKey, rankTableKey string
+	seenRank := make(map[int]bool)
+	for _, job := range jobs {
+		env := envByJob[job.JobID]
+		if env == nil {
+			continue
+		}
+		if hint.key == "" {
+			if addr := env["MASTER_ADDR"]; addr != "" && !isLocalAddr(addr) {
+				hint.key = rendezvousKey(addr, env["MASTER_PORT"])
+				hint.source = "env"
+			}
+		}
+		if schedKey == "" {
+			if addr := env["MS_SCHED_HOST"]; addr != "" && !isLocalAddr(addr) {
+				schedKey = rendezvousKey(addr, env["MS_SCHED_PORT"])
+			}
+		}
+		if rankTableKey == "" && env["RANK_TABLE_FILE"] != "" {
+			rankTableKey = "rank_table:" + env["RANK_TABLE_FILE"] + "|" + stringOrEmpty(group.MainJob.JobName)
+		}
+		if hint.worldSize == nil {
+			hint.worldSize = firstEnvInt(env, "WORLD_SIZE", "RANK_SIZE", "MS_WORKER_NUM")
+		}
+		if hint.nodeRank == nil {
+			hint.nodeRank = firstEnvInt(env, "NODE_RANK", "GROUP_RANK")
+		}
+		if rank := firstEnvInt(env, "RANK", "RANK_ID"); rank != nil && !seenRank[*rank] {
+			seenRank[*rank] = true
+			hint.ranks = append(hint.ranks, *rank)
+		}
+	}
+	sort.Ints(hint.ranks)
+
+	if group.MainJob.CommandLine != nil {
+		flags := parseLauncherFlags(*group.MainJob.CommandLine)
+		if hint.key == "" {
+			if endpoint := flags["rdzv_endpoint"]; endpoint != "" {
+				host, port, _ := strings.Cut(endpoint, ":")
+				if !isLocalAddr(host) {
+					hint.key = rendezvousKey(host, port)
+					hint.source = "cmdline"
+				}
+			} else if addr := flags["master_addr"]; addr != "" && !isLocalAddr(addr) {
+				hint.key = rendezvousKey(addr, flags["master_port"])
+				hint.source = "cmdline"
+			}
+		}
+		if hint.nodeRank == nil {
+			hint.nodeRank = parseIntPtr(flags["node_rank"])
+		}
+		if nnodes := flags["nnodes"]; nnodes != "" {
+			// 弹性写法 min:max 取上限
+			if _, max, ok := strings.Cut(nnodes, ":"); ok {
+				nnodes = max
+			}
+			n := parseIntPtr(nnodes)
+			if n != nil && *n == 1 {
+				hint.single = true
+			}
+			if hint.worldSize == nil && n != nil {
+				if nproc := parseIntPtr(flags["nproc_per_node"]); nproc != nil {
+					ws := *n * *nproc
+					hint.worldSize = &ws
+				}
+			}
+		}
+	}
+
+	if hint.key == "" && schedKey != "" {
+		hint.key, hint.source = schedKey, "env"
+	}
+	if hint.key == "" && rankTableKey != "" {
+		hint.key, hint.source = rankTableKey, "rank_table"
+	}
+	return hint
+}
+
+// launcherFlags torchrun / torch.distributed.launch 中与 rendezvous 相关的参数（连字符与下划线写法均可）
+var launcherFlags = map[string]string{
+	"--master_addr":    "master_addr",
+	"--master-addr":    "master_addr",
+	"--master_port":    "master_port",
+	"--master-port":    "master_port",
+	"--rdzv_endpoint":  "rdzv_endpoint",
+	"--rdzv-endpoint":  "rdzv_endpoint",
+	"--node_rank":      "node_rank",
+	"--node-rank":      "node_rank",
+	"--nnodes":         "nnodes",
+	"--nproc_per_node": "nproc_per_node",
+	"--nproc-per-node": "nproc_per_node",
+}
+
+// parseLauncherFlags 解析命令行中的 --flag=value 与 --flag value
+func parseLauncherFlags(cmdline string) map[string]string {
+	flags := make(map[string]string)
+	fields := strings.Fields(cmdline)
+	for i, f := range fields {
+		name, value, hasValue := strings.Cut(f, "=")
+		key, ok := launcherFlags[name]
+		if !ok {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(fields) {
+				continue
+			}
+			value = fields[i+1]
+		}
+		if _, exists := flags[key]; !exists {
+			flags[key] = strings.Trim(value, `"'`)
+		}
+	}
+	return flags
+}
+
+// splitByStartTime 按启动时间排序后，相邻分组间隔超过窗口即切分为不同的作业
+func splitByStartTime(candidates []distributedCandidate) [][]distributedCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return safeInt64(candidates[i].group.MainJob.StartTime) < safeInt64(candidates[j].group.MainJob.StartTime)
+	})
+	var clusters [][]distributedCandidate
+	var current []distributedCandidate
+	var last int64
+	for i, c := range candidates {
+		start := safeInt64(c.group.MainJob.StartTime)
+		if i > 0 && start-last > distributedStartWindowMs {
+			clusters = append(clusters, current)
+			current = nil
+		}
+		current = append(current, c)
+		last = start
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+	return clusters
+}
+
+// buildDistributedJob 由同一 rendezvous 的分组构建分布式作业，节点数不足 2 时返回 false
+func buildDistributedJob(key string, cluster []distributedCandidate) (DistributedJob, bool) {
+	nodes := make(map[string]bool)
+	for _, c := range cluster {
+		nodes[*c.group.MainJob.NodeID] = true
+	}
+	if len(nodes) < 2 {
+		return DistributedJob{}, false
+	}
+
+	members := make([]DistributedJobMember, 0, len(cluster))
+	job := DistributedJob{
+		Rendezvous: strings.TrimPrefix(key, "rank_table:"),
+		NodeCount:  len(nodes),
+	}
+	for _, c := range cluster {
+		ranks := c.hint.ranks
+		if ranks == nil {
+			ranks = []int{}
+		}
+		members = append(members, DistributedJobMember{
+			NodeID:    *c.group.MainJob.NodeID,
+			NodeRank:  c.hint.nodeRank,
+			Ranks:     ranks,
+			CardCount: c.group.CardCount,
+			Group:     c.group,
+		})
+		if c.group.CardCount != nil {
+			job.TotalCardCount += *c.group.CardCount
+		}
+		if job.WorldSize == nil {
+			job.WorldSize = c.hint.worldSize
+		}
+		if job.Source == "" {
+			job.Source = c.hint.source
+		}
+		if st := c.group.MainJob.StartTime; st != nil && (job.StartTime == nil || *st < *job.StartTime) {
+			job.StartTime = st
+		}
+	}
+
+	// 节点 rank 升序（未知排在最后），同 rank 按节点ID
+	sort.SliceStable(members, func(i, j int) bool {
+		a, b := members[i].NodeRank, members[j].NodeRank
+		if a != nil && b != nil && *a != *b {
+			return *a < *b
+		}
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		return members[i].NodeID < members[j].NodeID
+	})
+	job.Members = members
+	head := members[0].Group.MainJob
+	job.JobName, job.JobType, job.Framework = head.JobName, head.JobType, head.Framework
+	job.Status = aggregateDistributedStatus(members)
+	job.DistributedID = distributedID(key, members)
+	return job, true
+}
+
+// distributedStatusPriority 合并状态时的优先级，数值越大越优先
+var distributedStatusPriority = map[string]int{
+	"running": 5, "failed": 4, "lost": 3, "stopped": 2, "completed": 1,
+}
+
+func aggregateDistributedStatus(members []DistributedJobMember) string {
+	status := "unknown"
+	best := 0
+	for _, m := range members {
+		st := stringOrUnknown(m.Group.MainJob.Status)
+		if p := distributedStatusPriority[st]; p > best {
+			best, status = p, st
+		}
+	}
+	return status
+}
+
+// distributedID 由 rendezvous 与最早成员主作业ID生成稳定ID
+func distributedID(key string, members []DistributedJobMember) string {
+	earliest := members[0].Group.MainJob
+	for _, m := range members[1:] {
+		if safeInt64(m.Group.MainJob.StartTime) < safeInt64(earliest.StartTime) {
+			earliest = m.Group.MainJob
+		}
+	}
+	sum := sha1.Sum([]byte(key + "|" + earliest.JobID))
+	return "dist-" + hex.EncodeToString(sum[:])[:12]
+}
+
+func rendezvousKey(addr, port string) string {
+	if port == "" {
+		port = "29500" // torch.distributed 默认端口
+	}
+	return addr + ":" + port
+}
+
+// isLocalAddr 回环地址只用于单机多卡，不能用来跨节点关联
+func isLocalAddr(addr string) bool {
+	return addr == "localhost" || addr == "::1" || addr == "0.0.0.0" || strings.HasPrefix(addr, "127.")
+}
+
+func firstEnvInt(env map[string]string, keys ...string) *int {
+	for _, k := range keys {
+		if v := parseIntPtr(env[k]); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func parseIntPtr(s string) *int {
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
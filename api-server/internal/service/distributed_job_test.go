@@ -0,0 +1,173 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+)
+
+func distributedTestJob(jobID, nodeID string, pid, ppid, startTime int64, cmdline string) model.Job {
+	running := "running"
+	name := "pretrain_gpt.py"
+	job := model.Job{JobID: jobID, NodeID: &nodeID, PID: &pid, PPID: &ppid, StartTime: &startTime, Status: &running, JobName: &name}
+	if cmdline != "" {
+		job.CommandLine = &cmdline
+	}
+	return job
+}
+
+func envParam(jobID, env string) model.Parameter {
+	return model.Parameter{JobID: &jobID, EnvVars: &env}
+}
+
+// recentJobFilter 只查询运行中或 distributedLookback 内结束的作业
+func recentJobFilter(f JobFilter) bool {
+	if f.EndFrom == nil {
+		return false
+	}
+	lookback := time.Now().UnixMilli() - *f.EndFrom
+	return lookback > int64(distributedLookback/time.Millisecond)-60000 && lookback <= int64(distributedLookback/time.Millisecond)+60000
+}
+
+func TestJobService_GetDistributedJobs_CorrelatesAcrossNodes(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockParamRepo := new(MockParameterRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewJobService(mockJobRepo, mockParamRepo, new(MockCodeRepository), mockMetricsRepo)
+
+	start := int64(1770373780000)
+	jobs := []model.Job{
+		// node-001: torchrun 启动器 + worker（RANK 0..1）
+		distributedTestJob("n1-launcher", "node-001", 100, 1, start, "torchrun --nnodes=2 --nproc_per_node=2 --node_rank=0 --master_addr=10.0.0.1 --master_port=29600 pretrain_gpt.py"),
+		distributedTestJob("n1-rank0", "node-001", 101, 100, start+1000, ""),
+		distributedTestJob("n1-rank1", "node-001", 102, 100, start+1000, ""),
+		// node-002：只从 worker 环境变量识别
+		distributedTestJob("n2-launcher", "node-002", 200, 1, start+5000, "torchrun pretrain_gpt.py"),
+		distributedTestJob("n2-rank2", "node-002", 201, 200, start+6000, ""),
+		// 同一端口在一小时后被另一个单节点作业复用
+		distributedTestJob("n3-later", "node-003", 300, 1, start+3600*1000, "torchrun --master_addr 10.0.0.1 --master_port 29600 pretrain_gpt.py"),
+		// 单机多卡
+		distributedTestJob("n4-local", "node-004", 400, 1, start, "torchrun --master_addr=127.0.0.1 pretrain_gpt.py"),
+	}
+	mockJobRepo.On("FindFiltered", mock.MatchedBy(recentJobFilter), "", "").Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{101: {0}, 102: {1}}, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-002", mock.Anything).Return(map[int64][]int{201: {0, 1}}, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", mock.Anything, mock.Anything).Return(map[int64][]int{}, nil)
+	mockParamRepo.On("FindEnvVarsByJobIDs", mock.Anything).Return([]model.Parameter{
+		envParam("n1-rank0", `{"MASTER_ADDR":"10.0.0.1","MASTER_PORT":"29600","RANK":"0","WORLD_SIZE":"4","GROUP_RANK":"0"}`),
+		envParam("n1-rank1", `{"MASTER_ADDR":"10.0.0.1","MASTER_PORT":"29600","RANK":"1","WORLD_SIZE":"4","GROUP_RANK":"0"}`),
+		envParam("n2-rank2", `{"MASTER_ADDR":"10.0.0.1","MASTER_PORT":"29600","RANK":"2","WORLD_SIZE":"4","GROUP_RANK":"1"}`),
+	}, nil)
+
+	items, total, err := svc.GetDistributedJobs(nil, 1, 20, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, items, 1)
+
+	job := items[0]
+	assert.Equal(t, "10.0.0.1:29600", job.Rendezvous)
+	assert.Equal(t, "env", job.Source)
+	assert.Equal(t, "running", job.Status)
+	assert.Equal(t, 2, job.NodeCount)
+	assert.Equal(t, 4, job.TotalCardCount)
+	assert.Equal(t, 4, *job.WorldSize)
+	assert.Equal(t, start, *job.StartTime)
+	assert.Regexp(t, "^dist-[0-9a-f]{12}$", job.DistributedID)
+	require.Len(t, job.Members, 2)
+	assert.Equal(t, "node-001", job.Members[0].NodeID)
+	assert.Equal(t, 0, *job.Members[0].NodeRank)
+	assert.Equal(t, []int{0, 1}, job.Members[0].Ranks)
+	assert.Equal(t, "node-002", job.Members[1].NodeID)
+	assert.Equal(t, 1, *job.Members[1].NodeRank)
+	assert.Equal(t, []int{2}, job.Members[1].Ranks)
+
+	// 状态筛选
+	items, total, err = svc.GetDistributedJobs([]string{"completed"}, 1, 20, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, items)
+}
+
+func TestJobService_GetDistributedJobDetail_NotFound(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockParamRepo := new(MockParameterRepository)
+	svc := NewJobService(mockJobRepo, mockParamRepo, new(MockCodeRepository), new(MockMetricsRepository))
+
+	mockJobRepo.On("FindFiltered", mock.MatchedBy(recentJobFilter), "", "").Return([]model.Job{}, nil)
+
+	_, err := svc.GetDistributedJobDetail("dist-000000000000", ProjectFilter{})
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestParseLauncherFlags(t *testing.T) {
+	flags := parseLauncherFlags(`python -m torch.distributed.run --nnodes 1:4 --nproc-per-node=8 --rdzv-endpoint="10.0.0.9:29400" train.py --lr 0.1`)
+	assert.Equal(t, "1:4", flags["nnodes"])
+	assert.Equal(t, "8", flags["nproc_per_node"])
+	assert.Equal(t, "10.0.0.9:29400", flags["rdzv_endpoint"])
+	assert.NotContains(t, flags, "master_addr")
+}
+
+func TestExtractDistributedHint_SingleNodeAndRankTable(t *testing.T) {
+	single := JobGroup{MainJob: distributedTestJob("a", "node-001", 1, 0, 0, "torchrun --nnodes=1 --master_addr=10.0.0.1 train.py")}
+	hint := extractDistributedHint(single, nil)
+	assert.True(t, hint.single)
+
+	// MindSpore/HCCL rank table 启动：按 rank table 路径与作业名关联
+	hccl := JobGroup{MainJob: distributedTestJob("b", "node-001", 1, 0, 0, "python train.py")}
+	hint = extractDistributedHint(hccl, map[string]map[string]string{
+		"b": {"RANK_TABLE_FILE": "/data/hccl_2p.json", "RANK_SIZE": "16", "RANK_ID": "8"},
+	})
+	assert.Equal(t, "rank_table", hint.source)
+	assert.Equal(t, "rank_table:/data/hccl_2p.json|pretrain_gpt.py", hint.key)
+	assert.Equal(t, 16, *hint.worldSize)
+	assert.Equal(t, []int{8}, hint.ranks)
+}
+
+func TestJobService_FilterDistributedByProject(t *testing.T) {
+	projectRepo := new(MockProjectRepository)
+	svc := NewJobService(new(MockJobRepository), new(MockParameterRepository), new(MockCodeRepository), new(MockMetricsRepository))
+	svc.SetProjectRepository(projectRepo)
+	member := func(jobID string) DistributedJobMember {
+		return DistributedJobMember{Group: JobGroup{MainJob: model.Job{JobID: jobID}}}
+	}
+	jobs := []DistributedJob{
+		{DistributedID: "dist-visible", Members: []DistributedJobMember{member("a1"), member("a2")}},
+		{DistributedID: "dist-mixed", Members: []DistributedJobMember{member("b1"), member("b2")}},
+	}
+	projectRepo.On("FindJobProjects", []string{"a1", "a2", "b1", "b2"}).Return([]model.JobProject{
+		{JobID: "a1", ProjectID: 5},
+		{JobID: "b2", ProjectID: 7},
+	}, nil)
+
+	// 有成员归属不可见项目的分布式作业整体不可见
+	filtered, err := svc.filterDistributedByProject(jobs, ProjectFilter{Restricted: true, VisibleIDs: []uint{5}})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "dist-visible", filtered[0].DistributedID)
+
+	filtered, err = svc.filterDistributedByProject(jobs, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Len(t, filtered, 2)
+}
+
+func TestJobService_GetDistributedJobs_GroupStoreLimitsToRecent(t *testing.T) {
+	mockGroupRepo := new(MockJobGroupRepository)
+	svc := NewJobService(new(MockJobRepository), new(MockParameterRepository), new(MockCodeRepository), new(MockMetricsRepository))
+	svc.SetJobGroupRepository(mockGroupRepo)
+	svc.groupsReady.Store(true)
+
+	recent := mock.MatchedBy(func(f JobGroupFilter) bool { return recentJobFilter(f.JobFilter) })
+	mockGroupRepo.On("Find", recent, "", "", -1, -1).Return([]model.JobGroupRecord{}, int64(0), nil)
+
+	items, total, err := svc.GetDistributedJobs(nil, 1, 20, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, items)
+	mockGroupRepo.AssertExpectations(t)
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// 空闲检测的问题类型
+const (
+	IdleIssueHolding        = "idle_holding"    // 占用 HBM 但 AICore 几乎空转
+	IdleIssueLowUtilization = "low_utilization" // AICore 平均利用率偏低
+	IdleIssueImbalanced     = "imbalanced"      // 多卡作业各卡负载差异过大
+)
+
+// IdleIssues 支持的问题类型
+var IdleIssues = []string{IdleIssueHolding, IdleIssueLowUtilization, IdleIssueImbalanced}
+
+// MaxIdleLookback 空闲检测回看窗口上限
+const MaxIdleLookback = 7 * 24 * time.Hour
+
+// idleScanChunkSize 每批扫描的运行中作业分组数
+const idleScanChunkSize = 200
+
+// ErrInvalidIdleQuery 空闲检测参数不合法
+var ErrInvalidIdleQuery = errors.New("invalid idle job query")
+
+// IdleCardStats 单张卡在回看窗口内的指标统计，只统计作业实际占用的芯片
+type IdleCardStats struct {
+	NpuID          int      `json:"npuId"`
+	AvgAICoreUsage *float64 `json:"avgAicoreUsage"`
+	MaxAICoreUsage *float64 `json:"maxAicoreUsage"`
+	AvgHBMUsageMB  *float64 `json:"avgHbmUsageMb"`
+	AvgHBMUsage    *float64 `json:"avgHbmUsage"` // HBM 平均占用百分比
+	Samples        int64    `json:"samples"`
+}
+
+// IdleJob 被标记的运行中作业分组
+type IdleJob struct {
+	JobID           string          `json:"jobId"`
+	JobName         string          `json:"jobName"`
+	NodeID          string          `json:"nodeId"`
+	Framework       string          `json:"framework"`
+	JobType         string          `json:"jobType"`
+	GroupID         uint            `json:"groupId,omitempty"`
+	StartTime       int64           `json:"startTime"`
+	CardCount       int             `json:"cardCount"`
+	Issues          []string        `json:"issues"`
+	AvgAICoreUsage  float64         `json:"avgAicoreUsage"`
+	AvgHBMUsage     *float64        `json:"avgHbmUsage"`
+	AICoreSpread    float64         `json:"aicoreSpread"` // 各卡平均利用率的极差
+	ObservedHours   float64         `json:"observedHours"`
+	WastedCardHours float64         `json:"wastedCardHours"` // 观测时长内各卡未使用的 AICore 比例折算的卡时
+	Cards           []IdleCardStats `json:"cards"`
+}
+
+// IdleJobsReport 一次空闲检测的结果
+type IdleJobsReport struct {
+	GeneratedAt     time.Time `json:"generatedAt"`
+	LookbackMinutes int       `json:"lookbackMinutes"`
+	ScannedJobs     int       `json:"scannedJobs"` // 回看窗口内有指标的运行中作业分组数
+	FlaggedJobs     int       `json:"flaggedJobs"`
+	WastedCardHours float64   `json:"wastedCardHours"` // 返回作业的浪费卡时合计
+	Jobs            []IdleJob `json:"jobs"`
+}
+
+// InsightsService 资源使用洞察：扫描运行中的作业分组，找出空占、低效或负载不均的 NPU 卡
+type InsightsService struct {
+	jobService  JobServiceInterface
+	metricsRepo repository.MetricsRepositoryInterface
+	now         func() time.Time
+
+	mu  sync.RWMutex
+	cfg config.InsightsConfig
+}
+
+// NewInsightsService 创建资源使用洞察服务
+func NewInsightsService(jobService JobServiceInterface, metricsRepo repository.MetricsRepositoryInterface,
+	cfg config.InsightsConfig) *InsightsService {
+	return &InsightsService{
+		jobService:  jobService,
+		metricsRepo: metricsRepo,
+		now:         time.Now,
+		cfg:         cfg,
+	}
+}
+
+// SetConfig 更新检测阈值，支持热加载
+func (s *InsightsService) SetConfig(cfg config.InsightsConfig) {
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+}
+
+func (s *InsightsService) config() config.InsightsConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// idleWindowKey 同一节点上观测起点相同的作业共用一次指标统计查询
+type idleWindowKey struct {
+	nodeID string
+	since  int64
+}
+
+// DetectIdleJobs 检测运行中的作业分组在最近 lookback 内的 NPU 使用情况，lookback 为 0 时取配置值；
+// issue 非空时只返回包含该问题的作业。运行时长不足 min_running_minutes 的作业不参与检测，
+// 作业在窗口内启动时从启动时间开始观测；scope 为用户的项目可见范围
+func (s *InsightsService) DetectIdleJobs(ctx context.Context, lookback time.Duration, issue string, scope ProjectFilter) (*IdleJobsReport, error) {
+	cfg := s.config()
+	if lookback == 0 {
+		lookback = time.Duration(cfg.LookbackMinutes) * time.Minute
+	}
+	if lookback < time.Minute || lookback > MaxIdleLookback {
+		return nil, fmt.Errorf("%w: lookback must be between 1 minute and %s", ErrInvalidIdleQuery, MaxIdleLookback)
+	}
+	if issue != "" && !slices.Contains(IdleIssues, issue) {
+		return nil, fmt.Errorf("%w: unsupported issue %q", ErrInvalidIdleQuery, issue)
+	}
+
+	now := s.now()
+	since := now.Add(-lookback)
+	startTo := now.Add(-time.Duration(cfg.MinRunningMinutes) * time.Minute).UnixMilli()
+	filter := JobGroupFilter{JobFilter: repository.JobFilter{Statuses: []string{"running"}, StartTo: &startTo, Projects: scope}}
+	report := &IdleJobsReport{GeneratedAt: now, LookbackMinutes: int(lookback / time.Minute), Jobs: []IdleJob{}}
+
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		groups, _, next, err := s.jobService.GetGroupedJobsByCursor(filter, "startTime", "asc", cursor, idleScanChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("query job groups: %w", err)
+		}
+		if err := s.scanGroups(groups, since, now, cfg, report); err != nil {
+			return nil, err
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	var wasted float64
+	flagged := report.Jobs[:0]
+	for _, job := range report.Jobs {
+		if issue == "" || slices.Contains(job.Issues, issue) {
+			flagged = append(flagged, job)
+			wasted += job.WastedCardHours
+		}
+	}
+	report.Jobs = flagged
+	sort.Slice(report.Jobs, func(i, j int) bool {
+		if report.Jobs[i].WastedCardHours != report.Jobs[j].WastedCardHours {
+			return report.Jobs[i].WastedCardHours > report.Jobs[j].WastedCardHours
+		}
+		return report.Jobs[i].JobID < report.Jobs[j].JobID
+	})
+	report.FlaggedJobs = len(report.Jobs)
+	report.WastedCardHours = round2(wasted)
+	return report, nil
+}
+
+// scanGroups 检测一批作业分组，被标记的作业追加到 report.Jobs
+func (s *InsightsService) scanGroups(groups []JobGroup, since, now time.Time, cfg config.InsightsConfig, report *IdleJobsReport) error {
+	mains := make([]JobGroup, 0, len(groups))
+	for _, g := range groups {
+		if g.MainJob.NodeID != nil && g.MainJob.StartTime != nil {
+			mains = append(mains, g)
+		}
+	}
+	if len(mains) == 0 {
+		return nil
+	}
+	jobs := make([]model.Job, 0, len(mains))
+	for _, g := range mains {
+		jobs = append(jobs, g.MainJob)
+	}
+	cards, err := s.jobService.GetJobsNPUCards(jobs)
+	if err != nil {
+		return fmt.Errorf("query npu cards: %w", err)
+	}
+
+	windowStart := func(g JobGroup) time.Time {
+		if start := time.UnixMilli(*g.MainJob.StartTime); start.After(since) {
+			return start
+		}
+		return since
+	}
+	npuIDs := make(map[idleWindowKey]map[int]struct{})
+	for _, g := range mains {
+		key := idleWindowKey{nodeID: *g.MainJob.NodeID, since: windowStart(g).UnixMilli()}
+		for _, card := range cards[g.MainJob.JobID] {
+			if npuIDs[key] == nil {
+				npuIDs[key] = make(map[int]struct{})
+			}
+			npuIDs[key][card.NpuID] = struct{}{}
+		}
+	}
+	stats := make(map[idleWindowKey]map[int][]repository.NPUChipWindowStats, len(npuIDs))
+	for key, set := range npuIDs {
+		ids := make([]int, 0, len(set))
+		for id := range set {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		rows, err := s.metricsRepo.FindNPUMetricWindowStats(key.nodeID, ids, time.UnixMilli(key.since))
+		if err != nil {
+			return fmt.Errorf("query npu metrics: %w", err)
+		}
+		byNPU := make(map[int][]repository.NPUChipWindowStats)
+		for _, row := range rows {
+			byNPU[row.NPUID] = append(byNPU[row.NPUID], row)
+		}
+		stats[key] = byNPU
+	}
+
+	for _, g := range mains {
+		start := windowStart(g)
+		key := idleWindowKey{nodeID: *g.MainJob.NodeID, since: start.UnixMilli()}
+		job, ok := evaluateIdleJob(g, cards[g.MainJob.JobID], stats[key], now.Sub(start), cfg)
+		if !ok {
+			continue
+		}
+		report.ScannedJobs++
+		if len(job.Issues) > 0 {
+			report.Jobs = append(report.Jobs, job)
+		}
+	}
+	return nil
+}
+
+// evaluateIdleJob 汇总作业占用各卡的窗口指标并判定问题类型；没有任何窗口指标时返回 false
+func evaluateIdleJob(g JobGroup, cards []NPUCardInfo, stats map[int][]repository.NPUChipWindowStats,
+	observed time.Duration, cfg config.InsightsConfig) (IdleJob, bool) {
+	job := IdleJob{
+		JobID:         g.MainJob.JobID,
+		JobName:       stringOrEmpty(g.MainJob.JobName),
+		NodeID:        stringOrEmpty(g.MainJob.NodeID),
+		Framework:     stringOrEmpty(g.MainJob.Framework),
+		JobType:       stringOrEmpty(g.MainJob.JobType),
+		GroupID:       g.GroupID,
+		StartTime:     *g.MainJob.StartTime,
+		CardCount:     len(cards),
+		Issues:        []string{},
+		ObservedHours: round2(observed.Hours()),
+	}
+
+	var aicoreSum, hbmUsed, hbmTotal float64
+	minAICore, maxAICore := math.Inf(1), math.Inf(-1)
+	measured := 0
+	for _, card := range cards {
+		cs, total := summarizeCardWindow(card, stats[card.NpuID])
+		job.Cards = append(job.Cards, cs)
+		if cs.AvgAICoreUsage == nil {
+			continue
+		}
+		avg := *cs.AvgAICoreUsage
+		measured++
+		aicoreSum += avg
+		minAICore = math.Min(minAICore, avg)
+		maxAICore = math.Max(maxAICore, avg)
+		if cs.AvgHBMUsageMB != nil && total > 0 {
+			hbmUsed += *cs.AvgHBMUsageMB
+			hbmTotal += total
+		}
+		job.WastedCardHours += observed.Hours() * (100 - math.Min(avg, 100)) / 100
+	}
+	if measured == 0 {
+		return job, false
+	}
+
+	job.AvgAICoreUsage = round2(aicoreSum / float64(measured))
+	job.AICoreSpread = round2(maxAICore - minAICore)
+	job.WastedCardHours = round2(job.WastedCardHours)
+	if hbmTotal > 0 {
+		v := round2(hbmUsed * 100 / hbmTotal)
+		job.AvgHBMUsage = &v
+	}
+
+	switch {
+	case job.AvgAICoreUsage <= float64(cfg.IdleAICorePercent) && job.AvgHBMUsage != nil && *job.AvgHBMUsage >= float64(cfg.IdleHBMPercent):
+		job.Issues = append(job.Issues, IdleIssueHolding)
+	case job.AvgAICoreUsage < float64(cfg.LowAICorePercent):
+		job.Issues = append(job.Issues, IdleIssueLowUtilization)
+	}
+	if measured > 1 && job.AICoreSpread >= float64(cfg.ImbalanceSpreadPercent) {
+		job.Issues = append(job.Issues, IdleIssueImbalanced)
+	}
+	return job, true
+}
+
+// summarizeCardWindow 汇总一张卡的芯片窗口指标，并返回这些芯片的 HBM 总容量。卡详情中带有芯片指标时
+// 只统计这些芯片（与作业详情的芯片映射一致），否则统计整卡
+func summarizeCardWindow(card NPUCardInfo, chips []repository.NPUChipWindowStats) (IdleCardStats, float64) {
+	cs := IdleCardStats{NpuID: card.NpuID}
+	busIDs := make(map[string]bool)
+	for _, m := range card.Metrics {
+		if m.BusID != nil {
+			busIDs[*m.BusID] = true
+		}
+	}
+	if len(busIDs) > 0 {
+		var matched []repository.NPUChipWindowStats
+		for _, chip := range chips {
+			if chip.BusID != nil && busIDs[*chip.BusID] {
+				matched = append(matched, chip)
+			}
+		}
+		if len(matched) > 0 {
+			chips = matched
+		}
+	}
+
+	var aicoreSum, hbmUsed, hbmTotal float64
+	aicoreN, hbmN := 0, 0
+	for _, chip := range chips {
+		cs.Samples += chip.Samples
+		if chip.AvgAICore != nil {
+			aicoreSum += *chip.AvgAICore
+			aicoreN++
+		}
+		if chip.MaxAICore != nil && (cs.MaxAICoreUsage == nil || *chip.MaxAICore > *cs.MaxAICoreUsage) {
+			v := *chip.MaxAICore
+			cs.MaxAICoreUsage = &v
+		}
+		if chip.AvgHBMUsageMB != nil {
+			hbmUsed += *chip.AvgHBMUsageMB
+			hbmN++
+			if chip.HBMTotalMB != nil {
+				hbmTotal += *chip.HBMTotalMB
+			}
+		}
+	}
+	cs.AvgAICoreUsage = average(aicoreSum, aicoreN)
+	if hbmN > 0 {
+		v := round2(hbmUsed)
+		cs.AvgHBMUsageMB = &v
+	}
+	if hbmN > 0 && hbmTotal > 0 {
+		v := round2(hbmUsed * 100 / hbmTotal)
+		cs.AvgHBMUsage = &v
+	}
+	return cs, hbmTotal
+}
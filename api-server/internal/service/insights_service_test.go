@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+var testInsightsConfig = config.InsightsConfig{
+	LookbackMinutes:        60,
+	MinRunningMinutes:      30,
+	IdleAICorePercent:      5,
+	IdleHBMPercent:         10,
+	LowAICorePercent:       30,
+	ImbalanceSpreadPercent: 40,
+}
+
+func windowChip(npuID int, busID string, aicore, hbmUsed, hbmTotal float64) repository.NPUChipWindowStats {
+	return repository.NPUChipWindowStats{
+		NPUID: npuID, BusID: &busID, AvgAICore: &aicore, MaxAICore: &aicore,
+		AvgHBMUsageMB: &hbmUsed, HBMTotalMB: &hbmTotal, Samples: 12,
+	}
+}
+
+func idleGroup(jobID, nodeID string, start time.Time) JobGroup {
+	startMs := start.UnixMilli()
+	return JobGroup{MainJob: model.Job{JobID: jobID, NodeID: &nodeID, StartTime: &startMs}}
+}
+
+func TestInsightsService_DetectIdleJobs(t *testing.T) {
+	mockJobService := new(MockJobServiceForLLM)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewInsightsService(mockJobService, mockMetricsRepo, testInsightsConfig)
+	now := time.Unix(1770373800, 0)
+	svc.now = func() time.Time { return now }
+	since := now.Add(-time.Hour)
+
+	idle := idleGroup("job-idle", "node-1", now.Add(-5*time.Hour))
+	skewed := idleGroup("job-skewed", "node-1", now.Add(-40*time.Minute))
+	busy := idleGroup("job-busy", "node-2", now.Add(-3*time.Hour))
+	noCards := idleGroup("job-nocards", "node-2", now.Add(-3*time.Hour))
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return len(f.Statuses) == 1 && f.Statuses[0] == "running" && *f.StartTo == now.Add(-30*time.Minute).UnixMilli()
+	}), "startTime", "asc", "", idleScanChunkSize).Return([]JobGroup{idle, skewed, busy, noCards}, int64(4), "", nil)
+
+	bus := func(s string) *string { return &s }
+	mockJobService.On("GetJobsNPUCards", mock.Anything).Return(map[string][]NPUCardInfo{
+		// 卡 0 只占用一个芯片，另一芯片的指标不计入
+		"job-idle":    {{NpuID: 0, Metrics: []model.NPUMetric{{BusID: bus("0000:C1:00.0")}}}, {NpuID: 1}},
+		"job-skewed":  {{NpuID: 2}, {NpuID: 3}},
+		"job-busy":    {{NpuID: 0}},
+		"job-nocards": {},
+	}, nil)
+
+	mockMetricsRepo.On("FindNPUMetricWindowStats", "node-1", []int{0, 1}, since).Return([]repository.NPUChipWindowStats{
+		windowChip(0, "0000:C1:00.0", 0, 30000, 32000),
+		windowChip(0, "0000:C2:00.0", 90, 30000, 32000),
+		windowChip(1, "0000:C3:00.0", 2, 28000, 32000),
+	}, nil)
+	mockMetricsRepo.On("FindNPUMetricWindowStats", "node-1", []int{2, 3}, now.Add(-40*time.Minute)).Return([]repository.NPUChipWindowStats{
+		windowChip(2, "0000:C4:00.0", 90, 20000, 32000),
+		windowChip(3, "0000:C5:00.0", 20, 20000, 32000),
+	}, nil)
+	mockMetricsRepo.On("FindNPUMetricWindowStats", "node-2", []int{0}, since).Return([]repository.NPUChipWindowStats{
+		windowChip(0, "0000:C1:00.0", 80, 20000, 32000),
+	}, nil)
+
+	report, err := svc.DetectIdleJobs(context.Background(), 0, "", ProjectFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 60, report.LookbackMinutes)
+	assert.Equal(t, 3, report.ScannedJobs)
+	assert.Equal(t, 2, report.FlaggedJobs)
+	if assert.Len(t, report.Jobs, 2) {
+		first := report.Jobs[0]
+		assert.Equal(t, "job-idle", first.JobID)
+		assert.Equal(t, []string{IdleIssueHolding}, first.Issues)
+		assert.Equal(t, 1.0, first.AvgAICoreUsage)
+		assert.Equal(t, 90.63, *first.AvgHBMUsage)
+		assert.Equal(t, 1.0, first.ObservedHours)
+		assert.Equal(t, 1.98, first.WastedCardHours)
+
+		second := report.Jobs[1]
+		assert.Equal(t, "job-skewed", second.JobID)
+		assert.Equal(t, []string{IdleIssueImbalanced}, second.Issues)
+		assert.Equal(t, 70.0, second.AICoreSpread)
+		assert.Equal(t, 0.67, second.ObservedHours)
+	}
+	assert.Equal(t, 2.58, report.WastedCardHours)
+
+	report, err = svc.DetectIdleJobs(context.Background(), 0, IdleIssueImbalanced, ProjectFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, report.Jobs, 1) {
+		assert.Equal(t, "job-skewed", report.Jobs[0].JobID)
+	}
+}
+
+func TestInsightsService_DetectIdleJobs_Invalid(t *testing.T) {
+	svc := NewInsightsService(new(MockJobServiceForLLM), new(MockMetricsRepository), testInsightsConfig)
+
+	_, err := svc.DetectIdleJobs(context.Background(), 8*24*time.Hour, "", ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidIdleQuery)
+	_, err = svc.DetectIdleJobs(context.Background(), time.Hour, "busy", ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidIdleQuery)
+}
+
+func TestEvaluateIdleJob_LowUtilization(t *testing.T) {
+	g := idleGroup("job-1", "node-1", time.Unix(1770373800, 0))
+	stats := map[int][]repository.NPUChipWindowStats{
+		0: {windowChip(0, "0000:C1:00.0", 10, 1000, 32000)},
+	}
+
+	job, ok := evaluateIdleJob(g, []NPUCardInfo{{NpuID: 0}, {NpuID: 1}}, stats, 2*time.Hour, testInsightsConfig)
+	assert.True(t, ok)
+	// 没有指标的卡不参与判定
+	assert.Equal(t, []string{IdleIssueLowUtilization}, job.Issues)
+	assert.Equal(t, 2, job.CardCount)
+	assert.Nil(t, job.Cards[1].AvgAICoreUsage)
+	assert.Equal(t, 1.8, job.WastedCardHours)
+
+	_, ok = evaluateIdleJob(g, []NPUCardInfo{{NpuID: 1}}, stats, time.Hour, testInsightsConfig)
+	assert.False(t, ok)
+}
+
+func TestInsightsService_DetectIdleJobs_ProjectScope(t *testing.T) {
+	mockJobService := new(MockJobServiceForLLM)
+	svc := NewInsightsService(mockJobService, new(MockMetricsRepository), testInsightsConfig)
+	scope := ProjectFilter{Restricted: true, VisibleIDs: []uint{5}}
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return f.Projects.Restricted && len(f.Projects.VisibleIDs) == 1 && f.Projects.VisibleIDs[0] == 5
+	}), "startTime", "asc", "", idleScanChunkSize).Return([]JobGroup{}, int64(0), "", nil)
+
+	report, err := svc.DetectIdleJobs(context.Background(), 0, "", scope)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Jobs)
+	mockJobService.AssertExpectations(t)
+}
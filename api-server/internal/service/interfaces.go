@@ -1,10 +1,26 @@
 package service
 
 import (
+	"context"
+	"time"
+
 	"github.com/task-monitor/api-server/internal/config"
 	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
 )
 
+// JobFilter 作业列表筛选条件
+type JobFilter = repository.JobFilter
+
+// JobGroupFilter 分组作业列表筛选条件
+type JobGroupFilter = repository.JobGroupFilter
+
+// AnalysisFilter AI 分析字段筛选条件
+type AnalysisFilter = repository.AnalysisFilter
+
+// ProjectFilter 作业归属项目筛选条件
+type ProjectFilter = repository.ProjectFilter
+
 // NodeServiceInterface defines the interface for node service operations
 type NodeServiceInterface interface {
 	GetNodes() ([]model.Node, error)
@@ -13,11 +29,17 @@ type NodeServiceInterface interface {
 	GetNodeStats() (map[string]int64, error)
 }
 
+// SearchServiceInterface 全局搜索服务接口
+type SearchServiceInterface interface {
+	Search(query, searchType string, limit int, scope ProjectFilter) (*SearchResult, error)
+}
+
 // JobGroup 作业分组（按 node_id + pgid 分组）
 type JobGroup struct {
 	MainJob   model.Job   `json:"mainJob"`
 	ChildJobs []model.Job `json:"childJobs"`
-	CardCount *int        `json:"cardCount"` // nil 表示 unknown
+	CardCount *int        `json:"cardCount"`         // nil 表示 unknown
+	GroupID   uint        `json:"groupId,omitempty"` // 持久化分组ID，未启用持久化分组时为空
 }
 
 // NPUCardInfo 进程占用的 NPU 卡信息
@@ -119,6 +141,11 @@ type LLMServiceWithModelInterface interface {
 	AnalyzeJobWithModel(jobID, modelID string) (*AnalysisWithStatus, error)
 }
 
+// LLMModelTesterInterface 支持模型连通性测试的扩展接口
+type LLMModelTesterInterface interface {
+	TestModel(modelID string, override *config.LLMModelConfig) (*LLMModelTestResult, error)
+}
+
 // JobServiceInterface defines the interface for job service operations
 type JobServiceInterface interface {
 	GetJobByID(jobID string) (*model.Job, error)
@@ -126,15 +153,26 @@ type JobServiceInterface interface {
 	GetJobsByNodeID(nodeID string) ([]model.Job, error)
 	GetJobsByStatus(status string) ([]model.Job, error)
 	GetAllJobs() ([]model.Job, error)
-	GetJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error)
-	GetGroupedJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, cardCounts []int, sortBy, sortOrder string, page, pageSize int) ([]JobGroup, int64, error)
-	GetDistinctCardCounts() ([]int, error)
+	GetJobs(filter JobFilter, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error)
+	GetGroupedJobs(filter JobGroupFilter, sortBy, sortOrder string, page, pageSize int) ([]JobGroup, int64, error)
+	GetJobsByCursor(filter JobFilter, sortBy, sortOrder, cursor string, pageSize int) ([]model.Job, int64, string, error)
+	GetGroupedJobsByCursor(filter JobGroupFilter, sortBy, sortOrder, cursor string, pageSize int) ([]JobGroup, int64, string, error)
+	GetDistinctCardCounts(scope ProjectFilter) ([]int, error)
 	GetJobParameters(jobID string) ([]model.Parameter, error)
+	GetJobsParameters(jobIDs []string) (map[string][]model.Parameter, error)
 	GetJobCode(jobID string) ([]model.Code, error)
-	GetJobStats() (map[string]int64, error)
+	GetJobCodes(jobIDs []string) (map[string][]model.Code, error)
+	GetJobsNPUCards(jobs []model.Job) (map[string][]NPUCardInfo, error)
+	GetJobStats(filter JobGroupFilter) (map[string]int64, error)
 	UpdateJobFields(jobID string, fields map[string]interface{}) error
 }
 
+// DistributedJobServiceInterface 跨节点分布式作业查询接口
+type DistributedJobServiceInterface interface {
+	GetDistributedJobs(statuses []string, page, pageSize int, scope ProjectFilter) ([]DistributedJob, int64, error)
+	GetDistributedJobDetail(distributedID string, scope ProjectFilter) (*DistributedJobDetail, error)
+}
+
 // AuthServiceInterface 认证服务接口
 type AuthServiceInterface interface {
 	Login(username, password string) (string, error)
@@ -145,3 +183,71 @@ type AuthServiceInterface interface {
 	ChangePassword(userID uint, newPassword string) error
 	DeleteUser(userID uint, currentUserID uint) error
 }
+
+// SavedViewServiceInterface 保存视图服务接口
+type SavedViewServiceInterface interface {
+	List(userID uint) ([]model.SavedView, error)
+	Get(id uint, userID uint) (*model.SavedView, error)
+	Create(ownerID uint, input SavedViewInput) (*model.SavedView, error)
+	Update(id uint, userID uint, input SavedViewInput) (*model.SavedView, error)
+	Delete(id uint, userID uint) error
+}
+
+// ReportServiceInterface 定时报表服务接口
+type ReportServiceInterface interface {
+	GenerateReport(ctx context.Context, from, to time.Time, topN int, scope ProjectFilter) (*UtilizationReport, error)
+	ListSchedules() ([]model.ReportSchedule, error)
+	CreateSchedule(input ReportScheduleInput, userID uint) (*model.ReportSchedule, error)
+	UpdateSchedule(id uint, input ReportScheduleInput) (*model.ReportSchedule, error)
+	DeleteSchedule(id uint) error
+	RunSchedule(name string) (*model.ReportRun, error)
+	ListRuns(scheduleName string, limit int) ([]model.ReportRun, error)
+	GetRun(id uint) (*model.ReportRun, error)
+}
+
+// StatsServiceInterface 集群利用率统计服务接口
+type StatsServiceInterface interface {
+	GetClusterStats(scope ProjectFilter) (*ClusterStats, error)
+	GetNodeStats(scope ProjectFilter) ([]NodeStats, error)
+	GetTrend(metric string, from, to time.Time, interval string, scope ProjectFilter) (*Trend, error)
+}
+
+// InsightsServiceInterface 资源使用洞察服务接口
+type InsightsServiceInterface interface {
+	DetectIdleJobs(ctx context.Context, lookback time.Duration, issue string, scope ProjectFilter) (*IdleJobsReport, error)
+}
+
+// NodeCardServiceInterface 节点 NPU 卡状态服务接口
+type NodeCardServiceInterface interface {
+	GetNodeCards(nodeID string, scope ProjectFilter) (*NodeCards, error)
+	GetNodeOverview(nodeID string, scope ProjectFilter) (*NodeOverview, error)
+	FindFreeCards(npuModel string, count int, scope ProjectFilter) (*FreeCardsResult, error)
+}
+
+// AccountingServiceInterface 卡时核算服务接口
+type AccountingServiceInterface interface {
+	GetCardHours(ctx context.Context, from, to time.Time, groupBy []string, scope ProjectFilter) (*CardHourReport, error)
+	ListCardHourRecords(ctx context.Context, from, to time.Time, scope ProjectFilter) ([]CardHourRecord, error)
+}
+
+// ProjectServiceInterface 项目管理与作业归属服务接口
+type ProjectServiceInterface interface {
+	VisibleScope(userID uint) (ProjectFilter, error)
+	CanViewJob(jobID string, userID uint) (bool, error)
+	FilterVisibleJobIDs(jobIDs []string, scope ProjectFilter) ([]string, error)
+	List(userID uint) ([]model.Project, error)
+	Get(id, userID uint) (*model.Project, error)
+	Create(userID uint, input ProjectInput) (*model.Project, error)
+	Update(id, userID uint, input ProjectInput) (*model.Project, error)
+	Delete(id, userID uint) error
+	ListMembers(id, userID uint) ([]ProjectMemberInfo, error)
+	AddMember(id, userID uint, input ProjectMemberInput) (*ProjectMemberInfo, error)
+	RemoveMember(id, userID, memberID uint) error
+	ListRules(id, userID uint) ([]model.ProjectRule, error)
+	CreateRule(id, userID uint, input ProjectRuleInput) (*model.ProjectRule, error)
+	UpdateRule(id, ruleID, userID uint, input ProjectRuleInput) (*model.ProjectRule, error)
+	DeleteRule(id, ruleID, userID uint) error
+	GetJobProject(jobID string, userID uint) (*JobProjectInfo, error)
+	AssignJob(jobID string, projectID, userID uint) (*JobProjectInfo, error)
+	ClearJobAssignment(jobID string, userID uint) (*JobProjectInfo, error)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+// ErrCursorSortUnsupported 游标分页只支持按启动时间排序
+var ErrCursorSortUnsupported = errors.New("cursor pagination only supports sortBy=startTime")
+
+// parseCursorParams 校验排序参数并解码游标；token 为空表示第一页。sortOrder 为 asc 时升序，其余降序
+func parseCursorParams(sortBy, sortOrder, token string) (bool, *repository.JobCursor, error) {
+	if sortBy != "" && sortBy != "startTime" {
+		return false, nil, ErrCursorSortUnsupported
+	}
+	desc := sortOrder != "asc"
+	if token == "" {
+		return desc, nil, nil
+	}
+	var cursor repository.JobCursor
+	if err := utils.DecodeCursor(token, &cursor); err != nil {
+		return false, nil, err
+	}
+	if cursor.ID == "" {
+		return false, nil, utils.ErrInvalidCursor
+	}
+	return desc, &cursor, nil
+}
+
+// GetJobsByCursor 按 (start_time, job_id) 键集分页查询作业，返回当前页、筛选后总数与下一页游标（最后一页为空）。
+// 与 offset 分页不同，翻页期间新上报的作业不会导致跳行或重复。
+func (s *JobService) GetJobsByCursor(filter JobFilter, sortBy, sortOrder, cursor string, pageSize int) ([]model.Job, int64, string, error) {
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	desc, after, err := parseCursorParams(sortBy, sortOrder, cursor)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	total, err := s.jobRepo.Count(filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	// 多取一行判断是否还有下一页
+	jobs, err := s.jobRepo.FindByCursor(filter, desc, after, pageSize+1)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	next := ""
+	if len(jobs) > pageSize {
+		jobs = jobs[:pageSize]
+		last := jobs[pageSize-1]
+		next = utils.EncodeCursor(repository.JobCursor{StartTime: last.StartTime, ID: last.JobID})
+	}
+	return jobs, total, next, nil
+}
+
+// GetGroupedJobsByCursor 按主进程 (start_time, job_id) 键集分页查询分组作业，返回当前页、总数与下一页游标
+func (s *JobService) GetGroupedJobsByCursor(filter JobGroupFilter, sortBy, sortOrder, cursor string, pageSize int) ([]JobGroup, int64, string, error) {
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	desc, after, err := parseCursorParams(sortBy, sortOrder, cursor)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	if s.useGroupStore() {
+		records, total, err := s.groupRepo.FindByCursor(filter, desc, after, pageSize+1)
+		if err != nil {
+			return nil, 0, "", fmt.Errorf("find job groups: %w", err)
+		}
+		// 展开时可能丢弃或合并分组，是否有下一页与游标按持久化记录的键集判断
+		next := ""
+		if len(records) > pageSize {
+			records = records[:pageSize]
+			last := records[pageSize-1]
+			next = utils.EncodeCursor(repository.JobCursor{StartTime: last.StartTime, ID: last.RootJobID})
+		}
+		groups, err := s.expandGroupRecords(records)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return groups, total, next, nil
+	}
+
+	all, err := s.buildFilteredGroups(filter, "", "")
+	if err != nil {
+		return nil, 0, "", err
+	}
+	groups := groupsAfterCursor(all, desc, after, pageSize+1)
+	next := ""
+	if len(groups) > pageSize {
+		groups = groups[:pageSize]
+		last := groups[pageSize-1].MainJob
+		next = utils.EncodeCursor(repository.JobCursor{StartTime: last.StartTime, ID: last.JobID})
+	}
+	return groups, int64(len(all)), next, nil
+}
+
+// groupsAfterCursor 内存分组按与 SQL 相同的键集顺序排序，返回游标之后的至多 limit 个分组
+func groupsAfterCursor(groups []JobGroup, desc bool, cursor *repository.JobCursor, limit int) []JobGroup {
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].MainJob, groups[j].MainJob
+		return keysetBefore(a.StartTime, a.JobID, b.StartTime, b.JobID, desc)
+	})
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(groups), func(i int) bool {
+			g := groups[i].MainJob
+			return keysetBefore(cursor.StartTime, cursor.ID, g.StartTime, g.JobID, desc)
+		})
+	}
+	end := start + limit
+	if end > len(groups) {
+		end = len(groups)
+	}
+	return groups[start:end]
+}
+
+// keysetBefore (aStart, aID) 是否排在 (bStart, bID) 之前；与 MySQL 一致，NULL 升序时在前、降序时在后
+func keysetBefore(aStart *int64, aID string, bStart *int64, bID string, desc bool) bool {
+	switch {
+	case aStart == nil && bStart == nil:
+		if desc {
+			return aID > bID
+		}
+		return aID < bID
+	case aStart == nil:
+		return !desc
+	case bStart == nil:
+		return desc
+	case *aStart != *bStart:
+		if desc {
+			return *aStart > *bStart
+		}
+		return *aStart < *bStart
+	case desc:
+		return aID > bID
+	default:
+		return aID < bID
+	}
+}
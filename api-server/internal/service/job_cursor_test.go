@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+func TestJobService_GetJobsByCursor(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), new(MockMetricsRepository))
+
+	t1, t2 := int64(2000), int64(1000)
+	mockJobRepo.On("Count", JobFilter{}).Return(int64(3), nil)
+	mockJobRepo.On("FindByCursor", JobFilter{}, true, (*repository.JobCursor)(nil), 3).
+		Return([]model.Job{{JobID: "job-003", StartTime: &t1}, {JobID: "job-002", StartTime: &t2}, {JobID: "job-001", StartTime: &t2}}, nil)
+
+	jobs, total, next, err := svc.GetJobsByCursor(JobFilter{}, "", "", "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, jobs, 2)
+	require.NotEmpty(t, next)
+
+	var cursor repository.JobCursor
+	require.NoError(t, utils.DecodeCursor(next, &cursor))
+	assert.Equal(t, "job-002", cursor.ID)
+	assert.Equal(t, t2, *cursor.StartTime)
+
+	// 下一页取游标之后的行，不足一页时不再返回游标
+	mockJobRepo.On("FindByCursor", JobFilter{}, true, &cursor, 3).
+		Return([]model.Job{{JobID: "job-001", StartTime: &t2}}, nil)
+	jobs, _, next, err = svc.GetJobsByCursor(JobFilter{}, "startTime", "desc", next, 2)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Empty(t, next)
+}
+
+func TestJobService_GetJobsByCursor_InvalidParams(t *testing.T) {
+	svc := NewJobService(new(MockJobRepository), new(MockParameterRepository), new(MockCodeRepository), new(MockMetricsRepository))
+
+	_, _, _, err := svc.GetJobsByCursor(JobFilter{}, "jobName", "asc", "", 20)
+	assert.True(t, errors.Is(err, ErrCursorSortUnsupported))
+
+	_, _, _, err = svc.GetJobsByCursor(JobFilter{}, "", "", "not-a-cursor!", 20)
+	assert.True(t, errors.Is(err, utils.ErrInvalidCursor))
+}
+
+func TestJobService_GetGroupedJobsByCursor_InMemoryWalksAllPages(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+
+	nodeID := "node-001"
+	ppid := int64(1)
+	t1, t2 := int64(2000), int64(1000)
+	pids := []int64{100, 101, 102, 103}
+	jobs := []model.Job{
+		{JobID: "job-a", NodeID: &nodeID, PID: &pids[0], PPID: &ppid, StartTime: &t2},
+		{JobID: "job-b", NodeID: &nodeID, PID: &pids[1], PPID: &ppid, StartTime: &t1},
+		{JobID: "job-c", NodeID: &nodeID, PID: &pids[2], PPID: &ppid, StartTime: &t2},
+		{JobID: "job-d", NodeID: &nodeID, PID: &pids[3], PPID: &ppid}, // start_time 为空，降序排最后
+	}
+	mockJobRepo.On("FindFiltered", JobFilter{}, "", "").Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{}, nil)
+
+	var seen []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		groups, total, next, err := svc.GetGroupedJobsByCursor(JobGroupFilter{}, "", "", cursor, 3)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		for _, g := range groups {
+			seen = append(seen, g.MainJob.JobID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, []string{"job-b", "job-c", "job-a", "job-d"}, seen)
+}
+
+func TestJobService_GetGroupedJobsByCursor_GroupStoreCursorFromRecords(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockGroupRepo := new(MockJobGroupRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+	svc.SetJobGroupRepository(mockGroupRepo)
+	svc.groupsReady.Store(true)
+
+	nodeID := "node-001"
+	pid, ppid := int64(100), int64(1)
+	t1, t2, t3 := int64(3000), int64(2000), int64(1000)
+	mockGroupRepo.On("FindByCursor", JobGroupFilter{}, true, (*repository.JobCursor)(nil), 3).Return([]model.JobGroupRecord{
+		{ID: 7, NodeID: "node-001", RootJobID: "job-001", StartTime: &t1},
+		{ID: 8, NodeID: "node-001", RootJobID: "job-002", StartTime: &t2},
+		{ID: 9, NodeID: "node-001", RootJobID: "job-003", StartTime: &t3},
+	}, int64(3), nil)
+	// 分组 8 的成员作业已被清理，展开后本页只剩一个分组，但仍有下一页
+	mockGroupRepo.On("FindMembersByGroupIDs", []uint{7, 8}).Return([]model.JobGroupMember{{JobID: "job-001", GroupID: 7}}, nil)
+	mockJobRepo.On("FindByIDs", []string{"job-001"}).
+		Return([]model.Job{{JobID: "job-001", NodeID: &nodeID, PID: &pid, PPID: &ppid, StartTime: &t1}}, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{}, nil)
+
+	groups, total, next, err := svc.GetGroupedJobsByCursor(JobGroupFilter{}, "", "", "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "job-001", groups[0].MainJob.JobID)
+	require.NotEmpty(t, next)
+	var cursor repository.JobCursor
+	require.NoError(t, utils.DecodeCursor(next, &cursor))
+	assert.Equal(t, "job-002", cursor.ID)
+	assert.Equal(t, t2, *cursor.StartTime)
+}
+
+func TestKeysetBefore_NullOrdering(t *testing.T) {
+	ts := int64(1)
+	assert.True(t, keysetBefore(&ts, "a", nil, "b", true))
+	assert.False(t, keysetBefore(&ts, "a", nil, "b", false))
+	assert.True(t, keysetBefore(nil, "b", nil, "a", true))
+	assert.True(t, keysetBefore(nil, "a", nil, "b", false))
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// SetJobGroupRepository 启用持久化分组：分组列表、卡数筛选、分页与统计改为在 job_groups 表上通过 SQL 完成。
+// 首次同步完成前仍使用内存 Union-Find 计算。
+func (s *JobService) SetJobGroupRepository(repo repository.JobGroupRepositoryInterface) {
+	s.groupRepo = repo
+}
+
+// SetGroupsChangedHook 设置分组因作业变更被重写后的回调（如失效查询缓存）
+func (s *JobService) SetGroupsChangedHook(fn func()) {
+	s.onGroupsChanged = fn
+}
+
+// useGroupStore 持久化分组已启用且至少完成过一次同步
+func (s *JobService) useGroupStore() bool {
+	return s.groupRepo != nil && s.groupsReady.Load()
+}
+
+// StartJobGroupSync 后台维护持久化分组：启动时立即同步一次（尚未建立时全量重建），之后每隔 interval 增量同步
+func (s *JobService) StartJobGroupSync(ctx context.Context, interval time.Duration) {
+	go func() {
+		s.syncAndLog()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.syncAndLog()
+			}
+		}
+	}()
+}
+
+func (s *JobService) syncAndLog() {
+	start := time.Now()
+	changed, err := s.SyncJobGroups()
+	if err != nil {
+		slog.Error("job group sync failed", "error", err)
+		return
+	}
+	if changed > 0 {
+		slog.Info("job groups synced", "changed_jobs", changed, "elapsed_ms", time.Since(start).Milliseconds())
+	}
+}
+
+// SyncJobGroups 增量同步持久化分组，返回本轮检测到的作业变更数。
+// 重新计算的范围：上次同步后新增或更新的作业、它们的直接父子/同进程组作业、以及这些作业原本所在分组的全部成员；
+// 运行中的分组每轮都重新计算，使卡数跟随 npu_processes 的变化。
+func (s *JobService) SyncJobGroups() (int, error) {
+	state, err := s.groupRepo.GetSyncState()
+	if err != nil {
+		return 0, fmt.Errorf("get sync state: %w", err)
+	}
+	if state == nil || state.RebuiltAt == nil {
+		return s.RebuildJobGroups()
+	}
+
+	changed, err := s.jobRepo.FindChangedSince(state.Watermark)
+	if err != nil {
+		return 0, fmt.Errorf("find changed jobs: %w", err)
+	}
+	// 水位按 >= 查询以免漏掉同一时刻写入的作业，边界上的作业会被重复处理，只有晚于水位的才计为新变更
+	watermark := state.Watermark
+	fresh := 0
+	for _, job := range changed {
+		t := jobChangeTime(job)
+		if t.After(state.Watermark) {
+			fresh++
+		}
+		if t.After(watermark) {
+			watermark = t
+		}
+	}
+
+	runningIDs, err := s.groupRepo.FindGroupIDsByStatus("running")
+	if err != nil {
+		return 0, fmt.Errorf("find running groups: %w", err)
+	}
+	runningMembers, err := s.groupRepo.FindMembersByGroupIDs(runningIDs)
+	if err != nil {
+		return 0, fmt.Errorf("find running group members: %w", err)
+	}
+	runningJobs, err := s.jobRepo.FindByIDs(memberJobIDs(runningMembers))
+	if err != nil {
+		return 0, fmt.Errorf("find running group jobs: %w", err)
+	}
+
+	seedByNode := make(map[string][]model.Job)
+	for _, job := range append(changed, runningJobs...) {
+		nid := normalizeNodeID(job.NodeID)
+		seedByNode[nid] = append(seedByNode[nid], job)
+	}
+	for nid, seed := range seedByNode {
+		if err := s.regroupNode(nid, seed); err != nil {
+			return 0, fmt.Errorf("regroup node %q: %w", nid, err)
+		}
+	}
+
+	state.Watermark = watermark
+	if err := s.groupRepo.SaveSyncState(state); err != nil {
+		return 0, fmt.Errorf("save sync state: %w", err)
+	}
+	s.groupsReady.Store(true)
+	if fresh > 0 && s.onGroupsChanged != nil {
+		s.onGroupsChanged()
+	}
+	return fresh, nil
+}
+
+// RebuildJobGroups 按节点全量重建持久化分组，返回参与重建的作业数
+func (s *JobService) RebuildJobGroups() (int, error) {
+	// 先记录水位再重建，重建期间写入的作业在下一轮增量同步中处理
+	watermark, err := s.jobRepo.MaxChangeTime()
+	if err != nil {
+		return 0, fmt.Errorf("get max change time: %w", err)
+	}
+	nodeIDs, err := s.jobRepo.DistinctNodeIDs()
+	if err != nil {
+		return 0, fmt.Errorf("find node ids: %w", err)
+	}
+
+	total := 0
+	for _, nid := range nodeIDs {
+		jobs, err := s.jobRepo.FindByNodeForGrouping(nid)
+		if err != nil {
+			return 0, fmt.Errorf("find jobs of node %q: %w", nid, err)
+		}
+		oldIDs, err := s.groupRepo.FindGroupIDsByNode(nid)
+		if err != nil {
+			return 0, fmt.Errorf("find groups of node %q: %w", nid, err)
+		}
+		if err := s.replaceGroups(oldIDs, jobs); err != nil {
+			return 0, fmt.Errorf("rebuild node %q: %w", nid, err)
+		}
+		total += len(jobs)
+	}
+	if err := s.groupRepo.DeleteGroupsExceptNodes(nodeIDs); err != nil {
+		return 0, fmt.Errorf("delete stale groups: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.groupRepo.SaveSyncState(&model.JobGroupSyncState{Watermark: watermark, RebuiltAt: &now}); err != nil {
+		return 0, fmt.Errorf("save sync state: %w", err)
+	}
+	s.groupsReady.Store(true)
+	slog.Info("job groups rebuilt", "nodes", len(nodeIDs), "jobs", total)
+	if s.onGroupsChanged != nil {
+		s.onGroupsChanged()
+	}
+	return total, nil
+}
+
+// regroupNode 重新计算节点上受 seed 作业影响的分组
+func (s *JobService) regroupNode(nodeID string, seed []model.Job) error {
+	byID := make(map[string]model.Job, len(seed))
+	var pids, ppids, pgids []int64
+	for _, job := range seed {
+		byID[job.JobID] = job
+		if job.PID != nil {
+			pids = append(pids, *job.PID)
+		}
+		if job.PPID != nil {
+			ppids = append(ppids, *job.PPID)
+		}
+		if job.PGID != nil && *job.PGID != 0 {
+			pgids = append(pgids, *job.PGID)
+		}
+	}
+
+	relatives, err := s.jobRepo.FindRelatives(nodeID, dedupeInt64(pids), dedupeInt64(ppids), dedupeInt64(pgids))
+	if err != nil {
+		return fmt.Errorf("find relatives: %w", err)
+	}
+	for _, job := range relatives {
+		byID[job.JobID] = job
+	}
+
+	// 受影响作业原本所在的分组整体参与重算，分组可能因新的父子关系合并或拆分
+	jobIDs := make([]string, 0, len(byID))
+	for id := range byID {
+		jobIDs = append(jobIDs, id)
+	}
+	members, err := s.groupRepo.FindMembersByJobIDs(jobIDs)
+	if err != nil {
+		return fmt.Errorf("find members: %w", err)
+	}
+	groupIDs := make([]uint, 0, len(members))
+	seenGroup := make(map[uint]bool)
+	for _, m := range members {
+		if !seenGroup[m.GroupID] {
+			seenGroup[m.GroupID] = true
+			groupIDs = append(groupIDs, m.GroupID)
+		}
+	}
+	groupMembers, err := s.groupRepo.FindMembersByGroupIDs(groupIDs)
+	if err != nil {
+		return fmt.Errorf("find group members: %w", err)
+	}
+	var missing []string
+	for _, id := range memberJobIDs(groupMembers) {
+		if _, ok := byID[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	others, err := s.jobRepo.FindByIDs(missing)
+	if err != nil {
+		return fmt.Errorf("find group jobs: %w", err)
+	}
+	for _, job := range others {
+		byID[job.JobID] = job
+	}
+
+	jobs := make([]model.Job, 0, len(byID))
+	for _, job := range byID {
+		jobs = append(jobs, job)
+	}
+	sortJobsForGrouping(jobs)
+	return s.replaceGroups(groupIDs, jobs)
+}
+
+// replaceGroups 对 jobs 计算分组并替换 oldGroupIDs 对应的旧分组
+func (s *JobService) replaceGroups(oldGroupIDs []uint, jobs []model.Job) error {
+	groups, clusters, err := s.buildGroupedJobsWithClusters(jobs)
+	if err != nil {
+		return err
+	}
+
+	records := make([]repository.JobGroupWithMembers, 0, len(groups))
+	for i, group := range groups {
+		cluster := clusters[i]
+		jobIDs := make([]string, 0, len(cluster.jobs))
+		for _, idx := range cluster.jobs {
+			jobIDs = append(jobIDs, jobs[idx].JobID)
+		}
+		main := group.MainJob
+		records = append(records, repository.JobGroupWithMembers{
+			Group: model.JobGroupRecord{
+				NodeID:    cluster.nid,
+				RootJobID: main.JobID,
+				JobName:   main.JobName,
+				JobType:   main.JobType,
+				Framework: main.Framework,
+				Status:    main.Status,
+				StartTime: main.StartTime,
+				EndTime:   main.EndTime,
+				CardCount: group.CardCount,
+				JobCount:  len(cluster.jobs),
+				Hidden:    isStopNameGroup(group),
+			},
+			JobIDs: jobIDs,
+		})
+	}
+	return s.groupRepo.ReplaceGroups(oldGroupIDs, records)
+}
+
+// getGroupedJobsFromStore 在持久化分组上筛选、排序、分页，再按当前数据组装本页分组的子进程与卡数
+func (s *JobService) getGroupedJobsFromStore(filter repository.JobGroupFilter, sortBy, sortOrder string, offset, limit int) ([]JobGroup, int64, error) {
+	records, total, err := s.groupRepo.Find(filter, sortBy, sortOrder, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find job groups: %w", err)
+	}
+	groups, err := s.expandGroupRecords(records)
+	if err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// expandGroupRecords 加载分组成员并组装 JobGroup，结果保持 records 的顺序
+func (s *JobService) expandGroupRecords(records []model.JobGroupRecord) ([]JobGroup, error) {
+	if len(records) == 0 {
+		return []JobGroup{}, nil
+	}
+
+	groupIDs := make([]uint, len(records))
+	rootOf := make(map[uint]string, len(records))
+	for i, r := range records {
+		groupIDs[i] = r.ID
+		rootOf[r.ID] = r.RootJobID
+	}
+	members, err := s.groupRepo.FindMembersByGroupIDs(groupIDs)
+	if err != nil {
+		return nil, fmt.Errorf("find group members: %w", err)
+	}
+	groupOf := make(map[string]uint, len(members))
+	for _, m := range members {
+		groupOf[m.JobID] = m.GroupID
+	}
+	jobs, err := s.jobRepo.FindByIDs(memberJobIDs(members))
+	if err != nil {
+		return nil, fmt.Errorf("find group jobs: %w", err)
+	}
+	sortJobsForGrouping(jobs)
+
+	built, err := s.buildGroupedJobs(jobs)
+	if err != nil {
+		return nil, err
+	}
+	// 同步间隔内进程树可能已变化，同一持久化分组算出多个分组时优先取根作业所在的那个
+	byGroup := make(map[uint]JobGroup, len(built))
+	for _, g := range built {
+		id, ok := groupOf[g.MainJob.JobID]
+		if !ok {
+			continue
+		}
+		if _, exists := byGroup[id]; !exists || g.MainJob.JobID == rootOf[id] {
+			byGroup[id] = g
+		}
+	}
+
+	result := make([]JobGroup, 0, len(records))
+	for _, r := range records {
+		g, ok := byGroup[r.ID]
+		if !ok {
+			continue
+		}
+		g.GroupID = r.ID
+		result = append(result, g)
+	}
+	return result, nil
+}
+
+// countGroupsFromStore 从持久化分组读取满足筛选条件的分组按状态、类型、框架聚合的数量
+func (s *JobService) countGroupsFromStore(filter JobGroupFilter) ([]JobGroupCount, error) {
+	rows, err := s.groupRepo.CountByStatusTypeFramework(filter)
+	if err != nil {
+		return nil, fmt.Errorf("count job groups: %w", err)
+	}
+	counts := make([]JobGroupCount, len(rows))
+	for i, r := range rows {
+		counts[i] = JobGroupCount{Status: r.Status, JobType: r.JobType, Framework: r.Framework, Count: r.Count}
+	}
+	return counts, nil
+}
+
+// sortJobsForGrouping 与 FindFiltered 默认排序一致（start_time DESC, job_id DESC），保证分组顺序与主进程选择稳定
+func sortJobsForGrouping(jobs []model.Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := jobs[i].StartTime, jobs[j].StartTime
+		switch {
+		case a != nil && b != nil && *a != *b:
+			return *a > *b
+		case a == nil && b != nil:
+			return false
+		case a != nil && b == nil:
+			return true
+		}
+		return jobs[i].JobID > jobs[j].JobID
+	})
+}
+
+// jobChangeTime 作业最近一次创建或更新的时间
+func jobChangeTime(job model.Job) time.Time {
+	if job.UpdatedAt != nil && job.UpdatedAt.After(job.CreatedAt) {
+		return *job.UpdatedAt
+	}
+	return job.CreatedAt
+}
+
+func memberJobIDs(members []model.JobGroupMember) []string {
+	ids := make([]string, len(members))
+	for i, m := range members {
+		ids[i] = m.JobID
+	}
+	return ids
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// MockJobGroupRepository is a mock implementation of JobGroupRepository
+type MockJobGroupRepository struct {
+	mock.Mock
+}
+
+func (m *MockJobGroupRepository) Find(filter repository.JobGroupFilter, sortBy, sortOrder string, limit, offset int) ([]model.JobGroupRecord, int64, error) {
+	args := m.Called(filter, sortBy, sortOrder, limit, offset)
+	return args.Get(0).([]model.JobGroupRecord), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockJobGroupRepository) FindByCursor(filter repository.JobGroupFilter, desc bool, cursor *repository.JobCursor, limit int) ([]model.JobGroupRecord, int64, error) {
+	args := m.Called(filter, desc, cursor, limit)
+	return args.Get(0).([]model.JobGroupRecord), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockJobGroupRepository) DistinctCardCounts(projects repository.ProjectFilter) ([]int, error) {
+	args := m.Called(projects)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockJobGroupRepository) CountByStatusTypeFramework(filter repository.JobGroupFilter) ([]repository.JobGroupCountRow, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]repository.JobGroupCountRow), args.Error(1)
+}
+
+func (m *MockJobGroupRepository) FindMembersByJobIDs(jobIDs []string) ([]model.JobGroupMember, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.JobGroupMember), args.Error(1)
+}
+
+func (m *MockJobGroupRepository) FindMembersByGroupIDs(groupIDs []uint) ([]model.JobGroupMember, error) {
+	args := m.Called(groupIDs)
+	return args.Get(0).([]model.JobGroupMember), args.Error(1)
+}
+
+func (m *MockJobGroupRepository) FindGroupIDsByNode(nodeID string) ([]uint, error) {
+	args := m.Called(nodeID)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockJobGroupRepository) FindGroupIDsByStatus(status string) ([]uint, error) {
+	args := m.Called(status)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockJobGroupRepository) ReplaceGroups(oldGroupIDs []uint, groups []repository.JobGroupWithMembers) error {
+	args := m.Called(oldGroupIDs, groups)
+	return args.Error(0)
+}
+
+func (m *MockJobGroupRepository) DeleteGroupsExceptNodes(nodeIDs []string) error {
+	args := m.Called(nodeIDs)
+	return args.Error(0)
+}
+
+func (m *MockJobGroupRepository) GetSyncState() (*model.JobGroupSyncState, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.JobGroupSyncState), args.Error(1)
+}
+
+func (m *MockJobGroupRepository) SaveSyncState(state *model.JobGroupSyncState) error {
+	args := m.Called(state)
+	return args.Error(0)
+}
+
+func TestJobService_SyncJobGroups_RebuildWhenEmpty(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockGroupRepo := new(MockJobGroupRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+	svc.SetJobGroupRepository(mockGroupRepo)
+	hookCalls := 0
+	svc.SetGroupsChangedHook(func() { hookCalls++ })
+
+	nodeID := "node-001"
+	pid1, pid2, pid3 := int64(100), int64(101), int64(200)
+	ppid1, ppid2 := int64(1), int64(100)
+	startTime := int64(1770373780000)
+	running := "running"
+	bash := "bash"
+	jobs := []model.Job{
+		{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid2, StartTime: &startTime, Status: &running},
+		{JobID: "job-001", NodeID: &nodeID, PID: &pid1, PPID: &ppid1, StartTime: &startTime, Status: &running},
+		{JobID: "job-003", NodeID: &nodeID, PID: &pid3, PPID: &ppid1, StartTime: &startTime, Status: &running, ProcessName: &bash},
+	}
+	watermark := time.Date(2026, 2, 6, 10, 0, 0, 0, time.UTC)
+
+	mockGroupRepo.On("GetSyncState").Return(nil, nil)
+	mockJobRepo.On("MaxChangeTime").Return(watermark, nil)
+	mockJobRepo.On("DistinctNodeIDs").Return([]string{"node-001"}, nil)
+	mockJobRepo.On("FindByNodeForGrouping", "node-001").Return(jobs, nil)
+	mockGroupRepo.On("FindGroupIDsByNode", "node-001").Return([]uint{3}, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{100: {0}, 101: {1}}, nil)
+	var written []repository.JobGroupWithMembers
+	mockGroupRepo.On("ReplaceGroups", []uint{3}, mock.Anything).
+		Run(func(args mock.Arguments) { written = args.Get(1).([]repository.JobGroupWithMembers) }).
+		Return(nil)
+	mockGroupRepo.On("DeleteGroupsExceptNodes", []string{"node-001"}).Return(nil)
+	mockGroupRepo.On("SaveSyncState", mock.MatchedBy(func(s *model.JobGroupSyncState) bool {
+		return s.Watermark.Equal(watermark) && s.RebuiltAt != nil
+	})).Return(nil)
+
+	changed, err := svc.SyncJobGroups()
+	require.NoError(t, err)
+	assert.Equal(t, 3, changed)
+	assert.True(t, svc.useGroupStore())
+	assert.Equal(t, 1, hookCalls)
+
+	require.Len(t, written, 2)
+	byRoot := make(map[string]repository.JobGroupWithMembers)
+	for _, g := range written {
+		byRoot[g.Group.RootJobID] = g
+	}
+	tree := byRoot["job-001"]
+	assert.Equal(t, "node-001", tree.Group.NodeID)
+	assert.ElementsMatch(t, []string{"job-001", "job-002"}, tree.JobIDs)
+	assert.Equal(t, 2, tree.Group.JobCount)
+	assert.Equal(t, 2, *tree.Group.CardCount)
+	assert.False(t, tree.Group.Hidden)
+	// 纯停止词进程组写入但标记为隐藏
+	assert.True(t, byRoot["job-003"].Group.Hidden)
+	mockGroupRepo.AssertExpectations(t)
+}
+
+func TestJobService_SyncJobGroups_IncrementalRegroupsAffectedGroups(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockGroupRepo := new(MockJobGroupRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+	svc.SetJobGroupRepository(mockGroupRepo)
+	hookCalls := 0
+	svc.SetGroupsChangedHook(func() { hookCalls++ })
+
+	nodeID := "node-001"
+	pid1, pid2, pid3 := int64(100), int64(101), int64(102)
+	ppid1, ppid2 := int64(1), int64(100)
+	startTime := int64(1770373780000)
+	running := "running"
+	watermark := time.Date(2026, 2, 6, 10, 0, 0, 0, time.UTC)
+	rebuiltAt := watermark
+	parent := model.Job{JobID: "job-001", NodeID: &nodeID, PID: &pid1, PPID: &ppid1, StartTime: &startTime, Status: &running, CreatedAt: watermark.Add(-time.Hour)}
+	child := model.Job{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid2, StartTime: &startTime, Status: &running, CreatedAt: watermark.Add(-time.Hour)}
+	// 新上报的子进程，父进程已在分组 7 中
+	newChild := model.Job{JobID: "job-003", NodeID: &nodeID, PID: &pid3, PPID: &ppid2, StartTime: &startTime, Status: &running, CreatedAt: watermark.Add(time.Minute)}
+
+	mockGroupRepo.On("GetSyncState").Return(&model.JobGroupSyncState{ID: 1, Watermark: watermark, RebuiltAt: &rebuiltAt}, nil)
+	mockJobRepo.On("FindChangedSince", watermark).Return([]model.Job{newChild}, nil)
+	mockGroupRepo.On("FindGroupIDsByStatus", "running").Return([]uint{}, nil)
+	mockGroupRepo.On("FindMembersByGroupIDs", []uint{}).Return([]model.JobGroupMember{}, nil)
+	mockJobRepo.On("FindByIDs", []string{}).Return([]model.Job{}, nil)
+	mockJobRepo.On("FindRelatives", "node-001", []int64{102}, []int64{100}, []int64{}).Return([]model.Job{parent}, nil)
+	mockGroupRepo.On("FindMembersByJobIDs", mock.MatchedBy(func(ids []string) bool { return len(ids) == 2 })).
+		Return([]model.JobGroupMember{{JobID: "job-001", GroupID: 7}}, nil)
+	mockGroupRepo.On("FindMembersByGroupIDs", []uint{7}).
+		Return([]model.JobGroupMember{{JobID: "job-001", GroupID: 7}, {JobID: "job-002", GroupID: 7}}, nil)
+	mockJobRepo.On("FindByIDs", []string{"job-002"}).Return([]model.Job{child}, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{100: {0}, 101: {1}, 102: {2}}, nil)
+	var written []repository.JobGroupWithMembers
+	mockGroupRepo.On("ReplaceGroups", []uint{7}, mock.Anything).
+		Run(func(args mock.Arguments) { written = args.Get(1).([]repository.JobGroupWithMembers) }).
+		Return(nil)
+	mockGroupRepo.On("SaveSyncState", mock.MatchedBy(func(s *model.JobGroupSyncState) bool {
+		return s.Watermark.Equal(newChild.CreatedAt)
+	})).Return(nil)
+
+	changed, err := svc.SyncJobGroups()
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	assert.Equal(t, 1, hookCalls)
+	require.Len(t, written, 1)
+	assert.Equal(t, "job-001", written[0].Group.RootJobID)
+	assert.ElementsMatch(t, []string{"job-001", "job-002", "job-003"}, written[0].JobIDs)
+	assert.Equal(t, 3, *written[0].Group.CardCount)
+	mockJobRepo.AssertNotCalled(t, "FindAll")
+	mockGroupRepo.AssertExpectations(t)
+}
+
+func TestJobService_GetGroupedJobs_FromGroupStore(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockGroupRepo := new(MockJobGroupRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+	svc.SetJobGroupRepository(mockGroupRepo)
+	svc.groupsReady.Store(true)
+
+	nodeID := "node-001"
+	pid1, pid2 := int64(100), int64(101)
+	ppid1, ppid2 := int64(1), int64(100)
+	startTime := int64(1770373780000)
+	running := "running"
+	jobs := []model.Job{
+		{JobID: "job-001", NodeID: &nodeID, PID: &pid1, PPID: &ppid1, StartTime: &startTime, Status: &running},
+		{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid2, StartTime: &startTime, Status: &running},
+	}
+	filter := JobGroupFilter{JobFilter: JobFilter{NodeIDs: []string{"node-001"}, Statuses: []string{"running"}}, CardCounts: []int{2}}
+
+	mockGroupRepo.On("Find", filter, "startTime", "desc", 10, 10).
+		Return([]model.JobGroupRecord{{ID: 7, NodeID: "node-001", RootJobID: "job-001"}}, int64(11), nil)
+	mockGroupRepo.On("FindMembersByGroupIDs", []uint{7}).
+		Return([]model.JobGroupMember{{JobID: "job-001", GroupID: 7}, {JobID: "job-002", GroupID: 7}}, nil)
+	mockJobRepo.On("FindByIDs", []string{"job-001", "job-002"}).Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{100: {0}, 101: {1}}, nil)
+
+	groups, total, err := svc.GetGroupedJobs(filter, "startTime", "desc", 2, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), total)
+	require.Len(t, groups, 1)
+	assert.Equal(t, uint(7), groups[0].GroupID)
+	assert.Equal(t, "job-001", groups[0].MainJob.JobID)
+	assert.Len(t, groups[0].ChildJobs, 1)
+	assert.Equal(t, 2, *groups[0].CardCount)
+	mockJobRepo.AssertNotCalled(t, "FindFiltered")
+}
+
+func TestJobService_GetJobStats_FromGroupStore(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockGroupRepo := new(MockJobGroupRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), new(MockMetricsRepository))
+	svc.SetJobGroupRepository(mockGroupRepo)
+	svc.groupsReady.Store(true)
+
+	mockGroupRepo.On("CountByStatusTypeFramework", repository.JobGroupFilter{}).Return([]repository.JobGroupCountRow{
+		{Status: "failed", JobType: "training", Framework: "pytorch", Count: 1},
+		{Status: "running", JobType: "inference", Framework: "vllm", Count: 2},
+		{Status: "running", JobType: "training", Framework: "pytorch", Count: 3},
+		{Status: "unknown", JobType: "unknown", Framework: "unknown", Count: 4},
+	}, nil)
+
+	stats, err := svc.GetJobStats(JobGroupFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), stats["total"])
+	assert.Equal(t, int64(5), stats["running"])
+	assert.Equal(t, int64(1), stats["failed"])
+	assert.Equal(t, int64(0), stats["completed"])
+	mockJobRepo.AssertNotCalled(t, "FindAll")
+}
@@ -2,13 +2,23 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"sort"
+	"strings"
+	"sync/atomic"
 
 	"github.com/task-monitor/api-server/internal/model"
 	"github.com/task-monitor/api-server/internal/repository"
 )
 
+// ErrAnalysisFilterUnsupported 未配置 AI 分析仓库时不支持按分析字段筛选
+var ErrAnalysisFilterUnsupported = errors.New("analysis filters are not supported")
+
+// ErrProjectFilterUnsupported 未配置项目仓库且未启用持久化分组时不支持按归属项目筛选
+var ErrProjectFilterUnsupported = errors.New("project filters are not supported")
+
 // chipSnapshot 与 agent 端 chipSnapshot 结构一致，用于解析 card_metrics_snapshot JSON
 type chipSnapshot struct {
 	BusID              string  `json:"busId"`
@@ -26,6 +36,13 @@ type JobService struct {
 	paramRepo   repository.ParameterRepositoryInterface
 	codeRepo    repository.CodeRepositoryInterface
 	metricsRepo repository.MetricsRepositoryInterface
+
+	groupRepo       repository.JobGroupRepositoryInterface // 可选：持久化分组
+	groupsReady     atomic.Bool
+	onGroupsChanged func()
+
+	analysisRepo repository.JobAnalysisRepositoryInterface // 可选：按 AI 分析字段筛选分组
+	projectRepo  repository.ProjectRepositoryInterface     // 可选：未启用持久化分组时按归属项目筛选分组
 }
 
 // NewJobService 创建作业服务
@@ -43,6 +60,16 @@ func NewJobService(
 	}
 }
 
+// SetJobAnalysisRepository 设置 AI 分析仓库，未设置时分组列表不支持分析字段筛选
+func (s *JobService) SetJobAnalysisRepository(repo repository.JobAnalysisRepositoryInterface) {
+	s.analysisRepo = repo
+}
+
+// SetProjectRepository 设置项目仓库，未设置且未启用持久化分组时分组列表不支持项目筛选
+func (s *JobService) SetProjectRepository(repo repository.ProjectRepositoryInterface) {
+	s.projectRepo = repo
+}
+
 // GetJobByID 根据ID获取作业
 func (s *JobService) GetJobByID(jobID string) (*model.Job, error) {
 	return s.jobRepo.FindByID(jobID)
@@ -144,82 +171,197 @@ func (s *JobService) GetJobDetail(jobID string, aggregate bool) (*JobDetailRespo
 	}
 
 	// 2. 按 npu_id 去重（取最大显存占用），同时收集 chip_id 集合用于精确匹配
-	cardMemory := make(map[int]float64)
-	procChipIDs := make(map[int]map[int]struct{}) // npu_id -> {chip_id: {}}
+	usage := collectCardUsage(npuProcs)
+
+	if len(usage.npuIDs) > 0 {
+		// 3. 查询卡详情
+		var metrics []model.NPUMetric
+		// 已停止的作业：查询运行期间 HBM 峰值快照，反映真实使用量
+		if isTerminalJobStatus(job.Status) && job.StartTime != nil && job.EndTime != nil && *job.EndTime > 0 {
+			metrics, err = s.metricsRepo.FindNPUMetricsPeakInPeriod(nodeID, usage.npuIDs, *job.StartTime, *job.EndTime)
+		} else {
+			metrics, err = s.metricsRepo.FindLatestNPUMetrics(nodeID, usage.npuIDs)
+		}
+		if err != nil {
+			metrics = nil
+		}
+		// 4. 组装 NPUCardInfo（按 npu_id 有序输出）
+		resp.NPUCards = usage.cards(nodeID, metrics, npuProcs, aggregate)
+	}
+
+	// 5. 查关联 NPU 进程（同 pgid 范围内 Union-Find），仅 aggregate 模式
+	if aggregate && job.PGID != nil {
+		resp.RelatedJobs = s.findRelatedNPUJobs(job)
+	}
+
+	return resp, nil
+}
+
+// cardUsage 进程占用的 NPU 卡：按 npu_id 去重（取最大显存占用）并记录各卡被占用的 chip_id
+type cardUsage struct {
+	npuIDs      []int // 有序
+	memory      map[int]float64
+	procChipIDs map[int]map[int]struct{} // npu_id -> {chip_id: {}}
+}
+
+func collectCardUsage(npuProcs []model.NPUProcess) cardUsage {
+	usage := cardUsage{
+		memory:      make(map[int]float64),
+		procChipIDs: make(map[int]map[int]struct{}),
+	}
 	for _, np := range npuProcs {
 		if np.NPUID == nil {
 			continue
 		}
 		npuID := *np.NPUID
 		if np.ChipID != nil {
-			if _, ok := procChipIDs[npuID]; !ok {
-				procChipIDs[npuID] = make(map[int]struct{})
+			if _, ok := usage.procChipIDs[npuID]; !ok {
+				usage.procChipIDs[npuID] = make(map[int]struct{})
 			}
-			procChipIDs[npuID][*np.ChipID] = struct{}{}
+			usage.procChipIDs[npuID][*np.ChipID] = struct{}{}
 		}
 		if np.MemoryUsageMB == nil {
-			if _, ok := cardMemory[npuID]; !ok {
-				cardMemory[npuID] = 0
+			if _, ok := usage.memory[npuID]; !ok {
+				usage.memory[npuID] = 0
 			}
 			continue
 		}
-		if prev, ok := cardMemory[npuID]; !ok || *np.MemoryUsageMB > prev {
-			cardMemory[npuID] = *np.MemoryUsageMB
+		if prev, ok := usage.memory[npuID]; !ok || *np.MemoryUsageMB > prev {
+			usage.memory[npuID] = *np.MemoryUsageMB
 		}
 	}
+	for id := range usage.memory {
+		usage.npuIDs = append(usage.npuIDs, id)
+	}
+	sort.Ints(usage.npuIDs)
+	return usage
+}
 
-	if len(cardMemory) > 0 {
-		npuIDs := make([]int, 0, len(cardMemory))
-		for id := range cardMemory {
-			npuIDs = append(npuIDs, id)
+// cards 按 npu_id 关联指标组装卡信息；metrics 可包含其他卡的数据，会被忽略。
+// 指标为空时从 npu_processes.card_metrics_snapshot 回退
+func (u cardUsage) cards(nodeID string, metrics []model.NPUMetric, npuProcs []model.NPUProcess, aggregate bool) []NPUCardInfo {
+	metricsByNPU := make(map[int][]model.NPUMetric)
+	for _, m := range metrics {
+		if m.NPUID == nil {
+			continue
 		}
-		sort.Ints(npuIDs)
-
-		// 3. 查询卡详情并按 npu_id 建立映射
-		metricsByNPU := make(map[int][]model.NPUMetric)
-		var metrics []model.NPUMetric
-		// 已停止的作业：查询运行期间 HBM 峰值快照，反映真实使用量
-		if isTerminalJobStatus(job.Status) && job.StartTime != nil && job.EndTime != nil && *job.EndTime > 0 {
-			metrics, err = s.metricsRepo.FindNPUMetricsPeakInPeriod(nodeID, npuIDs, *job.StartTime, *job.EndTime)
-		} else {
-			metrics, err = s.metricsRepo.FindLatestNPUMetrics(nodeID, npuIDs)
+		if _, ok := u.memory[*m.NPUID]; ok {
+			metricsByNPU[*m.NPUID] = append(metricsByNPU[*m.NPUID], m)
 		}
-		if err == nil {
-			for _, m := range metrics {
-				if m.NPUID != nil {
-					metricsByNPU[*m.NPUID] = append(metricsByNPU[*m.NPUID], m)
-				}
+	}
+
+	if len(metricsByNPU) == 0 {
+		snapshotMetrics := parseSnapshotMetrics(npuProcs, nodeID)
+		for _, m := range snapshotMetrics {
+			if m.NPUID != nil {
+				metricsByNPU[*m.NPUID] = append(metricsByNPU[*m.NPUID], m)
 			}
 		}
+	}
 
-		// 3.1 npu_metrics 为空时，从 npu_processes.card_metrics_snapshot 回退
-		if len(metricsByNPU) == 0 {
-			snapshotMetrics := parseSnapshotMetrics(npuProcs, nodeID)
-			for _, m := range snapshotMetrics {
-				if m.NPUID != nil {
-					metricsByNPU[*m.NPUID] = append(metricsByNPU[*m.NPUID], m)
-				}
+	metricsByNPU = filterChipsByID(metricsByNPU, u.procChipIDs, aggregate)
+
+	cards := make([]NPUCardInfo, 0, len(u.npuIDs))
+	for _, npuID := range u.npuIDs {
+		cards = append(cards, NPUCardInfo{
+			NpuID:         npuID,
+			MemoryUsageMB: u.memory[npuID],
+			Metrics:       metricsByNPU[npuID],
+		})
+	}
+	return cards
+}
+
+// GetJobsNPUCards 批量获取主作业的 NPU 卡信息，结果与 GetJobDetail(jobID, true).NPUCards 一致。
+// 运行中且主进程直接占卡的作业按节点合并为两次查询；其余作业（需聚合子进程或查询运行期峰值的终态作业）逐个回退到 GetJobDetail
+func (s *JobService) GetJobsNPUCards(jobs []model.Job) (map[string][]NPUCardInfo, error) {
+	result := make(map[string][]NPUCardInfo, len(jobs))
+	byNode := make(map[string][]model.Job)
+	var fallback []string
+	for _, job := range jobs {
+		if job.NodeID == nil || job.PID == nil {
+			result[job.JobID] = []NPUCardInfo{}
+			continue
+		}
+		byNode[*job.NodeID] = append(byNode[*job.NodeID], job)
+	}
+
+	for nodeID, nodeJobs := range byNode {
+		pids := make([]int64, 0, len(nodeJobs))
+		for _, job := range nodeJobs {
+			pids = append(pids, *job.PID)
+		}
+		procs, err := s.metricsRepo.FindNPUProcessesByPIDs(nodeID, pids)
+		if err != nil {
+			return nil, err
+		}
+		procsByPID := make(map[int64][]model.NPUProcess)
+		for _, p := range procs {
+			if p.PID != nil {
+				procsByPID[*p.PID] = append(procsByPID[*p.PID], p)
 			}
 		}
 
-		metricsByNPU = filterChipsByID(metricsByNPU, procChipIDs, aggregate)
+		type pending struct {
+			jobID string
+			procs []model.NPUProcess
+			usage cardUsage
+		}
+		var direct []pending
+		npuSet := make(map[int]struct{})
+		for _, job := range nodeJobs {
+			jobProcs := procsByPID[*job.PID]
+			if len(jobProcs) == 0 || isTerminalJobStatus(job.Status) {
+				fallback = append(fallback, job.JobID)
+				continue
+			}
+			usage := collectCardUsage(jobProcs)
+			for _, id := range usage.npuIDs {
+				npuSet[id] = struct{}{}
+			}
+			direct = append(direct, pending{jobID: job.JobID, procs: jobProcs, usage: usage})
+		}
+		if len(direct) == 0 {
+			continue
+		}
 
-		// 4. 组装 NPUCardInfo（按 npu_id 有序输出）
-		for _, npuID := range npuIDs {
-			resp.NPUCards = append(resp.NPUCards, NPUCardInfo{
-				NpuID:         npuID,
-				MemoryUsageMB: cardMemory[npuID],
-				Metrics:       metricsByNPU[npuID],
-			})
+		npuIDs := make([]int, 0, len(npuSet))
+		for id := range npuSet {
+			npuIDs = append(npuIDs, id)
+		}
+		sort.Ints(npuIDs)
+		metrics, err := s.metricsRepo.FindLatestNPUMetrics(nodeID, npuIDs)
+		if err != nil {
+			metrics = nil
+		}
+		for _, p := range direct {
+			result[p.jobID] = p.usage.cards(nodeID, metrics, p.procs, true)
 		}
 	}
 
-	// 5. 查关联 NPU 进程（同 pgid 范围内 Union-Find），仅 aggregate 模式
-	if aggregate && job.PGID != nil {
-		resp.RelatedJobs = s.findRelatedNPUJobs(job)
+	for _, jobID := range fallback {
+		detail, err := s.GetJobDetail(jobID, true)
+		if err != nil {
+			return nil, err
+		}
+		result[jobID] = detail.NPUCards
 	}
+	return result, nil
+}
 
-	return resp, nil
+// GetJobCodes 批量获取作业代码，按作业ID分组，同一作业内按时间倒序
+func (s *JobService) GetJobCodes(jobIDs []string) (map[string][]model.Code, error) {
+	codes, err := s.codeRepo.FindByJobIDs(jobIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]model.Code, len(jobIDs))
+	for _, code := range codes {
+		if code.JobID != nil {
+			result[*code.JobID] = append(result[*code.JobID], code)
+		}
+	}
+	return result, nil
 }
 
 // GetJobsByNodeID 根据节点ID获取作业列表
@@ -242,6 +384,21 @@ func (s *JobService) GetJobParameters(jobID string) ([]model.Parameter, error) {
 	return s.paramRepo.FindByJobID(jobID)
 }
 
+// GetJobsParameters 批量获取多个作业的参数（不含参数原文、配置文件内容与环境变量），按作业ID分组
+func (s *JobService) GetJobsParameters(jobIDs []string) (map[string][]model.Parameter, error) {
+	params, err := s.paramRepo.FindSummariesByJobIDs(jobIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]model.Parameter, len(jobIDs))
+	for _, p := range params {
+		if p.JobID != nil {
+			result[*p.JobID] = append(result[*p.JobID], p)
+		}
+	}
+	return result, nil
+}
+
 // GetJobCode 获取作业代码
 func (s *JobService) GetJobCode(jobID string) ([]model.Code, error) {
 	return s.codeRepo.FindByJobID(jobID)
@@ -253,7 +410,7 @@ func (s *JobService) GetAllJobs() ([]model.Job, error) {
 }
 
 // GetJobs 灵活查询作业，支持多条件筛选和排序
-func (s *JobService) GetJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error) {
+func (s *JobService) GetJobs(filter JobFilter, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -261,13 +418,13 @@ func (s *JobService) GetJobs(nodeID string, statuses []string, jobTypes []string
 		pageSize = 20
 	}
 
-	total, err := s.jobRepo.Count(nodeID, statuses, jobTypes, frameworks)
+	total, err := s.jobRepo.Count(filter)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	jobs, err := s.jobRepo.Find(nodeID, statuses, jobTypes, frameworks, sortBy, sortOrder, pageSize, offset)
+	jobs, err := s.jobRepo.Find(filter, sortBy, sortOrder, pageSize, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -418,21 +575,10 @@ func (s *JobService) findRelatedNPUJobs(job *model.Job) []model.Job {
 	return related
 }
 
-// GetJobStats 获取作业统计信息（按分组统计，与作业管理页一致）
-func (s *JobService) GetJobStats() (map[string]int64, error) {
-	jobs, err := s.jobRepo.FindAll()
-	if err != nil {
-		return nil, err
-	}
-
-	groups, err := s.buildGroupedJobs(jobs)
-	if err != nil {
-		return nil, err
-	}
-	groups = filterStopNameGroups(groups)
-
+// GetJobStats 获取满足筛选条件的作业统计信息（按分组统计，与作业管理页一致）
+func (s *JobService) GetJobStats(filter JobGroupFilter) (map[string]int64, error) {
 	stats := map[string]int64{
-		"total":     int64(len(groups)),
+		"total":     0,
 		"running":   0,
 		"completed": 0,
 		"failed":    0,
@@ -440,6 +586,32 @@ func (s *JobService) GetJobStats() (map[string]int64, error) {
 		"lost":      0,
 	}
 
+	if s.useGroupStore() {
+		counts, err := s.countGroupsFromStore(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range counts {
+			stats["total"] += c.Count
+			if _, ok := stats[c.Status]; ok && c.Status != "total" {
+				stats[c.Status] += c.Count
+			}
+		}
+		return stats, nil
+	}
+
+	var groups []JobGroup
+	var err error
+	if reflect.DeepEqual(filter, JobGroupFilter{}) {
+		groups, err = s.loadAllGroups()
+	} else {
+		groups, err = s.buildFilteredGroups(filter, "", "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stats["total"] = int64(len(groups))
 	for _, group := range groups {
 		if group.MainJob.Status != nil {
 			switch *group.MainJob.Status {
@@ -460,9 +632,102 @@ func (s *JobService) GetJobStats() (map[string]int64, error) {
 	return stats, nil
 }
 
-// GetDistinctCardCounts 获取所有去重的卡数值（基于 npu_processes）
-func (s *JobService) GetDistinctCardCounts() ([]int, error) {
-	jobs, err := s.jobRepo.FindFiltered("", nil, nil, nil, "", "")
+// JobGroupCount 按状态、作业类型、框架聚合的作业组数量
+type JobGroupCount struct {
+	Status    string `json:"status"`
+	JobType   string `json:"jobType"`
+	Framework string `json:"framework"`
+	Count     int64  `json:"count"`
+}
+
+// GetJobGroupCounts 统计 scope 范围内的作业组数量，按主进程的状态、作业类型、框架分组；口径与 GetJobStats 一致，空值记为 unknown
+func (s *JobService) GetJobGroupCounts(scope ProjectFilter) ([]JobGroupCount, error) {
+	filter := JobGroupFilter{JobFilter: JobFilter{Projects: scope}}
+	if s.useGroupStore() {
+		return s.countGroupsFromStore(filter)
+	}
+
+	var groups []JobGroup
+	var err error
+	if scope.IsEmpty() {
+		groups, err = s.loadAllGroups()
+	} else {
+		groups, err = s.buildFilteredGroups(filter, "", "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ status, jobType, framework string }
+	counts := make(map[key]int64)
+	var order []key
+	for _, group := range groups {
+		k := key{
+			status:    stringOrUnknown(group.MainJob.Status),
+			jobType:   stringOrUnknown(group.MainJob.JobType),
+			framework: stringOrUnknown(group.MainJob.Framework),
+		}
+		if _, ok := counts[k]; !ok {
+			order = append(order, k)
+		}
+		counts[k]++
+	}
+
+	result := make([]JobGroupCount, 0, len(order))
+	for _, k := range order {
+		result = append(result, JobGroupCount{Status: k.status, JobType: k.jobType, Framework: k.framework, Count: counts[k]})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		if a.JobType != b.JobType {
+			return a.JobType < b.JobType
+		}
+		return a.Framework < b.Framework
+	})
+	return result, nil
+}
+
+// loadAllGroups 加载全部作业并分组（过滤启动器类分组），供统计类接口使用
+func (s *JobService) loadAllGroups() ([]JobGroup, error) {
+	if s.useGroupStore() {
+		// limit/offset 为 -1 时不分页
+		groups, _, err := s.getGroupedJobsFromStore(repository.JobGroupFilter{}, "", "", -1, -1)
+		return groups, err
+	}
+
+	jobs, err := s.jobRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.buildGroupedJobs(jobs)
+	if err != nil {
+		return nil, err
+	}
+	return filterStopNameGroups(groups), nil
+}
+
+func stringOrUnknown(s *string) string {
+	if s == nil || *s == "" {
+		return "unknown"
+	}
+	return *s
+}
+
+// GetDistinctCardCounts 获取 scope 范围内所有去重的卡数值（基于 npu_processes）
+func (s *JobService) GetDistinctCardCounts(scope ProjectFilter) ([]int, error) {
+	if s.useGroupStore() {
+		counts, err := s.groupRepo.DistinctCardCounts(scope)
+		if err != nil {
+			return nil, fmt.Errorf("distinct card counts: %w", err)
+		}
+		return counts, nil
+	}
+
+	jobs, err := s.jobRepo.FindFiltered(JobFilter{}, "", "")
 	if err != nil {
 		return nil, fmt.Errorf("find filtered for card counts: %w", err)
 	}
@@ -472,7 +737,10 @@ func (s *JobService) GetDistinctCardCounts() ([]int, error) {
 		return nil, err
 	}
 
-	groups = filterStopNameGroups(groups)
+	groups, err = s.filterGroupsByProject(filterStopNameGroups(groups), scope)
+	if err != nil {
+		return nil, err
+	}
 
 	seen := make(map[int]struct{})
 	counts := make([]int, 0)
@@ -492,7 +760,7 @@ func (s *JobService) GetDistinctCardCounts() ([]int, error) {
 }
 
 // GetGroupedJobs 按 ppid 链路构建进程树分组查询作业
-func (s *JobService) GetGroupedJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, cardCounts []int, sortBy, sortOrder string, page, pageSize int) ([]JobGroup, int64, error) {
+func (s *JobService) GetGroupedJobs(filter JobGroupFilter, sortBy, sortOrder string, page, pageSize int) ([]JobGroup, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -501,27 +769,143 @@ func (s *JobService) GetGroupedJobs(nodeID string, statuses []string, jobTypes [
 	}
 	offset := (page - 1) * pageSize
 
-	// 1. 查出所有符合条件的 jobs
-	jobs, err := s.jobRepo.FindFiltered(nodeID, statuses, jobTypes, frameworks, sortBy, sortOrder)
+	// 持久化分组已就绪时，筛选、排序、分页均在 job_groups 表上完成
+	if s.useGroupStore() {
+		return s.getGroupedJobsFromStore(filter, sortBy, sortOrder, offset, pageSize)
+	}
+
+	groups, err := s.buildFilteredGroups(filter, sortBy, sortOrder)
 	if err != nil {
-		return nil, 0, fmt.Errorf("find filtered: %w", err)
+		return nil, 0, err
+	}
+
+	// 内存分页
+	total := int64(len(groups))
+	return paginateJobGroups(groups, offset, pageSize), total, nil
+}
+
+// buildFilteredGroups 未启用持久化分组时在内存中构建并筛选分组
+func (s *JobService) buildFilteredGroups(filter JobGroupFilter, sortBy, sortOrder string) ([]JobGroup, error) {
+	// 1. 查出所有符合条件的 jobs；启动时间、结束时间上限与关键词需作用于分组整体，分组后再筛选；
+	// 结束时间下限先排除早已结束的作业以减少加载量，分组后再按主进程判断
+	jobFilter := filter.JobFilter
+	jobFilter.StartFrom, jobFilter.StartTo, jobFilter.EndTo, jobFilter.Search = nil, nil, nil, ""
+	jobFilter.Projects = ProjectFilter{}
+	jobs, err := s.jobRepo.FindFiltered(jobFilter, sortBy, sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("find filtered: %w", err)
 	}
 
 	// 2. 在内存中按 ppid 链路构建进程树分组
-	groups, err := s.buildGroupedJobs(jobs)
+	groups, clusters, err := s.buildGroupedJobsWithClusters(jobs)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// 3. 过滤掉纯停止词进程的独立组（无业务子进程的 shell/容器运行时进程）
-	groups = filterStopNameGroups(groups)
+	// 3. 过滤掉纯停止词进程的独立组（无业务子进程的 shell/容器运行时进程），
+	// 再应用卡数、主进程启动/结束时间与关键词筛选；关键词匹配进程树内全部作业，与 job_groups 表上的筛选一致
+	terms := strings.Fields(strings.ToLower(filter.Search))
+	filtered := make([]JobGroup, 0, len(groups))
+	for i, group := range groups {
+		if isStopNameGroup(group) {
+			continue
+		}
+		if len(filter.CardCounts) > 0 && !matchCardCount(group.CardCount, filter.CardCounts) {
+			continue
+		}
+		if !cardCountInRange(group.CardCount, filter) {
+			continue
+		}
+		if !startTimeInRange(group.MainJob.StartTime, filter.JobFilter) || !endTimeInRange(group.MainJob.EndTime, filter.JobFilter) {
+			continue
+		}
+		if len(terms) > 0 && !clusterMatchesSearch(jobs, clusters[i], terms) {
+			continue
+		}
+		filtered = append(filtered, group)
+	}
+	filtered, err = s.filterGroupsByAnalysis(filtered, filter.Analysis)
+	if err != nil {
+		return nil, err
+	}
+	return s.filterGroupsByProject(filtered, filter.Projects)
+}
 
-	// 4. 应用 cardCount 筛选
-	groups = filterJobGroupsByCardCounts(groups, cardCounts)
+// filterGroupsByProject 保留主进程归属项目满足条件的分组
+func (s *JobService) filterGroupsByProject(groups []JobGroup, filter ProjectFilter) ([]JobGroup, error) {
+	if filter.IsEmpty() {
+		return groups, nil
+	}
+	if s.projectRepo == nil {
+		return nil, ErrProjectFilterUnsupported
+	}
 
-	// 5. 内存分页
-	total := int64(len(groups))
-	return paginateJobGroups(groups, offset, pageSize), total, nil
+	projectOf := make(map[string]uint, len(groups))
+	for start := 0; start < len(groups); start += analysisLookupBatchSize {
+		end := start + analysisLookupBatchSize
+		if end > len(groups) {
+			end = len(groups)
+		}
+		jobIDs := make([]string, 0, end-start)
+		for _, group := range groups[start:end] {
+			jobIDs = append(jobIDs, group.MainJob.JobID)
+		}
+		assignments, err := s.projectRepo.FindJobProjects(jobIDs)
+		if err != nil {
+			return nil, fmt.Errorf("find job projects: %w", err)
+		}
+		for _, a := range assignments {
+			projectOf[a.JobID] = a.ProjectID
+		}
+	}
+
+	filtered := make([]JobGroup, 0, len(groups))
+	for _, group := range groups {
+		if filter.Matches(projectOf[group.MainJob.JobID]) {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered, nil
+}
+
+// analysisLookupBatchSize 按分析字段筛选时每批查询的主作业数
+const analysisLookupBatchSize = 1000
+
+// filterGroupsByAnalysis 保留主进程 AI 分析字段满足条件的分组
+func (s *JobService) filterGroupsByAnalysis(groups []JobGroup, filter AnalysisFilter) ([]JobGroup, error) {
+	if filter.IsEmpty() {
+		return groups, nil
+	}
+	if s.analysisRepo == nil {
+		return nil, ErrAnalysisFilterUnsupported
+	}
+
+	matched := make(map[string]struct{})
+	for start := 0; start < len(groups); start += analysisLookupBatchSize {
+		end := start + analysisLookupBatchSize
+		if end > len(groups) {
+			end = len(groups)
+		}
+		jobIDs := make([]string, 0, end-start)
+		for _, group := range groups[start:end] {
+			jobIDs = append(jobIDs, group.MainJob.JobID)
+		}
+		ids, err := s.analysisRepo.FindJobIDsByFields(jobIDs, filter)
+		if err != nil {
+			return nil, fmt.Errorf("find analyzed jobs: %w", err)
+		}
+		for _, id := range ids {
+			matched[id] = struct{}{}
+		}
+	}
+
+	filtered := make([]JobGroup, 0, len(matched))
+	for _, group := range groups {
+		if _, ok := matched[group.MainJob.JobID]; ok {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered, nil
 }
 
 // ppidStopNames 非业务进程停止词，Union-Find 合并时不穿越这些进程
@@ -758,12 +1142,22 @@ func hasNPUForAnyPID(npuMap map[int64][]int, pids []int64) bool {
 	return false
 }
 
+// jobCluster Union-Find 得到的一棵进程树，包含全部成员（不区分是否展示为子进程）
+type jobCluster struct {
+	mainIdx int     // 主进程在 jobs 中的下标
+	jobs    []int   // 全部成员在 jobs 中的下标
+	nid     string  // 节点ID
+	pids    []int64 // 全部成员的 pid
+}
+
 // buildGroupedJobs 使用 Union-Find 按 ppid 链路构建进程树分组，并补充卡数信息
 func (s *JobService) buildGroupedJobs(jobs []model.Job) ([]JobGroup, error) {
-	if len(jobs) == 0 {
-		return []JobGroup{}, nil
-	}
+	groups, _, err := s.buildGroupedJobsWithClusters(jobs)
+	return groups, err
+}
 
+// clusterJobs 按 node_id + ppid 链路（pgid 兜底）将作业合并为进程树，按 jobs 中首次出现的顺序返回
+func clusterJobs(jobs []model.Job) []jobCluster {
 	parent := make([]int, len(jobs))
 	for i := range jobs {
 		parent[i] = i
@@ -829,40 +1223,62 @@ func (s *JobService) buildGroupedJobs(jobs []model.Job) ([]JobGroup, error) {
 	}
 
 	// 按根 pid 聚合分组
-	type groupInfo struct {
-		jobs []int // jobs 数组下标
-		nid  string
-		pids []int64
-	}
-	groupMap := make(map[int]*groupInfo)
-	var rootOrder []int
+	clusterIdx := make(map[int]int)
+	var clusters []jobCluster
 
 	for i, job := range jobs {
 		if job.PID == nil {
 			continue
 		}
 		root := find(i)
-		if g, ok := groupMap[root]; ok {
-			g.jobs = append(g.jobs, i)
-			g.pids = append(g.pids, *job.PID)
+		if ci, ok := clusterIdx[root]; ok {
+			clusters[ci].jobs = append(clusters[ci].jobs, i)
+			clusters[ci].pids = append(clusters[ci].pids, *job.PID)
 		} else {
-			var nid string
-			if job.NodeID != nil {
-				nid = *job.NodeID
-			}
-			groupMap[root] = &groupInfo{
+			clusterIdx[root] = len(clusters)
+			clusters = append(clusters, jobCluster{
 				jobs: []int{i},
-				nid:  nid,
+				nid:  normalizeNodeID(job.NodeID),
 				pids: []int64{*job.PID},
+			})
+		}
+	}
+
+	// 选择 MainJob：没有父链接的进程作为根；如有多个，选 start_time 最早的
+	for ci := range clusters {
+		c := &clusters[ci]
+		mainIdx := c.jobs[0]
+		for _, idx := range c.jobs {
+			_, jobHasParent := parentLink[idx]
+			_, curHasParent := parentLink[mainIdx]
+			if !jobHasParent && curHasParent {
+				mainIdx = idx
+			} else if !jobHasParent && !curHasParent {
+				job := jobs[idx]
+				curMain := jobs[mainIdx]
+				// 都是根，选 start_time 最早的
+				if job.StartTime != nil && curMain.StartTime != nil && *job.StartTime < *curMain.StartTime {
+					mainIdx = idx
+				}
 			}
-			rootOrder = append(rootOrder, root)
 		}
+		c.mainIdx = mainIdx
 	}
 
+	return clusters
+}
+
+// buildGroupedJobsWithClusters 构建分组并返回对应的进程树，groups[i] 与 clusters[i] 一一对应
+func (s *JobService) buildGroupedJobsWithClusters(jobs []model.Job) ([]JobGroup, []jobCluster, error) {
+	if len(jobs) == 0 {
+		return []JobGroup{}, nil, nil
+	}
+
+	clusters := clusterJobs(jobs)
+
 	// 批量查询 NPU 卡信息
 	nodeAllPIDs := make(map[string][]int64)
-	for _, root := range rootOrder {
-		info := groupMap[root]
+	for _, info := range clusters {
 		nodeAllPIDs[info.nid] = append(nodeAllPIDs[info.nid], info.pids...)
 	}
 
@@ -873,7 +1289,7 @@ func (s *JobService) buildGroupedJobs(jobs []model.Job) ([]JobGroup, error) {
 		}
 		npuMap, err := s.metricsRepo.FindNPUCardsByPIDs(nid, dedupeInt64(pids))
 		if err != nil {
-			return nil, fmt.Errorf("find npu cards: %w", err)
+			return nil, nil, fmt.Errorf("find npu cards: %w", err)
 		}
 		nodeNPUMap[nid] = npuMap
 	}
@@ -883,27 +1299,9 @@ func (s *JobService) buildGroupedJobs(jobs []model.Job) ([]JobGroup, error) {
 	nodeNPUFallbackMap := make(map[string]map[int64][]int)
 
 	// 组装 JobGroup 结果
-	groups := make([]JobGroup, 0, len(rootOrder))
-	for _, root := range rootOrder {
-		info := groupMap[root]
-
-		// 选择 MainJob：没有父链接的进程作为根；如有多个，选 start_time 最早的
-		mainIdx := info.jobs[0]
-		for _, idx := range info.jobs {
-			_, jobHasParent := parentLink[idx]
-			_, curHasParent := parentLink[mainIdx]
-			if !jobHasParent && curHasParent {
-				mainIdx = idx
-			} else if !jobHasParent && !curHasParent {
-				job := jobs[idx]
-				curMain := jobs[mainIdx]
-				// 都是根，选 start_time 最早的
-				if job.StartTime != nil && curMain.StartTime != nil && *job.StartTime < *curMain.StartTime {
-					mainIdx = idx
-				}
-			}
-		}
-
+	groups := make([]JobGroup, 0, len(clusters))
+	for _, info := range clusters {
+		mainIdx := info.mainIdx
 		group := JobGroup{
 			MainJob:   jobs[mainIdx],
 			ChildJobs: make([]model.Job, 0, len(info.jobs)-1),
@@ -916,7 +1314,7 @@ func (s *JobService) buildGroupedJobs(jobs []model.Job) ([]JobGroup, error) {
 				var err error
 				fallbackMap, err = s.metricsRepo.FindNPUCardsByPIDsWithStatuses(info.nid, dedupeInt64(nodeAllPIDs[info.nid]), []string{"running", "stopped"})
 				if err != nil {
-					return nil, fmt.Errorf("find fallback npu cards: %w", err)
+					return nil, nil, fmt.Errorf("find fallback npu cards: %w", err)
 				}
 				nodeNPUFallbackMap[info.nid] = fallbackMap
 			}
@@ -954,7 +1352,7 @@ func (s *JobService) buildGroupedJobs(jobs []model.Job) ([]JobGroup, error) {
 		groups = append(groups, group)
 	}
 
-	return groups, nil
+	return groups, clusters, nil
 }
 
 // matchCardCount 检查卡数是否在筛选列表中
@@ -974,40 +1372,87 @@ func matchCardCount(cardCount *int, cardCounts []int) bool {
 func filterStopNameGroups(groups []JobGroup) []JobGroup {
 	filtered := make([]JobGroup, 0, len(groups))
 	for _, group := range groups {
-		hasBusinessJob := false
-		// 检查 MainJob
-		if group.MainJob.ProcessName == nil || !ppidStopNames[*group.MainJob.ProcessName] {
-			hasBusinessJob = true
-		}
-		// 检查 ChildJobs
-		if !hasBusinessJob {
-			for _, child := range group.ChildJobs {
-				if child.ProcessName == nil || !ppidStopNames[*child.ProcessName] {
-					hasBusinessJob = true
-					break
-				}
-			}
-		}
-		if hasBusinessJob {
+		if !isStopNameGroup(group) {
 			filtered = append(filtered, group)
 		}
 	}
 	return filtered
 }
 
-// filterJobGroupsByCardCounts 根据 cardCount 条件过滤分组
-func filterJobGroupsByCardCounts(groups []JobGroup, cardCounts []int) []JobGroup {
-	if len(cardCounts) == 0 {
-		return groups
+// isStopNameGroup 组内所有进程（主进程与子进程）都是停止词进程
+func isStopNameGroup(group JobGroup) bool {
+	if group.MainJob.ProcessName == nil || !ppidStopNames[*group.MainJob.ProcessName] {
+		return false
 	}
+	for _, child := range group.ChildJobs {
+		if child.ProcessName == nil || !ppidStopNames[*child.ProcessName] {
+			return false
+		}
+	}
+	return true
+}
 
-	filtered := make([]JobGroup, 0, len(groups))
-	for _, group := range groups {
-		if matchCardCount(group.CardCount, cardCounts) {
-			filtered = append(filtered, group)
+// startTimeInRange 启动时间是否在筛选范围内；设置了范围时启动时间未知的作业不匹配
+func startTimeInRange(start *int64, filter JobFilter) bool {
+	if filter.StartFrom != nil && (start == nil || *start < *filter.StartFrom) {
+		return false
+	}
+	if filter.StartTo != nil && (start == nil || *start > *filter.StartTo) {
+		return false
+	}
+	return true
+}
+
+// endTimeInRange 结束时间是否在筛选范围内；未结束的作业满足下限、不满足上限，与 SQL 条件一致
+func endTimeInRange(end *int64, filter JobFilter) bool {
+	if filter.EndFrom != nil && end != nil && *end < *filter.EndFrom {
+		return false
+	}
+	if filter.EndTo != nil && (end == nil || *end > *filter.EndTo) {
+		return false
+	}
+	return true
+}
+
+// cardCountInRange 卡数是否在 MinCardCount/MaxCardCount 范围内；设置了范围时卡数未知的分组不匹配
+func cardCountInRange(cardCount *int, filter JobGroupFilter) bool {
+	if filter.MinCardCount == nil && filter.MaxCardCount == nil {
+		return true
+	}
+	if cardCount == nil {
+		return false
+	}
+	if filter.MinCardCount != nil && *cardCount < *filter.MinCardCount {
+		return false
+	}
+	return filter.MaxCardCount == nil || *cardCount <= *filter.MaxCardCount
+}
+
+// clusterMatchesSearch 每个关键词都须命中进程树内某个作业（可以是不同作业）的作业名、命令行、工作目录或进程名
+func clusterMatchesSearch(jobs []model.Job, cluster jobCluster, terms []string) bool {
+	for _, term := range terms {
+		matched := false
+		for _, idx := range cluster.jobs {
+			if jobMatchesTerm(jobs[idx], term) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
 		}
 	}
-	return filtered
+	return true
+}
+
+// jobMatchesTerm 作业搜索字段是否包含关键词（term 已转为小写）
+func jobMatchesTerm(job model.Job, term string) bool {
+	for _, field := range []*string{job.JobName, job.CommandLine, job.CWD, job.ProcessName} {
+		if field != nil && strings.Contains(strings.ToLower(*field), term) {
+			return true
+		}
+	}
+	return false
 }
 
 // paginateJobGroups 对分组结果进行内存分页
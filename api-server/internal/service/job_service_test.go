@@ -3,10 +3,12 @@ package service
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
 )
 
 // MockJobRepository is a mock implementation of JobRepository
@@ -57,18 +59,18 @@ func (m *MockJobRepository) FindAll() ([]model.Job, error) {
 	return args.Get(0).([]model.Job), args.Error(1)
 }
 
-func (m *MockJobRepository) Find(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string, limit, offset int) ([]model.Job, error) {
-	args := m.Called(nodeID, statuses, jobTypes, frameworks, sortBy, sortOrder, limit, offset)
+func (m *MockJobRepository) Find(filter JobFilter, sortBy, sortOrder string, limit, offset int) ([]model.Job, error) {
+	args := m.Called(filter, sortBy, sortOrder, limit, offset)
 	return args.Get(0).([]model.Job), args.Error(1)
 }
 
-func (m *MockJobRepository) Count(nodeID string, statuses []string, jobTypes []string, frameworks []string) (int64, error) {
-	args := m.Called(nodeID, statuses, jobTypes, frameworks)
+func (m *MockJobRepository) Count(filter JobFilter) (int64, error) {
+	args := m.Called(filter)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockJobRepository) FindFiltered(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string) ([]model.Job, error) {
-	args := m.Called(nodeID, statuses, jobTypes, frameworks, sortBy, sortOrder)
+func (m *MockJobRepository) FindFiltered(filter JobFilter, sortBy, sortOrder string) ([]model.Job, error) {
+	args := m.Called(filter, sortBy, sortOrder)
 	return args.Get(0).([]model.Job), args.Error(1)
 }
 
@@ -97,6 +99,41 @@ func (m *MockJobRepository) UpdateFields(jobID string, fields map[string]interfa
 	return args.Error(0)
 }
 
+func (m *MockJobRepository) FindByCursor(filter JobFilter, desc bool, cursor *repository.JobCursor, limit int) ([]model.Job, error) {
+	args := m.Called(filter, desc, cursor, limit)
+	return args.Get(0).([]model.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) FindByIDs(jobIDs []string) ([]model.Job, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) FindByNodeForGrouping(nodeID string) ([]model.Job, error) {
+	args := m.Called(nodeID)
+	return args.Get(0).([]model.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) FindRelatives(nodeID string, pids, ppids, pgids []int64) ([]model.Job, error) {
+	args := m.Called(nodeID, pids, ppids, pgids)
+	return args.Get(0).([]model.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) FindChangedSince(since time.Time) ([]model.Job, error) {
+	args := m.Called(since)
+	return args.Get(0).([]model.Job), args.Error(1)
+}
+
+func (m *MockJobRepository) MaxChangeTime() (time.Time, error) {
+	args := m.Called()
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockJobRepository) DistinctNodeIDs() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // MockParameterRepository is a mock implementation of ParameterRepository
 type MockParameterRepository struct {
 	mock.Mock
@@ -112,6 +149,16 @@ func (m *MockParameterRepository) FindByJobID(jobID string) ([]model.Parameter,
 	return args.Get(0).([]model.Parameter), args.Error(1)
 }
 
+func (m *MockParameterRepository) FindEnvVarsByJobIDs(jobIDs []string) ([]model.Parameter, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.Parameter), args.Error(1)
+}
+
+func (m *MockParameterRepository) FindSummariesByJobIDs(jobIDs []string) ([]model.Parameter, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.Parameter), args.Error(1)
+}
+
 func (m *MockParameterRepository) BatchCreate(params []model.Parameter) error {
 	args := m.Called(params)
 	return args.Error(0)
@@ -132,6 +179,11 @@ func (m *MockCodeRepository) FindByJobID(jobID string) ([]model.Code, error) {
 	return args.Get(0).([]model.Code), args.Error(1)
 }
 
+func (m *MockCodeRepository) FindByJobIDs(jobIDs []string) ([]model.Code, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.Code), args.Error(1)
+}
+
 func (m *MockCodeRepository) BatchCreate(codes []model.Code) error {
 	args := m.Called(codes)
 	return args.Error(0)
@@ -190,6 +242,41 @@ func (m *MockMetricsRepository) FindNPUMetricsPeakInPeriod(nodeID string, npuIDs
 	return args.Get(0).([]model.NPUMetric), args.Error(1)
 }
 
+func (m *MockMetricsRepository) FindLatestNPUMetricsSince(since time.Time) ([]model.NPUMetric, error) {
+	args := m.Called(since)
+	return args.Get(0).([]model.NPUMetric), args.Error(1)
+}
+
+func (m *MockMetricsRepository) FindRunningNPUProcesses(projects repository.ProjectFilter) ([]repository.RunningNPUProcess, error) {
+	args := m.Called(projects)
+	return args.Get(0).([]repository.RunningNPUProcess), args.Error(1)
+}
+
+func (m *MockMetricsRepository) FindLatestNodeNPUMetricsSince(nodeID string, since time.Time) ([]model.NPUMetric, error) {
+	args := m.Called(nodeID, since)
+	return args.Get(0).([]model.NPUMetric), args.Error(1)
+}
+
+func (m *MockMetricsRepository) FindRunningNPUProcessesByNode(nodeID string) ([]model.NPUProcess, error) {
+	args := m.Called(nodeID)
+	return args.Get(0).([]model.NPUProcess), args.Error(1)
+}
+
+func (m *MockMetricsRepository) AggregateNPUMetrics(from, to time.Time, bucketSeconds int64, projects repository.ProjectFilter) ([]repository.NPUMetricBucket, error) {
+	args := m.Called(from, to, bucketSeconds, projects)
+	return args.Get(0).([]repository.NPUMetricBucket), args.Error(1)
+}
+
+func (m *MockMetricsRepository) FindNPUProcessSpans(fromMs, toMs int64, projects repository.ProjectFilter) ([]repository.NPUProcessSpan, error) {
+	args := m.Called(fromMs, toMs, projects)
+	return args.Get(0).([]repository.NPUProcessSpan), args.Error(1)
+}
+
+func (m *MockMetricsRepository) FindNPUMetricWindowStats(nodeID string, npuIDs []int, since time.Time) ([]repository.NPUChipWindowStats, error) {
+	args := m.Called(nodeID, npuIDs, since)
+	return args.Get(0).([]repository.NPUChipWindowStats), args.Error(1)
+}
+
 func (m *MockMetricsRepository) CreateNPUMetric(metric *model.NPUMetric) error {
 	args := m.Called(metric)
 	return args.Error(0)
@@ -360,10 +447,10 @@ func TestJobService_GetJobs(t *testing.T) {
 	statuses := []string{"running"}
 	var jobTypes, frameworks []string
 
-	mockJobRepo.On("Count", nodeID, statuses, jobTypes, frameworks).Return(int64(25), nil)
-	mockJobRepo.On("Find", nodeID, statuses, jobTypes, frameworks, "", "", 10, 10).Return(expectedJobs, nil)
+	mockJobRepo.On("Count", JobFilter{NodeIDs: []string{nodeID}, Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}).Return(int64(25), nil)
+	mockJobRepo.On("Find", JobFilter{NodeIDs: []string{nodeID}, Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "", 10, 10).Return(expectedJobs, nil)
 
-	jobs, total, err := svc.GetJobs(nodeID, statuses, jobTypes, frameworks, "", "", 2, 10)
+	jobs, total, err := svc.GetJobs(JobFilter{NodeIDs: []string{nodeID}, Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "", 2, 10)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(25), total)
@@ -400,7 +487,7 @@ func TestJobService_GetGroupedJobs(t *testing.T) {
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 
 	npuMap := map[int64][]int{
 		100: {7},
@@ -410,7 +497,7 @@ func TestJobService_GetGroupedJobs(t *testing.T) {
 		return len(pids) == 2
 	})).Return(npuMap, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
@@ -453,14 +540,14 @@ func TestJobService_GetGroupedJobs_MultipleGroups(t *testing.T) {
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 
 	npuMap := map[int64][]int{}
 	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.MatchedBy(func(pids []int64) bool {
 		return len(pids) == 2
 	})).Return(npuMap, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), total)
@@ -473,6 +560,36 @@ func TestJobService_GetGroupedJobs_MultipleGroups(t *testing.T) {
 	mockMetricsRepo.AssertExpectations(t)
 }
 
+func TestJobService_GetJobGroupCounts(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+
+	nodeID := "node-001"
+	ppid := int64(1)
+	pid1, pid2, pid3 := int64(100), int64(200), int64(300)
+	start1, start2, start3 := int64(1770373780000), int64(1770373790000), int64(1770373800000)
+	running := "running"
+	training, inference := "training", "inference"
+	pytorch := "pytorch"
+
+	mockJobRepo.On("FindAll").Return([]model.Job{
+		{JobID: "job-001", NodeID: &nodeID, PID: &pid1, PPID: &ppid, StartTime: &start1, Status: &running, JobType: &training, Framework: &pytorch},
+		{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid, StartTime: &start2, Status: &running, JobType: &training, Framework: &pytorch},
+		{JobID: "job-003", NodeID: &nodeID, PID: &pid3, PPID: &ppid, StartTime: &start3, Status: &running, JobType: &inference},
+	}, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{}, nil)
+
+	counts, err := svc.GetJobGroupCounts(ProjectFilter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []JobGroupCount{
+		{Status: "running", JobType: "inference", Framework: "unknown", Count: 1},
+		{Status: "running", JobType: "training", Framework: "pytorch", Count: 2},
+	}, counts)
+	mockJobRepo.AssertExpectations(t)
+}
+
 func TestJobService_GetGroupedJobs_CardCountFilterBeforePagination(t *testing.T) {
 	mockJobRepo := new(MockJobRepository)
 	mockParamRepo := new(MockParameterRepository)
@@ -507,7 +624,7 @@ func TestJobService_GetGroupedJobs_CardCountFilterBeforePagination(t *testing.T)
 	var statuses, jobTypes, frameworks []string
 	cardCounts := []int{2}
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 
 	npuMap := map[int64][]int{
 		100: {0},
@@ -518,7 +635,7 @@ func TestJobService_GetGroupedJobs_CardCountFilterBeforePagination(t *testing.T)
 		return len(pids) == 3
 	})).Return(npuMap, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 1)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 1)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
@@ -530,6 +647,38 @@ func TestJobService_GetGroupedJobs_CardCountFilterBeforePagination(t *testing.T)
 	mockMetricsRepo.AssertExpectations(t)
 }
 
+func TestJobService_GetGroupedJobs_CardCountAndEndTimeRangeInMemory(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+
+	nodeID := "node-001"
+	pid1, pid2, pid3, ppid := int64(100), int64(200), int64(300), int64(1)
+	start := int64(1770373780000)
+	end1, end2 := int64(1770380000000), int64(1770390000000)
+	running, completed := "running", "completed"
+	jobs := []model.Job{
+		{JobID: "job-001", NodeID: &nodeID, PID: &pid1, PPID: &ppid, StartTime: &start, EndTime: &end1, Status: &completed},
+		{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid, StartTime: &start, EndTime: &end2, Status: &completed},
+		{JobID: "job-003", NodeID: &nodeID, PID: &pid3, PPID: &ppid, StartTime: &start, Status: &running},
+	}
+	mockJobRepo.On("FindFiltered", JobFilter{}, "", "").Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{
+		100: {0, 1, 2, 3, 4, 5, 6, 7},
+		200: {0},
+		300: {0, 1, 2, 3, 4, 5, 6, 7},
+	}, nil)
+
+	// 卡数不少于 8 且在 end2 之前结束：job-002 卡数不足，job-003 未结束
+	minCards, endTo := 8, end2
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{EndTo: &endTo}, MinCardCount: &minCards}, "", "", 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "job-001", groups[0].MainJob.JobID)
+	}
+}
+
 func TestJobService_GetGroupedJobs_CardCountFilterPageOutOfRange(t *testing.T) {
 	mockJobRepo := new(MockJobRepository)
 	mockParamRepo := new(MockParameterRepository)
@@ -552,12 +701,12 @@ func TestJobService_GetGroupedJobs_CardCountFilterPageOutOfRange(t *testing.T) {
 	var statuses, jobTypes, frameworks []string
 	cardCounts := []int{1}
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.MatchedBy(func(pids []int64) bool {
 		return len(pids) == 1 && pids[0] == 100
 	})).Return(map[int64][]int{100: {0}}, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 2, 1)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 2, 1)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
@@ -595,7 +744,7 @@ func TestJobService_GetDistinctCardCounts(t *testing.T) {
 		{JobID: "job-003", NodeID: &node2, PID: &pid3, PPID: &ppidRoot, StartTime: &start3, ProcessName: &name3, Status: &status},
 	}
 
-	mockJobRepo.On("FindFiltered", "", []string(nil), []string(nil), []string(nil), "", "").Return(jobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{}, "", "").Return(jobs, nil)
 	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.MatchedBy(func(pids []int64) bool {
 		return len(pids) == 2
 	})).Return(map[int64][]int{100: {0}, 101: {0}}, nil)
@@ -603,7 +752,7 @@ func TestJobService_GetDistinctCardCounts(t *testing.T) {
 		return len(pids) == 1 && pids[0] == 200
 	})).Return(map[int64][]int{200: {0, 1}}, nil)
 
-	counts, err := svc.GetDistinctCardCounts()
+	counts, err := svc.GetDistinctCardCounts(ProjectFilter{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, []int{1, 2}, counts)
@@ -646,7 +795,7 @@ func TestJobService_GetGroupedJobs_NoCrossNodePIDMerge(t *testing.T) {
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(jobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(jobs, nil)
 	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.MatchedBy(func(pids []int64) bool {
 		return len(pids) == 2
 	})).Return(map[int64][]int{100: {0}, 101: {0}}, nil)
@@ -654,7 +803,7 @@ func TestJobService_GetGroupedJobs_NoCrossNodePIDMerge(t *testing.T) {
 		return len(pids) == 2
 	})).Return(map[int64][]int{100: {1}, 201: {1}}, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), total)
@@ -709,7 +858,7 @@ func TestJobService_GetGroupedJobs_PPIDChain(t *testing.T) {
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 
 	npuMap := map[int64][]int{
 		100: {0},
@@ -720,7 +869,7 @@ func TestJobService_GetGroupedJobs_PPIDChain(t *testing.T) {
 		return len(pids) == 3
 	})).Return(npuMap, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
@@ -782,7 +931,7 @@ func TestJobService_GetGroupedJobs_ChildJobsFilteredByNPU(t *testing.T) {
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 
 	// 只有 pid=100,101,102 在 NPU 上，103,104 不在
 	npuMap := map[int64][]int{
@@ -794,7 +943,7 @@ func TestJobService_GetGroupedJobs_ChildJobsFilteredByNPU(t *testing.T) {
 		return len(pids) == 5
 	})).Return(npuMap, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
@@ -845,7 +994,7 @@ func TestJobService_GetGroupedJobs_StoppedFallbackToHistoricalNPU(t *testing.T)
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.MatchedBy(func(pids []int64) bool {
 		return len(pids) == 2
 	})).Return(map[int64][]int{}, nil)
@@ -856,7 +1005,7 @@ func TestJobService_GetGroupedJobs_StoppedFallbackToHistoricalNPU(t *testing.T)
 		101: {0, 1},
 	}, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
@@ -897,7 +1046,7 @@ func TestJobService_GetGroupedJobs_StoppedIncludeChildrenWhenNoNPUData(t *testin
 	var statuses, jobTypes, frameworks []string
 	var cardCounts []int
 
-	mockJobRepo.On("FindFiltered", "", statuses, jobTypes, frameworks, "", "").Return(returnedJobs, nil)
+	mockJobRepo.On("FindFiltered", JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, "", "").Return(returnedJobs, nil)
 	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.MatchedBy(func(pids []int64) bool {
 		return len(pids) == 2
 	})).Return(map[int64][]int{}, nil)
@@ -905,7 +1054,7 @@ func TestJobService_GetGroupedJobs_StoppedIncludeChildrenWhenNoNPUData(t *testin
 		return len(pids) == 2
 	}), []string{"running", "stopped"}).Return(map[int64][]int{}, nil)
 
-	groups, total, err := svc.GetGroupedJobs("", statuses, jobTypes, frameworks, cardCounts, "", "", 1, 20)
+	groups, total, err := svc.GetGroupedJobs(JobGroupFilter{JobFilter: JobFilter{Statuses: statuses, JobTypes: jobTypes, Frameworks: frameworks}, CardCounts: cardCounts}, "", "", 1, 20)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
@@ -978,6 +1127,7 @@ func TestJobService_GetJobDetail_WithNPU(t *testing.T) {
 		{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid2, PGID: &pgid, JobName: &childName, Status: &status, StartTime: &startTime},
 	}
 	mockJobRepo.On("FindByNodeIDAndPGID", nodeID, pgid).Return(samePGIDJobs, nil)
+	mockJobRepo.On("FindByNodeIDAndPPID", nodeID, pid).Return([]model.Job{samePGIDJobs[1]}, nil)
 
 	// NPU cards for related pids
 	npuMap := map[int64][]int{101: {0}}
@@ -1242,6 +1392,7 @@ func TestJobService_GetJobDetail_NoNPU(t *testing.T) {
 
 	mockJobRepo.On("FindByID", "job-001").Return(job, nil)
 	mockMetricsRepo.On("FindNPUProcessesByPID", nodeID, pid).Return([]model.NPUProcess{}, nil)
+	mockJobRepo.On("FindByNodeIDAndPPID", nodeID, pid).Return([]model.Job{}, nil)
 
 	detail, err := svc.GetJobDetail("job-001", true)
 
@@ -1270,3 +1421,187 @@ func TestJobService_GetJobDetail_NotFound(t *testing.T) {
 	assert.Nil(t, detail)
 	mockJobRepo.AssertExpectations(t)
 }
+
+func TestJobService_GetGroupedJobs_StartRangeAndSearchInMemory(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+
+	nodeID := "node-001"
+	pid1, pid2, pid3 := int64(100), int64(101), int64(200)
+	ppid1, ppid2, ppid3 := int64(1), int64(100), int64(1)
+	early, late, later := int64(1000), int64(5000), int64(6000)
+	launcher, worker, other := "torchrun", "python", "serve.py"
+	cmdline := "python pretrain_llama.py --lr 0.1"
+	jobs := []model.Job{
+		{JobID: "job-001", NodeID: &nodeID, PID: &pid1, PPID: &ppid1, StartTime: &late, JobName: &launcher},
+		{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid2, StartTime: &later, JobName: &worker, CommandLine: &cmdline},
+		{JobID: "job-003", NodeID: &nodeID, PID: &pid3, PPID: &ppid3, StartTime: &early, JobName: &other},
+	}
+	// 启动时间与关键词不下推到作业查询，分组后再按分组整体筛选
+	mockJobRepo.On("FindFiltered", JobFilter{NodeIDs: []string{nodeID}}, "", "").Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", nodeID, mock.Anything).Return(map[int64][]int{}, nil)
+
+	from := int64(2000)
+	filter := JobGroupFilter{JobFilter: JobFilter{NodeIDs: []string{nodeID}, StartFrom: &from, Search: "LLAMA torchrun"}}
+	groups, total, err := svc.GetGroupedJobs(filter, "", "", 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "job-001", groups[0].MainJob.JobID)
+	}
+
+	// 关键词须全部命中
+	filter.Search = "llama serve"
+	_, total, err = svc.GetGroupedJobs(filter, "", "", 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestJobService_GetGroupedJobs_AnalysisFilterInMemory(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockAnalysisRepo := new(MockJobAnalysisRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+
+	nodeID := "node-001"
+	pid1, pid2, ppid := int64(100), int64(200), int64(1)
+	jobs := []model.Job{
+		{JobID: "job-001", NodeID: &nodeID, PID: &pid1, PPID: &ppid},
+		{JobID: "job-002", NodeID: &nodeID, PID: &pid2, PPID: &ppid},
+	}
+	mockJobRepo.On("FindFiltered", JobFilter{}, "", "").Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", nodeID, mock.Anything).Return(map[int64][]int{}, nil)
+
+	filter := JobGroupFilter{Analysis: AnalysisFilter{InferenceFrameworks: []string{"vLLM"}, IssueSeverities: []string{"critical"}}}
+
+	// 未设置分析仓库时不支持分析字段筛选
+	_, _, err := svc.GetGroupedJobs(filter, "", "", 1, 20)
+	assert.ErrorIs(t, err, ErrAnalysisFilterUnsupported)
+
+	svc.SetJobAnalysisRepository(mockAnalysisRepo)
+	mockAnalysisRepo.On("FindJobIDsByFields", mock.MatchedBy(func(ids []string) bool { return len(ids) == 2 }), filter.Analysis).
+		Return([]string{"job-002"}, nil)
+
+	groups, total, err := svc.GetGroupedJobs(filter, "", "", 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "job-002", groups[0].MainJob.JobID)
+	}
+}
+
+func TestJobService_GetJobsNPUCards_BatchesByNode(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockCodeRepo := new(MockCodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), mockCodeRepo, mockMetricsRepo)
+
+	nodeID := "node-001"
+	running := "running"
+	pid1, pid2 := int64(100), int64(200)
+	job1 := model.Job{JobID: "job-1", NodeID: &nodeID, PID: &pid1, Status: &running}
+	job2 := model.Job{JobID: "job-2", NodeID: &nodeID, PID: &pid2, Status: &running}
+	job3 := model.Job{JobID: "job-3"}
+
+	npu0, npu1 := 0, 1
+	mem := 1024.0
+	mockMetricsRepo.On("FindNPUProcessesByPIDs", nodeID, []int64{100, 200}).Return([]model.NPUProcess{
+		{NodeID: &nodeID, PID: &pid1, NPUID: &npu1, MemoryUsageMB: &mem},
+	}, nil)
+	power := 150.0
+	mockMetricsRepo.On("FindLatestNPUMetrics", nodeID, []int{1}).Return([]model.NPUMetric{
+		{NPUID: &npu0, PowerW: &power},
+		{NPUID: &npu1, PowerW: &power},
+	}, nil)
+	// job-2 主进程未直接占卡，回退到 GetJobDetail 聚合子进程
+	mockJobRepo.On("FindByID", "job-2").Return(&job2, nil)
+	mockMetricsRepo.On("FindNPUProcessesByPID", nodeID, pid2).Return([]model.NPUProcess{}, nil)
+	mockJobRepo.On("FindByNodeIDAndPPID", nodeID, pid2).Return([]model.Job{}, nil)
+
+	cards, err := svc.GetJobsNPUCards([]model.Job{job1, job2, job3})
+
+	assert.NoError(t, err)
+	if assert.Len(t, cards["job-1"], 1) {
+		assert.Equal(t, 1, cards["job-1"][0].NpuID)
+		assert.Equal(t, 1024.0, cards["job-1"][0].MemoryUsageMB)
+		assert.Len(t, cards["job-1"][0].Metrics, 1)
+	}
+	assert.Empty(t, cards["job-2"])
+	assert.Contains(t, cards, "job-3")
+	mockJobRepo.AssertExpectations(t)
+	mockMetricsRepo.AssertExpectations(t)
+}
+
+func TestJobService_GetJobCodes(t *testing.T) {
+	mockCodeRepo := new(MockCodeRepository)
+	svc := NewJobService(new(MockJobRepository), new(MockParameterRepository), mockCodeRepo, new(MockMetricsRepository))
+
+	job1, job2 := "job-1", "job-2"
+	mockCodeRepo.On("FindByJobIDs", []string{"job-1", "job-2"}).Return([]model.Code{
+		{JobID: &job1}, {JobID: &job2}, {JobID: &job1},
+	}, nil)
+
+	codes, err := svc.GetJobCodes([]string{"job-1", "job-2"})
+	assert.NoError(t, err)
+	assert.Len(t, codes["job-1"], 2)
+	assert.Len(t, codes["job-2"], 1)
+}
+
+func TestJobService_GetJobsParameters(t *testing.T) {
+	mockParamRepo := new(MockParameterRepository)
+	svc := NewJobService(new(MockJobRepository), mockParamRepo, new(MockCodeRepository), new(MockMetricsRepository))
+
+	job1, job2 := "job-1", "job-2"
+	mockParamRepo.On("FindSummariesByJobIDs", []string{"job-1", "job-2"}).Return([]model.Parameter{
+		{JobID: &job1}, {JobID: &job1}, {JobID: &job2}, {},
+	}, nil)
+
+	params, err := svc.GetJobsParameters([]string{"job-1", "job-2"})
+	assert.NoError(t, err)
+	assert.Len(t, params["job-1"], 2)
+	assert.Len(t, params["job-2"], 1)
+	mockParamRepo.AssertExpectations(t)
+}
+
+func TestJobService_GetGroupedJobs_ProjectFilterInMemory(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewJobService(mockJobRepo, new(MockParameterRepository), new(MockCodeRepository), mockMetricsRepo)
+
+	nodeID := "node-001"
+	status := "running"
+	job := func(id string, pid, start int64) model.Job {
+		ppid := int64(1)
+		return model.Job{JobID: id, NodeID: &nodeID, PID: &pid, PPID: &ppid, StartTime: &start, Status: &status}
+	}
+	jobs := []model.Job{job("job-a", 100, 3000), job("job-b", 200, 2000), job("job-c", 300, 1000)}
+	mockJobRepo.On("FindFiltered", JobFilter{}, "", "").Return(jobs, nil)
+	mockMetricsRepo.On("FindNPUCardsByPIDs", "node-001", mock.Anything).Return(map[int64][]int{}, nil)
+
+	filter := JobGroupFilter{JobFilter: JobFilter{Projects: ProjectFilter{ProjectIDs: []uint{1, 0}}}}
+	_, _, err := svc.GetGroupedJobs(filter, "", "", 1, 10)
+	assert.ErrorIs(t, err, ErrProjectFilterUnsupported)
+
+	mockProjectRepo := new(MockProjectRepository)
+	svc.SetProjectRepository(mockProjectRepo)
+	mockProjectRepo.On("FindJobProjects", mock.Anything).Return([]model.JobProject{
+		{JobID: "job-a", ProjectID: 1},
+		{JobID: "job-b", ProjectID: 2},
+	}, nil)
+
+	// 项目 1 与未归属的作业
+	groups, total, err := svc.GetGroupedJobs(filter, "", "", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "job-a", groups[0].MainJob.JobID)
+	assert.Equal(t, "job-c", groups[1].MainJob.JobID)
+
+	// 可见范围限制：只能看到项目 2 与未归属的作业
+	filter = JobGroupFilter{JobFilter: JobFilter{Projects: ProjectFilter{Restricted: true, VisibleIDs: []uint{2}}}}
+	groups, total, err = svc.GetGroupedJobs(filter, "", "", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "job-b", groups[0].MainJob.JobID)
+	assert.Equal(t, "job-c", groups[1].MainJob.JobID)
+}
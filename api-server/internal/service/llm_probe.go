@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/config"
+)
+
+// ErrLLMModelNotFound 模型ID不存在
+var ErrLLMModelNotFound = errors.New("llm model not found")
+
+// probeMaxTokens 连通性探测只需要极短输出
+const probeMaxTokens = 64
+
+// probeSystemPrompt / probeUserPrompt 连通性探测提示词，要求模型只返回一个固定 JSON 对象
+const (
+	probeSystemPrompt = `You are a connectivity probe. Reply with a single JSON object and nothing else.`
+	probeUserPrompt   = `Return exactly: {"ok": true}`
+)
+
+// probeResponsePreviewLen 返回给前端的原始响应预览长度
+const probeResponsePreviewLen = 500
+
+// LLMModelTestResult 模型连通性测试结果
+type LLMModelTestResult struct {
+	ModelID       string `json:"modelId"`
+	Provider      string `json:"provider"`
+	Model         string `json:"model"`
+	Success       bool   `json:"success"`
+	LatencyMs     int64  `json:"latencyMs"`
+	HTTPStatus    int    `json:"httpStatus"`
+	ModelExists   *bool  `json:"modelExists"`   // nil 表示服务端未提供模型列表
+	ModelListNote string `json:"modelListNote"` // 模型列表不可用的原因
+	JSONParseable bool   `json:"jsonParseable"`
+	Response      string `json:"response,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// TestModel 对指定模型发送探测请求：测量延迟与 HTTP 状态、核对模型名是否存在、检查是否返回可解析 JSON。
+// override 非空时使用其中的配置（用于保存前测试）；掩码 API Key 只在 endpoint 与 provider 均与已保存模型一致时还原为已保存的值，
+// 否则要求重新填写，避免把已保存的密钥发送到其他地址。
+func (s *LLMService) TestModel(modelID string, override *config.LLMModelConfig) (*LLMModelTestResult, error) {
+	s.mu.RLock()
+	cfg := normalizeLLMConfig(s.config)
+	s.mu.RUnlock()
+
+	modelID = strings.TrimSpace(modelID)
+	idx := findModelIndexByID(cfg.Models, modelID)
+
+	var modelCfg config.LLMModelConfig
+	keyMismatch := false
+	switch {
+	case override != nil:
+		modelCfg = *override
+		modelCfg.ID = modelID
+		if strings.HasPrefix(modelCfg.APIKey, "****") {
+			if idx >= 0 && sameLLMTarget(modelCfg, cfg.Models[idx]) {
+				modelCfg.APIKey = cfg.Models[idx].APIKey
+			} else {
+				keyMismatch = true
+			}
+		}
+	case idx >= 0:
+		modelCfg = cfg.Models[idx]
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrLLMModelNotFound, modelID)
+	}
+
+	modelCfg.Provider = NormalizeLLMProvider(modelCfg.Provider)
+	modelCfg.Endpoint = strings.TrimSpace(modelCfg.Endpoint)
+	modelCfg.Model = strings.TrimSpace(modelCfg.Model)
+	if modelCfg.Timeout <= 0 {
+		modelCfg.Timeout = cfg.Timeout
+	}
+
+	result := &LLMModelTestResult{
+		ModelID:  modelID,
+		Provider: modelCfg.Provider,
+		Model:    modelCfg.Model,
+	}
+	if modelCfg.Endpoint == "" || modelCfg.Model == "" {
+		result.Error = "endpoint and model are required"
+		return result, nil
+	}
+	if keyMismatch {
+		result.Error = "api key must be re-entered when the endpoint or provider differs from the saved model"
+		return result, nil
+	}
+
+	provider, err := newLLMProvider(modelCfg.Provider)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	s.probeModelList(provider, modelCfg, result)
+	s.probeChat(provider, modelCfg, result)
+
+	result.Success = result.Error == "" && result.HTTPStatus == http.StatusOK && result.JSONParseable &&
+		(result.ModelExists == nil || *result.ModelExists)
+	return result, nil
+}
+
+// sameLLMTarget 判断两个模型配置是否指向同一服务（provider 与 endpoint 一致）
+func sameLLMTarget(a, b config.LLMModelConfig) bool {
+	return NormalizeLLMProvider(a.Provider) == NormalizeLLMProvider(b.Provider) &&
+		strings.TrimRight(strings.TrimSpace(a.Endpoint), "/") == strings.TrimRight(strings.TrimSpace(b.Endpoint), "/")
+}
+
+// probeModelList 通过模型列表接口核对模型名；接口不可用时 ModelExists 保持 nil
+func (s *LLMService) probeModelList(provider llmProvider, modelCfg config.LLMModelConfig, result *LLMModelTestResult) {
+	req, err := provider.buildListModelsRequest(modelCfg)
+	if err != nil {
+		result.ModelListNote = err.Error()
+		return
+	}
+	statusCode, body, err := s.doLLMRequest(req, modelCfg.Timeout)
+	if err != nil {
+		result.ModelListNote = err.Error()
+		return
+	}
+	if statusCode != http.StatusOK {
+		result.ModelListNote = fmt.Sprintf("model list returned status %d", statusCode)
+		return
+	}
+	ids, err := provider.parseModelIDs(body)
+	if err != nil {
+		result.ModelListNote = err.Error()
+		return
+	}
+	if len(ids) == 0 {
+		result.ModelListNote = "model list is empty"
+		return
+	}
+
+	exists := false
+	for _, id := range ids {
+		if id == modelCfg.Model {
+			exists = true
+			break
+		}
+	}
+	result.ModelExists = &exists
+}
+
+// probeChat 发送一次极小的对话请求，记录延迟、HTTP 状态与 JSON 可解析性
+func (s *LLMService) probeChat(provider llmProvider, modelCfg config.LLMModelConfig, result *LLMModelTestResult) {
+	modelCfg.MaxTokens = probeMaxTokens
+	req, err := provider.buildRequest(probeSystemPrompt, probeUserPrompt, modelCfg)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	start := time.Now()
+	statusCode, body, err := s.doLLMRequest(req, modelCfg.Timeout)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.HTTPStatus = statusCode
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	if statusCode != http.StatusOK {
+		result.Error = fmt.Sprintf("LLM API returned status %d", statusCode)
+		result.Response = truncateRunes(string(body), probeResponsePreviewLen)
+		return
+	}
+
+	content, err := provider.parseResponse(body)
+	if err != nil {
+		result.Error = err.Error()
+		result.Response = truncateRunes(string(body), probeResponsePreviewLen)
+		return
+	}
+	result.Response = truncateRunes(content, probeResponsePreviewLen)
+	result.JSONParseable = json.Valid([]byte(extractJSON(content)))
+}
+
+// truncateRunes 按字符截断，避免切断多字节字符
+func truncateRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "..."
+}
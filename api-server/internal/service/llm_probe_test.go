@@ -0,0 +1,139 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/task-monitor/api-server/internal/config"
+)
+
+func newProbeServer(t *testing.T, models []string, content string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			data := make([]map[string]string, 0, len(models))
+			for _, m := range models {
+				data = append(data, map[string]string{"id": m})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		case "/v1/chat/completions":
+			var req chatRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, probeMaxTokens, req.MaxTokens)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"choices": []map[string]interface{}{{"message": map[string]string{"content": content}}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestLLMService_TestModel_Success(t *testing.T) {
+	server := newProbeServer(t, []string{"qwen2.5"}, `{"ok": true}`)
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{
+		Enabled: true,
+		Models: []config.LLMModelConfig{
+			{ID: "m1", Endpoint: server.URL + "/v1", Model: "qwen2.5", Enabled: true},
+		},
+	})
+
+	result, err := svc.TestModel("m1", nil)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, http.StatusOK, result.HTTPStatus)
+	assert.True(t, result.JSONParseable)
+	if assert.NotNil(t, result.ModelExists) {
+		assert.True(t, *result.ModelExists)
+	}
+}
+
+func TestLLMService_TestModel_ModelMissingAndNotJSON(t *testing.T) {
+	server := newProbeServer(t, []string{"other-model"}, "hello")
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{})
+
+	result, err := svc.TestModel("draft", &config.LLMModelConfig{
+		Endpoint: server.URL + "/v1",
+		Model:    "qwen2.5",
+	})
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, http.StatusOK, result.HTTPStatus)
+	assert.False(t, result.JSONParseable)
+	if assert.NotNil(t, result.ModelExists) {
+		assert.False(t, *result.ModelExists)
+	}
+}
+
+func TestLLMService_TestModel_OverrideRestoresMaskedKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk-secret-1234", r.Header.Get("Authorization"))
+		if r.URL.Path == "/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": "{}"}}},
+		})
+	}))
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{
+		Models: []config.LLMModelConfig{
+			{ID: "m1", Endpoint: server.URL, APIKey: "sk-secret-1234", Model: "m", Enabled: true},
+		},
+	})
+
+	result, err := svc.TestModel("m1", &config.LLMModelConfig{
+		Endpoint: server.URL,
+		APIKey:   "****1234",
+		Model:    "m-v2",
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Nil(t, result.ModelExists)
+	assert.Contains(t, result.ModelListNote, "404")
+}
+
+func TestLLMService_TestModel_MaskedKeyRequiresSameEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{
+		Models: []config.LLMModelConfig{
+			{ID: "m1", Endpoint: "http://saved", APIKey: "sk-secret-1234", Model: "m", Enabled: true},
+		},
+	})
+
+	for _, override := range []config.LLMModelConfig{
+		{Endpoint: server.URL, APIKey: "****1234", Model: "m"},
+		{Endpoint: "http://saved", Provider: "anthropic", APIKey: "****1234", Model: "m"},
+	} {
+		result, err := svc.TestModel("m1", &override)
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "api key must be re-entered")
+	}
+
+	// 未保存的模型没有可还原的密钥
+	result, err := svc.TestModel("draft", &config.LLMModelConfig{Endpoint: server.URL, APIKey: "****1234", Model: "m"})
+	assert.NoError(t, err)
+	assert.Contains(t, result.Error, "api key must be re-entered")
+}
+
+func TestLLMService_TestModel_NotFound(t *testing.T) {
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{})
+
+	_, err := svc.TestModel("missing", nil)
+	assert.True(t, errors.Is(err, ErrLLMModelNotFound))
+}
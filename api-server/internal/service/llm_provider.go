@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/task-monitor/api-server/internal/config"
+)
+
+// 支持的 LLM 服务商协议
+const (
+	LLMProviderOpenAI    = "openai"    // OpenAI 兼容 /chat/completions（vLLM、MindIE、DeepSeek 等）
+	LLMProviderAnthropic = "anthropic" // Anthropic Messages API /messages
+	LLMProviderOllama    = "ollama"    // Ollama /api/chat
+)
+
+// anthropicAPIVersion Anthropic Messages API 版本头
+const anthropicAPIVersion = "2023-06-01"
+
+// defaultLLMMaxTokens 未配置 max_tokens 时的输出上限（Anthropic 为必填字段）
+const defaultLLMMaxTokens = 4096
+
+// llmTemperature 分析场景使用较低温度，保证输出稳定
+const llmTemperature = 0.3
+
+// llmProvider LLM 服务商适配器：负责鉴权头、system prompt 放置、结构化输出开关与响应解析
+type llmProvider interface {
+	// buildRequest 构造发往服务商的 HTTP 请求
+	buildRequest(sysPrompt, userPrompt string, modelCfg config.LLMModelConfig) (*http.Request, error)
+	// parseResponse 从响应体中提取模型输出的文本内容
+	parseResponse(body []byte) (string, error)
+	// buildListModelsRequest 构造查询服务端可用模型列表的请求
+	buildListModelsRequest(modelCfg config.LLMModelConfig) (*http.Request, error)
+	// parseModelIDs 从模型列表响应中提取模型名
+	parseModelIDs(body []byte) ([]string, error)
+}
+
+// NormalizeLLMProvider 规范化 provider 名称，空值视为 OpenAI 兼容协议
+func NormalizeLLMProvider(provider string) string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return LLMProviderOpenAI
+	}
+	return provider
+}
+
+// IsSupportedLLMProvider 判断 provider 是否受支持
+func IsSupportedLLMProvider(provider string) bool {
+	switch NormalizeLLMProvider(provider) {
+	case LLMProviderOpenAI, LLMProviderAnthropic, LLMProviderOllama:
+		return true
+	default:
+		return false
+	}
+}
+
+// newLLMProvider 根据 provider 名称创建适配器
+func newLLMProvider(provider string) (llmProvider, error) {
+	switch NormalizeLLMProvider(provider) {
+	case LLMProviderOpenAI:
+		return openAIProvider{}, nil
+	case LLMProviderAnthropic:
+		return anthropicProvider{}, nil
+	case LLMProviderOllama:
+		return ollamaProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider %q", provider)
+	}
+}
+
+// newJSONRequest 序列化请求体并创建 POST 请求
+func newJSONRequest(endpoint string, body interface{}) (*http.Request, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// modelListResponse OpenAI / Anthropic 通用的 /models 响应
+type modelListResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+func parseModelListIDs(body []byte) ([]string, error) {
+	var resp modelListResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal model list: %w", err)
+	}
+	ids := make([]string, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+func resolveMaxTokens(modelCfg config.LLMModelConfig) int {
+	if modelCfg.MaxTokens > 0 {
+		return modelCfg.MaxTokens
+	}
+	return defaultLLMMaxTokens
+}
+
+// ---------------- OpenAI 兼容 ----------------
+
+// chatMessage OpenAI chat message
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatResponseFormat OpenAI response_format
+type chatResponseFormat struct {
+	Type string `json:"type"`
+}
+
+// chatRequest OpenAI chat completions request
+type chatRequest struct {
+	Model          string              `json:"model"`
+	Messages       []chatMessage       `json:"messages"`
+	Temperature    float64             `json:"temperature"`
+	MaxTokens      int                 `json:"max_tokens,omitempty"`
+	ResponseFormat *chatResponseFormat `json:"response_format,omitempty"`
+}
+
+// chatResponse OpenAI chat completions response
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+type openAIProvider struct{}
+
+func (openAIProvider) buildRequest(sysPrompt, userPrompt string, modelCfg config.LLMModelConfig) (*http.Request, error) {
+	body := chatRequest{
+		Model: modelCfg.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Temperature: llmTemperature,
+		MaxTokens:   modelCfg.MaxTokens,
+	}
+	if !modelCfg.DisableJSONMode {
+		body.ResponseFormat = &chatResponseFormat{Type: "json_object"}
+	}
+
+	endpoint := strings.TrimRight(modelCfg.Endpoint, "/") + "/chat/completions"
+	req, err := newJSONRequest(endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if modelCfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+modelCfg.APIKey)
+	}
+	return req, nil
+}
+
+func (openAIProvider) buildListModelsRequest(modelCfg config.LLMModelConfig) (*http.Request, error) {
+	req, err := http.NewRequest("GET", strings.TrimRight(modelCfg.Endpoint, "/")+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if modelCfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+modelCfg.APIKey)
+	}
+	return req, nil
+}
+
+func (openAIProvider) parseModelIDs(body []byte) ([]string, error) {
+	return parseModelListIDs(body)
+}
+
+func (openAIProvider) parseResponse(body []byte) (string, error) {
+	var resp chatResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("LLM returned empty choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// ---------------- Anthropic Messages API ----------------
+
+// anthropicRequest Anthropic messages request（system 为顶层字段）
+type anthropicRequest struct {
+	Model       string        `json:"model"`
+	System      string        `json:"system,omitempty"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+// anthropicResponse Anthropic messages response
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// anthropicJSONPrefill 预填 assistant 回复的开头，约束模型直接输出 JSON 对象
+const anthropicJSONPrefill = "{"
+
+type anthropicProvider struct{}
+
+func (anthropicProvider) buildRequest(sysPrompt, userPrompt string, modelCfg config.LLMModelConfig) (*http.Request, error) {
+	messages := []chatMessage{{Role: "user", Content: userPrompt}}
+	if !modelCfg.DisableJSONMode {
+		messages = append(messages, chatMessage{Role: "assistant", Content: anthropicJSONPrefill})
+	}
+	body := anthropicRequest{
+		Model:       modelCfg.Model,
+		System:      sysPrompt,
+		Messages:    messages,
+		MaxTokens:   resolveMaxTokens(modelCfg),
+		Temperature: llmTemperature,
+	}
+
+	endpoint := strings.TrimRight(modelCfg.Endpoint, "/") + "/messages"
+	req, err := newJSONRequest(endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+	if modelCfg.APIKey != "" {
+		req.Header.Set("x-api-key", modelCfg.APIKey)
+	}
+	return req, nil
+}
+
+func (anthropicProvider) buildListModelsRequest(modelCfg config.LLMModelConfig) (*http.Request, error) {
+	req, err := http.NewRequest("GET", strings.TrimRight(modelCfg.Endpoint, "/")+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+	if modelCfg.APIKey != "" {
+		req.Header.Set("x-api-key", modelCfg.APIKey)
+	}
+	return req, nil
+}
+
+func (anthropicProvider) parseModelIDs(body []byte) ([]string, error) {
+	return parseModelListIDs(body)
+}
+
+func (anthropicProvider) parseResponse(body []byte) (string, error) {
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	var sb strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("LLM returned empty content")
+	}
+	text := sb.String()
+	// 预填内容不会出现在响应中，需要补回才是完整 JSON
+	if !strings.HasPrefix(strings.TrimSpace(text), anthropicJSONPrefill) {
+		text = anthropicJSONPrefill + text
+	}
+	return text, nil
+}
+
+// ---------------- Ollama ----------------
+
+// ollamaOptions Ollama 推理参数
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+// ollamaRequest Ollama /api/chat request
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   string        `json:"format,omitempty"`
+	Options  ollamaOptions `json:"options"`
+}
+
+// ollamaTagsResponse Ollama /api/tags response
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	} `json:"models"`
+}
+
+// ollamaResponse Ollama /api/chat response（非流式）
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Error string `json:"error"`
+}
+
+type ollamaProvider struct{}
+
+func (ollamaProvider) buildRequest(sysPrompt, userPrompt string, modelCfg config.LLMModelConfig) (*http.Request, error) {
+	body := ollamaRequest{
+		Model: modelCfg.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: sysPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Stream:  false,
+		Options: ollamaOptions{Temperature: llmTemperature, NumPredict: modelCfg.MaxTokens},
+	}
+	if !modelCfg.DisableJSONMode {
+		body.Format = "json"
+	}
+
+	endpoint := strings.TrimRight(modelCfg.Endpoint, "/") + "/api/chat"
+	req, err := newJSONRequest(endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	// Ollama 本身不鉴权，配置了 Key 时按反向代理常见的 Bearer 方式透传
+	if modelCfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+modelCfg.APIKey)
+	}
+	return req, nil
+}
+
+func (ollamaProvider) buildListModelsRequest(modelCfg config.LLMModelConfig) (*http.Request, error) {
+	req, err := http.NewRequest("GET", strings.TrimRight(modelCfg.Endpoint, "/")+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if modelCfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+modelCfg.APIKey)
+	}
+	return req, nil
+}
+
+func (ollamaProvider) parseModelIDs(body []byte) ([]string, error) {
+	var resp ollamaTagsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal model list: %w", err)
+	}
+	ids := make([]string, 0, len(resp.Models)*2)
+	for _, m := range resp.Models {
+		ids = append(ids, m.Name)
+		if m.Model != "" && m.Model != m.Name {
+			ids = append(ids, m.Model)
+		}
+	}
+	return ids, nil
+}
+
+func (ollamaProvider) parseResponse(body []byte) (string, error) {
+	var resp ollamaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("LLM returned error: %s", resp.Error)
+	}
+	if resp.Message.Content == "" {
+		return "", fmt.Errorf("LLM returned empty content")
+	}
+	return resp.Message.Content, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/task-monitor/api-server/internal/config"
+)
+
+func TestLLMService_CallLLM_OpenAIJSONMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+
+		var req map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, map[string]interface{}{"type": "json_object"}, req["response_format"])
+		messages := req["messages"].([]interface{})
+		assert.Equal(t, "system", messages[0].(map[string]interface{})["role"])
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": `{"summary":"ok"}`}}},
+		})
+	}))
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{})
+	content, err := svc.callLLM("sys", "user", config.LLMModelConfig{
+		Endpoint: server.URL + "/v1",
+		APIKey:   "sk-test",
+		Model:    "qwen2.5",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"summary":"ok"}`, content)
+}
+
+func TestLLMService_CallLLM_OpenAIJSONModeDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_, ok := req["response_format"]
+		assert.False(t, ok)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": "{}"}}},
+		})
+	}))
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{})
+	_, err := svc.callLLM("sys", "user", config.LLMModelConfig{
+		Provider:        LLMProviderOpenAI,
+		Endpoint:        server.URL,
+		Model:           "legacy",
+		DisableJSONMode: true,
+	})
+	assert.NoError(t, err)
+}
+
+func TestLLMService_CallLLM_Anthropic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "sk-ant", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var req anthropicRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "sys", req.System)
+		assert.Equal(t, defaultLLMMaxTokens, req.MaxTokens)
+		if assert.Len(t, req.Messages, 2) {
+			assert.Equal(t, "user", req.Messages[0].Role)
+			assert.Equal(t, "assistant", req.Messages[1].Role)
+			assert.Equal(t, "{", req.Messages[1].Content)
+		}
+
+		// 预填的 "{" 不会出现在响应中
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": `"summary":"ok"}`}},
+		})
+	}))
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{})
+	content, err := svc.callLLM("sys", "user", config.LLMModelConfig{
+		Provider: "Anthropic",
+		Endpoint: server.URL + "/v1",
+		APIKey:   "sk-ant",
+		Model:    "claude-sonnet",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"summary":"ok"}`, content)
+
+	result, err := svc.parseResponse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result.Summary)
+}
+
+func TestLLMService_CallLLM_Ollama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+
+		var req ollamaRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "json", req.Format)
+		assert.False(t, req.Stream)
+		assert.Equal(t, 2048, req.Options.NumPredict)
+		if assert.Len(t, req.Messages, 2) {
+			assert.Equal(t, "system", req.Messages[0].Role)
+			assert.Equal(t, "sys", req.Messages[0].Content)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": `{"summary":"ok"}`},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	svc := NewLLMService(new(MockJobServiceForLLM), nil, config.LLMConfig{})
+	content, err := svc.callLLM("sys", "user", config.LLMModelConfig{
+		Provider:  LLMProviderOllama,
+		Endpoint:  server.URL,
+		Model:     "qwen2.5:7b",
+		MaxTokens: 2048,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"summary":"ok"}`, content)
+}
+
+func TestResolveModelConfig_UnsupportedProvider(t *testing.T) {
+	cfg := config.LLMConfig{
+		Enabled: true,
+		Models: []config.LLMModelConfig{
+			{ID: "m1", Provider: "gemini", Endpoint: "http://x", Model: "m", Enabled: true},
+		},
+	}
+	_, err := resolveModelConfig(cfg, "m1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported provider")
+}
+
+func TestNormalizeLLMConfig_DefaultsProvider(t *testing.T) {
+	cfg := normalizeLLMConfig(config.LLMConfig{
+		Models: []config.LLMModelConfig{{ID: "m1", Endpoint: "http://x", Model: "m", Enabled: true}},
+	})
+	assert.Equal(t, LLMProviderOpenAI, cfg.Models[0].Provider)
+}
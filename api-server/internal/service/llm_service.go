@@ -1,17 +1,17 @@
 package service
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/metrics"
 	"github.com/task-monitor/api-server/internal/model"
 	"github.com/task-monitor/api-server/internal/repository"
 )
@@ -87,28 +87,6 @@ func (s *LLMService) GetAnalysis(jobID string) (*AnalysisWithStatus, error) {
 	return resp, nil
 }
 
-// chatMessage OpenAI chat message
-type chatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-// chatRequest OpenAI chat completions request
-type chatRequest struct {
-	Model       string        `json:"model"`
-	Messages    []chatMessage `json:"messages"`
-	Temperature float64       `json:"temperature"`
-}
-
-// chatResponse OpenAI chat completions response
-type chatResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-}
-
 // AnalyzeJob 异步分析作业（使用默认模型）
 func (s *LLMService) AnalyzeJob(jobID string) (*AnalysisWithStatus, error) {
 	return s.analyzeJobAsync(jobID, "")
@@ -189,7 +167,7 @@ func (s *LLMService) doAnalyze(jobID string, selectedModel config.LLMModelConfig
 	// 1. 聚合作业数据
 	userPrompt, err := s.buildUserPrompt(jobID)
 	if err != nil {
-		log.Printf("analyze job %s: build prompt failed: %v", jobID, err)
+		slog.Error("analyze job failed", "job_id", jobID, "stage", "build_prompt", "error", err)
 		s.analysisRepo.UpdateStatus(jobID, "failed", err.Error())
 		return err
 	}
@@ -197,7 +175,7 @@ func (s *LLMService) doAnalyze(jobID string, selectedModel config.LLMModelConfig
 	// 2. 调用LLM
 	content, err := s.callLLM(systemPrompt, userPrompt, selectedModel)
 	if err != nil {
-		log.Printf("analyze job %s: call LLM failed: %v", jobID, err)
+		slog.Error("analyze job failed", "job_id", jobID, "stage", "call_llm", "model_id", selectedModel.ID, "error", err)
 		s.analysisRepo.UpdateStatus(jobID, "failed", err.Error())
 		return err
 	}
@@ -205,7 +183,7 @@ func (s *LLMService) doAnalyze(jobID string, selectedModel config.LLMModelConfig
 	// 3. 解析返回的JSON
 	result, err := s.parseResponse(content)
 	if err != nil {
-		log.Printf("analyze job %s: parse response failed: %v", jobID, err)
+		slog.Error("analyze job failed", "job_id", jobID, "stage", "parse_response", "model_id", selectedModel.ID, "error", err)
 		s.analysisRepo.UpdateStatus(jobID, "failed", err.Error())
 		return err
 	}
@@ -213,11 +191,12 @@ func (s *LLMService) doAnalyze(jobID string, selectedModel config.LLMModelConfig
 	// 4. 持久化分析结果
 	resultJSON, err := json.Marshal(result)
 	if err != nil {
-		log.Printf("analyze job %s: marshal result failed: %v", jobID, err)
+		slog.Error("analyze job failed", "job_id", jobID, "stage", "marshal_result", "error", err)
 		s.analysisRepo.UpdateStatus(jobID, "failed", "")
 		return err
 	}
 	s.analysisRepo.UpdateStatus(jobID, "completed", string(resultJSON))
+	s.saveAnalysisFields(jobID, result)
 
 	// 5. 回写 job_type / framework（仅在原字段为空时）
 	s.backfillJobFields(jobID, result)
@@ -247,7 +226,7 @@ func (s *LLMService) backfillJobFields(jobID string, result *JobAnalysisResponse
 
 	if len(updates) > 0 {
 		if err := s.jobService.UpdateJobFields(jobID, updates); err != nil {
-			log.Printf("failed to backfill job fields for %s: %v", jobID, err)
+			slog.Warn("failed to backfill job fields", "job_id", jobID, "error", err)
 		}
 	}
 }
@@ -409,66 +388,61 @@ func (s *LLMService) buildUserPrompt(jobID string) (string, error) {
 	return sb.String(), nil
 }
 
-// callLLM 调用OpenAI兼容接口
+// callLLM 按模型配置的 provider 调用对应的 LLM 接口，返回模型输出文本
 func (s *LLMService) callLLM(sysPrompt, userPrompt string, modelCfg config.LLMModelConfig) (string, error) {
-	reqBody := chatRequest{
-		Model: modelCfg.Model,
-		Messages: []chatMessage{
-			{Role: "system", Content: sysPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		Temperature: 0.3,
+	provider, err := newLLMProvider(modelCfg.Provider)
+	if err != nil {
+		return "", err
 	}
 
-	bodyBytes, err := json.Marshal(reqBody)
+	req, err := provider.buildRequest(sysPrompt, userPrompt, modelCfg)
 	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
+		return "", err
 	}
 
-	endpoint := strings.TrimRight(modelCfg.Endpoint, "/") + "/chat/completions"
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(bodyBytes))
+	start := time.Now()
+	statusCode, respBytes, err := s.doLLMRequest(req, modelCfg.Timeout)
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		metrics.ObserveLLMCall(modelCfg.ID, modelCfg.Provider, "request_error", time.Since(start))
+		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	if modelCfg.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+modelCfg.APIKey)
+	if statusCode != http.StatusOK {
+		metrics.ObserveLLMCall(modelCfg.ID, modelCfg.Provider, "http_error", time.Since(start))
+		return "", fmt.Errorf("LLM API returned status %d: %s", statusCode, string(respBytes))
 	}
 
-	timeout := modelCfg.Timeout
+	content, err := provider.parseResponse(respBytes)
+	if err != nil {
+		metrics.ObserveLLMCall(modelCfg.ID, modelCfg.Provider, "parse_error", time.Since(start))
+		return "", err
+	}
+	metrics.ObserveLLMCall(modelCfg.ID, modelCfg.Provider, "success", time.Since(start))
+	return content, nil
+}
+
+// doLLMRequest 发送请求并读取完整响应体；timeout 为秒，<=0 时使用 60 秒
+func (s *LLMService) doLLMRequest(req *http.Request, timeout int) (int, []byte, error) {
 	if timeout <= 0 {
 		timeout = 60
 	}
+	s.mu.RLock()
 	client := s.httpClient
+	s.mu.RUnlock()
 	if client == nil || client.Timeout != time.Duration(timeout)*time.Second {
 		client = &http.Client{Timeout: time.Duration(timeout) * time.Second}
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http request: %w", err)
+		return 0, nil, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("LLM API returned status %d: %s", resp.StatusCode, string(respBytes))
-	}
-
-	var chatResp chatResponse
-	if err := json.Unmarshal(respBytes, &chatResp); err != nil {
-		return "", fmt.Errorf("unmarshal response: %w", err)
-	}
-
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("LLM returned empty choices")
+		return resp.StatusCode, nil, fmt.Errorf("read response: %w", err)
 	}
-
-	return chatResp.Choices[0].Message.Content, nil
+	return resp.StatusCode, respBytes, nil
 }
 
 // parseResponse 解析LLM返回的JSON
@@ -524,13 +498,16 @@ func normalizeLLMConfig(cfg config.LLMConfig) config.LLMConfig {
 			id = fmt.Sprintf("model-%d", i+1)
 		}
 		normalizedModels = append(normalizedModels, config.LLMModelConfig{
-			ID:       id,
-			Name:     strings.TrimSpace(m.Name),
-			Endpoint: strings.TrimSpace(m.Endpoint),
-			APIKey:   strings.TrimSpace(m.APIKey),
-			Model:    strings.TrimSpace(m.Model),
-			Timeout:  m.Timeout,
-			Enabled:  m.Enabled,
+			ID:              id,
+			Name:            strings.TrimSpace(m.Name),
+			Provider:        NormalizeLLMProvider(m.Provider),
+			Endpoint:        strings.TrimSpace(m.Endpoint),
+			APIKey:          strings.TrimSpace(m.APIKey),
+			Model:           strings.TrimSpace(m.Model),
+			Timeout:         m.Timeout,
+			MaxTokens:       m.MaxTokens,
+			DisableJSONMode: m.DisableJSONMode,
+			Enabled:         m.Enabled,
 		})
 	}
 
@@ -542,6 +519,7 @@ func normalizeLLMConfig(cfg config.LLMConfig) config.LLMConfig {
 		normalizedModels = []config.LLMModelConfig{{
 			ID:       "default",
 			Name:     "默认模型",
+			Provider: LLMProviderOpenAI,
 			Endpoint: legacyEndpoint,
 			APIKey:   legacyAPIKey,
 			Model:    legacyModel,
@@ -591,6 +569,7 @@ func resolveModelConfig(cfg config.LLMConfig, requestedModelID string) (config.L
 		return config.LLMModelConfig{
 			ID:       "default",
 			Name:     "默认模型",
+			Provider: LLMProviderOpenAI,
 			Endpoint: strings.TrimSpace(cfg.Endpoint),
 			APIKey:   strings.TrimSpace(cfg.APIKey),
 			Model:    strings.TrimSpace(cfg.Model),
@@ -623,6 +602,9 @@ func resolveModelConfig(cfg config.LLMConfig, requestedModelID string) (config.L
 	if selected.Endpoint == "" || selected.Model == "" {
 		return config.LLMModelConfig{}, fmt.Errorf("model %q is incomplete", modelID)
 	}
+	if !IsSupportedLLMProvider(selected.Provider) {
+		return config.LLMModelConfig{}, fmt.Errorf("model %q uses unsupported provider %q", modelID, selected.Provider)
+	}
 	if selected.Timeout <= 0 {
 		selected.Timeout = cfg.Timeout
 	}
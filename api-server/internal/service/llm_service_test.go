@@ -48,18 +48,28 @@ func (m *MockJobServiceForLLM) GetAllJobs() ([]model.Job, error) {
 	return args.Get(0).([]model.Job), args.Error(1)
 }
 
-func (m *MockJobServiceForLLM) GetJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error) {
-	args := m.Called(nodeID, statuses, jobTypes, frameworks, sortBy, sortOrder, page, pageSize)
+func (m *MockJobServiceForLLM) GetJobs(filter JobFilter, sortBy, sortOrder string, page, pageSize int) ([]model.Job, int64, error) {
+	args := m.Called(filter, sortBy, sortOrder, page, pageSize)
 	return args.Get(0).([]model.Job), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockJobServiceForLLM) GetGroupedJobs(nodeID string, statuses []string, jobTypes []string, frameworks []string, cardCounts []int, sortBy, sortOrder string, page, pageSize int) ([]JobGroup, int64, error) {
-	args := m.Called(nodeID, statuses, jobTypes, frameworks, cardCounts, sortBy, sortOrder, page, pageSize)
+func (m *MockJobServiceForLLM) GetGroupedJobs(filter JobGroupFilter, sortBy, sortOrder string, page, pageSize int) ([]JobGroup, int64, error) {
+	args := m.Called(filter, sortBy, sortOrder, page, pageSize)
 	return args.Get(0).([]JobGroup), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockJobServiceForLLM) GetDistinctCardCounts() ([]int, error) {
-	args := m.Called()
+func (m *MockJobServiceForLLM) GetJobsByCursor(filter JobFilter, sortBy, sortOrder, cursor string, pageSize int) ([]model.Job, int64, string, error) {
+	args := m.Called(filter, sortBy, sortOrder, cursor, pageSize)
+	return args.Get(0).([]model.Job), args.Get(1).(int64), args.String(2), args.Error(3)
+}
+
+func (m *MockJobServiceForLLM) GetGroupedJobsByCursor(filter JobGroupFilter, sortBy, sortOrder, cursor string, pageSize int) ([]JobGroup, int64, string, error) {
+	args := m.Called(filter, sortBy, sortOrder, cursor, pageSize)
+	return args.Get(0).([]JobGroup), args.Get(1).(int64), args.String(2), args.Error(3)
+}
+
+func (m *MockJobServiceForLLM) GetDistinctCardCounts(scope ProjectFilter) ([]int, error) {
+	args := m.Called(scope)
 	return args.Get(0).([]int), args.Error(1)
 }
 
@@ -68,20 +78,117 @@ func (m *MockJobServiceForLLM) GetJobParameters(jobID string) ([]model.Parameter
 	return args.Get(0).([]model.Parameter), args.Error(1)
 }
 
+func (m *MockJobServiceForLLM) GetJobsParameters(jobIDs []string) (map[string][]model.Parameter, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).(map[string][]model.Parameter), args.Error(1)
+}
+
+func (m *MockJobServiceForLLM) GetJobCodes(jobIDs []string) (map[string][]model.Code, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).(map[string][]model.Code), args.Error(1)
+}
+
+func (m *MockJobServiceForLLM) GetJobsNPUCards(jobs []model.Job) (map[string][]NPUCardInfo, error) {
+	args := m.Called(jobs)
+	return args.Get(0).(map[string][]NPUCardInfo), args.Error(1)
+}
+
 func (m *MockJobServiceForLLM) GetJobCode(jobID string) ([]model.Code, error) {
 	args := m.Called(jobID)
 	return args.Get(0).([]model.Code), args.Error(1)
 }
 
-func (m *MockJobServiceForLLM) GetJobStats() (map[string]int64, error) {
-	args := m.Called()
+func (m *MockJobServiceForLLM) GetJobStats(filter JobGroupFilter) (map[string]int64, error) {
+	args := m.Called(filter)
 	return args.Get(0).(map[string]int64), args.Error(1)
 }
 
+func (m *MockJobServiceForLLM) UpdateJobFields(jobID string, fields map[string]interface{}) error {
+	args := m.Called(jobID, fields)
+	return args.Error(0)
+}
+
+// MockJobAnalysisRepository implements repository.JobAnalysisRepositoryInterface for LLM tests
+type MockJobAnalysisRepository struct {
+	mock.Mock
+}
+
+func (m *MockJobAnalysisRepository) FindByJobID(jobID string) (*model.JobAnalysis, error) {
+	args := m.Called(jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.JobAnalysis), args.Error(1)
+}
+
+func (m *MockJobAnalysisRepository) FindByJobIDs(jobIDs []string) ([]model.JobAnalysis, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.JobAnalysis), args.Error(1)
+}
+
+func (m *MockJobAnalysisRepository) Upsert(analysis *model.JobAnalysis) error {
+	args := m.Called(analysis)
+	return args.Error(0)
+}
+
+func (m *MockJobAnalysisRepository) UpdateStatus(jobID, status, result string) error {
+	args := m.Called(jobID, status, result)
+	return args.Error(0)
+}
+
+func (m *MockJobAnalysisRepository) Search(query string, limit int, projects ProjectFilter) ([]model.JobAnalysis, error) {
+	args := m.Called(query, limit, projects)
+	return args.Get(0).([]model.JobAnalysis), args.Error(1)
+}
+
+func (m *MockJobAnalysisRepository) UpdateFields(jobID string, fields model.JobAnalysisFields) error {
+	args := m.Called(jobID, fields)
+	return args.Error(0)
+}
+
+func (m *MockJobAnalysisRepository) FindUnextracted(limit int) ([]model.JobAnalysis, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]model.JobAnalysis), args.Error(1)
+}
+
+func (m *MockJobAnalysisRepository) FindFieldsByJobIDs(jobIDs []string) ([]model.JobAnalysis, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.JobAnalysis), args.Error(1)
+}
+
+func (m *MockJobAnalysisRepository) FindJobIDsByFields(jobIDs []string, filter AnalysisFilter) ([]string, error) {
+	args := m.Called(jobIDs, filter)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// newMockAnalysisRepo 创建接受 analyzing 写入的分析仓库 mock
+func newMockAnalysisRepo() *MockJobAnalysisRepository {
+	repo := new(MockJobAnalysisRepository)
+	repo.On("Upsert", mock.Anything).Return(nil)
+	repo.On("UpdateFields", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return repo
+}
+
+// storedResult 取出 mock 仓库中以 completed 状态保存的分析结果
+func storedResult(t *testing.T, repo *MockJobAnalysisRepository, jobID string) *JobAnalysisResponse {
+	t.Helper()
+	for _, call := range repo.Calls {
+		if call.Method != "UpdateStatus" || call.Arguments.String(0) != jobID || call.Arguments.String(1) != "completed" {
+			continue
+		}
+		var result JobAnalysisResponse
+		if assert.NoError(t, json.Unmarshal([]byte(call.Arguments.String(2)), &result)) {
+			return &result
+		}
+	}
+	t.Fatalf("no completed analysis stored for %s", jobID)
+	return nil
+}
+
 func TestLLMService_AnalyzeJob_Disabled(t *testing.T) {
 	mockJobSvc := new(MockJobServiceForLLM)
 	cfg := config.LLMConfig{Enabled: false}
-	svc := NewLLMService(mockJobSvc, cfg)
+	svc := NewLLMService(mockJobSvc, newMockAnalysisRepo(), cfg)
 
 	result, err := svc.AnalyzeJob("job-001")
 	assert.Error(t, err)
@@ -101,7 +208,7 @@ func TestLLMService_AnalyzeJob_Success(t *testing.T) {
 			HbmUtilization: "medium",
 			Description:    "NPU利用率良好",
 		},
-		Issues:      []JobAnalysisIssue{},
+		Issues: []JobAnalysisIssue{},
 	}
 	resultJSON, _ := json.Marshal(analysisResult)
 
@@ -131,14 +238,20 @@ func TestLLMService_AnalyzeJob_Success(t *testing.T) {
 		Model:    "test-model",
 		Timeout:  10,
 	}
-	svc := NewLLMService(mockJobSvc, cfg)
+	analysisRepo := newMockAnalysisRepo()
+	analysisRepo.On("UpdateStatus", "job-001", "completed", mock.Anything).Return(nil)
+	svc := NewLLMService(mockJobSvc, analysisRepo, cfg)
 
-	result, err := svc.AnalyzeJob("job-001")
+	err := svc.AnalyzeJobSync("job-001")
 	assert.NoError(t, err)
-	assert.NotNil(t, result)
+	result := storedResult(t, analysisRepo, "job-001")
 	assert.Equal(t, "vLLM推理服务，使用Qwen2.5-7B模型", result.Summary)
 	assert.Equal(t, "inference", result.TaskType.Category)
+	analysisRepo.AssertCalled(t, "UpdateFields", "job-001", mock.MatchedBy(func(f model.JobAnalysisFields) bool {
+		return f.Category == "inference"
+	}))
 	mockJobSvc.AssertExpectations(t)
+	analysisRepo.AssertExpectations(t)
 }
 
 func TestLLMService_AnalyzeJob_LLMError(t *testing.T) {
@@ -157,12 +270,14 @@ func TestLLMService_AnalyzeJob_LLMError(t *testing.T) {
 		Model:    "test-model",
 		Timeout:  10,
 	}
-	svc := NewLLMService(mockJobSvc, cfg)
+	analysisRepo := newMockAnalysisRepo()
+	analysisRepo.On("UpdateStatus", "job-001", "failed", mock.Anything).Return(nil)
+	svc := NewLLMService(mockJobSvc, analysisRepo, cfg)
 
-	result, err := svc.AnalyzeJob("job-001")
+	err := svc.AnalyzeJobSync("job-001")
 	assert.Error(t, err)
-	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "status 500")
+	analysisRepo.AssertExpectations(t)
 }
 
 func TestLLMService_AnalyzeJob_MarkdownWrappedJSON(t *testing.T) {
@@ -176,7 +291,7 @@ func TestLLMService_AnalyzeJob_MarkdownWrappedJSON(t *testing.T) {
 			HbmUtilization: "high",
 			Description:    "HBM使用率较高",
 		},
-		Issues:      []JobAnalysisIssue{},
+		Issues: []JobAnalysisIssue{},
 	}
 	resultJSON, _ := json.Marshal(analysisResult)
 	// LLM返回markdown包裹的JSON
@@ -202,11 +317,13 @@ func TestLLMService_AnalyzeJob_MarkdownWrappedJSON(t *testing.T) {
 		Model:    "test-model",
 		Timeout:  10,
 	}
-	svc := NewLLMService(mockJobSvc, cfg)
+	analysisRepo := newMockAnalysisRepo()
+	analysisRepo.On("UpdateStatus", "job-001", "completed", mock.Anything).Return(nil)
+	svc := NewLLMService(mockJobSvc, analysisRepo, cfg)
 
-	result, err := svc.AnalyzeJob("job-001")
+	err := svc.AnalyzeJobSync("job-001")
 	assert.NoError(t, err)
-	assert.NotNil(t, result)
+	result := storedResult(t, analysisRepo, "job-001")
 	assert.Equal(t, "训练作业", result.Summary)
 	assert.Equal(t, "training", result.TaskType.Category)
 }
@@ -272,7 +389,7 @@ func TestLLMService_GetConfig_MasksAPIKey(t *testing.T) {
 		Model:    "qwen2.5",
 		Timeout:  60,
 	}
-	svc := NewLLMService(mockJobSvc, cfg)
+	svc := NewLLMService(mockJobSvc, nil, cfg)
 
 	result := svc.GetConfig()
 	assert.Equal(t, "****3456", result.APIKey)
@@ -283,7 +400,7 @@ func TestLLMService_GetConfig_MasksAPIKey(t *testing.T) {
 func TestLLMService_GetConfig_ShortAPIKey(t *testing.T) {
 	mockJobSvc := new(MockJobServiceForLLM)
 	cfg := config.LLMConfig{APIKey: "ab"}
-	svc := NewLLMService(mockJobSvc, cfg)
+	svc := NewLLMService(mockJobSvc, nil, cfg)
 
 	result := svc.GetConfig()
 	assert.Equal(t, "****", result.APIKey)
@@ -292,7 +409,7 @@ func TestLLMService_GetConfig_ShortAPIKey(t *testing.T) {
 func TestLLMService_GetConfig_EmptyAPIKey(t *testing.T) {
 	mockJobSvc := new(MockJobServiceForLLM)
 	cfg := config.LLMConfig{APIKey: ""}
-	svc := NewLLMService(mockJobSvc, cfg)
+	svc := NewLLMService(mockJobSvc, nil, cfg)
 
 	result := svc.GetConfig()
 	assert.Equal(t, "", result.APIKey)
@@ -305,7 +422,7 @@ func TestLLMService_UpdateConfig(t *testing.T) {
 		Model:   "old-model",
 		Timeout: 30,
 	}
-	svc := NewLLMService(mockJobSvc, cfg)
+	svc := NewLLMService(mockJobSvc, nil, cfg)
 
 	newCfg := config.LLMConfig{
 		Enabled:  true,
@@ -353,15 +470,17 @@ func setupMockJobData(mockJobSvc *MockJobServiceForLLM, jobID string) {
 				Metrics: []model.NPUMetric{
 					{
 						AICoreUsagePercent: &aicore,
-						HBMUsageMB:        &hbmUsed,
-						HBMTotalMB:        &hbmTotal,
+						HBMUsageMB:         &hbmUsed,
+						HBMTotalMB:         &hbmTotal,
 					},
 				},
 			},
 		},
 		RelatedJobs: []model.Job{},
 	}
-	mockJobSvc.On("GetJobDetail", jobID).Return(detail, nil)
+	mockJobSvc.On("GetJobDetail", jobID, true).Return(detail, nil)
+	mockJobSvc.On("GetJobByID", jobID).Return(&detail.Job, nil).Maybe()
+	mockJobSvc.On("UpdateJobFields", jobID, mock.Anything).Return(nil).Maybe()
 
 	paramData := `{"model":"Qwen2.5-7B","tensor_parallel_size":"1"}`
 	envVars := `{"PATH":"/usr/bin","CUDA_VISIBLE_DEVICES":"0"}`
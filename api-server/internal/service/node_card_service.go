@@ -0,0 +1,555 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// NPU 卡状态
+const (
+	CardStatusFree      = "free"      // 指标正常、无运行中的进程
+	CardStatusBusy      = "busy"      // 有运行中的 NPU 进程
+	CardStatusUnhealthy = "unhealthy" // 无进程但有芯片健康状态不是 OK
+	CardStatusNoData    = "no_data"   // 过期时间内没有上报指标
+)
+
+// MaxFreeCardCount 空闲卡查询单节点需要的最大卡数
+const MaxFreeCardCount = 64
+
+// NodeOverviewStatusChangeLimit 节点概览返回的最近状态变更条数
+const NodeOverviewStatusChangeLimit = 20
+
+// nodeGroupScanChunkSize 查询节点上运行中作业分组时每批的分组数
+const nodeGroupScanChunkSize = 500
+
+// ErrInvalidFreeCardQuery 空闲卡查询参数不合法
+var ErrInvalidFreeCardQuery = errors.New("invalid free card query")
+
+// NodeCardProcess 占用 NPU 卡的运行中进程及其所属的作业分组，未对应到运行中分组时分组字段为空
+type NodeCardProcess struct {
+	PID           int64    `json:"pid"`
+	ChipID        *int     `json:"chipId"` // 为空表示整卡占用
+	ProcessName   string   `json:"processName"`
+	MemoryUsageMB *float64 `json:"memoryUsageMb"`
+	JobID         string   `json:"jobId,omitempty"` // 分组主作业ID
+	JobName       string   `json:"jobName,omitempty"`
+	GroupID       uint     `json:"groupId,omitempty"`
+}
+
+// NodeCardChip 卡上单个芯片的最新指标，ChipID 为同卡芯片按 bus_id 排序后的序号（与作业详情一致）
+type NodeCardChip struct {
+	ChipID             int       `json:"chipId"`
+	BusID              string    `json:"busId"`
+	Name               string    `json:"name"`
+	Health             string    `json:"health"`
+	TempC              *float64  `json:"tempC"`
+	PowerW             *float64  `json:"powerW"`
+	AICoreUsagePercent *float64  `json:"aicoreUsagePercent"`
+	HBMUsageMB         *float64  `json:"hbmUsageMb"`
+	HBMTotalMB         *float64  `json:"hbmTotalMb"`
+	Used               bool      `json:"used"`
+	Timestamp          time.Time `json:"timestamp"`
+}
+
+// NodeCard 单张 NPU 卡的状态、芯片指标汇总与占用进程
+type NodeCard struct {
+	NPUID              int               `json:"npuId"`
+	Status             string            `json:"status"` // free / busy / unhealthy / no_data
+	Health             string            `json:"health"` // 全部芯片为 OK 时为 OK，否则为第一个异常芯片的状态；无指标时为空
+	TempC              *float64          `json:"tempC"`  // 芯片最高温度
+	PowerW             *float64          `json:"powerW"` // 芯片功耗之和
+	AICoreUsagePercent *float64          `json:"aicoreUsagePercent"`
+	HBMUsageMB         *float64          `json:"hbmUsageMb"`
+	HBMTotalMB         *float64          `json:"hbmTotalMb"`
+	HBMUsagePercent    *float64          `json:"hbmUsagePercent"`
+	LastReportAt       *time.Time        `json:"lastReportAt"`
+	Chips              []NodeCardChip    `json:"chips"`
+	Processes          []NodeCardProcess `json:"processes"`
+}
+
+// NodeCards 节点上每张 NPU 卡的状态
+type NodeCards struct {
+	NodeID         string     `json:"nodeId"`
+	Hostname       string     `json:"hostname"`
+	NPUModel       string     `json:"npuModel"`
+	NPUCount       *int       `json:"npuCount"` // 节点登记的卡数
+	TotalCards     int        `json:"totalCards"`
+	FreeCards      int        `json:"freeCards"`
+	BusyCards      int        `json:"busyCards"`
+	UnhealthyCards int        `json:"unhealthyCards"`
+	NoDataCards    int        `json:"noDataCards"`
+	Cards          []NodeCard `json:"cards"`
+	Timestamp      time.Time  `json:"timestamp"`
+}
+
+// FreeCardNode 有足够空闲卡的节点
+type FreeCardNode struct {
+	NodeID    string `json:"nodeId"`
+	Hostname  string `json:"hostname"`
+	NPUModel  string `json:"npuModel"`
+	FreeCount int    `json:"freeCount"`
+	FreeCards []int  `json:"freeCards"`
+}
+
+// FreeCardsResult 空闲卡查询结果，节点按空闲卡数从少到多排列，优先使用碎片节点
+type FreeCardsResult struct {
+	NPUModel       string         `json:"npuModel"`
+	Count          int            `json:"count"`
+	TotalFreeCards int            `json:"totalFreeCards"` // 满足条件的节点上的空闲卡总数
+	Nodes          []FreeCardNode `json:"nodes"`
+	Timestamp      time.Time      `json:"timestamp"`
+}
+
+// NodeOverview 节点概览，节点页一次请求即可取得全部数据
+type NodeOverview struct {
+	Node                model.Node                    `json:"node"`
+	HeartbeatAgeSeconds *int64                        `json:"heartbeatAgeSeconds"` // 距最近一次心跳的秒数，无心跳记录时为空
+	Usage               NPUUsageStats                 `json:"usage"`               // 整机功耗与利用率汇总
+	RunningGroupCount   int                           `json:"runningGroupCount"`
+	RunningJobGroups    []JobGroup                    `json:"runningJobGroups"`
+	Cards               *NodeCards                    `json:"cards"`
+	RecentStatusChanges []repository.NodeStatusChange `json:"recentStatusChanges"`
+	Timestamp           time.Time                     `json:"timestamp"`
+}
+
+// NodeCardService 按卡查看节点的 NPU 状态与占用并汇总节点概览，数据来自 nodes、npu_metrics、npu_processes、
+// job_status_histories 与运行中的作业分组
+type NodeCardService struct {
+	nodeRepo    repository.NodeRepositoryInterface
+	metricsRepo repository.MetricsRepositoryInterface
+	historyRepo repository.JobStatusHistoryRepositoryInterface
+	jobService  JobServiceInterface
+	now         func() time.Time
+
+	mu         sync.RWMutex
+	staleAfter time.Duration
+}
+
+// NewNodeCardService 创建节点卡状态服务；staleAfter 内未上报指标的芯片视为无数据
+func NewNodeCardService(nodeRepo repository.NodeRepositoryInterface, metricsRepo repository.MetricsRepositoryInterface,
+	jobService JobServiceInterface, staleAfter time.Duration) *NodeCardService {
+	return &NodeCardService{
+		nodeRepo:    nodeRepo,
+		metricsRepo: metricsRepo,
+		jobService:  jobService,
+		now:         time.Now,
+		staleAfter:  staleAfter,
+	}
+}
+
+// SetStatusHistoryRepository 设置作业状态历史仓库，未设置时节点概览不含状态变更
+func (s *NodeCardService) SetStatusHistoryRepository(repo repository.JobStatusHistoryRepositoryInterface) {
+	s.historyRepo = repo
+}
+
+// SetStaleAfter 更新芯片过期时间，支持热加载
+func (s *NodeCardService) SetStaleAfter(d time.Duration) {
+	s.mu.Lock()
+	s.staleAfter = d
+	s.mu.Unlock()
+}
+
+func (s *NodeCardService) since() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.now().Add(-s.staleAfter)
+}
+
+// GetNodeCards 获取节点上每张卡的健康状态、最新指标与占用进程；节点不存在时返回 gorm.ErrRecordNotFound。
+// 属于 scope 之外分组的进程仍计入卡状态，但不返回其作业ID、作业名与分组ID
+func (s *NodeCardService) GetNodeCards(nodeID string, scope ProjectFilter) (*NodeCards, error) {
+	snap, err := s.loadNode(nodeID, scope)
+	if err != nil {
+		return nil, err
+	}
+	return s.nodeCards(snap), nil
+}
+
+// GetNodeOverview 获取节点页所需的全部数据：节点信息、心跳间隔、运行中的作业分组、每卡状态、
+// 整机功耗与利用率汇总以及最近的作业状态变更；节点不存在时返回 gorm.ErrRecordNotFound。
+// 分组列表与状态变更只包含 scope 内的作业，功耗与利用率汇总仍按整机计算
+func (s *NodeCardService) GetNodeOverview(nodeID string, scope ProjectFilter) (*NodeOverview, error) {
+	snap, err := s.loadNode(nodeID, scope)
+	if err != nil {
+		return nil, err
+	}
+	changes := []repository.NodeStatusChange{}
+	if s.historyRepo != nil {
+		rows, err := s.historyRepo.FindRecentByNode(nodeID, NodeOverviewStatusChangeLimit, scope)
+		if err != nil {
+			return nil, fmt.Errorf("query status changes: %w", err)
+		}
+		if rows != nil {
+			changes = rows
+		}
+	}
+
+	running := make([]repository.RunningNPUProcess, 0, len(snap.processes))
+	for _, p := range snap.processes {
+		if p.NPUID == nil || p.PID == nil {
+			continue
+		}
+		running = append(running, repository.RunningNPUProcess{NodeID: nodeID, NPUID: *p.NPUID, ChipID: p.ChipID, PID: *p.PID})
+	}
+	groups := make([]JobGroup, 0, len(snap.groups))
+	for _, g := range snap.groups {
+		if snap.isVisible(g.MainJob.JobID) {
+			groups = append(groups, g)
+		}
+	}
+
+	now := s.now()
+	overview := &NodeOverview{
+		Node:                *snap.node,
+		Usage:               summarizeNPUUsage(snap.chips, running),
+		RunningGroupCount:   len(groups),
+		RunningJobGroups:    groups,
+		Cards:               s.nodeCards(snap),
+		RecentStatusChanges: changes,
+		Timestamp:           now,
+	}
+	if hb := snap.node.LastHeartbeat; hb != nil {
+		age := int64(now.Sub(*hb).Seconds())
+		overview.HeartbeatAgeSeconds = &age
+	}
+	return overview, nil
+}
+
+// nodeSnapshot 单个节点的指标、进程与运行中分组；visible 为可见范围内的分组主作业ID，nil 表示全部可见
+type nodeSnapshot struct {
+	node      *model.Node
+	chips     []model.NPUMetric
+	processes []model.NPUProcess
+	groups    []JobGroup
+	visible   map[string]bool
+}
+
+func (s *NodeCardService) loadNode(nodeID string, scope ProjectFilter) (*nodeSnapshot, error) {
+	node, err := s.nodeRepo.FindByID(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	chips, err := s.metricsRepo.FindLatestNodeNPUMetricsSince(nodeID, s.since())
+	if err != nil {
+		return nil, fmt.Errorf("query npu metrics: %w", err)
+	}
+	processes, err := s.metricsRepo.FindRunningNPUProcessesByNode(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("query npu processes: %w", err)
+	}
+	groups, err := s.runningGroupsOnNode(nodeID, ProjectFilter{})
+	if err != nil {
+		return nil, err
+	}
+	snap := &nodeSnapshot{node: node, chips: chips, processes: processes, groups: groups}
+	if !scope.IsEmpty() {
+		// 卡状态与进程归属需要全部分组，可见性单独按 scope 再查一次
+		visibleGroups, err := s.runningGroupsOnNode(nodeID, scope)
+		if err != nil {
+			return nil, err
+		}
+		snap.visible = make(map[string]bool, len(visibleGroups))
+		for _, g := range visibleGroups {
+			snap.visible[g.MainJob.JobID] = true
+		}
+	}
+	return snap, nil
+}
+
+// isVisible 判断分组主作业是否在可见范围内
+func (snap *nodeSnapshot) isVisible(mainJobID string) bool {
+	return snap.visible == nil || snap.visible[mainJobID]
+}
+
+func (s *NodeCardService) nodeCards(snap *nodeSnapshot) *NodeCards {
+	node := snap.node
+	result := &NodeCards{
+		NodeID:   node.NodeID,
+		Hostname: stringOrEmpty(node.Hostname),
+		NPUModel: stringOrEmpty(node.NPUModel),
+		NPUCount: node.NPUCount,
+		Cards:    buildNodeCards(node.NPUCount, snap.chips, snap.processes, snap.groups),
+	}
+	for i := range result.Cards {
+		procs := result.Cards[i].Processes
+		for j := range procs {
+			if procs[j].JobID != "" && !snap.isVisible(procs[j].JobID) {
+				procs[j].JobID, procs[j].JobName, procs[j].GroupID = "", "", 0
+			}
+		}
+	}
+	for _, card := range result.Cards {
+		switch card.Status {
+		case CardStatusFree:
+			result.FreeCards++
+		case CardStatusBusy:
+			result.BusyCards++
+		case CardStatusUnhealthy:
+			result.UnhealthyCards++
+		case CardStatusNoData:
+			result.NoDataCards++
+		}
+	}
+	result.TotalCards = len(result.Cards)
+	result.Timestamp = s.now()
+	return result
+}
+
+// FindFreeCards 查找全集群有至少 count 张空闲卡的活跃节点；npuModel 非空时按节点登记的 NPU 型号
+// （未登记时按芯片名称）忽略大小写匹配。卡的占用始终按全部运行中进程判断，否则其他项目占用的卡
+// 会被误报为空闲；scope 指定了项目时只在这些项目运行中作业所在的节点内查找，仅受可见范围限制时
+// 不缩小节点范围（空闲卡不含作业信息）
+func (s *NodeCardService) FindFreeCards(npuModel string, count int, scope ProjectFilter) (*FreeCardsResult, error) {
+	if count < 1 || count > MaxFreeCardCount {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidFreeCardQuery, MaxFreeCardCount)
+	}
+	nodes, err := s.nodeRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("query nodes: %w", err)
+	}
+	chips, err := s.metricsRepo.FindLatestNPUMetricsSince(s.since())
+	if err != nil {
+		return nil, fmt.Errorf("query npu metrics: %w", err)
+	}
+	running, err := s.metricsRepo.FindRunningNPUProcesses(repository.ProjectFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("query npu processes: %w", err)
+	}
+	var projectNodes map[string]bool
+	if len(scope.ProjectIDs) > 0 {
+		scoped, err := s.metricsRepo.FindRunningNPUProcesses(scope)
+		if err != nil {
+			return nil, fmt.Errorf("query project npu processes: %w", err)
+		}
+		projectNodes = make(map[string]bool, len(scoped))
+		for _, p := range scoped {
+			projectNodes[p.NodeID] = true
+		}
+	}
+
+	chipsByNode := make(map[string][]model.NPUMetric)
+	for _, c := range chips {
+		id := stringOrEmpty(c.NodeID)
+		chipsByNode[id] = append(chipsByNode[id], c)
+	}
+	procsByNode := make(map[string][]model.NPUProcess)
+	for _, p := range running {
+		npuID, pid := p.NPUID, p.PID
+		procsByNode[p.NodeID] = append(procsByNode[p.NodeID], model.NPUProcess{NPUID: &npuID, ChipID: p.ChipID, PID: &pid})
+	}
+
+	result := &FreeCardsResult{NPUModel: npuModel, Count: count, Nodes: []FreeCardNode{}, Timestamp: s.now()}
+	for _, n := range nodes {
+		if stringOrEmpty(n.Status) != "active" || (projectNodes != nil && !projectNodes[n.NodeID]) {
+			continue
+		}
+		nodeModel := stringOrEmpty(n.NPUModel)
+		if nodeModel == "" {
+			for _, c := range chipsByNode[n.NodeID] {
+				if name := stringOrEmpty(c.Name); name != "" {
+					nodeModel = name
+					break
+				}
+			}
+		}
+		if npuModel != "" && !strings.EqualFold(nodeModel, npuModel) {
+			continue
+		}
+		free := []int{}
+		for _, card := range buildNodeCards(n.NPUCount, chipsByNode[n.NodeID], procsByNode[n.NodeID], nil) {
+			if card.Status == CardStatusFree {
+				free = append(free, card.NPUID)
+			}
+		}
+		if len(free) < count {
+			continue
+		}
+		result.Nodes = append(result.Nodes, FreeCardNode{
+			NodeID:    n.NodeID,
+			Hostname:  stringOrEmpty(n.Hostname),
+			NPUModel:  nodeModel,
+			FreeCount: len(free),
+			FreeCards: free,
+		})
+		result.TotalFreeCards += len(free)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		if result.Nodes[i].FreeCount != result.Nodes[j].FreeCount {
+			return result.Nodes[i].FreeCount < result.Nodes[j].FreeCount
+		}
+		return result.Nodes[i].NodeID < result.Nodes[j].NodeID
+	})
+	return result, nil
+}
+
+// runningGroupsOnNode 按游标分批查询节点上 projects 范围内全部运行中的作业分组
+func (s *NodeCardService) runningGroupsOnNode(nodeID string, projects ProjectFilter) ([]JobGroup, error) {
+	filter := JobGroupFilter{JobFilter: repository.JobFilter{NodeIDs: []string{nodeID}, Statuses: []string{"running"}, Projects: projects}}
+	var all []JobGroup
+	cursor := ""
+	for {
+		groups, _, next, err := s.jobService.GetGroupedJobsByCursor(filter, "startTime", "desc", cursor, nodeGroupScanChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("query job groups: %w", err)
+		}
+		all = append(all, groups...)
+		if next == "" {
+			return all, nil
+		}
+		cursor = next
+	}
+}
+
+// buildNodeCards 按卡汇总芯片指标与运行中的进程。卡号取登记卡数范围内的全部卡与有指标或进程的卡；
+// 进程的 chip_id 对应同卡芯片按 bus_id 排序后的序号，未记录或无法对应时视为整卡占用（与集群统计一致）。
+// 进程按 PID 对应到分组的主作业或 NPU 子进程
+func buildNodeCards(npuCount *int, chips []model.NPUMetric, processes []model.NPUProcess, groups []JobGroup) []NodeCard {
+	ids := make(map[int]bool)
+	if npuCount != nil {
+		for i := 0; i < *npuCount; i++ {
+			ids[i] = true
+		}
+	}
+	chipsByCard := make(map[int][]model.NPUMetric)
+	for _, c := range chips {
+		if c.NPUID != nil {
+			ids[*c.NPUID] = true
+			chipsByCard[*c.NPUID] = append(chipsByCard[*c.NPUID], c)
+		}
+	}
+	procsByCard := make(map[int][]model.NPUProcess)
+	for _, p := range processes {
+		if p.NPUID != nil && p.PID != nil {
+			ids[*p.NPUID] = true
+			procsByCard[*p.NPUID] = append(procsByCard[*p.NPUID], p)
+		}
+	}
+	groupByPID := make(map[int64]*JobGroup)
+	for i := range groups {
+		for _, job := range append([]model.Job{groups[i].MainJob}, groups[i].ChildJobs...) {
+			if job.PID != nil {
+				if _, ok := groupByPID[*job.PID]; !ok {
+					groupByPID[*job.PID] = &groups[i]
+				}
+			}
+		}
+	}
+
+	sorted := make([]int, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Ints(sorted)
+	cards := make([]NodeCard, 0, len(sorted))
+	for _, id := range sorted {
+		cards = append(cards, buildNodeCard(id, chipsByCard[id], procsByCard[id], groupByPID))
+	}
+	return cards
+}
+
+func buildNodeCard(npuID int, chips []model.NPUMetric, processes []model.NPUProcess, groupByPID map[int64]*JobGroup) NodeCard {
+	card := NodeCard{NPUID: npuID, Chips: make([]NodeCardChip, 0, len(chips)), Processes: make([]NodeCardProcess, 0, len(processes))}
+	sort.Slice(chips, func(i, j int) bool { return stringOrEmpty(chips[i].BusID) < stringOrEmpty(chips[j].BusID) })
+
+	wholeCard := false
+	usedChips := make(map[int]bool)
+	type procKey struct {
+		pid  int64
+		chip int
+	}
+	seen := make(map[procKey]bool)
+	for _, p := range processes {
+		chip := -1
+		if p.ChipID != nil {
+			chip = *p.ChipID
+		}
+		if seen[procKey{*p.PID, chip}] {
+			continue
+		}
+		seen[procKey{*p.PID, chip}] = true
+		if p.ChipID == nil || *p.ChipID < 0 || *p.ChipID >= len(chips) {
+			wholeCard = true
+		} else {
+			usedChips[*p.ChipID] = true
+		}
+		proc := NodeCardProcess{PID: *p.PID, ChipID: p.ChipID, ProcessName: stringOrEmpty(p.ProcessName), MemoryUsageMB: p.MemoryUsageMB}
+		if g := groupByPID[*p.PID]; g != nil {
+			proc.JobID, proc.JobName, proc.GroupID = g.MainJob.JobID, stringOrEmpty(g.MainJob.JobName), g.GroupID
+		}
+		card.Processes = append(card.Processes, proc)
+	}
+
+	var aicoreSum, powerSum, hbmUsed, hbmTotal float64
+	var aicoreN, powerN, hbmN int
+	for idx, c := range chips {
+		health := stringOrEmpty(c.Health)
+		card.Chips = append(card.Chips, NodeCardChip{
+			ChipID:             idx,
+			BusID:              stringOrEmpty(c.BusID),
+			Name:               stringOrEmpty(c.Name),
+			Health:             health,
+			TempC:              c.TempC,
+			PowerW:             c.PowerW,
+			AICoreUsagePercent: c.AICoreUsagePercent,
+			HBMUsageMB:         c.HBMUsageMB,
+			HBMTotalMB:         c.HBMTotalMB,
+			Used:               wholeCard || usedChips[idx],
+			Timestamp:          c.Timestamp,
+		})
+		if card.Health == "" || (card.Health == "OK" && health != "OK") {
+			card.Health = health
+		}
+		if c.TempC != nil && (card.TempC == nil || *c.TempC > *card.TempC) {
+			card.TempC = c.TempC
+		}
+		if c.PowerW != nil {
+			powerSum += *c.PowerW
+			powerN++
+		}
+		if c.AICoreUsagePercent != nil {
+			aicoreSum += *c.AICoreUsagePercent
+			aicoreN++
+		}
+		if c.HBMUsageMB != nil && c.HBMTotalMB != nil {
+			hbmUsed += *c.HBMUsageMB
+			hbmTotal += *c.HBMTotalMB
+			hbmN++
+		}
+		if card.LastReportAt == nil || c.Timestamp.After(*card.LastReportAt) {
+			ts := c.Timestamp
+			card.LastReportAt = &ts
+		}
+	}
+	card.AICoreUsagePercent = average(aicoreSum, aicoreN)
+	if powerN > 0 {
+		v := round2(powerSum)
+		card.PowerW = &v
+	}
+	if hbmN > 0 {
+		used, total := round2(hbmUsed), round2(hbmTotal)
+		card.HBMUsageMB, card.HBMTotalMB = &used, &total
+		if hbmTotal > 0 {
+			v := round2(hbmUsed / hbmTotal * 100)
+			card.HBMUsagePercent = &v
+		}
+	}
+
+	switch {
+	case len(card.Processes) > 0:
+		card.Status = CardStatusBusy
+	case len(chips) == 0:
+		card.Status = CardStatusNoData
+	case card.Health != "OK":
+		card.Status = CardStatusUnhealthy
+	default:
+		card.Status = CardStatusFree
+	}
+	return card
+}
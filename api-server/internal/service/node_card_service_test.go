@@ -0,0 +1,325 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"gorm.io/gorm"
+)
+
+func npuProcess(npuID int, chipID *int, pid int64, name string) model.NPUProcess {
+	return model.NPUProcess{NPUID: &npuID, ChipID: chipID, PID: &pid, ProcessName: &name}
+}
+
+func TestNodeCardService_GetNodeCards(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockJobService := new(MockJobServiceForLLM)
+	svc := NewNodeCardService(mockNodeRepo, mockMetricsRepo, mockJobService, 10*time.Minute)
+	now := time.Unix(1770373800, 0)
+	svc.now = func() time.Time { return now }
+
+	four, hostname := 4, "host-1"
+	mockNodeRepo.On("FindByID", "node-1").Return(&model.Node{NodeID: "node-1", Hostname: &hostname, NPUCount: &four}, nil)
+	warning := statsChip("node-1", 2, "0000:C4:00.0", 0, 0, 32000, 60)
+	health := "Warning"
+	warning.Health = &health
+	mockMetricsRepo.On("FindLatestNodeNPUMetricsSince", "node-1", now.Add(-10*time.Minute)).Return([]model.NPUMetric{
+		// 卡 0 有两个芯片，进程只占用 chip 1（bus_id 排序后的第二个）
+		statsChip("node-1", 0, "0000:C2:00.0", 90, 30000, 32000, 300),
+		statsChip("node-1", 0, "0000:C1:00.0", 10, 2000, 32000, 100),
+		statsChip("node-1", 1, "0000:C3:00.0", 0, 0, 32000, 70),
+		warning,
+	}, nil)
+	chip1 := 1
+	mockMetricsRepo.On("FindRunningNPUProcessesByNode", "node-1").Return([]model.NPUProcess{
+		npuProcess(0, &chip1, 101, "python"),
+		npuProcess(0, &chip1, 101, "python"),
+		npuProcess(3, nil, 300, "orphan"),
+	}, nil)
+	pid100, pid101 := int64(100), int64(101)
+	jobName := "train"
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return f.NodeIDs[0] == "node-1" && f.Statuses[0] == "running"
+	}), "startTime", "desc", "", nodeGroupScanChunkSize).Return([]JobGroup{{
+		MainJob:   model.Job{JobID: "job-a", PID: &pid100, JobName: &jobName},
+		ChildJobs: []model.Job{{JobID: "job-b", PID: &pid101}},
+		GroupID:   7,
+	}}, int64(1), "", nil)
+
+	result, err := svc.GetNodeCards("node-1", ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, "host-1", result.Hostname)
+	assert.Equal(t, 4, result.TotalCards)
+	assert.Equal(t, 1, result.FreeCards)
+	assert.Equal(t, 2, result.BusyCards)
+	assert.Equal(t, 1, result.UnhealthyCards)
+	assert.Equal(t, 0, result.NoDataCards)
+
+	card := result.Cards[0]
+	assert.Equal(t, CardStatusBusy, card.Status)
+	assert.Equal(t, "OK", card.Health)
+	assert.Equal(t, 400.0, *card.PowerW)
+	assert.Equal(t, 50.0, *card.AICoreUsagePercent)
+	assert.Equal(t, 50.0, *card.HBMUsagePercent)
+	require.Len(t, card.Chips, 2)
+	assert.Equal(t, "0000:C1:00.0", card.Chips[0].BusID)
+	assert.False(t, card.Chips[0].Used)
+	assert.True(t, card.Chips[1].Used)
+	// 重复的进程记录只保留一条，子进程对应到分组主作业
+	require.Len(t, card.Processes, 1)
+	assert.Equal(t, NodeCardProcess{PID: 101, ChipID: &chip1, ProcessName: "python", JobID: "job-a", JobName: "train", GroupID: 7}, card.Processes[0])
+
+	assert.Equal(t, CardStatusFree, result.Cards[1].Status)
+	assert.Equal(t, CardStatusUnhealthy, result.Cards[2].Status)
+	assert.Equal(t, "Warning", result.Cards[2].Health)
+	// 没有指标但有进程的卡仍为占用，未对应到分组的进程分组字段为空
+	assert.Equal(t, CardStatusBusy, result.Cards[3].Status)
+	assert.Empty(t, result.Cards[3].Chips)
+	assert.Equal(t, "", result.Cards[3].Processes[0].JobID)
+}
+
+func TestNodeCardService_GetNodeCards_ProjectScope(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockJobService := new(MockJobServiceForLLM)
+	svc := NewNodeCardService(mockNodeRepo, mockMetricsRepo, mockJobService, 10*time.Minute)
+	now := time.Unix(1770373800, 0)
+	svc.now = func() time.Time { return now }
+
+	two := 2
+	mockNodeRepo.On("FindByID", "node-1").Return(&model.Node{NodeID: "node-1", NPUCount: &two}, nil)
+	mockMetricsRepo.On("FindLatestNodeNPUMetricsSince", "node-1", now.Add(-10*time.Minute)).Return([]model.NPUMetric{}, nil)
+	mockMetricsRepo.On("FindRunningNPUProcessesByNode", "node-1").Return([]model.NPUProcess{
+		npuProcess(0, nil, 100, "python"),
+		npuProcess(1, nil, 200, "python"),
+	}, nil)
+	pid100, pid200 := int64(100), int64(200)
+	nameA, nameB := "train-a", "train-b"
+	groupA := JobGroup{MainJob: model.Job{JobID: "job-a", PID: &pid100, JobName: &nameA}, GroupID: 1}
+	groupB := JobGroup{MainJob: model.Job{JobID: "job-b", PID: &pid200, JobName: &nameB}, GroupID: 2}
+	scope := ProjectFilter{Restricted: true, VisibleIDs: []uint{5}}
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return f.Projects.IsEmpty()
+	}), "startTime", "desc", "", nodeGroupScanChunkSize).Return([]JobGroup{groupA, groupB}, int64(2), "", nil)
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return f.Projects.Restricted
+	}), "startTime", "desc", "", nodeGroupScanChunkSize).Return([]JobGroup{groupA}, int64(1), "", nil)
+
+	result, err := svc.GetNodeCards("node-1", scope)
+	require.NoError(t, err)
+	// 不可见分组的进程仍使卡处于占用状态，但不返回作业信息
+	assert.Equal(t, 2, result.BusyCards)
+	assert.Equal(t, NodeCardProcess{PID: 100, ProcessName: "python", JobID: "job-a", JobName: "train-a", GroupID: 1}, result.Cards[0].Processes[0])
+	assert.Equal(t, NodeCardProcess{PID: 200, ProcessName: "python"}, result.Cards[1].Processes[0])
+	mockJobService.AssertExpectations(t)
+}
+
+func TestNodeCardService_GetNodeCards_NotFound(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	svc := NewNodeCardService(mockNodeRepo, new(MockMetricsRepository), new(MockJobServiceForLLM), 10*time.Minute)
+	mockNodeRepo.On("FindByID", "missing").Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := svc.GetNodeCards("missing", ProjectFilter{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// MockJobStatusHistoryRepository 作业状态历史仓库 mock
+type MockJobStatusHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockJobStatusHistoryRepository) FindRecentByNode(nodeID string, limit int, projects repository.ProjectFilter) ([]repository.NodeStatusChange, error) {
+	args := m.Called(nodeID, limit, projects)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.NodeStatusChange), args.Error(1)
+}
+
+func TestNodeCardService_GetNodeOverview(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockJobService := new(MockJobServiceForLLM)
+	mockHistoryRepo := new(MockJobStatusHistoryRepository)
+	svc := NewNodeCardService(mockNodeRepo, mockMetricsRepo, mockJobService, 10*time.Minute)
+	svc.SetStatusHistoryRepository(mockHistoryRepo)
+	now := time.Unix(1770373800, 0)
+	svc.now = func() time.Time { return now }
+
+	two := 2
+	heartbeat := now.Add(-45 * time.Second)
+	mockNodeRepo.On("FindByID", "node-1").Return(&model.Node{NodeID: "node-1", NPUCount: &two, LastHeartbeat: &heartbeat}, nil)
+	mockMetricsRepo.On("FindLatestNodeNPUMetricsSince", "node-1", now.Add(-10*time.Minute)).Return([]model.NPUMetric{
+		statsChip("node-1", 0, "0000:C1:00.0", 80, 16000, 32000, 300),
+		statsChip("node-1", 1, "0000:C2:00.0", 20, 8000, 32000, 100),
+	}, nil)
+	mockMetricsRepo.On("FindRunningNPUProcessesByNode", "node-1").Return([]model.NPUProcess{
+		npuProcess(0, nil, 100, "python"),
+	}, nil)
+	pid100 := int64(100)
+	mockJobService.On("GetGroupedJobsByCursor", mock.Anything, "startTime", "desc", "", nodeGroupScanChunkSize).
+		Return([]JobGroup{{MainJob: model.Job{JobID: "job-a", PID: &pid100}}}, int64(1), "", nil)
+	newStatus := "failed"
+	mockHistoryRepo.On("FindRecentByNode", "node-1", NodeOverviewStatusChangeLimit, ProjectFilter{}).Return([]repository.NodeStatusChange{
+		{ID: 3, JobID: "job-z", NewStatus: &newStatus, ChangedAt: now.Add(-time.Hour)},
+	}, nil)
+
+	overview, err := svc.GetNodeOverview("node-1", ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", overview.Node.NodeID)
+	require.NotNil(t, overview.HeartbeatAgeSeconds)
+	assert.Equal(t, int64(45), *overview.HeartbeatAgeSeconds)
+	assert.Equal(t, 1, overview.RunningGroupCount)
+	assert.Equal(t, "job-a", overview.RunningJobGroups[0].MainJob.JobID)
+	assert.Equal(t, 2, overview.Cards.TotalCards)
+	assert.Equal(t, 1, overview.Cards.BusyCards)
+	assert.Equal(t, "job-a", overview.Cards.Cards[0].Processes[0].JobID)
+	assert.Equal(t, 2, overview.Usage.TotalCards)
+	assert.Equal(t, 1, overview.Usage.UsedCards)
+	assert.Equal(t, 400.0, overview.Usage.TotalPowerW)
+	assert.Equal(t, 50.0, *overview.Usage.AvgAICoreUsage)
+	require.Len(t, overview.RecentStatusChanges, 1)
+	assert.Equal(t, "job-z", overview.RecentStatusChanges[0].JobID)
+	assert.Equal(t, now, overview.Timestamp)
+}
+
+func TestNodeCardService_GetNodeOverview_ProjectScope(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockJobService := new(MockJobServiceForLLM)
+	mockHistoryRepo := new(MockJobStatusHistoryRepository)
+	svc := NewNodeCardService(mockNodeRepo, mockMetricsRepo, mockJobService, 10*time.Minute)
+	svc.SetStatusHistoryRepository(mockHistoryRepo)
+
+	two := 2
+	mockNodeRepo.On("FindByID", "node-1").Return(&model.Node{NodeID: "node-1", NPUCount: &two}, nil)
+	mockMetricsRepo.On("FindLatestNodeNPUMetricsSince", "node-1", mock.Anything).Return([]model.NPUMetric{}, nil)
+	mockMetricsRepo.On("FindRunningNPUProcessesByNode", "node-1").Return([]model.NPUProcess{
+		npuProcess(0, nil, 100, "python"),
+		npuProcess(1, nil, 200, "python"),
+	}, nil)
+	pid100, pid200 := int64(100), int64(200)
+	groupA := JobGroup{MainJob: model.Job{JobID: "job-a", PID: &pid100}, GroupID: 1}
+	groupB := JobGroup{MainJob: model.Job{JobID: "job-b", PID: &pid200}, GroupID: 2}
+	scope := ProjectFilter{Restricted: true, VisibleIDs: []uint{5}}
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return f.Projects.IsEmpty()
+	}), "startTime", "desc", "", nodeGroupScanChunkSize).Return([]JobGroup{groupA, groupB}, int64(2), "", nil)
+	mockJobService.On("GetGroupedJobsByCursor", mock.MatchedBy(func(f JobGroupFilter) bool {
+		return f.Projects.Restricted
+	}), "startTime", "desc", "", nodeGroupScanChunkSize).Return([]JobGroup{groupA}, int64(1), "", nil)
+	mockHistoryRepo.On("FindRecentByNode", "node-1", NodeOverviewStatusChangeLimit, scope).Return([]repository.NodeStatusChange{}, nil)
+
+	overview, err := svc.GetNodeOverview("node-1", scope)
+	require.NoError(t, err)
+	// 分组列表只含可见分组，卡状态仍按整机计算
+	assert.Equal(t, 1, overview.RunningGroupCount)
+	require.Len(t, overview.RunningJobGroups, 1)
+	assert.Equal(t, "job-a", overview.RunningJobGroups[0].MainJob.JobID)
+	assert.Equal(t, 2, overview.Cards.BusyCards)
+	assert.Equal(t, "job-a", overview.Cards.Cards[0].Processes[0].JobID)
+	assert.Equal(t, "", overview.Cards.Cards[1].Processes[0].JobID)
+	mockHistoryRepo.AssertExpectations(t)
+}
+
+func TestNodeCardService_GetNodeOverview_NoHeartbeatOrHistory(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockJobService := new(MockJobServiceForLLM)
+	svc := NewNodeCardService(mockNodeRepo, mockMetricsRepo, mockJobService, 10*time.Minute)
+
+	mockNodeRepo.On("FindByID", "node-1").Return(&model.Node{NodeID: "node-1"}, nil)
+	mockMetricsRepo.On("FindLatestNodeNPUMetricsSince", "node-1", mock.Anything).Return([]model.NPUMetric{}, nil)
+	mockMetricsRepo.On("FindRunningNPUProcessesByNode", "node-1").Return([]model.NPUProcess{}, nil)
+	mockJobService.On("GetGroupedJobsByCursor", mock.Anything, "startTime", "desc", "", nodeGroupScanChunkSize).
+		Return([]JobGroup(nil), int64(0), "", nil)
+
+	// 未设置状态历史仓库且无心跳时，对应字段为空但不报错
+	overview, err := svc.GetNodeOverview("node-1", ProjectFilter{})
+	require.NoError(t, err)
+	assert.Nil(t, overview.HeartbeatAgeSeconds)
+	assert.NotNil(t, overview.RunningJobGroups)
+	assert.Empty(t, overview.RunningJobGroups)
+	assert.NotNil(t, overview.RecentStatusChanges)
+	assert.Empty(t, overview.RecentStatusChanges)
+	assert.Equal(t, 0, overview.Cards.TotalCards)
+}
+
+func TestNodeCardService_GetNodeOverview_HistoryError(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	mockJobService := new(MockJobServiceForLLM)
+	mockHistoryRepo := new(MockJobStatusHistoryRepository)
+	svc := NewNodeCardService(mockNodeRepo, mockMetricsRepo, mockJobService, 10*time.Minute)
+	svc.SetStatusHistoryRepository(mockHistoryRepo)
+
+	mockNodeRepo.On("FindByID", "node-1").Return(&model.Node{NodeID: "node-1"}, nil)
+	mockMetricsRepo.On("FindLatestNodeNPUMetricsSince", "node-1", mock.Anything).Return([]model.NPUMetric{}, nil)
+	mockMetricsRepo.On("FindRunningNPUProcessesByNode", "node-1").Return([]model.NPUProcess{}, nil)
+	mockJobService.On("GetGroupedJobsByCursor", mock.Anything, "startTime", "desc", "", nodeGroupScanChunkSize).
+		Return([]JobGroup{}, int64(0), "", nil)
+	mockHistoryRepo.On("FindRecentByNode", "node-1", NodeOverviewStatusChangeLimit, ProjectFilter{}).Return(nil, assert.AnError)
+
+	_, err := svc.GetNodeOverview("node-1", ProjectFilter{})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestNodeCardService_FindFreeCards(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewNodeCardService(mockNodeRepo, mockMetricsRepo, new(MockJobServiceForLLM), 10*time.Minute)
+	now := time.Unix(1770373800, 0)
+	svc.now = func() time.Time { return now }
+
+	active, inactive, two := "active", "inactive", 2
+	model910B, model310P := "Ascend910B", "Ascend310P"
+	mockNodeRepo.On("FindAll").Return([]model.Node{
+		{NodeID: "node-1", Status: &active, NPUModel: &model910B, NPUCount: &two},
+		{NodeID: "node-2", Status: &active, NPUModel: &model910B, NPUCount: &two},
+		{NodeID: "node-3", Status: &active, NPUModel: &model310P, NPUCount: &two},
+		{NodeID: "node-4", Status: &inactive, NPUModel: &model910B, NPUCount: &two},
+	}, nil)
+	mockMetricsRepo.On("FindLatestNPUMetricsSince", now.Add(-10*time.Minute)).Return([]model.NPUMetric{
+		statsChip("node-1", 0, "0000:C1:00.0", 0, 0, 32000, 70),
+		statsChip("node-1", 1, "0000:C2:00.0", 0, 0, 32000, 70),
+		statsChip("node-2", 0, "0000:C1:00.0", 90, 30000, 32000, 300),
+		statsChip("node-2", 1, "0000:C2:00.0", 0, 0, 32000, 70),
+		statsChip("node-3", 0, "0000:C1:00.0", 0, 0, 32000, 70),
+		statsChip("node-4", 0, "0000:C1:00.0", 0, 0, 32000, 70),
+	}, nil)
+	mockMetricsRepo.On("FindRunningNPUProcesses", repository.ProjectFilter{}).Return([]repository.RunningNPUProcess{
+		{NodeID: "node-2", NPUID: 0, PID: 100},
+	}, nil)
+
+	// 空闲卡少的节点在前；型号忽略大小写匹配，非活跃节点不参与
+	result, err := svc.FindFreeCards("ascend910b", 1, ProjectFilter{})
+	require.NoError(t, err)
+	require.Len(t, result.Nodes, 2)
+	assert.Equal(t, FreeCardNode{NodeID: "node-2", NPUModel: "Ascend910B", FreeCount: 1, FreeCards: []int{1}}, result.Nodes[0])
+	assert.Equal(t, FreeCardNode{NodeID: "node-1", NPUModel: "Ascend910B", FreeCount: 2, FreeCards: []int{0, 1}}, result.Nodes[1])
+	assert.Equal(t, 3, result.TotalFreeCards)
+
+	result, err = svc.FindFreeCards("", 2, ProjectFilter{})
+	require.NoError(t, err)
+	require.Len(t, result.Nodes, 1)
+	assert.Equal(t, "node-1", result.Nodes[0].NodeID)
+
+	// 指定项目时只在项目作业所在的节点内查找，卡的占用仍按全部进程判断
+	scope := ProjectFilter{ProjectIDs: []uint{5}}
+	mockMetricsRepo.On("FindRunningNPUProcesses", scope).Return([]repository.RunningNPUProcess{
+		{NodeID: "node-2", NPUID: 0, PID: 100},
+	}, nil)
+	result, err = svc.FindFreeCards("", 1, scope)
+	require.NoError(t, err)
+	require.Len(t, result.Nodes, 1)
+	assert.Equal(t, FreeCardNode{NodeID: "node-2", NPUModel: "Ascend910B", FreeCount: 1, FreeCards: []int{1}}, result.Nodes[0])
+
+	_, err = svc.FindFreeCards("", 0, ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidFreeCardQuery)
+}
@@ -42,6 +42,11 @@ func (m *MockNodeRepository) FindByStatus(status string) ([]model.Node, error) {
 	return args.Get(0).([]model.Node), args.Error(1)
 }
 
+func (m *MockNodeRepository) Search(query string, limit int) ([]model.Node, error) {
+	args := m.Called(query, limit)
+	return args.Get(0).([]model.Node), args.Error(1)
+}
+
 func (m *MockNodeRepository) UpdateHeartbeat(nodeID string) error {
 	args := m.Called(nodeID)
 	return args.Error(0)
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// NPUService NPU 指标服务
+type NPUService struct {
+	metricsRepo repository.MetricsRepositoryInterface
+	now         func() time.Time
+}
+
+// NewNPUService 创建 NPU 指标服务
+func NewNPUService(metricsRepo repository.MetricsRepositoryInterface) *NPUService {
+	return &NPUService{
+		metricsRepo: metricsRepo,
+		now:         time.Now,
+	}
+}
+
+// GetLatestNPUMetrics 获取全集群每张芯片的最新指标，maxAge 内未上报的芯片视为离线不返回
+func (s *NPUService) GetLatestNPUMetrics(maxAge time.Duration) ([]model.NPUMetric, error) {
+	return s.metricsRepo.FindLatestNPUMetricsSince(s.now().Add(-maxAge))
+}
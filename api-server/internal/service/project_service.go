@@ -0,0 +1,910 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrProjectNotFound 项目不存在或对当前用户不可见
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrProjectForbidden 仅项目 owner 或管理员可修改项目
+	ErrProjectForbidden = errors.New("only project owners or administrators can modify this project")
+	// ErrProjectAdminRequired 归属规则对全部作业生效，仅管理员可添加或修改
+	ErrProjectAdminRequired = errors.New("only administrators can perform this operation")
+	// ErrProjectNameExists 项目名称重复
+	ErrProjectNameExists = errors.New("project name already exists")
+	// ErrProjectNameRequired 项目名称为空
+	ErrProjectNameRequired = errors.New("project name is required")
+	// ErrProjectUserNotFound 添加成员时用户不存在
+	ErrProjectUserNotFound = errors.New("user not found")
+	// ErrInvalidProjectMember 成员角色不合法或移除了最后一个 owner
+	ErrInvalidProjectMember = errors.New("invalid project member")
+	// ErrProjectRuleNotFound 归属规则不存在
+	ErrProjectRuleNotFound = errors.New("project rule not found")
+	// ErrInvalidProjectRule 归属规则的匹配字段或正则表达式不合法
+	ErrInvalidProjectRule = errors.New("invalid project rule")
+	// ErrProjectJobNotFound 作业不存在或对当前用户不可见
+	ErrProjectJobNotFound = errors.New("job not found")
+)
+
+// ProjectRuleFields 归属规则支持的匹配字段，另外支持 env:<变量名>（取自作业参数中的环境变量）
+var ProjectRuleFields = []string{"cwd", "command_line", "node"}
+
+const (
+	// projectSyncBatchSize 全量计算归属时每批处理的作业数
+	projectSyncBatchSize = 1000
+	// projectSyncOverlap 增量计算回看的时长：环境变量随参数上报，可能晚于作业行写入
+	projectSyncOverlap = 5 * time.Minute
+)
+
+// ProjectInput 创建/更新项目的内容
+type ProjectInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ProjectMemberInput 添加成员的内容，Role 为空时按 member 处理
+type ProjectMemberInput struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// ProjectRuleInput 创建/更新归属规则的内容
+type ProjectRuleInput struct {
+	Field    string `json:"field"`
+	Pattern  string `json:"pattern"`
+	Priority int    `json:"priority"`
+}
+
+// ProjectMemberInfo 项目成员及其用户名
+type ProjectMemberInfo struct {
+	UserID    uint      `json:"userId"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// JobProjectInfo 作业的归属项目，ProjectID 为 nil 表示未归属
+type JobProjectInfo struct {
+	JobID       string `json:"jobId"`
+	ProjectID   *uint  `json:"projectId"`
+	ProjectName string `json:"projectName,omitempty"`
+	Source      string `json:"source,omitempty"` // rule / manual
+	RuleID      *uint  `json:"ruleId,omitempty"`
+}
+
+// projectRule 编译后的归属规则
+type projectRule struct {
+	id        uint
+	projectID uint
+	field     string
+	re        *regexp.Regexp
+}
+
+// ProjectService 项目与成员管理、作业归属计算与按用户的可见范围
+type ProjectService struct {
+	repo      repository.ProjectRepositoryInterface
+	jobRepo   repository.JobRepositoryInterface
+	paramRepo repository.ParameterRepositoryInterface
+	userRepo  repository.UserRepositoryInterface
+
+	mu       sync.RWMutex
+	restrict bool
+	admins   map[string]struct{}
+
+	syncMu     sync.Mutex
+	watermark  time.Time
+	fullResync atomic.Bool
+	trigger    chan struct{}
+	onChanged  func()
+}
+
+// NewProjectService 创建项目服务；首次计算归属时全量处理全部作业
+func NewProjectService(repo repository.ProjectRepositoryInterface, jobRepo repository.JobRepositoryInterface,
+	paramRepo repository.ParameterRepositoryInterface, userRepo repository.UserRepositoryInterface,
+	cfg config.ProjectsConfig) *ProjectService {
+	s := &ProjectService{
+		repo:      repo,
+		jobRepo:   jobRepo,
+		paramRepo: paramRepo,
+		userRepo:  userRepo,
+		trigger:   make(chan struct{}, 1),
+	}
+	s.fullResync.Store(true)
+	s.SetConfig(cfg)
+	return s
+}
+
+// SetConfig 更新可见范围与管理员列表，支持热加载
+func (s *ProjectService) SetConfig(cfg config.ProjectsConfig) {
+	admins := make(map[string]struct{}, len(cfg.Admins))
+	for _, name := range cfg.Admins {
+		admins[strings.TrimSpace(name)] = struct{}{}
+	}
+	s.mu.Lock()
+	s.restrict = cfg.RestrictVisibility
+	s.admins = admins
+	s.mu.Unlock()
+}
+
+// SetChangedHook 设置作业归属变化后的回调（如失效作业查询缓存）
+func (s *ProjectService) SetChangedHook(fn func()) {
+	s.onChanged = fn
+}
+
+// isAdmin 判断用户是否在管理员列表中，匿名用户不是管理员
+func (s *ProjectService) isAdmin(userID uint) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+	s.mu.RLock()
+	empty := len(s.admins) == 0
+	s.mu.RUnlock()
+	if empty {
+		return false, nil
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.admins[user.Username]
+	return ok, nil
+}
+
+// VisibleScope 返回用户的作业可见范围：未开启可见范围限制或用户为管理员时不限制，
+// 否则只能看到所属项目与未归属的作业；userID 为 0 表示匿名用户
+func (s *ProjectService) VisibleScope(userID uint) (ProjectFilter, error) {
+	s.mu.RLock()
+	restrict := s.restrict
+	s.mu.RUnlock()
+	if !restrict {
+		return ProjectFilter{}, nil
+	}
+	admin, err := s.isAdmin(userID)
+	if err != nil || admin {
+		return ProjectFilter{}, err
+	}
+	scope := ProjectFilter{Restricted: true}
+	if userID != 0 {
+		if scope.VisibleIDs, err = s.repo.FindProjectIDsByUser(userID); err != nil {
+			return ProjectFilter{}, fmt.Errorf("find user projects: %w", err)
+		}
+	}
+	return scope, nil
+}
+
+// CanViewJob 判断作业是否在用户的可见范围内
+func (s *ProjectService) CanViewJob(jobID string, userID uint) (bool, error) {
+	scope, err := s.VisibleScope(userID)
+	if err != nil || !scope.Restricted {
+		return err == nil, err
+	}
+	assignments, err := s.repo.FindJobProjects([]string{jobID})
+	if err != nil {
+		return false, fmt.Errorf("find job project: %w", err)
+	}
+	var projectID uint
+	if len(assignments) > 0 {
+		projectID = assignments[0].ProjectID
+	}
+	return scope.Matches(projectID), nil
+}
+
+// FilterVisibleJobIDs 保留在可见范围 scope 内的作业ID，顺序不变
+func (s *ProjectService) FilterVisibleJobIDs(jobIDs []string, scope ProjectFilter) ([]string, error) {
+	if scope.IsEmpty() || len(jobIDs) == 0 {
+		return jobIDs, nil
+	}
+	projectOf := make(map[string]uint, len(jobIDs))
+	for start := 0; start < len(jobIDs); start += projectSyncBatchSize {
+		end := min(start+projectSyncBatchSize, len(jobIDs))
+		assignments, err := s.repo.FindJobProjects(jobIDs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("find job projects: %w", err)
+		}
+		for _, a := range assignments {
+			projectOf[a.JobID] = a.ProjectID
+		}
+	}
+	visible := make([]string, 0, len(jobIDs))
+	for _, id := range jobIDs {
+		if scope.Matches(projectOf[id]) {
+			visible = append(visible, id)
+		}
+	}
+	return visible, nil
+}
+
+// List 列出用户可见的项目：开启可见范围限制时非管理员只能看到所属项目
+func (s *ProjectService) List(userID uint) ([]model.Project, error) {
+	projects, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	scope, err := s.VisibleScope(userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.Project, 0, len(projects))
+	for _, p := range projects {
+		if !scope.Restricted || scope.Matches(p.ID) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// Get 获取单个项目；不可见的项目按不存在处理
+func (s *ProjectService) Get(id, userID uint) (*model.Project, error) {
+	project, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := s.VisibleScope(userID)
+	if err != nil {
+		return nil, err
+	}
+	if scope.Restricted && !scope.Matches(id) {
+		return nil, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// Create 创建项目，创建者成为项目 owner
+func (s *ProjectService) Create(userID uint, input ProjectInput) (*model.Project, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrProjectNameRequired
+	}
+	if _, err := s.repo.FindByName(name); err == nil {
+		return nil, ErrProjectNameExists
+	}
+	project := &model.Project{Name: name, Description: strings.TrimSpace(input.Description), CreatedBy: userID}
+	if err := s.repo.Create(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Update 更新项目名称与描述，仅 owner 或管理员可操作
+func (s *ProjectService) Update(id, userID uint, input ProjectInput) (*model.Project, error) {
+	project, err := s.managed(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrProjectNameRequired
+	}
+	if name != project.Name {
+		if _, err := s.repo.FindByName(name); err == nil {
+			return nil, ErrProjectNameExists
+		}
+	}
+	project.Name = name
+	project.Description = strings.TrimSpace(input.Description)
+	if err := s.repo.Update(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Delete 删除项目，归属于该项目的作业变为未归属，随后按其他项目的规则重新计算
+func (s *ProjectService) Delete(id, userID uint) error {
+	if _, err := s.managed(id, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.RequestResync()
+	return nil
+}
+
+// ListMembers 列出项目成员
+func (s *ProjectService) ListMembers(id, userID uint) ([]ProjectMemberInfo, error) {
+	if _, err := s.Get(id, userID); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.FindMembers(id)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.userRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	result := make([]ProjectMemberInfo, 0, len(members))
+	for _, m := range members {
+		result = append(result, ProjectMemberInfo{UserID: m.UserID, Username: names[m.UserID], Role: m.Role, CreatedAt: m.CreatedAt})
+	}
+	return result, nil
+}
+
+// AddMember 按用户名添加成员，已是成员时更新角色；仅 owner 或管理员可操作
+func (s *ProjectService) AddMember(id, userID uint, input ProjectMemberInput) (*ProjectMemberInfo, error) {
+	if _, err := s.managed(id, userID); err != nil {
+		return nil, err
+	}
+	role := input.Role
+	if role == "" {
+		role = model.ProjectRoleMember
+	}
+	if role != model.ProjectRoleOwner && role != model.ProjectRoleMember {
+		return nil, fmt.Errorf("%w: unsupported role %q", ErrInvalidProjectMember, role)
+	}
+	user, err := s.userRepo.FindByUsername(strings.TrimSpace(input.Username))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProjectUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if role == model.ProjectRoleMember {
+		if err := s.checkLastOwner(id, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	member := &model.ProjectMember{ProjectID: id, UserID: user.ID, Role: role}
+	if err := s.repo.SaveMember(member); err != nil {
+		return nil, err
+	}
+	return &ProjectMemberInfo{UserID: user.ID, Username: user.Username, Role: role, CreatedAt: member.CreatedAt}, nil
+}
+
+// RemoveMember 移除成员；仅 owner 或管理员可操作，成员也可以退出项目
+func (s *ProjectService) RemoveMember(id, userID, memberID uint) error {
+	if userID != memberID {
+		if _, err := s.managed(id, userID); err != nil {
+			return err
+		}
+	} else if _, err := s.find(id); err != nil {
+		return err
+	}
+	if err := s.checkLastOwner(id, memberID); err != nil {
+		return err
+	}
+	return s.repo.DeleteMember(id, memberID)
+}
+
+// checkLastOwner 项目至少保留一个 owner：memberID 是唯一的 owner 时不允许移除或降级
+func (s *ProjectService) checkLastOwner(id, memberID uint) error {
+	members, err := s.repo.FindMembers(id)
+	if err != nil {
+		return err
+	}
+	owners, isOwner := 0, false
+	for _, m := range members {
+		if m.Role == model.ProjectRoleOwner {
+			owners++
+			isOwner = isOwner || m.UserID == memberID
+		}
+	}
+	if isOwner && owners == 1 {
+		return fmt.Errorf("%w: a project must keep at least one owner", ErrInvalidProjectMember)
+	}
+	return nil
+}
+
+// ListRules 列出项目的归属规则，按匹配顺序排列
+func (s *ProjectService) ListRules(id, userID uint) ([]model.ProjectRule, error) {
+	if _, err := s.Get(id, userID); err != nil {
+		return nil, err
+	}
+	rules, err := s.repo.FindRules(id)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []model.ProjectRule{}
+	}
+	return rules, nil
+}
+
+// CreateRule 添加归属规则，随后按新规则重新计算全部作业的归属。规则跨项目按优先级匹配，
+// 宽泛的规则会把其他项目的作业划走，因此仅管理员可操作
+func (s *ProjectService) CreateRule(id, userID uint, input ProjectRuleInput) (*model.ProjectRule, error) {
+	if _, err := s.managed(id, userID); err != nil {
+		return nil, err
+	}
+	if err := s.checkAdmin(userID); err != nil {
+		return nil, err
+	}
+	if err := ValidateProjectRule(input); err != nil {
+		return nil, err
+	}
+	rule := &model.ProjectRule{ProjectID: id, Field: input.Field, Pattern: input.Pattern, Priority: input.Priority}
+	if err := s.repo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	s.RequestResync()
+	return rule, nil
+}
+
+// UpdateRule 更新归属规则的字段、正则与优先级，仅管理员可操作
+func (s *ProjectService) UpdateRule(id, ruleID, userID uint, input ProjectRuleInput) (*model.ProjectRule, error) {
+	rule, err := s.managedRule(id, ruleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAdmin(userID); err != nil {
+		return nil, err
+	}
+	if err := ValidateProjectRule(input); err != nil {
+		return nil, err
+	}
+	rule.Field, rule.Pattern, rule.Priority = input.Field, input.Pattern, input.Priority
+	if err := s.repo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+	s.RequestResync()
+	return rule, nil
+}
+
+// DeleteRule 删除归属规则
+func (s *ProjectService) DeleteRule(id, ruleID, userID uint) error {
+	if _, err := s.managedRule(id, ruleID, userID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteRule(ruleID); err != nil {
+		return err
+	}
+	s.RequestResync()
+	return nil
+}
+
+// ValidateProjectRule 校验归属规则的匹配字段与正则表达式
+func ValidateProjectRule(input ProjectRuleInput) error {
+	switch {
+	case strings.HasPrefix(input.Field, "env:"):
+		if strings.TrimPrefix(input.Field, "env:") == "" {
+			return fmt.Errorf("%w: env field requires a variable name", ErrInvalidProjectRule)
+		}
+	case !slices.Contains(ProjectRuleFields, input.Field):
+		return fmt.Errorf("%w: unsupported field %q", ErrInvalidProjectRule, input.Field)
+	}
+	if input.Pattern == "" {
+		return fmt.Errorf("%w: pattern is required", ErrInvalidProjectRule)
+	}
+	if _, err := regexp.Compile(input.Pattern); err != nil {
+		return fmt.Errorf("%w: invalid pattern: %v", ErrInvalidProjectRule, err)
+	}
+	return nil
+}
+
+// GetJobProject 查询作业的归属项目
+func (s *ProjectService) GetJobProject(jobID string, userID uint) (*JobProjectInfo, error) {
+	if _, err := s.visibleJob(jobID, userID); err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.FindJobProjects([]string{jobID})
+	if err != nil {
+		return nil, fmt.Errorf("find job project: %w", err)
+	}
+	info := &JobProjectInfo{JobID: jobID}
+	if len(assignments) == 0 {
+		return info, nil
+	}
+	a := assignments[0]
+	info.ProjectID, info.Source, info.RuleID = &a.ProjectID, a.Source, a.RuleID
+	if project, err := s.repo.FindByID(a.ProjectID); err == nil {
+		info.ProjectName = project.Name
+	}
+	return info, nil
+}
+
+// AssignJob 手动指定作业的归属项目，之后规则不再改变该作业的归属；需为目标项目与当前归属项目的成员，
+// 未归属的作业只能由管理员指定，避免把其他团队的作业划入自己的项目
+func (s *ProjectService) AssignJob(jobID string, projectID, userID uint) (*JobProjectInfo, error) {
+	if _, err := s.visibleJob(jobID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.find(projectID); err != nil {
+		return nil, err
+	}
+	if err := s.checkMember(projectID, userID); err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.FindJobProjects([]string{jobID})
+	if err != nil {
+		return nil, fmt.Errorf("find job project: %w", err)
+	}
+	if len(assignments) == 0 {
+		err = s.checkAdmin(userID)
+	} else if assignments[0].ProjectID != projectID {
+		err = s.checkMember(assignments[0].ProjectID, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetManualAssignment(jobID, projectID); err != nil {
+		return nil, err
+	}
+	s.notifyChanged()
+	return s.GetJobProject(jobID, userID)
+}
+
+// ClearJobAssignment 取消手动归属，作业立即按归属规则重新计算；需为当前归属项目的成员或管理员
+func (s *ProjectService) ClearJobAssignment(jobID string, userID uint) (*JobProjectInfo, error) {
+	job, err := s.visibleJob(jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.FindJobProjects([]string{jobID})
+	if err != nil {
+		return nil, fmt.Errorf("find job project: %w", err)
+	}
+	if len(assignments) > 0 {
+		if err := s.checkMember(assignments[0].ProjectID, userID); err != nil {
+			return nil, err
+		}
+		if err := s.repo.DeleteAssignment(jobID); err != nil {
+			return nil, err
+		}
+	}
+	rules, err := s.loadRules()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.resolveJobs([]model.Job{*job}, rules); err != nil {
+		return nil, err
+	}
+	s.notifyChanged()
+	return s.GetJobProject(jobID, userID)
+}
+
+// ProjectNamesByJobIDs 批量查询作业归属的项目名称，未归属的作业不在结果中
+func (s *ProjectService) ProjectNamesByJobIDs(jobIDs []string) (map[string]string, error) {
+	assignments, err := s.repo.FindJobProjects(jobIDs)
+	if err != nil {
+		return nil, fmt.Errorf("find job projects: %w", err)
+	}
+	if len(assignments) == 0 {
+		return map[string]string{}, nil
+	}
+	projects, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(projects))
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+	result := make(map[string]string, len(assignments))
+	for _, a := range assignments {
+		if name, ok := names[a.ProjectID]; ok {
+			result[a.JobID] = name
+		}
+	}
+	return result, nil
+}
+
+// visibleJob 查找作业；作业不存在或不在用户可见范围内时返回 ErrProjectJobNotFound
+func (s *ProjectService) visibleJob(jobID string, userID uint) (*model.Job, error) {
+	job, err := s.jobRepo.FindByID(jobID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProjectJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	visible, err := s.CanViewJob(jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrProjectJobNotFound
+	}
+	return job, nil
+}
+
+func (s *ProjectService) find(id uint) (*model.Project, error) {
+	project, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProjectNotFound
+	}
+	return project, err
+}
+
+// managed 查找项目并校验用户为项目 owner 或管理员；不可见的项目按不存在处理
+func (s *ProjectService) managed(id, userID uint) (*model.Project, error) {
+	project, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := s.isAdmin(userID)
+	if err != nil || admin {
+		return project, err
+	}
+	member, err := s.repo.FindMember(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && member.Role != model.ProjectRoleOwner) {
+		return nil, ErrProjectForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *ProjectService) managedRule(id, ruleID, userID uint) (*model.ProjectRule, error) {
+	if _, err := s.managed(id, userID); err != nil {
+		return nil, err
+	}
+	rule, err := s.repo.FindRuleByID(ruleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && rule.ProjectID != id) {
+		return nil, ErrProjectRuleNotFound
+	}
+	return rule, err
+}
+
+// checkAdmin 校验用户为管理员
+func (s *ProjectService) checkAdmin(userID uint) error {
+	admin, err := s.isAdmin(userID)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return ErrProjectAdminRequired
+	}
+	return nil
+}
+
+// checkMember 校验用户为项目成员（任意角色）或管理员
+func (s *ProjectService) checkMember(id, userID uint) error {
+	admin, err := s.isAdmin(userID)
+	if err != nil || admin {
+		return err
+	}
+	if _, err := s.repo.FindMember(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProjectForbidden
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *ProjectService) notifyChanged() {
+	if s.onChanged != nil {
+		s.onChanged()
+	}
+}
+
+// RequestResync 归属规则变化后请求全量重新计算，由后台任务尽快执行
+func (s *ProjectService) RequestResync() {
+	s.fullResync.Store(true)
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// StartProjectSync 后台计算作业归属：启动时全量计算一次，之后每隔 interval 处理新增与变更的作业，
+// 归属规则变化时立即全量重新计算
+func (s *ProjectService) StartProjectSync(ctx context.Context, interval time.Duration) {
+	go func() {
+		s.syncAndLog()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.trigger:
+			}
+			s.syncAndLog()
+		}
+	}()
+}
+
+func (s *ProjectService) syncAndLog() {
+	start := time.Now()
+	changed, err := s.SyncJobProjects()
+	if err != nil {
+		slog.Error("job project sync failed", "error", err)
+		return
+	}
+	if changed > 0 {
+		slog.Info("job projects synced", "changed_jobs", changed, "elapsed_ms", time.Since(start).Milliseconds())
+	}
+}
+
+// SyncJobProjects 按归属规则计算作业归属，返回归属发生变化的作业数。
+// 需要全量计算时按 (start_time, job_id) 分批遍历全部作业，否则只处理水位之后新增或更新的作业；手动归属保持不变
+func (s *ProjectService) SyncJobProjects() (int, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	full := s.fullResync.Swap(false)
+	rules, err := s.loadRules()
+	var changed int
+	if err == nil {
+		changed, err = s.syncJobs(full, rules)
+	}
+	if err != nil {
+		if full {
+			s.fullResync.Store(true)
+		}
+		return 0, err
+	}
+	if changed > 0 {
+		s.notifyChanged()
+	}
+	return changed, nil
+}
+
+func (s *ProjectService) syncJobs(full bool, rules []projectRule) (int, error) {
+	if !full {
+		jobs, err := s.jobRepo.FindChangedSince(s.watermark.Add(-projectSyncOverlap))
+		if err != nil {
+			return 0, fmt.Errorf("find changed jobs: %w", err)
+		}
+		watermark := s.watermark
+		for _, job := range jobs {
+			if t := jobChangeTime(job); t.After(watermark) {
+				watermark = t
+			}
+		}
+		changed := 0
+		for start := 0; start < len(jobs); start += projectSyncBatchSize {
+			end := start + projectSyncBatchSize
+			if end > len(jobs) {
+				end = len(jobs)
+			}
+			n, err := s.resolveJobs(jobs[start:end], rules)
+			if err != nil {
+				return 0, err
+			}
+			changed += n
+		}
+		s.watermark = watermark
+		return changed, nil
+	}
+
+	// 先记录水位再全量计算，计算期间写入的作业在下一轮增量计算中处理
+	watermark, err := s.jobRepo.MaxChangeTime()
+	if err != nil {
+		return 0, fmt.Errorf("get max change time: %w", err)
+	}
+	changed := 0
+	var cursor *repository.JobCursor
+	for {
+		jobs, err := s.jobRepo.FindByCursor(repository.JobFilter{}, false, cursor, projectSyncBatchSize)
+		if err != nil {
+			return 0, fmt.Errorf("find jobs: %w", err)
+		}
+		n, err := s.resolveJobs(jobs, rules)
+		if err != nil {
+			return 0, err
+		}
+		changed += n
+		if len(jobs) < projectSyncBatchSize {
+			break
+		}
+		last := jobs[len(jobs)-1]
+		cursor = &repository.JobCursor{StartTime: last.StartTime, ID: last.JobID}
+	}
+	s.watermark = watermark
+	return changed, nil
+}
+
+// loadRules 加载并编译全部归属规则；无法编译的规则（如库中被直接修改）被忽略
+func (s *ProjectService) loadRules() ([]projectRule, error) {
+	records, err := s.repo.FindRules(0)
+	if err != nil {
+		return nil, fmt.Errorf("find project rules: %w", err)
+	}
+	rules := make([]projectRule, 0, len(records))
+	for _, r := range records {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			slog.Warn("skip invalid project rule", "rule_id", r.ID, "error", err)
+			continue
+		}
+		rules = append(rules, projectRule{id: r.ID, projectID: r.ProjectID, field: r.Field, re: re})
+	}
+	return rules, nil
+}
+
+// resolveJobs 计算一批作业的规则归属并写入有变化的记录，返回变化的作业数
+func (s *ProjectService) resolveJobs(jobs []model.Job, rules []projectRule) (int, error) {
+	if len(jobs) == 0 {
+		return 0, nil
+	}
+	jobIDs := make([]string, len(jobs))
+	for i, job := range jobs {
+		jobIDs[i] = job.JobID
+	}
+	existing, err := s.repo.FindJobProjects(jobIDs)
+	if err != nil {
+		return 0, fmt.Errorf("find job projects: %w", err)
+	}
+	current := make(map[string]model.JobProject, len(existing))
+	for _, a := range existing {
+		current[a.JobID] = a
+	}
+
+	envByJob := make(map[string]map[string]string)
+	for _, r := range rules {
+		if strings.HasPrefix(r.field, "env:") {
+			params, err := s.paramRepo.FindEnvVarsByJobIDs(jobIDs)
+			if err != nil {
+				return 0, fmt.Errorf("find env vars: %w", err)
+			}
+			collectLatestEnvVars(params, envByJob)
+			break
+		}
+	}
+
+	var upserts []model.JobProject
+	var clears []string
+	for _, job := range jobs {
+		cur, assigned := current[job.JobID]
+		if assigned && cur.Source == model.JobProjectSourceManual {
+			continue
+		}
+		rule := matchProjectRule(job, envByJob[job.JobID], rules)
+		switch {
+		case rule == nil && assigned:
+			clears = append(clears, job.JobID)
+		case rule == nil:
+		case !assigned || cur.ProjectID != rule.projectID || cur.RuleID == nil || *cur.RuleID != rule.id:
+			ruleID := rule.id
+			upserts = append(upserts, model.JobProject{
+				JobID: job.JobID, ProjectID: rule.projectID, Source: model.JobProjectSourceRule, RuleID: &ruleID,
+			})
+		}
+	}
+	if len(upserts) == 0 && len(clears) == 0 {
+		return 0, nil
+	}
+	if err := s.repo.SaveRuleAssignments(upserts, clears); err != nil {
+		return 0, fmt.Errorf("save job projects: %w", err)
+	}
+	return len(upserts) + len(clears), nil
+}
+
+// matchProjectRule 返回第一条命中作业的规则，没有命中时返回 nil
+func matchProjectRule(job model.Job, env map[string]string, rules []projectRule) *projectRule {
+	for i, r := range rules {
+		var value string
+		switch {
+		case r.field == "cwd":
+			value = stringOrEmpty(job.CWD)
+		case r.field == "command_line":
+			value = stringOrEmpty(job.CommandLine)
+		case r.field == "node":
+			value = stringOrEmpty(job.NodeID)
+		case strings.HasPrefix(r.field, "env:"):
+			value = env[strings.TrimPrefix(r.field, "env:")]
+		}
+		if value != "" && r.re.MatchString(value) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
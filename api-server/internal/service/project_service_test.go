@@ -0,0 +1,358 @@
+package service
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"gorm.io/gorm"
+)
+
+// MockProjectRepository is a mock implementation of ProjectRepositoryInterface
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) FindAll() ([]model.Project, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) FindByID(id uint) (*model.Project, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) FindByName(name string) (*model.Project, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) Create(project *model.Project) error {
+	return m.Called(project).Error(0)
+}
+
+func (m *MockProjectRepository) Update(project *model.Project) error {
+	return m.Called(project).Error(0)
+}
+
+func (m *MockProjectRepository) Delete(id uint) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockProjectRepository) FindMembers(projectID uint) ([]model.ProjectMember, error) {
+	args := m.Called(projectID)
+	return args.Get(0).([]model.ProjectMember), args.Error(1)
+}
+
+func (m *MockProjectRepository) FindMember(projectID, userID uint) (*model.ProjectMember, error) {
+	args := m.Called(projectID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ProjectMember), args.Error(1)
+}
+
+func (m *MockProjectRepository) SaveMember(member *model.ProjectMember) error {
+	return m.Called(member).Error(0)
+}
+
+func (m *MockProjectRepository) DeleteMember(projectID, userID uint) error {
+	return m.Called(projectID, userID).Error(0)
+}
+
+func (m *MockProjectRepository) FindProjectIDsByUser(userID uint) ([]uint, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockProjectRepository) FindRules(projectID uint) ([]model.ProjectRule, error) {
+	args := m.Called(projectID)
+	return args.Get(0).([]model.ProjectRule), args.Error(1)
+}
+
+func (m *MockProjectRepository) FindRuleByID(id uint) (*model.ProjectRule, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ProjectRule), args.Error(1)
+}
+
+func (m *MockProjectRepository) CreateRule(rule *model.ProjectRule) error {
+	return m.Called(rule).Error(0)
+}
+
+func (m *MockProjectRepository) UpdateRule(rule *model.ProjectRule) error {
+	return m.Called(rule).Error(0)
+}
+
+func (m *MockProjectRepository) DeleteRule(id uint) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockProjectRepository) FindJobProjects(jobIDs []string) ([]model.JobProject, error) {
+	args := m.Called(jobIDs)
+	return args.Get(0).([]model.JobProject), args.Error(1)
+}
+
+func (m *MockProjectRepository) SaveRuleAssignments(upserts []model.JobProject, clearJobIDs []string) error {
+	return m.Called(upserts, clearJobIDs).Error(0)
+}
+
+func (m *MockProjectRepository) SetManualAssignment(jobID string, projectID uint) error {
+	return m.Called(jobID, projectID).Error(0)
+}
+
+func (m *MockProjectRepository) DeleteAssignment(jobID string) error {
+	return m.Called(jobID).Error(0)
+}
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestMatchProjectRule(t *testing.T) {
+	rules := []projectRule{
+		{id: 1, projectID: 10, field: "env:PROJECT", re: regexp.MustCompile(`^llm$`)},
+		{id: 2, projectID: 20, field: "cwd", re: regexp.MustCompile(`^/data/vision/`)},
+		{id: 3, projectID: 30, field: "node", re: regexp.MustCompile(`^infer-`)},
+		{id: 4, projectID: 40, field: "command_line", re: regexp.MustCompile(`train\.py`)},
+	}
+	str := func(s string) *string { return &s }
+
+	// 按规则顺序匹配，第一条命中的规则生效
+	job := model.Job{CWD: str("/data/vision/a"), NodeID: str("infer-01")}
+	assert.Equal(t, uint(1), matchProjectRule(job, map[string]string{"PROJECT": "llm"}, rules).id)
+	assert.Equal(t, uint(2), matchProjectRule(job, nil, rules).id)
+	assert.Equal(t, uint(3), matchProjectRule(model.Job{NodeID: str("infer-02")}, nil, rules).id)
+	assert.Equal(t, uint(4), matchProjectRule(model.Job{CommandLine: str("python train.py")}, nil, rules).id)
+	assert.Nil(t, matchProjectRule(model.Job{CWD: str("/tmp")}, nil, rules))
+}
+
+func TestValidateProjectRule(t *testing.T) {
+	assert.NoError(t, ValidateProjectRule(ProjectRuleInput{Field: "cwd", Pattern: `^/data/`}))
+	assert.NoError(t, ValidateProjectRule(ProjectRuleInput{Field: "env:PROJECT", Pattern: `.+`}))
+	assert.ErrorIs(t, ValidateProjectRule(ProjectRuleInput{Field: "env:", Pattern: `.+`}), ErrInvalidProjectRule)
+	assert.ErrorIs(t, ValidateProjectRule(ProjectRuleInput{Field: "owner", Pattern: `.+`}), ErrInvalidProjectRule)
+	assert.ErrorIs(t, ValidateProjectRule(ProjectRuleInput{Field: "cwd", Pattern: `(`}), ErrInvalidProjectRule)
+	assert.ErrorIs(t, ValidateProjectRule(ProjectRuleInput{Field: "cwd"}), ErrInvalidProjectRule)
+}
+
+func TestProjectService_SyncJobProjects_Full(t *testing.T) {
+	repo := new(MockProjectRepository)
+	jobRepo := new(MockJobRepository)
+	paramRepo := new(MockParameterRepository)
+	svc := NewProjectService(repo, jobRepo, paramRepo, new(MockUserRepository), config.ProjectsConfig{})
+	changed := 0
+	svc.SetChangedHook(func() { changed++ })
+
+	str := func(s string) *string { return &s }
+	jobs := []model.Job{
+		{JobID: "job-a", CWD: str("/data/vision/a")},
+		{JobID: "job-b", CWD: str("/data/vision/b")},
+		{JobID: "job-c", CWD: str("/tmp")},
+		{JobID: "job-d", CWD: str("/tmp")},
+		{JobID: "job-e", CWD: str("/data/vision/e")},
+	}
+	repo.On("FindRules", uint(0)).Return([]model.ProjectRule{
+		{ID: 7, ProjectID: 2, Field: "env:PROJECT", Pattern: `^nlp$`},
+		{ID: 5, ProjectID: 1, Field: "cwd", Pattern: `^/data/vision/`},
+		{ID: 9, ProjectID: 1, Field: "cwd", Pattern: `(`},
+	}, nil)
+	jobRepo.On("MaxChangeTime").Return(time.Unix(100, 0), nil)
+	jobRepo.On("FindByCursor", repository.JobFilter{}, false, (*repository.JobCursor)(nil), projectSyncBatchSize).Return(jobs, nil)
+	repo.On("FindJobProjects", []string{"job-a", "job-b", "job-c", "job-d", "job-e"}).Return([]model.JobProject{
+		{JobID: "job-b", ProjectID: 1, Source: model.JobProjectSourceRule, RuleID: uintPtr(5)},
+		{JobID: "job-c", ProjectID: 1, Source: model.JobProjectSourceRule, RuleID: uintPtr(5)},
+		{JobID: "job-e", ProjectID: 3, Source: model.JobProjectSourceManual},
+	}, nil)
+	paramRepo.On("FindEnvVarsByJobIDs", []string{"job-a", "job-b", "job-c", "job-d", "job-e"}).
+		Return([]model.Parameter{envParam("job-d", `{"PROJECT":"nlp"}`)}, nil)
+	// job-a 新归属、job-d 按环境变量归属、job-c 不再命中被清除；job-b 未变化，job-e 为手动归属保持不变
+	repo.On("SaveRuleAssignments", []model.JobProject{
+		{JobID: "job-a", ProjectID: 1, Source: model.JobProjectSourceRule, RuleID: uintPtr(5)},
+		{JobID: "job-d", ProjectID: 2, Source: model.JobProjectSourceRule, RuleID: uintPtr(7)},
+	}, []string{"job-c"}).Return(nil)
+
+	n, err := svc.SyncJobProjects()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 1, changed)
+	assert.Equal(t, time.Unix(100, 0), svc.watermark)
+	repo.AssertExpectations(t)
+
+	// 之后只处理水位之后变更的作业
+	jobRepo.On("FindChangedSince", time.Unix(100, 0).Add(-projectSyncOverlap)).Return([]model.Job{}, nil)
+	n, err = svc.SyncJobProjects()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 1, changed)
+	jobRepo.AssertExpectations(t)
+}
+
+func TestProjectService_VisibleScope(t *testing.T) {
+	repo := new(MockProjectRepository)
+	userRepo := new(MockUserRepository)
+	svc := NewProjectService(repo, nil, nil, userRepo, config.ProjectsConfig{})
+
+	scope, err := svc.VisibleScope(1)
+	require.NoError(t, err)
+	assert.True(t, scope.IsEmpty())
+
+	svc.SetConfig(config.ProjectsConfig{RestrictVisibility: true, Admins: []string{"admin"}})
+	userRepo.On("FindByID", uint(1)).Return(&model.User{ID: 1, Username: "admin"}, nil)
+	userRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Username: "alice"}, nil)
+	repo.On("FindProjectIDsByUser", uint(2)).Return([]uint{3, 5}, nil)
+
+	scope, err = svc.VisibleScope(1)
+	require.NoError(t, err)
+	assert.False(t, scope.Restricted)
+
+	scope, err = svc.VisibleScope(2)
+	require.NoError(t, err)
+	assert.Equal(t, ProjectFilter{Restricted: true, VisibleIDs: []uint{3, 5}}, scope)
+	assert.True(t, scope.Matches(0))
+	assert.True(t, scope.Matches(5))
+	assert.False(t, scope.Matches(4))
+
+	// 匿名用户只能看到未归属的作业
+	scope, err = svc.VisibleScope(0)
+	require.NoError(t, err)
+	assert.Equal(t, ProjectFilter{Restricted: true}, scope)
+}
+
+func TestProjectService_ManagePermissions(t *testing.T) {
+	repo := new(MockProjectRepository)
+	userRepo := new(MockUserRepository)
+	svc := NewProjectService(repo, nil, nil, userRepo, config.ProjectsConfig{Admins: []string{"admin"}})
+	project := &model.Project{ID: 1, Name: "vision"}
+	repo.On("FindByID", uint(1)).Return(project, nil)
+	repo.On("FindByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("FindMember", uint(1), uint(2)).Return(&model.ProjectMember{ProjectID: 1, UserID: 2, Role: model.ProjectRoleMember}, nil)
+	repo.On("FindMember", uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("FindMember", uint(1), uint(4)).Return(&model.ProjectMember{ProjectID: 1, UserID: 4, Role: model.ProjectRoleOwner}, nil)
+	userRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Username: "bob"}, nil)
+	userRepo.On("FindByID", uint(3)).Return(&model.User{ID: 3, Username: "carol"}, nil)
+	userRepo.On("FindByID", uint(4)).Return(&model.User{ID: 4, Username: "alice"}, nil)
+	userRepo.On("FindByID", uint(5)).Return(&model.User{ID: 5, Username: "admin"}, nil)
+
+	_, err := svc.CreateRule(1, 2, ProjectRuleInput{Field: "cwd", Pattern: `^/data/`})
+	assert.ErrorIs(t, err, ErrProjectForbidden)
+	_, err = svc.CreateRule(1, 3, ProjectRuleInput{Field: "cwd", Pattern: `^/data/`})
+	assert.ErrorIs(t, err, ErrProjectForbidden)
+	_, err = svc.CreateRule(9, 4, ProjectRuleInput{Field: "cwd", Pattern: `^/data/`})
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+	// 规则跨项目匹配，项目 owner 也不能添加规则
+	_, err = svc.CreateRule(1, 4, ProjectRuleInput{Field: "cwd", Pattern: `.*`})
+	assert.ErrorIs(t, err, ErrProjectAdminRequired)
+	_, err = svc.CreateRule(1, 5, ProjectRuleInput{Field: "cwd", Pattern: `(`})
+	assert.ErrorIs(t, err, ErrInvalidProjectRule)
+
+	repo.On("CreateRule", mock.MatchedBy(func(r *model.ProjectRule) bool {
+		return r.ProjectID == 1 && r.Field == "cwd" && r.Pattern == `^/data/`
+	})).Return(nil)
+	_, err = svc.CreateRule(1, 5, ProjectRuleInput{Field: "cwd", Pattern: `^/data/`})
+	require.NoError(t, err)
+	// 规则变化后请求全量重新计算
+	assert.True(t, svc.fullResync.Load())
+	assert.Len(t, svc.trigger, 1)
+
+	repo.On("FindRuleByID", uint(7)).Return(&model.ProjectRule{ID: 7, ProjectID: 1, Field: "cwd", Pattern: `^/data/`}, nil)
+	_, err = svc.UpdateRule(1, 7, 4, ProjectRuleInput{Field: "cwd", Pattern: `^/data/`, Priority: 100})
+	assert.ErrorIs(t, err, ErrProjectAdminRequired)
+}
+
+func TestProjectService_RemoveMember_KeepsLastOwner(t *testing.T) {
+	repo := new(MockProjectRepository)
+	svc := NewProjectService(repo, nil, nil, new(MockUserRepository), config.ProjectsConfig{})
+	repo.On("FindByID", uint(1)).Return(&model.Project{ID: 1, Name: "vision"}, nil)
+	repo.On("FindMembers", uint(1)).Return([]model.ProjectMember{
+		{ProjectID: 1, UserID: 4, Role: model.ProjectRoleOwner},
+		{ProjectID: 1, UserID: 5, Role: model.ProjectRoleMember},
+	}, nil)
+	repo.On("DeleteMember", uint(1), uint(5)).Return(nil)
+
+	// 唯一的 owner 不能退出项目
+	assert.ErrorIs(t, svc.RemoveMember(1, 4, 4), ErrInvalidProjectMember)
+	// 成员可以自行退出
+	assert.NoError(t, svc.RemoveMember(1, 5, 5))
+	repo.AssertExpectations(t)
+}
+
+func TestProjectService_AssignJob(t *testing.T) {
+	repo := new(MockProjectRepository)
+	jobRepo := new(MockJobRepository)
+	userRepo := new(MockUserRepository)
+	svc := NewProjectService(repo, jobRepo, nil, userRepo, config.ProjectsConfig{Admins: []string{"admin"}})
+	invalidated := false
+	svc.SetChangedHook(func() { invalidated = true })
+
+	jobRepo.On("FindByID", "job-a").Return(&model.Job{JobID: "job-a"}, nil)
+	jobRepo.On("FindByID", "job-b").Return(&model.Job{JobID: "job-b"}, nil)
+	jobRepo.On("FindByID", "job-free").Return(&model.Job{JobID: "job-free"}, nil)
+	jobRepo.On("FindByID", "missing").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("FindByID", uint(1)).Return(&model.Project{ID: 1, Name: "vision"}, nil)
+	repo.On("FindMember", uint(1), uint(2)).Return(&model.ProjectMember{ProjectID: 1, UserID: 2, Role: model.ProjectRoleMember}, nil)
+	repo.On("FindMember", uint(1), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+	repo.On("FindMember", uint(2), uint(2)).Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Username: "bob"}, nil)
+	userRepo.On("FindByID", uint(3)).Return(&model.User{ID: 3, Username: "carol"}, nil)
+	repo.On("SetManualAssignment", "job-a", uint(1)).Return(nil)
+	repo.On("FindJobProjects", []string{"job-a"}).Return([]model.JobProject{
+		{JobID: "job-a", ProjectID: 1, Source: model.JobProjectSourceManual},
+	}, nil)
+	repo.On("FindJobProjects", []string{"job-b"}).Return([]model.JobProject{
+		{JobID: "job-b", ProjectID: 2, Source: model.JobProjectSourceRule},
+	}, nil)
+	repo.On("FindJobProjects", []string{"job-free"}).Return([]model.JobProject{}, nil)
+
+	_, err := svc.AssignJob("missing", 1, 2)
+	assert.ErrorIs(t, err, ErrProjectJobNotFound)
+	_, err = svc.AssignJob("job-a", 1, 3)
+	assert.ErrorIs(t, err, ErrProjectForbidden)
+	// 不能把其他项目的作业划入自己的项目
+	_, err = svc.AssignJob("job-b", 1, 2)
+	assert.ErrorIs(t, err, ErrProjectForbidden)
+	// 未归属的作业只能由管理员指定
+	_, err = svc.AssignJob("job-free", 1, 2)
+	assert.ErrorIs(t, err, ErrProjectAdminRequired)
+	assert.False(t, invalidated)
+
+	info, err := svc.AssignJob("job-a", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), *info.ProjectID)
+	assert.Equal(t, "vision", info.ProjectName)
+	assert.Equal(t, model.JobProjectSourceManual, info.Source)
+	assert.True(t, invalidated)
+	repo.AssertNumberOfCalls(t, "SetManualAssignment", 1)
+}
+
+func TestProjectService_FilterVisibleJobIDs(t *testing.T) {
+	repo := new(MockProjectRepository)
+	svc := NewProjectService(repo, nil, nil, new(MockUserRepository), config.ProjectsConfig{})
+	repo.On("FindJobProjects", []string{"job-a", "job-b", "job-c"}).Return([]model.JobProject{
+		{JobID: "job-a", ProjectID: 5},
+		{JobID: "job-b", ProjectID: 7},
+	}, nil)
+
+	ids, err := svc.FilterVisibleJobIDs([]string{"job-a", "job-b", "job-c"}, ProjectFilter{Restricted: true, VisibleIDs: []uint{5}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"job-a", "job-c"}, ids)
+
+	// 不限制时不查询归属
+	ids, err = svc.FilterVisibleJobIDs([]string{"job-b"}, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"job-b"}, ids)
+	repo.AssertNumberOfCalls(t, "FindJobProjects", 1)
+}
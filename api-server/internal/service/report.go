@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// 报表输出格式
+const (
+	ReportFormatMarkdown = "markdown"
+	ReportFormatHTML     = "html"
+	ReportFormatCSV      = "csv"
+)
+
+const (
+	defaultReportTopN       = 10
+	defaultReportPeriodDays = 7
+	// reportChunkSize 生成报表时每批查询的分组数
+	reportChunkSize = 500
+)
+
+// idleUtilizations AI 分析判定为空闲的 NPU 利用率等级
+var idleUtilizations = map[string]bool{"idle": true, "low": true}
+
+// failedJobStatuses 异常结束的作业状态
+var failedJobStatuses = map[string]bool{"failed": true, "lost": true}
+
+// UtilizationReport 统计窗口内的 NPU 使用与 AI 分析问题汇总；作业以分组为单位，卡时按窗口内的运行时长计算
+type UtilizationReport struct {
+	From            time.Time        `json:"from"`
+	To              time.Time        `json:"to"`
+	GeneratedAt     time.Time        `json:"generatedAt"`
+	TotalJobs       int              `json:"totalJobs"`
+	RunningJobs     int              `json:"runningJobs"`
+	FailedJobCount  int              `json:"failedJobCount"`
+	UnknownCardJobs int              `json:"unknownCardJobs"` // 卡数未知，不计入卡时
+	CardHours       float64          `json:"cardHours"`
+	IdleCardHours   float64          `json:"idleCardHours"` // AI 分析判定 NPU 利用率为 idle/low 的作业卡时
+	AnalyzedJobs    int              `json:"analyzedJobs"`
+	IssueSeverities map[string]int   `json:"issueSeverities"` // 已分析作业按最高问题级别计数
+	Frameworks      []FrameworkUsage `json:"frameworks"`
+	TopIdleJobs     []ReportJob      `json:"topIdleJobs"`
+	FailedJobs      []ReportJob      `json:"failedJobs"`
+	TopIssueJobs    []ReportJob      `json:"topIssueJobs"`
+	// Jobs 窗口内的全部作业，只用于 CSV 输出
+	Jobs []ReportJob `json:"-"`
+}
+
+// FrameworkUsage 按框架汇总的卡时
+type FrameworkUsage struct {
+	Framework string  `json:"framework"`
+	Jobs      int     `json:"jobs"`
+	CardHours float64 `json:"cardHours"`
+}
+
+// ReportJob 报表中的作业分组
+type ReportJob struct {
+	JobID            string  `json:"jobId"`
+	JobName          string  `json:"jobName"`
+	NodeID           string  `json:"nodeId"`
+	Framework        string  `json:"framework"`
+	Status           string  `json:"status"`
+	CardCount        *int    `json:"cardCount"`
+	CardHours        float64 `json:"cardHours"`
+	StartTime        int64   `json:"startTime"`
+	EndTime          *int64  `json:"endTime"`
+	Category         string  `json:"category"`
+	NPUUtilization   string  `json:"npuUtilization"`
+	MaxIssueSeverity string  `json:"maxIssueSeverity"`
+}
+
+// GenerateReport 统计 [from, to) 内运行过的作业分组：窗口结束前启动、且未在窗口开始前结束。
+// AI 分析字段取自 job_analysis 的提取列，未分析的作业不计入空闲与问题统计；scope 为用户的项目可见范围，定时运行时不限制
+func (s *ReportService) GenerateReport(ctx context.Context, from, to time.Time, topN int, scope ProjectFilter) (*UtilizationReport, error) {
+	if topN <= 0 {
+		topN = defaultReportTopN
+	}
+	now := time.Now()
+	report := &UtilizationReport{From: from, To: to, GeneratedAt: now, IssueSeverities: map[string]int{}}
+	frameworks := make(map[string]*FrameworkUsage)
+
+	toMs := to.UnixMilli() - 1
+	filter := JobGroupFilter{JobFilter: repository.JobFilter{StartTo: &toMs, Projects: scope}}
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		groups, _, next, err := s.jobService.GetGroupedJobsByCursor(filter, "startTime", "desc", cursor, reportChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("query job groups: %w", err)
+		}
+		jobs := make([]ReportJob, 0, len(groups))
+		for _, g := range groups {
+			span := jobActiveSpan(g.MainJob, from, to, now)
+			if span <= 0 {
+				continue
+			}
+			job := newReportJob(g)
+			if g.CardCount != nil {
+				job.CardHours = float64(*g.CardCount) * span.Hours()
+			}
+			jobs = append(jobs, job)
+		}
+		if err := s.fillAnalysisFields(jobs); err != nil {
+			return nil, err
+		}
+		for _, job := range jobs {
+			report.add(job, frameworks)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	for _, f := range frameworks {
+		report.Frameworks = append(report.Frameworks, *f)
+	}
+	sort.Slice(report.Frameworks, func(i, j int) bool {
+		if report.Frameworks[i].CardHours != report.Frameworks[j].CardHours {
+			return report.Frameworks[i].CardHours > report.Frameworks[j].CardHours
+		}
+		return report.Frameworks[i].Framework < report.Frameworks[j].Framework
+	})
+	report.TopIdleJobs = topReportJobs(report.Jobs, topN, func(j ReportJob) bool {
+		return idleUtilizations[j.NPUUtilization]
+	}, byCardHours)
+	report.FailedJobs = topReportJobs(report.Jobs, topN, func(j ReportJob) bool {
+		return failedJobStatuses[j.Status]
+	}, func(a, b ReportJob) bool { return a.StartTime > b.StartTime })
+	report.TopIssueJobs = topReportJobs(report.Jobs, topN, func(j ReportJob) bool {
+		return issueSeverityRank[j.MaxIssueSeverity] >= issueSeverityRank["warning"]
+	}, func(a, b ReportJob) bool {
+		if ra, rb := issueSeverityRank[a.MaxIssueSeverity], issueSeverityRank[b.MaxIssueSeverity]; ra != rb {
+			return ra > rb
+		}
+		return byCardHours(a, b)
+	})
+	return report, nil
+}
+
+func (r *UtilizationReport) add(job ReportJob, frameworks map[string]*FrameworkUsage) {
+	r.Jobs = append(r.Jobs, job)
+	r.TotalJobs++
+	if job.Status == "running" {
+		r.RunningJobs++
+	}
+	if failedJobStatuses[job.Status] {
+		r.FailedJobCount++
+	}
+	if job.CardCount == nil {
+		r.UnknownCardJobs++
+	}
+	r.CardHours += job.CardHours
+	if idleUtilizations[job.NPUUtilization] {
+		r.IdleCardHours += job.CardHours
+	}
+	if job.Category != "" {
+		r.AnalyzedJobs++
+		if job.MaxIssueSeverity != "" {
+			r.IssueSeverities[job.MaxIssueSeverity]++
+		}
+	}
+	name := job.Framework
+	if name == "" {
+		name = "unknown"
+	}
+	f, ok := frameworks[name]
+	if !ok {
+		f = &FrameworkUsage{Framework: name}
+		frameworks[name] = f
+	}
+	f.Jobs++
+	f.CardHours += job.CardHours
+}
+
+// fillAnalysisFields 批量填充作业的 AI 分析提取字段
+func (s *ReportService) fillAnalysisFields(jobs []ReportJob) error {
+	if len(jobs) == 0 || s.analysisRepo == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		ids = append(ids, j.JobID)
+	}
+	analyses, err := s.analysisRepo.FindFieldsByJobIDs(ids)
+	if err != nil {
+		return fmt.Errorf("query analysis fields: %w", err)
+	}
+	fields := make(map[string]model.JobAnalysisFields, len(analyses))
+	for _, a := range analyses {
+		fields[a.JobID] = a.JobAnalysisFields
+	}
+	for i := range jobs {
+		if f, ok := fields[jobs[i].JobID]; ok {
+			jobs[i].Category = f.Category
+			jobs[i].NPUUtilization = f.NPUUtilization
+			jobs[i].MaxIssueSeverity = f.MaxIssueSeverity
+		}
+	}
+	return nil
+}
+
+func newReportJob(g JobGroup) ReportJob {
+	job := ReportJob{
+		JobID:     g.MainJob.JobID,
+		JobName:   stringOrEmpty(g.MainJob.JobName),
+		NodeID:    stringOrEmpty(g.MainJob.NodeID),
+		Framework: stringOrEmpty(g.MainJob.Framework),
+		Status:    stringOrEmpty(g.MainJob.Status),
+		CardCount: g.CardCount,
+		EndTime:   g.MainJob.EndTime,
+	}
+	if g.MainJob.StartTime != nil {
+		job.StartTime = *g.MainJob.StartTime
+	}
+	return job
+}
+
+// jobActiveSpan 返回作业在 [from, to) 内的运行时长。未记录结束时间时：运行中的作业截止到当前时间，
+// 已结束的作业以最后更新时间近似结束时间
+func jobActiveSpan(job model.Job, from, to, now time.Time) time.Duration {
+	if job.StartTime == nil {
+		return 0
+	}
+	start := time.UnixMilli(*job.StartTime)
+	end := now
+	switch {
+	case job.EndTime != nil:
+		end = time.UnixMilli(*job.EndTime)
+	case isTerminalJobStatus(job.Status) && job.UpdatedAt != nil:
+		end = *job.UpdatedAt
+	}
+	if end.After(now) {
+		end = now
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+func byCardHours(a, b ReportJob) bool {
+	if a.CardHours != b.CardHours {
+		return a.CardHours > b.CardHours
+	}
+	return a.JobID < b.JobID
+}
+
+// topReportJobs 筛选满足条件的作业，按 less 排序后取前 n 个
+func topReportJobs(jobs []ReportJob, n int, match func(ReportJob) bool, less func(a, b ReportJob) bool) []ReportJob {
+	matched := make([]ReportJob, 0)
+	for _, j := range jobs {
+		if match(j) {
+			matched = append(matched, j)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+	if len(matched) > n {
+		matched = matched[:n]
+	}
+	return matched
+}
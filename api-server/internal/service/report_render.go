@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/utils"
+)
+
+const reportTimeLayout = "2006-01-02 15:04"
+
+// ReportFileExt 报表格式对应的文件扩展名
+func ReportFileExt(format string) string {
+	switch format {
+	case ReportFormatHTML:
+		return "html"
+	case ReportFormatCSV:
+		return "csv"
+	default:
+		return "md"
+	}
+}
+
+// ReportContentType 报表格式对应的 Content-Type
+func ReportContentType(format string) string {
+	switch format {
+	case ReportFormatHTML:
+		return "text/html; charset=utf-8"
+	case ReportFormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// RenderReport 按格式输出报表：Markdown/HTML 为汇总与各列表，CSV 为窗口内全部作业明细
+func RenderReport(r *UtilizationReport, format string) ([]byte, error) {
+	switch format {
+	case ReportFormatMarkdown:
+		return renderReportMarkdown(r), nil
+	case ReportFormatHTML:
+		return renderReportHTML(r)
+	case ReportFormatCSV:
+		return renderReportCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// reportSummaryRows 汇总指标，Markdown 与 HTML 共用
+func reportSummaryRows(r *UtilizationReport) [][2]string {
+	idleShare := "-"
+	if r.CardHours > 0 {
+		idleShare = fmt.Sprintf("%.1f%%", r.IdleCardHours/r.CardHours*100)
+	}
+	return [][2]string{
+		{"作业数", strconv.Itoa(r.TotalJobs)},
+		{"运行中", strconv.Itoa(r.RunningJobs)},
+		{"异常结束", strconv.Itoa(r.FailedJobCount)},
+		{"总卡时", formatHours(r.CardHours)},
+		{"空闲卡时（idle/low）", fmt.Sprintf("%s（%s）", formatHours(r.IdleCardHours), idleShare)},
+		{"卡数未知的作业", strconv.Itoa(r.UnknownCardJobs)},
+		{"已完成 AI 分析", strconv.Itoa(r.AnalyzedJobs)},
+		{"问题分布", formatSeverities(r.IssueSeverities)},
+	}
+}
+
+func formatHours(h float64) string {
+	return strconv.FormatFloat(h, 'f', 1, 64)
+}
+
+func formatSeverities(counts map[string]int) string {
+	var parts []string
+	for _, s := range []string{"critical", "warning", "info", "none"} {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", s, counts[s]))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, "，")
+}
+
+func formatCardCount(n *int) string {
+	if n == nil {
+		return "unknown"
+	}
+	return strconv.Itoa(*n)
+}
+
+func formatMillis(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).Format(reportTimeLayout)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// reportJobSection 报表中的一个作业列表
+type reportJobSection struct {
+	title string
+	jobs  []ReportJob
+}
+
+func reportJobSections(r *UtilizationReport) []reportJobSection {
+	return []reportJobSection{
+		{"空闲卡时最多的作业", r.TopIdleJobs},
+		{"异常结束的作业", r.FailedJobs},
+		{"AI 分析发现问题的作业", r.TopIssueJobs},
+	}
+}
+
+// reportJobRows 作业列表的表头与各行，Markdown 与 HTML 共用
+func reportJobRows(jobs []ReportJob) (header []string, rows [][]string) {
+	header = []string{"作业", "节点", "框架", "状态", "卡数", "卡时", "NPU 利用率", "最高问题级别", "启动时间"}
+	for _, j := range jobs {
+		name := j.JobName
+		if name == "" {
+			name = j.JobID
+		}
+		rows = append(rows, []string{
+			name, orDash(j.NodeID), orDash(j.Framework), orDash(j.Status), formatCardCount(j.CardCount),
+			formatHours(j.CardHours), orDash(j.NPUUtilization), orDash(j.MaxIssueSeverity), formatMillis(j.StartTime),
+		})
+	}
+	return header, rows
+}
+
+func renderReportMarkdown(r *UtilizationReport) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# NPU 使用与 AI 分析周报\n\n统计区间：%s ~ %s\n\n", r.From.Format(reportTimeLayout), r.To.Format(reportTimeLayout))
+
+	b.WriteString("## 汇总\n\n| 指标 | 数值 |\n| --- | --- |\n")
+	for _, row := range reportSummaryRows(r) {
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], row[1])
+	}
+
+	b.WriteString("\n## 各框架卡时\n\n")
+	if len(r.Frameworks) == 0 {
+		b.WriteString("无\n")
+	} else {
+		b.WriteString("| 框架 | 作业数 | 卡时 |\n| --- | --- | --- |\n")
+		for _, f := range r.Frameworks {
+			fmt.Fprintf(&b, "| %s | %d | %s |\n", markdownCell(f.Framework), f.Jobs, formatHours(f.CardHours))
+		}
+	}
+
+	for _, section := range reportJobSections(r) {
+		fmt.Fprintf(&b, "\n## %s\n\n", section.title)
+		if len(section.jobs) == 0 {
+			b.WriteString("无\n")
+			continue
+		}
+		header, rows := reportJobRows(section.jobs)
+		b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		b.WriteString(strings.Repeat("| --- ", len(header)) + "|\n")
+		for _, row := range rows {
+			for i := range row {
+				row[i] = markdownCell(row[i])
+			}
+			b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	}
+	fmt.Fprintf(&b, "\n生成时间：%s\n", r.GeneratedAt.Format(reportTimeLayout))
+	return []byte(b.String())
+}
+
+// markdownCell 转义表格分隔符与换行
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(s)
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>NPU 使用与 AI 分析周报</title>
+<style>
+body { font-family: sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+th { background: #f0f0f0; }
+</style>
+</head>
+<body>
+<h1>NPU 使用与 AI 分析周报</h1>
+<p>统计区间：{{.From}} ~ {{.To}}</p>
+<h2>汇总</h2>
+<table>
+{{range .Summary}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+<h2>各框架卡时</h2>
+{{if .Frameworks}}<table>
+<tr><th>框架</th><th>作业数</th><th>卡时</th></tr>
+{{range .Frameworks}}<tr><td>{{.Framework}}</td><td>{{.Jobs}}</td><td>{{printf "%.1f" .CardHours}}</td></tr>
+{{end}}</table>{{else}}<p>无</p>{{end}}
+{{range .Sections}}<h2>{{.Title}}</h2>
+{{if .Rows}}<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{else}}<p>无</p>{{end}}
+{{end}}<p>生成时间：{{.GeneratedAt}}</p>
+</body>
+</html>
+`))
+
+type reportHTMLSection struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+func renderReportHTML(r *UtilizationReport) ([]byte, error) {
+	data := struct {
+		From, To, GeneratedAt string
+		Summary               [][2]string
+		Frameworks            []FrameworkUsage
+		Sections              []reportHTMLSection
+	}{
+		From:        r.From.Format(reportTimeLayout),
+		To:          r.To.Format(reportTimeLayout),
+		GeneratedAt: r.GeneratedAt.Format(reportTimeLayout),
+		Summary:     reportSummaryRows(r),
+		Frameworks:  r.Frameworks,
+	}
+	for _, section := range reportJobSections(r) {
+		header, rows := reportJobRows(section.jobs)
+		data.Sections = append(data.Sections, reportHTMLSection{Title: section.title, Header: header, Rows: rows})
+	}
+	var buf bytes.Buffer
+	if err := reportHTMLTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderReportCSV 输出全部作业明细，带 BOM 便于 Excel 识别 UTF-8；文本列做 CSV 注入转义
+func renderReportCSV(r *UtilizationReport) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"jobId", "jobName", "nodeId", "framework", "status", "cardCount", "cardHours",
+		"category", "npuUtilization", "maxIssueSeverity", "startTime", "endTime"})
+	for _, j := range r.Jobs {
+		end := ""
+		if j.EndTime != nil {
+			end = formatMillis(*j.EndTime)
+		}
+		cardCount := ""
+		if j.CardCount != nil {
+			cardCount = strconv.Itoa(*j.CardCount)
+		}
+		w.Write([]string{utils.SanitizeCSVCell(j.JobID), utils.SanitizeCSVCell(j.JobName), utils.SanitizeCSVCell(j.NodeID),
+			utils.SanitizeCSVCell(j.Framework), utils.SanitizeCSVCell(j.Status), cardCount,
+			strconv.FormatFloat(j.CardHours, 'f', 2, 64), utils.SanitizeCSVCell(j.Category),
+			utils.SanitizeCSVCell(j.NPUUtilization), utils.SanitizeCSVCell(j.MaxIssueSeverity),
+			formatMillis(j.StartTime), end})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
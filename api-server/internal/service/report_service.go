@@ -0,0 +1,632 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReportScheduleNotFound 报表计划不存在
+	ErrReportScheduleNotFound = errors.New("report schedule not found")
+	// ErrReportScheduleReadOnly 配置文件中的计划只能通过修改配置变更
+	ErrReportScheduleReadOnly = errors.New("report schedule is defined in the config file")
+	// ErrReportScheduleExists 计划名称与已有计划（含配置文件中的）重复
+	ErrReportScheduleExists = errors.New("report schedule name already exists")
+	// ErrInvalidReportSchedule 计划内容不合法
+	ErrInvalidReportSchedule = errors.New("invalid report schedule")
+	// ErrReportRunning 同一计划的上一次运行尚未结束
+	ErrReportRunning = errors.New("report is already running")
+	// ErrReportRunNotFound 运行记录不存在
+	ErrReportRunNotFound = errors.New("report run not found")
+)
+
+// 报表运行触发方式与状态
+const (
+	reportTriggerSchedule = "schedule"
+	reportTriggerManual   = "manual"
+
+	reportRunRunning   = "running"
+	reportRunSucceeded = "succeeded"
+	reportRunFailed    = "failed"
+)
+
+// reportWebhookTimeout 投递 webhook 的超时时间
+const reportWebhookTimeout = 30 * time.Second
+
+var reportFileNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ReportScheduleInput 通过接口创建/更新报表计划的内容；Webhook 为 nil 表示更新时保留原值
+type ReportScheduleInput struct {
+	Name       string   `json:"name"`
+	Cron       string   `json:"cron"`
+	PeriodDays int      `json:"periodDays"`
+	Formats    []string `json:"formats"`
+	Webhook    *string  `json:"webhook"`
+	TopN       int      `json:"topN"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+// ReportService 生成 NPU 使用与 AI 问题周报，并按 cron 计划投递到 webhook 或写入目录。
+// 计划来自配置文件 reports.schedules 与 report_schedules 表，每次运行记录在 report_runs 表
+type ReportService struct {
+	jobService   JobServiceInterface
+	analysisRepo repository.JobAnalysisRepositoryInterface
+	repo         repository.ReportRepositoryInterface
+	client       *http.Client
+
+	mu      sync.Mutex
+	cfg     config.ReportsConfig
+	started time.Time
+	// lastFire 各计划上一次到期的时间，下一次运行时间由此按 cron 推算
+	lastFire map[string]time.Time
+	running  map[string]bool
+}
+
+// NewReportService 创建报表服务；analysisRepo 为 nil 时报表不含 AI 分析统计
+func NewReportService(jobService JobServiceInterface, analysisRepo repository.JobAnalysisRepositoryInterface,
+	repo repository.ReportRepositoryInterface, cfg config.ReportsConfig) *ReportService {
+	return &ReportService{
+		jobService:   jobService,
+		analysisRepo: analysisRepo,
+		repo:         repo,
+		client:       &http.Client{Timeout: reportWebhookTimeout},
+		cfg:          cfg,
+		started:      time.Now(),
+		lastFire:     make(map[string]time.Time),
+		running:      make(map[string]bool),
+	}
+}
+
+// UpdateConfig 热更新配置文件中的报表计划
+func (s *ReportService) UpdateConfig(cfg config.ReportsConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// Start 后台检查计划是否到期。已有运行记录的计划从最近一次按计划运行的时间推算下次运行，
+// 服务停机期间错过的运行在启动后补跑一次；新计划从服务启动（或计划创建）时开始计算
+func (s *ReportService) Start(ctx context.Context) {
+	if starts, err := s.repo.LatestRunStarts(); err != nil {
+		slog.Warn("failed to load report run history", "error", err)
+	} else {
+		s.mu.Lock()
+		for name, t := range starts {
+			s.lastFire[name] = t
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	interval := time.Duration(s.cfg.CheckIntervalSeconds) * time.Second
+	s.mu.Unlock()
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.runDue(ctx, now)
+			}
+		}
+	}()
+}
+
+// runDue 启动所有已到期的计划
+func (s *ReportService) runDue(ctx context.Context, now time.Time) {
+	schedules, err := s.allSchedules()
+	if err != nil {
+		slog.Error("failed to list report schedules", "error", err)
+		return
+	}
+	for _, sched := range s.dueSchedules(schedules, now) {
+		run, err := s.startRun(sched, reportTriggerSchedule, now)
+		if err != nil {
+			if !errors.Is(err, ErrReportRunning) {
+				slog.Error("failed to start scheduled report", "schedule", sched.Name, "error", err)
+			}
+			continue
+		}
+		go s.completeRun(ctx, sched, run)
+	}
+}
+
+// dueSchedules 返回到期的启用计划，并把它们的到期时间推进到 now
+func (s *ReportService) dueSchedules(schedules []model.ReportSchedule, now time.Time) []model.ReportSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []model.ReportSchedule
+	for _, sched := range schedules {
+		if !sched.Enabled {
+			continue
+		}
+		spec, err := cron.ParseStandard(sched.Cron)
+		if err != nil {
+			continue
+		}
+		last, ok := s.lastFire[sched.Name]
+		if !ok {
+			last = s.started
+			if sched.CreatedAt.After(last) {
+				last = sched.CreatedAt
+			}
+			s.lastFire[sched.Name] = last
+		}
+		if spec.Next(last).After(now) {
+			continue
+		}
+		s.lastFire[sched.Name] = now
+		due = append(due, sched)
+	}
+	return due
+}
+
+// ListSchedules 返回配置文件与数据库中的全部计划，webhook 地址只保留协议与主机
+func (s *ReportService) ListSchedules() ([]model.ReportSchedule, error) {
+	schedules, err := s.allSchedules()
+	if err != nil {
+		return nil, err
+	}
+	for i := range schedules {
+		schedules[i].Webhook = maskWebhook(schedules[i].Webhook)
+	}
+	return schedules, nil
+}
+
+func (s *ReportService) allSchedules() ([]model.ReportSchedule, error) {
+	s.mu.Lock()
+	configured := s.cfg.Schedules
+	s.mu.Unlock()
+
+	schedules := make([]model.ReportSchedule, 0, len(configured))
+	for _, c := range configured {
+		schedules = append(schedules, model.ReportSchedule{
+			Name:       c.Name,
+			Cron:       c.Cron,
+			PeriodDays: c.PeriodDays,
+			Formats:    c.Formats,
+			Webhook:    c.Webhook,
+			Dir:        c.Dir,
+			TopN:       c.TopN,
+			Enabled:    !c.Disabled,
+			Source:     "config",
+		})
+	}
+	stored, err := s.repo.ListSchedules()
+	if err != nil {
+		return nil, err
+	}
+	for _, sched := range stored {
+		sched.Source = "db"
+		schedules = append(schedules, sched)
+	}
+	return schedules, nil
+}
+
+func (s *ReportService) findSchedule(name string) (*model.ReportSchedule, error) {
+	schedules, err := s.allSchedules()
+	if err != nil {
+		return nil, err
+	}
+	for i := range schedules {
+		if schedules[i].Name == name {
+			return &schedules[i], nil
+		}
+	}
+	return nil, ErrReportScheduleNotFound
+}
+
+// maskWebhook 隐藏 webhook 地址中的路径与参数（通常含访问令牌）
+func maskWebhook(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
+
+// CreateSchedule 创建数据库中的报表计划，名称不能与配置文件中的计划重复
+func (s *ReportService) CreateSchedule(input ReportScheduleInput, userID uint) (*model.ReportSchedule, error) {
+	sched := &model.ReportSchedule{Enabled: true, CreatedBy: userID}
+	if err := s.applyScheduleInput(sched, input); err != nil {
+		return nil, err
+	}
+	if err := s.checkScheduleName(sched.Name, 0); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateSchedule(sched); err != nil {
+		return nil, err
+	}
+	sched.Source = "db"
+	sched.Webhook = maskWebhook(sched.Webhook)
+	return sched, nil
+}
+
+// UpdateSchedule 更新数据库中的报表计划
+func (s *ReportService) UpdateSchedule(id uint, input ReportScheduleInput) (*model.ReportSchedule, error) {
+	sched, err := s.findStoredSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	oldName := sched.Name
+	if err := s.applyScheduleInput(sched, input); err != nil {
+		return nil, err
+	}
+	if err := s.checkScheduleName(sched.Name, sched.ID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateSchedule(sched); err != nil {
+		return nil, err
+	}
+	if sched.Name != oldName {
+		s.mu.Lock()
+		delete(s.lastFire, oldName)
+		s.mu.Unlock()
+	}
+	sched.Source = "db"
+	sched.Webhook = maskWebhook(sched.Webhook)
+	return sched, nil
+}
+
+// DeleteSchedule 删除数据库中的报表计划，运行记录保留
+func (s *ReportService) DeleteSchedule(id uint) error {
+	sched, err := s.findStoredSchedule(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteSchedule(sched.ID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.lastFire, sched.Name)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ReportService) findStoredSchedule(id uint) (*model.ReportSchedule, error) {
+	if id == 0 {
+		return nil, ErrReportScheduleReadOnly
+	}
+	sched, err := s.repo.FindScheduleByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportScheduleNotFound
+		}
+		return nil, err
+	}
+	return sched, nil
+}
+
+// applyScheduleInput 校验并写入计划内容，未指定的周期、格式与条数使用默认值
+func (s *ReportService) applyScheduleInput(sched *model.ReportSchedule, input ReportScheduleInput) error {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidReportSchedule)
+	}
+	webhook := sched.Webhook
+	if input.Webhook != nil {
+		webhook = strings.TrimSpace(*input.Webhook)
+	}
+	if err := config.ValidateReportSchedule(config.ReportScheduleConfig{
+		Name:       input.Name,
+		Cron:       input.Cron,
+		PeriodDays: input.PeriodDays,
+		Formats:    input.Formats,
+		Webhook:    webhook,
+		TopN:       input.TopN,
+	}); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReportSchedule, err)
+	}
+	if input.Webhook != nil {
+		if err := s.checkWebhookHost(webhook); err != nil {
+			return err
+		}
+	}
+	sched.Name = input.Name
+	sched.Cron = strings.TrimSpace(input.Cron)
+	sched.PeriodDays = input.PeriodDays
+	sched.Formats = input.Formats
+	sched.Webhook = webhook
+	sched.TopN = input.TopN
+	if input.Enabled != nil {
+		sched.Enabled = *input.Enabled
+	}
+	return nil
+}
+
+// checkWebhookHost 接口维护的计划只能投递到 reports.webhook_hosts 中的主机，避免借服务端请求访问内网地址
+func (s *ReportService) checkWebhookHost(webhook string) error {
+	if webhook == "" {
+		return nil
+	}
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return fmt.Errorf("%w: webhook must be an http(s) URL", ErrInvalidReportSchedule)
+	}
+	s.mu.Lock()
+	allowed := s.cfg.WebhookHosts
+	s.mu.Unlock()
+	for _, host := range allowed {
+		host = strings.TrimSpace(host)
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: webhook host %q is not listed in reports.webhook_hosts", ErrInvalidReportSchedule, u.Host)
+}
+
+// checkScheduleName 检查名称是否与其他计划重复；excludeID 为正在更新的计划
+func (s *ReportService) checkScheduleName(name string, excludeID uint) error {
+	s.mu.Lock()
+	for _, c := range s.cfg.Schedules {
+		if c.Name == name {
+			s.mu.Unlock()
+			return ErrReportScheduleExists
+		}
+	}
+	s.mu.Unlock()
+	existing, err := s.repo.FindScheduleByName(name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != excludeID {
+		return ErrReportScheduleExists
+	}
+	return nil
+}
+
+// RunSchedule 立即运行一次计划（不论是否启用），返回运行记录；报表在后台生成与投递
+func (s *ReportService) RunSchedule(name string) (*model.ReportRun, error) {
+	sched, err := s.findSchedule(name)
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.startRun(*sched, reportTriggerManual, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	copied := *run
+	go s.completeRun(context.Background(), *sched, run)
+	return &copied, nil
+}
+
+// reportPeriod 统计窗口截止到运行当天零点（服务器本地时区），向前 periodDays 天
+func reportPeriod(now time.Time, periodDays int) (time.Time, time.Time) {
+	if periodDays <= 0 {
+		periodDays = defaultReportPeriodDays
+	}
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return to.AddDate(0, 0, -periodDays), to
+}
+
+// startRun 标记计划运行中并写入运行记录
+func (s *ReportService) startRun(sched model.ReportSchedule, trigger string, now time.Time) (*model.ReportRun, error) {
+	s.mu.Lock()
+	if s.running[sched.Name] {
+		s.mu.Unlock()
+		return nil, ErrReportRunning
+	}
+	s.running[sched.Name] = true
+	s.mu.Unlock()
+
+	from, to := reportPeriod(now, sched.PeriodDays)
+	run := &model.ReportRun{
+		ScheduleName: sched.Name,
+		Trigger:      trigger,
+		PeriodFrom:   from,
+		PeriodTo:     to,
+		Status:       reportRunRunning,
+		StartedAt:    now,
+	}
+	if err := s.repo.CreateRun(run); err != nil {
+		s.finishRunning(sched.Name)
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *ReportService) finishRunning(name string) {
+	s.mu.Lock()
+	delete(s.running, name)
+	s.mu.Unlock()
+}
+
+// completeRun 生成并投递报表，结果写回运行记录
+func (s *ReportService) completeRun(ctx context.Context, sched model.ReportSchedule, run *model.ReportRun) {
+	defer s.finishRunning(sched.Name)
+
+	report, err := s.GenerateReport(ctx, run.PeriodFrom, run.PeriodTo, sched.TopN, ProjectFilter{})
+	if err == nil {
+		err = s.deliver(ctx, sched, report, run)
+	}
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = reportRunSucceeded
+	if err != nil {
+		run.Status = reportRunFailed
+		run.Error = err.Error()
+		slog.Error("report run failed", "schedule", sched.Name, "run_id", run.ID, "error", err)
+	} else {
+		slog.Info("report delivered", "schedule", sched.Name, "run_id", run.ID, "files", len(run.Files), "webhook", run.WebhookDelivered)
+	}
+	if err := s.repo.UpdateRun(run); err != nil {
+		slog.Error("failed to save report run", "schedule", sched.Name, "run_id", run.ID, "error", err)
+	}
+}
+
+// deliver 按计划的格式渲染报表，写入目录并/或投递 webhook；均未指定时写入 reports.dir。
+// 单个投递目标失败不影响其他目标，错误合并返回
+func (s *ReportService) deliver(ctx context.Context, sched model.ReportSchedule, report *UtilizationReport, run *model.ReportRun) error {
+	formats := sched.Formats
+	if len(formats) == 0 {
+		formats = []string{ReportFormatMarkdown}
+	}
+	contents := make(map[string][]byte, len(formats))
+	for _, f := range formats {
+		data, err := RenderReport(report, f)
+		if err != nil {
+			return err
+		}
+		contents[f] = data
+	}
+
+	dir := sched.Dir
+	if dir == "" && sched.Webhook == "" {
+		s.mu.Lock()
+		dir = s.cfg.Dir
+		s.mu.Unlock()
+	}
+	var errs []error
+	if dir != "" {
+		files, err := writeReportFiles(dir, sched.Name, report, formats, contents)
+		run.Files = files
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if sched.Webhook != "" {
+		var err error
+		// 数据库中的计划可能在收紧 webhook_hosts 之前创建，投递前再校验一次
+		if sched.Source == "db" {
+			err = s.checkWebhookHost(sched.Webhook)
+		}
+		if err == nil {
+			err = s.postWebhook(ctx, sched, report, contents)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			run.WebhookDelivered = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeReportFiles 写入 <dir>/<计划名>_<起始日期>_<截止日期>.<扩展名>，先写临时文件再重命名
+func writeReportFiles(dir, name string, report *UtilizationReport, formats []string, contents map[string][]byte) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create report dir: %w", err)
+	}
+	base := fmt.Sprintf("%s_%s_%s", reportFileNameSanitizer.ReplaceAllString(name, "_"),
+		report.From.Format("20060102"), report.To.Format("20060102"))
+	var files []string
+	for _, f := range formats {
+		path := filepath.Join(dir, base+"."+ReportFileExt(f))
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, contents[f], 0o644); err != nil {
+			os.Remove(tmp)
+			return files, fmt.Errorf("write report file: %w", err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return files, fmt.Errorf("write report file: %w", err)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// reportWebhookPayload webhook 请求体：text 为 Markdown 正文（兼容常见聊天机器人），
+// summary 为结构化汇总，contents 为各格式的完整内容
+type reportWebhookPayload struct {
+	Schedule   string             `json:"schedule"`
+	PeriodFrom time.Time          `json:"periodFrom"`
+	PeriodTo   time.Time          `json:"periodTo"`
+	Text       string             `json:"text"`
+	Summary    *UtilizationReport `json:"summary"`
+	Contents   map[string]string  `json:"contents"`
+}
+
+func (s *ReportService) postWebhook(ctx context.Context, sched model.ReportSchedule, report *UtilizationReport, contents map[string][]byte) error {
+	payload := reportWebhookPayload{
+		Schedule:   sched.Name,
+		PeriodFrom: report.From,
+		PeriodTo:   report.To,
+		Summary:    report,
+		Contents:   make(map[string]string, len(contents)),
+	}
+	for f, data := range contents {
+		payload.Contents[f] = string(data)
+	}
+	if md, ok := contents[ReportFormatMarkdown]; ok {
+		payload.Text = string(md)
+	} else {
+		payload.Text = string(renderReportMarkdown(report))
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sched.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// url.Error 中的完整地址可能带令牌，只保留底层错误与主机
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook %s: %w", maskWebhook(sched.Webhook), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: unexpected status %d", maskWebhook(sched.Webhook), resp.StatusCode)
+	}
+	return nil
+}
+
+// ListRuns 返回运行记录，scheduleName 为空表示全部计划
+func (s *ReportService) ListRuns(scheduleName string, limit int) ([]model.ReportRun, error) {
+	runs, err := s.repo.ListRuns(scheduleName, limit)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []model.ReportRun{}
+	}
+	return runs, nil
+}
+
+// GetRun 获取单条运行记录
+func (s *ReportService) GetRun(id uint) (*model.ReportRun, error) {
+	run, err := s.repo.FindRunByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportRunNotFound
+		}
+		return nil, err
+	}
+	return run, nil
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/config"
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+)
+
+// MockReportRepository is a mock implementation of ReportRepositoryInterface
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) ListSchedules() ([]model.ReportSchedule, error) {
+	args := m.Called()
+	return args.Get(0).([]model.ReportSchedule), args.Error(1)
+}
+
+func (m *MockReportRepository) FindScheduleByID(id uint) (*model.ReportSchedule, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReportSchedule), args.Error(1)
+}
+
+func (m *MockReportRepository) FindScheduleByName(name string) (*model.ReportSchedule, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReportSchedule), args.Error(1)
+}
+
+func (m *MockReportRepository) CreateSchedule(schedule *model.ReportSchedule) error {
+	args := m.Called(schedule)
+	return args.Error(0)
+}
+
+func (m *MockReportRepository) UpdateSchedule(schedule *model.ReportSchedule) error {
+	args := m.Called(schedule)
+	return args.Error(0)
+}
+
+func (m *MockReportRepository) DeleteSchedule(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockReportRepository) CreateRun(run *model.ReportRun) error {
+	args := m.Called(run)
+	return args.Error(0)
+}
+
+func (m *MockReportRepository) UpdateRun(run *model.ReportRun) error {
+	args := m.Called(run)
+	return args.Error(0)
+}
+
+func (m *MockReportRepository) FindRunByID(id uint) (*model.ReportRun, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ReportRun), args.Error(1)
+}
+
+func (m *MockReportRepository) ListRuns(scheduleName string, limit int) ([]model.ReportRun, error) {
+	args := m.Called(scheduleName, limit)
+	return args.Get(0).([]model.ReportRun), args.Error(1)
+}
+
+func (m *MockReportRepository) LatestRunStarts() (map[string]time.Time, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]time.Time), args.Error(1)
+}
+
+func reportTestGroup(jobID, framework, status string, cards *int, start time.Time, end *time.Time) JobGroup {
+	startMs := start.UnixMilli()
+	job := model.Job{JobID: jobID, Framework: &framework, Status: &status, StartTime: &startMs}
+	if end != nil {
+		endMs := end.UnixMilli()
+		job.EndTime = &endMs
+	}
+	return JobGroup{MainJob: job, CardCount: cards}
+}
+
+func TestJobActiveSpan(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	now := to.Add(time.Hour)
+	ms := func(t time.Time) *int64 { v := t.UnixMilli(); return &v }
+	failed := "failed"
+	updated := from.Add(5 * time.Hour)
+
+	tests := []struct {
+		name string
+		job  model.Job
+		want time.Duration
+	}{
+		{"no start time", model.Job{}, 0},
+		{"started before window, still running", model.Job{StartTime: ms(from.Add(-time.Hour))}, 24 * time.Hour},
+		{"ended inside window", model.Job{StartTime: ms(from.Add(time.Hour)), EndTime: ms(from.Add(3 * time.Hour))}, 2 * time.Hour},
+		{"ended before window", model.Job{StartTime: ms(from.Add(-3 * time.Hour)), EndTime: ms(from.Add(-time.Hour))}, 0},
+		{"terminal without end time uses updated_at", model.Job{StartTime: ms(from.Add(time.Hour)), Status: &failed, UpdatedAt: &updated}, 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, jobActiveSpan(tt.job, from, to, now))
+		})
+	}
+}
+
+func TestReportPeriod(t *testing.T) {
+	now := time.Date(2026, 3, 9, 9, 30, 0, 0, time.UTC)
+	from, to := reportPeriod(now, 0)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), to)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), from)
+
+	from, _ = reportPeriod(now, 1)
+	assert.Equal(t, time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), from)
+}
+
+func TestReportService_GenerateReport(t *testing.T) {
+	mockJobService := new(MockJobServiceForLLM)
+	mockAnalysisRepo := new(MockJobAnalysisRepository)
+	svc := NewReportService(mockJobService, mockAnalysisRepo, new(MockReportRepository), config.ReportsConfig{})
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	two, eight := 2, 8
+	end := from.Add(10 * time.Hour)
+	groups := []JobGroup{
+		reportTestGroup("job-idle", "pytorch", "completed", &eight, from, &end),
+		reportTestGroup("job-failed", "mindspore", "failed", &two, from.Add(2*time.Hour), &end),
+		reportTestGroup("job-unknown", "pytorch", "completed", nil, from, &end),
+		// 窗口开始前已结束，不计入
+		reportTestGroup("job-old", "pytorch", "completed", &two, from.Add(-48*time.Hour), ptrTime(from.Add(-time.Hour))),
+	}
+	toMs := to.UnixMilli() - 1
+	filter := JobGroupFilter{JobFilter: JobFilter{StartTo: &toMs}}
+	mockJobService.On("GetGroupedJobsByCursor", filter, "startTime", "desc", "", reportChunkSize).
+		Return(groups, int64(len(groups)), "", nil)
+	mockAnalysisRepo.On("FindFieldsByJobIDs", []string{"job-idle", "job-failed", "job-unknown"}).Return([]model.JobAnalysis{
+		{JobID: "job-idle", JobAnalysisFields: model.JobAnalysisFields{Category: "training", NPUUtilization: "idle", MaxIssueSeverity: "warning"}},
+		{JobID: "job-failed", JobAnalysisFields: model.JobAnalysisFields{Category: "training", NPUUtilization: "high", MaxIssueSeverity: "critical"}},
+	}, nil)
+
+	report, err := svc.GenerateReport(context.Background(), from, to, 1, ProjectFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.TotalJobs)
+	assert.Equal(t, 1, report.FailedJobCount)
+	assert.Equal(t, 1, report.UnknownCardJobs)
+	assert.Equal(t, 2, report.AnalyzedJobs)
+	assert.InDelta(t, 8*10+2*8, report.CardHours, 1e-9)
+	assert.InDelta(t, 80, report.IdleCardHours, 1e-9)
+	assert.Equal(t, map[string]int{"warning": 1, "critical": 1}, report.IssueSeverities)
+	assert.Equal(t, []FrameworkUsage{
+		{Framework: "pytorch", Jobs: 2, CardHours: 80},
+		{Framework: "mindspore", Jobs: 1, CardHours: 16},
+	}, report.Frameworks)
+	if assert.Len(t, report.TopIdleJobs, 1) {
+		assert.Equal(t, "job-idle", report.TopIdleJobs[0].JobID)
+	}
+	if assert.Len(t, report.FailedJobs, 1) {
+		assert.Equal(t, "job-failed", report.FailedJobs[0].JobID)
+	}
+	// topN=1 时只保留最严重的问题
+	if assert.Len(t, report.TopIssueJobs, 1) {
+		assert.Equal(t, "job-failed", report.TopIssueJobs[0].JobID)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+func sampleReport() *UtilizationReport {
+	cards := 4
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	job := ReportJob{JobID: "job-1", JobName: "train|llama", Framework: "pytorch", Status: "running", CardCount: &cards,
+		CardHours: 12.5, NPUUtilization: "idle", StartTime: from.UnixMilli()}
+	return &UtilizationReport{
+		From: from, To: from.AddDate(0, 0, 7), GeneratedAt: from.AddDate(0, 0, 7),
+		TotalJobs: 1, CardHours: 12.5, IdleCardHours: 12.5, IssueSeverities: map[string]int{},
+		Frameworks:  []FrameworkUsage{{Framework: "pytorch", Jobs: 1, CardHours: 12.5}},
+		TopIdleJobs: []ReportJob{job},
+		Jobs:        []ReportJob{job},
+	}
+}
+
+func TestRenderReport(t *testing.T) {
+	report := sampleReport()
+
+	md, err := RenderReport(report, ReportFormatMarkdown)
+	assert.NoError(t, err)
+	assert.Contains(t, string(md), "| 总卡时 | 12.5 |")
+	assert.Contains(t, string(md), `train\|llama`)
+
+	html, err := RenderReport(report, ReportFormatHTML)
+	assert.NoError(t, err)
+	assert.Contains(t, string(html), "<td>train|llama</td>")
+
+	csvData, err := RenderReport(report, ReportFormatCSV)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(csvData), "\uFEFF")), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], "job-1,train|llama,,pytorch,running,4,12.50,,idle,"))
+
+	// 以公式字符开头的文本加单引号
+	report.Jobs[0].JobName = "=cmd|' /C calc'!A0"
+	csvData, err = RenderReport(report, ReportFormatCSV)
+	assert.NoError(t, err)
+	assert.Contains(t, string(csvData), `job-1,'=cmd|' /C calc'!A0,`)
+
+	_, err = RenderReport(report, "pdf")
+	assert.Error(t, err)
+}
+
+func TestReportService_Deliver(t *testing.T) {
+	var received reportWebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	svc := NewReportService(new(MockJobServiceForLLM), nil, new(MockReportRepository), config.ReportsConfig{})
+	sched := model.ReportSchedule{Name: "weekly ops", Formats: []string{ReportFormatMarkdown, ReportFormatCSV},
+		Dir: dir, Webhook: server.URL + "/hook?token=secret"}
+	run := &model.ReportRun{}
+
+	err := svc.deliver(context.Background(), sched, sampleReport(), run)
+	assert.NoError(t, err)
+	assert.True(t, run.WebhookDelivered)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "weekly_ops_20260302_20260309.md"),
+		filepath.Join(dir, "weekly_ops_20260302_20260309.csv"),
+	}, run.Files)
+	for _, f := range run.Files {
+		_, err := os.Stat(f)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "weekly ops", received.Schedule)
+	assert.Contains(t, received.Text, "# NPU 使用与 AI 分析周报")
+	assert.Len(t, received.Contents, 2)
+}
+
+func TestReportService_Deliver_WebhookFailureHidesToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	svc := NewReportService(new(MockJobServiceForLLM), nil, new(MockReportRepository), config.ReportsConfig{Dir: dir})
+	sched := model.ReportSchedule{Name: "weekly", Webhook: server.URL + "/hook?token=secret"}
+	run := &model.ReportRun{}
+
+	err := svc.deliver(context.Background(), sched, sampleReport(), run)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 502")
+	assert.NotContains(t, err.Error(), "secret")
+	assert.False(t, run.WebhookDelivered)
+	// 指定了 webhook 时不写入默认目录
+	assert.Empty(t, run.Files)
+}
+
+func TestReportService_Deliver_StoredScheduleHostNotAllowed(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	svc := NewReportService(new(MockJobServiceForLLM), nil, new(MockReportRepository), config.ReportsConfig{
+		WebhookHosts: []string{"hooks.example.com"},
+	})
+	// 收紧 webhook_hosts 之前创建的数据库计划不再投递
+	sched := model.ReportSchedule{Name: "team", Webhook: server.URL + "/hook", Source: "db"}
+	run := &model.ReportRun{}
+
+	err := svc.deliver(context.Background(), sched, sampleReport(), run)
+	assert.ErrorIs(t, err, ErrInvalidReportSchedule)
+	assert.False(t, called)
+	assert.False(t, run.WebhookDelivered)
+}
+
+func TestReportService_DueSchedules(t *testing.T) {
+	svc := NewReportService(new(MockJobServiceForLLM), nil, new(MockReportRepository), config.ReportsConfig{})
+	svc.started = time.Date(2026, 3, 2, 8, 0, 0, 0, time.Local)
+	svc.lastFire["history"] = time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+
+	schedules := []model.ReportSchedule{
+		{Name: "daily", Cron: "0 9 * * *", Enabled: true},
+		{Name: "history", Cron: "0 9 * * 1", Enabled: true},
+		{Name: "disabled", Cron: "0 9 * * *"},
+	}
+	due := svc.dueSchedules(schedules, time.Date(2026, 3, 2, 8, 59, 0, 0, time.Local))
+	assert.Empty(t, due)
+
+	now := time.Date(2026, 3, 2, 9, 0, 30, 0, time.Local)
+	due = svc.dueSchedules(schedules, now)
+	names := make([]string, 0, len(due))
+	for _, s := range due {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"daily", "history"}, names)
+
+	// 同一时刻不会重复触发
+	assert.Empty(t, svc.dueSchedules(schedules, now.Add(time.Minute)))
+}
+
+func TestReportService_ListSchedules_MasksWebhook(t *testing.T) {
+	mockRepo := new(MockReportRepository)
+	svc := NewReportService(new(MockJobServiceForLLM), nil, mockRepo, config.ReportsConfig{
+		Schedules: []config.ReportScheduleConfig{{Name: "ops", Cron: "0 9 * * 1", Webhook: "https://hooks.example.com/send?key=abc"}},
+	})
+	mockRepo.On("ListSchedules").Return([]model.ReportSchedule{{ID: 3, Name: "team", Cron: "0 9 * * *", Enabled: true}}, nil)
+
+	schedules, err := svc.ListSchedules()
+	assert.NoError(t, err)
+	if assert.Len(t, schedules, 2) {
+		assert.Equal(t, "config", schedules[0].Source)
+		assert.True(t, schedules[0].Enabled)
+		assert.Equal(t, "https://hooks.example.com/***", schedules[0].Webhook)
+		assert.Equal(t, "db", schedules[1].Source)
+	}
+
+	all, err := svc.allSchedules()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/send?key=abc", all[0].Webhook)
+}
+
+func TestReportService_CreateSchedule(t *testing.T) {
+	mockRepo := new(MockReportRepository)
+	svc := NewReportService(new(MockJobServiceForLLM), nil, mockRepo, config.ReportsConfig{
+		Schedules:    []config.ReportScheduleConfig{{Name: "ops", Cron: "0 9 * * 1"}},
+		WebhookHosts: []string{"hooks.example.com"},
+	})
+
+	_, err := svc.CreateSchedule(ReportScheduleInput{Name: "ops", Cron: "0 9 * * 1"}, 1)
+	assert.ErrorIs(t, err, ErrReportScheduleExists)
+
+	_, err = svc.CreateSchedule(ReportScheduleInput{Name: "team", Cron: "every monday"}, 1)
+	assert.ErrorIs(t, err, ErrInvalidReportSchedule)
+
+	// 只能投递到 webhook_hosts 中的主机
+	internal := "http://10.0.0.1:8080/admin"
+	_, err = svc.CreateSchedule(ReportScheduleInput{Name: "team", Cron: "0 9 * * *", Webhook: &internal}, 1)
+	assert.ErrorIs(t, err, ErrInvalidReportSchedule)
+	assert.Contains(t, err.Error(), "10.0.0.1:8080")
+
+	webhook := "https://hooks.example.com/send?key=abc"
+	mockRepo.On("FindScheduleByName", "team").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("CreateSchedule", mock.MatchedBy(func(s *model.ReportSchedule) bool {
+		return s.Name == "team" && s.Webhook == webhook && s.Enabled && s.CreatedBy == 1
+	})).Return(nil)
+
+	sched, err := svc.CreateSchedule(ReportScheduleInput{Name: " team ", Cron: "0 9 * * *", Webhook: &webhook}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "db", sched.Source)
+	assert.Equal(t, "https://hooks.example.com/***", sched.Webhook)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReportService_UpdateSchedule_KeepsWebhook(t *testing.T) {
+	mockRepo := new(MockReportRepository)
+	svc := NewReportService(new(MockJobServiceForLLM), nil, mockRepo, config.ReportsConfig{})
+
+	_, err := svc.UpdateSchedule(0, ReportScheduleInput{Name: "ops", Cron: "0 9 * * 1"})
+	assert.ErrorIs(t, err, ErrReportScheduleReadOnly)
+
+	mockRepo.On("FindScheduleByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	_, err = svc.UpdateSchedule(9, ReportScheduleInput{Name: "ops", Cron: "0 9 * * 1"})
+	assert.ErrorIs(t, err, ErrReportScheduleNotFound)
+
+	stored := &model.ReportSchedule{ID: 3, Name: "team", Cron: "0 9 * * *", Webhook: "https://hooks.example.com/send?key=abc", Enabled: true}
+	mockRepo.On("FindScheduleByID", uint(3)).Return(stored, nil)
+	mockRepo.On("FindScheduleByName", "team").Return(stored, nil)
+	mockRepo.On("UpdateSchedule", mock.MatchedBy(func(s *model.ReportSchedule) bool {
+		return s.Cron == "30 8 * * 1" && s.Webhook == "https://hooks.example.com/send?key=abc"
+	})).Return(nil)
+
+	_, err = svc.UpdateSchedule(3, ReportScheduleInput{Name: "team", Cron: "30 8 * * 1"})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReportService_RunSchedule(t *testing.T) {
+	mockJobService := new(MockJobServiceForLLM)
+	mockRepo := new(MockReportRepository)
+	dir := t.TempDir()
+	svc := NewReportService(mockJobService, nil, mockRepo, config.ReportsConfig{
+		Dir:       dir,
+		Schedules: []config.ReportScheduleConfig{{Name: "ops", Cron: "0 9 * * 1", Formats: []string{ReportFormatCSV}}},
+	})
+	mockRepo.On("ListSchedules").Return([]model.ReportSchedule{}, nil)
+	mockJobService.On("GetGroupedJobsByCursor", mock.Anything, "startTime", "desc", "", reportChunkSize).
+		Return([]JobGroup{}, int64(0), "", nil)
+	mockRepo.On("CreateRun", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.ReportRun).ID = 5
+	}).Return(nil)
+	finished := make(chan model.ReportRun, 1)
+	mockRepo.On("UpdateRun", mock.Anything).Run(func(args mock.Arguments) {
+		finished <- *args.Get(0).(*model.ReportRun)
+	}).Return(nil)
+
+	_, err := svc.RunSchedule("missing")
+	assert.ErrorIs(t, err, ErrReportScheduleNotFound)
+
+	run, err := svc.RunSchedule("ops")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), run.ID)
+	assert.Equal(t, reportTriggerManual, run.Trigger)
+	assert.Equal(t, reportRunRunning, run.Status)
+
+	select {
+	case done := <-finished:
+		assert.Equal(t, reportRunSucceeded, done.Status)
+		if assert.Len(t, done.Files, 1) {
+			assert.Equal(t, dir, filepath.Dir(done.Files[0]))
+			assert.True(t, strings.HasSuffix(done.Files[0], ".csv"))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("report run did not finish")
+	}
+}
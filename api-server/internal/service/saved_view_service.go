@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrViewNotFound 视图不存在或对当前用户不可见
+	ErrViewNotFound = errors.New("saved view not found")
+	// ErrViewForbidden 仅视图所有者可修改或删除
+	ErrViewForbidden = errors.New("only the owner can modify this saved view")
+	// ErrViewNameExists 同一用户下视图名称重复
+	ErrViewNameExists = errors.New("saved view name already exists")
+	// ErrViewNameRequired 视图名称为空
+	ErrViewNameRequired = errors.New("saved view name is required")
+)
+
+// SavedViewInput 创建/更新视图的内容，参数合法性由调用方（handler）校验
+type SavedViewInput struct {
+	Name    string              `json:"name"`
+	Shared  bool                `json:"shared"`
+	Params  map[string][]string `json:"params"`
+	Columns []string            `json:"columns"`
+}
+
+// SavedViewService 保存视图服务
+type SavedViewService struct {
+	repo repository.SavedViewRepositoryInterface
+}
+
+// NewSavedViewService 创建保存视图服务
+func NewSavedViewService(repo repository.SavedViewRepositoryInterface) *SavedViewService {
+	return &SavedViewService{repo: repo}
+}
+
+// List 列出用户可见的视图（自己的与共享的），userID 为 0 表示匿名用户
+func (s *SavedViewService) List(userID uint) ([]model.SavedView, error) {
+	views, err := s.repo.FindVisible(userID)
+	if err != nil {
+		return nil, err
+	}
+	if views == nil {
+		views = []model.SavedView{}
+	}
+	return views, nil
+}
+
+// Get 获取单个视图；他人的非共享视图按不存在处理，避免暴露视图 ID
+func (s *SavedViewService) Get(id uint, userID uint) (*model.SavedView, error) {
+	view, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if !view.Shared && (userID == 0 || view.OwnerID != userID) {
+		return nil, ErrViewNotFound
+	}
+	return view, nil
+}
+
+// Create 创建视图，所有者为当前用户
+func (s *SavedViewService) Create(ownerID uint, input SavedViewInput) (*model.SavedView, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrViewNameRequired
+	}
+	if _, err := s.repo.FindByOwnerAndName(ownerID, name); err == nil {
+		return nil, ErrViewNameExists
+	}
+	view := &model.SavedView{
+		Name:    name,
+		OwnerID: ownerID,
+		Shared:  input.Shared,
+		Params:  input.Params,
+		Columns: input.Columns,
+	}
+	if err := s.repo.Create(view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// Update 全量更新视图，仅所有者可操作
+func (s *SavedViewService) Update(id uint, userID uint, input SavedViewInput) (*model.SavedView, error) {
+	view, err := s.owned(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrViewNameRequired
+	}
+	if name != view.Name {
+		if _, err := s.repo.FindByOwnerAndName(userID, name); err == nil {
+			return nil, ErrViewNameExists
+		}
+	}
+	view.Name = name
+	view.Shared = input.Shared
+	view.Params = input.Params
+	view.Columns = input.Columns
+	if err := s.repo.Update(view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// Delete 删除视图，仅所有者可操作
+func (s *SavedViewService) Delete(id uint, userID uint) error {
+	if _, err := s.owned(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// owned 查找视图并校验所有权：不可见的视图返回不存在，可见但非本人的共享视图返回无权限
+func (s *SavedViewService) owned(id uint, userID uint) (*model.SavedView, error) {
+	view, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if view.OwnerID != userID {
+		return nil, ErrViewForbidden
+	}
+	return view, nil
+}
+
+func (s *SavedViewService) find(id uint) (*model.SavedView, error) {
+	view, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrViewNotFound
+	}
+	return view, err
+}
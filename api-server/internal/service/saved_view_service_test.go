@@ -0,0 +1,123 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/model"
+	"gorm.io/gorm"
+)
+
+// MockSavedViewRepository is a mock implementation of SavedViewRepositoryInterface
+type MockSavedViewRepository struct {
+	mock.Mock
+}
+
+func (m *MockSavedViewRepository) FindByID(id uint) (*model.SavedView, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewRepository) FindByOwnerAndName(ownerID uint, name string) (*model.SavedView, error) {
+	args := m.Called(ownerID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewRepository) FindVisible(userID uint) ([]model.SavedView, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewRepository) Create(view *model.SavedView) error {
+	return m.Called(view).Error(0)
+}
+
+func (m *MockSavedViewRepository) Update(view *model.SavedView) error {
+	return m.Called(view).Error(0)
+}
+
+func (m *MockSavedViewRepository) Delete(id uint) error {
+	return m.Called(id).Error(0)
+}
+
+func TestSavedViewService_Create(t *testing.T) {
+	repo := new(MockSavedViewRepository)
+	svc := NewSavedViewService(repo)
+
+	repo.On("FindByOwnerAndName", uint(1), "mine").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("Create", mock.MatchedBy(func(v *model.SavedView) bool {
+		return v.OwnerID == 1 && v.Name == "mine" && v.Params["status"][0] == "running"
+	})).Return(nil)
+
+	view, err := svc.Create(1, SavedViewInput{Name: "  mine ", Params: map[string][]string{"status": {"running"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "mine", view.Name)
+	repo.AssertExpectations(t)
+}
+
+func TestSavedViewService_Create_Errors(t *testing.T) {
+	repo := new(MockSavedViewRepository)
+	svc := NewSavedViewService(repo)
+
+	_, err := svc.Create(1, SavedViewInput{Name: " "})
+	assert.ErrorIs(t, err, ErrViewNameRequired)
+
+	repo.On("FindByOwnerAndName", uint(1), "dup").Return(&model.SavedView{ID: 2}, nil)
+	_, err = svc.Create(1, SavedViewInput{Name: "dup"})
+	assert.ErrorIs(t, err, ErrViewNameExists)
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestSavedViewService_GetVisibility(t *testing.T) {
+	repo := new(MockSavedViewRepository)
+	svc := NewSavedViewService(repo)
+
+	repo.On("FindByID", uint(1)).Return(&model.SavedView{ID: 1, OwnerID: 1}, nil)
+	repo.On("FindByID", uint(2)).Return(&model.SavedView{ID: 2, OwnerID: 1, Shared: true}, nil)
+	repo.On("FindByID", uint(3)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := svc.Get(1, 1)
+	assert.NoError(t, err)
+	// 他人的私有视图与匿名访问私有视图均按不存在处理
+	_, err = svc.Get(1, 2)
+	assert.ErrorIs(t, err, ErrViewNotFound)
+	_, err = svc.Get(1, 0)
+	assert.ErrorIs(t, err, ErrViewNotFound)
+	_, err = svc.Get(2, 0)
+	assert.NoError(t, err)
+	_, err = svc.Get(3, 1)
+	assert.ErrorIs(t, err, ErrViewNotFound)
+}
+
+func TestSavedViewService_UpdateAndDelete_OwnerOnly(t *testing.T) {
+	repo := new(MockSavedViewRepository)
+	svc := NewSavedViewService(repo)
+
+	repo.On("FindByID", uint(2)).Return(&model.SavedView{ID: 2, OwnerID: 1, Name: "shared", Shared: true}, nil)
+
+	_, err := svc.Update(2, 5, SavedViewInput{Name: "hijack"})
+	assert.ErrorIs(t, err, ErrViewForbidden)
+	assert.ErrorIs(t, svc.Delete(2, 5), ErrViewForbidden)
+
+	repo.On("FindByOwnerAndName", uint(1), "renamed").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("Update", mock.MatchedBy(func(v *model.SavedView) bool {
+		return v.Name == "renamed" && !v.Shared && len(v.Columns) == 1
+	})).Return(nil)
+	view, err := svc.Update(2, 1, SavedViewInput{Name: "renamed", Columns: []string{"jobId"}})
+	assert.NoError(t, err)
+	assert.False(t, view.Shared)
+
+	repo.On("Delete", uint(2)).Return(nil)
+	assert.NoError(t, svc.Delete(2, 1))
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// 全局搜索资源类型
+const (
+	SearchTypeAll      = "all"
+	SearchTypeJob      = "job"
+	SearchTypeNode     = "node"
+	SearchTypeAnalysis = "analysis"
+)
+
+// ErrInvalidSearchType 不支持的搜索资源类型
+var ErrInvalidSearchType = errors.New("type must be one of all, job, node, analysis")
+
+// AnalysisSearchHit 命中的 AI 分析结果摘要
+type AnalysisSearchHit struct {
+	JobID     string    `json:"jobId"`
+	Summary   string    `json:"summary"`
+	Category  string    `json:"category,omitempty"`
+	ModelName *string   `json:"modelName,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SearchResult 全局搜索结果，total 为各类命中数之和
+type SearchResult struct {
+	Jobs     []model.Job         `json:"jobs"`
+	Nodes    []model.Node        `json:"nodes"`
+	Analyses []AnalysisSearchHit `json:"analyses"`
+	Total    int                 `json:"total"`
+}
+
+// SearchService 全局搜索服务：作业（作业名、命令行、工作目录、进程名）、节点（ID、主机名、IP、NPU 型号）、AI 分析结果
+type SearchService struct {
+	jobRepo      repository.JobRepositoryInterface
+	nodeRepo     repository.NodeRepositoryInterface
+	analysisRepo repository.JobAnalysisRepositoryInterface
+}
+
+// NewSearchService 创建全局搜索服务
+func NewSearchService(jobRepo repository.JobRepositoryInterface, nodeRepo repository.NodeRepositoryInterface, analysisRepo repository.JobAnalysisRepositoryInterface) *SearchService {
+	return &SearchService{jobRepo: jobRepo, nodeRepo: nodeRepo, analysisRepo: analysisRepo}
+}
+
+// Search 按资源类型搜索，每类最多返回 limit 条；作业与分析结果只返回可见范围 scope 内的作业
+func (s *SearchService) Search(query, searchType string, limit int, scope ProjectFilter) (*SearchResult, error) {
+	if searchType == "" {
+		searchType = SearchTypeAll
+	}
+	switch searchType {
+	case SearchTypeAll, SearchTypeJob, SearchTypeNode, SearchTypeAnalysis:
+	default:
+		return nil, ErrInvalidSearchType
+	}
+
+	result := &SearchResult{Jobs: []model.Job{}, Nodes: []model.Node{}, Analyses: []AnalysisSearchHit{}}
+	if searchType == SearchTypeAll || searchType == SearchTypeJob {
+		jobs, err := s.jobRepo.Find(JobFilter{Search: query, Projects: scope}, "", "", limit, 0)
+		if err != nil {
+			return nil, fmt.Errorf("search jobs: %w", err)
+		}
+		result.Jobs = append(result.Jobs, jobs...)
+	}
+	if searchType == SearchTypeAll || searchType == SearchTypeNode {
+		nodes, err := s.nodeRepo.Search(query, limit)
+		if err != nil {
+			return nil, fmt.Errorf("search nodes: %w", err)
+		}
+		result.Nodes = append(result.Nodes, nodes...)
+	}
+	if searchType == SearchTypeAll || searchType == SearchTypeAnalysis {
+		analyses, err := s.analysisRepo.Search(query, limit, scope)
+		if err != nil {
+			return nil, fmt.Errorf("search analyses: %w", err)
+		}
+		for _, a := range analyses {
+			result.Analyses = append(result.Analyses, analysisSearchHit(a))
+		}
+	}
+	result.Total = len(result.Jobs) + len(result.Nodes) + len(result.Analyses)
+	return result, nil
+}
+
+// analysisSearchHit 从分析结果 JSON 中提取摘要字段；解析失败时只返回作业ID与时间
+func analysisSearchHit(a model.JobAnalysis) AnalysisSearchHit {
+	hit := AnalysisSearchHit{JobID: a.JobID, UpdatedAt: a.UpdatedAt}
+	var resp JobAnalysisResponse
+	if err := json.Unmarshal([]byte(a.Result), &resp); err != nil {
+		return hit
+	}
+	hit.Summary = resp.Summary
+	hit.Category = resp.TaskType.Category
+	if resp.ModelInfo != nil {
+		hit.ModelName = resp.ModelInfo.ModelName
+	}
+	return hit
+}
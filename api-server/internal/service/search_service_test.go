@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/task-monitor/api-server/internal/model"
+)
+
+func TestSearchService_Search_All(t *testing.T) {
+	mockJobRepo := new(MockJobRepository)
+	mockNodeRepo := new(MockNodeRepository)
+	mockAnalysisRepo := new(MockJobAnalysisRepository)
+	svc := NewSearchService(mockJobRepo, mockNodeRepo, mockAnalysisRepo)
+
+	jobName := "train_llama.py"
+	updatedAt := time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC)
+	mockJobRepo.On("Find", JobFilter{Search: "llama"}, "", "", 10, 0).
+		Return([]model.Job{{JobID: "job-001", JobName: &jobName}}, nil)
+	mockNodeRepo.On("Search", "llama", 10).Return([]model.Node{}, nil)
+	mockAnalysisRepo.On("Search", "llama", 10, ProjectFilter{}).Return([]model.JobAnalysis{
+		{JobID: "job-002", Result: `{"summary":"Llama 预训练","taskType":{"category":"training"},"modelInfo":{"modelName":"llama-7b"}}`, UpdatedAt: updatedAt},
+		{JobID: "job-003", Result: "not json", UpdatedAt: updatedAt},
+	}, nil)
+
+	result, err := svc.Search("llama", "", 10, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	require.Len(t, result.Jobs, 1)
+	assert.Equal(t, "job-001", result.Jobs[0].JobID)
+	assert.Empty(t, result.Nodes)
+	require.Len(t, result.Analyses, 2)
+	assert.Equal(t, "Llama 预训练", result.Analyses[0].Summary)
+	assert.Equal(t, "training", result.Analyses[0].Category)
+	assert.Equal(t, "llama-7b", *result.Analyses[0].ModelName)
+	assert.Equal(t, "job-003", result.Analyses[1].JobID)
+	assert.Empty(t, result.Analyses[1].Summary)
+}
+
+func TestSearchService_Search_SingleTypeAndInvalidType(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	svc := NewSearchService(new(MockJobRepository), mockNodeRepo, new(MockJobAnalysisRepository))
+
+	mockNodeRepo.On("Search", "10.0.0", 5).Return([]model.Node{{NodeID: "node-001"}}, nil)
+
+	result, err := svc.Search("10.0.0", SearchTypeNode, 5, ProjectFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.Empty(t, result.Jobs)
+	assert.Empty(t, result.Analyses)
+
+	_, err = svc.Search("x", "user", 5, ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidSearchType)
+}
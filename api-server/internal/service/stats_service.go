@@ -0,0 +1,551 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+// 趋势指标
+const (
+	TrendNPUUsage  = "npu_usage"  // 平均 AICore 利用率（%）
+	TrendHBMUsage  = "hbm_usage"  // 平均 HBM 占用率（%）
+	TrendPower     = "power"      // 集群总功耗（W）
+	TrendNodeCount = "node_count" // 上报 NPU 指标的节点数
+	TrendUsedCards = "used_cards" // 被作业占用的 NPU 卡数
+	TrendJobCount  = "job_count"  // 占用 NPU 的作业数（按进程组计）
+)
+
+// TrendMetrics 支持的趋势指标
+var TrendMetrics = []string{TrendNPUUsage, TrendHBMUsage, TrendPower, TrendNodeCount, TrendUsedCards, TrendJobCount}
+
+// TrendIntervals 支持的趋势数据间隔
+var TrendIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// MaxTrendPoints 单次趋势查询的最大数据点数
+const MaxTrendPoints = 1440
+
+// ErrInvalidTrendQuery 趋势查询参数不合法
+var ErrInvalidTrendQuery = errors.New("invalid trend query")
+
+// JobGroupCounter 提供 scope 范围内按状态/类型/框架聚合的作业组数量
+type JobGroupCounter interface {
+	GetJobGroupCounts(scope ProjectFilter) ([]JobGroupCount, error)
+}
+
+// NPUUsageStats NPU 卡与芯片的占用情况和平均负载。卡按 node_id + npu_id 计，芯片按 node_id + npu_id + bus_id 计；
+// 只统计在过期时间内上报过指标的芯片，占用取自运行中的 npu_processes
+type NPUUsageStats struct {
+	TotalCards     int      `json:"totalCards"`
+	UsedCards      int      `json:"usedCards"`
+	IdleCards      int      `json:"idleCards"`
+	TotalChips     int      `json:"totalChips"`
+	UsedChips      int      `json:"usedChips"`
+	IdleChips      int      `json:"idleChips"`
+	HealthyChips   int      `json:"healthyChips"`
+	AvgAICoreUsage *float64 `json:"avgAicoreUsage"` // 平均 AICore 利用率（%）
+	AvgHBMUsage    *float64 `json:"avgHbmUsage"`    // HBM 已用 / 总量（%）
+	HBMUsedMB      float64  `json:"hbmUsedMb"`
+	HBMTotalMB     float64  `json:"hbmTotalMb"`
+	TotalPowerW    float64  `json:"totalPowerW"`
+	AvgPowerW      *float64 `json:"avgPowerW"`
+	AvgTempC       *float64 `json:"avgTempC"`
+}
+
+// ClusterStats 集群整体统计
+type ClusterStats struct {
+	TotalNodes     int `json:"totalNodes"`
+	ActiveNodes    int `json:"activeNodes"`
+	InactiveNodes  int `json:"inactiveNodes"`
+	ReportingNodes int `json:"reportingNodes"` // 过期时间内上报过 NPU 指标的节点
+	NPUUsageStats
+	TotalJobs     int64 `json:"totalJobs"`
+	RunningJobs   int64 `json:"runningJobs"`
+	CompletedJobs int64 `json:"completedJobs"`
+	FailedJobs    int64 `json:"failedJobs"`
+	// JobTypeDistribution / FrameworkDistribution 运行中作业组按类型、框架计数
+	JobTypeDistribution   map[string]int64 `json:"jobTypeDistribution"`
+	FrameworkDistribution map[string]int64 `json:"frameworkDistribution"`
+	Timestamp             time.Time        `json:"timestamp"`
+}
+
+// NodeStats 单个节点的统计
+type NodeStats struct {
+	NodeID   string `json:"nodeId"`
+	Hostname string `json:"hostname"`
+	Status   string `json:"status"`
+	NPUModel string `json:"npuModel"`
+	NPUCount *int   `json:"npuCount"` // 节点登记的卡数
+	NPUUsageStats
+	RunningJobs  int        `json:"runningJobs"` // 占用 NPU 的运行中作业（按进程组计）
+	LastReportAt *time.Time `json:"lastReportAt"`
+}
+
+// TrendPoint 趋势数据点，桶内无数据时 Value 为 nil
+type TrendPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     *float64  `json:"value"`
+}
+
+// Trend 趋势数据
+type Trend struct {
+	Metric     string       `json:"metric"`
+	Interval   string       `json:"interval"`
+	StartTime  time.Time    `json:"startTime"`
+	EndTime    time.Time    `json:"endTime"`
+	DataPoints []TrendPoint `json:"dataPoints"`
+}
+
+// StatsService 集群利用率统计，数据来自 npu_metrics、npu_processes 与作业组统计。
+// 各查询的 scope 非空时只统计范围内作业：节点、卡与芯片限于这些作业正在占用的部分，作业数按范围内分组计
+type StatsService struct {
+	nodeRepo    repository.NodeRepositoryInterface
+	metricsRepo repository.MetricsRepositoryInterface
+	jobCounter  JobGroupCounter
+	now         func() time.Time
+
+	mu         sync.RWMutex
+	staleAfter time.Duration
+}
+
+// NewStatsService 创建统计服务；staleAfter 内未上报指标的芯片不计入统计
+func NewStatsService(nodeRepo repository.NodeRepositoryInterface, metricsRepo repository.MetricsRepositoryInterface,
+	jobCounter JobGroupCounter, staleAfter time.Duration) *StatsService {
+	return &StatsService{
+		nodeRepo:    nodeRepo,
+		metricsRepo: metricsRepo,
+		jobCounter:  jobCounter,
+		now:         time.Now,
+		staleAfter:  staleAfter,
+	}
+}
+
+// SetStaleAfter 更新芯片过期时间，支持热加载
+func (s *StatsService) SetStaleAfter(d time.Duration) {
+	s.mu.Lock()
+	s.staleAfter = d
+	s.mu.Unlock()
+}
+
+// npuSnapshot 一次读取的最新芯片指标与运行中的 NPU 进程
+type npuSnapshot struct {
+	chips     []model.NPUMetric
+	processes []repository.RunningNPUProcess
+}
+
+// loadNPUSnapshot 读取芯片指标与运行中进程；scope 非空时进程限于范围内作业，芯片限于这些进程占用的卡
+func (s *StatsService) loadNPUSnapshot(scope ProjectFilter) (*npuSnapshot, error) {
+	s.mu.RLock()
+	staleAfter := s.staleAfter
+	s.mu.RUnlock()
+
+	chips, err := s.metricsRepo.FindLatestNPUMetricsSince(s.now().Add(-staleAfter))
+	if err != nil {
+		return nil, fmt.Errorf("query npu metrics: %w", err)
+	}
+	processes, err := s.metricsRepo.FindRunningNPUProcesses(scope)
+	if err != nil {
+		return nil, fmt.Errorf("query npu processes: %w", err)
+	}
+	if !scope.IsEmpty() {
+		used := make(map[npuCardKey]bool, len(processes))
+		for _, p := range processes {
+			used[npuCardKey{p.NodeID, p.NPUID}] = true
+		}
+		visible := make([]model.NPUMetric, 0, len(chips))
+		for _, c := range chips {
+			if c.NPUID != nil && used[npuCardKey{stringOrEmpty(c.NodeID), *c.NPUID}] {
+				visible = append(visible, c)
+			}
+		}
+		chips = visible
+	}
+	return &npuSnapshot{chips: chips, processes: processes}, nil
+}
+
+// nodesInScope scope 非空时只保留有范围内作业进程的节点
+func nodesInScope(nodes []model.Node, snap *npuSnapshot, scope ProjectFilter) []model.Node {
+	if scope.IsEmpty() {
+		return nodes
+	}
+	active := make(map[string]bool)
+	for _, p := range snap.processes {
+		active[p.NodeID] = true
+	}
+	filtered := make([]model.Node, 0, len(active))
+	for _, n := range nodes {
+		if active[n.NodeID] {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
+// GetClusterStats 获取集群整体统计；scope 非空时只统计范围内作业
+func (s *StatsService) GetClusterStats(scope ProjectFilter) (*ClusterStats, error) {
+	nodes, err := s.nodeRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("query nodes: %w", err)
+	}
+	snap, err := s.loadNPUSnapshot(scope)
+	if err != nil {
+		return nil, err
+	}
+	nodes = nodesInScope(nodes, snap, scope)
+	counts, err := s.jobCounter.GetJobGroupCounts(scope)
+	if err != nil {
+		return nil, fmt.Errorf("count job groups: %w", err)
+	}
+
+	stats := &ClusterStats{
+		TotalNodes:            len(nodes),
+		JobTypeDistribution:   map[string]int64{},
+		FrameworkDistribution: map[string]int64{},
+		Timestamp:             s.now(),
+	}
+	for _, n := range nodes {
+		if stringOrEmpty(n.Status) == "active" {
+			stats.ActiveNodes++
+		} else {
+			stats.InactiveNodes++
+		}
+	}
+	reporting := make(map[string]bool)
+	for _, c := range snap.chips {
+		reporting[stringOrEmpty(c.NodeID)] = true
+	}
+	stats.ReportingNodes = len(reporting)
+	stats.NPUUsageStats = summarizeNPUUsage(snap.chips, snap.processes)
+
+	for _, c := range counts {
+		stats.TotalJobs += c.Count
+		switch c.Status {
+		case "running":
+			stats.RunningJobs += c.Count
+			stats.JobTypeDistribution[c.JobType] += c.Count
+			stats.FrameworkDistribution[c.Framework] += c.Count
+		case "completed":
+			stats.CompletedJobs += c.Count
+		case "failed", "lost":
+			stats.FailedJobs += c.Count
+		}
+	}
+	return stats, nil
+}
+
+// GetNodeStats 获取各节点的统计，包含只上报了指标但未登记的节点，按节点ID排序；scope 非空时只统计范围内作业
+func (s *StatsService) GetNodeStats(scope ProjectFilter) ([]NodeStats, error) {
+	nodes, err := s.nodeRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("query nodes: %w", err)
+	}
+	snap, err := s.loadNPUSnapshot(scope)
+	if err != nil {
+		return nil, err
+	}
+	nodes = nodesInScope(nodes, snap, scope)
+
+	chipsByNode := make(map[string][]model.NPUMetric)
+	for _, c := range snap.chips {
+		id := stringOrEmpty(c.NodeID)
+		chipsByNode[id] = append(chipsByNode[id], c)
+	}
+	procsByNode := make(map[string][]repository.RunningNPUProcess)
+	for _, p := range snap.processes {
+		procsByNode[p.NodeID] = append(procsByNode[p.NodeID], p)
+	}
+
+	result := make([]NodeStats, 0, len(nodes))
+	seen := make(map[string]bool, len(nodes))
+	add := func(ns NodeStats) {
+		ns.NPUUsageStats = summarizeNPUUsage(chipsByNode[ns.NodeID], procsByNode[ns.NodeID])
+		ns.RunningJobs = countProcessGroups(procsByNode[ns.NodeID])
+		for _, c := range chipsByNode[ns.NodeID] {
+			if ns.LastReportAt == nil || c.Timestamp.After(*ns.LastReportAt) {
+				ts := c.Timestamp
+				ns.LastReportAt = &ts
+			}
+		}
+		result = append(result, ns)
+	}
+	for _, n := range nodes {
+		seen[n.NodeID] = true
+		add(NodeStats{
+			NodeID:   n.NodeID,
+			Hostname: stringOrEmpty(n.Hostname),
+			Status:   stringOrEmpty(n.Status),
+			NPUModel: stringOrEmpty(n.NPUModel),
+			NPUCount: n.NPUCount,
+		})
+	}
+	for id := range chipsByNode {
+		if !seen[id] {
+			add(NodeStats{NodeID: id})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NodeID < result[j].NodeID })
+	return result, nil
+}
+
+// countProcessGroups 统计运行中 NPU 进程所属的进程组数，无对应作业的进程单独计数
+func countProcessGroups(processes []repository.RunningNPUProcess) int {
+	groups := make(map[int64]bool)
+	for _, p := range processes {
+		if p.PGID != nil {
+			groups[*p.PGID] = true
+		} else {
+			groups[-p.PID] = true
+		}
+	}
+	return len(groups)
+}
+
+type npuCardKey struct {
+	nodeID string
+	npuID  int
+}
+
+// summarizeNPUUsage 汇总芯片指标与占用。芯片的 chip_id 对应同卡芯片按 bus_id 排序后的序号（与作业详情一致）；
+// 进程未记录 chip_id 或 chip_id 无法对应时视为整卡占用。没有指标的卡不计入
+func summarizeNPUUsage(chips []model.NPUMetric, processes []repository.RunningNPUProcess) NPUUsageStats {
+	var stats NPUUsageStats
+
+	cards := make(map[npuCardKey][]model.NPUMetric)
+	for _, c := range chips {
+		if c.NPUID == nil {
+			continue
+		}
+		key := npuCardKey{stringOrEmpty(c.NodeID), *c.NPUID}
+		cards[key] = append(cards[key], c)
+	}
+	usedChips := make(map[npuCardKey]map[int]bool)
+	wholeCard := make(map[npuCardKey]bool)
+	for _, p := range processes {
+		key := npuCardKey{p.NodeID, p.NPUID}
+		if p.ChipID == nil {
+			wholeCard[key] = true
+			continue
+		}
+		if usedChips[key] == nil {
+			usedChips[key] = make(map[int]bool)
+		}
+		usedChips[key][*p.ChipID] = true
+	}
+
+	var aicoreSum, powerSum, tempSum float64
+	var aicoreN, powerN, tempN int
+	for key, cardChips := range cards {
+		sort.Slice(cardChips, func(i, j int) bool {
+			return stringOrEmpty(cardChips[i].BusID) < stringOrEmpty(cardChips[j].BusID)
+		})
+		stats.TotalCards++
+		stats.TotalChips += len(cardChips)
+
+		used := 0
+		if wholeCard[key] {
+			used = len(cardChips)
+		} else if ids := usedChips[key]; len(ids) > 0 {
+			for idx := range cardChips {
+				if ids[idx] {
+					used++
+				}
+			}
+			if used == 0 {
+				used = len(cardChips)
+			}
+		}
+		if used > 0 {
+			stats.UsedCards++
+			stats.UsedChips += used
+		}
+
+		for _, c := range cardChips {
+			if stringOrEmpty(c.Health) == "OK" {
+				stats.HealthyChips++
+			}
+			if c.AICoreUsagePercent != nil {
+				aicoreSum += *c.AICoreUsagePercent
+				aicoreN++
+			}
+			if c.HBMUsageMB != nil && c.HBMTotalMB != nil && *c.HBMTotalMB > 0 {
+				stats.HBMUsedMB += *c.HBMUsageMB
+				stats.HBMTotalMB += *c.HBMTotalMB
+			}
+			if c.PowerW != nil {
+				powerSum += *c.PowerW
+				powerN++
+			}
+			if c.TempC != nil {
+				tempSum += *c.TempC
+				tempN++
+			}
+		}
+	}
+	stats.IdleCards = stats.TotalCards - stats.UsedCards
+	stats.IdleChips = stats.TotalChips - stats.UsedChips
+	stats.AvgAICoreUsage = average(aicoreSum, aicoreN)
+	if stats.HBMTotalMB > 0 {
+		v := round2(stats.HBMUsedMB / stats.HBMTotalMB * 100)
+		stats.AvgHBMUsage = &v
+	}
+	stats.TotalPowerW = round2(powerSum)
+	stats.AvgPowerW = average(powerSum, powerN)
+	stats.AvgTempC = average(tempSum, tempN)
+	return stats
+}
+
+func average(sum float64, n int) *float64 {
+	if n == 0 {
+		return nil
+	}
+	v := round2(sum / float64(n))
+	return &v
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// GetTrend 按时间桶统计 [from, to) 内的指标。from 向下对齐到间隔整点（1d 按 UTC 零点）；
+// interval 为空时选择数据点不超过 300 的最小间隔。scope 非空时卡数与作业数只计范围内作业，
+// 芯片指标只统计区间内被范围内作业占用过的卡
+func (s *StatsService) GetTrend(metric string, from, to time.Time, interval string, scope ProjectFilter) (*Trend, error) {
+	if !slices.Contains(TrendMetrics, metric) {
+		return nil, fmt.Errorf("%w: unsupported metric %q", ErrInvalidTrendQuery, metric)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: endTime must be after startTime", ErrInvalidTrendQuery)
+	}
+	if interval == "" {
+		interval = autoTrendInterval(to.Sub(from))
+	}
+	step, ok := TrendIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported interval %q", ErrInvalidTrendQuery, interval)
+	}
+	from = from.Truncate(step)
+	n := int((to.Sub(from) + step - 1) / step)
+	if n > MaxTrendPoints {
+		return nil, fmt.Errorf("%w: %d data points exceed the limit of %d, use a larger interval", ErrInvalidTrendQuery, n, MaxTrendPoints)
+	}
+
+	values := make([]*float64, n)
+	switch metric {
+	case TrendUsedCards, TrendJobCount:
+		spans, err := s.metricsRepo.FindNPUProcessSpans(from.UnixMilli(), to.UnixMilli(), scope)
+		if err != nil {
+			return nil, fmt.Errorf("query npu process spans: %w", err)
+		}
+		fillSpanTrend(values, metric, spans, from, to, step, s.now())
+	default:
+		buckets, err := s.metricsRepo.AggregateNPUMetrics(from, to, int64(step/time.Second), scope)
+		if err != nil {
+			return nil, fmt.Errorf("aggregate npu metrics: %w", err)
+		}
+		for _, b := range buckets {
+			if b.Bucket < 0 || b.Bucket >= int64(n) {
+				continue
+			}
+			values[b.Bucket] = metricBucketValue(metric, b)
+		}
+	}
+
+	trend := &Trend{Metric: metric, Interval: interval, StartTime: from, EndTime: to, DataPoints: make([]TrendPoint, n)}
+	for i := range values {
+		trend.DataPoints[i] = TrendPoint{Timestamp: from.Add(time.Duration(i) * step), Value: values[i]}
+	}
+	return trend, nil
+}
+
+func autoTrendInterval(span time.Duration) string {
+	best, bestStep := "1d", 24*time.Hour
+	for name, step := range TrendIntervals {
+		if span/step <= 300 && step < bestStep {
+			best, bestStep = name, step
+		}
+	}
+	return best
+}
+
+func metricBucketValue(metric string, b repository.NPUMetricBucket) *float64 {
+	var v float64
+	switch metric {
+	case TrendNPUUsage:
+		if b.AvgAICore == nil {
+			return nil
+		}
+		v = *b.AvgAICore
+	case TrendHBMUsage:
+		if b.AvgHBMPercent == nil {
+			return nil
+		}
+		v = *b.AvgHBMPercent
+	case TrendPower:
+		// 桶内各芯片平均功耗 × 芯片数，近似集群总功耗
+		if b.AvgPowerW == nil {
+			return nil
+		}
+		v = *b.AvgPowerW * float64(b.Chips)
+	case TrendNodeCount:
+		v = float64(b.Nodes)
+	}
+	v = round2(v)
+	return &v
+}
+
+// fillSpanTrend 统计每个桶内运行过的作业占用的卡数或进程组数，运行区间的推算与定时报表一致
+func fillSpanTrend(values []*float64, metric string, spans []repository.NPUProcessSpan, from, to time.Time, step time.Duration, now time.Time) {
+	type groupKey struct {
+		nodeID string
+		pgid   int64
+	}
+	buckets := make([]map[interface{}]bool, len(values))
+	for _, sp := range spans {
+		job := model.Job{StartTime: sp.StartTime, EndTime: sp.EndTime, Status: sp.Status, UpdatedAt: sp.UpdatedAt}
+		active := jobActiveSpan(job, from, to, now)
+		if active <= 0 {
+			continue
+		}
+		start := time.UnixMilli(*sp.StartTime)
+		if start.Before(from) {
+			start = from
+		}
+		end := start.Add(active)
+
+		var key interface{} = npuCardKey{sp.NodeID, sp.NPUID}
+		if metric == TrendJobCount {
+			// 未记录 pgid 的进程各自算一组，与 countProcessGroups 一致
+			pgid := -sp.PID
+			if sp.PGID != nil {
+				pgid = *sp.PGID
+			}
+			key = groupKey{sp.NodeID, pgid}
+		}
+		first := int(start.Sub(from) / step)
+		last := int((end.Sub(from) - 1) / step)
+		for i := first; i <= last && i < len(values); i++ {
+			if buckets[i] == nil {
+				buckets[i] = make(map[interface{}]bool)
+			}
+			buckets[i][key] = true
+		}
+	}
+	for i, b := range buckets {
+		v := float64(len(b))
+		values[i] = &v
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/task-monitor/api-server/internal/model"
+	"github.com/task-monitor/api-server/internal/repository"
+)
+
+type stubJobGroupCounter []JobGroupCount
+
+func (c stubJobGroupCounter) GetJobGroupCounts(scope ProjectFilter) ([]JobGroupCount, error) {
+	return c, nil
+}
+
+func statsChip(nodeID string, npuID int, busID string, aicore, hbmUsed, hbmTotal, power float64) model.NPUMetric {
+	health := "OK"
+	return model.NPUMetric{
+		NodeID: &nodeID, NPUID: &npuID, BusID: &busID, Health: &health,
+		AICoreUsagePercent: &aicore, HBMUsageMB: &hbmUsed, HBMTotalMB: &hbmTotal, PowerW: &power,
+		Timestamp: time.Unix(1770373780, 0),
+	}
+}
+
+func TestSummarizeNPUUsage(t *testing.T) {
+	chips := []model.NPUMetric{
+		// node-1 卡 0 有两个芯片，进程只占用 chip 1（bus_id 排序后的第二个）
+		statsChip("node-1", 0, "0000:C2:00.0", 90, 30000, 32000, 300),
+		statsChip("node-1", 0, "0000:C1:00.0", 0, 0, 32000, 80),
+		// 卡 1 进程未记录 chip_id，整卡占用
+		statsChip("node-1", 1, "0000:C3:00.0", 50, 16000, 32000, 200),
+		// 卡 2 空闲
+		statsChip("node-1", 2, "0000:C4:00.0", 0, 0, 32000, 60),
+	}
+	chip1 := 1
+	processes := []repository.RunningNPUProcess{
+		{NodeID: "node-1", NPUID: 0, ChipID: &chip1, PID: 100},
+		{NodeID: "node-1", NPUID: 1, PID: 101},
+		// 没有指标的卡不计入
+		{NodeID: "node-9", NPUID: 0, PID: 102},
+	}
+
+	stats := summarizeNPUUsage(chips, processes)
+	assert.Equal(t, 3, stats.TotalCards)
+	assert.Equal(t, 2, stats.UsedCards)
+	assert.Equal(t, 1, stats.IdleCards)
+	assert.Equal(t, 4, stats.TotalChips)
+	assert.Equal(t, 2, stats.UsedChips)
+	assert.Equal(t, 2, stats.IdleChips)
+	assert.Equal(t, 4, stats.HealthyChips)
+	assert.Equal(t, 35.0, *stats.AvgAICoreUsage)
+	assert.Equal(t, 35.94, *stats.AvgHBMUsage)
+	assert.Equal(t, 640.0, stats.TotalPowerW)
+	assert.Equal(t, 160.0, *stats.AvgPowerW)
+	assert.Nil(t, stats.AvgTempC)
+
+	empty := summarizeNPUUsage(nil, nil)
+	assert.Zero(t, empty.TotalCards)
+	assert.Nil(t, empty.AvgAICoreUsage)
+	assert.Nil(t, empty.AvgHBMUsage)
+}
+
+func TestStatsService_GetClusterStats(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	counts := stubJobGroupCounter{
+		{Status: "running", JobType: "training", Framework: "pytorch", Count: 3},
+		{Status: "running", JobType: "inference", Framework: "vllm", Count: 2},
+		{Status: "completed", JobType: "training", Framework: "pytorch", Count: 5},
+		{Status: "lost", JobType: "unknown", Framework: "unknown", Count: 1},
+	}
+	svc := NewStatsService(mockNodeRepo, mockMetricsRepo, counts, 10*time.Minute)
+	now := time.Unix(1770373800, 0)
+	svc.now = func() time.Time { return now }
+
+	active, offline := "active", "inactive"
+	mockNodeRepo.On("FindAll").Return([]model.Node{
+		{NodeID: "node-1", Status: &active},
+		{NodeID: "node-2", Status: &offline},
+	}, nil)
+	mockMetricsRepo.On("FindLatestNPUMetricsSince", now.Add(-10*time.Minute)).Return([]model.NPUMetric{
+		statsChip("node-1", 0, "0000:C1:00.0", 80, 16000, 32000, 250),
+		statsChip("node-1", 1, "0000:C2:00.0", 0, 0, 32000, 70),
+	}, nil)
+	mockMetricsRepo.On("FindRunningNPUProcesses", repository.ProjectFilter{}).Return([]repository.RunningNPUProcess{
+		{NodeID: "node-1", NPUID: 0, PID: 100},
+	}, nil)
+
+	stats, err := svc.GetClusterStats(ProjectFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.TotalNodes)
+	assert.Equal(t, 1, stats.ActiveNodes)
+	assert.Equal(t, 1, stats.InactiveNodes)
+	assert.Equal(t, 1, stats.ReportingNodes)
+	assert.Equal(t, 2, stats.TotalCards)
+	assert.Equal(t, 1, stats.UsedCards)
+	assert.Equal(t, int64(11), stats.TotalJobs)
+	assert.Equal(t, int64(5), stats.RunningJobs)
+	assert.Equal(t, int64(5), stats.CompletedJobs)
+	assert.Equal(t, int64(1), stats.FailedJobs)
+	assert.Equal(t, map[string]int64{"training": 3, "inference": 2}, stats.JobTypeDistribution)
+	assert.Equal(t, map[string]int64{"pytorch": 3, "vllm": 2}, stats.FrameworkDistribution)
+}
+
+type scopedJobGroupCounter map[bool][]JobGroupCount
+
+func (c scopedJobGroupCounter) GetJobGroupCounts(scope ProjectFilter) ([]JobGroupCount, error) {
+	return c[scope.IsEmpty()], nil
+}
+
+func TestStatsService_GetClusterStats_ProjectScope(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	counts := scopedJobGroupCounter{
+		true:  {{Status: "running", JobType: "training", Framework: "pytorch", Count: 3}},
+		false: {{Status: "running", JobType: "training", Framework: "pytorch", Count: 1}},
+	}
+	svc := NewStatsService(mockNodeRepo, mockMetricsRepo, counts, 10*time.Minute)
+	scope := ProjectFilter{ProjectIDs: []uint{5}}
+
+	active := "active"
+	mockNodeRepo.On("FindAll").Return([]model.Node{
+		{NodeID: "node-1", Status: &active},
+		{NodeID: "node-2", Status: &active},
+	}, nil)
+	mockMetricsRepo.On("FindLatestNPUMetricsSince", mock.Anything).Return([]model.NPUMetric{
+		statsChip("node-1", 0, "0000:C1:00.0", 80, 16000, 32000, 250),
+		statsChip("node-1", 1, "0000:C2:00.0", 0, 0, 32000, 70),
+		statsChip("node-2", 0, "0000:C1:00.0", 60, 8000, 32000, 200),
+	}, nil)
+	// 范围内只有 node-1 卡 0 上的作业进程
+	mockMetricsRepo.On("FindRunningNPUProcesses", scope).Return([]repository.RunningNPUProcess{
+		{NodeID: "node-1", NPUID: 0, PID: 100},
+	}, nil)
+
+	stats, err := svc.GetClusterStats(scope)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalNodes)
+	assert.Equal(t, 1, stats.ReportingNodes)
+	assert.Equal(t, 1, stats.TotalCards)
+	assert.Equal(t, 1, stats.UsedCards)
+	assert.Equal(t, 250.0, stats.TotalPowerW)
+	assert.Equal(t, int64(1), stats.RunningJobs)
+	mockMetricsRepo.AssertExpectations(t)
+}
+
+func TestStatsService_GetNodeStats(t *testing.T) {
+	mockNodeRepo := new(MockNodeRepository)
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewStatsService(mockNodeRepo, mockMetricsRepo, stubJobGroupCounter{}, 10*time.Minute)
+
+	hostname, cards := "npu-01", 8
+	mockNodeRepo.On("FindAll").Return([]model.Node{{NodeID: "node-2", Hostname: &hostname, NPUCount: &cards}}, nil)
+	mockMetricsRepo.On("FindLatestNPUMetricsSince", mock.Anything).Return([]model.NPUMetric{
+		statsChip("node-2", 0, "0000:C1:00.0", 80, 16000, 32000, 250),
+		statsChip("node-1", 0, "0000:C1:00.0", 10, 1000, 32000, 90),
+	}, nil)
+	pgid := int64(90)
+	mockMetricsRepo.On("FindRunningNPUProcesses", repository.ProjectFilter{}).Return([]repository.RunningNPUProcess{
+		{NodeID: "node-2", NPUID: 0, PID: 100, PGID: &pgid},
+		{NodeID: "node-2", NPUID: 0, PID: 101, PGID: &pgid},
+		{NodeID: "node-2", NPUID: 0, PID: 300},
+	}, nil)
+
+	nodes, err := svc.GetNodeStats(ProjectFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, nodes, 2) {
+		// 未登记但上报了指标的节点也会返回
+		assert.Equal(t, "node-1", nodes[0].NodeID)
+		assert.Equal(t, 0, nodes[0].UsedCards)
+		assert.Equal(t, "node-2", nodes[1].NodeID)
+		assert.Equal(t, "npu-01", nodes[1].Hostname)
+		assert.Equal(t, 8, *nodes[1].NPUCount)
+		assert.Equal(t, 1, nodes[1].UsedCards)
+		assert.Equal(t, 2, nodes[1].RunningJobs)
+		assert.NotNil(t, nodes[1].LastReportAt)
+	}
+}
+
+func TestStatsService_GetTrend_Metrics(t *testing.T) {
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewStatsService(new(MockNodeRepository), mockMetricsRepo, stubJobGroupCounter{}, 10*time.Minute)
+
+	from := time.Date(2026, 3, 1, 0, 20, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	aligned := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	power := 200.0
+	mockMetricsRepo.On("AggregateNPUMetrics", aligned, to, int64(3600), repository.ProjectFilter{}).Return([]repository.NPUMetricBucket{
+		{Bucket: 0, AvgPowerW: &power, Chips: 16, Nodes: 2},
+		{Bucket: 2, Chips: 0},
+	}, nil)
+
+	trend, err := svc.GetTrend(TrendPower, from, to, "1h", ProjectFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, aligned, trend.StartTime)
+	if assert.Len(t, trend.DataPoints, 3) {
+		assert.Equal(t, 3200.0, *trend.DataPoints[0].Value)
+		assert.Nil(t, trend.DataPoints[1].Value)
+		assert.Nil(t, trend.DataPoints[2].Value)
+		assert.Equal(t, aligned.Add(2*time.Hour), trend.DataPoints[2].Timestamp)
+	}
+}
+
+func TestStatsService_GetTrend_UsedCards(t *testing.T) {
+	mockMetricsRepo := new(MockMetricsRepository)
+	svc := NewStatsService(new(MockNodeRepository), mockMetricsRepo, stubJobGroupCounter{}, 10*time.Minute)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(3 * time.Hour)
+	svc.now = func() time.Time { return to.Add(time.Hour) }
+
+	ms := func(t time.Time) *int64 { v := t.UnixMilli(); return &v }
+	running := "running"
+	pgid := int64(7)
+	mockMetricsRepo.On("FindNPUProcessSpans", from.UnixMilli(), to.UnixMilli(), repository.ProjectFilter{}).Return([]repository.NPUProcessSpan{
+		// 同一作业占用两张卡，跨第 0、1 个桶
+		{NodeID: "node-1", NPUID: 0, PGID: &pgid, StartTime: ms(from.Add(-time.Hour)), EndTime: ms(from.Add(90 * time.Minute))},
+		{NodeID: "node-1", NPUID: 1, PGID: &pgid, StartTime: ms(from.Add(-time.Hour)), EndTime: ms(from.Add(90 * time.Minute))},
+		// 仍在运行，从第 2 个桶开始；两个进程都没有 pgid，各算一个作业
+		{NodeID: "node-2", NPUID: 0, PID: 300, StartTime: ms(from.Add(2 * time.Hour)), Status: &running},
+		{NodeID: "node-2", NPUID: 1, PID: 301, StartTime: ms(from.Add(2 * time.Hour)), Status: &running},
+	}, nil)
+
+	trend, err := svc.GetTrend(TrendUsedCards, from, to, "1h", ProjectFilter{})
+	assert.NoError(t, err)
+	values := make([]float64, 0, len(trend.DataPoints))
+	for _, p := range trend.DataPoints {
+		values = append(values, *p.Value)
+	}
+	assert.Equal(t, []float64{2, 2, 2}, values)
+
+	trend, err = svc.GetTrend(TrendJobCount, from, to, "1h", ProjectFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, *trend.DataPoints[0].Value)
+	assert.Equal(t, 2.0, *trend.DataPoints[2].Value)
+}
+
+func TestStatsService_GetTrend_Invalid(t *testing.T) {
+	svc := NewStatsService(new(MockNodeRepository), new(MockMetricsRepository), stubJobGroupCounter{}, 10*time.Minute)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := svc.GetTrend("gpu_usage", from, from.Add(time.Hour), "", ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidTrendQuery)
+	_, err = svc.GetTrend(TrendNPUUsage, from, from, "", ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidTrendQuery)
+	_, err = svc.GetTrend(TrendNPUUsage, from, from.Add(time.Hour), "2h", ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidTrendQuery)
+	_, err = svc.GetTrend(TrendNPUUsage, from, from.AddDate(0, 0, 7), "1m", ProjectFilter{})
+	assert.ErrorIs(t, err, ErrInvalidTrendQuery)
+}
+
+func TestAutoTrendInterval(t *testing.T) {
+	assert.Equal(t, "5m", autoTrendInterval(24*time.Hour))
+	assert.Equal(t, "1m", autoTrendInterval(time.Hour))
+	assert.Equal(t, "1h", autoTrendInterval(7*24*time.Hour))
+	assert.Equal(t, "1d", autoTrendInterval(2*365*24*time.Hour))
+}
@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// SanitizeCSVCell 以 = + - @ 或制表符、回车开头的单元格前加单引号，防止在 Excel 中被当作公式执行（CSV 注入）
+func SanitizeCSVCell(v string) string {
+	if v == "" {
+		return ""
+	}
+	if strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeCSVCell(t *testing.T) {
+	cases := map[string]string{
+		"":               "",
+		"job-1":          "job-1",
+		"a=b":            "a=b",
+		"=SUM(A1:A2)":    "'=SUM(A1:A2)",
+		"+1":             "'+1",
+		"-1":             "'-1",
+		"@cmd":           "'@cmd",
+		"\t=1+1":         "'\t=1+1",
+		"\r=1+1":         "'\r=1+1",
+		"train.py\t--lr": "train.py\t--lr",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, SanitizeCSVCell(in), "input %q", in)
+	}
+}
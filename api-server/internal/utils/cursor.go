@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor 游标格式错误（被篡改或来自其他接口）
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor 将游标位置编码为不透明字符串（base64url 编码的 JSON），客户端只需原样回传
+func EncodeCursor(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解码 EncodeCursor 生成的游标
+func DecodeCursor(token string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
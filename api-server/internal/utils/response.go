@@ -30,6 +30,7 @@ func ErrorResponse(c *gin.Context, code int, message string) {
 type PaginationResponse struct {
 	Items      interface{} `json:"items"`
 	Pagination Pagination  `json:"pagination"`
+	NextCursor string      `json:"nextCursor,omitempty"` // 游标分页时的下一页游标，最后一页为空
 }
 
 // Pagination 分页信息
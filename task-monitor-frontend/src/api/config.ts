@@ -1,12 +1,17 @@
 import apiClient from './client';
 
+export type LLMProvider = 'openai' | 'anthropic' | 'ollama';
+
 export interface LLMModelConfig {
   id: string;
   name: string;
+  provider?: LLMProvider;
   endpoint: string;
   api_key: string;
   model: string;
   timeout: number;
+  max_tokens?: number;
+  disable_json_mode?: boolean;
   enabled: boolean;
 }
 
@@ -21,6 +26,20 @@ export interface LLMConfig {
   models?: LLMModelConfig[];
 }
 
+export interface LLMModelTestResult {
+  modelId: string;
+  provider: string;
+  model: string;
+  success: boolean;
+  latencyMs: number;
+  httpStatus: number;
+  modelExists: boolean | null;
+  modelListNote: string;
+  jsonParseable: boolean;
+  response?: string;
+  error?: string;
+}
+
 export const configApi = {
   getLLMConfig: async (): Promise<LLMConfig> => {
     return apiClient.get('/config/llm');
@@ -29,4 +48,8 @@ export const configApi = {
   updateLLMConfig: async (data: Partial<LLMConfig>): Promise<LLMConfig> => {
     return apiClient.put('/config/llm', data);
   },
+
+  testLLMModel: async (id: string, data?: Partial<LLMModelConfig>): Promise<LLMModelTestResult> => {
+    return apiClient.post(`/config/llm/models/${encodeURIComponent(id)}/test`, data);
+  },
 };
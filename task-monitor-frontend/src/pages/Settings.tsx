@@ -16,7 +16,7 @@ import {
   Card,
   Typography,
 } from "antd";
-import { PlusOutlined, DeleteOutlined } from "@ant-design/icons";
+import { PlusOutlined, DeleteOutlined, ApiOutlined } from "@ant-design/icons";
 import { configApi, type LLMConfig, type LLMModelConfig } from "@/api/config";
 import { authApi, type User } from "@/api/auth";
 import { useAuthStore } from "@/stores/useAuthStore";
@@ -25,6 +25,7 @@ function buildDefaultModel(seed: number): LLMModelConfig {
   return {
     id: `model-${seed}`,
     name: "",
+    provider: "openai",
     endpoint: "",
     api_key: "",
     model: "",
@@ -74,6 +75,7 @@ const Settings: React.FC = () => {
   const [form] = Form.useForm();
   const [loading, setLoading] = useState(true);
   const [saving, setSaving] = useState(false);
+  const [testingIndex, setTestingIndex] = useState<number | null>(null);
 
   // 用户管理状态
   const [users, setUsers] = useState<User[]>([]);
@@ -158,6 +160,38 @@ const Settings: React.FC = () => {
     }
   };
 
+  const handleTestModel = async (index: number) => {
+    const model = form.getFieldValue(["models", index]) as LLMModelConfig | undefined;
+    const id = model?.id?.trim();
+    if (!model || !id) {
+      message.error("模型ID不能为空");
+      return;
+    }
+    try {
+      setTestingIndex(index);
+      const result = await configApi.testLLMModel(id, model);
+      const details = [
+        `延迟 ${result.latencyMs}ms`,
+        `HTTP ${result.httpStatus || "-"}`,
+        result.modelExists === null
+          ? `模型列表不可用${result.modelListNote ? `（${result.modelListNote}）` : ""}`
+          : result.modelExists
+            ? "模型存在"
+            : "模型不存在",
+        result.jsonParseable ? "JSON可解析" : "JSON不可解析",
+      ].join("，");
+      if (result.success) {
+        message.success(`连接成功：${details}`);
+      } else {
+        message.error(`连接失败：${result.error ? result.error + "；" : ""}${details}`);
+      }
+    } catch (err: any) {
+      message.error("测试失败: " + (err.message || "未知错误"));
+    } finally {
+      setTestingIndex(null);
+    }
+  };
+
   const loadUsers = async () => {
     try {
       setUsersLoading(true);
@@ -276,16 +310,26 @@ const Settings: React.FC = () => {
                             size="small"
                             title={`模型 ${index + 1}`}
                             extra={
-                              fields.length > 1 ? (
+                              <Space size={8}>
                                 <Button
-                                  danger
                                   size="small"
-                                  icon={<DeleteOutlined />}
-                                  onClick={() => remove(field.name)}
+                                  icon={<ApiOutlined />}
+                                  loading={testingIndex === field.name}
+                                  onClick={() => handleTestModel(field.name)}
                                 >
-                                  删除
+                                  测试连接
                                 </Button>
-                              ) : null
+                                {fields.length > 1 ? (
+                                  <Button
+                                    danger
+                                    size="small"
+                                    icon={<DeleteOutlined />}
+                                    onClick={() => remove(field.name)}
+                                  >
+                                    删除
+                                  </Button>
+                                ) : null}
+                              </Space>
                             }
                           >
                             <Space direction="vertical" style={{ width: "100%" }} size={8}>
@@ -320,6 +364,20 @@ const Settings: React.FC = () => {
                               </Space>
 
                               <Space style={{ width: "100%" }} size={12} wrap>
+                                <Form.Item
+                                  {...field}
+                                  name={[field.name, "provider"]}
+                                  label="协议"
+                                  style={{ minWidth: 200, marginBottom: 8 }}
+                                >
+                                  <Select
+                                    options={[
+                                      { value: "openai", label: "OpenAI 兼容" },
+                                      { value: "anthropic", label: "Anthropic" },
+                                      { value: "ollama", label: "Ollama" },
+                                    ]}
+                                  />
+                                </Form.Item>
                                 <Form.Item
                                   {...field}
                                   name={[field.name, "endpoint"]}
@@ -358,6 +416,25 @@ const Settings: React.FC = () => {
                                 >
                                   <InputNumber min={1} style={{ width: "100%" }} />
                                 </Form.Item>
+                                <Form.Item
+                                  {...field}
+                                  name={[field.name, "max_tokens"]}
+                                  label="输出上限"
+                                  tooltip="max_tokens，留空使用默认值（Anthropic 缺省 4096）"
+                                  style={{ minWidth: 160, marginBottom: 8 }}
+                                >
+                                  <InputNumber min={0} style={{ width: "100%" }} />
+                                </Form.Item>
+                                <Form.Item
+                                  {...field}
+                                  name={[field.name, "disable_json_mode"]}
+                                  label="关闭JSON模式"
+                                  tooltip="服务端不支持 response_format / format=json 时开启"
+                                  valuePropName="checked"
+                                  style={{ marginBottom: 8 }}
+                                >
+                                  <Switch />
+                                </Form.Item>
                               </Space>
                             </Space>
                           </Card>